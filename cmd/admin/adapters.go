@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/pricing"
+	"github.com/richxcame/ride-hailing/internal/ridehistory"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/richxcame/ride-hailing/pkg/storage"
@@ -54,3 +56,38 @@ func (s *stubDriverService) GetDriverByUserID(_ context.Context, userID uuid.UUI
 		zap.String("user_id", userID.String()))
 	return nil, fmt.Errorf("driver service not configured for admin")
 }
+
+// ---- Ride sample source (replays completed rides in pricing simulations) ----
+
+type rideHistorySampleSource struct {
+	repo *ridehistory.Repository
+}
+
+func (s *rideHistorySampleSource) SampleCompletedRides(ctx context.Context, from, to time.Time, limit int) ([]pricing.SimulationRide, error) {
+	entries, err := s.repo.SampleCompletedRides(ctx, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	rides := make([]pricing.SimulationRide, 0, len(entries))
+	for _, e := range entries {
+		charged := e.EstimatedFare
+		if e.FinalFare != nil {
+			charged = *e.FinalFare
+		}
+		rides = append(rides, pricing.SimulationRide{
+			RideID:           e.ID,
+			RideTypeID:       e.RideTypeID,
+			PickupLatitude:   e.PickupLatitude,
+			PickupLongitude:  e.PickupLongitude,
+			DropoffLatitude:  e.DropoffLatitude,
+			DropoffLongitude: e.DropoffLongitude,
+			DistanceKm:       e.Distance,
+			DurationMin:      e.Duration,
+			Currency:         e.Currency,
+			RequestedAt:      e.RequestedAt,
+			ChargedFare:      charged,
+		})
+	}
+	return rides, nil
+}
@@ -22,9 +22,11 @@ import (
 	"github.com/richxcame/ride-hailing/internal/earnings"
 	"github.com/richxcame/ride-hailing/internal/fraud"
 	"github.com/richxcame/ride-hailing/internal/geography"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/internal/payments"
 	"github.com/richxcame/ride-hailing/internal/pricing"
 	"github.com/richxcame/ride-hailing/internal/promos"
+	"github.com/richxcame/ride-hailing/internal/ridehistory"
 	"github.com/richxcame/ride-hailing/internal/ridetypes"
 	"github.com/richxcame/ride-hailing/internal/support"
 	"github.com/richxcame/ride-hailing/internal/vehicle"
@@ -32,6 +34,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
@@ -167,6 +170,27 @@ func main() {
 	service := admin.NewService(repo, redisClient, fraudSvc)
 	handler := admin.NewHandler(service)
 
+	// Connect to NATS for dead-letter queue inspection and replay
+	if cfg.NATS.Enabled {
+		bus, err := eventbus.New(eventbus.Config{
+			URL:        cfg.NATS.URL,
+			Name:       "admin-service",
+			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
+		})
+		if err != nil {
+			logger.Warn("Failed to connect to NATS - dead-letter tooling disabled", zap.Error(err))
+		} else {
+			defer bus.Close()
+			service.SetDeadLetterQueue(bus)
+			logger.Info("NATS event bus connected for dead-letter tooling")
+		}
+	}
+
+	// Ledger reporting (trial balance, account balances, journal entries)
+	ledgerSvc := ledger.NewService(ledger.NewRepository(db))
+	service.SetLedger(ledgerSvc)
+
 	// Initialize geography admin
 	geoRepo := geography.NewRepository(db)
 	geoSvc := geography.NewService(geoRepo)
@@ -175,6 +199,7 @@ func main() {
 	// Initialize pricing admin
 	pricingRepo := pricing.NewRepository(db)
 	pricingSvc := pricing.NewService(pricingRepo, geoSvc, nil)
+	pricingSvc.SetRideSampleSource(&rideHistorySampleSource{repo: ridehistory.NewRepository(db)})
 	pricingAdminHandler := pricing.NewAdminHandler(pricingRepo, pricingSvc)
 
 	// Initialize ride types admin
@@ -199,7 +224,7 @@ func main() {
 
 	// Initialize cancellation
 	cancellationRepo := cancellation.NewRepository(db)
-	cancellationSvc := cancellation.NewService(cancellationRepo, db)
+	cancellationSvc := cancellation.NewService(cancellationRepo, db, nil) // admin only waives fees and reads stats
 	cancellationHandler := cancellation.NewHandler(cancellationSvc)
 
 	// Initialize analytics
@@ -215,6 +240,7 @@ func main() {
 	// Initialize payments (nil stripe client — admin endpoints use repo directly)
 	paymentsRepo := payments.NewRepository(db)
 	paymentsSvc := payments.NewService(paymentsRepo, nil, nil)
+	paymentsSvc.SetLedger(ledgerSvc)
 	paymentsHandler := payments.NewHandler(paymentsSvc)
 
 	// Initialize documents (stub storage + stub driver service — admin only reviews documents)
@@ -332,6 +358,23 @@ func main() {
 		// Audit logs
 		api.GET("/audit-logs", handler.GetAuditLogs)
 
+		// Event bus dead-letter queue
+		deadLetters := api.Group("/dead-letters")
+		{
+			deadLetters.GET("", handler.GetDeadLetters)
+			deadLetters.GET("/:seq", handler.GetDeadLetter)
+			deadLetters.POST("/:seq/replay", handler.ReplayDeadLetter)
+			deadLetters.DELETE("/:seq", handler.DiscardDeadLetter)
+		}
+
+		// Double-entry ledger reporting
+		ledgerRoutes := api.Group("/ledger")
+		{
+			ledgerRoutes.GET("/trial-balance", handler.GetTrialBalance)
+			ledgerRoutes.GET("/accounts", handler.GetLedgerAccountBalances)
+			ledgerRoutes.GET("/entries", handler.GetLedgerEntries)
+		}
+
 		// Geography management (countries, regions, cities, pricing zones)
 		geoAdminHandler.RegisterRoutes(api)
 
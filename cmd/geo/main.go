@@ -15,6 +15,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
@@ -137,6 +138,23 @@ func main() {
 	service.SetETATracker(etaTracker)
 	logger.Info("Real-time ETA tracking enabled")
 
+	// Publish driver location events for consumers such as trip anomaly detection
+	if cfg.NATS.Enabled && cfg.NATS.URL != "" {
+		bus, err := eventbus.New(eventbus.Config{
+			URL:        cfg.NATS.URL,
+			Name:       serviceName,
+			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
+		})
+		if err != nil {
+			logger.Warn("Failed to connect to NATS - driver location events disabled", zap.Error(err))
+		} else {
+			defer bus.Close()
+			service.SetEventBus(bus)
+			logger.Info("Driver location events enabled")
+		}
+	}
+
 	handler := geo.NewHandler(service, geocodingSvc)
 
 	jwtProvider, err := jwtkeys.NewManagerFromConfig(rootCtx, cfg.JWT, true)
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/experiments"
 	"github.com/richxcame/ride-hailing/internal/onboarding"
 	"github.com/richxcame/ride-hailing/internal/pool"
 	"github.com/richxcame/ride-hailing/internal/ridetypes"
@@ -196,3 +197,23 @@ func (a *rideTypesServiceAdapter) GetAvailableRideTypes(ctx interface{}, latitud
 
 	return result, nil
 }
+
+// ---- Pricing A/B test assigner (experiments-backed) ----
+
+// pricingExperimentAssigner buckets riders into an A/B test pricing version
+// through a running experiment keyed "pricing_version_<version id>". Riders in
+// a non-control variant see the test version.
+type pricingExperimentAssigner struct {
+	experiments *experiments.Service
+}
+
+func (a *pricingExperimentAssigner) AssignABTest(ctx context.Context, versionID, riderID uuid.UUID) (bool, bool) {
+	variant, err := a.experiments.GetVariantForUser(ctx, "pricing_version_"+versionID.String(), &experiments.UserContext{
+		UserID: riderID,
+		Role:   string(models.RoleRider),
+	})
+	if err != nil || variant == nil {
+		return false, false
+	}
+	return !variant.IsControl, true
+}
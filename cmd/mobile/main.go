@@ -12,12 +12,14 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/richxcame/ride-hailing/internal/cancellation"
 	"github.com/richxcame/ride-hailing/internal/chat"
 	"github.com/richxcame/ride-hailing/internal/corporate"
 	"github.com/richxcame/ride-hailing/internal/currency"
+	"github.com/richxcame/ride-hailing/internal/discounts"
 	"github.com/richxcame/ride-hailing/internal/delivery"
 	"github.com/richxcame/ride-hailing/internal/demandforecast"
 	"github.com/richxcame/ride-hailing/internal/disputes"
@@ -30,14 +32,18 @@ import (
 	"github.com/richxcame/ride-hailing/internal/gamification"
 	"github.com/richxcame/ride-hailing/internal/geography"
 	"github.com/richxcame/ride-hailing/internal/giftcards"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/internal/loyalty"
+	"github.com/richxcame/ride-hailing/internal/maps"
 	"github.com/richxcame/ride-hailing/internal/negotiation"
 	"github.com/richxcame/ride-hailing/internal/onboarding"
 	"github.com/richxcame/ride-hailing/internal/paymentmethods"
+	"github.com/richxcame/ride-hailing/internal/payments"
 	"github.com/richxcame/ride-hailing/internal/paymentsplit"
 	"github.com/richxcame/ride-hailing/internal/pool"
 	"github.com/richxcame/ride-hailing/internal/preferences"
 	"github.com/richxcame/ride-hailing/internal/pricing"
+	"github.com/richxcame/ride-hailing/internal/promos"
 	"github.com/richxcame/ride-hailing/internal/ratings"
 	"github.com/richxcame/ride-hailing/internal/recording"
 	"github.com/richxcame/ride-hailing/internal/ridehistory"
@@ -53,6 +59,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
@@ -199,8 +206,17 @@ func main() {
 	promosServiceURL := getEnv("PROMOS_SERVICE_URL", "http://localhost:8089")
 	logger.Info("Promos service URL configured", zap.String("url", promosServiceURL))
 
-	// Initialize WebSocket hub
+	// Initialize WebSocket hub. Without Redis it only reaches sockets on this
+	// node.
 	wsHub := ws.NewHub()
+	if redisClient != nil {
+		if err := wsHub.SetBackplane(rootCtx, ws.NewRedisBackplane(redisClient.Client)); err != nil {
+			logger.Warn("Failed to subscribe to WebSocket backplane - broadcasts limited to this node", zap.Error(err))
+		} else {
+			wsHub.SetPresence(ws.NewRedisPresence(redisClient.Client))
+			wsHub.SetReplayStore(ws.NewRedisReplayStore(redisClient.Client))
+		}
+	}
 	go wsHub.Run()
 
 	// Initialize repositories
@@ -240,16 +256,22 @@ func main() {
 	safetyRepo := safety.NewRepository(db)
 	rideTypesRepo := ridetypes.NewRepository(db)
 	documentsRepo := documents.NewRepository(db)
+	promosRepo := promos.NewRepository(db)
 
 	// Initialize services
 	ridesService := rides.NewService(ridesRepo, promosServiceURL, nil) // CircuitBreaker is nil-safe
 	favoritesService := favorites.NewService(favoritesRepo)
-	cancellationService := cancellation.NewService(cancellationRepo, db)
+	cancellationService := cancellation.NewService(cancellationRepo, db, ridesService)
 	supportService := support.NewService(supportRepo)
 	disputesService := disputes.NewService(disputesRepo)
 	tipsService := tips.NewService(tipsRepo)
 	ratingsService := ratings.NewService(ratingsRepo)
 	earningsService := earnings.NewService(earningsRepo)
+	// Instant payouts go straight to the driver's Stripe Connect account
+	if cfg.Payments.StripeAPIKey != "" {
+		earningsService.SetPayoutProvider(payments.NewStripePayoutProvider(payments.NewResilientStripeClient(cfg.Payments.StripeAPIKey, nil)))
+		earningsService.SetLedger(ledger.NewService(ledger.NewRepository(db)))
+	}
 	vehicleService := vehicle.NewService(vehicleRepo)
 	paymentmethodsService := paymentmethods.NewService(paymentmethodsRepo)
 	ridehistoryService := ridehistory.NewService(ridehistoryRepo)
@@ -262,6 +284,7 @@ func main() {
 	corporateService := corporate.NewService(corporateRepo)
 	twofaService := twofa.NewService(twofaRepo, &stubSMSSender{}, nil, getEnv("APP_NAME", "RideHailing")) // Redis is nil-safe (OTP stored in DB)
 	loyaltyService := loyalty.NewService(loyaltyRepo)
+	promosService := promos.NewService(promosRepo)
 	poolService := pool.NewService(poolRepo, &stubMapsService{}, pool.DefaultServiceConfig())
 	deliveryService := delivery.NewService(deliveryRepo)
 	recordingService := recording.NewService(recordingRepo, &stubStorage{}, recording.Config{})
@@ -273,8 +296,24 @@ func main() {
 	paymentsplitService := paymentsplit.NewService(paymentsplitRepo, &stubPaymentService{}, &stubSplitNotificationService{})
 	geographyService := geography.NewService(geographyRepo)
 	currencyService := currency.NewService(currencyRepo, getEnv("BASE_CURRENCY", "USD"))
+	if err := currencyService.SyncMoneyExponents(context.Background()); err != nil {
+		logger.Warn("Failed to load currency exponents, using ISO 4217 defaults", zap.Error(err))
+	}
 	pricingService := pricing.NewService(pricingRepo, geographyService, currencyService)
+	pricingService.SetABTestAssigner(&pricingExperimentAssigner{experiments: experimentsService})
 	ridesService.SetPricingService(pricingService)
+	// Estimates carry fare quotes that ride requests redeem to lock the price.
+	// The key must differ from the JWT secret so quotes never pass as auth tokens.
+	if quoteSecret := getEnv("FARE_QUOTE_SECRET", ""); quoteSecret != "" {
+		pricingService.SetQuoteSigner(pricing.NewQuoteSigner(quoteSecret,
+			time.Duration(getEnvAsInt("FARE_QUOTE_TTL_SECONDS", 300))*time.Second))
+	} else {
+		logger.Info("FARE_QUOTE_SECRET not set, estimates are issued without fare quotes")
+	}
+	ridesService.SetFareLockConfig(&rides.FareLockConfig{
+		MaxEndpointDriftMeters:  float64(getEnvAsInt("FARE_QUOTE_MAX_ENDPOINT_DRIFT_METERS", 200)),
+		MaxDistanceDeviationPct: float64(getEnvAsInt("FARE_QUOTE_MAX_DISTANCE_DEVIATION_PCT", 50)),
+	})
 	// Wire geography as the location resolver for rides.
 	// Adapter converts *geography.ResolvedLocation → *rides.LocationContext
 	// without creating an import cycle between the two packages.
@@ -303,16 +342,113 @@ func main() {
 		return lc, nil
 	}))
 	rideTypesService := ridetypes.NewService(rideTypesRepo, geographyService)
+	ridesService.SetRideTypeNameFetcher(func(ctx context.Context, id uuid.UUID) (string, error) {
+		rt, err := rideTypesService.GetRideTypeByID(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		return rt.Name, nil
+	})
+
+	// Promo, subscription, loyalty and gift card reductions are settled
+	// together when a ride completes, in the configured order
+	discountConfig, err := discounts.ParseConfig(
+		getEnv("DISCOUNT_PRECEDENCE", "subscription,promo,loyalty,gift_card"),
+		getEnv("DISCOUNT_EXCLUSIVE_SOURCES", ""),
+		float64(getEnvAsInt("DISCOUNT_MAX_PCT", 100)),
+	)
+	if err != nil {
+		logger.Fatal("Invalid discount configuration", zap.Error(err))
+	}
+	discountOrchestrator := discounts.NewOrchestrator(discountConfig)
+	discountOrchestrator.Register(discounts.SourcePromo, discounts.NewPromoApplier(promosService))
+	discountOrchestrator.Register(discounts.SourceSubscription, discounts.NewSubscriptionApplier(subscriptionsService))
+	discountOrchestrator.Register(discounts.SourceLoyalty, discounts.NewLoyaltyApplier(loyaltyService))
+	discountOrchestrator.Register(discounts.SourceGiftCard, discounts.NewGiftCardApplier(giftcardsService))
+	ridesService.SetDiscountOrchestrator(discountOrchestrator)
 	negotiationService := negotiation.NewService(negotiationRepo, pricingService, geographyService)
+	// Unacknowledged SOS alerts page the on-call safety queue, then go to the
+	// emergency service webhook; either step is skipped when its URL is unset
 	safetyService := safety.NewService(safetyRepo, safety.Config{
-		EmergencyNumber: getEnv("EMERGENCY_NUMBER", "112"),
+		NotificationURL:            getEnv("NOTIFICATIONS_SERVICE_URL", ""),
+		EmergencyNumber:            getEnv("EMERGENCY_NUMBER", "112"),
+		OnCallWebhookURL:           getEnv("SOS_ONCALL_WEBHOOK_URL", ""),
+		EmergencyServiceWebhookURL: getEnv("SOS_EMERGENCY_WEBHOOK_URL", ""),
+		EmergencyServiceAfter:      time.Duration(getEnvAsInt("SOS_EMERGENCY_AFTER_MINUTES", 5)) * time.Minute,
 	})
+	safetyService.SetRideLookup(ridesService)
+	escalationWorker := safety.NewEscalationWorker(safetyService, 10*time.Second)
+	go escalationWorker.Start(rootCtx)
+	defer escalationWorker.Stop()
+	// Trip anomaly detection compares driver locations with routes planned
+	// through the maps service; without a maps key route deviation isn't checked
+	var routePlanner safety.RoutePlanner
+	if mapsAPIKey := getEnv("GOOGLE_MAPS_API_KEY", ""); mapsAPIKey != "" {
+		mapsConfig := maps.DefaultConfig()
+		mapsConfig.Primary = maps.ProviderConfig{Provider: maps.ProviderGoogle, APIKey: mapsAPIKey}
+		var mapsCache redisclient.ClientInterface
+		if redisClient != nil {
+			mapsCache = redisClient
+		}
+		mapsService, err := maps.NewService(mapsConfig, mapsCache)
+		if err != nil {
+			logger.Warn("Failed to initialize maps service - route deviation checks disabled", zap.Error(err))
+		} else {
+			routePlanner = mapsService
+		}
+	}
 	documentsService := documents.NewService(documentsRepo, &stubStorage{}, documents.ServiceConfig{
 		MaxFileSizeMB:    10,
 		AllowedMimeTypes: []string{"image/jpeg", "image/png", "application/pdf"},
 		OCREnabled:       false,
 	})
 
+	// Initialize NATS event bus: ride lifecycle events are staged in the outbox,
+	// declined card holds cancel the ride, the driver_arrived / started events
+	// drive the pickup wait timer, and
+	// driver locations during rides are checked for safety anomalies. The
+	// outbox relay holds events until the bus is reachable, so a NATS outage
+	// at boot delays them rather than dropping them.
+	if cfg.NATS.Enabled && cfg.NATS.URL != "" {
+		busDialer := eventbus.NewDialer(eventbus.Config{
+			URL:        cfg.NATS.URL,
+			Name:       "mobile-service",
+			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
+		}, 5*time.Second)
+		defer busDialer.Close()
+
+		ridesService.EnableEventOutbox()
+		outboxRelay := rides.NewOutboxRelay(ridesRepo, busDialer, rides.DefaultOutboxRelayConfig())
+		go outboxRelay.Start(rootCtx)
+		defer outboxRelay.Stop()
+
+		safetyService.SetEventBus(busDialer)
+
+		busDialer.OnConnect(func(bus *eventbus.Bus) {
+			logger.Info("NATS event bus connected for ride events")
+
+			ridesEventHandler := rides.NewEventHandler(ridesService)
+			if err := ridesEventHandler.RegisterSubscriptions(rootCtx, bus); err != nil {
+				logger.Error("Failed to register ride payment event subscriptions", zap.Error(err))
+			}
+
+			waittimeEventHandler := waittime.NewEventHandler(waittimeService)
+			if err := waittimeEventHandler.RegisterSubscriptions(rootCtx, bus); err != nil {
+				logger.Error("Failed to register wait time event subscriptions", zap.Error(err))
+			}
+
+			anomalyDetector := safety.NewAnomalyDetector(safetyService, routePlanner, safety.DefaultAnomalyConfig())
+			if redisClient != nil {
+				anomalyDetector.SetRedis(redisClient.Client)
+			}
+			if err := anomalyDetector.RegisterSubscriptions(rootCtx, bus); err != nil {
+				logger.Error("Failed to register trip anomaly detection subscriptions", zap.Error(err))
+			}
+		})
+		go busDialer.Run(rootCtx)
+	}
+
 	// Initialize handlers
 	ridesHandler := rides.NewHandler(ridesService)
 	favoritesHandler := favorites.NewHandler(favoritesService)
@@ -468,6 +604,7 @@ func main() {
 	negotiationHandler.RegisterRoutes(apiGroup)
 	rideTypesHandler.RegisterRoutes(apiGroup)
 	safetyHandler.RegisterRoutes(apiGroup)
+	safetyHandler.RegisterAdminRoutes(router.Group("/api/v1"), middleware.AuthMiddlewareWithProvider(jwtProvider))
 	documentsHandler.RegisterRoutesOnGroup(apiGroup)
 
 	// Create HTTP server with timeouts
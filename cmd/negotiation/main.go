@@ -115,10 +115,19 @@ func main() {
 		logger.Info("Redis connected for negotiation state management")
 	}
 
-	// Initialize WebSocket hub
+	// Initialize WebSocket hub. Without Redis it only reaches sockets on this
+	// node.
 	wsHub := websocket.NewHub()
+	if redisClient != nil {
+		if err := wsHub.SetBackplane(rootCtx, websocket.NewRedisBackplane(redisClient.Client)); err != nil {
+			logger.Warn("Failed to subscribe to WebSocket backplane - broadcasts limited to this node", zap.Error(err))
+		} else {
+			wsHub.SetPresence(websocket.NewRedisPresence(redisClient.Client))
+			wsHub.SetReplayStore(websocket.NewRedisReplayStore(redisClient.Client))
+		}
+	}
 	go wsHub.Run()
-	log.Info("WebSocket hub started")
+	log.Info("WebSocket hub started", zap.String("node_id", wsHub.NodeID()))
 
 	// Initialize dependent services
 	geoRepo := geography.NewRepository(db)
@@ -142,6 +151,7 @@ func main() {
 			URL:        cfg.NATS.URL,
 			Name:       serviceName,
 			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
 		})
 		if err != nil {
 			logger.Warn("Failed to connect to NATS - event-driven features disabled", zap.Error(err))
@@ -165,6 +165,7 @@ func main() {
 	notificationRepo := notifications.NewRepository(db)
 	notificationService := notifications.NewServiceWithClients(notificationRepo, firebaseClient, twilioClient, emailClient)
 	notificationService.SetCircuitBreakers(firebaseBreaker, twilioBreaker, smtpBreaker)
+	notificationService.SetTemplateStore(notificationRepo)
 
 	// Initialize NATS event bus for receiving ride lifecycle events
 	if cfg.NATS.Enabled {
@@ -172,6 +173,7 @@ func main() {
 			URL:        cfg.NATS.URL,
 			Name:       serviceName,
 			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
 		})
 		if err != nil {
 			log.Warn("Failed to connect to NATS - event-driven notifications disabled", zap.Error(err))
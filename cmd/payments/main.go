@@ -11,6 +11,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/richxcame/ride-hailing/internal/currency"
+	"github.com/richxcame/ride-hailing/internal/disputes"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/internal/payments"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
@@ -127,11 +131,33 @@ func main() {
 		logger.Info("Redis connected for idempotency support")
 	}
 
+	// Minor-unit amounts follow the decimal places configured in the currencies table
+	if err := currency.LoadMoneyExponents(rootCtx, currency.NewRepository(db)); err != nil {
+		logger.Warn("Failed to load currency exponents, using ISO 4217 defaults", zap.Error(err))
+	}
+
 	// Initialize payment service
 	paymentRepo := payments.NewRepository(db)
 	stripeClient := payments.NewResilientStripeClient(stripeAPIKey, stripeBreaker)
 	paymentService := payments.NewService(paymentRepo, stripeClient, &cfg.Business)
-	paymentHandler := payments.NewHandler(paymentService)
+	ledgerService := ledger.NewService(ledger.NewRepository(db))
+	paymentService.SetLedger(ledgerService)
+	// Journal entries that failed to post are queued and posted from here
+	go ledgerService.RetryPending(rootCtx, ledger.DefaultRetryConfig())
+	paymentService.SetDisputes(disputes.NewService(disputes.NewRepository(db)))
+	paymentService.SetBankPayouts(earnings.NewService(earnings.NewRepository(db)))
+	paymentHandler := payments.NewHandlerWithWebhookSecret(paymentService,
+		cfg.Payments.StripeWebhookSecret, cfg.Payments.StripeConnectWebhookSecret)
+	if cfg.Payments.StripeWebhookSecret == "" {
+		// Unsigned webhooks are only ever accepted outside production, and only
+		// when explicitly asked for
+		if cfg.Payments.StripeWebhookAllowUnsigned && cfg.Server.Environment != "production" {
+			paymentHandler.SetAllowUnsignedWebhooks(true)
+			logger.Warn("STRIPE_WEBHOOK_SECRET is not set - accepting unsigned Stripe webhooks (STRIPE_WEBHOOK_ALLOW_UNSIGNED)")
+		} else {
+			logger.Warn("STRIPE_WEBHOOK_SECRET is not set - Stripe webhooks will be rejected")
+		}
+	}
 
 	// Initialize NATS event bus for driver payout on ride completion
 	if cfg.NATS.Enabled && cfg.NATS.URL != "" {
@@ -139,6 +165,7 @@ func main() {
 			URL:        cfg.NATS.URL,
 			Name:       serviceName,
 			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
 		})
 		if err != nil {
 			logger.Warn("Failed to connect to NATS - driver payouts via events disabled", zap.Error(err))
@@ -242,6 +242,11 @@ func main() {
 			admin.PATCH("/promo-codes/:id", handler.UpdatePromoCode)
 			admin.DELETE("/promo-codes/:id", handler.DeactivatePromoCode)
 			admin.GET("/promo-codes/:id/usage", handler.GetPromoCodeUsageStats)
+			admin.POST("/promo-campaigns", handler.CreatePromoCampaign)
+			admin.GET("/promo-campaigns", handler.GetAllPromoCampaigns)
+			admin.GET("/promo-campaigns/:id", handler.GetPromoCampaign)
+			admin.PATCH("/promo-campaigns/:id", handler.UpdatePromoCampaign)
+			admin.GET("/promo-campaigns/:id/usage", handler.GetPromoCampaignUsageStats)
 			admin.GET("/referral-codes", handler.GetAllReferralCodes)
 			admin.GET("/referrals/:id", handler.GetReferralDetails)
 		}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	stdlog "log"
 	"net/http"
 	"os"
@@ -23,6 +24,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/errors"
 	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/httpclient"
 	"github.com/richxcame/ride-hailing/pkg/jwtkeys"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/middleware"
@@ -82,6 +84,35 @@ func (a *geoServiceAdapter) CalculateDistance(latitude1, longitude1, latitude2,
 	return a.Service.CalculateDistance(latitude1, longitude1, latitude2, longitude2)
 }
 
+// mlETAEstimator adapts the ML ETA service to matching.ETAEstimator
+type mlETAEstimator struct {
+	client *httpclient.Client
+}
+
+func (e *mlETAEstimator) EstimatePickupETA(ctx context.Context, driver matching.DriverLocation, pickupLatitude, pickupLongitude float64) (float64, error) {
+	payload := map[string]interface{}{
+		"pickup_latitude":   driver.Latitude,
+		"pickup_longitude":  driver.Longitude,
+		"dropoff_latitude":  pickupLatitude,
+		"dropoff_longitude": pickupLongitude,
+		"traffic_level":     "medium",
+		"weather":           "clear",
+		"driver_id":         driver.DriverID.String(),
+	}
+	body, err := e.client.Post(ctx, "/api/v1/eta/predict", payload, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		EstimatedMinutes float64 `json:"estimated_minutes"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	return resp.EstimatedMinutes, nil
+}
+
 func main() {
 	// Set default port for realtime service if not set
 	if os.Getenv("PORT") == "" {
@@ -174,10 +205,16 @@ func main() {
 	}
 	logger.Info("Connected to Redis")
 
-	// Create WebSocket hub
+	// Create WebSocket hub, sharing broadcasts, presence and message streams
+	// with the other realtime nodes through Redis
 	hub := ws.NewHub()
+	if err := hub.SetBackplane(rootCtx, ws.NewRedisBackplane(redisClient.Client)); err != nil {
+		logger.Fatal("Failed to subscribe to WebSocket backplane", zap.Error(err))
+	}
+	hub.SetPresence(ws.NewRedisPresence(redisClient.Client))
+	hub.SetReplayStore(ws.NewRedisReplayStore(redisClient.Client))
 	go hub.Run()
-	logger.Info("WebSocket hub started")
+	logger.Info("WebSocket hub started", zap.String("node_id", hub.NodeID()))
 
 	// Connect to NATS event bus
 	var eventBus *eventbus.Bus
@@ -186,6 +223,7 @@ func main() {
 			URL:        cfg.NATS.URL,
 			Name:       "realtime-service",
 			StreamName: cfg.NATS.StreamName,
+			MaxDeliver: cfg.NATS.MaxDeliver,
 		}
 		eventBus, err = eventbus.New(eventBusCfg)
 		if err != nil {
@@ -196,6 +234,9 @@ func main() {
 
 	// Initialize services for matching
 	geoService := geo.NewService(redisClient)
+	if eventBus != nil {
+		geoService.SetEventBus(eventBus)
+	}
 	geoAdapter := &geoServiceAdapter{Service: geoService}
 
 	// Create stub rides repository (matching service doesn't use it heavily)
@@ -204,6 +245,13 @@ func main() {
 	// Create matching service and start it
 	if eventBus != nil {
 		matchingConfig := matching.DefaultMatchingConfig()
+		matchingConfig.BatchMatchingEnabled = cfg.Matching.BatchEnabled
+		if cfg.Matching.BatchWindowMS > 0 {
+			matchingConfig.BatchWindow = cfg.Matching.BatchWindow()
+		}
+		if cfg.Matching.BatchETATimeoutMS > 0 {
+			matchingConfig.BatchETATimeout = cfg.Matching.BatchETATimeout()
+		}
 		matchingSvc := matching.NewService(
 			geoAdapter,
 			stubRidesRepo,
@@ -212,6 +260,15 @@ func main() {
 			redisClient,
 			matchingConfig,
 		)
+		if mlEtaURL := os.Getenv("ML_ETA_SERVICE_URL"); mlEtaURL != "" {
+			matchingSvc.SetETAEstimator(&mlETAEstimator{client: httpclient.NewClient(mlEtaURL)})
+		}
+		matchingSvc.SetOfferStatsStore(matching.NewOfferStatsRepository(db))
+		matchingSvc.SetEligibilityChecker(matching.NewEligibilityRepository(db))
+		matchingSvc.SetDriverListStore(matching.NewDriverListRepository(db))
+		if matchingConfig.BatchMatchingEnabled {
+			logger.Info("Batch matching enabled", zap.Duration("window", matchingConfig.BatchWindow))
+		}
 
 		ctx := context.Background()
 		if err := matchingSvc.Start(ctx); err != nil {
@@ -227,6 +284,14 @@ func main() {
 	service := realtime.NewService(hub, db, redisClient, geoService, log)
 	handler := realtime.NewHandler(service, log)
 
+	// Relay ride stop progress from the rides service to connected clients
+	if eventBus != nil {
+		eventHandler := realtime.NewEventHandler(service)
+		if err := eventHandler.RegisterSubscriptions(context.Background(), eventBus); err != nil {
+			logger.Error("Failed to register realtime event subscriptions", zap.Error(err))
+		}
+	}
+
 	// Set up Gin router with proper middleware stack
 	if cfg.Server.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
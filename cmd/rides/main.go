@@ -215,20 +215,35 @@ func main() {
 		return rt.Name, nil
 	})
 
-	// Initialize NATS event bus for async ride lifecycle events
+	// Initialize NATS event bus for async ride lifecycle events. Lifecycle
+	// events are committed with the ride and relayed by the outbox worker,
+	// which holds them until the bus is reachable, so a NATS outage at boot
+	// delays events rather than dropping them.
 	if cfg.NATS.Enabled {
-		bus, err := eventbus.New(eventbus.Config{
+		busDialer := eventbus.NewDialer(eventbus.Config{
 			URL:        cfg.NATS.URL,
 			Name:       serviceName,
 			StreamName: cfg.NATS.StreamName,
-		})
-		if err != nil {
-			logger.Warn("Failed to connect to NATS - event bus disabled", zap.Error(err))
-		} else {
-			service.SetEventBus(bus)
-			defer bus.Close()
+			MaxDeliver: cfg.NATS.MaxDeliver,
+		}, 5*time.Second)
+		defer busDialer.Close()
+
+		service.EnableEventOutbox()
+		outboxRelay := rides.NewOutboxRelay(repo, busDialer, rides.DefaultOutboxRelayConfig())
+		go outboxRelay.Start(rootCtx)
+		defer outboxRelay.Stop()
+		logger.Info("Ride event outbox relay started")
+
+		busDialer.OnConnect(func(bus *eventbus.Bus) {
 			logger.Info("NATS event bus enabled", zap.String("url", cfg.NATS.URL))
-		}
+
+			// Rides whose card hold is declined are cancelled before matching
+			ridesEventHandler := rides.NewEventHandler(service)
+			if err := ridesEventHandler.RegisterSubscriptions(rootCtx, bus); err != nil {
+				logger.Error("Failed to register ride payment event subscriptions", zap.Error(err))
+			}
+		})
+		go busDialer.Run(rootCtx)
 	}
 
 	handler := rides.NewHandler(service)
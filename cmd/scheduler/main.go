@@ -11,6 +11,10 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/internal/payments"
+	"github.com/richxcame/ride-hailing/internal/pricing"
 	"github.com/richxcame/ride-hailing/internal/scheduler"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
@@ -103,6 +107,17 @@ func main() {
 
 	// Create scheduler worker
 	worker := scheduler.NewWorker(db, logger.Get(), notificationsServiceURL)
+	worker.SetPricingVersionScheduler(pricing.NewVersionScheduler(pricing.NewRepository(db)))
+
+	// Pay drivers out in scheduled batches through Stripe Connect
+	if cfg.Payments.StripeAPIKey != "" {
+		earningsService := earnings.NewService(earnings.NewRepository(db))
+		earningsService.SetPayoutProvider(payments.NewStripePayoutProvider(payments.NewResilientStripeClient(cfg.Payments.StripeAPIKey, nil)))
+		earningsService.SetLedger(ledger.NewService(ledger.NewRepository(db)))
+		worker.SetPayoutBatcher(earningsService)
+	} else {
+		logger.Warn("Stripe API key not configured, driver payout batches disabled")
+	}
 
 	// Start worker in background
 	ctx, cancel := context.WithCancel(context.Background())
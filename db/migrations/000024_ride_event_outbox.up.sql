@@ -0,0 +1,34 @@
+-- =============================================
+-- Migration 000024: Ride Event Outbox
+-- Ride lifecycle events are written in the same transaction as the
+-- ride state change and relayed to NATS by the rides service.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS ride_event_outbox (
+    seq BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    aggregate_id UUID NOT NULL,
+    subject VARCHAR(100) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at TIMESTAMPTZ
+);
+
+-- Relay scan: unpublished rows in commit order
+CREATE INDEX IF NOT EXISTS idx_ride_event_outbox_pending
+    ON ride_event_outbox (seq)
+    WHERE published_at IS NULL;
+
+-- Per-ride ordering check for rows that are backing off
+CREATE INDEX IF NOT EXISTS idx_ride_event_outbox_pending_aggregate
+    ON ride_event_outbox (aggregate_id, next_attempt_at)
+    WHERE published_at IS NULL;
+
+-- Retention cleanup of relayed rows
+CREATE INDEX IF NOT EXISTS idx_ride_event_outbox_published_at
+    ON ride_event_outbox (published_at)
+    WHERE published_at IS NOT NULL;
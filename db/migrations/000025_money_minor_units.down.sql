@@ -0,0 +1,24 @@
+ALTER TABLE driver_payouts DROP COLUMN IF EXISTS amount_minor;
+
+ALTER TABLE driver_earnings
+    DROP COLUMN IF EXISTS gross_amount_minor,
+    DROP COLUMN IF EXISTS commission_minor,
+    DROP COLUMN IF EXISTS net_amount_minor;
+
+ALTER TABLE wallet_transactions
+    DROP COLUMN IF EXISTS amount_minor,
+    DROP COLUMN IF EXISTS balance_before_minor,
+    DROP COLUMN IF EXISTS balance_after_minor;
+
+ALTER TABLE payment_methods DROP COLUMN IF EXISTS wallet_balance_minor;
+
+ALTER TABLE wallets DROP COLUMN IF EXISTS balance_minor;
+
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS amount_minor,
+    DROP COLUMN IF EXISTS commission_minor,
+    DROP COLUMN IF EXISTS driver_earnings_minor;
+
+DROP FUNCTION IF EXISTS from_minor_units(BIGINT, VARCHAR);
+DROP FUNCTION IF EXISTS to_minor_units(NUMERIC, VARCHAR);
+DROP FUNCTION IF EXISTS currency_exponent(VARCHAR);
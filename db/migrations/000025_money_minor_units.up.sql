@@ -0,0 +1,85 @@
+-- Store monetary amounts as integer minor units (cents, fils, yen) alongside
+-- the legacy DECIMAL columns.
+--
+-- Migration path:
+--   1. (this migration) add *_minor columns and backfill them from the DECIMAL
+--      columns using the currency exponent; repositories write both and read
+--      the *_minor columns.
+--   2. once reporting queries have moved to *_minor, drop the DECIMAL columns
+--      in a follow-up migration.
+
+-- Minor-unit exponent for a currency; unknown codes default to 2 like pkg/money.
+CREATE OR REPLACE FUNCTION currency_exponent(code VARCHAR) RETURNS INTEGER AS $$
+    SELECT COALESCE((SELECT decimal_places FROM currencies WHERE currencies.code = UPPER($1)), 2)
+$$ LANGUAGE SQL STABLE;
+
+-- Converts a major-unit amount to minor units, rounding half away from zero.
+CREATE OR REPLACE FUNCTION to_minor_units(amount NUMERIC, code VARCHAR) RETURNS BIGINT AS $$
+    SELECT ROUND(amount * POWER(10::NUMERIC, currency_exponent(code)))::BIGINT
+$$ LANGUAGE SQL STABLE;
+
+-- Converts minor units back to a major-unit amount for the legacy columns.
+CREATE OR REPLACE FUNCTION from_minor_units(amount BIGINT, code VARCHAR) RETURNS NUMERIC AS $$
+    SELECT amount::NUMERIC / POWER(10::NUMERIC, currency_exponent(code))
+$$ LANGUAGE SQL STABLE;
+
+-- Payments
+ALTER TABLE payments
+    ADD COLUMN IF NOT EXISTS amount_minor BIGINT,
+    ADD COLUMN IF NOT EXISTS commission_minor BIGINT,
+    ADD COLUMN IF NOT EXISTS driver_earnings_minor BIGINT;
+
+UPDATE payments SET
+    amount_minor = to_minor_units(amount, COALESCE(currency, 'USD')),
+    commission_minor = to_minor_units(commission, COALESCE(currency, 'USD')),
+    driver_earnings_minor = to_minor_units(driver_earnings, COALESCE(currency, 'USD'));
+
+ALTER TABLE payments ALTER COLUMN amount_minor SET NOT NULL;
+
+-- Legacy wallets
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS balance_minor BIGINT NOT NULL DEFAULT 0;
+
+UPDATE wallets SET balance_minor = to_minor_units(COALESCE(balance, 0), COALESCE(currency, 'USD'));
+
+-- Wallet payment methods
+ALTER TABLE payment_methods ADD COLUMN IF NOT EXISTS wallet_balance_minor BIGINT;
+
+UPDATE payment_methods SET wallet_balance_minor = to_minor_units(wallet_balance, currency)
+WHERE wallet_balance IS NOT NULL;
+
+-- Wallet transactions take their currency from the wallet payment method
+ALTER TABLE wallet_transactions
+    ADD COLUMN IF NOT EXISTS amount_minor BIGINT,
+    ADD COLUMN IF NOT EXISTS balance_before_minor BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS balance_after_minor BIGINT NOT NULL DEFAULT 0;
+
+UPDATE wallet_transactions wt SET
+    amount_minor = to_minor_units(wt.amount, pm.currency),
+    balance_before_minor = to_minor_units(wt.balance_before, pm.currency),
+    balance_after_minor = to_minor_units(wt.balance_after, pm.currency)
+FROM payment_methods pm
+WHERE pm.id = wt.payment_method_id;
+
+ALTER TABLE wallet_transactions ALTER COLUMN amount_minor SET NOT NULL;
+
+-- Driver earnings and payouts
+ALTER TABLE driver_earnings
+    ADD COLUMN IF NOT EXISTS gross_amount_minor BIGINT,
+    ADD COLUMN IF NOT EXISTS commission_minor BIGINT,
+    ADD COLUMN IF NOT EXISTS net_amount_minor BIGINT;
+
+UPDATE driver_earnings SET
+    gross_amount_minor = to_minor_units(gross_amount, currency),
+    commission_minor = to_minor_units(commission, currency),
+    net_amount_minor = to_minor_units(net_amount, currency);
+
+ALTER TABLE driver_earnings
+    ALTER COLUMN gross_amount_minor SET NOT NULL,
+    ALTER COLUMN commission_minor SET NOT NULL,
+    ALTER COLUMN net_amount_minor SET NOT NULL;
+
+ALTER TABLE driver_payouts ADD COLUMN IF NOT EXISTS amount_minor BIGINT;
+
+UPDATE driver_payouts SET amount_minor = to_minor_units(amount, currency);
+
+ALTER TABLE driver_payouts ALTER COLUMN amount_minor SET NOT NULL;
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS ledger_postings_balanced ON ledger_postings;
+DROP TRIGGER IF EXISTS ledger_postings_immutable ON ledger_postings;
+DROP TRIGGER IF EXISTS ledger_entries_immutable ON ledger_entries;
+DROP FUNCTION IF EXISTS ledger_check_entry_balanced();
+DROP FUNCTION IF EXISTS ledger_reject_mutation();
+
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_accounts;
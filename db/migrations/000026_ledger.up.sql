@@ -0,0 +1,85 @@
+-- =============================================
+-- Migration 000026: Double-Entry Ledger
+-- Every money movement is posted as an immutable, balanced journal entry.
+-- Amounts are integer minor units (see migration 000025).
+-- =============================================
+
+-- Chart of accounts. Rider wallets and driver payables are opened per user;
+-- platform-wide accounts use the nil UUID as owner.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(50) NOT NULL CHECK (code IN (
+        'rider_wallet', 'driver_payable', 'platform_revenue',
+        'stripe_clearing', 'gift_card_liability', 'promo_expense'
+    )),
+    account_type VARCHAR(20) NOT NULL CHECK (account_type IN ('asset', 'liability', 'revenue', 'expense')),
+    owner_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (code, owner_id, currency)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY,
+    entry_type VARCHAR(50) NOT NULL,
+    reference_type VARCHAR(50) NOT NULL,
+    reference_id UUID,
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+    description TEXT,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_reference ON ledger_entries (reference_type, reference_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_created_at ON ledger_entries (created_at);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id UUID PRIMARY KEY,
+    entry_id UUID NOT NULL REFERENCES ledger_entries(id),
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id),
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry ON ledger_postings (entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account ON ledger_postings (account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_currency_created_at ON ledger_postings (currency, created_at);
+
+-- Entries and postings are append-only; corrections are posted as new entries.
+CREATE OR REPLACE FUNCTION ledger_reject_mutation()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger table % is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_immutable BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_mutation();
+
+CREATE TRIGGER ledger_postings_immutable BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_mutation();
+
+-- Debits must equal credits for every entry. Checked at commit so an entry's
+-- postings can be inserted one at a time within its transaction.
+CREATE OR REPLACE FUNCTION ledger_check_entry_balanced()
+RETURNS TRIGGER AS $$
+DECLARE
+    imbalance BIGINT;
+BEGIN
+    SELECT COALESCE(SUM(CASE WHEN direction = 'debit' THEN amount_minor ELSE -amount_minor END), 0)
+    INTO imbalance
+    FROM ledger_postings
+    WHERE entry_id = NEW.entry_id;
+
+    IF imbalance <> 0 THEN
+        RAISE EXCEPTION 'ledger entry % is not balanced (debits - credits = %)', NEW.entry_id, imbalance;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_entry_balanced();
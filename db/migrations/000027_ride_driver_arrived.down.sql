@@ -0,0 +1,9 @@
+-- Rides waiting at pickup fall back to accepted
+UPDATE rides SET status = 'accepted' WHERE status = 'driver_arrived';
+
+ALTER TABLE rides DROP CONSTRAINT IF EXISTS rides_status_check;
+ALTER TABLE rides ADD CONSTRAINT rides_status_check CHECK (status IN (
+    'requested', 'accepted', 'in_progress', 'completed', 'cancelled'
+));
+
+ALTER TABLE rides DROP COLUMN IF EXISTS arrived_at;
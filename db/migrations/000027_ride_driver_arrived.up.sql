@@ -0,0 +1,13 @@
+-- =============================================
+-- Migration 000027: Driver Arrived Ride Status
+-- Adds the driver_arrived status between accepted and in_progress and the
+-- arrival timestamp that drives pickup wait time and rider no-show fees.
+-- Legal transitions are enforced in internal/rides/statemachine.go.
+-- =============================================
+
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS arrived_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE rides DROP CONSTRAINT IF EXISTS rides_status_check;
+ALTER TABLE rides ADD CONSTRAINT rides_status_check CHECK (status IN (
+    'requested', 'accepted', 'driver_arrived', 'in_progress', 'completed', 'cancelled'
+));
@@ -0,0 +1,24 @@
+-- =============================================
+-- Migration 000028: Multi-Stop Rides
+-- Intermediate stops between pickup and dropoff. Pickup and dropoff stay on
+-- the rides row; stops are visited in stop_order. The ride's fare is charged
+-- per leg plus the wait time recorded at each stop.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS ride_stops (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    stop_order INTEGER NOT NULL CHECK (stop_order > 0),
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    address TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'arrived', 'completed', 'skipped')),
+    wait_minutes INTEGER NOT NULL DEFAULT 0 CHECK (wait_minutes >= 0),
+    added_mid_trip BOOLEAN NOT NULL DEFAULT false,
+    arrived_at TIMESTAMP WITH TIME ZONE,
+    departed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (ride_id, stop_order)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_stops_ride_id ON ride_stops (ride_id, stop_order);
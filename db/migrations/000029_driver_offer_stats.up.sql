@@ -0,0 +1,21 @@
+-- =============================================
+-- Migration 000029: Driver Offer Stats
+-- How each driver responds to ride offers, recorded by the matching service.
+-- Feeds the acceptance and responsiveness factors in driver scoring and the
+-- cool-down applied after consecutive ignored offers.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS driver_offer_stats (
+    driver_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    offers_sent INTEGER NOT NULL DEFAULT 0 CHECK (offers_sent >= 0),
+    offers_accepted INTEGER NOT NULL DEFAULT 0 CHECK (offers_accepted >= 0),
+    offers_declined INTEGER NOT NULL DEFAULT 0 CHECK (offers_declined >= 0),
+    offers_expired INTEGER NOT NULL DEFAULT 0 CHECK (offers_expired >= 0),
+    consecutive_ignored INTEGER NOT NULL DEFAULT 0 CHECK (consecutive_ignored >= 0),
+    cooldown_until TIMESTAMP WITH TIME ZONE,
+    last_offer_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_offer_stats_cooldown ON driver_offer_stats (cooldown_until)
+    WHERE cooldown_until IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE ride_types DROP COLUMN IF EXISTS vehicle_categories;
@@ -0,0 +1,11 @@
+-- =============================================
+-- Migration 000030: Ride Type Vehicle Categories
+-- Which vehicle categories may serve each ride type. Matching only offers a
+-- ride to drivers whose primary vehicle is in one of these categories; an
+-- empty list allows any category.
+-- =============================================
+
+ALTER TABLE ride_types ADD COLUMN IF NOT EXISTS vehicle_categories TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE ride_types SET vehicle_categories = ARRAY['premium', 'lux'] WHERE name = 'Premium';
+UPDATE ride_types SET vehicle_categories = ARRAY['xl'] WHERE name = 'XL';
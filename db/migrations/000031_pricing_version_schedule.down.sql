@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS pricing_version_history;
+
+DROP INDEX IF EXISTS idx_pricing_versions_scheduled;
+
+ALTER TABLE pricing_config_versions DROP CONSTRAINT IF EXISTS chk_rollback_guard;
+ALTER TABLE pricing_config_versions
+    DROP COLUMN IF EXISTS rollback_checked_at,
+    DROP COLUMN IF EXISTS rollback_window_minutes,
+    DROP COLUMN IF EXISTS rollback_max_drop_pct,
+    DROP COLUMN IF EXISTS rollback_metric,
+    DROP COLUMN IF EXISTS previous_version_id,
+    DROP COLUMN IF EXISTS activated_at;
+
+UPDATE pricing_config_versions SET status = 'draft' WHERE status = 'scheduled';
+ALTER TABLE pricing_config_versions DROP CONSTRAINT IF EXISTS chk_status;
+ALTER TABLE pricing_config_versions ADD CONSTRAINT chk_status
+    CHECK (status IN ('draft', 'active', 'archived', 'ab_test'));
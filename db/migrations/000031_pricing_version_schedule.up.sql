@@ -0,0 +1,48 @@
+-- =============================================
+-- Migration 000031: Pricing Version Schedule
+-- Scheduled versions are activated by the scheduler at effective_from and
+-- retired at effective_until. A version can carry a rollback guard: once its
+-- observation window has passed, the guard metric is compared with the same
+-- window before the switch and the previous version is restored if it dropped
+-- too far. Every switch is recorded per affected city.
+-- =============================================
+
+ALTER TABLE pricing_config_versions DROP CONSTRAINT IF EXISTS chk_status;
+ALTER TABLE pricing_config_versions ADD CONSTRAINT chk_status
+    CHECK (status IN ('draft', 'scheduled', 'active', 'archived', 'ab_test'));
+
+ALTER TABLE pricing_config_versions
+    ADD COLUMN IF NOT EXISTS activated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS previous_version_id UUID REFERENCES pricing_config_versions(id),
+    ADD COLUMN IF NOT EXISTS rollback_metric VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS rollback_max_drop_pct DECIMAL(5,2),
+    ADD COLUMN IF NOT EXISTS rollback_window_minutes INTEGER,
+    ADD COLUMN IF NOT EXISTS rollback_checked_at TIMESTAMPTZ;
+
+ALTER TABLE pricing_config_versions ADD CONSTRAINT chk_rollback_guard CHECK (
+    rollback_metric IS NULL OR (
+        rollback_metric IN ('accept_rate', 'completion_rate')
+        AND rollback_max_drop_pct > 0 AND rollback_max_drop_pct <= 100
+        AND rollback_window_minutes > 0
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_versions_scheduled ON pricing_config_versions (effective_from)
+    WHERE status = 'scheduled';
+
+CREATE TABLE IF NOT EXISTS pricing_version_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    version_id UUID NOT NULL REFERENCES pricing_config_versions(id) ON DELETE CASCADE,
+    previous_version_id UUID REFERENCES pricing_config_versions(id) ON DELETE SET NULL,
+    city_id UUID REFERENCES cities(id) ON DELETE CASCADE, -- NULL: global configs
+    event VARCHAR(20) NOT NULL CHECK (event IN ('activated', 'expired', 'rolled_back')),
+    reason TEXT,
+    metric_name VARCHAR(50),
+    baseline_value DECIMAL(10,4),
+    observed_value DECIMAL(10,4),
+    created_by UUID REFERENCES users(id), -- NULL: changed by the scheduler
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_version_history_city ON pricing_version_history (city_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_pricing_version_history_version ON pricing_version_history (version_id);
@@ -0,0 +1,23 @@
+-- =============================================
+-- Migration 000032: Ride Fare Quotes
+-- A ride requested with a fare quote token keeps the quote here for disputes.
+-- While the quote is locked the rider pays the quoted fare; it is released,
+-- and the ride re-priced, when the route deviates too far or stops are added.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS ride_fare_quotes (
+    ride_id UUID PRIMARY KEY REFERENCES rides(id) ON DELETE CASCADE,
+    quote_id UUID NOT NULL UNIQUE, -- a quote can be redeemed once
+    quoted_fare DECIMAL(10, 2) NOT NULL CHECK (quoted_fare >= 0),
+    currency_code VARCHAR(3) NOT NULL,
+    surge_multiplier DECIMAL(4, 2) NOT NULL DEFAULT 1.00,
+    distance_km DECIMAL(10, 2) NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    pricing_version_id UUID REFERENCES pricing_config_versions(id),
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked BOOLEAN NOT NULL DEFAULT true,
+    release_reason TEXT,
+    released_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,8 @@
+-- =============================================
+-- Migration 000033: Promo Targeting Rules
+-- Restricts a promo code to ride types, cities or regions, a rider's first
+-- N rides, day and hour windows, payment methods and user segments.
+-- NULL rules mean the code applies to any ride.
+-- =============================================
+
+ALTER TABLE promo_codes ADD COLUMN IF NOT EXISTS rules JSONB;
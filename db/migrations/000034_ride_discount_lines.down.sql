@@ -0,0 +1,2 @@
+ALTER TABLE loyalty_redemptions DROP COLUMN IF EXISTS ride_id;
+DROP TABLE IF EXISTS ride_discount_lines;
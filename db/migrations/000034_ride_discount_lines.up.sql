@@ -0,0 +1,23 @@
+-- =============================================
+-- Migration 000034: Ride Discount Lines
+-- When a ride completes, promo, subscription, loyalty and gift card
+-- reductions are settled together and each one is recorded here as a
+-- receipt line. Loyalty redemptions remember the ride that consumed them.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS ride_discount_lines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ride_id UUID NOT NULL REFERENCES rides(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL, -- order the line was applied in
+    source VARCHAR(20) NOT NULL, -- promo, subscription, loyalty, gift_card
+    line_type VARCHAR(10) NOT NULL CHECK (line_type IN ('discount', 'credit')),
+    label VARCHAR(100) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    reference_id UUID, -- promo code, loyalty redemption, etc.
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (ride_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_discount_lines_ride ON ride_discount_lines(ride_id);
+
+ALTER TABLE loyalty_redemptions ADD COLUMN IF NOT EXISTS ride_id UUID REFERENCES rides(id) ON DELETE SET NULL;
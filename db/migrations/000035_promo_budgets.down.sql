@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS promo_budget_alerts;
+DROP INDEX IF EXISTS idx_promo_code_uses_used_at;
+DROP INDEX IF EXISTS idx_promo_codes_campaign;
+
+ALTER TABLE promo_codes
+    DROP COLUMN IF EXISTS burn_rate_alert,
+    DROP COLUMN IF EXISTS spend_date,
+    DROP COLUMN IF EXISTS daily_spent,
+    DROP COLUMN IF EXISTS spent,
+    DROP COLUMN IF EXISTS daily_budget,
+    DROP COLUMN IF EXISTS budget,
+    DROP COLUMN IF EXISTS campaign_id;
+
+DROP TABLE IF EXISTS promo_campaigns;
@@ -0,0 +1,60 @@
+-- =============================================
+-- Migration 000035: Promo Budgets
+-- Caps the total and daily discount a promo code, or a campaign of codes,
+-- may give. Spend is reserved atomically as codes are applied; a code or
+-- campaign whose total budget runs out is deactivated. Spend faster than
+-- the configured burn rate raises an alert for admins.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS promo_campaigns (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    budget DECIMAL(12, 2) CHECK (budget > 0), -- NULL means unlimited
+    daily_budget DECIMAL(12, 2) CHECK (daily_budget > 0),
+    spent DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    daily_spent DECIMAL(12, 2) NOT NULL DEFAULT 0, -- spend on spend_date
+    spend_date DATE,
+    burn_rate_alert DECIMAL(12, 2) CHECK (burn_rate_alert > 0), -- spend per hour
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE promo_codes
+    ADD COLUMN IF NOT EXISTS campaign_id UUID REFERENCES promo_campaigns(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS budget DECIMAL(12, 2) CHECK (budget > 0),
+    ADD COLUMN IF NOT EXISTS daily_budget DECIMAL(12, 2) CHECK (daily_budget > 0),
+    ADD COLUMN IF NOT EXISTS spent DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS daily_spent DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS spend_date DATE,
+    ADD COLUMN IF NOT EXISTS burn_rate_alert DECIMAL(12, 2) CHECK (burn_rate_alert > 0);
+
+-- Codes applied before budgets existed count towards them
+UPDATE promo_codes pc
+SET spent = u.total
+FROM (
+    SELECT promo_code_id, SUM(discount_amount) AS total
+    FROM promo_code_uses
+    GROUP BY promo_code_id
+) u
+WHERE u.promo_code_id = pc.id;
+
+CREATE INDEX IF NOT EXISTS idx_promo_codes_campaign ON promo_codes(campaign_id);
+CREATE INDEX IF NOT EXISTS idx_promo_code_uses_used_at ON promo_code_uses(promo_code_id, used_at);
+
+CREATE TABLE IF NOT EXISTS promo_budget_alerts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    promo_code_id UUID REFERENCES promo_codes(id) ON DELETE CASCADE,
+    campaign_id UUID REFERENCES promo_campaigns(id) ON DELETE CASCADE,
+    alert_type VARCHAR(30) NOT NULL CHECK (alert_type IN ('burn_rate', 'budget_exhausted')),
+    spend_per_hour DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    threshold DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    message TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CHECK (promo_code_id IS NOT NULL OR campaign_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_promo_budget_alerts_promo ON promo_budget_alerts(promo_code_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_promo_budget_alerts_campaign ON promo_budget_alerts(campaign_id, created_at DESC);
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS payout_reconciliation_mismatches;
+DROP INDEX IF EXISTS idx_driver_earnings_payout_id;
+DROP INDEX IF EXISTS idx_driver_payouts_processed_at;
+DROP INDEX IF EXISTS idx_driver_payouts_provider_transfer;
+DROP INDEX IF EXISTS idx_driver_payouts_idempotency_key;
+
+ALTER TABLE driver_payouts
+    DROP COLUMN IF EXISTS reconciled_at,
+    DROP COLUMN IF EXISTS provider_transfer_id,
+    DROP COLUMN IF EXISTS idempotency_key,
+    DROP COLUMN IF EXISTS fee,
+    DROP COLUMN IF EXISTS schedule;
+
+DROP TABLE IF EXISTS driver_payout_settings;
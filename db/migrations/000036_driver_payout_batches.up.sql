@@ -0,0 +1,51 @@
+-- =============================================
+-- Migration 000036: Driver Payout Batches
+-- Drivers are paid out in scheduled batches (daily or weekly) to their
+-- payment provider account, or instantly on request for a fee. Each batch
+-- carries an idempotency key so a retried run never pays twice, and a daily
+-- reconciliation matches the provider's transfers back to payouts and
+-- records any mismatch for finance to review.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS driver_payout_settings (
+    driver_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    schedule VARCHAR(20) NOT NULL DEFAULT 'weekly' CHECK (schedule IN ('daily', 'weekly', 'instant')),
+    weekly_day SMALLINT NOT NULL DEFAULT 1 CHECK (weekly_day BETWEEN 0 AND 6), -- 0 = Sunday
+    provider_account_id VARCHAR(255), -- e.g. Stripe connected account; no batches without one
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE driver_payouts
+    ADD COLUMN IF NOT EXISTS schedule VARCHAR(20),
+    ADD COLUMN IF NOT EXISTS fee DECIMAL(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS idempotency_key VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS provider_transfer_id VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMPTZ;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_driver_payouts_idempotency_key ON driver_payouts(idempotency_key);
+CREATE INDEX IF NOT EXISTS idx_driver_payouts_provider_transfer ON driver_payouts(provider_transfer_id)
+    WHERE provider_transfer_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_driver_payouts_processed_at ON driver_payouts(processed_at);
+CREATE INDEX IF NOT EXISTS idx_driver_earnings_payout_id ON driver_earnings(payout_id);
+
+CREATE TABLE IF NOT EXISTS payout_reconciliation_mismatches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    payout_id UUID REFERENCES driver_payouts(id),
+    provider_transfer_id VARCHAR(255),
+    mismatch_type VARCHAR(30) NOT NULL CHECK (mismatch_type IN (
+        'missing_transfer', 'unknown_transfer', 'amount_mismatch', 'reversed', 'status_mismatch'
+    )),
+    expected_amount DECIMAL(12, 2),
+    provider_amount DECIMAL(12, 2),
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    details TEXT NOT NULL DEFAULT '',
+    resolved BOOLEAN NOT NULL DEFAULT false,
+    resolved_by UUID REFERENCES users(id),
+    resolved_at TIMESTAMPTZ,
+    detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_mismatches_unresolved ON payout_reconciliation_mismatches(detected_at)
+    WHERE resolved = false;
+CREATE INDEX IF NOT EXISTS idx_payout_mismatches_payout ON payout_reconciliation_mismatches(payout_id);
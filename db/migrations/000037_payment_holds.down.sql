@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payment_holds_payment_intent;
+DROP INDEX IF EXISTS idx_payment_holds_status;
+DROP TABLE IF EXISTS payment_holds;
@@ -0,0 +1,32 @@
+-- =============================================
+-- Migration 000037: Payment Authorization Holds
+-- Card rides place an authorization hold for the estimated fare plus a
+-- buffer when the ride is requested, so a declined card surfaces before the
+-- trip. The hold is captured for the final fare on completion (incrementing
+-- the authorization when the fare outgrows it) and released on
+-- cancellation, less any cancellation fee.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS payment_holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    ride_id UUID NOT NULL UNIQUE REFERENCES rides(id) ON DELETE CASCADE,
+    rider_id UUID NOT NULL REFERENCES users(id),
+    stripe_payment_intent_id VARCHAR(255),
+    currency VARCHAR(3) NOT NULL DEFAULT 'USD',
+    estimated_amount_minor BIGINT NOT NULL,
+    authorized_amount_minor BIGINT NOT NULL,
+    captured_amount_minor BIGINT NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'authorized' CHECK (status IN (
+        'authorized', 'processing', 'captured', 'released', 'failed'
+    )),
+    payment_id UUID REFERENCES payments(id),
+    failure_reason TEXT,
+    captured_at TIMESTAMPTZ,
+    released_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_holds_status ON payment_holds(status, updated_at);
+CREATE INDEX IF NOT EXISTS idx_payment_holds_payment_intent ON payment_holds(stripe_payment_intent_id)
+    WHERE stripe_payment_intent_id IS NOT NULL;
@@ -0,0 +1,20 @@
+DELETE FROM payout_reconciliation_mismatches WHERE mismatch_type = 'bank_payout_failed';
+ALTER TABLE payout_reconciliation_mismatches
+    DROP CONSTRAINT IF EXISTS payout_reconciliation_mismatches_mismatch_type_check;
+ALTER TABLE payout_reconciliation_mismatches
+    ADD CONSTRAINT payout_reconciliation_mismatches_mismatch_type_check CHECK (mismatch_type IN (
+        'missing_transfer', 'unknown_transfer', 'amount_mismatch', 'reversed', 'status_mismatch'
+    ));
+ALTER TABLE payout_reconciliation_mismatches DROP COLUMN IF EXISTS provider_payout_id;
+
+DROP INDEX IF EXISTS idx_driver_payout_settings_account;
+ALTER TABLE driver_payout_settings
+    DROP COLUMN IF EXISTS held_at,
+    DROP COLUMN IF EXISTS hold_reason;
+
+DROP INDEX IF EXISTS idx_fare_disputes_provider_dispute;
+ALTER TABLE fare_disputes DROP COLUMN IF EXISTS provider_dispute_id;
+
+DROP INDEX IF EXISTS idx_stripe_webhook_events_unprocessed;
+DROP INDEX IF EXISTS idx_stripe_webhook_events_type;
+DROP TABLE IF EXISTS stripe_webhook_events;
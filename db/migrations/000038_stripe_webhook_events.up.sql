@@ -0,0 +1,49 @@
+-- =============================================
+-- Migration 000038: Stripe Webhook Events
+-- Every verified Stripe webhook is stored raw under its event ID before it
+-- is applied, so a redelivered event is acknowledged without being applied
+-- twice and a failed one can be retried. Chargebacks open fare disputes
+-- keyed by the Stripe dispute, and a failed bank payout on a driver's
+-- connected account holds their payouts until the account is fixed.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS stripe_webhook_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    event_id VARCHAR(255) NOT NULL UNIQUE,
+    event_type VARCHAR(100) NOT NULL,
+    account_id VARCHAR(255), -- connected account the event came from, if any
+    livemode BOOLEAN NOT NULL DEFAULT false,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'processing' CHECK (status IN (
+        'processing', 'processed', 'failed'
+    )),
+    attempts INTEGER NOT NULL DEFAULT 1,
+    last_error TEXT,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stripe_webhook_events_type ON stripe_webhook_events(event_type, received_at);
+CREATE INDEX IF NOT EXISTS idx_stripe_webhook_events_unprocessed ON stripe_webhook_events(received_at)
+    WHERE status <> 'processed';
+
+ALTER TABLE fare_disputes ADD COLUMN IF NOT EXISTS provider_dispute_id VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_fare_disputes_provider_dispute ON fare_disputes(provider_dispute_id)
+    WHERE provider_dispute_id IS NOT NULL;
+
+ALTER TABLE driver_payout_settings
+    ADD COLUMN IF NOT EXISTS hold_reason TEXT,
+    ADD COLUMN IF NOT EXISTS held_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_driver_payout_settings_account ON driver_payout_settings(provider_account_id)
+    WHERE provider_account_id IS NOT NULL;
+
+ALTER TABLE payout_reconciliation_mismatches
+    ADD COLUMN IF NOT EXISTS provider_payout_id VARCHAR(255);
+ALTER TABLE payout_reconciliation_mismatches
+    DROP CONSTRAINT IF EXISTS payout_reconciliation_mismatches_mismatch_type_check;
+ALTER TABLE payout_reconciliation_mismatches
+    ADD CONSTRAINT payout_reconciliation_mismatches_mismatch_type_check CHECK (mismatch_type IN (
+        'missing_transfer', 'unknown_transfer', 'amount_mismatch', 'reversed', 'status_mismatch',
+        'bank_payout_failed'
+    ));
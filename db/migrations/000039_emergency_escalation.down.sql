@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_emergency_alert_events_alert;
+DROP TABLE IF EXISTS emergency_alert_events;
+
+DROP INDEX IF EXISTS idx_emergency_alerts_escalation;
+ALTER TABLE emergency_alerts
+    DROP COLUMN IF EXISTS last_location_at,
+    DROP COLUMN IF EXISTS last_longitude,
+    DROP COLUMN IF EXISTS last_latitude,
+    DROP COLUMN IF EXISTS share_url,
+    DROP COLUMN IF EXISTS escalated_at,
+    DROP COLUMN IF EXISTS next_escalation_at,
+    DROP COLUMN IF EXISTS escalation_level;
@@ -0,0 +1,31 @@
+-- =============================================
+-- Migration 000039: Emergency Escalation
+-- SOS alerts follow a timed escalation policy: the on-call safety queue is
+-- paged when the alert is raised, and emergency services are dispatched if
+-- nobody acknowledges it in time. next_escalation_at is when the next step
+-- is due; workers claim due alerts by pushing it forward. Every step, and
+-- every action on the alert, is recorded in its audit timeline.
+-- =============================================
+
+ALTER TABLE emergency_alerts
+    ADD COLUMN IF NOT EXISTS escalation_level INTEGER NOT NULL DEFAULT 0, -- 0 raised, 1 on-call paged, 2 emergency services dispatched
+    ADD COLUMN IF NOT EXISTS next_escalation_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS share_url TEXT, -- live ride tracking link
+    ADD COLUMN IF NOT EXISTS last_latitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS last_longitude DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS last_location_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_emergency_alerts_escalation ON emergency_alerts(next_escalation_at)
+    WHERE next_escalation_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS emergency_alert_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    alert_id UUID NOT NULL REFERENCES emergency_alerts(id) ON DELETE CASCADE,
+    event_type VARCHAR(50) NOT NULL,
+    actor_id UUID REFERENCES users(id), -- user or admin who acted; NULL for the system
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_emergency_alert_events_alert ON emergency_alert_events(alert_id, created_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notification_templates_active;
+DROP TABLE IF EXISTS notification_templates;
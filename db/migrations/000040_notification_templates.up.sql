@@ -0,0 +1,26 @@
+-- =============================================
+-- Migration 000040: Notification Templates
+-- Push, SMS and email copy editable without a deploy. Templates are keyed
+-- by notification type, channel and language; every edit is a new version
+-- and at most one version per key is active. Without an active template
+-- the compiled-in pkg/i18n translations are used.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    notification_type VARCHAR(50) NOT NULL,
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('push', 'sms', 'email')),
+    language VARCHAR(10) NOT NULL,
+    version INTEGER NOT NULL,
+    title TEXT NOT NULL DEFAULT '', -- push title / email subject
+    body TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT false,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activated_at TIMESTAMPTZ,
+    UNIQUE (notification_type, channel, language, version)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_templates_active
+    ON notification_templates(notification_type, channel, language)
+    WHERE is_active;
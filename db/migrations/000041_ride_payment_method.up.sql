@@ -0,0 +1,9 @@
+-- =============================================
+-- Migration 000041: Ride Payment Method
+-- The payment method a ride was requested with. Card rides are held and
+-- captured with it, and promo codes restricted to a payment method are
+-- checked against it when the fare is settled rather than trusting what
+-- the client sends when validating the code.
+-- =============================================
+
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS payment_method VARCHAR(20);
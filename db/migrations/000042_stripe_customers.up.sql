@@ -0,0 +1,8 @@
+-- =============================================
+-- Migration 000042: Stripe Customers
+-- The Stripe customer a user's saved cards are attached to. Card rides are
+-- held off-session against the rider's default card, which Stripe only
+-- allows through the customer that owns it.
+-- =============================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS stripe_customer_id VARCHAR(255);
@@ -0,0 +1,19 @@
+-- =============================================
+-- Migration 000043: Ledger Pending Entries
+-- Journal entries for money that has already moved but could not be
+-- posted. They are retried until posted, so a failed post is never lost.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS ledger_pending_entries (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    entry_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Retry scan: due entries, oldest first
+CREATE INDEX IF NOT EXISTS idx_ledger_pending_entries_due
+    ON ledger_pending_entries (next_attempt_at, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE payout_reconciliation_mismatches
+    DROP COLUMN IF EXISTS expected_amount_minor,
+    DROP COLUMN IF EXISTS provider_amount_minor;
+
+ALTER TABLE driver_payouts DROP COLUMN IF EXISTS fee_minor;
@@ -0,0 +1,17 @@
+-- =============================================
+-- Migration 000044: Payout Minor Units
+-- Payout fees and reconciliation amounts in integer minor units alongside
+-- the DECIMAL columns, which are still written for reporting.
+-- =============================================
+
+ALTER TABLE driver_payouts ADD COLUMN IF NOT EXISTS fee_minor BIGINT NOT NULL DEFAULT 0;
+
+UPDATE driver_payouts SET fee_minor = to_minor_units(COALESCE(fee, 0), COALESCE(currency, 'USD'));
+
+ALTER TABLE payout_reconciliation_mismatches
+    ADD COLUMN IF NOT EXISTS expected_amount_minor BIGINT,
+    ADD COLUMN IF NOT EXISTS provider_amount_minor BIGINT;
+
+UPDATE payout_reconciliation_mismatches SET
+    expected_amount_minor = to_minor_units(expected_amount, currency),
+    provider_amount_minor = to_minor_units(provider_amount, currency);
@@ -0,0 +1,17 @@
+-- =============================================
+-- Migration 000045: Pending Ride Offers
+-- Offers waiting on a driver's response, shared by every matching replica.
+-- Each offer is taken once: by the driver's response, by the ride being
+-- taken or cancelled, or by its expiry, which counts it as ignored.
+-- =============================================
+
+CREATE TABLE IF NOT EXISTS pending_ride_offers (
+    ride_id UUID NOT NULL,
+    driver_id UUID NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (ride_id, driver_id)
+);
+
+-- Expiry sweep
+CREATE INDEX IF NOT EXISTS idx_pending_ride_offers_expires_at ON pending_ride_offers (expires_at);
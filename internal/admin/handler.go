@@ -2,11 +2,14 @@ package admin
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/pagination"
 )
 
@@ -491,3 +494,201 @@ func (h *Handler) GetAuditLogs(c *gin.Context) {
 	meta := pagination.BuildMeta(params.Limit, params.Offset, total)
 	common.SuccessResponseWithMeta(c, logs, meta)
 }
+
+// GetDeadLetters lists events that consumers failed to process.
+// Query params: consumer, after (DLQ sequence cursor), limit.
+func (h *Handler) GetDeadLetters(c *gin.Context) {
+	filter := eventbus.DeadLetterFilter{
+		Consumer: c.Query("consumer"),
+		Limit:    20,
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil {
+			filter.Limit = limit
+		}
+	}
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err := strconv.ParseUint(afterStr, 10, 64)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "Invalid after cursor")
+			return
+		}
+		filter.AfterSeq = after
+	}
+
+	messages, err := h.service.ListDeadLetters(c.Request.Context(), filter)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch dead letters")
+		return
+	}
+
+	response := gin.H{"dead_letters": messages}
+	if len(messages) > 0 {
+		response["next_after"] = messages[len(messages)-1].Sequence
+	}
+	common.SuccessResponse(c, response)
+}
+
+// GetDeadLetter returns a dead-lettered event with its payload and last error.
+func (h *Handler) GetDeadLetter(c *gin.Context) {
+	seq, ok := parseDeadLetterSeq(c)
+	if !ok {
+		return
+	}
+
+	msg, err := h.service.GetDeadLetter(c.Request.Context(), seq)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch dead letter")
+		return
+	}
+
+	common.SuccessResponse(c, msg)
+}
+
+// ReplayDeadLetter redelivers a dead-lettered event to the consumer that failed it.
+func (h *Handler) ReplayDeadLetter(c *gin.Context) {
+	seq, ok := parseDeadLetterSeq(c)
+	if !ok {
+		return
+	}
+
+	msg, err := h.service.ReplayDeadLetter(c.Request.Context(), getAdminID(c), seq)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to replay dead letter")
+		return
+	}
+
+	common.SuccessResponseWithStatus(c, http.StatusOK, msg, "Dead letter replayed successfully")
+}
+
+// DiscardDeadLetter permanently removes a dead-lettered event.
+func (h *Handler) DiscardDeadLetter(c *gin.Context) {
+	seq, ok := parseDeadLetterSeq(c)
+	if !ok {
+		return
+	}
+
+	msg, err := h.service.DiscardDeadLetter(c.Request.Context(), getAdminID(c), seq)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to discard dead letter")
+		return
+	}
+
+	common.SuccessResponseWithStatus(c, http.StatusOK, msg, "Dead letter discarded successfully")
+}
+
+func parseDeadLetterSeq(c *gin.Context) (uint64, bool) {
+	seq, err := strconv.ParseUint(c.Param("seq"), 10, 64)
+	if err != nil || seq == 0 {
+		common.ErrorResponse(c, http.StatusBadRequest, "Invalid dead letter sequence")
+		return 0, false
+	}
+	return seq, true
+}
+
+// GetTrialBalance returns the ledger trial balance for a currency. as_of
+// accepts an RFC 3339 timestamp or a date, which means the end of that day.
+func (h *Handler) GetTrialBalance(c *gin.Context) {
+	currency := c.DefaultQuery("currency", "USD")
+
+	var asOf time.Time
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		t, err := time.Parse(time.RFC3339, asOfStr)
+		if err != nil {
+			day, dayErr := time.Parse("2006-01-02", asOfStr)
+			if dayErr != nil {
+				common.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of")
+				return
+			}
+			t = day.Add(24*time.Hour - time.Nanosecond)
+		}
+		asOf = t
+	}
+
+	tb, err := h.service.GetTrialBalance(c.Request.Context(), currency, asOf)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch trial balance")
+		return
+	}
+
+	common.SuccessResponse(c, tb)
+}
+
+// GetLedgerAccountBalances lists ledger account balances, filtered by
+// account code, owner and currency.
+func (h *Handler) GetLedgerAccountBalances(c *gin.Context) {
+	params := pagination.ParseParams(c)
+	filter := ledger.AccountFilter{
+		AccountCode: ledger.AccountCode(c.Query("code")),
+		Currency:    c.Query("currency"),
+		Limit:       params.Limit,
+		Offset:      params.Offset,
+	}
+	if ownerStr := c.Query("owner_id"); ownerStr != "" {
+		ownerID, err := uuid.Parse(ownerStr)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "Invalid owner ID")
+			return
+		}
+		filter.OwnerID = &ownerID
+	}
+
+	balances, err := h.service.GetLedgerAccountBalances(c.Request.Context(), filter)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch account balances")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"accounts": balances})
+}
+
+// GetLedgerEntries returns the journal entries for a reference, e.g.
+// ?reference_type=payment&reference_id=<payment id>.
+func (h *Handler) GetLedgerEntries(c *gin.Context) {
+	referenceType := c.Query("reference_type")
+	if referenceType == "" {
+		common.ErrorResponse(c, http.StatusBadRequest, "reference_type is required")
+		return
+	}
+	referenceID, err := uuid.Parse(c.Query("reference_id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "Invalid reference ID")
+		return
+	}
+
+	entries, err := h.service.GetLedgerEntries(c.Request.Context(), referenceType, referenceID)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch ledger entries")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"entries": entries})
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
@@ -43,3 +45,20 @@ type RepositoryInterface interface {
 	InsertAuditLog(ctx context.Context, adminID uuid.UUID, action, targetType string, targetID uuid.UUID, metadata map[string]interface{})
 	GetAuditLogs(ctx context.Context, limit, offset int, filter *AuditLogFilter) ([]*AuditLog, int64, error)
 }
+
+// DeadLetterQueue is the event bus dead-letter tooling exposed to admins.
+// Implemented by eventbus.Bus.
+type DeadLetterQueue interface {
+	ListDeadLetters(ctx context.Context, filter eventbus.DeadLetterFilter) ([]*eventbus.DeadLetterMessage, error)
+	GetDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error)
+	ReplayDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error)
+	DiscardDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error)
+}
+
+// LedgerReporter is the read side of the double-entry ledger used for
+// financial reporting. Implemented by ledger.Service.
+type LedgerReporter interface {
+	GetTrialBalance(ctx context.Context, currency string, asOf time.Time) (*ledger.TrialBalance, error)
+	ListAccountBalances(ctx context.Context, filter ledger.AccountFilter) ([]*ledger.AccountBalance, error)
+	GetEntriesByReference(ctx context.Context, referenceType string, referenceID uuid.UUID) ([]*ledger.JournalEntry, error)
+}
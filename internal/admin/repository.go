@@ -1046,6 +1046,7 @@ func (r *Repository) GetActivityFeed(ctx context.Context, limit, offset int) ([]
 			       CASE r.status
 			           WHEN 'requested' THEN 'ride_requested'
 			           WHEN 'accepted' THEN 'ride_accepted'
+			           WHEN 'driver_arrived' THEN 'driver_arrived'
 			           WHEN 'in_progress' THEN 'ride_started'
 			           WHEN 'completed' THEN 'ride_completed'
 			           WHEN 'cancelled' THEN 'ride_cancelled'
@@ -1053,6 +1054,7 @@ func (r *Repository) GetActivityFeed(ctx context.Context, limit, offset int) ([]
 			       CASE r.status
 			           WHEN 'requested' THEN CONCAT(u.first_name, ' ', u.last_name, ' requested a ride from ', r.pickup_address)
 			           WHEN 'accepted' THEN CONCAT(COALESCE(du.first_name, ''), ' ', COALESCE(du.last_name, ''), ' accepted a ride')
+			           WHEN 'driver_arrived' THEN CONCAT(COALESCE(du.first_name, ''), ' ', COALESCE(du.last_name, ''), ' arrived at ', r.pickup_address)
 			           WHEN 'in_progress' THEN CONCAT('Ride to ', r.dropoff_address, ' started')
 			           WHEN 'completed' THEN CONCAT('Ride completed — $', COALESCE(r.final_fare::text, r.estimated_fare::text))
 			           WHEN 'cancelled' THEN CONCAT(u.first_name, ' ', u.last_name, ' cancelled a ride', CASE WHEN r.cancellation_reason IS NOT NULL THEN CONCAT(' — ', r.cancellation_reason) ELSE '' END)
@@ -1067,6 +1069,7 @@ func (r *Repository) GetActivityFeed(ctx context.Context, limit, offset int) ([]
 			               WHEN 'completed' THEN r.completed_at
 			               WHEN 'cancelled' THEN r.cancelled_at
 			               WHEN 'accepted' THEN r.accepted_at
+			               WHEN 'driver_arrived' THEN r.arrived_at
 			               WHEN 'in_progress' THEN r.started_at
 			               ELSE r.requested_at
 			           END, r.created_at
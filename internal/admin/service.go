@@ -3,10 +3,14 @@ package admin
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	redisclient "github.com/richxcame/ride-hailing/pkg/redis"
 )
@@ -22,6 +26,8 @@ type Service struct {
 	repo         RepositoryInterface
 	redis        *redisclient.Client
 	fraudService FraudSuspender
+	deadLetters  DeadLetterQueue
+	ledger       LedgerReporter
 }
 
 // NewService creates a new admin service.
@@ -30,6 +36,16 @@ func NewService(repo RepositoryInterface, redis *redisclient.Client, fraudServic
 	return &Service{repo: repo, redis: redis, fraudService: fraudService}
 }
 
+// SetDeadLetterQueue enables the event dead-letter endpoints.
+func (s *Service) SetDeadLetterQueue(dlq DeadLetterQueue) {
+	s.deadLetters = dlq
+}
+
+// SetLedger enables the ledger reporting endpoints.
+func (s *Service) SetLedger(l LedgerReporter) {
+	s.ledger = l
+}
+
 // GetAllUsers retrieves all users with pagination and filters
 func (s *Service) GetAllUsers(ctx context.Context, limit, offset int, filter *UserFilter) ([]*models.User, int, error) {
 	if limit <= 0 || limit > 100 {
@@ -573,3 +589,109 @@ type ActivityFeedItem struct {
 	Metadata    string     `json:"metadata,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 }
+
+// ListDeadLetters returns dead-lettered events, optionally for one consumer.
+func (s *Service) ListDeadLetters(ctx context.Context, filter eventbus.DeadLetterFilter) ([]*eventbus.DeadLetterMessage, error) {
+	if s.deadLetters == nil {
+		return nil, common.NewServiceUnavailableError("event bus is not configured")
+	}
+	messages, err := s.deadLetters.ListDeadLetters(ctx, filter)
+	if err != nil {
+		return nil, common.NewInternalError("failed to list dead letters", err)
+	}
+	return messages, nil
+}
+
+// GetDeadLetter returns a single dead-lettered event for inspection.
+func (s *Service) GetDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error) {
+	if s.deadLetters == nil {
+		return nil, common.NewServiceUnavailableError("event bus is not configured")
+	}
+	msg, err := s.deadLetters.GetDeadLetter(ctx, seq)
+	if err != nil {
+		return nil, deadLetterError(err, "failed to get dead letter")
+	}
+	return msg, nil
+}
+
+// ReplayDeadLetter redelivers a dead-lettered event to its consumer.
+func (s *Service) ReplayDeadLetter(ctx context.Context, adminID uuid.UUID, seq uint64) (*eventbus.DeadLetterMessage, error) {
+	if s.deadLetters == nil {
+		return nil, common.NewServiceUnavailableError("event bus is not configured")
+	}
+	msg, err := s.deadLetters.ReplayDeadLetter(ctx, seq)
+	if err != nil {
+		return nil, deadLetterError(err, "failed to replay dead letter")
+	}
+	s.repo.InsertAuditLog(ctx, adminID, "replay_dead_letter", "event", deadLetterTargetID(msg), deadLetterAuditMetadata(msg))
+	return msg, nil
+}
+
+// DiscardDeadLetter permanently removes a dead-lettered event.
+func (s *Service) DiscardDeadLetter(ctx context.Context, adminID uuid.UUID, seq uint64) (*eventbus.DeadLetterMessage, error) {
+	if s.deadLetters == nil {
+		return nil, common.NewServiceUnavailableError("event bus is not configured")
+	}
+	msg, err := s.deadLetters.DiscardDeadLetter(ctx, seq)
+	if err != nil {
+		return nil, deadLetterError(err, "failed to discard dead letter")
+	}
+	s.repo.InsertAuditLog(ctx, adminID, "discard_dead_letter", "event", deadLetterTargetID(msg), deadLetterAuditMetadata(msg))
+	return msg, nil
+}
+
+func deadLetterError(err error, message string) error {
+	switch {
+	case errors.Is(err, eventbus.ErrDeadLetterNotFound):
+		return common.NewNotFoundError("dead letter not found", err)
+	case errors.Is(err, eventbus.ErrDeadLetterNotReplayable):
+		return common.NewBadRequestError("dead letter has no replayable event", err)
+	default:
+		return common.NewInternalError(message, err)
+	}
+}
+
+// deadLetterTargetID returns the original event ID for audit logging.
+func deadLetterTargetID(msg *eventbus.DeadLetterMessage) uuid.UUID {
+	if msg.Event != nil {
+		if id, err := uuid.Parse(msg.Event.ID); err == nil {
+			return id
+		}
+	}
+	return uuid.Nil
+}
+
+func deadLetterAuditMetadata(msg *eventbus.DeadLetterMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"dlq_sequence": msg.Sequence,
+		"consumer":     msg.Consumer,
+		"subject":      msg.Subject,
+		"error":        msg.Error,
+	}
+}
+
+// GetTrialBalance returns the ledger trial balance for a currency.
+func (s *Service) GetTrialBalance(ctx context.Context, currency string, asOf time.Time) (*ledger.TrialBalance, error) {
+	if s.ledger == nil {
+		return nil, common.NewServiceUnavailableError("ledger is not configured")
+	}
+	return s.ledger.GetTrialBalance(ctx, currency, asOf)
+}
+
+// GetLedgerAccountBalances returns ledger account balances, e.g. every
+// driver's outstanding payable.
+func (s *Service) GetLedgerAccountBalances(ctx context.Context, filter ledger.AccountFilter) ([]*ledger.AccountBalance, error) {
+	if s.ledger == nil {
+		return nil, common.NewServiceUnavailableError("ledger is not configured")
+	}
+	return s.ledger.ListAccountBalances(ctx, filter)
+}
+
+// GetLedgerEntries returns the journal entries recorded for a reference such
+// as a payment.
+func (s *Service) GetLedgerEntries(ctx context.Context, referenceType string, referenceID uuid.UUID) ([]*ledger.JournalEntry, error) {
+	if s.ledger == nil {
+		return nil, common.NewServiceUnavailableError("ledger is not configured")
+	}
+	return s.ledger.GetEntriesByReference(ctx, referenceType, referenceID)
+}
@@ -7,6 +7,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -1804,3 +1807,186 @@ func TestGetActionItems(t *testing.T) {
 		})
 	}
 }
+
+// ========================================
+// TESTS: Dead-letter queue
+// ========================================
+
+type mockDeadLetterQueue struct {
+	mock.Mock
+}
+
+func (m *mockDeadLetterQueue) ListDeadLetters(ctx context.Context, filter eventbus.DeadLetterFilter) ([]*eventbus.DeadLetterMessage, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*eventbus.DeadLetterMessage), args.Error(1)
+}
+
+func (m *mockDeadLetterQueue) GetDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error) {
+	args := m.Called(ctx, seq)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*eventbus.DeadLetterMessage), args.Error(1)
+}
+
+func (m *mockDeadLetterQueue) ReplayDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error) {
+	args := m.Called(ctx, seq)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*eventbus.DeadLetterMessage), args.Error(1)
+}
+
+func (m *mockDeadLetterQueue) DiscardDeadLetter(ctx context.Context, seq uint64) (*eventbus.DeadLetterMessage, error) {
+	args := m.Called(ctx, seq)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*eventbus.DeadLetterMessage), args.Error(1)
+}
+
+func newTestDeadLetter(t *testing.T, seq uint64) *eventbus.DeadLetterMessage {
+	t.Helper()
+	evt, err := eventbus.NewEvent("ride.requested", "rides-service", map[string]string{"ride_id": "r1"})
+	require.NoError(t, err)
+	return &eventbus.DeadLetterMessage{
+		Sequence: seq,
+		DeadLetter: eventbus.DeadLetter{
+			Consumer:   "notifications-rides",
+			Subject:    eventbus.SubjectRideRequested,
+			Event:      evt,
+			Error:      "smtp timeout",
+			Deliveries: 5,
+		},
+	}
+}
+
+func TestDeadLetters_NotConfigured(t *testing.T) {
+	svc := newTestService(new(mockRepo))
+	ctx := context.Background()
+
+	_, err := svc.ListDeadLetters(ctx, eventbus.DeadLetterFilter{})
+	var appErr *common.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 503, appErr.Code)
+
+	_, err = svc.ReplayDeadLetter(ctx, uuid.New(), 1)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 503, appErr.Code)
+}
+
+func TestReplayDeadLetter(t *testing.T) {
+	adminID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+
+	tests := []struct {
+		name       string
+		setupMocks func(t *testing.T, repo *mockRepo, dlq *mockDeadLetterQueue)
+		wantCode   int
+	}{
+		{
+			name: "success - replayed with audit log",
+			setupMocks: func(t *testing.T, repo *mockRepo, dlq *mockDeadLetterQueue) {
+				dl := newTestDeadLetter(t, 7)
+				dlq.On("ReplayDeadLetter", mock.Anything, uint64(7)).Return(dl, nil)
+				repo.On("InsertAuditLog", mock.Anything, adminID, "replay_dead_letter", "event", uuid.MustParse(dl.Event.ID), mock.MatchedBy(func(meta map[string]interface{}) bool {
+					return meta["dlq_sequence"] == uint64(7) && meta["consumer"] == "notifications-rides"
+				})).Return()
+			},
+		},
+		{
+			name: "error - not found",
+			setupMocks: func(t *testing.T, repo *mockRepo, dlq *mockDeadLetterQueue) {
+				dlq.On("ReplayDeadLetter", mock.Anything, uint64(7)).Return(nil, eventbus.ErrDeadLetterNotFound)
+			},
+			wantCode: 404,
+		},
+		{
+			name: "error - malformed event cannot be replayed",
+			setupMocks: func(t *testing.T, repo *mockRepo, dlq *mockDeadLetterQueue) {
+				dlq.On("ReplayDeadLetter", mock.Anything, uint64(7)).Return(nil, eventbus.ErrDeadLetterNotReplayable)
+			},
+			wantCode: 400,
+		},
+		{
+			name: "error - bus failure",
+			setupMocks: func(t *testing.T, repo *mockRepo, dlq *mockDeadLetterQueue) {
+				dlq.On("ReplayDeadLetter", mock.Anything, uint64(7)).Return(nil, errors.New("nats: timeout"))
+			},
+			wantCode: 500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(mockRepo)
+			dlq := new(mockDeadLetterQueue)
+			tt.setupMocks(t, repo, dlq)
+			svc := newTestService(repo)
+			svc.SetDeadLetterQueue(dlq)
+
+			msg, err := svc.ReplayDeadLetter(context.Background(), adminID, 7)
+
+			if tt.wantCode != 0 {
+				var appErr *common.AppError
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, tt.wantCode, appErr.Code)
+				assert.Nil(t, msg)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, uint64(7), msg.Sequence)
+			}
+
+			repo.AssertExpectations(t)
+			dlq.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDiscardDeadLetter_UndecodableEvent(t *testing.T) {
+	adminID := uuid.New()
+	repo := new(mockRepo)
+	dlq := new(mockDeadLetterQueue)
+	svc := newTestService(repo)
+	svc.SetDeadLetterQueue(dlq)
+
+	dl := &eventbus.DeadLetterMessage{
+		Sequence: 3,
+		DeadLetter: eventbus.DeadLetter{
+			Consumer: "payments-rides",
+			Subject:  eventbus.SubjectRideCompleted,
+			RawData:  []byte("{not json"),
+			Error:    "malformed event",
+		},
+	}
+	dlq.On("DiscardDeadLetter", mock.Anything, uint64(3)).Return(dl, nil)
+	// No event ID to reference, so the audit entry targets the nil UUID
+	repo.On("InsertAuditLog", mock.Anything, adminID, "discard_dead_letter", "event", uuid.Nil, mock.Anything).Return()
+
+	msg, err := svc.DiscardDeadLetter(context.Background(), adminID, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "payments-rides", msg.Consumer)
+
+	repo.AssertExpectations(t)
+	dlq.AssertExpectations(t)
+}
+
+func TestLedgerReporting_NotConfigured(t *testing.T) {
+	svc := newTestService(new(mockRepo))
+	ctx := context.Background()
+
+	_, err := svc.GetTrialBalance(ctx, "USD", time.Time{})
+	var appErr *common.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 503, appErr.Code)
+
+	_, err = svc.GetLedgerAccountBalances(ctx, ledger.AccountFilter{})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 503, appErr.Code)
+
+	_, err = svc.GetLedgerEntries(ctx, "payment", uuid.New())
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 503, appErr.Code)
+}
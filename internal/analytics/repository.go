@@ -269,7 +269,7 @@ func (r *Repository) GetDashboardMetrics(ctx context.Context) (*DashboardMetrics
 	query1 := `
 		SELECT
 			COUNT(*) as total_rides,
-			COUNT(*) FILTER (WHERE status IN ('requested', 'accepted', 'in_progress')) as active_rides,
+			COUNT(*) FILTER (WHERE status IN ('requested', 'accepted', 'driver_arrived', 'in_progress')) as active_rides,
 			COUNT(*) FILTER (WHERE status = 'completed' AND completed_at >= $1 AND completed_at < $2) as completed_today,
 			COALESCE(SUM(final_fare) FILTER (WHERE status = 'completed' AND completed_at >= $1 AND completed_at < $2), 0) as revenue_today,
 			COALESCE(AVG(rating) FILTER (WHERE rating IS NOT NULL), 0) as avg_rating
@@ -675,7 +675,7 @@ func (r *Repository) GetDriverAnalytics(ctx context.Context, startDate, endDate
 	query2 := `
 		SELECT
 			COALESCE(COUNT(*) FILTER (WHERE status = 'cancelled')::float / NULLIF(COUNT(*), 0) * 100, 0) as cancellation_rate,
-			COALESCE(COUNT(*) FILTER (WHERE status IN ('accepted', 'in_progress', 'completed'))::float / NULLIF(COUNT(*), 0) * 100, 0) as acceptance_rate
+			COALESCE(COUNT(*) FILTER (WHERE status IN ('accepted', 'driver_arrived', 'in_progress', 'completed'))::float / NULLIF(COUNT(*), 0) * 100, 0) as acceptance_rate
 		FROM rides
 		WHERE driver_id IS NOT NULL
 		  AND created_at >= $1
@@ -816,7 +816,7 @@ func (r *Repository) GetTopDriversDetailed(ctx context.Context, startDate, endDa
 			COUNT(r.id) as total_rides,
 			COALESCE(SUM(r.final_fare), 0) as total_revenue,
 			COALESCE(d.rating, 0) as avg_rating,
-			COALESCE(COUNT(*) FILTER (WHERE r.status IN ('accepted', 'in_progress', 'completed'))::float / NULLIF(COUNT(*), 0) * 100, 0) as acceptance_rate,
+			COALESCE(COUNT(*) FILTER (WHERE r.status IN ('accepted', 'driver_arrived', 'in_progress', 'completed'))::float / NULLIF(COUNT(*), 0) * 100, 0) as acceptance_rate,
 			COALESCE(d.total_rides::float * 0.5, 0) as online_hours
 		FROM drivers d
 		JOIN users u ON d.user_id = u.id
@@ -8,6 +8,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/internal/rides"
 	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
@@ -21,6 +22,8 @@ var (
 	ErrCancellationNotFound  = errors.New("cancellation record not found")
 	ErrCreateRecordFailed    = errors.New("failed to create cancellation record")
 	ErrUpdateRideFailed      = errors.New("failed to update ride status")
+	ErrDriverNotArrived      = errors.New("driver has not arrived at pickup")
+	ErrNoShowTooEarly        = errors.New("rider no-show reported before the no-show wait elapsed")
 )
 
 // RepositoryInterface defines the interface for the cancellation repository
@@ -60,19 +63,27 @@ type RideGetter interface {
 	GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ride, error)
 }
 
+// RideCanceller cancels a ride and publishes the cancellation, returning
+// false when the ride was completed or cancelled first
+type RideCanceller interface {
+	CancelRideWithPolicy(ctx context.Context, ride *models.Ride, cancelledBy, reason string, feeWaived bool, cancelledAt time.Time) (bool, error)
+}
+
 // TestableService is a service wrapper that uses interfaces for testability
 type TestableService struct {
 	repo       RepositoryInterface
 	db         DBInterface
 	rideGetter RideGetter
+	rides      RideCanceller
 }
 
 // NewTestableService creates a new testable cancellation service
-func NewTestableService(repo RepositoryInterface, db DBInterface, rideGetter RideGetter) *TestableService {
+func NewTestableService(repo RepositoryInterface, db DBInterface, rideGetter RideGetter, rides RideCanceller) *TestableService {
 	return &TestableService{
 		repo:       repo,
 		db:         db,
 		rideGetter: rideGetter,
+		rides:      rides,
 	}
 }
 
@@ -89,7 +100,7 @@ func (s *TestableService) PreviewCancellation(ctx context.Context, rideID, userI
 		return nil, ErrNotAuthorized
 	}
 
-	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
+	if err := rides.ValidateTransition(ride.Status, models.RideStatusCancelled); err != nil {
 		return nil, ErrRideAlreadyFinished
 	}
 
@@ -145,7 +156,7 @@ func (s *TestableService) CancelRide(ctx context.Context, rideID, userID uuid.UU
 		return nil, ErrNotAuthorizedToCancel
 	}
 
-	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
+	if err := rides.ValidateTransition(ride.Status, models.RideStatusCancelled); err != nil {
 		return nil, ErrRideAlreadyFinished
 	}
 
@@ -164,7 +175,24 @@ func (s *TestableService) CancelRide(ctx context.Context, rideID, userID uuid.UU
 		minutesSinceAccept = &m
 	}
 
-	feeResult := s.calculateFee(ctx, userID, cancelledBy, minutesSinceRequest, ride, policy)
+	var feeResult *CancellationFeeResult
+	if cancelledBy == CancelledByDriver && req.ReasonCode == ReasonDriverRiderNoShow {
+		feeResult, err = riderNoShowFee(ride, policy, now, 5.0)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		feeResult = s.calculateFee(ctx, userID, cancelledBy, minutesSinceRequest, ride, policy)
+	}
+
+	// Cancel the ride first so a lost race leaves no cancellation record
+	cancelled, err := s.rides.CancelRideWithPolicy(ctx, ride, string(cancelledBy), string(req.ReasonCode), feeResult.FeeWaived, now)
+	if err != nil {
+		return nil, ErrUpdateRideFailed
+	}
+	if !cancelled {
+		return nil, ErrRideAlreadyFinished
+	}
 
 	record := &CancellationRecord{
 		ID:                  uuid.New(),
@@ -190,16 +218,6 @@ func (s *TestableService) CancelRide(ctx context.Context, rideID, userID uuid.UU
 		return nil, ErrCreateRecordFailed
 	}
 
-	// Update ride status
-	_, err = s.db.Exec(ctx, `
-		UPDATE rides SET status = $1, cancelled_at = $2, cancellation_reason = $3, updated_at = $4
-		WHERE id = $5`,
-		models.RideStatusCancelled, now, string(req.ReasonCode), now, rideID,
-	)
-	if err != nil {
-		return nil, ErrUpdateRideFailed
-	}
-
 	var waiverStr *string
 	if feeResult.WaiverReason != nil {
 		ws := string(*feeResult.WaiverReason)
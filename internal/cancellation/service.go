@@ -2,6 +2,7 @@ package cancellation
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/richxcame/ride-hailing/internal/pricing"
+	"github.com/richxcame/ride-hailing/internal/rides"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/models"
 )
@@ -26,13 +28,15 @@ var defaultPolicy = CancellationPolicy{
 
 // Service handles cancellation business logic
 type Service struct {
-	repo *Repository
-	db   *pgxpool.Pool
+	repo  *Repository
+	db    *pgxpool.Pool
+	rides RideCanceller
 }
 
-// NewService creates a new cancellation service
-func NewService(repo *Repository, db *pgxpool.Pool) *Service {
-	return &Service{repo: repo, db: db}
+// NewService creates a new cancellation service. Rides are cancelled through
+// rides, so the cancellation is published with the status change.
+func NewService(repo *Repository, db *pgxpool.Pool, rides RideCanceller) *Service {
+	return &Service{repo: repo, db: db, rides: rides}
 }
 
 // PreviewCancellation shows what would happen if the user cancels
@@ -48,8 +52,8 @@ func (s *Service) PreviewCancellation(ctx context.Context, rideID, userID uuid.U
 		return nil, common.NewForbiddenError("not authorized for this ride")
 	}
 
-	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
-		return nil, common.NewBadRequestError("ride already completed or cancelled", nil)
+	if err := rides.ValidateTransition(ride.Status, models.RideStatusCancelled); err != nil {
+		return nil, common.NewBadRequestError("ride already completed or cancelled", err)
 	}
 
 	cancelledBy := CancelledByRider
@@ -104,8 +108,8 @@ func (s *Service) CancelRide(ctx context.Context, rideID, userID uuid.UUID, req
 		return nil, common.NewForbiddenError("not authorized to cancel this ride")
 	}
 
-	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
-		return nil, common.NewBadRequestError("ride already completed or cancelled", nil)
+	if err := rides.ValidateTransition(ride.Status, models.RideStatusCancelled); err != nil {
+		return nil, common.NewBadRequestError("ride already completed or cancelled", err)
 	}
 
 	cancelledBy := CancelledByRider
@@ -123,7 +127,30 @@ func (s *Service) CancelRide(ctx context.Context, rideID, userID uuid.UUID, req
 		minutesSinceAccept = &m
 	}
 
-	feeResult := s.calculateFee(ctx, userID, cancelledBy, minutesSinceRequest, ride, policy)
+	var feeResult *CancellationFeeResult
+	if cancelledBy == CancelledByDriver && req.ReasonCode == ReasonDriverRiderNoShow {
+		fee := pricing.GetCancellationFee(&pricing.DefaultPricing, minutesSinceRequest, ride.EstimatedFare)
+		feeResult, err = riderNoShowFee(ride, policy, now, fee)
+		if errors.Is(err, ErrNoShowTooEarly) {
+			return nil, common.NewBadRequestError(
+				fmt.Sprintf("rider no-show can be reported %d minutes after arriving at pickup", policy.RiderNoShowMinutes), err)
+		}
+		if err != nil {
+			return nil, common.NewBadRequestError("rider no-show can only be reported after arriving at pickup", err)
+		}
+	} else {
+		feeResult = s.calculateFee(ctx, userID, cancelledBy, minutesSinceRequest, ride, policy)
+	}
+
+	// Cancel the ride first; the status guard makes a concurrent start or
+	// completion win without leaving a stray cancellation record.
+	cancelled, err := s.rides.CancelRideWithPolicy(ctx, ride, string(cancelledBy), string(req.ReasonCode), feeResult.FeeWaived, now)
+	if err != nil {
+		return nil, fmt.Errorf("cancel ride: %w", err)
+	}
+	if !cancelled {
+		return nil, common.NewConflictError("ride already completed or cancelled")
+	}
 
 	record := &CancellationRecord{
 		ID:                  uuid.New(),
@@ -149,16 +176,6 @@ func (s *Service) CancelRide(ctx context.Context, rideID, userID uuid.UUID, req
 		return nil, fmt.Errorf("create cancellation record: %w", err)
 	}
 
-	// Update ride status
-	_, err = s.db.Exec(ctx, `
-		UPDATE rides SET status = $1, cancelled_at = $2, cancellation_reason = $3, updated_at = $4
-		WHERE id = $5`,
-		models.RideStatusCancelled, now, string(req.ReasonCode), now, rideID,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("update ride status: %w", err)
-	}
-
 	var waiverStr *string
 	if feeResult.WaiverReason != nil {
 		ws := string(*feeResult.WaiverReason)
@@ -341,6 +358,24 @@ func (s *Service) calculateFee(ctx context.Context, userID uuid.UUID, cancelledB
 	}
 }
 
+// riderNoShowFee charges the rider when the driver cancels because the rider
+// did not show up. The driver must be at pickup and have waited at least the
+// policy's rider no-show time since the recorded arrival.
+func riderNoShowFee(ride *models.Ride, policy *CancellationPolicy, now time.Time, fee float64) (*CancellationFeeResult, error) {
+	if ride.Status != models.RideStatusDriverArrived || ride.ArrivedAt == nil {
+		return nil, ErrDriverNotArrived
+	}
+	waited := now.Sub(*ride.ArrivedAt).Minutes()
+	if waited < float64(policy.RiderNoShowMinutes) {
+		return nil, ErrNoShowTooEarly
+	}
+	return &CancellationFeeResult{
+		FeeAmount:   fee,
+		FeeWaived:   false,
+		Explanation: fmt.Sprintf("Rider no-show fee of %.2f applied (driver waited %d minutes at pickup)", fee, int(waited)),
+	}, nil
+}
+
 // getPolicy retrieves the active policy, falling back to defaults
 func (s *Service) getPolicy(ctx context.Context) *CancellationPolicy {
 	policy, err := s.repo.GetDefaultPolicy(ctx)
@@ -356,12 +391,12 @@ func (s *Service) getRide(ctx context.Context, rideID uuid.UUID) (*models.Ride,
 	err := s.db.QueryRow(ctx, `
 		SELECT id, rider_id, driver_id, status,
 			pickup_latitude, pickup_longitude,
-			estimated_fare, created_at, accepted_at
+			estimated_fare, created_at, accepted_at, arrived_at
 		FROM rides WHERE id = $1`, rideID,
 	).Scan(
 		&ride.ID, &ride.RiderID, &ride.DriverID, &ride.Status,
 		&ride.PickupLatitude, &ride.PickupLongitude,
-		&ride.EstimatedFare, &ride.CreatedAt, &ride.AcceptedAt,
+		&ride.EstimatedFare, &ride.CreatedAt, &ride.AcceptedAt, &ride.ArrivedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -1526,6 +1526,18 @@ func (m *MockRideGetter) GetRide(ctx context.Context, rideID uuid.UUID) (*models
 	return nil, ErrRideNotFound
 }
 
+// MockRideCanceller implements RideCanceller for testing
+type MockRideCanceller struct {
+	CancelFunc func(ctx context.Context, ride *models.Ride, cancelledBy, reason string, feeWaived bool, cancelledAt time.Time) (bool, error)
+}
+
+func (m *MockRideCanceller) CancelRideWithPolicy(ctx context.Context, ride *models.Ride, cancelledBy, reason string, feeWaived bool, cancelledAt time.Time) (bool, error) {
+	if m.CancelFunc != nil {
+		return m.CancelFunc(ctx, ride, cancelledBy, reason, feeWaived, cancelledAt)
+	}
+	return true, nil
+}
+
 // ============================================================================
 // TESTABLE SERVICE TESTS
 // ============================================================================
@@ -1535,16 +1547,17 @@ func TestNewTestableService(t *testing.T) {
 	db := &MockDB{}
 	rideGetter := &MockRideGetter{}
 
-	svc := NewTestableService(repo, db, rideGetter)
+	svc := NewTestableService(repo, db, rideGetter, &MockRideCanceller{})
 
 	assert.NotNil(t, svc)
 	assert.NotNil(t, svc.repo)
 	assert.NotNil(t, svc.db)
 	assert.NotNil(t, svc.rideGetter)
+	assert.NotNil(t, svc.rides)
 }
 
 func TestTestableService_GetCancellationReasons_Rider(t *testing.T) {
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	resp := svc.GetCancellationReasons(false)
 
@@ -1554,7 +1567,7 @@ func TestTestableService_GetCancellationReasons_Rider(t *testing.T) {
 }
 
 func TestTestableService_GetCancellationReasons_Driver(t *testing.T) {
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	resp := svc.GetCancellationReasons(true)
 
@@ -1569,7 +1582,7 @@ func TestTestableService_PreviewCancellation_RideNotFound(t *testing.T) {
 			return nil, ErrRideNotFound
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.PreviewCancellation(context.Background(), uuid.New(), uuid.New())
 
@@ -1593,7 +1606,7 @@ func TestTestableService_PreviewCancellation_NotAuthorized(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.PreviewCancellation(context.Background(), uuid.New(), userID)
 
@@ -1614,7 +1627,7 @@ func TestTestableService_PreviewCancellation_RideAlreadyFinished(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.PreviewCancellation(context.Background(), uuid.New(), riderID)
 
@@ -1635,7 +1648,7 @@ func TestTestableService_PreviewCancellation_RideCancelled(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.PreviewCancellation(context.Background(), uuid.New(), riderID)
 
@@ -1661,7 +1674,7 @@ func TestTestableService_PreviewCancellation_Success_Rider(t *testing.T) {
 			return 0, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	resp, err := svc.PreviewCancellation(context.Background(), uuid.New(), riderID)
 
@@ -1691,7 +1704,7 @@ func TestTestableService_PreviewCancellation_Success_Driver(t *testing.T) {
 			return 1, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	resp, err := svc.PreviewCancellation(context.Background(), uuid.New(), driverID)
 
@@ -1708,7 +1721,7 @@ func TestTestableService_CancelRide_RideNotFound(t *testing.T) {
 			return nil, ErrRideNotFound
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.CancelRide(context.Background(), uuid.New(), uuid.New(), &CancelRideRequest{
 		ReasonCode: ReasonRiderChangedMind,
@@ -1734,7 +1747,7 @@ func TestTestableService_CancelRide_NotAuthorized(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.CancelRide(context.Background(), uuid.New(), userID, &CancelRideRequest{
 		ReasonCode: ReasonRiderChangedMind,
@@ -1757,7 +1770,7 @@ func TestTestableService_CancelRide_AlreadyCompleted(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.CancelRide(context.Background(), uuid.New(), riderID, &CancelRideRequest{
 		ReasonCode: ReasonRiderChangedMind,
@@ -1785,7 +1798,7 @@ func TestTestableService_CancelRide_CreateRecordFailed(t *testing.T) {
 			return errors.New("database error")
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.CancelRide(context.Background(), uuid.New(), riderID, &CancelRideRequest{
 		ReasonCode: ReasonRiderChangedMind,
@@ -1813,12 +1826,12 @@ func TestTestableService_CancelRide_UpdateRideFailed(t *testing.T) {
 			return nil
 		},
 	}
-	db := &MockDB{
-		ExecFunc: func(ctx context.Context, sql string, arguments ...interface{}) (DBCommandTag, error) {
-			return nil, errors.New("update failed")
+	canceller := &MockRideCanceller{
+		CancelFunc: func(ctx context.Context, ride *models.Ride, cancelledBy, reason string, feeWaived bool, cancelledAt time.Time) (bool, error) {
+			return false, errors.New("update failed")
 		},
 	}
-	svc := NewTestableService(repo, db, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, canceller)
 
 	_, err := svc.CancelRide(context.Background(), uuid.New(), riderID, &CancelRideRequest{
 		ReasonCode: ReasonRiderChangedMind,
@@ -1850,12 +1863,16 @@ func TestTestableService_CancelRide_Success_Rider(t *testing.T) {
 			return nil
 		},
 	}
-	db := &MockDB{
-		ExecFunc: func(ctx context.Context, sql string, arguments ...interface{}) (DBCommandTag, error) {
-			return MockCommandTag{rowsAffected: 1}, nil
+	var cancelled *models.Ride
+	var cancelledBy string
+	var feeWaived bool
+	canceller := &MockRideCanceller{
+		CancelFunc: func(ctx context.Context, ride *models.Ride, by, reason string, waived bool, cancelledAt time.Time) (bool, error) {
+			cancelled, cancelledBy, feeWaived = ride, by, waived
+			return true, nil
 		},
 	}
-	svc := NewTestableService(repo, db, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, canceller)
 
 	resp, err := svc.CancelRide(context.Background(), rideID, riderID, &CancelRideRequest{
 		ReasonCode: ReasonRiderChangedMind,
@@ -1866,6 +1883,12 @@ func TestTestableService_CancelRide_Success_Rider(t *testing.T) {
 	assert.Equal(t, rideID, resp.RideID)
 	assert.Equal(t, "rider", resp.CancelledBy)
 	assert.True(t, resp.FeeWaived)
+
+	// The ride is cancelled through the rides service with the policy outcome
+	require.NotNil(t, cancelled)
+	assert.Equal(t, rideID, cancelled.ID)
+	assert.Equal(t, "rider", cancelledBy)
+	assert.True(t, feeWaived)
 }
 
 func TestTestableService_CancelRide_Success_Driver(t *testing.T) {
@@ -1893,10 +1916,10 @@ func TestTestableService_CancelRide_Success_Driver(t *testing.T) {
 		},
 	}
 	db := &MockDB{}
-	svc := NewTestableService(repo, db, rideGetter)
+	svc := NewTestableService(repo, db, rideGetter, &MockRideCanceller{})
 
 	resp, err := svc.CancelRide(context.Background(), rideID, driverID, &CancelRideRequest{
-		ReasonCode: ReasonDriverRiderNoShow,
+		ReasonCode: ReasonDriverVehicleIssue,
 	})
 
 	assert.NoError(t, err)
@@ -1907,13 +1930,99 @@ func TestTestableService_CancelRide_Success_Driver(t *testing.T) {
 	assert.Equal(t, string(WaiverDriverFault), *resp.WaiverReason)
 }
 
+func TestTestableService_CancelRide_RiderNoShow(t *testing.T) {
+	riderID := uuid.New()
+	driverID := uuid.New()
+	justArrived := time.Now().Add(-1 * time.Minute)
+	waitedLongEnough := time.Now().Add(-time.Duration(defaultPolicy.RiderNoShowMinutes+1) * time.Minute)
+
+	tests := []struct {
+		name      string
+		status    models.RideStatus
+		arrivedAt *time.Time
+		wantErr   error
+	}{
+		{"driver not arrived", models.RideStatusAccepted, nil, ErrDriverNotArrived},
+		{"reported too early", models.RideStatusDriverArrived, &justArrived, ErrNoShowTooEarly},
+		{"rider charged after no-show wait", models.RideStatusDriverArrived, &waitedLongEnough, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rideGetter := &MockRideGetter{
+				GetRideFunc: func(ctx context.Context, id uuid.UUID) (*models.Ride, error) {
+					return &models.Ride{
+						ID:         id,
+						RiderID:    riderID,
+						DriverID:   &driverID,
+						Status:     tt.status,
+						ArrivedAt:  tt.arrivedAt,
+						CreatedAt:  time.Now().Add(-15 * time.Minute),
+						AcceptedAt: &justArrived,
+					}, nil
+				},
+			}
+			repo := &MockRepository{
+				CreateCancellationRecordFunc: func(ctx context.Context, rec *CancellationRecord) error {
+					return nil
+				},
+			}
+			svc := NewTestableService(repo, &MockDB{}, rideGetter, &MockRideCanceller{})
+
+			resp, err := svc.CancelRide(context.Background(), uuid.New(), driverID, &CancelRideRequest{
+				ReasonCode: ReasonDriverRiderNoShow,
+			})
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "driver", resp.CancelledBy)
+			assert.False(t, resp.FeeWaived)
+			assert.Greater(t, resp.FeeAmount, 0.0)
+		})
+	}
+}
+
+func TestTestableService_CancelRide_LostRace(t *testing.T) {
+	riderID := uuid.New()
+	recordCreated := false
+
+	rideGetter := &MockRideGetter{
+		GetRideFunc: func(ctx context.Context, id uuid.UUID) (*models.Ride, error) {
+			return &models.Ride{ID: id, RiderID: riderID, Status: models.RideStatusAccepted, CreatedAt: time.Now()}, nil
+		},
+	}
+	repo := &MockRepository{
+		CreateCancellationRecordFunc: func(ctx context.Context, rec *CancellationRecord) error {
+			recordCreated = true
+			return nil
+		},
+	}
+	canceller := &MockRideCanceller{
+		CancelFunc: func(ctx context.Context, ride *models.Ride, cancelledBy, reason string, feeWaived bool, cancelledAt time.Time) (bool, error) {
+			return false, nil
+		},
+	}
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, canceller)
+
+	_, err := svc.CancelRide(context.Background(), uuid.New(), riderID, &CancelRideRequest{
+		ReasonCode: ReasonRiderChangedMind,
+	})
+
+	assert.ErrorIs(t, err, ErrRideAlreadyFinished)
+	assert.False(t, recordCreated)
+}
+
 func TestTestableService_GetCancellationDetails_RideNotFound(t *testing.T) {
 	rideGetter := &MockRideGetter{
 		GetRideFunc: func(ctx context.Context, rideID uuid.UUID) (*models.Ride, error) {
 			return nil, ErrRideNotFound
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.GetCancellationDetails(context.Background(), uuid.New(), uuid.New())
 
@@ -1936,7 +2045,7 @@ func TestTestableService_GetCancellationDetails_NotAuthorized(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter)
+	svc := NewTestableService(&MockRepository{}, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.GetCancellationDetails(context.Background(), uuid.New(), userID)
 
@@ -1961,7 +2070,7 @@ func TestTestableService_GetCancellationDetails_NotFound(t *testing.T) {
 			return nil, pgx.ErrNoRows
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	_, err := svc.GetCancellationDetails(context.Background(), uuid.New(), riderID)
 
@@ -1996,7 +2105,7 @@ func TestTestableService_GetCancellationDetails_Success(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, rideGetter)
+	svc := NewTestableService(repo, &MockDB{}, rideGetter, &MockRideCanceller{})
 
 	rec, err := svc.GetCancellationDetails(context.Background(), rideID, riderID)
 
@@ -2018,7 +2127,7 @@ func TestTestableService_GetMyCancellationStats_Success(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	stats, err := svc.GetMyCancellationStats(context.Background(), userID)
 
@@ -2043,7 +2152,7 @@ func TestTestableService_GetMyCancellationStats_Warned(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	stats, err := svc.GetMyCancellationStats(context.Background(), userID)
 
@@ -2064,7 +2173,7 @@ func TestTestableService_GetMyCancellationStats_Penalized(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	stats, err := svc.GetMyCancellationStats(context.Background(), userID)
 
@@ -2081,7 +2190,7 @@ func TestTestableService_GetMyCancellationStats_Error(t *testing.T) {
 			return nil, errors.New("database error")
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	_, err := svc.GetMyCancellationStats(context.Background(), userID)
 
@@ -2100,7 +2209,7 @@ func TestTestableService_GetMyCancellationHistory_Success(t *testing.T) {
 			return records, 2, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	result, total, err := svc.GetMyCancellationHistory(context.Background(), userID, 20, 0)
 
@@ -2120,7 +2229,7 @@ func TestTestableService_GetMyCancellationHistory_DefaultPagination(t *testing.T
 			return []CancellationRecord{}, 0, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	// Test with invalid limit (0 defaults to 20)
 	svc.GetMyCancellationHistory(context.Background(), userID, 0, 10)
@@ -2144,7 +2253,7 @@ func TestTestableService_WaiveFee_NotFound(t *testing.T) {
 			return nil, pgx.ErrNoRows
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	err := svc.WaiveFee(context.Background(), uuid.New(), "test reason")
 
@@ -2167,7 +2276,7 @@ func TestTestableService_WaiveFee_Success(t *testing.T) {
 			return nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	err := svc.WaiveFee(context.Background(), cancellationID, "customer complaint")
 
@@ -2190,7 +2299,7 @@ func TestTestableService_GetCancellationStats_Success(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	stats, err := svc.GetCancellationStats(context.Background(), from, to)
 
@@ -2210,7 +2319,7 @@ func TestTestableService_GetUserCancellationStats_Success(t *testing.T) {
 			}, nil
 		},
 	}
-	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{})
+	svc := NewTestableService(repo, &MockDB{}, &MockRideGetter{}, &MockRideCanceller{})
 
 	stats, err := svc.GetUserCancellationStats(context.Background(), userID)
 
@@ -215,7 +215,7 @@ func (r *Repository) GetActiveConversations(ctx context.Context, userID uuid.UUI
 		FROM chat_messages cm
 		JOIN rides r ON r.id = cm.ride_id
 		WHERE (r.rider_id = $1 OR r.driver_id = $1)
-			AND r.status IN ('accepted', 'in_progress')
+			AND r.status IN ('accepted', 'driver_arrived', 'in_progress')
 		ORDER BY cm.ride_id`,
 		userID,
 	)
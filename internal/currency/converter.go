@@ -3,6 +3,9 @@ package currency
 import (
 	"fmt"
 	"math"
+	"strings"
+
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 // Converter handles currency conversion calculations
@@ -30,6 +33,30 @@ func (c *Converter) ConvertInverse(amount float64, rate *ExchangeRate, roundingM
 	return c.Round(converted, roundingMode, decimalPlaces)
 }
 
+// ConvertMoney converts a minor-unit amount using the given rate. Exponents of
+// both currencies come from pkg/money, so e.g. USD cents convert to whole JPY.
+func (c *Converter) ConvertMoney(amount money.Money, rate *ExchangeRate, roundingMode RoundingMode) (money.Money, error) {
+	if !strings.EqualFold(amount.Currency, rate.FromCurrency) {
+		return money.Money{}, fmt.Errorf("%w: amount is %s, rate is from %s", money.ErrCurrencyMismatch, amount.Currency, rate.FromCurrency)
+	}
+	return amount.Convert(rate.ToCurrency, rate.Rate, toMoneyRounding(roundingMode)), nil
+}
+
+// toMoneyRounding maps a RoundingMode onto pkg/money rounding. Minor units
+// are always whole, so RoundingModeNone falls back to standard rounding.
+func toMoneyRounding(mode RoundingMode) money.RoundingMode {
+	switch mode {
+	case RoundingModeBankers:
+		return money.RoundHalfEven
+	case RoundingModeFloor:
+		return money.RoundDown
+	case RoundingModeCeiling:
+		return money.RoundUp
+	default:
+		return money.RoundHalfUp
+	}
+}
+
 // Round rounds an amount according to the specified mode and decimal places
 func (c *Converter) Round(amount float64, mode RoundingMode, decimalPlaces int) float64 {
 	if decimalPlaces < 0 {
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 // Service handles currency business logic
@@ -274,12 +275,40 @@ func (s *Service) GetAllRatesFromBase(ctx context.Context) ([]*ExchangeRate, err
 
 // CreateCurrency creates a new currency
 func (s *Service) CreateCurrency(ctx context.Context, currency *Currency) error {
-	return s.repo.CreateCurrency(ctx, currency)
+	if err := s.repo.CreateCurrency(ctx, currency); err != nil {
+		return err
+	}
+	money.RegisterCurrency(currency.Code, currency.DecimalPlaces)
+	return nil
 }
 
 // UpdateCurrency updates a currency
 func (s *Service) UpdateCurrency(ctx context.Context, currency *Currency) error {
-	return s.repo.UpdateCurrency(ctx, currency)
+	if err := s.repo.UpdateCurrency(ctx, currency); err != nil {
+		return err
+	}
+	money.RegisterCurrency(currency.Code, currency.DecimalPlaces)
+	return nil
+}
+
+// SyncMoneyExponents registers the decimal places of all active currencies
+// with pkg/money so minor-unit amounts follow the currencies table.
+func (s *Service) SyncMoneyExponents(ctx context.Context) error {
+	return LoadMoneyExponents(ctx, s.repo)
+}
+
+// LoadMoneyExponents registers the decimal places of all active currencies
+// with pkg/money. Services that store minor units without running a currency
+// Service call this at startup.
+func LoadMoneyExponents(ctx context.Context, repo RepositoryInterface) error {
+	currencies, err := repo.GetActiveCurrencies(ctx)
+	if err != nil {
+		return fmt.Errorf("load currencies: %w", err)
+	}
+	for _, c := range currencies {
+		money.RegisterCurrency(c.Code, c.DecimalPlaces)
+	}
+	return nil
 }
 
 // ValidateConversion validates that a conversion can be performed
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -2357,3 +2358,49 @@ func TestGetExchangeRate_FallbackScenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncMoneyExponents(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetActiveCurrencies", ctx).Return([]*Currency{
+		{Code: CurrencyUSD, DecimalPlaces: 2},
+		{Code: "XTS", DecimalPlaces: 3},
+	}, nil)
+
+	require.NoError(t, service.SyncMoneyExponents(ctx))
+	t.Cleanup(func() { money.RegisterCurrency("XTS", money.DefaultExponent) })
+
+	assert.Equal(t, 3, money.Exponent("XTS"))
+	assert.Equal(t, int64(1235), money.FromMajor(1.2345, "XTS").Amount)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSyncMoneyExponents_Error(t *testing.T) {
+	mockRepo := new(MockRepository)
+	service := NewService(mockRepo, CurrencyUSD)
+	ctx := context.Background()
+
+	mockRepo.On("GetActiveCurrencies", ctx).Return(nil, errors.New("db down"))
+
+	err := service.SyncMoneyExponents(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "db down")
+}
+
+func TestConverter_ConvertMoney(t *testing.T) {
+	converter := NewConverter(CurrencyUSD)
+	rate := &ExchangeRate{FromCurrency: CurrencyUSD, ToCurrency: "JPY", Rate: 150.25}
+
+	converted, err := converter.ConvertMoney(money.New(1000, CurrencyUSD), rate, RoundingModeBankers)
+	require.NoError(t, err)
+	assert.Equal(t, money.New(1502, "JPY"), converted)
+
+	converted, err = converter.ConvertMoney(money.New(1000, CurrencyUSD), rate, RoundingModeStandard)
+	require.NoError(t, err)
+	assert.Equal(t, money.New(1503, "JPY"), converted)
+
+	_, err = converter.ConvertMoney(money.New(1000, CurrencyEUR), rate, RoundingModeStandard)
+	assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+}
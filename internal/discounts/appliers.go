@@ -0,0 +1,129 @@
+package discounts
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
+)
+
+// The services behind the appliers keep major-unit amounts, so each applier
+// converts at its boundary and the orchestrator works in minor units only.
+
+// PromoRedeemer is implemented by promos.Service
+type PromoRedeemer interface {
+	RedeemPromoCode(ctx context.Context, promoCodeID, userID, rideID uuid.UUID, paymentMethod string, originalAmount, discount float64) (float64, error)
+	ReleasePromoCodeUse(ctx context.Context, rideID uuid.UUID) error
+}
+
+type promoApplier struct {
+	promos PromoRedeemer
+}
+
+// NewPromoApplier applies the promo discount validated when the ride was
+// requested. The code's use is recorded here, against its usage limits and
+// budgets, so the discount may come out lower or not apply at all.
+func NewPromoApplier(promos PromoRedeemer) Applier {
+	return &promoApplier{promos: promos}
+}
+
+func (a *promoApplier) Apply(ctx context.Context, c *Checkout, limit money.Money) (*Applied, error) {
+	if c.PromoCodeID == nil || !c.PromoDiscount.IsPositive() {
+		return nil, nil
+	}
+	granted, err := a.promos.RedeemPromoCode(ctx, *c.PromoCodeID, c.RiderID, c.RideID, c.PaymentMethod, c.Fare.Major(), minMoney(c.PromoDiscount, limit).Major())
+	if err != nil {
+		return nil, err
+	}
+	return &Applied{Amount: money.FromMajor(granted, c.Fare.Currency), Label: "Promo code", ReferenceID: c.PromoCodeID}, nil
+}
+
+func (a *promoApplier) Release(ctx context.Context, c *Checkout, _ LineItem) error {
+	return a.promos.ReleasePromoCodeUse(ctx, c.RideID)
+}
+
+// SubscriptionDiscounter is implemented by subscriptions.Service
+type SubscriptionDiscounter interface {
+	ApplySubscriptionDiscountUpTo(ctx context.Context, userID, rideID uuid.UUID, originalFare float64, rideType string, maxSavings float64) (float64, error)
+	ReleaseRideDiscount(ctx context.Context, rideID uuid.UUID) error
+}
+
+type subscriptionApplier struct {
+	subscriptions SubscriptionDiscounter
+}
+
+// NewSubscriptionApplier applies the rider's subscription plan discount
+func NewSubscriptionApplier(subscriptions SubscriptionDiscounter) Applier {
+	return &subscriptionApplier{subscriptions: subscriptions}
+}
+
+func (a *subscriptionApplier) Apply(ctx context.Context, c *Checkout, limit money.Money) (*Applied, error) {
+	discounted, err := a.subscriptions.ApplySubscriptionDiscountUpTo(ctx, c.RiderID, c.RideID, c.Fare.Major(), c.RideType, limit.Major())
+	if err != nil {
+		return nil, err
+	}
+	saved := c.Fare.Amount - money.FromMajor(discounted, c.Fare.Currency).Amount
+	return &Applied{Amount: money.New(saved, c.Fare.Currency), Label: "Subscription"}, nil
+}
+
+func (a *subscriptionApplier) Release(ctx context.Context, c *Checkout, _ LineItem) error {
+	return a.subscriptions.ReleaseRideDiscount(ctx, c.RideID)
+}
+
+// LoyaltyCreditRedeemer is implemented by loyalty.Service
+type LoyaltyCreditRedeemer interface {
+	UseRideCredit(ctx context.Context, riderID, rideID uuid.UUID, maxAmount float64) (float64, *uuid.UUID, error)
+	ReleaseRideCredit(ctx context.Context, redemptionID, rideID uuid.UUID) error
+}
+
+type loyaltyApplier struct {
+	loyalty LoyaltyCreditRedeemer
+}
+
+// NewLoyaltyApplier applies a discount or free ride the rider redeemed
+// loyalty points for
+func NewLoyaltyApplier(loyalty LoyaltyCreditRedeemer) Applier {
+	return &loyaltyApplier{loyalty: loyalty}
+}
+
+func (a *loyaltyApplier) Apply(ctx context.Context, c *Checkout, limit money.Money) (*Applied, error) {
+	amount, redemptionID, err := a.loyalty.UseRideCredit(ctx, c.RiderID, c.RideID, limit.Major())
+	if err != nil {
+		return nil, err
+	}
+	return &Applied{Amount: money.FromMajor(amount, c.Fare.Currency), Label: "Loyalty reward", ReferenceID: redemptionID}, nil
+}
+
+func (a *loyaltyApplier) Release(ctx context.Context, c *Checkout, line LineItem) error {
+	if line.ReferenceID == nil {
+		return nil
+	}
+	return a.loyalty.ReleaseRideCredit(ctx, *line.ReferenceID, c.RideID)
+}
+
+// GiftCardSpender is implemented by giftcards.Service
+type GiftCardSpender interface {
+	UseBalance(ctx context.Context, userID uuid.UUID, rideID uuid.UUID, amount float64) (float64, error)
+	RefundRide(ctx context.Context, rideID uuid.UUID) error
+}
+
+type giftCardApplier struct {
+	giftCards GiftCardSpender
+}
+
+// NewGiftCardApplier pays towards the fare from the rider's gift card balance
+func NewGiftCardApplier(giftCards GiftCardSpender) Applier {
+	return &giftCardApplier{giftCards: giftCards}
+}
+
+func (a *giftCardApplier) Apply(ctx context.Context, c *Checkout, limit money.Money) (*Applied, error) {
+	amount, err := a.giftCards.UseBalance(ctx, c.RiderID, c.RideID, limit.Major())
+	if err != nil {
+		return nil, err
+	}
+	return &Applied{Amount: money.FromMajor(amount, c.Fare.Currency), Label: "Gift card"}, nil
+}
+
+func (a *giftCardApplier) Release(ctx context.Context, c *Checkout, _ LineItem) error {
+	return a.giftCards.RefundRide(ctx, c.RideID)
+}
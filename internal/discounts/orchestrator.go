@@ -0,0 +1,282 @@
+// Package discounts settles the promo, subscription, loyalty and gift card
+// reductions a rider has against a completed ride's fare, in one place and
+// one configurable order.
+package discounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"go.uber.org/zap"
+)
+
+// Source is a kind of fare reduction
+type Source string
+
+const (
+	SourcePromo        Source = "promo"
+	SourceSubscription Source = "subscription"
+	SourceLoyalty      Source = "loyalty"
+	SourceGiftCard     Source = "gift_card"
+)
+
+// Receipt line types. Gift card balance was paid for in advance, so it is a
+// credit towards the fare rather than a discount on it.
+const (
+	LineDiscount = "discount"
+	LineCredit   = "credit"
+)
+
+// Checkout is a completed ride whose fare is being settled. Every amount is
+// in the fare's currency.
+type Checkout struct {
+	RideID        uuid.UUID
+	RiderID       uuid.UUID
+	RideType      string      // ride type name, as subscription plans list them
+	Fare          money.Money // fare before any reductions
+	PromoCodeID   *uuid.UUID
+	PromoDiscount money.Money // promo discount validated when the ride was requested
+	PaymentMethod string      // payment method recorded on the ride
+}
+
+// Applied is what one source took off the fare
+type Applied struct {
+	Amount      money.Money
+	Label       string
+	ReferenceID *uuid.UUID // promo code, redemption, etc. behind the line
+}
+
+// Applier applies one source to a checkout. It takes at most limit off the
+// fare and must record usage (balances, counters) for exactly the amount it
+// returns. A nil result or zero amount means the source did not apply.
+type Applier interface {
+	Apply(ctx context.Context, c *Checkout, limit money.Money) (*Applied, error)
+}
+
+// Releaser is implemented by appliers that can give back the usage they
+// recorded for a line, for a checkout whose ride did not complete after all
+type Releaser interface {
+	Release(ctx context.Context, c *Checkout, line LineItem) error
+}
+
+// Rule configures one source
+type Rule struct {
+	Source    Source
+	Stackable bool    // false: applies only if no discount has yet, and no discount applies after it
+	MaxAmount float64 // cap per ride in major units of the fare's currency, 0 = none
+	MaxPct    float64 // cap as a percentage of the fare, 0 = none
+}
+
+// Config sets the order sources apply in and how far they may stack
+type Config struct {
+	Precedence     []Rule
+	MaxDiscountPct float64 // cap on all discounts together as a percentage of the fare; gift card credit is exempt
+}
+
+// DefaultConfig applies the subscription first, then the promo code, loyalty
+// credit and finally gift card balance, all stacking up to the full fare
+func DefaultConfig() *Config {
+	return &Config{
+		Precedence: []Rule{
+			{Source: SourceSubscription, Stackable: true},
+			{Source: SourcePromo, Stackable: true},
+			{Source: SourceLoyalty, Stackable: true},
+			{Source: SourceGiftCard, Stackable: true},
+		},
+		MaxDiscountPct: 100,
+	}
+}
+
+// ParseConfig builds a config from a comma-separated precedence such as
+// "subscription,promo,loyalty,gift_card" and the sources that may not stack
+func ParseConfig(precedence, exclusive string, maxDiscountPct float64) (*Config, error) {
+	notStackable := make(map[Source]bool)
+	for _, name := range splitList(exclusive) {
+		source, err := parseSource(name)
+		if err != nil {
+			return nil, err
+		}
+		notStackable[source] = true
+	}
+
+	config := &Config{MaxDiscountPct: maxDiscountPct}
+	seen := make(map[Source]bool)
+	for _, name := range splitList(precedence) {
+		source, err := parseSource(name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[source] {
+			return nil, fmt.Errorf("discount source %q listed twice", source)
+		}
+		seen[source] = true
+		config.Precedence = append(config.Precedence, Rule{Source: source, Stackable: !notStackable[source]})
+	}
+	if len(config.Precedence) == 0 {
+		return nil, fmt.Errorf("discount precedence is empty")
+	}
+	return config, nil
+}
+
+func parseSource(name string) (Source, error) {
+	switch source := Source(name); source {
+	case SourcePromo, SourceSubscription, SourceLoyalty, SourceGiftCard:
+		return source, nil
+	}
+	return "", fmt.Errorf("unknown discount source %q", name)
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// LineItem is one source's share of the fare on the receipt
+type LineItem struct {
+	Source      Source      `json:"source"`
+	Type        string      `json:"type"` // discount or credit
+	Label       string      `json:"label"`
+	Amount      money.Money `json:"amount"`
+	ReferenceID *uuid.UUID  `json:"reference_id,omitempty"`
+}
+
+// Result is a settled fare, in the fare's currency
+type Result struct {
+	Fare      money.Money `json:"fare"`
+	Lines     []LineItem  `json:"lines"`
+	Discount  money.Money `json:"discount"`   // total of discount lines
+	Credit    money.Money `json:"credit"`     // total of credit lines
+	AmountDue money.Money `json:"amount_due"` // left to charge the rider's payment method
+}
+
+// Orchestrator applies every registered source to a checkout
+type Orchestrator struct {
+	config   *Config
+	appliers map[Source]Applier
+}
+
+// NewOrchestrator creates an orchestrator. config defaults to DefaultConfig.
+func NewOrchestrator(config *Config) *Orchestrator {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &Orchestrator{config: config, appliers: make(map[Source]Applier)}
+}
+
+// Register sets the applier for a source. Sources without one are skipped.
+func (o *Orchestrator) Register(source Source, applier Applier) {
+	o.appliers[source] = applier
+}
+
+// Settle applies the sources in precedence order. A source that fails, or
+// answers in another currency, is logged and skipped so the ride still
+// settles on the others.
+func (o *Orchestrator) Settle(ctx context.Context, c *Checkout) *Result {
+	fare := c.Fare
+	zero := money.Zero(fare.Currency)
+	result := &Result{Fare: fare, Lines: []LineItem{}, Discount: zero, Credit: zero, AmountDue: fare}
+
+	// Caps are rounded down so discounts never exceed them
+	discountBudget := fare
+	if o.config.MaxDiscountPct > 0 && o.config.MaxDiscountPct < 100 {
+		discountBudget = fare.MulRate(o.config.MaxDiscountPct/100, money.RoundDown)
+	}
+	exclusiveApplied := false
+
+	for _, rule := range o.config.Precedence {
+		if !result.AmountDue.IsPositive() {
+			break
+		}
+		applier, ok := o.appliers[rule.Source]
+		if !ok {
+			continue
+		}
+
+		credit := rule.Source == SourceGiftCard
+		if !credit && (exclusiveApplied || (!rule.Stackable && result.Discount.IsPositive())) {
+			continue
+		}
+
+		limit := ruleLimit(rule, fare, result.AmountDue)
+		if !credit {
+			limit = minMoney(limit, money.New(discountBudget.Amount-result.Discount.Amount, fare.Currency))
+		}
+		if !limit.IsPositive() {
+			continue
+		}
+
+		applied, err := applier.Apply(ctx, c, limit)
+		if err == nil && applied != nil && !applied.Amount.IsZero() && !applied.Amount.SameCurrency(fare) {
+			err = fmt.Errorf("%w: %s applied to a %s fare", money.ErrCurrencyMismatch, applied.Amount.Currency, fare.Currency)
+		}
+		if err != nil {
+			logger.WarnContext(ctx, "discount source failed, settling without it",
+				zap.String("ride_id", c.RideID.String()), zap.String("source", string(rule.Source)), zap.Error(err))
+			continue
+		}
+		if applied == nil || !applied.Amount.IsPositive() {
+			continue
+		}
+
+		amount := minMoney(applied.Amount, limit)
+		line := LineItem{Source: rule.Source, Type: LineDiscount, Label: applied.Label, Amount: amount, ReferenceID: applied.ReferenceID}
+		if credit {
+			line.Type = LineCredit
+			result.Credit = money.New(result.Credit.Amount+amount.Amount, fare.Currency)
+		} else {
+			result.Discount = money.New(result.Discount.Amount+amount.Amount, fare.Currency)
+			exclusiveApplied = !rule.Stackable
+		}
+		result.Lines = append(result.Lines, line)
+		result.AmountDue = money.New(result.AmountDue.Amount-amount.Amount, fare.Currency)
+	}
+
+	return result
+}
+
+// Release gives back what Settle recorded for a checkout's lines. Every line
+// is attempted; the errors of those that could not be released are returned
+// together.
+func (o *Orchestrator) Release(ctx context.Context, c *Checkout, result *Result) error {
+	var errs []error
+	for _, line := range result.Lines {
+		releaser, ok := o.appliers[line.Source].(Releaser)
+		if !ok {
+			continue
+		}
+		if err := releaser.Release(ctx, c, line); err != nil {
+			errs = append(errs, fmt.Errorf("release %s: %w", line.Source, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ruleLimit returns the most a rule's source may take off the fare
+func ruleLimit(rule Rule, fare, due money.Money) money.Money {
+	limit := due
+	if rule.MaxAmount > 0 {
+		limit = minMoney(limit, money.FromMajorRounded(rule.MaxAmount, fare.Currency, money.RoundDown))
+	}
+	if rule.MaxPct > 0 {
+		limit = minMoney(limit, fare.MulRate(rule.MaxPct/100, money.RoundDown))
+	}
+	return limit
+}
+
+// minMoney returns the smaller of two amounts in the same currency
+func minMoney(a, b money.Money) money.Money {
+	if b.Amount < a.Amount {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,284 @@
+package discounts
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeApplier takes up to amount off the fare, in major units of its
+// currency, and records the limits it saw and the lines it was asked to
+// release
+type fakeApplier struct {
+	amount     float64
+	currency   string // defaults to the fare's
+	err        error
+	releaseErr error
+	limits     []float64
+	released   []LineItem
+}
+
+func (f *fakeApplier) Apply(_ context.Context, _ *Checkout, limit money.Money) (*Applied, error) {
+	f.limits = append(f.limits, limit.Major())
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.currency != "" {
+		return &Applied{Amount: money.FromMajor(f.amount, f.currency), Label: "fake"}, nil
+	}
+	return &Applied{Amount: minMoney(money.FromMajor(f.amount, limit.Currency), limit), Label: "fake"}, nil
+}
+
+func (f *fakeApplier) Release(_ context.Context, _ *Checkout, line LineItem) error {
+	f.released = append(f.released, line)
+	return f.releaseErr
+}
+
+// fakePromos grants promo discounts up to what its budget has left
+type fakePromos struct {
+	budget   float64
+	redeemed []float64
+	released []uuid.UUID
+}
+
+func (f *fakePromos) RedeemPromoCode(_ context.Context, _, _, _ uuid.UUID, _ string, _, discount float64) (float64, error) {
+	granted := min(discount, f.budget)
+	f.budget -= granted
+	f.redeemed = append(f.redeemed, granted)
+	return granted, nil
+}
+
+func (f *fakePromos) ReleasePromoCodeUse(_ context.Context, rideID uuid.UUID) error {
+	f.released = append(f.released, rideID)
+	return nil
+}
+
+func newCheckout(fare float64) *Checkout {
+	return &Checkout{RideID: uuid.New(), RiderID: uuid.New(), Fare: usd(fare), PromoDiscount: usd(0)}
+}
+
+func usd(major float64) money.Money {
+	return money.FromMajor(major, "USD")
+}
+
+func TestSettle_AppliesInPrecedenceOrder(t *testing.T) {
+	o := NewOrchestrator(nil)
+	subscription := &fakeApplier{amount: 5}
+	loyalty := &fakeApplier{amount: 8}
+	giftCard := &fakeApplier{amount: 100}
+	o.Register(SourceSubscription, subscription)
+	o.Register(SourceLoyalty, loyalty)
+	o.Register(SourceGiftCard, giftCard)
+	o.Register(SourcePromo, NewPromoApplier(&fakePromos{budget: 100}))
+
+	promoID := uuid.New()
+	c := newCheckout(30)
+	c.PromoCodeID = &promoID
+	c.PromoDiscount = usd(4)
+
+	result := o.Settle(context.Background(), c)
+
+	require.Len(t, result.Lines, 4)
+	assert.Equal(t, []Source{SourceSubscription, SourcePromo, SourceLoyalty, SourceGiftCard},
+		[]Source{result.Lines[0].Source, result.Lines[1].Source, result.Lines[2].Source, result.Lines[3].Source})
+	assert.Equal(t, &promoID, result.Lines[1].ReferenceID)
+	assert.Equal(t, LineCredit, result.Lines[3].Type)
+
+	// Each source only sees what the ones before it left
+	assert.Equal(t, []float64{21}, loyalty.limits)
+	assert.Equal(t, []float64{13}, giftCard.limits)
+
+	assert.Equal(t, usd(17.0), result.Discount)
+	assert.Equal(t, usd(13.0), result.Credit)
+	assert.Equal(t, usd(0.0), result.AmountDue)
+}
+
+func TestSettle_CapsDiscountsButNotCredit(t *testing.T) {
+	o := NewOrchestrator(&Config{
+		Precedence: []Rule{
+			{Source: SourceSubscription, Stackable: true, MaxPct: 10},
+			{Source: SourceLoyalty, Stackable: true, MaxAmount: 50},
+			{Source: SourceGiftCard, Stackable: true},
+		},
+		MaxDiscountPct: 40,
+	})
+	subscription := &fakeApplier{amount: 100}
+	loyalty := &fakeApplier{amount: 100}
+	giftCard := &fakeApplier{amount: 100}
+	o.Register(SourceSubscription, subscription)
+	o.Register(SourceLoyalty, loyalty)
+	o.Register(SourceGiftCard, giftCard)
+
+	result := o.Settle(context.Background(), newCheckout(50))
+
+	assert.Equal(t, []float64{5}, subscription.limits) // 10% of 50
+	assert.Equal(t, []float64{15}, loyalty.limits)     // 40% of 50, less the subscription
+	assert.Equal(t, usd(20.0), result.Discount)
+	assert.Equal(t, usd(30.0), result.Credit)
+	assert.Equal(t, usd(0.0), result.AmountDue)
+}
+
+func TestSettle_ExclusiveSource(t *testing.T) {
+	config, err := ParseConfig("promo,subscription,loyalty,gift_card", "promo", 100)
+	require.NoError(t, err)
+
+	promoID := uuid.New()
+	withPromo := newCheckout(20)
+	withPromo.PromoCodeID = &promoID
+	withPromo.PromoDiscount = usd(5)
+
+	o := NewOrchestrator(config)
+	subscription := &fakeApplier{amount: 3}
+	giftCard := &fakeApplier{amount: 4}
+	o.Register(SourcePromo, NewPromoApplier(&fakePromos{budget: 100}))
+	o.Register(SourceSubscription, subscription)
+	o.Register(SourceGiftCard, giftCard)
+
+	// The promo applies first and blocks later discounts, but not gift card credit
+	result := o.Settle(context.Background(), withPromo)
+	require.Len(t, result.Lines, 2)
+	assert.Equal(t, SourcePromo, result.Lines[0].Source)
+	assert.Equal(t, SourceGiftCard, result.Lines[1].Source)
+	assert.Empty(t, subscription.limits)
+	assert.Equal(t, usd(11.0), result.AmountDue)
+
+	// Without a promo the other discounts stack as usual
+	result = o.Settle(context.Background(), newCheckout(20))
+	require.Len(t, result.Lines, 2)
+	assert.Equal(t, SourceSubscription, result.Lines[0].Source)
+	assert.Equal(t, usd(13.0), result.AmountDue)
+}
+
+func TestSettle_ExclusiveSourceSkippedAfterDiscount(t *testing.T) {
+	config, err := ParseConfig("subscription,promo", "promo", 100)
+	require.NoError(t, err)
+
+	promoID := uuid.New()
+	c := newCheckout(20)
+	c.PromoCodeID = &promoID
+	c.PromoDiscount = usd(5)
+
+	o := NewOrchestrator(config)
+	o.Register(SourceSubscription, &fakeApplier{amount: 2})
+	o.Register(SourcePromo, NewPromoApplier(&fakePromos{budget: 100}))
+
+	result := o.Settle(context.Background(), c)
+	require.Len(t, result.Lines, 1)
+	assert.Equal(t, SourceSubscription, result.Lines[0].Source)
+	assert.Equal(t, usd(2.0), result.Discount)
+}
+
+func TestSettle_PromoRecordsUseAgainstBudget(t *testing.T) {
+	o := NewOrchestrator(nil)
+	promos := &fakePromos{budget: 3}
+	o.Register(SourcePromo, NewPromoApplier(promos))
+
+	promoID := uuid.New()
+	c := newCheckout(20)
+	c.PromoCodeID = &promoID
+	c.PromoDiscount = usd(5)
+
+	// The budget ran low after the ride was requested
+	result := o.Settle(context.Background(), c)
+	require.Len(t, result.Lines, 1)
+	assert.Equal(t, usd(3.0), result.Lines[0].Amount)
+	assert.Equal(t, []float64{3}, promos.redeemed)
+	assert.Equal(t, usd(17.0), result.AmountDue)
+
+	require.NoError(t, o.Release(context.Background(), c, result))
+	assert.Equal(t, []uuid.UUID{c.RideID}, promos.released)
+
+	// Nothing is recorded for a ride without a promo code
+	o.Settle(context.Background(), newCheckout(20))
+	assert.Len(t, promos.redeemed, 1)
+}
+
+func TestSettle_SkipsFailingSource(t *testing.T) {
+	o := NewOrchestrator(nil)
+	o.Register(SourceSubscription, &fakeApplier{err: errors.New("plan lookup failed")})
+	o.Register(SourceLoyalty, &fakeApplier{amount: 2.5})
+
+	result := o.Settle(context.Background(), newCheckout(12.345))
+
+	require.Len(t, result.Lines, 1)
+	assert.Equal(t, SourceLoyalty, result.Lines[0].Source)
+	assert.Equal(t, usd(12.35), result.Fare)
+	assert.Equal(t, usd(9.85), result.AmountDue)
+}
+
+func TestSettle_UsesTheFareCurrency(t *testing.T) {
+	o := NewOrchestrator(&Config{
+		Precedence: []Rule{
+			{Source: SourceSubscription, Stackable: true, MaxPct: 15},
+			{Source: SourceLoyalty, Stackable: true},
+			{Source: SourceGiftCard, Stackable: true},
+		},
+		MaxDiscountPct: 100,
+	})
+	subscription := &fakeApplier{amount: 500}
+	loyalty := &fakeApplier{amount: 5, currency: "USD"}
+	giftCard := &fakeApplier{amount: 333}
+	o.Register(SourceSubscription, subscription)
+	o.Register(SourceLoyalty, loyalty)
+	o.Register(SourceGiftCard, giftCard)
+
+	c := &Checkout{RideID: uuid.New(), RiderID: uuid.New(), Fare: money.FromMajor(1005, "JPY"), PromoDiscount: money.Zero("JPY")}
+	result := o.Settle(context.Background(), c)
+
+	// 15% of 1005 yen is capped down to whole yen, and a source answering in
+	// another currency is skipped
+	assert.Equal(t, []float64{150}, subscription.limits)
+	require.Len(t, result.Lines, 2)
+	assert.Equal(t, money.New(150, "JPY"), result.Lines[0].Amount)
+	assert.Equal(t, money.New(333, "JPY"), result.Credit)
+	assert.Equal(t, money.New(522, "JPY"), result.AmountDue)
+}
+
+func TestRelease_GivesBackEverySettledLine(t *testing.T) {
+	o := NewOrchestrator(nil)
+	subscription := &fakeApplier{amount: 5, releaseErr: errors.New("db down")}
+	loyalty := &fakeApplier{amount: 0}
+	giftCard := &fakeApplier{amount: 3}
+	o.Register(SourceSubscription, subscription)
+	o.Register(SourceLoyalty, loyalty)
+	o.Register(SourceGiftCard, giftCard)
+	o.Register(SourcePromo, NewPromoApplier(&fakePromos{budget: 100}))
+
+	c := newCheckout(20)
+	result := o.Settle(context.Background(), c)
+	require.Len(t, result.Lines, 2)
+
+	err := o.Release(context.Background(), c, result)
+
+	// A failing source does not stop the others being released
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "subscription")
+	assert.Len(t, subscription.released, 1)
+	assert.Equal(t, []LineItem{result.Lines[1]}, giftCard.released)
+	assert.Empty(t, loyalty.released) // it applied nothing
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(" loyalty, promo ,gift_card", "loyalty", 50)
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Source: SourceLoyalty, Stackable: false},
+		{Source: SourcePromo, Stackable: true},
+		{Source: SourceGiftCard, Stackable: true},
+	}, config.Precedence)
+	assert.Equal(t, 50.0, config.MaxDiscountPct)
+
+	_, err = ParseConfig("promo,promo", "", 100)
+	assert.Error(t, err)
+	_, err = ParseConfig("promo,cashback", "", 100)
+	assert.Error(t, err)
+	_, err = ParseConfig("promo", "cashback", 100)
+	assert.Error(t, err)
+	_, err = ParseConfig("", "", 100)
+	assert.Error(t, err)
+}
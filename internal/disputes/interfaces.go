@@ -13,8 +13,10 @@ type RepositoryInterface interface {
 	CreateDispute(ctx context.Context, d *Dispute) error
 	GetDisputeByID(ctx context.Context, id uuid.UUID) (*Dispute, error)
 	GetDisputeByRideAndUser(ctx context.Context, rideID, userID uuid.UUID) (*Dispute, error)
+	GetDisputeByProviderID(ctx context.Context, providerDisputeID string) (*Dispute, error)
 	GetUserDisputes(ctx context.Context, userID uuid.UUID, status *DisputeStatus, limit, offset int) ([]DisputeSummary, int, error)
 	ResolveDispute(ctx context.Context, id uuid.UUID, status DisputeStatus, resType ResolutionType, refundAmount *float64, note string, resolvedBy uuid.UUID) error
+	ResolveChargeback(ctx context.Context, id uuid.UUID, status DisputeStatus, resType ResolutionType, refundAmount *float64, note string) error
 	UpdateDisputeStatus(ctx context.Context, id uuid.UUID, status DisputeStatus) error
 
 	// Comment operations
@@ -32,6 +32,7 @@ const (
 	ReasonCancelFeeWrong DisputeReason = "wrong_cancel_fee"
 	ReasonDuplicateCharge DisputeReason = "duplicate_charge"
 	ReasonOther          DisputeReason = "other"
+	ReasonChargeback     DisputeReason = "chargeback" // opened with the card issuer, not in the app
 )
 
 // ResolutionType represents how the dispute was resolved
@@ -62,11 +63,25 @@ type Dispute struct {
 	ResolutionNote  *string        `json:"resolution_note,omitempty" db:"resolution_note"`
 	ResolvedBy      *uuid.UUID     `json:"resolved_by,omitempty" db:"resolved_by"`
 	Evidence        []string       `json:"evidence,omitempty" db:"evidence"`
+	ProviderDisputeID *string      `json:"provider_dispute_id,omitempty" db:"provider_dispute_id"`
 	ResolvedAt      *time.Time     `json:"resolved_at,omitempty" db:"resolved_at"`
 	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
 }
 
+// Chargeback is a card dispute the rider raised with their card issuer, as
+// reported by the payment provider
+type Chargeback struct {
+	ProviderDisputeID string
+	RideID            uuid.UUID
+	UserID            uuid.UUID
+	DriverID          *uuid.UUID
+	Fare              float64
+	Amount            float64 // amount the issuer is disputing
+	ProviderReason    string
+	EvidenceDueBy     *time.Time
+}
+
 // DisputeComment represents a comment in a dispute thread
 type DisputeComment struct {
 	ID         uuid.UUID `json:"id" db:"id"`
@@ -29,11 +29,11 @@ func (r *Repository) CreateDispute(ctx context.Context, d *Dispute) error {
 		INSERT INTO fare_disputes (
 			id, ride_id, user_id, driver_id, dispute_number,
 			reason, description, status, original_fare, disputed_amount,
-			evidence, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+			evidence, created_at, updated_at, provider_dispute_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`,
 		d.ID, d.RideID, d.UserID, d.DriverID, d.DisputeNumber,
 		d.Reason, d.Description, d.Status, d.OriginalFare, d.DisputedAmount,
-		d.Evidence, d.CreatedAt, d.UpdatedAt,
+		d.Evidence, d.CreatedAt, d.UpdatedAt, d.ProviderDisputeID,
 	)
 	return err
 }
@@ -45,13 +45,13 @@ func (r *Repository) GetDisputeByID(ctx context.Context, id uuid.UUID) (*Dispute
 		SELECT id, ride_id, user_id, driver_id, dispute_number,
 			reason, description, status, original_fare, disputed_amount,
 			refund_amount, resolution_type, resolution_note, resolved_by,
-			evidence, resolved_at, created_at, updated_at
+			evidence, provider_dispute_id, resolved_at, created_at, updated_at
 		FROM fare_disputes WHERE id = $1`, id,
 	).Scan(
 		&d.ID, &d.RideID, &d.UserID, &d.DriverID, &d.DisputeNumber,
 		&d.Reason, &d.Description, &d.Status, &d.OriginalFare, &d.DisputedAmount,
 		&d.RefundAmount, &d.ResolutionType, &d.ResolutionNote, &d.ResolvedBy,
-		&d.Evidence, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt,
+		&d.Evidence, &d.ProviderDisputeID, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -66,7 +66,7 @@ func (r *Repository) GetDisputeByRideAndUser(ctx context.Context, rideID, userID
 		SELECT id, ride_id, user_id, driver_id, dispute_number,
 			reason, description, status, original_fare, disputed_amount,
 			refund_amount, resolution_type, resolution_note, resolved_by,
-			evidence, resolved_at, created_at, updated_at
+			evidence, provider_dispute_id, resolved_at, created_at, updated_at
 		FROM fare_disputes
 		WHERE ride_id = $1 AND user_id = $2 AND status NOT IN ('closed', 'rejected')
 		LIMIT 1`, rideID, userID,
@@ -74,7 +74,30 @@ func (r *Repository) GetDisputeByRideAndUser(ctx context.Context, rideID, userID
 		&d.ID, &d.RideID, &d.UserID, &d.DriverID, &d.DisputeNumber,
 		&d.Reason, &d.Description, &d.Status, &d.OriginalFare, &d.DisputedAmount,
 		&d.RefundAmount, &d.ResolutionType, &d.ResolutionNote, &d.ResolvedBy,
-		&d.Evidence, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt,
+		&d.Evidence, &d.ProviderDisputeID, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetDisputeByProviderID returns the dispute opened for a payment provider's
+// chargeback
+func (r *Repository) GetDisputeByProviderID(ctx context.Context, providerDisputeID string) (*Dispute, error) {
+	d := &Dispute{}
+	err := r.db.QueryRow(ctx, `
+		SELECT id, ride_id, user_id, driver_id, dispute_number,
+			reason, description, status, original_fare, disputed_amount,
+			refund_amount, resolution_type, resolution_note, resolved_by,
+			evidence, provider_dispute_id, resolved_at, created_at, updated_at
+		FROM fare_disputes
+		WHERE provider_dispute_id = $1`, providerDisputeID,
+	).Scan(
+		&d.ID, &d.RideID, &d.UserID, &d.DriverID, &d.DisputeNumber,
+		&d.Reason, &d.Description, &d.Status, &d.OriginalFare, &d.DisputedAmount,
+		&d.RefundAmount, &d.ResolutionType, &d.ResolutionNote, &d.ResolvedBy,
+		&d.Evidence, &d.ProviderDisputeID, &d.ResolvedAt, &d.CreatedAt, &d.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -145,6 +168,20 @@ func (r *Repository) ResolveDispute(ctx context.Context, id uuid.UUID, status Di
 	return err
 }
 
+// ResolveChargeback records the card issuer's decision on a chargeback
+// dispute, which no admin resolves
+func (r *Repository) ResolveChargeback(ctx context.Context, id uuid.UUID, status DisputeStatus, resType ResolutionType, refundAmount *float64, note string) error {
+	now := time.Now()
+	_, err := r.db.Exec(ctx, `
+		UPDATE fare_disputes
+		SET status = $2, resolution_type = $3, refund_amount = $4,
+			resolution_note = $5, resolved_at = $6, updated_at = $6
+		WHERE id = $1`,
+		id, status, resType, refundAmount, note, now,
+	)
+	return err
+}
+
 // UpdateDisputeStatus updates the status of a dispute
 func (r *Repository) UpdateDisputeStatus(ctx context.Context, id uuid.UUID, status DisputeStatus) error {
 	_, err := r.db.Exec(ctx, `
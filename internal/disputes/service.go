@@ -334,6 +334,104 @@ func (s *Service) AdminGetStats(ctx context.Context, from, to time.Time) (*Dispu
 	return s.repo.GetDisputeStats(ctx, from, to)
 }
 
+// ========================================
+// CHARGEBACKS
+// ========================================
+
+// OpenChargeback opens a dispute for a chargeback reported by the payment
+// provider. Each chargeback opens one dispute; reporting it again returns
+// the existing one.
+func (s *Service) OpenChargeback(ctx context.Context, cb *Chargeback) (*Dispute, error) {
+	existing, err := s.repo.GetDisputeByProviderID(ctx, cb.ProviderDisputeID)
+	if err == nil {
+		return existing, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Chargeback %s raised with the card issuer", cb.ProviderDisputeID)
+	if cb.ProviderReason != "" {
+		description += fmt.Sprintf(" (%s)", cb.ProviderReason)
+	}
+
+	now := time.Now()
+	providerID := cb.ProviderDisputeID
+	dispute := &Dispute{
+		ID:                uuid.New(),
+		RideID:            cb.RideID,
+		UserID:            cb.UserID,
+		DriverID:          cb.DriverID,
+		DisputeNumber:     generateDisputeNumber(),
+		Reason:            ReasonChargeback,
+		Description:       description,
+		Status:            DisputeStatusReviewing,
+		OriginalFare:      cb.Fare,
+		DisputedAmount:    cb.Amount,
+		Evidence:          []string{},
+		ProviderDisputeID: &providerID,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.repo.CreateDispute(ctx, dispute); err != nil {
+		return nil, fmt.Errorf("create chargeback dispute: %w", err)
+	}
+
+	if cb.EvidenceDueBy != nil {
+		comment := &DisputeComment{
+			ID:         uuid.New(),
+			DisputeID:  dispute.ID,
+			UserID:     cb.UserID,
+			UserRole:   "system",
+			Comment:    fmt.Sprintf("Evidence is due to the card issuer by %s", cb.EvidenceDueBy.UTC().Format(time.RFC1123)),
+			IsInternal: true,
+			CreatedAt:  now,
+		}
+		s.repo.CreateComment(ctx, comment)
+	}
+
+	return dispute, nil
+}
+
+// CloseChargeback resolves a chargeback dispute with the card issuer's
+// decision: a won chargeback is rejected, a lost one is refunded by the
+// disputed amount. A chargeback whose opening was never reported is opened
+// first, and one already resolved is returned unchanged.
+func (s *Service) CloseChargeback(ctx context.Context, cb *Chargeback, won bool) (*Dispute, error) {
+	dispute, err := s.OpenChargeback(ctx, cb)
+	if err != nil {
+		return nil, err
+	}
+	if dispute.Status != DisputeStatusPending && dispute.Status != DisputeStatusReviewing {
+		return dispute, nil
+	}
+
+	status, resType := DisputeStatusRejected, ResolutionNoAction
+	var refund *float64
+	note := "Chargeback won: the card issuer upheld the charge"
+	if !won {
+		amount := cb.Amount
+		refund = &amount
+		status, resType = DisputeStatusApproved, ResolutionFullRefund
+		if amount < dispute.OriginalFare {
+			status, resType = DisputeStatusPartial, ResolutionPartialRefund
+		}
+		note = "Chargeback lost: the card issuer refunded the rider"
+	}
+
+	if err := s.repo.ResolveChargeback(ctx, dispute.ID, status, resType, refund, note); err != nil {
+		return nil, fmt.Errorf("resolve chargeback: %w", err)
+	}
+
+	now := time.Now()
+	dispute.Status = status
+	dispute.ResolutionType = &resType
+	dispute.RefundAmount = refund
+	dispute.ResolutionNote = &note
+	dispute.ResolvedAt = &now
+	return dispute, nil
+}
+
 // ========================================
 // HELPERS
 // ========================================
@@ -120,6 +120,15 @@ func (m *MockRepository) GetDisputeByRideAndUser(ctx context.Context, rideID, us
 	return d, nil
 }
 
+func (m *MockRepository) GetDisputeByProviderID(ctx context.Context, providerDisputeID string) (*Dispute, error) {
+	for _, d := range m.disputes {
+		if d.ProviderDisputeID != nil && *d.ProviderDisputeID == providerDisputeID {
+			return d, nil
+		}
+	}
+	return nil, pgx.ErrNoRows
+}
+
 func (m *MockRepository) GetUserDisputes(ctx context.Context, userID uuid.UUID, status *DisputeStatus, limit, offset int) ([]DisputeSummary, int, error) {
 	m.getUserDisputesCalled = true
 	if m.getUserDisputesErr != nil {
@@ -157,6 +166,18 @@ func (m *MockRepository) ResolveDispute(ctx context.Context, id uuid.UUID, statu
 	return nil
 }
 
+func (m *MockRepository) ResolveChargeback(ctx context.Context, id uuid.UUID, status DisputeStatus, resType ResolutionType, refundAmount *float64, note string) error {
+	m.resolveDisputeCalled = true
+	m.lastResolveArgs = &resolveArgs{
+		ID:           id,
+		Status:       status,
+		ResType:      resType,
+		RefundAmount: refundAmount,
+		Note:         note,
+	}
+	return m.resolveDisputeErr
+}
+
 func (m *MockRepository) UpdateDisputeStatus(ctx context.Context, id uuid.UUID, status DisputeStatus) error {
 	m.updateStatusCalled = true
 	m.lastStatusUpdate = &statusUpdateArgs{ID: id, Status: status}
@@ -2148,6 +2169,93 @@ func TestNewService_NilRepositoryPanics(t *testing.T) {
 	})
 }
 
+// ========================================
+// CHARGEBACK TESTS
+// ========================================
+
+func newTestChargeback(amount float64) *Chargeback {
+	driverID := uuid.New()
+	due := time.Now().Add(7 * 24 * time.Hour)
+	return &Chargeback{
+		ProviderDisputeID: "dp_" + uuid.NewString(),
+		RideID:            uuid.New(),
+		UserID:            uuid.New(),
+		DriverID:          &driverID,
+		Fare:              40.0,
+		Amount:            amount,
+		ProviderReason:    "fraudulent",
+		EvidenceDueBy:     &due,
+	}
+}
+
+func TestService_OpenChargeback(t *testing.T) {
+	mock := NewMockRepository()
+	svc := createTestService(mock)
+	cb := newTestChargeback(40.0)
+
+	dispute, err := svc.OpenChargeback(context.Background(), cb)
+	require.NoError(t, err)
+	assert.Equal(t, ReasonChargeback, dispute.Reason)
+	assert.Equal(t, DisputeStatusReviewing, dispute.Status)
+	assert.Equal(t, cb.RideID, dispute.RideID)
+	assert.Equal(t, 40.0, dispute.DisputedAmount)
+	require.NotNil(t, dispute.ProviderDisputeID)
+	assert.Equal(t, cb.ProviderDisputeID, *dispute.ProviderDisputeID)
+	assert.Contains(t, dispute.Description, "fraudulent")
+	require.NotNil(t, mock.lastCommentCreated)
+	assert.True(t, mock.lastCommentCreated.IsInternal)
+
+	// Reporting the chargeback again returns the same dispute
+	mock.createDisputeCalled = false
+	again, err := svc.OpenChargeback(context.Background(), cb)
+	require.NoError(t, err)
+	assert.Equal(t, dispute.ID, again.ID)
+	assert.False(t, mock.createDisputeCalled)
+}
+
+func TestService_CloseChargeback_Won(t *testing.T) {
+	mock := NewMockRepository()
+	svc := createTestService(mock)
+	cb := newTestChargeback(40.0)
+	_, err := svc.OpenChargeback(context.Background(), cb)
+	require.NoError(t, err)
+
+	dispute, err := svc.CloseChargeback(context.Background(), cb, true)
+	require.NoError(t, err)
+	assert.Equal(t, DisputeStatusRejected, dispute.Status)
+	assert.Equal(t, ResolutionNoAction, mock.lastResolveArgs.ResType)
+	assert.Nil(t, mock.lastResolveArgs.RefundAmount)
+}
+
+func TestService_CloseChargeback_LostWithoutOpen(t *testing.T) {
+	mock := NewMockRepository()
+	svc := createTestService(mock)
+	cb := newTestChargeback(15.0)
+
+	// The opening event never arrived: the dispute is opened, then resolved
+	dispute, err := svc.CloseChargeback(context.Background(), cb, false)
+	require.NoError(t, err)
+	assert.True(t, mock.createDisputeCalled)
+	assert.Equal(t, DisputeStatusPartial, dispute.Status)
+	require.NotNil(t, mock.lastResolveArgs.RefundAmount)
+	assert.Equal(t, 15.0, *mock.lastResolveArgs.RefundAmount)
+	assert.Equal(t, ResolutionPartialRefund, mock.lastResolveArgs.ResType)
+}
+
+func TestService_CloseChargeback_AlreadyResolved(t *testing.T) {
+	mock := NewMockRepository()
+	svc := createTestService(mock)
+	cb := newTestChargeback(40.0)
+	dispute, err := svc.OpenChargeback(context.Background(), cb)
+	require.NoError(t, err)
+	dispute.Status = DisputeStatusApproved
+
+	_, err = svc.CloseChargeback(context.Background(), cb, true)
+	require.NoError(t, err)
+	assert.False(t, mock.resolveDisputeCalled)
+	assert.Equal(t, DisputeStatusApproved, dispute.Status)
+}
+
 // ========================================
 // EDGE CASE TESTS
 // ========================================
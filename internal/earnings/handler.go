@@ -175,6 +175,52 @@ func (h *Handler) GetPayoutHistory(c *gin.Context) {
 	common.SuccessResponse(c, resp)
 }
 
+// GetPayoutSettings returns the driver's payout schedule
+// GET /api/v1/driver/earnings/payout-settings
+func (h *Handler) GetPayoutSettings(c *gin.Context) {
+	driverID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	settings, err := h.service.GetPayoutSettings(c.Request.Context(), driverID)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get payout settings")
+		return
+	}
+
+	common.SuccessResponse(c, settings)
+}
+
+// UpdatePayoutSettings changes the driver's payout schedule
+// PUT /api/v1/driver/earnings/payout-settings
+func (h *Handler) UpdatePayoutSettings(c *gin.Context) {
+	driverID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req UpdatePayoutSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	settings, err := h.service.UpdatePayoutSettings(c.Request.Context(), driverID, &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to update payout settings")
+		return
+	}
+
+	common.SuccessResponse(c, settings)
+}
+
 // ========================================
 // BANK ACCOUNTS
 // ========================================
@@ -433,6 +479,80 @@ func (h *Handler) AdminRejectPayout(c *gin.Context) {
 	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "Payout rejected")
 }
 
+// AdminSetPayoutAccount links a driver to their payment provider account
+// PUT /api/v1/admin/earnings/drivers/:id/payout-account
+func (h *Handler) AdminSetPayoutAccount(c *gin.Context) {
+	driverID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid driver ID")
+		return
+	}
+
+	var req SetPayoutAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "provider_account_id is required")
+		return
+	}
+
+	settings, err := h.service.SetPayoutAccount(c.Request.Context(), driverID, req.ProviderAccountID)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to set payout account")
+		return
+	}
+
+	common.SuccessResponse(c, settings)
+}
+
+// AdminGetPayoutMismatches lists payout reconciliation mismatches
+// GET /api/v1/admin/earnings/payout-mismatches?resolved=false
+func (h *Handler) AdminGetPayoutMismatches(c *gin.Context) {
+	params := pagination.ParseParams(c)
+	resolved := c.Query("resolved") == "true"
+
+	mismatches, total, err := h.service.GetPayoutMismatches(c.Request.Context(), resolved, params.Limit, params.Offset)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get payout mismatches")
+		return
+	}
+	if mismatches == nil {
+		mismatches = []PayoutMismatch{}
+	}
+
+	meta := pagination.BuildMeta(params.Limit, params.Offset, total)
+	common.SuccessResponseWithMeta(c, mismatches, meta)
+}
+
+// AdminResolvePayoutMismatch marks a reconciliation mismatch resolved
+// POST /api/v1/admin/earnings/payout-mismatches/:id/resolve
+func (h *Handler) AdminResolvePayoutMismatch(c *gin.Context) {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	mismatchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid mismatch ID")
+		return
+	}
+
+	if err := h.service.ResolvePayoutMismatch(c.Request.Context(), mismatchID, adminID); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to resolve payout mismatch")
+		return
+	}
+
+	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "Payout mismatch resolved")
+}
+
 // AdminGetPlatformStats returns platform-wide earnings statistics
 // GET /api/v1/admin/earnings/stats?period=this_month
 func (h *Handler) AdminGetPlatformStats(c *gin.Context) {
@@ -534,6 +654,8 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider)
 		// Payouts
 		driver.POST("/payouts", h.RequestPayout)
 		driver.GET("/payouts", h.GetPayoutHistory)
+		driver.GET("/payout-settings", h.GetPayoutSettings)
+		driver.PUT("/payout-settings", h.UpdatePayoutSettings)
 
 		// Bank accounts
 		driver.POST("/bank-accounts", h.AddBankAccount)
@@ -564,6 +686,9 @@ func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
 		earnings.GET("/payouts/:id", h.AdminGetPayout)
 		earnings.POST("/payouts/:id/approve", h.AdminApprovePayout)
 		earnings.POST("/payouts/:id/reject", h.AdminRejectPayout)
+		earnings.GET("/payout-mismatches", h.AdminGetPayoutMismatches)
+		earnings.POST("/payout-mismatches/:id/resolve", h.AdminResolvePayoutMismatch)
+		earnings.PUT("/drivers/:id/payout-account", h.AdminSetPayoutAccount)
 		earnings.GET("/stats", h.AdminGetPlatformStats)
 		earnings.GET("/top-drivers", h.AdminGetTopDrivers)
 		earnings.GET("/drivers/:id", h.AdminGetDriverEarnings)
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 // EarningType represents the type of earning
@@ -75,6 +76,23 @@ type DriverPayout struct {
 	FailureReason *string      `json:"failure_reason,omitempty" db:"failure_reason"`
 	CreatedAt     time.Time    `json:"created_at" db:"created_at"`
 	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`
+
+	// Batch and provider fields
+	Schedule           PayoutSchedule `json:"schedule,omitempty" db:"schedule"` // empty for payouts requested for bank transfer
+	Fee                float64        `json:"fee" db:"fee"`                     // taken from the payout, e.g. for instant pay
+	IdempotencyKey     string         `json:"-" db:"idempotency_key"`
+	ProviderTransferID *string        `json:"provider_transfer_id,omitempty" db:"provider_transfer_id"`
+	ReconciledAt       *time.Time     `json:"reconciled_at,omitempty" db:"reconciled_at"`
+}
+
+// AmountMoney returns the amount paid out in minor units of the payout's currency
+func (p *DriverPayout) AmountMoney() money.Money {
+	return money.FromMajor(p.Amount, p.Currency)
+}
+
+// FeeMoney returns the fee taken from the payout in minor units of its currency
+func (p *DriverPayout) FeeMoney() money.Money {
+	return money.FromMajor(p.Fee, p.Currency)
 }
 
 // DriverBankAccount represents a driver's bank account for payouts
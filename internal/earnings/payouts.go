@@ -0,0 +1,680 @@
+package earnings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"go.uber.org/zap"
+)
+
+const (
+	instantPayoutFeeRate = 0.015 // 1.5% of the payout
+	instantPayoutFeeMin  = 0.50  // in major units of the payout's currency
+	// A payout left in processing this long was interrupted mid-transfer and
+	// is retried with the same idempotency key
+	stalePayoutAfter = 15 * time.Minute
+	// Transfers and payouts this close to the edge of a reconciliation window
+	// are still matched, so a transfer and its payout straddling the edge are
+	// not flagged
+	reconcileSlack  = time.Hour
+	reconcileWindow = 48 * time.Hour
+)
+
+// PayoutSchedule is how often a driver's earnings are paid out
+type PayoutSchedule string
+
+const (
+	PayoutScheduleDaily   PayoutSchedule = "daily"
+	PayoutScheduleWeekly  PayoutSchedule = "weekly"
+	PayoutScheduleInstant PayoutSchedule = "instant" // only on request, for a fee
+)
+
+// DriverPayoutSettings is how and where a driver is paid out
+type DriverPayoutSettings struct {
+	DriverID          uuid.UUID      `json:"driver_id" db:"driver_id"`
+	Schedule          PayoutSchedule `json:"schedule" db:"schedule"`
+	WeeklyDay         time.Weekday   `json:"weekly_day" db:"weekly_day"` // 0 = Sunday
+	ProviderAccountID *string        `json:"provider_account_id,omitempty" db:"provider_account_id"`
+	HoldReason        *string        `json:"hold_reason,omitempty" db:"hold_reason"` // payouts held: the bank payout failed
+	HeldAt            *time.Time     `json:"held_at,omitempty" db:"held_at"`
+	CreatedAt         time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// UpdatePayoutSettingsRequest changes a driver's payout schedule
+type UpdatePayoutSettingsRequest struct {
+	Schedule  PayoutSchedule `json:"schedule" binding:"required"`
+	WeeklyDay *time.Weekday  `json:"weekly_day,omitempty"`
+}
+
+// SetPayoutAccountRequest links a driver to their payment provider account
+type SetPayoutAccountRequest struct {
+	ProviderAccountID string `json:"provider_account_id" binding:"required"`
+}
+
+// ErrTransferRejected marks a transfer the provider refused outright, such
+// as one to a closed account. Any other transfer error may have left money
+// moving, so the payout stays in processing and is retried with the same
+// idempotency key.
+var ErrTransferRejected = errors.New("transfer rejected")
+
+// PayoutProvider moves payouts to drivers' provider accounts and reports the
+// transfers it made. payments.StripePayoutProvider implements it over Stripe
+// Connect.
+type PayoutProvider interface {
+	// Transfer pays a payout out. Calls with the same idempotency key must
+	// return the original transfer rather than pay again. Errors that are
+	// certain to have paid nothing wrap ErrTransferRejected.
+	Transfer(ctx context.Context, req *TransferRequest) (*ProviderTransfer, error)
+	// ListTransfers returns the transfers created in [from, to)
+	ListTransfers(ctx context.Context, from, to time.Time) ([]ProviderTransfer, error)
+}
+
+// LedgerPoster records double-entry journal entries for money movements.
+// Implemented by *ledger.Service.
+type LedgerPoster interface {
+	Post(ctx context.Context, entry *ledger.JournalEntry) error
+}
+
+// TransferRequest is one payout sent to the provider
+type TransferRequest struct {
+	PayoutID       uuid.UUID
+	DriverID       uuid.UUID
+	Amount         money.Money
+	Destination    string // provider account ID
+	Description    string
+	IdempotencyKey string
+}
+
+// ProviderTransfer is a transfer as the provider reports it
+type ProviderTransfer struct {
+	ID          string      `json:"id"`
+	PayoutID    *uuid.UUID  `json:"payout_id,omitempty"` // from the transfer's metadata
+	Destination string      `json:"destination"`
+	Amount      money.Money `json:"amount"`
+	Reversed    bool        `json:"reversed"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// ProviderPayout is the provider paying a driver's account balance out to
+// their bank, as reported by its webhooks. Transfers land in the account;
+// payouts move them on to the bank.
+type ProviderPayout struct {
+	ID             string
+	AccountID      string
+	Amount         money.Money
+	Paid           bool // false when the payout failed
+	FailureCode    string
+	FailureMessage string
+}
+
+// PayoutMismatchType is a way a provider transfer and a payout disagree
+type PayoutMismatchType string
+
+const (
+	MismatchMissingTransfer PayoutMismatchType = "missing_transfer" // payout completed, provider has no transfer
+	MismatchUnknownTransfer PayoutMismatchType = "unknown_transfer" // provider transfer matches no payout
+	MismatchAmount          PayoutMismatchType = "amount_mismatch"
+	MismatchReversed        PayoutMismatchType = "reversed"           // provider reversed the transfer
+	MismatchStatus          PayoutMismatchType = "status_mismatch"    // transfer exists but the payout is not completed
+	MismatchBankPayout      PayoutMismatchType = "bank_payout_failed" // provider could not pay the account out to the bank
+)
+
+// PayoutMismatch is a discrepancy found by reconciliation. Amounts are in
+// minor units of Currency.
+type PayoutMismatch struct {
+	ID                 uuid.UUID          `json:"id" db:"id"`
+	PayoutID           *uuid.UUID         `json:"payout_id,omitempty" db:"payout_id"`
+	ProviderTransferID *string            `json:"provider_transfer_id,omitempty" db:"provider_transfer_id"`
+	ProviderPayoutID   *string            `json:"provider_payout_id,omitempty" db:"provider_payout_id"`
+	Type               PayoutMismatchType `json:"type" db:"mismatch_type"`
+	ExpectedAmount     *int64             `json:"expected_amount_minor,omitempty" db:"expected_amount_minor"`
+	ProviderAmount     *int64             `json:"provider_amount_minor,omitempty" db:"provider_amount_minor"`
+	Currency           string             `json:"currency" db:"currency"`
+	Details            string             `json:"details" db:"details"`
+	Resolved           bool               `json:"resolved" db:"resolved"`
+	ResolvedBy         *uuid.UUID         `json:"resolved_by,omitempty" db:"resolved_by"`
+	ResolvedAt         *time.Time         `json:"resolved_at,omitempty" db:"resolved_at"`
+	DetectedAt         time.Time          `json:"detected_at" db:"detected_at"`
+}
+
+// batchPayout is an unpaid batch payout and where it is to be paid
+type batchPayout struct {
+	DriverPayout
+	ProviderAccountID string
+}
+
+// ReconciliationReport summarizes one reconciliation run
+type ReconciliationReport struct {
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Transfers  int              `json:"transfers"`
+	Payouts    int              `json:"payouts"`
+	Matched    int              `json:"matched"`
+	Mismatches []PayoutMismatch `json:"mismatches"`
+}
+
+// SetPayoutProvider enables scheduled payout batches, instant payouts and
+// reconciliation against the provider
+func (s *Service) SetPayoutProvider(provider PayoutProvider) {
+	s.provider = provider
+}
+
+// SetLedger records completed payouts in the double-entry ledger
+func (s *Service) SetLedger(l LedgerPoster) {
+	s.ledger = l
+}
+
+// ========================================
+// SETTINGS
+// ========================================
+
+// GetPayoutSettings returns a driver's payout settings, defaulting to weekly
+// payouts on Monday
+func (s *Service) GetPayoutSettings(ctx context.Context, driverID uuid.UUID) (*DriverPayoutSettings, error) {
+	settings, err := s.repo.GetPayoutSettings(ctx, driverID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &DriverPayoutSettings{DriverID: driverID, Schedule: PayoutScheduleWeekly, WeeklyDay: time.Monday}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get payout settings: %w", err)
+	}
+	return settings, nil
+}
+
+// UpdatePayoutSettings changes a driver's payout schedule
+func (s *Service) UpdatePayoutSettings(ctx context.Context, driverID uuid.UUID, req *UpdatePayoutSettingsRequest) (*DriverPayoutSettings, error) {
+	switch req.Schedule {
+	case PayoutScheduleDaily, PayoutScheduleWeekly, PayoutScheduleInstant:
+	default:
+		return nil, common.NewBadRequestError("schedule must be daily, weekly, or instant", nil)
+	}
+
+	settings, err := s.GetPayoutSettings(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	settings.Schedule = req.Schedule
+	if req.WeeklyDay != nil {
+		if *req.WeeklyDay < time.Sunday || *req.WeeklyDay > time.Saturday {
+			return nil, common.NewBadRequestError("weekly_day must be between 0 (Sunday) and 6 (Saturday)", nil)
+		}
+		settings.WeeklyDay = *req.WeeklyDay
+	}
+
+	if err := s.repo.UpsertPayoutSettings(ctx, settings); err != nil {
+		return nil, fmt.Errorf("update payout settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SetPayoutAccount links a driver to their payment provider account and
+// lifts any hold on their payouts (admin only)
+func (s *Service) SetPayoutAccount(ctx context.Context, driverID uuid.UUID, providerAccountID string) (*DriverPayoutSettings, error) {
+	providerAccountID = strings.TrimSpace(providerAccountID)
+	if providerAccountID == "" {
+		return nil, common.NewBadRequestError("provider_account_id cannot be empty", nil)
+	}
+
+	settings, err := s.GetPayoutSettings(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	settings.ProviderAccountID = &providerAccountID
+
+	if err := s.repo.UpsertPayoutSettings(ctx, settings); err != nil {
+		return nil, fmt.Errorf("set payout account: %w", err)
+	}
+	if settings.HeldAt != nil {
+		if _, err := s.repo.ReleasePayoutHold(ctx, driverID); err != nil {
+			return nil, fmt.Errorf("release payout hold: %w", err)
+		}
+		settings.HoldReason, settings.HeldAt = nil, nil
+	}
+	return settings, nil
+}
+
+// ========================================
+// BATCHES
+// ========================================
+
+// payoutBatch returns the cut-off and idempotency key of the batch due for a
+// driver at now. Batches cut off at midnight UTC and include every unpaid
+// earning from before then: daily batches every day, weekly batches on the
+// driver's weekly day. It returns false when no batch is due.
+func payoutBatch(settings *DriverPayoutSettings, now time.Time) (time.Time, string, bool) {
+	now = now.UTC()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch settings.Schedule {
+	case PayoutScheduleDaily:
+	case PayoutScheduleWeekly:
+		if now.Weekday() != settings.WeeklyDay {
+			return time.Time{}, "", false
+		}
+	default:
+		return time.Time{}, "", false
+	}
+
+	key := fmt.Sprintf("%s:%s:%s", settings.Schedule, settings.DriverID, cutoff.Format("2006-01-02"))
+	return cutoff, key, true
+}
+
+// instantPayoutFee returns the fee for paying amount out instantly, in its
+// currency
+func instantPayoutFee(amount money.Money) money.Money {
+	fee := amount.MulRate(instantPayoutFeeRate, money.RoundHalfUp)
+	if min := money.FromMajor(instantPayoutFeeMin, amount.Currency); fee.Amount < min.Amount {
+		return min
+	}
+	return fee
+}
+
+// RunPayoutBatches creates the payout batches due at now and pays them, along
+// with any batch an earlier run left unpaid. Each batch is keyed by driver,
+// schedule and cut-off, so running it more than once a day creates nothing
+// new, and a batch interrupted mid-transfer is retried with the same
+// idempotency key so the provider pays it only once.
+func (s *Service) RunPayoutBatches(ctx context.Context, now time.Time) error {
+	if s.provider == nil {
+		return fmt.Errorf("no payout provider configured")
+	}
+
+	settings, err := s.repo.GetScheduledPayoutSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("get payout settings: %w", err)
+	}
+
+	var created int
+	for i := range settings {
+		cutoff, key, due := payoutBatch(&settings[i], now)
+		if !due {
+			continue
+		}
+		payout := &DriverPayout{
+			ID:             uuid.New(),
+			DriverID:       settings[i].DriverID,
+			Currency:       defaultCurrency,
+			Method:         PayoutMethodBankTransfer,
+			Status:         PayoutStatusPending,
+			Reference:      generatePayoutReference(),
+			PeriodEnd:      cutoff,
+			Schedule:       settings[i].Schedule,
+			IdempotencyKey: key,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		ok, err := s.repo.CreateBatchPayout(ctx, payout, minPayoutAmount)
+		if err != nil {
+			logger.ErrorContext(ctx, "failed to create payout batch", zap.String("driver_id", payout.DriverID.String()), zap.Error(err))
+			continue
+		}
+		if ok {
+			created++
+		}
+	}
+
+	payouts, err := s.repo.GetUnpaidBatchPayouts(ctx, now.Add(-stalePayoutAfter))
+	if err != nil {
+		return fmt.Errorf("get unpaid payout batches: %w", err)
+	}
+	var paid, failed int
+	for i := range payouts {
+		if err := s.executePayout(ctx, &payouts[i].DriverPayout, payouts[i].ProviderAccountID, now); err != nil {
+			failed++
+			continue
+		}
+		paid++
+	}
+
+	logger.InfoContext(ctx, "payout batches processed",
+		zap.Int("created", created), zap.Int("paid", paid), zap.Int("failed", failed))
+	return nil
+}
+
+// executePayout claims a payout and transfers it to the driver's provider
+// account. A rejected transfer fails the payout and releases its earnings
+// into the next batch. Any other transfer error leaves the payout in
+// processing, so a later run retries it with the same idempotency key once
+// the claim goes stale, rather than releasing earnings that may have been
+// paid.
+func (s *Service) executePayout(ctx context.Context, payout *DriverPayout, destination string, now time.Time) error {
+	claimed, err := s.repo.ClaimPayout(ctx, payout.ID, now.Add(-stalePayoutAfter))
+	if err != nil {
+		return fmt.Errorf("claim payout: %w", err)
+	}
+	if !claimed {
+		return nil // another run is paying it
+	}
+	payout.Status = PayoutStatusProcessing
+
+	transfer, err := s.provider.Transfer(ctx, &TransferRequest{
+		PayoutID:       payout.ID,
+		DriverID:       payout.DriverID,
+		Amount:         payout.AmountMoney(),
+		Destination:    destination,
+		Description:    fmt.Sprintf("Driver payout %s", payout.Reference),
+		IdempotencyKey: payout.IdempotencyKey,
+	})
+	if err != nil && !errors.Is(err, ErrTransferRejected) {
+		logger.WarnContext(ctx, "payout transfer outcome unknown, leaving it for retry",
+			zap.String("payout_id", payout.ID.String()), zap.String("driver_id", payout.DriverID.String()), zap.Error(err))
+		return fmt.Errorf("transfer payout: %w", err)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "payout transfer rejected",
+			zap.String("payout_id", payout.ID.String()), zap.String("driver_id", payout.DriverID.String()), zap.Error(err))
+		if failErr := s.repo.FailPayout(ctx, payout.ID, err.Error()); failErr != nil {
+			logger.ErrorContext(ctx, "failed to mark payout failed", zap.String("payout_id", payout.ID.String()), zap.Error(failErr))
+		}
+		payout.Status = PayoutStatusFailed
+		return fmt.Errorf("transfer payout: %w", err)
+	}
+
+	// Posted before the payout is marked completed, so a payout whose update
+	// fails is still on the books; the entry is keyed by payout ID, so the
+	// retry that completes it posts nothing twice
+	s.postPayout(ctx, payout)
+
+	if err := s.repo.CompletePayout(ctx, payout.ID, transfer.ID); err != nil {
+		// The money has moved; reconciliation flags the payout until this is fixed
+		logger.ErrorContext(ctx, "failed to mark payout completed",
+			zap.String("payout_id", payout.ID.String()), zap.String("transfer_id", transfer.ID), zap.Error(err))
+		return fmt.Errorf("complete payout: %w", err)
+	}
+	payout.Status = PayoutStatusCompleted
+	payout.ProviderTransferID = &transfer.ID
+	return nil
+}
+
+// payoutEntry is the journal entry for a payout paid out to the provider
+func payoutEntry(payout *DriverPayout) (*ledger.JournalEntry, error) {
+	return ledger.NewDriverPayoutEntry(payout.DriverID, payout.ID, payout.AmountMoney(), payout.FeeMoney())
+}
+
+// postPayout records a transferred payout in the ledger. The transfer is not
+// undone if posting fails; the ledger retries entries it could not store.
+func (s *Service) postPayout(ctx context.Context, payout *DriverPayout) {
+	if s.ledger == nil {
+		return
+	}
+	entry, err := payoutEntry(payout)
+	if err == nil {
+		err = s.ledger.Post(ctx, entry)
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to post payout to ledger",
+			zap.String("payout_id", payout.ID.String()), zap.Error(err))
+	}
+}
+
+// ========================================
+// RECONCILIATION
+// ========================================
+
+// reconcileTransfers matches provider transfers to payouts, by the payout ID
+// the transfer carries or else by transfer ID, and returns the IDs of payouts
+// that matched cleanly along with every mismatch. Payouts and transfers
+// outside [from, to) only serve as matches; they are not flagged themselves.
+func reconcileTransfers(payouts []DriverPayout, transfers []ProviderTransfer, from, to time.Time) ([]uuid.UUID, []PayoutMismatch) {
+	inWindow := func(t time.Time) bool { return !t.Before(from) && t.Before(to) }
+
+	byID := make(map[uuid.UUID]*DriverPayout, len(payouts))
+	byTransfer := make(map[string]*DriverPayout, len(payouts))
+	for i := range payouts {
+		byID[payouts[i].ID] = &payouts[i]
+		if payouts[i].ProviderTransferID != nil {
+			byTransfer[*payouts[i].ProviderTransferID] = &payouts[i]
+		}
+	}
+
+	var matched []uuid.UUID
+	var mismatches []PayoutMismatch
+	seen := make(map[uuid.UUID]bool, len(payouts))
+
+	for i := range transfers {
+		t := &transfers[i]
+		transferID := t.ID
+		providerAmount := t.Amount.Amount
+
+		var payout *DriverPayout
+		if t.PayoutID != nil {
+			payout = byID[*t.PayoutID]
+		}
+		if payout == nil {
+			payout = byTransfer[t.ID]
+		}
+		if payout == nil {
+			if inWindow(t.CreatedAt) {
+				mismatches = append(mismatches, PayoutMismatch{
+					PayoutID:           t.PayoutID,
+					ProviderTransferID: &transferID,
+					Type:               MismatchUnknownTransfer,
+					ProviderAmount:     &providerAmount,
+					Currency:           t.Amount.Currency,
+					Details:            fmt.Sprintf("transfer to %s matches no payout", t.Destination),
+				})
+			}
+			continue
+		}
+		seen[payout.ID] = true
+
+		payoutID := payout.ID
+		expected := payout.AmountMoney()
+		expectedAmount := expected.Amount
+		mismatch := PayoutMismatch{
+			PayoutID:           &payoutID,
+			ProviderTransferID: &transferID,
+			ExpectedAmount:     &expectedAmount,
+			ProviderAmount:     &providerAmount,
+			Currency:           expected.Currency,
+		}
+		switch {
+		case t.Reversed:
+			mismatch.Type = MismatchReversed
+			mismatch.Details = "provider reversed the transfer"
+		case payout.Status != PayoutStatusCompleted:
+			mismatch.Type = MismatchStatus
+			mismatch.Details = fmt.Sprintf("transfer exists but payout is %s", payout.Status)
+		case expected != t.Amount:
+			mismatch.Type = MismatchAmount
+			mismatch.Details = fmt.Sprintf("payout of %s, provider transferred %s", expected, t.Amount)
+		case payout.ProviderTransferID != nil && *payout.ProviderTransferID != t.ID:
+			mismatch.Type = MismatchStatus
+			mismatch.Details = fmt.Sprintf("payout records transfer %s", *payout.ProviderTransferID)
+		default:
+			matched = append(matched, payout.ID)
+			continue
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	for i := range payouts {
+		p := &payouts[i]
+		if seen[p.ID] || p.Status != PayoutStatusCompleted || p.ProviderTransferID == nil {
+			continue
+		}
+		if p.ProcessedAt == nil || !inWindow(*p.ProcessedAt) {
+			continue
+		}
+		payoutID := p.ID
+		expected := p.AmountMoney()
+		mismatches = append(mismatches, PayoutMismatch{
+			PayoutID:           &payoutID,
+			ProviderTransferID: p.ProviderTransferID,
+			Type:               MismatchMissingTransfer,
+			ExpectedAmount:     &expected.Amount,
+			Currency:           expected.Currency,
+			Details:            "provider reports no such transfer",
+		})
+	}
+
+	return matched, mismatches
+}
+
+// ReconcilePayouts matches the provider's transfers in [from, to) back to
+// payouts. Clean matches are marked reconciled; mismatches are recorded once
+// each for review.
+func (s *Service) ReconcilePayouts(ctx context.Context, from, to time.Time) (*ReconciliationReport, error) {
+	if s.provider == nil {
+		return nil, fmt.Errorf("no payout provider configured")
+	}
+
+	transfers, err := s.provider.ListTransfers(ctx, from.Add(-reconcileSlack), to.Add(reconcileSlack))
+	if err != nil {
+		return nil, fmt.Errorf("list provider transfers: %w", err)
+	}
+	payouts, err := s.repo.GetPayoutsForReconciliation(ctx, from.Add(-reconcileSlack), to.Add(reconcileSlack))
+	if err != nil {
+		return nil, fmt.Errorf("get payouts: %w", err)
+	}
+
+	// Transfers naming a payout outside the window still match it
+	known := make(map[uuid.UUID]bool, len(payouts))
+	for i := range payouts {
+		known[payouts[i].ID] = true
+	}
+	for _, t := range transfers {
+		if t.PayoutID == nil || known[*t.PayoutID] {
+			continue
+		}
+		if payout, err := s.repo.GetPayoutByID(ctx, *t.PayoutID); err == nil {
+			payouts = append(payouts, *payout)
+			known[payout.ID] = true
+		}
+	}
+
+	matched, mismatches := reconcileTransfers(payouts, transfers, from, to)
+
+	if err := s.repo.MarkPayoutsReconciled(ctx, matched); err != nil {
+		return nil, fmt.Errorf("mark payouts reconciled: %w", err)
+	}
+	recorded := make([]PayoutMismatch, 0, len(mismatches))
+	for i := range mismatches {
+		created, err := s.repo.RecordPayoutMismatch(ctx, &mismatches[i])
+		if err != nil {
+			return nil, fmt.Errorf("record payout mismatch: %w", err)
+		}
+		if created {
+			recorded = append(recorded, mismatches[i])
+			logger.WarnContext(ctx, "payout reconciliation mismatch",
+				zap.String("type", string(mismatches[i].Type)), zap.String("details", mismatches[i].Details))
+		}
+	}
+
+	return &ReconciliationReport{
+		From:       from,
+		To:         to,
+		Transfers:  len(transfers),
+		Payouts:    len(payouts),
+		Matched:    len(matched),
+		Mismatches: recorded,
+	}, nil
+}
+
+// RunPayoutReconciliation reconciles the two days up to an hour before now.
+// Consecutive daily runs overlap; a mismatch is only recorded once.
+func (s *Service) RunPayoutReconciliation(ctx context.Context, now time.Time) error {
+	to := now.Add(-reconcileSlack)
+	report, err := s.ReconcilePayouts(ctx, to.Add(-reconcileWindow), to)
+	if err != nil {
+		return err
+	}
+	logger.InfoContext(ctx, "payout reconciliation completed",
+		zap.Int("transfers", report.Transfers), zap.Int("payouts", report.Payouts),
+		zap.Int("matched", report.Matched), zap.Int("new_mismatches", len(report.Mismatches)))
+	return nil
+}
+
+// GetPayoutMismatches lists reconciliation mismatches (admin only)
+func (s *Service) GetPayoutMismatches(ctx context.Context, resolved bool, limit, offset int) ([]PayoutMismatch, int64, error) {
+	return s.repo.GetPayoutMismatches(ctx, resolved, limit, offset)
+}
+
+// ResolvePayoutMismatch marks a mismatch as dealt with (admin only)
+func (s *Service) ResolvePayoutMismatch(ctx context.Context, mismatchID, adminID uuid.UUID) error {
+	resolved, err := s.repo.ResolvePayoutMismatch(ctx, mismatchID, adminID)
+	if err != nil {
+		return fmt.Errorf("resolve payout mismatch: %w", err)
+	}
+	if !resolved {
+		return common.NewNotFoundError("unresolved mismatch not found", nil)
+	}
+	return nil
+}
+
+// ========================================
+// BANK PAYOUTS
+// ========================================
+
+// bankPayoutMismatch is the mismatch recorded for finance when the provider
+// fails to pay a driver's account out to their bank
+func bankPayoutMismatch(driverID uuid.UUID, payout *ProviderPayout) *PayoutMismatch {
+	amount := payout.Amount.Amount
+	details := fmt.Sprintf("bank payout %s to driver %s failed", payout.ID, driverID)
+	if payout.FailureCode != "" {
+		details += fmt.Sprintf(" (%s)", payout.FailureCode)
+	}
+	if payout.FailureMessage != "" {
+		details += ": " + payout.FailureMessage
+	}
+	return &PayoutMismatch{
+		ProviderPayoutID: &payout.ID,
+		Type:             MismatchBankPayout,
+		ProviderAmount:   &amount,
+		Currency:         payout.Amount.Currency,
+		Details:          details,
+	}
+}
+
+// HandleProviderPayout applies a bank payout reported by the provider. A
+// failed payout leaves the money in the driver's provider account, so their
+// payouts are held, keeping their earnings unpaid here, and a mismatch is
+// recorded for finance. The hold lifts when a later payout to the account
+// succeeds or the driver's account is changed. Payouts from accounts not
+// linked to a driver are ignored.
+func (s *Service) HandleProviderPayout(ctx context.Context, payout *ProviderPayout) error {
+	settings, err := s.repo.GetPayoutSettingsByAccount(ctx, payout.AccountID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		logger.WarnContext(ctx, "provider payout for unknown account",
+			zap.String("payout_id", payout.ID), zap.String("account_id", payout.AccountID))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get payout settings: %w", err)
+	}
+
+	if payout.Paid {
+		released, err := s.repo.ReleasePayoutHold(ctx, settings.DriverID)
+		if err != nil {
+			return fmt.Errorf("release payout hold: %w", err)
+		}
+		if released {
+			logger.InfoContext(ctx, "driver payouts resumed after bank payout succeeded",
+				zap.String("driver_id", settings.DriverID.String()), zap.String("payout_id", payout.ID))
+		}
+		return nil
+	}
+
+	mismatch := bankPayoutMismatch(settings.DriverID, payout)
+	if err := s.repo.HoldPayouts(ctx, settings.DriverID, mismatch.Details); err != nil {
+		return fmt.Errorf("hold payouts: %w", err)
+	}
+	if _, err := s.repo.RecordPayoutMismatch(ctx, mismatch); err != nil {
+		return fmt.Errorf("record bank payout failure: %w", err)
+	}
+	logger.WarnContext(ctx, "bank payout failed, driver payouts held",
+		zap.String("driver_id", settings.DriverID.String()),
+		zap.String("payout_id", payout.ID),
+		zap.String("failure_code", payout.FailureCode))
+	return nil
+}
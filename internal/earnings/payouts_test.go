@@ -0,0 +1,137 @@
+package earnings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayoutBatch(t *testing.T) {
+	driverID := uuid.New()
+	// Wednesday
+	now := time.Date(2026, 3, 4, 15, 30, 0, 0, time.UTC)
+	midnight := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	cutoff, key, due := payoutBatch(&DriverPayoutSettings{DriverID: driverID, Schedule: PayoutScheduleDaily}, now)
+	require.True(t, due)
+	assert.Equal(t, midnight, cutoff)
+	assert.Equal(t, "daily:"+driverID.String()+":2026-03-04", key)
+
+	// Later runs the same day create the same batch
+	_, again, _ := payoutBatch(&DriverPayoutSettings{DriverID: driverID, Schedule: PayoutScheduleDaily}, now.Add(6*time.Hour))
+	assert.Equal(t, key, again)
+
+	_, _, due = payoutBatch(&DriverPayoutSettings{DriverID: driverID, Schedule: PayoutScheduleWeekly, WeeklyDay: time.Monday}, now)
+	assert.False(t, due)
+
+	cutoff, key, due = payoutBatch(&DriverPayoutSettings{DriverID: driverID, Schedule: PayoutScheduleWeekly, WeeklyDay: time.Wednesday}, now)
+	require.True(t, due)
+	assert.Equal(t, midnight, cutoff)
+	assert.Equal(t, "weekly:"+driverID.String()+":2026-03-04", key)
+
+	_, _, due = payoutBatch(&DriverPayoutSettings{DriverID: driverID, Schedule: PayoutScheduleInstant}, now)
+	assert.False(t, due)
+}
+
+func TestInstantPayoutFee(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	assert.Equal(t, usd(150), instantPayoutFee(usd(10000)))
+	assert.Equal(t, usd(50), instantPayoutFee(usd(1250)))
+	assert.Equal(t, usd(375), instantPayoutFee(usd(25000)))
+	// The minimum is in the payout's currency
+	assert.Equal(t, money.New(1, "JPY"), instantPayoutFee(money.New(50, "JPY")))
+}
+
+func TestPayoutEntry(t *testing.T) {
+	payout := &DriverPayout{ID: uuid.New(), DriverID: uuid.New(), Amount: 98.50, Fee: 1.50, Currency: "USD"}
+
+	entry, err := payoutEntry(payout)
+	require.NoError(t, err)
+	assert.Equal(t, "payout", entry.ReferenceType)
+	assert.Equal(t, payout.ID, *entry.ReferenceID)
+	for _, p := range entry.Postings {
+		if p.AccountCode == ledger.AccountDriverPayable {
+			assert.Equal(t, int64(10000), p.Amount.Amount)
+		}
+	}
+}
+
+func TestReconcileTransfers(t *testing.T) {
+	from := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	at := from.Add(12 * time.Hour)
+	str := func(s string) *string { return &s }
+
+	payout := func(amount float64, status PayoutStatus, transferID *string) DriverPayout {
+		processed := at
+		return DriverPayout{ID: uuid.New(), Amount: amount, Currency: "USD", Status: status, ProviderTransferID: transferID, ProcessedAt: &processed}
+	}
+	transfer := func(id string, payoutID *uuid.UUID, amount float64) ProviderTransfer {
+		return ProviderTransfer{ID: id, PayoutID: payoutID, Amount: money.FromMajor(amount, "usd"), CreatedAt: at}
+	}
+
+	clean := payout(80, PayoutStatusCompleted, str("tr_clean"))
+	short := payout(50, PayoutStatusCompleted, str("tr_short"))
+	reversed := payout(20, PayoutStatusCompleted, str("tr_rev"))
+	failed := payout(30, PayoutStatusFailed, nil)
+	missing := payout(40, PayoutStatusCompleted, str("tr_missing"))
+	pending := payout(10, PayoutStatusPending, nil)
+
+	reversedTransfer := transfer("tr_rev", &reversed.ID, 20)
+	reversedTransfer.Reversed = true
+	early := transfer("tr_early", nil, 5)
+	early.CreatedAt = from.Add(-30 * time.Minute)
+
+	matched, mismatches := reconcileTransfers(
+		[]DriverPayout{clean, short, reversed, failed, missing, pending},
+		[]ProviderTransfer{
+			transfer("tr_clean", nil, 80), // matched by transfer ID
+			transfer("tr_short", &short.ID, 45),
+			reversedTransfer,
+			transfer("tr_failed", &failed.ID, 30),
+			transfer("tr_unknown", nil, 99),
+			early, // before the window: not flagged
+		},
+		from, to,
+	)
+
+	assert.Equal(t, []uuid.UUID{clean.ID}, matched)
+
+	byType := make(map[PayoutMismatchType]PayoutMismatch)
+	for _, m := range mismatches {
+		byType[m.Type] = m
+	}
+	require.Len(t, mismatches, 5)
+	assert.Equal(t, short.ID, *byType[MismatchAmount].PayoutID)
+	assert.Equal(t, int64(4500), *byType[MismatchAmount].ProviderAmount)
+	assert.Equal(t, int64(5000), *byType[MismatchAmount].ExpectedAmount)
+	assert.Equal(t, reversed.ID, *byType[MismatchReversed].PayoutID)
+	assert.Equal(t, failed.ID, *byType[MismatchStatus].PayoutID)
+	assert.Equal(t, "tr_unknown", *byType[MismatchUnknownTransfer].ProviderTransferID)
+	assert.Equal(t, "USD", byType[MismatchUnknownTransfer].Currency)
+	assert.Equal(t, missing.ID, *byType[MismatchMissingTransfer].PayoutID)
+}
+
+func TestBankPayoutMismatch(t *testing.T) {
+	driverID := uuid.New()
+	m := bankPayoutMismatch(driverID, &ProviderPayout{
+		ID:             "po_1",
+		AccountID:      "acct_1",
+		Amount:         money.New(8250, "usd"),
+		FailureCode:    "account_closed",
+		FailureMessage: "The bank account has been closed.",
+	})
+
+	assert.Equal(t, MismatchBankPayout, m.Type)
+	require.NotNil(t, m.ProviderPayoutID)
+	assert.Equal(t, "po_1", *m.ProviderPayoutID)
+	assert.Nil(t, m.PayoutID)
+	assert.Equal(t, int64(8250), *m.ProviderAmount)
+	assert.Equal(t, "USD", m.Currency)
+	assert.Equal(t, "bank payout po_1 to driver "+driverID.String()+" failed (account_closed): The bank account has been closed.", m.Details)
+}
@@ -6,7 +6,9 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 // Repository handles earnings data access
@@ -25,14 +27,21 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 
 // CreateEarning records a new earning
 func (r *Repository) CreateEarning(ctx context.Context, e *DriverEarning) error {
+	gross := money.FromMajor(e.GrossAmount, e.Currency)
+	commission := money.FromMajor(e.Commission, e.Currency)
+	net := money.FromMajor(e.NetAmount, e.Currency)
+	e.GrossAmount, e.Commission, e.NetAmount = gross.Major(), commission.Major(), net.Major()
+
 	_, err := r.db.Exec(ctx, `
 		INSERT INTO driver_earnings (
 			id, driver_id, ride_id, delivery_id, type,
-			gross_amount, commission, net_amount, currency,
+			gross_amount, commission, net_amount,
+			gross_amount_minor, commission_minor, net_amount_minor, currency,
 			description, is_paid_out, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
 		e.ID, e.DriverID, e.RideID, e.DeliveryID, e.Type,
-		e.GrossAmount, e.Commission, e.NetAmount, e.Currency,
+		e.GrossAmount, e.Commission, e.NetAmount,
+		gross.Amount, commission.Amount, net.Amount, e.Currency,
 		e.Description, e.IsPaidOut, e.CreatedAt,
 	)
 	return err
@@ -52,7 +61,7 @@ func (r *Repository) GetEarningsByDriver(ctx context.Context, driverID uuid.UUID
 
 	rows, err := r.db.Query(ctx, `
 		SELECT id, driver_id, ride_id, delivery_id, type,
-			gross_amount, commission, net_amount, currency,
+			gross_amount_minor, commission_minor, net_amount_minor, currency,
 			description, is_paid_out, payout_id, created_at
 		FROM driver_earnings
 		WHERE driver_id = $1 AND created_at >= $2 AND created_at < $3
@@ -68,13 +77,17 @@ func (r *Repository) GetEarningsByDriver(ctx context.Context, driverID uuid.UUID
 	var earnings []DriverEarning
 	for rows.Next() {
 		e := DriverEarning{}
+		var grossMinor, commissionMinor, netMinor int64
 		if err := rows.Scan(
 			&e.ID, &e.DriverID, &e.RideID, &e.DeliveryID, &e.Type,
-			&e.GrossAmount, &e.Commission, &e.NetAmount, &e.Currency,
+			&grossMinor, &commissionMinor, &netMinor, &e.Currency,
 			&e.Description, &e.IsPaidOut, &e.PayoutID, &e.CreatedAt,
 		); err != nil {
 			return nil, 0, err
 		}
+		e.GrossAmount = money.New(grossMinor, e.Currency).Major()
+		e.Commission = money.New(commissionMinor, e.Currency).Major()
+		e.NetAmount = money.New(netMinor, e.Currency).Major()
 		earnings = append(earnings, e)
 	}
 	return earnings, total, nil
@@ -217,19 +230,52 @@ func (r *Repository) MarkEarningsAsPaidOut(ctx context.Context, driverID uuid.UU
 
 // CreatePayout creates a new payout record
 func (r *Repository) CreatePayout(ctx context.Context, p *DriverPayout) error {
+	amount, fee := p.AmountMoney(), p.FeeMoney()
+	p.Amount, p.Fee = amount.Major(), fee.Major()
+
 	_, err := r.db.Exec(ctx, `
 		INSERT INTO driver_payouts (
-			id, driver_id, amount, currency, method, status,
+			id, driver_id, amount, amount_minor, currency, method, status,
 			bank_account_id, reference, earning_count,
-			period_start, period_end, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
-		p.ID, p.DriverID, p.Amount, p.Currency, p.Method, p.Status,
+			period_start, period_end, created_at, updated_at,
+			schedule, fee, fee_minor, idempotency_key
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			NULLIF($15, ''), $16, $17, NULLIF($18, ''))`,
+		p.ID, p.DriverID, p.Amount, amount.Amount, p.Currency, p.Method, p.Status,
 		p.BankAccountID, p.Reference, p.EarningCount,
 		p.PeriodStart, p.PeriodEnd, p.CreatedAt, p.UpdatedAt,
+		string(p.Schedule), p.Fee, fee.Amount, p.IdempotencyKey,
 	)
 	return err
 }
 
+// payoutColumns are the driver_payouts columns scanPayout reads, on alias dp
+const payoutColumns = `dp.id, dp.driver_id, dp.amount_minor, dp.currency, dp.method, dp.status,
+			dp.bank_account_id, dp.reference, dp.earning_count,
+			dp.period_start, dp.period_end, dp.processed_at,
+			dp.failure_reason, dp.created_at, dp.updated_at,
+			COALESCE(dp.schedule, ''), dp.fee_minor, COALESCE(dp.idempotency_key, ''),
+			dp.provider_transfer_id, dp.reconciled_at`
+
+func scanPayout(row pgx.Row, extra ...any) (*DriverPayout, error) {
+	p := &DriverPayout{}
+	var amountMinor, feeMinor int64
+	dest := []any{
+		&p.ID, &p.DriverID, &amountMinor, &p.Currency, &p.Method, &p.Status,
+		&p.BankAccountID, &p.Reference, &p.EarningCount,
+		&p.PeriodStart, &p.PeriodEnd, &p.ProcessedAt,
+		&p.FailureReason, &p.CreatedAt, &p.UpdatedAt,
+		&p.Schedule, &feeMinor, &p.IdempotencyKey,
+		&p.ProviderTransferID, &p.ReconciledAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	p.Amount = money.New(amountMinor, p.Currency).Major()
+	p.Fee = money.New(feeMinor, p.Currency).Major()
+	return p, nil
+}
+
 // GetPayoutsByDriver returns payouts for a driver
 func (r *Repository) GetPayoutsByDriver(ctx context.Context, driverID uuid.UUID, limit, offset int) ([]DriverPayout, int, error) {
 	var total int
@@ -242,13 +288,10 @@ func (r *Repository) GetPayoutsByDriver(ctx context.Context, driverID uuid.UUID,
 	}
 
 	rows, err := r.db.Query(ctx, `
-		SELECT id, driver_id, amount, currency, method, status,
-			bank_account_id, reference, earning_count,
-			period_start, period_end, processed_at,
-			failure_reason, created_at, updated_at
-		FROM driver_payouts
-		WHERE driver_id = $1
-		ORDER BY created_at DESC
+		SELECT `+payoutColumns+`
+		FROM driver_payouts dp
+		WHERE dp.driver_id = $1
+		ORDER BY dp.created_at DESC
 		LIMIT $2 OFFSET $3`,
 		driverID, limit, offset,
 	)
@@ -259,16 +302,11 @@ func (r *Repository) GetPayoutsByDriver(ctx context.Context, driverID uuid.UUID,
 
 	var payouts []DriverPayout
 	for rows.Next() {
-		p := DriverPayout{}
-		if err := rows.Scan(
-			&p.ID, &p.DriverID, &p.Amount, &p.Currency, &p.Method, &p.Status,
-			&p.BankAccountID, &p.Reference, &p.EarningCount,
-			&p.PeriodStart, &p.PeriodEnd, &p.ProcessedAt,
-			&p.FailureReason, &p.CreatedAt, &p.UpdatedAt,
-		); err != nil {
+		p, err := scanPayout(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-		payouts = append(payouts, p)
+		payouts = append(payouts, *p)
 	}
 	return payouts, total, nil
 }
@@ -284,6 +322,413 @@ func (r *Repository) UpdatePayoutStatus(ctx context.Context, payoutID uuid.UUID,
 	return err
 }
 
+// ========================================
+// PAYOUT SETTINGS & BATCHES
+// ========================================
+
+// GetPayoutSettings returns a driver's payout settings
+func (r *Repository) GetPayoutSettings(ctx context.Context, driverID uuid.UUID) (*DriverPayoutSettings, error) {
+	return scanPayoutSettings(r.db.QueryRow(ctx, `
+		SELECT `+payoutSettingsColumns+`
+		FROM driver_payout_settings
+		WHERE driver_id = $1`,
+		driverID,
+	))
+}
+
+// GetPayoutSettingsByAccount returns the payout settings linked to a
+// provider account
+func (r *Repository) GetPayoutSettingsByAccount(ctx context.Context, providerAccountID string) (*DriverPayoutSettings, error) {
+	return scanPayoutSettings(r.db.QueryRow(ctx, `
+		SELECT `+payoutSettingsColumns+`
+		FROM driver_payout_settings
+		WHERE provider_account_id = $1
+		LIMIT 1`,
+		providerAccountID,
+	))
+}
+
+// payoutSettingsColumns are the driver_payout_settings columns
+// scanPayoutSettings reads
+const payoutSettingsColumns = `driver_id, schedule, weekly_day, provider_account_id,
+	hold_reason, held_at, created_at, updated_at`
+
+func scanPayoutSettings(row pgx.Row) (*DriverPayoutSettings, error) {
+	s := &DriverPayoutSettings{}
+	if err := row.Scan(
+		&s.DriverID, &s.Schedule, &s.WeeklyDay, &s.ProviderAccountID,
+		&s.HoldReason, &s.HeldAt, &s.CreatedAt, &s.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// UpsertPayoutSettings creates or replaces a driver's payout settings
+func (r *Repository) UpsertPayoutSettings(ctx context.Context, s *DriverPayoutSettings) error {
+	return r.db.QueryRow(ctx, `
+		INSERT INTO driver_payout_settings (driver_id, schedule, weekly_day, provider_account_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (driver_id) DO UPDATE SET
+			schedule = EXCLUDED.schedule,
+			weekly_day = EXCLUDED.weekly_day,
+			provider_account_id = EXCLUDED.provider_account_id,
+			updated_at = NOW()
+		RETURNING created_at, updated_at`,
+		s.DriverID, s.Schedule, int(s.WeeklyDay), s.ProviderAccountID,
+	).Scan(&s.CreatedAt, &s.UpdatedAt)
+}
+
+// GetScheduledPayoutSettings returns the settings of every driver paid out
+// daily or weekly to a provider account whose payouts are not held
+func (r *Repository) GetScheduledPayoutSettings(ctx context.Context) ([]DriverPayoutSettings, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+payoutSettingsColumns+`
+		FROM driver_payout_settings
+		WHERE schedule IN ('daily', 'weekly') AND provider_account_id IS NOT NULL AND held_at IS NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var settings []DriverPayoutSettings
+	for rows.Next() {
+		s, err := scanPayoutSettings(rows)
+		if err != nil {
+			return nil, err
+		}
+		settings = append(settings, *s)
+	}
+	return settings, rows.Err()
+}
+
+// HoldPayouts stops a driver's payouts until the hold is released
+func (r *Repository) HoldPayouts(ctx context.Context, driverID uuid.UUID, reason string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE driver_payout_settings
+		SET hold_reason = $2, held_at = COALESCE(held_at, NOW()), updated_at = NOW()
+		WHERE driver_id = $1`,
+		driverID, reason,
+	)
+	return err
+}
+
+// ReleasePayoutHold resumes a driver's payouts. It returns whether they were
+// held.
+func (r *Repository) ReleasePayoutHold(ctx context.Context, driverID uuid.UUID) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE driver_payout_settings
+		SET hold_reason = NULL, held_at = NULL, updated_at = NOW()
+		WHERE driver_id = $1 AND held_at IS NOT NULL`,
+		driverID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CreateBatchPayout creates a batch payout for the driver's unpaid earnings
+// from before p.PeriodEnd and assigns them to it, in one transaction. It
+// returns false, creating nothing, if a payout with the same idempotency key
+// exists or the earnings come to less than minAmount.
+func (r *Repository) CreateBatchPayout(ctx context.Context, p *DriverPayout, minAmount float64) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO driver_payouts (
+			id, driver_id, amount, amount_minor, currency, method, status,
+			reference, earning_count, period_start, period_end,
+			created_at, updated_at, schedule, fee, idempotency_key
+		) VALUES ($1, $2, 0, 0, $3, $4, $5, $6, 0, $7, $7, $8, $8, $9, 0, $10)
+		ON CONFLICT (idempotency_key) DO NOTHING`,
+		p.ID, p.DriverID, p.Currency, p.Method, p.Status,
+		p.Reference, p.PeriodEnd, p.CreatedAt, p.Schedule, p.IdempotencyKey,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert payout: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	var total float64
+	var count int
+	var periodStart *time.Time
+	err = tx.QueryRow(ctx, `
+		WITH paid AS (
+			UPDATE driver_earnings
+			SET is_paid_out = true, payout_id = $2
+			WHERE driver_id = $1 AND is_paid_out = false AND created_at < $3
+			RETURNING net_amount, created_at
+		)
+		SELECT COALESCE(SUM(net_amount), 0), COUNT(*), MIN(created_at) FROM paid`,
+		p.DriverID, p.ID, p.PeriodEnd,
+	).Scan(&total, &count, &periodStart)
+	if err != nil {
+		return false, fmt.Errorf("assign earnings: %w", err)
+	}
+	if count == 0 || total < minAmount {
+		return false, nil
+	}
+
+	amount := money.FromMajor(total, p.Currency)
+	p.Amount = amount.Major()
+	p.EarningCount = count
+	p.PeriodStart = *periodStart
+	_, err = tx.Exec(ctx, `
+		UPDATE driver_payouts
+		SET amount = $2, amount_minor = $3, earning_count = $4, period_start = $5
+		WHERE id = $1`,
+		p.ID, p.Amount, amount.Amount, p.EarningCount, p.PeriodStart,
+	)
+	if err != nil {
+		return false, fmt.Errorf("update payout amount: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
+
+// GetUnpaidBatchPayouts returns the payouts waiting to be paid: pending
+// batch payouts, and batch or instant payouts left in processing since
+// before staleBefore
+func (r *Repository) GetUnpaidBatchPayouts(ctx context.Context, staleBefore time.Time) ([]batchPayout, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+payoutColumns+`, s.provider_account_id
+		FROM driver_payouts dp
+		JOIN driver_payout_settings s ON s.driver_id = dp.driver_id
+		WHERE s.provider_account_id IS NOT NULL
+		  AND s.held_at IS NULL
+		  AND ((dp.schedule IN ('daily', 'weekly') AND dp.status = 'pending')
+		    OR (dp.schedule IN ('daily', 'weekly', 'instant') AND dp.status = 'processing' AND dp.updated_at < $1))
+		ORDER BY dp.created_at`,
+		staleBefore,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payouts []batchPayout
+	for rows.Next() {
+		var account string
+		p, err := scanPayout(rows, &account)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, batchPayout{DriverPayout: *p, ProviderAccountID: account})
+	}
+	return payouts, rows.Err()
+}
+
+// ClaimPayout moves a payout to processing if it is pending or was left in
+// processing since before staleBefore. It returns false if another run holds
+// the payout or it is already settled.
+func (r *Repository) ClaimPayout(ctx context.Context, payoutID uuid.UUID, staleBefore time.Time) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE driver_payouts
+		SET status = 'processing', updated_at = NOW()
+		WHERE id = $1
+		  AND (status = 'pending' OR (status = 'processing' AND updated_at < $2))`,
+		payoutID, staleBefore,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CompletePayout records the provider transfer that paid a payout
+func (r *Repository) CompletePayout(ctx context.Context, payoutID uuid.UUID, transferID string) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE driver_payouts
+		SET status = 'completed', provider_transfer_id = $2, failure_reason = NULL,
+			processed_at = NOW(), updated_at = NOW()
+		WHERE id = $1`,
+		payoutID, transferID,
+	)
+	return err
+}
+
+// FailPayout fails a payout and releases its earnings so the next batch
+// picks them up
+func (r *Repository) FailPayout(ctx context.Context, payoutID uuid.UUID, reason string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE driver_payouts
+		SET status = 'failed', failure_reason = $2, processed_at = NOW(), updated_at = NOW()
+		WHERE id = $1`,
+		payoutID, reason,
+	); err != nil {
+		return fmt.Errorf("fail payout: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE driver_earnings
+		SET is_paid_out = false, payout_id = NULL
+		WHERE payout_id = $1`,
+		payoutID,
+	); err != nil {
+		return fmt.Errorf("release earnings: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// ========================================
+// RECONCILIATION
+// ========================================
+
+// GetPayoutsForReconciliation returns payouts sent to the provider, or
+// processed, in [from, to)
+func (r *Repository) GetPayoutsForReconciliation(ctx context.Context, from, to time.Time) ([]DriverPayout, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT `+payoutColumns+`
+		FROM driver_payouts dp
+		WHERE (dp.processed_at >= $1 AND dp.processed_at < $2)
+		   OR (dp.provider_transfer_id IS NOT NULL AND dp.updated_at >= $1 AND dp.updated_at < $2)`,
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payouts []DriverPayout
+	for rows.Next() {
+		p, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, *p)
+	}
+	return payouts, rows.Err()
+}
+
+// MarkPayoutsReconciled stamps payouts whose provider transfer matched
+func (r *Repository) MarkPayoutsReconciled(ctx context.Context, payoutIDs []uuid.UUID) error {
+	if len(payoutIDs) == 0 {
+		return nil
+	}
+	_, err := r.db.Exec(ctx, `
+		UPDATE driver_payouts
+		SET reconciled_at = NOW()
+		WHERE id = ANY($1) AND reconciled_at IS NULL`,
+		payoutIDs,
+	)
+	return err
+}
+
+// RecordPayoutMismatch records a mismatch unless the same one was recorded
+// before. It returns whether it was recorded.
+func (r *Repository) RecordPayoutMismatch(ctx context.Context, m *PayoutMismatch) (bool, error) {
+	m.ID = uuid.New()
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO payout_reconciliation_mismatches (
+			id, payout_id, provider_transfer_id, mismatch_type,
+			expected_amount, provider_amount, currency, details, provider_payout_id,
+			expected_amount_minor, provider_amount_minor
+		)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		WHERE NOT EXISTS (
+			SELECT 1 FROM payout_reconciliation_mismatches
+			WHERE mismatch_type = $4
+			  AND payout_id IS NOT DISTINCT FROM $2
+			  AND provider_transfer_id IS NOT DISTINCT FROM $3
+			  AND provider_payout_id IS NOT DISTINCT FROM $9
+		)
+		RETURNING detected_at`,
+		m.ID, m.PayoutID, m.ProviderTransferID, m.Type,
+		majorOrNil(m.ExpectedAmount, m.Currency), majorOrNil(m.ProviderAmount, m.Currency),
+		m.Currency, m.Details, m.ProviderPayoutID, m.ExpectedAmount, m.ProviderAmount,
+	).Scan(&m.DetectedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// majorOrNil converts an optional minor-unit amount for the DECIMAL columns
+func majorOrNil(minor *int64, currency string) *float64 {
+	if minor == nil {
+		return nil
+	}
+	major := money.New(*minor, currency).Major()
+	return &major
+}
+
+// GetPayoutMismatches lists resolved or unresolved mismatches, newest first
+func (r *Repository) GetPayoutMismatches(ctx context.Context, resolved bool, limit, offset int) ([]PayoutMismatch, int64, error) {
+	var total int64
+	err := r.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM payout_reconciliation_mismatches WHERE resolved = $1`,
+		resolved,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count payout mismatches: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, payout_id, provider_transfer_id, provider_payout_id, mismatch_type,
+			expected_amount_minor, provider_amount_minor, currency, details,
+			resolved, resolved_by, resolved_at, detected_at
+		FROM payout_reconciliation_mismatches
+		WHERE resolved = $1
+		ORDER BY detected_at DESC
+		LIMIT $2 OFFSET $3`,
+		resolved, limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get payout mismatches: %w", err)
+	}
+	defer rows.Close()
+
+	var mismatches []PayoutMismatch
+	for rows.Next() {
+		m := PayoutMismatch{}
+		if err := rows.Scan(
+			&m.ID, &m.PayoutID, &m.ProviderTransferID, &m.ProviderPayoutID, &m.Type,
+			&m.ExpectedAmount, &m.ProviderAmount, &m.Currency, &m.Details,
+			&m.Resolved, &m.ResolvedBy, &m.ResolvedAt, &m.DetectedAt,
+		); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan payout mismatch: %w", err)
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches, total, nil
+}
+
+// ResolvePayoutMismatch marks an unresolved mismatch resolved
+func (r *Repository) ResolvePayoutMismatch(ctx context.Context, mismatchID, adminID uuid.UUID) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE payout_reconciliation_mismatches
+		SET resolved = true, resolved_by = $2, resolved_at = NOW()
+		WHERE id = $1 AND resolved = false`,
+		mismatchID, adminID,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 // ========================================
 // BANK ACCOUNTS
 // ========================================
@@ -483,14 +928,11 @@ func (r *Repository) GetAllPayouts(ctx context.Context, limit, offset int, filte
 	}
 
 	query := fmt.Sprintf(`
-		SELECT dp.id, dp.driver_id, dp.amount, dp.currency, dp.method, dp.status,
-			dp.bank_account_id, dp.reference, dp.earning_count,
-			dp.period_start, dp.period_end, dp.processed_at,
-			dp.failure_reason, dp.created_at, dp.updated_at
+		SELECT %s
 		FROM driver_payouts dp
 		%s
 		ORDER BY dp.created_at DESC
-		LIMIT $%d OFFSET $%d`, whereClause, argIndex, argIndex+1)
+		LIMIT $%d OFFSET $%d`, payoutColumns, whereClause, argIndex, argIndex+1)
 
 	args = append(args, limit, offset)
 
@@ -502,35 +944,21 @@ func (r *Repository) GetAllPayouts(ctx context.Context, limit, offset int, filte
 
 	var payouts []DriverPayout
 	for rows.Next() {
-		p := DriverPayout{}
-		if err := rows.Scan(
-			&p.ID, &p.DriverID, &p.Amount, &p.Currency, &p.Method, &p.Status,
-			&p.BankAccountID, &p.Reference, &p.EarningCount,
-			&p.PeriodStart, &p.PeriodEnd, &p.ProcessedAt,
-			&p.FailureReason, &p.CreatedAt, &p.UpdatedAt,
-		); err != nil {
+		p, err := scanPayout(rows)
+		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan payout: %w", err)
 		}
-		payouts = append(payouts, p)
+		payouts = append(payouts, *p)
 	}
 	return payouts, total, nil
 }
 
 // GetPayoutByID retrieves a single payout by ID
 func (r *Repository) GetPayoutByID(ctx context.Context, payoutID uuid.UUID) (*DriverPayout, error) {
-	p := &DriverPayout{}
-	err := r.db.QueryRow(ctx, `
-		SELECT id, driver_id, amount, currency, method, status,
-			bank_account_id, reference, earning_count,
-			period_start, period_end, processed_at,
-			failure_reason, created_at, updated_at
-		FROM driver_payouts WHERE id = $1`, payoutID,
-	).Scan(
-		&p.ID, &p.DriverID, &p.Amount, &p.Currency, &p.Method, &p.Status,
-		&p.BankAccountID, &p.Reference, &p.EarningCount,
-		&p.PeriodStart, &p.PeriodEnd, &p.ProcessedAt,
-		&p.FailureReason, &p.CreatedAt, &p.UpdatedAt,
-	)
+	p, err := scanPayout(r.db.QueryRow(ctx, `
+		SELECT `+payoutColumns+`
+		FROM driver_payouts dp WHERE dp.id = $1`, payoutID,
+	))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payout: %w", err)
 	}
@@ -10,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 const (
@@ -20,7 +21,9 @@ const (
 
 // Service handles earnings business logic
 type Service struct {
-	repo *Repository
+	repo     *Repository
+	provider PayoutProvider
+	ledger   LedgerPoster
 }
 
 // NewService creates a new earnings service
@@ -39,8 +42,7 @@ func (s *Service) RecordRideEarning(ctx context.Context, driverID uuid.UUID, rid
 		rate = *commissionRate
 	}
 
-	commission := grossAmount * rate
-	netAmount := grossAmount - commission
+	grossAmount, commission, netAmount := splitCommission(grossAmount, rate)
 
 	earning := &DriverEarning{
 		ID:          uuid.New(),
@@ -142,10 +144,17 @@ func (s *Service) RecordSurge(ctx context.Context, driverID uuid.UUID, rideID uu
 	return earning, nil
 }
 
+// splitCommission splits a gross fare into commission and net earnings in
+// minor units: commission is rounded half-even and net takes the remainder.
+func splitCommission(grossAmount, rate float64) (gross, commission, net float64) {
+	g := money.FromMajor(grossAmount, defaultCurrency)
+	c, n := g.SplitRate(rate)
+	return g.Major(), c.Major(), n.Major()
+}
+
 // RecordDeliveryEarning records earnings from a delivery
 func (s *Service) RecordDeliveryEarning(ctx context.Context, driverID uuid.UUID, deliveryID uuid.UUID, grossAmount float64) (*DriverEarning, error) {
-	commission := grossAmount * defaultCommissionRate
-	netAmount := grossAmount - commission
+	grossAmount, commission, netAmount := splitCommission(grossAmount, defaultCommissionRate)
 
 	earning := &DriverEarning{
 		ID:          uuid.New(),
@@ -289,6 +298,21 @@ func (s *Service) RequestPayout(ctx context.Context, driverID uuid.UUID, req *Re
 		}
 	}
 
+	// Instant payouts go straight to the driver's provider account, less a fee
+	var settings *DriverPayoutSettings
+	if req.Method == PayoutMethodInstantPay {
+		settings, err = s.GetPayoutSettings(ctx, driverID)
+		if err != nil {
+			return nil, err
+		}
+		if s.provider == nil || settings.ProviderAccountID == nil {
+			return nil, common.NewBadRequestError("instant payouts are not available for this account", nil)
+		}
+		if settings.HeldAt != nil {
+			return nil, common.NewBadRequestError("payouts are on hold until your payout account is fixed", nil)
+		}
+	}
+
 	now := time.Now()
 	payout := &DriverPayout{
 		ID:        uuid.New(),
@@ -302,6 +326,14 @@ func (s *Service) RequestPayout(ctx context.Context, driverID uuid.UUID, req *Re
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
+	payout.IdempotencyKey = fmt.Sprintf("request:%s", payout.ID)
+	if req.Method == PayoutMethodInstantPay {
+		payout.Schedule = PayoutScheduleInstant
+		unpaid := money.FromMajor(unpaidTotal, defaultCurrency)
+		fee := instantPayoutFee(unpaid)
+		net, _ := unpaid.Sub(fee)
+		payout.Fee, payout.Amount = fee.Major(), net.Major()
+	}
 
 	// Set period start to earliest unpaid earning
 	payout.PeriodStart = now.AddDate(0, -1, 0) // Fallback to 1 month ago
@@ -317,6 +349,13 @@ func (s *Service) RequestPayout(ctx context.Context, driverID uuid.UUID, req *Re
 	}
 	payout.EarningCount = int(count)
 
+	if settings != nil {
+		// A rejected transfer is recorded on the payout, and one whose outcome
+		// is unknown is left processing for the batch run to retry; either
+		// way the payout is returned as is
+		_ = s.executePayout(ctx, payout, *settings.ProviderAccountID, now)
+	}
+
 	return payout, nil
 }
 
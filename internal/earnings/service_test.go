@@ -42,6 +42,13 @@ func TestCommissionCalculation(t *testing.T) {
 			expectedCommission: 15.0,
 			expectedNet:        85.0,
 		},
+		{
+			name:               "sub-cent commission rounds and net takes the remainder",
+			grossAmount:        6.78,
+			commissionRate:     defaultCommissionRate,
+			expectedCommission: 1.36,
+			expectedNet:        5.42,
+		},
 		{
 			name:               "zero fare",
 			grossAmount:        0.0,
@@ -53,11 +60,11 @@ func TestCommissionCalculation(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			commission := tt.grossAmount * tt.commissionRate
-			net := tt.grossAmount - commission
+			gross, commission, net := splitCommission(tt.grossAmount, tt.commissionRate)
 
-			assert.InDelta(t, tt.expectedCommission, commission, 0.01)
-			assert.InDelta(t, tt.expectedNet, net, 0.01)
+			assert.Equal(t, tt.grossAmount, gross)
+			assert.Equal(t, tt.expectedCommission, commission)
+			assert.Equal(t, tt.expectedNet, net)
 		})
 	}
 }
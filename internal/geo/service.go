@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	pkggeo "github.com/richxcame/ride-hailing/pkg/geo"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	redisClient "github.com/richxcame/ride-hailing/pkg/redis"
@@ -28,6 +30,9 @@ const (
 	h3CellDriversTTL    = 5 * time.Minute
 	h3SurgePrefix       = "h3:surge:"  // Surge data per H3 cell (resolution 8)
 	h3DemandPrefix      = "h3:demand:" // Demand counters per H3 cell (resolution 7)
+
+	// Location events are published at most this often per driver
+	locationEventMinInterval = 5 * time.Second
 )
 
 // DriverLocation represents a driver's location
@@ -64,11 +69,18 @@ type Service struct {
 	redis          redisClient.ClientInterface
 	locationBuffer *LocationBuffer
 	etaTracker     *ETATracker
+	eventBus       *eventbus.Bus
+
+	mu                sync.Mutex
+	lastLocationEvent map[uuid.UUID]time.Time // driverID -> last published location event
 }
 
 // NewService creates a new geo service
 func NewService(redis redisClient.ClientInterface) *Service {
-	return &Service{redis: redis}
+	return &Service{
+		redis:             redis,
+		lastLocationEvent: make(map[uuid.UUID]time.Time),
+	}
 }
 
 // SetLocationBuffer enables batched location writes.
@@ -81,6 +93,12 @@ func (s *Service) SetETATracker(tracker *ETATracker) {
 	s.etaTracker = tracker
 }
 
+// SetEventBus enables publishing driver location events for consumers such as
+// the safety service's trip anomaly detector.
+func (s *Service) SetEventBus(bus *eventbus.Bus) {
+	s.eventBus = bus
+}
+
 // UpdateDriverLocation updates a driver's current location with H3 indexing
 func (s *Service) UpdateDriverLocation(ctx context.Context, driverID uuid.UUID, latitude, longitude float64) error {
 	return s.UpdateDriverLocationFull(ctx, driverID, latitude, longitude, 0, 0)
@@ -96,6 +114,8 @@ func (s *Service) UpdateDriverLocationFull(ctx context.Context, driverID uuid.UU
 		go s.etaTracker.OnDriverLocationUpdate(ctx, driverID, latitude, longitude, heading, speed)
 	}
 
+	s.publishLocationUpdated(driverID, latitude, longitude, heading, speed, h3Cell)
+
 	// Use buffered pipeline when available (high-throughput path)
 	if s.locationBuffer != nil {
 		s.locationBuffer.Enqueue(LocationUpdate{
@@ -146,6 +166,44 @@ func (s *Service) UpdateDriverLocationFull(ctx context.Context, driverID uuid.UU
 	return nil
 }
 
+// publishLocationUpdated publishes the driver's location asynchronously, at
+// most once per locationEventMinInterval per driver
+func (s *Service) publishLocationUpdated(driverID uuid.UUID, latitude, longitude, heading, speed float64, h3Cell string) {
+	if s.eventBus == nil {
+		return
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	if last, ok := s.lastLocationEvent[driverID]; ok && now.Sub(last) < locationEventMinInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastLocationEvent[driverID] = now
+	s.mu.Unlock()
+
+	go func() {
+		evt, err := eventbus.NewEvent("driver.location.updated", "geo-service", eventbus.DriverLocationUpdatedData{
+			DriverID:  driverID,
+			Latitude:  latitude,
+			Longitude: longitude,
+			Heading:   heading,
+			Speed:     speed,
+			H3Cell:    h3Cell,
+			Timestamp: now.UTC(),
+		})
+		if err != nil {
+			logger.Warn("failed to create driver location event", zap.String("driver_id", driverID.String()), zap.Error(err))
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.eventBus.Publish(ctx, eventbus.SubjectDriverLocationUpdated, evt); err != nil {
+			logger.Warn("failed to publish driver location event", zap.String("driver_id", driverID.String()), zap.Error(err))
+		}
+	}()
+}
+
 // updateDriverH3Cell manages the H3 cell-based driver index
 func (s *Service) updateDriverH3Cell(ctx context.Context, driverID uuid.UUID, newCell string) {
 	driverIDStr := driverID.String()
@@ -92,6 +92,11 @@ func (m *MockGiftCardsRepository) GetTotalBalance(ctx context.Context, userID uu
 	return args.Get(0).(float64), args.Error(1)
 }
 
+func (m *MockGiftCardsRepository) RefundRide(ctx context.Context, rideID uuid.UUID) (float64, error) {
+	args := m.Called(ctx, rideID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
 func (m *MockGiftCardsRepository) ExpireCards(ctx context.Context) (int64, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(int64), args.Error(1)
@@ -20,6 +20,7 @@ type RepositoryInterface interface {
 	// Transaction operations
 	CreateTransaction(ctx context.Context, tx *GiftCardTransaction) error
 	GetTransactionsByUser(ctx context.Context, userID uuid.UUID, limit int) ([]GiftCardTransaction, error)
+	RefundRide(ctx context.Context, rideID uuid.UUID) (float64, error)
 
 	// Balance and admin operations
 	GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error)
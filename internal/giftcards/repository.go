@@ -2,6 +2,8 @@ package giftcards
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -197,6 +199,82 @@ func (r *Repository) CreateTransaction(ctx context.Context, tx *GiftCardTransact
 	return err
 }
 
+// RefundRide returns what a ride took from each gift card to its balance,
+// reactivating cards the ride used up, and records a refund transaction per
+// card. It returns the total refunded; refunding a ride twice refunds nothing.
+func (r *Repository) RefundRide(ctx context.Context, rideID uuid.UUID) (float64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialize refunds of the same ride
+	if _, err := tx.Exec(ctx, `SELECT id FROM gift_card_transactions WHERE ride_id = $1 FOR UPDATE`, rideID); err != nil {
+		return 0, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT card_id, user_id, SUM(amount)
+		FROM gift_card_transactions
+		WHERE ride_id = $1
+		GROUP BY card_id, user_id
+		HAVING SUM(amount) > 0`,
+		rideID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	var spent []GiftCardTransaction
+	for rows.Next() {
+		var t GiftCardTransaction
+		if err := rows.Scan(&t.CardID, &t.UserID, &t.Amount); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		spent = append(spent, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	for _, t := range spent {
+		var balanceAfter float64
+		err := tx.QueryRow(ctx, `
+			UPDATE gift_cards
+			SET remaining_amount = remaining_amount + $2,
+				status = CASE WHEN status = $3 THEN $4 ELSE status END,
+				updated_at = NOW()
+			WHERE id = $1
+			RETURNING remaining_amount`,
+			t.CardID, t.Amount, CardStatusRedeemed, CardStatusActive,
+		).Scan(&balanceAfter)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO gift_card_transactions (
+				id, card_id, user_id, ride_id, amount,
+				balance_before, balance_after, description, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			uuid.New(), t.CardID, t.UserID, rideID, -t.Amount,
+			balanceAfter-t.Amount, balanceAfter, "Ride payment refund", time.Now(),
+		)
+		if err != nil {
+			return 0, err
+		}
+		total += t.Amount
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return total, nil
+}
+
 // GetTransactionsByUser retrieves recent transactions for a user
 func (r *Repository) GetTransactionsByUser(ctx context.Context, userID uuid.UUID, limit int) ([]GiftCardTransaction, error) {
 	rows, err := r.db.Query(ctx, `
@@ -210,6 +210,13 @@ func (s *Service) UseBalance(ctx context.Context, userID uuid.UUID, rideID uuid.
 	return totalDeducted, nil
 }
 
+// RefundRide returns the gift card balance UseBalance took for a ride, for a
+// ride whose settlement was rolled back
+func (s *Service) RefundRide(ctx context.Context, rideID uuid.UUID) error {
+	_, err := s.repo.RefundRide(ctx, rideID)
+	return err
+}
+
 // GetTotalBalance returns the user's total available gift card balance
 func (s *Service) GetTotalBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
 	return s.repo.GetTotalBalance(ctx, userID)
@@ -73,6 +73,11 @@ func (m *mockRepository) GetTotalBalance(ctx context.Context, userID uuid.UUID)
 	return args.Get(0).(float64), args.Error(1)
 }
 
+func (m *mockRepository) RefundRide(ctx context.Context, rideID uuid.UUID) (float64, error) {
+	args := m.Called(ctx, rideID)
+	return args.Get(0).(float64), args.Error(1)
+}
+
 func (m *mockRepository) ExpireCards(ctx context.Context) (int64, error) {
 	args := m.Called(ctx)
 	return args.Get(0).(int64), args.Error(1)
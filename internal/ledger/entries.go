@@ -0,0 +1,209 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
+)
+
+// Funding is one source of money used to pay a ride fare.
+type Funding struct {
+	AccountCode AccountCode
+	OwnerID     *uuid.UUID
+	Amount      money.Money
+}
+
+// WalletFunding is fare paid from the rider's wallet.
+func WalletFunding(riderID uuid.UUID, amount money.Money) Funding {
+	return Funding{AccountCode: AccountRiderWallet, OwnerID: &riderID, Amount: amount}
+}
+
+// CardFunding is fare charged to the rider's card through Stripe.
+func CardFunding(amount money.Money) Funding {
+	return Funding{AccountCode: AccountStripeClearing, Amount: amount}
+}
+
+// PromoFunding is fare covered by a platform-funded promo discount.
+func PromoFunding(amount money.Money) Funding {
+	return Funding{AccountCode: AccountPromoExpense, Amount: amount}
+}
+
+// GiftCardFunding is fare paid from gift card balance.
+func GiftCardFunding(amount money.Money) Funding {
+	return Funding{AccountCode: AccountGiftCardLiability, Amount: amount}
+}
+
+// RidePayment describes how a ride fare was funded and split.
+type RidePayment struct {
+	PaymentID  uuid.UUID
+	RideID     uuid.UUID
+	DriverID   uuid.UUID
+	Funding    []Funding
+	Commission money.Money
+}
+
+// fare returns the total fare across all funding sources.
+func (p RidePayment) fare() (money.Money, error) {
+	if len(p.Funding) == 0 {
+		return money.Money{}, fmt.Errorf("%w: ride payment has no funding", ErrInvalidEntry)
+	}
+	total := money.Zero(p.Funding[0].Amount.Currency)
+	for _, f := range p.Funding {
+		var err error
+		if total, err = total.Add(f.Amount); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// NewRidePaymentEntry records a captured fare: the funding sources are debited,
+// the driver's payable is credited with their earnings and platform revenue
+// with the commission.
+func NewRidePaymentEntry(p RidePayment) (*JournalEntry, error) {
+	return newFareEntry(p, "payment", p.PaymentID)
+}
+
+// NewCoveredFareEntry records a fare paid entirely by discounts and gift card
+// credit. No payment is made for such a ride, so the entry is keyed by the
+// ride instead.
+func NewCoveredFareEntry(p RidePayment) (*JournalEntry, error) {
+	return newFareEntry(p, "ride", p.RideID)
+}
+
+// newFareEntry debits the fare's funding sources and splits it between the
+// driver's earnings and the platform's commission.
+func newFareEntry(p RidePayment, referenceType string, referenceID uuid.UUID) (*JournalEntry, error) {
+	fare, err := p.fare()
+	if err != nil {
+		return nil, err
+	}
+	earnings, err := fare.Sub(p.Commission)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := newEntry(EntryRidePayment, referenceType, referenceID, fare.Currency,
+		fmt.Sprintf("Fare for ride %s", p.RideID))
+	for _, f := range p.Funding {
+		entry.add(f.AccountCode, f.OwnerID, Debit, f.Amount)
+	}
+	entry.add(AccountDriverPayable, &p.DriverID, Credit, earnings)
+	entry.add(AccountPlatformRevenue, nil, Credit, p.Commission)
+	return entry, entry.Validate()
+}
+
+// NewRideRefundEntry reverses part or all of a ride payment. The refund is
+// taken from the driver's earnings and the platform's commission in the same
+// proportion as the original split, and returned to the funding sources in
+// proportion to what each contributed.
+func NewRideRefundEntry(p RidePayment, refund money.Money) (*JournalEntry, error) {
+	fare, err := p.fare()
+	if err != nil {
+		return nil, err
+	}
+	if cmp, err := refund.Cmp(fare); err != nil {
+		return nil, err
+	} else if cmp > 0 {
+		return nil, fmt.Errorf("%w: refund %s exceeds fare %s", ErrInvalidEntry, refund, fare)
+	}
+	earnings, err := fare.Sub(p.Commission)
+	if err != nil {
+		return nil, err
+	}
+
+	split, err := refund.Allocate(earnings.Amount, p.Commission.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+	weights := make([]int64, len(p.Funding))
+	for i, f := range p.Funding {
+		weights[i] = f.Amount.Amount
+	}
+	returned, err := refund.Allocate(weights...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+
+	entry := newEntry(EntryRideRefund, "payment", p.PaymentID, refund.Currency,
+		fmt.Sprintf("Refund for ride %s", p.RideID))
+	entry.add(AccountDriverPayable, &p.DriverID, Debit, split[0])
+	entry.add(AccountPlatformRevenue, nil, Debit, split[1])
+	for i, f := range p.Funding {
+		entry.add(f.AccountCode, f.OwnerID, Credit, returned[i])
+	}
+	return entry, entry.Validate()
+}
+
+// NewStripeRefundEntry reverses one Stripe refund of a ride payment. It is
+// keyed by the Stripe refund ID, so each partial refund is posted once
+// whether it arrives from the app or from a webhook.
+func NewStripeRefundEntry(p RidePayment, refundID string, refund money.Money) (*JournalEntry, error) {
+	if refundID == "" {
+		return nil, fmt.Errorf("%w: refund ID is required", ErrInvalidEntry)
+	}
+	entry, err := NewRideRefundEntry(p, refund)
+	if err != nil {
+		return nil, err
+	}
+	entry.IdempotencyKey = fmt.Sprintf("%s:%s:%s", EntryRideRefund, p.PaymentID, refundID)
+	return entry, nil
+}
+
+// NewWalletTopUpEntry records money charged through Stripe and credited to a
+// rider's wallet.
+func NewWalletTopUpEntry(userID, transactionID uuid.UUID, amount money.Money) (*JournalEntry, error) {
+	entry := newEntry(EntryWalletTopUp, "wallet_transaction", transactionID, amount.Currency, "Wallet top-up via Stripe")
+	entry.add(AccountStripeClearing, nil, Debit, amount)
+	entry.add(AccountRiderWallet, &userID, Credit, amount)
+	return entry, entry.Validate()
+}
+
+// NewDriverWithdrawalEntry records driver earnings leaving the platform.
+func NewDriverWithdrawalEntry(driverID, transactionID uuid.UUID, amount money.Money) (*JournalEntry, error) {
+	entry := newEntry(EntryDriverWithdrawal, "wallet_transaction", transactionID, amount.Currency, "Driver withdrawal")
+	entry.add(AccountDriverPayable, &driverID, Debit, amount)
+	entry.add(AccountStripeClearing, nil, Credit, amount)
+	return entry, entry.Validate()
+}
+
+// NewDriverPayoutEntry records driver earnings paid out to their provider
+// account. The fee taken out of an instant payout is platform revenue.
+func NewDriverPayoutEntry(driverID, payoutID uuid.UUID, amount, fee money.Money) (*JournalEntry, error) {
+	total, err := amount.Add(fee)
+	if err != nil {
+		return nil, err
+	}
+	entry := newEntry(EntryDriverWithdrawal, "payout", payoutID, amount.Currency, "Driver payout")
+	entry.add(AccountDriverPayable, &driverID, Debit, total)
+	entry.add(AccountStripeClearing, nil, Credit, amount)
+	entry.add(AccountPlatformRevenue, nil, Credit, fee)
+	return entry, entry.Validate()
+}
+
+// newEntry creates an entry whose idempotency key is derived from its type and
+// reference, so the same movement can only be recorded once.
+func newEntry(entryType EntryType, referenceType string, referenceID uuid.UUID, currency, description string) *JournalEntry {
+	return &JournalEntry{
+		Type:           entryType,
+		ReferenceType:  referenceType,
+		ReferenceID:    &referenceID,
+		IdempotencyKey: fmt.Sprintf("%s:%s", entryType, referenceID),
+		Description:    description,
+		Currency:       money.Zero(currency).Currency,
+	}
+}
+
+// add appends a posting, skipping zero amounts (e.g. a zero commission).
+func (e *JournalEntry) add(code AccountCode, ownerID *uuid.UUID, dir Direction, amount money.Money) {
+	if amount.IsZero() {
+		return
+	}
+	e.Postings = append(e.Postings, Posting{
+		AccountCode: code,
+		OwnerID:     ownerID,
+		Direction:   dir,
+		Amount:      amount,
+	})
+}
@@ -0,0 +1,26 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RepositoryInterface defines the interface for ledger repository operations
+type RepositoryInterface interface {
+	// InsertEntry stores an entry and its postings atomically. It returns false
+	// without error if an entry with the same idempotency key already exists.
+	InsertEntry(ctx context.Context, entry *JournalEntry) (bool, error)
+	GetEntriesByReference(ctx context.Context, referenceType string, referenceID uuid.UUID) ([]*JournalEntry, error)
+	GetAccountBalances(ctx context.Context, filter AccountFilter) ([]*AccountBalance, error)
+	// GetAccountTotals returns debit and credit totals per account code for
+	// postings in the currency made at or before asOf.
+	GetAccountTotals(ctx context.Context, currency string, asOf time.Time) ([]*AccountBalance, error)
+
+	// Entries that failed to post are queued and retried until posted.
+	QueuePendingEntry(ctx context.Context, entry *JournalEntry, errMsg string) error
+	ClaimPendingEntries(ctx context.Context, limit int, lease time.Duration) ([]*PendingEntry, error)
+	DeletePendingEntry(ctx context.Context, idempotencyKey string) error
+	MarkPendingEntryFailed(ctx context.Context, idempotencyKey, errMsg string, nextAttemptAt time.Time) error
+}
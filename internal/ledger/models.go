@@ -0,0 +1,229 @@
+// Package ledger is the double-entry book of record behind wallets, payouts
+// and commissions. Every money movement is posted as an immutable journal
+// entry whose debits equal its credits, so the sum of all balances is always
+// zero and any wallet or payable balance can be traced back to the entries
+// that produced it.
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
+)
+
+var (
+	// ErrInvalidEntry is returned for structurally invalid journal entries.
+	ErrInvalidEntry = errors.New("ledger: invalid journal entry")
+
+	// ErrUnbalanced is returned when an entry's debits do not equal its credits.
+	ErrUnbalanced = errors.New("ledger: journal entry is not balanced")
+)
+
+// AccountType is the accounting class of an account and decides which side
+// increases its balance.
+type AccountType string
+
+const (
+	AccountTypeAsset     AccountType = "asset"
+	AccountTypeLiability AccountType = "liability"
+	AccountTypeRevenue   AccountType = "revenue"
+	AccountTypeExpense   AccountType = "expense"
+)
+
+// DebitNormal reports whether debits increase accounts of this type.
+func (t AccountType) DebitNormal() bool {
+	return t == AccountTypeAsset || t == AccountTypeExpense
+}
+
+// AccountCode identifies a ledger account in the chart of accounts.
+type AccountCode string
+
+const (
+	// AccountRiderWallet is money riders hold in their in-app wallet (per user).
+	AccountRiderWallet AccountCode = "rider_wallet"
+	// AccountDriverPayable is earnings owed to a driver and not yet withdrawn (per user).
+	AccountDriverPayable AccountCode = "driver_payable"
+	// AccountPlatformRevenue is commission and fees kept by the platform.
+	AccountPlatformRevenue AccountCode = "platform_revenue"
+	// AccountStripeClearing is money collected or paid out through Stripe.
+	AccountStripeClearing AccountCode = "stripe_clearing"
+	// AccountGiftCardLiability is unredeemed gift card value.
+	AccountGiftCardLiability AccountCode = "gift_card_liability"
+	// AccountPromoExpense is the cost of promo discounts funded by the platform.
+	AccountPromoExpense AccountCode = "promo_expense"
+)
+
+// chartOfAccounts maps every known account to its type.
+var chartOfAccounts = map[AccountCode]AccountType{
+	AccountRiderWallet:       AccountTypeLiability,
+	AccountDriverPayable:     AccountTypeLiability,
+	AccountPlatformRevenue:   AccountTypeRevenue,
+	AccountStripeClearing:    AccountTypeAsset,
+	AccountGiftCardLiability: AccountTypeLiability,
+	AccountPromoExpense:      AccountTypeExpense,
+}
+
+// Valid reports whether the code is in the chart of accounts.
+func (c AccountCode) Valid() bool {
+	_, ok := chartOfAccounts[c]
+	return ok
+}
+
+// Type returns the account's accounting class.
+func (c AccountCode) Type() AccountType {
+	return chartOfAccounts[c]
+}
+
+// PerOwner reports whether the account is kept per user rather than once for
+// the whole platform.
+func (c AccountCode) PerOwner() bool {
+	return c == AccountRiderWallet || c == AccountDriverPayable
+}
+
+// Direction is the side of a posting.
+type Direction string
+
+const (
+	Debit  Direction = "debit"
+	Credit Direction = "credit"
+)
+
+// EntryType describes the business event behind a journal entry.
+type EntryType string
+
+const (
+	EntryRidePayment      EntryType = "ride_payment"
+	EntryRideRefund       EntryType = "ride_refund"
+	EntryWalletTopUp      EntryType = "wallet_topup"
+	EntryDriverWithdrawal EntryType = "driver_withdrawal"
+)
+
+// Posting is one debit or credit line of a journal entry.
+type Posting struct {
+	ID          uuid.UUID   `json:"id"`
+	AccountCode AccountCode `json:"account_code"`
+	OwnerID     *uuid.UUID  `json:"owner_id,omitempty"`
+	Direction   Direction   `json:"direction"`
+	Amount      money.Money `json:"amount"`
+}
+
+// JournalEntry is an immutable, balanced set of postings recorded for a
+// single money movement. IdempotencyKey is unique, so posting the same
+// movement twice records it once.
+type JournalEntry struct {
+	ID             uuid.UUID  `json:"id"`
+	Type           EntryType  `json:"type"`
+	ReferenceType  string     `json:"reference_type"`
+	ReferenceID    *uuid.UUID `json:"reference_id,omitempty"`
+	IdempotencyKey string     `json:"idempotency_key"`
+	Description    string     `json:"description"`
+	Currency       string     `json:"currency"`
+	Postings       []Posting  `json:"postings"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PendingEntry is a journal entry queued for another attempt after it failed
+// to post.
+type PendingEntry struct {
+	Entry    *JournalEntry
+	Attempts int
+	QueuedAt time.Time
+}
+
+// Validate checks that the entry is well formed and balanced: at least two
+// postings, known accounts with owners where required, positive amounts in a
+// single currency, and debits equal to credits.
+func (e *JournalEntry) Validate() error {
+	if e.Type == "" {
+		return fmt.Errorf("%w: missing entry type", ErrInvalidEntry)
+	}
+	if e.IdempotencyKey == "" {
+		return fmt.Errorf("%w: missing idempotency key", ErrInvalidEntry)
+	}
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: needs at least two postings", ErrInvalidEntry)
+	}
+
+	var debits, credits int64
+	for i, p := range e.Postings {
+		if !p.AccountCode.Valid() {
+			return fmt.Errorf("%w: posting %d: unknown account %q", ErrInvalidEntry, i, p.AccountCode)
+		}
+		if p.AccountCode.PerOwner() != (p.OwnerID != nil) {
+			return fmt.Errorf("%w: posting %d: owner does not match account %q", ErrInvalidEntry, i, p.AccountCode)
+		}
+		if !p.Amount.IsPositive() {
+			return fmt.Errorf("%w: posting %d: amount must be positive", ErrInvalidEntry, i)
+		}
+		if !p.Amount.SameCurrency(money.Zero(e.Currency)) {
+			return fmt.Errorf("%w: posting %d: currency %s differs from entry currency %s", ErrInvalidEntry, i, p.Amount.Currency, e.Currency)
+		}
+		switch p.Direction {
+		case Debit:
+			debits += p.Amount.Amount
+		case Credit:
+			credits += p.Amount.Amount
+		default:
+			return fmt.Errorf("%w: posting %d: invalid direction %q", ErrInvalidEntry, i, p.Direction)
+		}
+	}
+	if debits != credits {
+		return fmt.Errorf("%w: debits %d != credits %d", ErrUnbalanced, debits, credits)
+	}
+	return nil
+}
+
+// AccountBalance is the balance of one account. Balance is expressed in the
+// account's normal direction, so a positive rider wallet balance is money owed
+// to the rider and a positive Stripe clearing balance is money held at Stripe.
+type AccountBalance struct {
+	AccountCode AccountCode `json:"account_code"`
+	AccountType AccountType `json:"account_type"`
+	OwnerID     *uuid.UUID  `json:"owner_id,omitempty"`
+	Currency    string      `json:"currency"`
+	Debits      int64       `json:"debits"`
+	Credits     int64       `json:"credits"`
+	Balance     int64       `json:"balance"`
+}
+
+// normalize sets Balance from the debit and credit totals.
+func (b *AccountBalance) normalize() {
+	b.AccountType = b.AccountCode.Type()
+	if b.AccountType.DebitNormal() {
+		b.Balance = b.Debits - b.Credits
+	} else {
+		b.Balance = b.Credits - b.Debits
+	}
+}
+
+// AccountFilter selects account balances for reporting.
+type AccountFilter struct {
+	AccountCode AccountCode
+	OwnerID     *uuid.UUID
+	Currency    string
+	Limit       int
+	Offset      int
+}
+
+// TrialBalanceLine is one account in a trial balance, with its net balance
+// shown in the debit or credit column.
+type TrialBalanceLine struct {
+	AccountCode AccountCode `json:"account_code"`
+	AccountType AccountType `json:"account_type"`
+	Debit       int64       `json:"debit"`
+	Credit      int64       `json:"credit"`
+}
+
+// TrialBalance lists every account's net balance in one currency as of a
+// point in time. TotalDebits equals TotalCredits unless the books are broken.
+type TrialBalance struct {
+	Currency     string              `json:"currency"`
+	AsOf         time.Time           `json:"as_of"`
+	Lines        []*TrialBalanceLine `json:"lines"`
+	TotalDebits  int64               `json:"total_debits"`
+	TotalCredits int64               `json:"total_credits"`
+	Balanced     bool                `json:"balanced"`
+}
@@ -0,0 +1,301 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/pkg/money"
+)
+
+// Repository handles ledger data access. Journal entries and postings are
+// append-only; the database rejects updates and deletes and checks at commit
+// that every entry balances.
+type Repository struct {
+	db *pgxpool.Pool
+}
+
+// NewRepository creates a new ledger repository
+func NewRepository(db *pgxpool.Pool) *Repository {
+	return &Repository{db: db}
+}
+
+// InsertEntry stores a journal entry and its postings in one transaction.
+func (r *Repository) InsertEntry(ctx context.Context, entry *JournalEntry) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO ledger_entries (
+			id, entry_type, reference_type, reference_id, idempotency_key,
+			description, currency, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (idempotency_key) DO NOTHING`,
+		entry.ID, entry.Type, entry.ReferenceType, entry.ReferenceID, entry.IdempotencyKey,
+		entry.Description, entry.Currency, entry.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("insert ledger entry: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	for _, p := range entry.Postings {
+		accountID, err := ensureAccount(ctx, tx, p.AccountCode, p.OwnerID, entry.Currency)
+		if err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO ledger_postings (id, entry_id, account_id, direction, amount_minor, currency, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			p.ID, entry.ID, accountID, p.Direction, p.Amount.Amount, entry.Currency, entry.CreatedAt,
+		)
+		if err != nil {
+			return false, fmt.Errorf("insert ledger posting: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit ledger entry: %w", err)
+	}
+	return true, nil
+}
+
+// ensureAccount returns the ID of an account, opening it on first use.
+// Platform-wide accounts are stored with the nil UUID as owner.
+func ensureAccount(ctx context.Context, tx pgx.Tx, code AccountCode, ownerID *uuid.UUID, currency string) (uuid.UUID, error) {
+	owner := uuid.Nil
+	if ownerID != nil {
+		owner = *ownerID
+	}
+
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+		INSERT INTO ledger_accounts (code, account_type, owner_id, currency)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (code, owner_id, currency) DO UPDATE SET code = EXCLUDED.code
+		RETURNING id`,
+		code, code.Type(), owner, currency,
+	).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("open ledger account %s: %w", code, err)
+	}
+	return id, nil
+}
+
+// GetEntriesByReference returns all entries recorded for a reference, oldest first.
+func (r *Repository) GetEntriesByReference(ctx context.Context, referenceType string, referenceID uuid.UUID) ([]*JournalEntry, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT e.id, e.entry_type, e.reference_type, e.reference_id, e.idempotency_key,
+		       e.description, e.currency, e.created_at,
+		       p.id, a.code, a.owner_id, p.direction, p.amount_minor
+		FROM ledger_entries e
+		JOIN ledger_postings p ON p.entry_id = e.id
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE e.reference_type = $1 AND e.reference_id = $2
+		ORDER BY e.created_at, e.id, p.direction DESC, a.code`,
+		referenceType, referenceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*JournalEntry
+	byID := make(map[uuid.UUID]*JournalEntry)
+	for rows.Next() {
+		var (
+			e      JournalEntry
+			p      Posting
+			owner  uuid.UUID
+			amount int64
+		)
+		if err := rows.Scan(
+			&e.ID, &e.Type, &e.ReferenceType, &e.ReferenceID, &e.IdempotencyKey,
+			&e.Description, &e.Currency, &e.CreatedAt,
+			&p.ID, &p.AccountCode, &owner, &p.Direction, &amount,
+		); err != nil {
+			return nil, err
+		}
+
+		entry, ok := byID[e.ID]
+		if !ok {
+			entry = &e
+			byID[e.ID] = entry
+			entries = append(entries, entry)
+		}
+		if owner != uuid.Nil {
+			p.OwnerID = &owner
+		}
+		p.Amount = money.New(amount, entry.Currency)
+		entry.Postings = append(entry.Postings, p)
+	}
+	return entries, rows.Err()
+}
+
+// GetAccountBalances returns per-account balances computed from postings.
+func (r *Repository) GetAccountBalances(ctx context.Context, filter AccountFilter) ([]*AccountBalance, error) {
+	query := `
+		SELECT a.code, a.owner_id, a.currency,
+		       COALESCE(SUM(p.amount_minor) FILTER (WHERE p.direction = 'debit'), 0),
+		       COALESCE(SUM(p.amount_minor) FILTER (WHERE p.direction = 'credit'), 0)
+		FROM ledger_accounts a
+		LEFT JOIN ledger_postings p ON p.account_id = a.id
+		WHERE 1=1`
+	var args []interface{}
+	if filter.AccountCode != "" {
+		args = append(args, filter.AccountCode)
+		query += fmt.Sprintf(" AND a.code = $%d", len(args))
+	}
+	if filter.OwnerID != nil {
+		args = append(args, *filter.OwnerID)
+		query += fmt.Sprintf(" AND a.owner_id = $%d", len(args))
+	}
+	if filter.Currency != "" {
+		args = append(args, filter.Currency)
+		query += fmt.Sprintf(" AND a.currency = $%d", len(args))
+	}
+	query += " GROUP BY a.id, a.code, a.owner_id, a.currency ORDER BY a.code, a.currency, a.owner_id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*AccountBalance
+	for rows.Next() {
+		var (
+			b     AccountBalance
+			owner uuid.UUID
+		)
+		if err := rows.Scan(&b.AccountCode, &owner, &b.Currency, &b.Debits, &b.Credits); err != nil {
+			return nil, err
+		}
+		if owner != uuid.Nil {
+			b.OwnerID = &owner
+		}
+		b.normalize()
+		balances = append(balances, &b)
+	}
+	return balances, rows.Err()
+}
+
+// GetAccountTotals returns debit and credit totals per account code, summed
+// over all owners, for postings in currency made at or before asOf.
+func (r *Repository) GetAccountTotals(ctx context.Context, currency string, asOf time.Time) ([]*AccountBalance, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT a.code,
+		       COALESCE(SUM(p.amount_minor) FILTER (WHERE p.direction = 'debit'), 0),
+		       COALESCE(SUM(p.amount_minor) FILTER (WHERE p.direction = 'credit'), 0)
+		FROM ledger_postings p
+		JOIN ledger_accounts a ON a.id = p.account_id
+		WHERE p.currency = $1 AND p.created_at <= $2
+		GROUP BY a.code
+		ORDER BY a.code`,
+		currency, asOf,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*AccountBalance
+	for rows.Next() {
+		b := AccountBalance{Currency: currency}
+		if err := rows.Scan(&b.AccountCode, &b.Debits, &b.Credits); err != nil {
+			return nil, err
+		}
+		b.normalize()
+		totals = append(totals, &b)
+	}
+	return totals, rows.Err()
+}
+
+// QueuePendingEntry stores an entry that failed to post so it can be retried.
+// An entry already queued under the same idempotency key is kept as is.
+func (r *Repository) QueuePendingEntry(ctx context.Context, entry *JournalEntry, errMsg string) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode pending ledger entry: %w", err)
+	}
+	_, err = r.db.Exec(ctx, `
+		INSERT INTO ledger_pending_entries (idempotency_key, entry_type, payload, last_error)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (idempotency_key) DO NOTHING`,
+		entry.IdempotencyKey, entry.Type, payload, errMsg,
+	)
+	if err != nil {
+		return fmt.Errorf("queue pending ledger entry: %w", err)
+	}
+	return nil
+}
+
+// ClaimPendingEntries returns up to limit queued entries that are due, oldest
+// first, and hides them from other claimers for the lease.
+func (r *Repository) ClaimPendingEntries(ctx context.Context, limit int, lease time.Duration) ([]*PendingEntry, error) {
+	rows, err := r.db.Query(ctx, `
+		WITH due AS (
+			SELECT idempotency_key
+			FROM ledger_pending_entries
+			WHERE next_attempt_at <= NOW()
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE ledger_pending_entries e
+		SET attempts = e.attempts + 1,
+		    next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due
+		WHERE e.idempotency_key = due.idempotency_key
+		RETURNING e.payload, e.attempts, e.created_at`,
+		limit, lease.Seconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim pending ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*PendingEntry
+	for rows.Next() {
+		p := &PendingEntry{Entry: &JournalEntry{}}
+		var payload []byte
+		if err := rows.Scan(&payload, &p.Attempts, &p.QueuedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, p.Entry); err != nil {
+			return nil, fmt.Errorf("decode pending ledger entry: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// DeletePendingEntry removes a queued entry once it has been posted.
+func (r *Repository) DeletePendingEntry(ctx context.Context, idempotencyKey string) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM ledger_pending_entries WHERE idempotency_key = $1`, idempotencyKey)
+	return err
+}
+
+// MarkPendingEntryFailed records a failed retry and when to try again.
+func (r *Repository) MarkPendingEntryFailed(ctx context.Context, idempotencyKey, errMsg string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE ledger_pending_entries
+		SET last_error = $2, next_attempt_at = $3
+		WHERE idempotency_key = $1`,
+		idempotencyKey, errMsg, nextAttemptAt,
+	)
+	return err
+}
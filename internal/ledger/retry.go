@@ -0,0 +1,115 @@
+package ledger
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RetryConfig controls how queued entries are retried.
+type RetryConfig struct {
+	PollInterval time.Duration // how often to look for due entries
+	BatchSize    int           // max entries claimed per poll
+	Lease        time.Duration // how long a claimed entry is hidden from other retriers
+	BaseBackoff  time.Duration // delay after the first failed retry
+	MaxBackoff   time.Duration // retry delay cap
+}
+
+// DefaultRetryConfig returns sensible defaults for retrying queued entries.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		PollInterval: 10 * time.Second,
+		BatchSize:    100,
+		Lease:        time.Minute,
+		BaseBackoff:  30 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// RetryPending posts queued entries until ctx is cancelled. Several services
+// may retry concurrently: entries are claimed with a lease, and an entry that
+// was posted before being queued is recorded once by its idempotency key.
+func (s *Service) RetryPending(ctx context.Context, cfg RetryConfig) {
+	defaults := DefaultRetryConfig()
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaults.Lease
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaults.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.retryBatch(ctx, cfg); err != nil {
+				logger.Warn("ledger retry batch failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// retryBatch claims a batch of due entries and posts them. Returns the number
+// of entries claimed.
+func (s *Service) retryBatch(ctx context.Context, cfg RetryConfig) (int, error) {
+	pending, err := s.repo.ClaimPendingEntries(ctx, cfg.BatchSize, cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range pending {
+		key := p.Entry.IdempotencyKey
+		created, err := s.repo.InsertEntry(ctx, p.Entry)
+		if err != nil {
+			postFailuresTotal.WithLabelValues(string(p.Entry.Type)).Inc()
+			next := time.Now().Add(retryBackoff(cfg, p.Attempts))
+			logger.Warn("queued journal entry failed to post, will retry",
+				zap.String("idempotency_key", key),
+				zap.Int("attempts", p.Attempts),
+				zap.Time("next_attempt_at", next),
+				zap.Error(err))
+			if markErr := s.repo.MarkPendingEntryFailed(ctx, key, err.Error(), next); markErr != nil {
+				// The lease expires on its own; the entry is retried then
+				logger.Warn("failed to record journal entry retry", zap.String("idempotency_key", key), zap.Error(markErr))
+			}
+			continue
+		}
+
+		if created {
+			entriesPostedTotal.WithLabelValues(string(p.Entry.Type)).Inc()
+		}
+		if err := s.repo.DeletePendingEntry(ctx, key); err != nil {
+			// Posted but still queued: the next retry finds it already recorded
+			logger.Warn("failed to remove posted journal entry from queue", zap.String("idempotency_key", key), zap.Error(err))
+		}
+	}
+	return len(pending), nil
+}
+
+// retryBackoff returns the delay before the next retry after the given number
+// of attempts.
+func retryBackoff(cfg RetryConfig, attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := float64(cfg.BaseBackoff) * math.Pow(2, float64(attempts-1))
+	if delay > float64(cfg.MaxBackoff) {
+		return cfg.MaxBackoff
+	}
+	return time.Duration(delay)
+}
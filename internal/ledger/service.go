@@ -0,0 +1,165 @@
+package ledger
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	entriesPostedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ledger_entries_posted_total",
+		Help: "Total number of journal entries posted to the ledger",
+	}, []string{"type"})
+
+	postFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ledger_post_failures_total",
+		Help: "Total number of journal entries that failed to post",
+	}, []string{"type"})
+
+	entriesQueuedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ledger_entries_queued_total",
+		Help: "Total number of journal entries queued for retry after failing to post",
+	}, []string{"type"})
+)
+
+// Service handles business logic for the ledger
+type Service struct {
+	repo RepositoryInterface
+}
+
+// NewService creates a new ledger service
+func NewService(repo RepositoryInterface) *Service {
+	return &Service{repo: repo}
+}
+
+// Post validates and records a journal entry. Posting an entry whose
+// idempotency key was already recorded is a no-op. An entry that cannot be
+// stored is queued and posted later by RetryPending, so Post only fails for
+// invalid entries or when the entry cannot be queued either.
+func (s *Service) Post(ctx context.Context, entry *JournalEntry) error {
+	if err := entry.Validate(); err != nil {
+		postFailuresTotal.WithLabelValues(string(entry.Type)).Inc()
+		return common.NewBadRequestError(err.Error(), err)
+	}
+
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	for i := range entry.Postings {
+		if entry.Postings[i].ID == uuid.Nil {
+			entry.Postings[i].ID = uuid.New()
+		}
+	}
+
+	created, err := s.repo.InsertEntry(ctx, entry)
+	if err != nil {
+		postFailuresTotal.WithLabelValues(string(entry.Type)).Inc()
+		if queueErr := s.repo.QueuePendingEntry(ctx, entry, err.Error()); queueErr != nil {
+			logger.Error("failed to queue journal entry for retry",
+				zap.String("idempotency_key", entry.IdempotencyKey), zap.Error(queueErr))
+			return common.NewInternalError("failed to post journal entry", err)
+		}
+		entriesQueuedTotal.WithLabelValues(string(entry.Type)).Inc()
+		logger.Warn("journal entry queued for retry",
+			zap.String("idempotency_key", entry.IdempotencyKey), zap.Error(err))
+		return nil
+	}
+	if !created {
+		logger.Debug("journal entry already posted", zap.String("idempotency_key", entry.IdempotencyKey))
+		return nil
+	}
+
+	entriesPostedTotal.WithLabelValues(string(entry.Type)).Inc()
+	return nil
+}
+
+// GetEntriesByReference returns the journal entries recorded for a reference,
+// e.g. every entry for a payment.
+func (s *Service) GetEntriesByReference(ctx context.Context, referenceType string, referenceID uuid.UUID) ([]*JournalEntry, error) {
+	entries, err := s.repo.GetEntriesByReference(ctx, referenceType, referenceID)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get journal entries", err)
+	}
+	return entries, nil
+}
+
+// ListAccountBalances returns account balances matching the filter.
+func (s *Service) ListAccountBalances(ctx context.Context, filter AccountFilter) ([]*AccountBalance, error) {
+	if filter.AccountCode != "" && !filter.AccountCode.Valid() {
+		return nil, common.NewBadRequestError("unknown account code", nil)
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.Currency = strings.ToUpper(filter.Currency)
+
+	balances, err := s.repo.GetAccountBalances(ctx, filter)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get account balances", err)
+	}
+	return balances, nil
+}
+
+// GetTrialBalance returns the trial balance for a currency as of a point in
+// time. A zero asOf means now.
+func (s *Service) GetTrialBalance(ctx context.Context, currency string, asOf time.Time) (*TrialBalance, error) {
+	if currency == "" {
+		return nil, common.NewBadRequestError("currency is required", nil)
+	}
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	currency = strings.ToUpper(currency)
+
+	totals, err := s.repo.GetAccountTotals(ctx, currency, asOf)
+	if err != nil {
+		return nil, common.NewInternalError("failed to get trial balance", err)
+	}
+
+	tb := buildTrialBalance(currency, asOf, totals)
+	if !tb.Balanced {
+		logger.Error("ledger trial balance does not balance",
+			zap.String("currency", currency),
+			zap.Int64("total_debits", tb.TotalDebits),
+			zap.Int64("total_credits", tb.TotalCredits),
+		)
+	}
+	return tb, nil
+}
+
+// buildTrialBalance places each account's net balance in the debit or credit
+// column and totals both columns.
+func buildTrialBalance(currency string, asOf time.Time, totals []*AccountBalance) *TrialBalance {
+	tb := &TrialBalance{
+		Currency: currency,
+		AsOf:     asOf,
+		Lines:    make([]*TrialBalanceLine, 0, len(totals)),
+	}
+	for _, t := range totals {
+		line := &TrialBalanceLine{AccountCode: t.AccountCode, AccountType: t.AccountCode.Type()}
+		if net := t.Debits - t.Credits; net >= 0 {
+			line.Debit = net
+		} else {
+			line.Credit = -net
+		}
+		tb.TotalDebits += line.Debit
+		tb.TotalCredits += line.Credit
+		tb.Lines = append(tb.Lines, line)
+	}
+	tb.Balanced = tb.TotalDebits == tb.TotalCredits
+	return tb
+}
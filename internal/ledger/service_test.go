@@ -0,0 +1,428 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo is an in-memory RepositoryInterface that keeps entries by
+// idempotency key and derives account totals from their postings.
+type fakeRepo struct {
+	entries   map[string]*JournalEntry
+	pending   map[string]*PendingEntry
+	insertErr error
+	queueErr  error
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{entries: make(map[string]*JournalEntry), pending: make(map[string]*PendingEntry)}
+}
+
+func (f *fakeRepo) InsertEntry(_ context.Context, entry *JournalEntry) (bool, error) {
+	if f.insertErr != nil {
+		return false, f.insertErr
+	}
+	if _, ok := f.entries[entry.IdempotencyKey]; ok {
+		return false, nil
+	}
+	f.entries[entry.IdempotencyKey] = entry
+	return true, nil
+}
+
+func (f *fakeRepo) QueuePendingEntry(_ context.Context, entry *JournalEntry, _ string) error {
+	if f.queueErr != nil {
+		return f.queueErr
+	}
+	if _, ok := f.pending[entry.IdempotencyKey]; !ok {
+		f.pending[entry.IdempotencyKey] = &PendingEntry{Entry: entry, QueuedAt: time.Now()}
+	}
+	return nil
+}
+
+func (f *fakeRepo) ClaimPendingEntries(_ context.Context, limit int, _ time.Duration) ([]*PendingEntry, error) {
+	var out []*PendingEntry
+	for _, p := range f.pending {
+		if len(out) == limit {
+			break
+		}
+		p.Attempts++
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) DeletePendingEntry(_ context.Context, idempotencyKey string) error {
+	delete(f.pending, idempotencyKey)
+	return nil
+}
+
+func (f *fakeRepo) MarkPendingEntryFailed(_ context.Context, _, _ string, _ time.Time) error {
+	return nil
+}
+
+func (f *fakeRepo) GetEntriesByReference(_ context.Context, referenceType string, referenceID uuid.UUID) ([]*JournalEntry, error) {
+	var out []*JournalEntry
+	for _, e := range f.entries {
+		if e.ReferenceType == referenceType && e.ReferenceID != nil && *e.ReferenceID == referenceID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) GetAccountBalances(_ context.Context, _ AccountFilter) ([]*AccountBalance, error) {
+	return nil, nil
+}
+
+func (f *fakeRepo) GetAccountTotals(_ context.Context, currency string, _ time.Time) ([]*AccountBalance, error) {
+	byCode := make(map[AccountCode]*AccountBalance)
+	var out []*AccountBalance
+	for _, e := range f.entries {
+		if e.Currency != currency {
+			continue
+		}
+		for _, p := range e.Postings {
+			b, ok := byCode[p.AccountCode]
+			if !ok {
+				b = &AccountBalance{AccountCode: p.AccountCode, Currency: currency}
+				byCode[p.AccountCode] = b
+				out = append(out, b)
+			}
+			if p.Direction == Debit {
+				b.Debits += p.Amount.Amount
+			} else {
+				b.Credits += p.Amount.Amount
+			}
+		}
+	}
+	for _, b := range out {
+		b.normalize()
+	}
+	return out, nil
+}
+
+func usd(minor int64) money.Money { return money.New(minor, "USD") }
+
+func sumSide(entry *JournalEntry, dir Direction) int64 {
+	var total int64
+	for _, p := range entry.Postings {
+		if p.Direction == dir {
+			total += p.Amount.Amount
+		}
+	}
+	return total
+}
+
+func postingFor(t *testing.T, entry *JournalEntry, code AccountCode, dir Direction) Posting {
+	t.Helper()
+	for _, p := range entry.Postings {
+		if p.AccountCode == code && p.Direction == dir {
+			return p
+		}
+	}
+	t.Fatalf("no %s posting to %s", dir, code)
+	return Posting{}
+}
+
+func TestJournalEntry_Validate(t *testing.T) {
+	riderID := uuid.New()
+	valid := func() *JournalEntry {
+		return &JournalEntry{
+			Type:           EntryWalletTopUp,
+			IdempotencyKey: "wallet_topup:1",
+			Currency:       "USD",
+			Postings: []Posting{
+				{AccountCode: AccountStripeClearing, Direction: Debit, Amount: usd(1000)},
+				{AccountCode: AccountRiderWallet, OwnerID: &riderID, Direction: Credit, Amount: usd(1000)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(e *JournalEntry)
+		wantErr error
+	}{
+		{"valid", func(e *JournalEntry) {}, nil},
+		{"missing idempotency key", func(e *JournalEntry) { e.IdempotencyKey = "" }, ErrInvalidEntry},
+		{"single posting", func(e *JournalEntry) { e.Postings = e.Postings[:1] }, ErrInvalidEntry},
+		{"unknown account", func(e *JournalEntry) { e.Postings[0].AccountCode = "cash" }, ErrInvalidEntry},
+		{"per-user account without owner", func(e *JournalEntry) { e.Postings[1].OwnerID = nil }, ErrInvalidEntry},
+		{"platform account with owner", func(e *JournalEntry) { e.Postings[0].OwnerID = &riderID }, ErrInvalidEntry},
+		{"zero amount", func(e *JournalEntry) { e.Postings[0].Amount = usd(0) }, ErrInvalidEntry},
+		{"mixed currency", func(e *JournalEntry) { e.Postings[1].Amount = money.New(1000, "EUR") }, ErrInvalidEntry},
+		{"bad direction", func(e *JournalEntry) { e.Postings[1].Direction = "sideways" }, ErrInvalidEntry},
+		{"unbalanced", func(e *JournalEntry) { e.Postings[1].Amount = usd(999) }, ErrUnbalanced},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := valid()
+			tt.mutate(e)
+			err := e.Validate()
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewRidePaymentEntry(t *testing.T) {
+	riderID, driverID, paymentID := uuid.New(), uuid.New(), uuid.New()
+	fare := usd(1999)
+	commission, _ := fare.SplitRate(0.2)
+
+	entry, err := NewRidePaymentEntry(RidePayment{
+		PaymentID:  paymentID,
+		RideID:     uuid.New(),
+		DriverID:   driverID,
+		Funding:    []Funding{WalletFunding(riderID, fare)},
+		Commission: commission,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, EntryRidePayment, entry.Type)
+	assert.Equal(t, "ride_payment:"+paymentID.String(), entry.IdempotencyKey)
+	assert.Equal(t, int64(1999), sumSide(entry, Debit))
+	assert.Equal(t, int64(1999), sumSide(entry, Credit))
+
+	wallet := postingFor(t, entry, AccountRiderWallet, Debit)
+	assert.Equal(t, riderID, *wallet.OwnerID)
+	payable := postingFor(t, entry, AccountDriverPayable, Credit)
+	assert.Equal(t, driverID, *payable.OwnerID)
+	assert.Equal(t, int64(1599), payable.Amount.Amount)
+	assert.Equal(t, int64(400), postingFor(t, entry, AccountPlatformRevenue, Credit).Amount.Amount)
+}
+
+func TestNewRidePaymentEntry_MixedFunding(t *testing.T) {
+	riderID := uuid.New()
+	entry, err := NewRidePaymentEntry(RidePayment{
+		PaymentID: uuid.New(),
+		DriverID:  uuid.New(),
+		Funding: []Funding{
+			CardFunding(usd(1500)),
+			PromoFunding(usd(300)),
+			GiftCardFunding(usd(200)),
+			WalletFunding(riderID, usd(0)),
+		},
+		Commission: usd(0),
+	})
+	require.NoError(t, err)
+
+	// Zero-amount lines are dropped
+	assert.Len(t, entry.Postings, 4)
+	assert.Equal(t, int64(2000), sumSide(entry, Debit))
+	assert.Equal(t, int64(2000), postingFor(t, entry, AccountDriverPayable, Credit).Amount.Amount)
+}
+
+func TestNewCoveredFareEntry(t *testing.T) {
+	rideID := uuid.New()
+	entry, err := NewCoveredFareEntry(RidePayment{
+		RideID:     rideID,
+		DriverID:   uuid.New(),
+		Funding:    []Funding{PromoFunding(usd(700)), GiftCardFunding(usd(300))},
+		Commission: usd(200),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ride", entry.ReferenceType)
+	assert.Equal(t, rideID, *entry.ReferenceID)
+	assert.Equal(t, "ride_payment:"+rideID.String(), entry.IdempotencyKey)
+	assert.Equal(t, int64(1000), sumSide(entry, Debit))
+	assert.Equal(t, int64(800), postingFor(t, entry, AccountDriverPayable, Credit).Amount.Amount)
+}
+
+func TestNewDriverPayoutEntry(t *testing.T) {
+	driverID, payoutID := uuid.New(), uuid.New()
+	entry, err := NewDriverPayoutEntry(driverID, payoutID, usd(9850), usd(150))
+	require.NoError(t, err)
+
+	assert.Equal(t, "payout", entry.ReferenceType)
+	assert.Equal(t, "driver_withdrawal:"+payoutID.String(), entry.IdempotencyKey)
+	payable := postingFor(t, entry, AccountDriverPayable, Debit)
+	assert.Equal(t, driverID, *payable.OwnerID)
+	assert.Equal(t, int64(10000), payable.Amount.Amount)
+	assert.Equal(t, int64(9850), postingFor(t, entry, AccountStripeClearing, Credit).Amount.Amount)
+	assert.Equal(t, int64(150), postingFor(t, entry, AccountPlatformRevenue, Credit).Amount.Amount)
+}
+
+func TestNewRideRefundEntry(t *testing.T) {
+	riderID, driverID := uuid.New(), uuid.New()
+	payment := RidePayment{
+		PaymentID:  uuid.New(),
+		DriverID:   driverID,
+		Funding:    []Funding{WalletFunding(riderID, usd(678))},
+		Commission: usd(136),
+	}
+
+	t.Run("full refund reverses the split", func(t *testing.T) {
+		entry, err := NewRideRefundEntry(payment, usd(678))
+		require.NoError(t, err)
+		assert.Equal(t, int64(542), postingFor(t, entry, AccountDriverPayable, Debit).Amount.Amount)
+		assert.Equal(t, int64(136), postingFor(t, entry, AccountPlatformRevenue, Debit).Amount.Amount)
+		assert.Equal(t, int64(678), postingFor(t, entry, AccountRiderWallet, Credit).Amount.Amount)
+	})
+
+	t.Run("partial refund is split proportionally", func(t *testing.T) {
+		entry, err := NewRideRefundEntry(payment, usd(611))
+		require.NoError(t, err)
+		assert.Equal(t, sumSide(entry, Debit), sumSide(entry, Credit))
+		assert.Equal(t, int64(611), sumSide(entry, Credit))
+		assert.Equal(t, int64(489), postingFor(t, entry, AccountDriverPayable, Debit).Amount.Amount)
+		assert.Equal(t, int64(122), postingFor(t, entry, AccountPlatformRevenue, Debit).Amount.Amount)
+	})
+
+	t.Run("refund larger than fare is rejected", func(t *testing.T) {
+		_, err := NewRideRefundEntry(payment, usd(679))
+		assert.ErrorIs(t, err, ErrInvalidEntry)
+	})
+
+	t.Run("stripe refunds are keyed by refund ID", func(t *testing.T) {
+		first, err := NewStripeRefundEntry(payment, "re_1", usd(300))
+		require.NoError(t, err)
+		second, err := NewStripeRefundEntry(payment, "re_2", usd(300))
+		require.NoError(t, err)
+		assert.NotEqual(t, first.IdempotencyKey, second.IdempotencyKey)
+
+		_, err = NewStripeRefundEntry(payment, "", usd(300))
+		assert.ErrorIs(t, err, ErrInvalidEntry)
+	})
+
+	t.Run("currency mismatch is rejected", func(t *testing.T) {
+		_, err := NewRideRefundEntry(payment, money.New(100, "EUR"))
+		assert.ErrorIs(t, err, money.ErrCurrencyMismatch)
+	})
+}
+
+func TestService_Post(t *testing.T) {
+	repo := newFakeRepo()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	entry, err := NewWalletTopUpEntry(uuid.New(), uuid.New(), usd(2500))
+	require.NoError(t, err)
+
+	require.NoError(t, svc.Post(ctx, entry))
+	assert.NotEqual(t, uuid.Nil, entry.ID)
+	assert.False(t, entry.CreatedAt.IsZero())
+	for _, p := range entry.Postings {
+		assert.NotEqual(t, uuid.Nil, p.ID)
+	}
+
+	// Re-posting the same movement is a no-op
+	again, err := NewWalletTopUpEntry(*entry.Postings[1].OwnerID, *entry.ReferenceID, usd(2500))
+	require.NoError(t, err)
+	require.NoError(t, svc.Post(ctx, again))
+	assert.Len(t, repo.entries, 1)
+
+	// Invalid entries never reach the repository
+	entry.Postings[0].Amount = usd(1)
+	entry.IdempotencyKey = "tampered"
+	err = svc.Post(ctx, entry)
+	var appErr *common.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 400, appErr.Code)
+	assert.Len(t, repo.entries, 1)
+
+	repo.insertErr = errors.New("db down")
+	repo.queueErr = errors.New("db down")
+	withdrawal, err := NewDriverWithdrawalEntry(uuid.New(), uuid.New(), usd(500))
+	require.NoError(t, err)
+	err = svc.Post(ctx, withdrawal)
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, 500, appErr.Code)
+}
+
+func TestService_Post_QueuesFailedEntryForRetry(t *testing.T) {
+	repo := newFakeRepo()
+	svc := NewService(repo)
+	ctx := context.Background()
+
+	repo.insertErr = errors.New("serialization failure")
+	entry, err := NewDriverWithdrawalEntry(uuid.New(), uuid.New(), usd(500))
+	require.NoError(t, err)
+	require.NoError(t, svc.Post(ctx, entry))
+	assert.Empty(t, repo.entries)
+	require.Contains(t, repo.pending, entry.IdempotencyKey)
+
+	// A retry that fails again leaves the entry queued
+	n, err := svc.retryBatch(ctx, DefaultRetryConfig())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Contains(t, repo.pending, entry.IdempotencyKey)
+
+	repo.insertErr = nil
+	_, err = svc.retryBatch(ctx, DefaultRetryConfig())
+	require.NoError(t, err)
+	assert.Empty(t, repo.pending)
+	assert.Contains(t, repo.entries, entry.IdempotencyKey)
+}
+
+func TestService_GetTrialBalance(t *testing.T) {
+	repo := newFakeRepo()
+	svc := NewService(repo)
+	ctx := context.Background()
+	riderID, driverID := uuid.New(), uuid.New()
+
+	post := func(entry *JournalEntry, err error) {
+		t.Helper()
+		require.NoError(t, err)
+		require.NoError(t, svc.Post(ctx, entry))
+	}
+
+	payment := RidePayment{
+		PaymentID:  uuid.New(),
+		DriverID:   driverID,
+		Funding:    []Funding{WalletFunding(riderID, usd(1999))},
+		Commission: usd(400),
+	}
+	post(NewWalletTopUpEntry(riderID, uuid.New(), usd(5000)))
+	post(NewRidePaymentEntry(payment))
+	post(NewRideRefundEntry(payment, usd(500)))
+	post(NewDriverWithdrawalEntry(driverID, uuid.New(), usd(1000)))
+
+	tb, err := svc.GetTrialBalance(ctx, "usd", time.Time{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "USD", tb.Currency)
+	assert.False(t, tb.AsOf.IsZero())
+	assert.True(t, tb.Balanced)
+	assert.Equal(t, tb.TotalDebits, tb.TotalCredits)
+
+	lines := make(map[AccountCode]*TrialBalanceLine)
+	for _, l := range tb.Lines {
+		lines[l.AccountCode] = l
+	}
+	// Stripe: 5000 in, 1000 out to the driver
+	assert.Equal(t, int64(4000), lines[AccountStripeClearing].Debit)
+	// Rider wallet: 5000 topped up, 1999 spent, 500 refunded
+	assert.Equal(t, int64(3501), lines[AccountRiderWallet].Credit)
+	// Driver: 1599 earned, 400 clawed back by the refund, 1000 withdrawn
+	assert.Equal(t, int64(199), lines[AccountDriverPayable].Credit)
+	// Platform: 400 commission less 100 refunded
+	assert.Equal(t, int64(300), lines[AccountPlatformRevenue].Credit)
+	assert.Equal(t, AccountTypeRevenue, lines[AccountPlatformRevenue].AccountType)
+
+	_, err = svc.GetTrialBalance(ctx, "", time.Time{})
+	assert.Error(t, err)
+}
+
+func TestBuildTrialBalance_Unbalanced(t *testing.T) {
+	tb := buildTrialBalance("USD", time.Now(), []*AccountBalance{
+		{AccountCode: AccountStripeClearing, Debits: 100},
+		{AccountCode: AccountRiderWallet, Credits: 90},
+	})
+	assert.False(t, tb.Balanced)
+	assert.Equal(t, int64(100), tb.TotalDebits)
+	assert.Equal(t, int64(90), tb.TotalCredits)
+}
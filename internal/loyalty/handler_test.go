@@ -126,6 +126,24 @@ func (m *MockRepository) IncrementRewardRedemptionCount(ctx context.Context, rew
 	return args.Error(0)
 }
 
+func (m *MockRepository) GetActiveRideCredits(ctx context.Context, riderID uuid.UUID) ([]*Redemption, error) {
+	args := m.Called(ctx, riderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Redemption), args.Error(1)
+}
+
+func (m *MockRepository) MarkRedemptionUsed(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, redemptionID, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) ReleaseRedemption(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, redemptionID, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockRepository) GetActiveChallenges(ctx context.Context, tierID *uuid.UUID) ([]*RiderChallenge, error) {
 	args := m.Called(ctx, tierID)
 	if args.Get(0) == nil {
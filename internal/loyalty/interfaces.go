@@ -31,6 +31,9 @@ type RepositoryInterface interface {
 	GetUserRedemptionCount(ctx context.Context, riderID, rewardID uuid.UUID) (int, error)
 	CreateRedemption(ctx context.Context, redemption *Redemption) error
 	IncrementRewardRedemptionCount(ctx context.Context, rewardID uuid.UUID) error
+	GetActiveRideCredits(ctx context.Context, riderID uuid.UUID) ([]*Redemption, error)
+	MarkRedemptionUsed(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error)
+	ReleaseRedemption(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error)
 
 	// Challenges
 	GetActiveChallenges(ctx context.Context, tierID *uuid.UUID) ([]*RiderChallenge, error)
@@ -394,6 +394,79 @@ func (r *Repository) IncrementRewardRedemptionCount(ctx context.Context, rewardI
 	return err
 }
 
+// GetActiveRideCredits gets a rider's unused, unexpired redemptions of
+// rewards that pay towards a fare, soonest to expire first
+func (r *Repository) GetActiveRideCredits(ctx context.Context, riderID uuid.UUID) ([]*Redemption, error) {
+	query := `
+		SELECT rd.id, rd.rider_id, rd.reward_id, rd.points_spent, rd.redemption_code,
+		       rd.status, rd.expires_at, rd.created_at,
+		       rw.id, rw.name, rw.reward_type, rw.points_required, rw.value
+		FROM loyalty_redemptions rd
+		JOIN loyalty_rewards rw ON rw.id = rd.reward_id
+		WHERE rd.rider_id = $1
+		  AND rd.status = 'active'
+		  AND rd.expires_at > NOW()
+		  AND rw.reward_type IN ('discount', 'free_ride')
+		ORDER BY rd.expires_at ASC
+	`
+
+	rows, err := r.db.Query(ctx, query, riderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var redemptions []*Redemption
+	for rows.Next() {
+		rd := &Redemption{Reward: &RewardCatalogItem{}}
+		err := rows.Scan(
+			&rd.ID, &rd.RiderID, &rd.RewardID, &rd.PointsSpent, &rd.RedemptionCode,
+			&rd.Status, &rd.ExpiresAt, &rd.CreatedAt,
+			&rd.Reward.ID, &rd.Reward.Name, &rd.Reward.RewardType, &rd.Reward.PointsRequired, &rd.Reward.Value,
+		)
+		if err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, rd)
+	}
+
+	return redemptions, rows.Err()
+}
+
+// MarkRedemptionUsed marks an active redemption as used on a ride. It returns
+// false if the redemption was no longer active.
+func (r *Repository) MarkRedemptionUsed(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE loyalty_redemptions
+		SET status = 'used', used_at = NOW(), ride_id = $2
+		WHERE id = $1 AND status = 'active'
+	`
+
+	result, err := r.db.Exec(ctx, query, redemptionID, rideID)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// ReleaseRedemption makes a redemption used on a ride active again. It
+// returns false if the redemption was not used on that ride.
+func (r *Repository) ReleaseRedemption(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE loyalty_redemptions
+		SET status = 'active', used_at = NULL, ride_id = NULL
+		WHERE id = $1 AND ride_id = $2 AND status = 'used'
+	`
+
+	result, err := r.db.Exec(ctx, query, redemptionID, rideID)
+	if err != nil {
+		return false, err
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
 // ========================================
 // CHALLENGES
 // ========================================
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -479,6 +480,73 @@ func (s *Service) GetRewardsCatalog(ctx context.Context, riderID uuid.UUID) ([]*
 	return s.repo.GetAvailableRewards(ctx, tierID)
 }
 
+// UseRideCredit spends the rider's next points redemption that pays towards
+// a fare (a discount or free ride reward from RedeemPoints) on a ride. A
+// redemption is single-use, so value above maxAmount is forfeited. It returns
+// the amount covered and the redemption used, or 0 and nil if there is none.
+func (s *Service) UseRideCredit(ctx context.Context, riderID, rideID uuid.UUID, maxAmount float64) (float64, *uuid.UUID, error) {
+	credits, err := s.repo.GetActiveRideCredits(ctx, riderID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("get ride credits: %w", err)
+	}
+
+	for _, credit := range credits {
+		amount := rideCreditValue(credit.Reward, maxAmount)
+		if amount <= 0 {
+			continue
+		}
+
+		used, err := s.repo.MarkRedemptionUsed(ctx, credit.ID, rideID)
+		if err != nil {
+			return 0, nil, fmt.Errorf("mark redemption used: %w", err)
+		}
+		if !used {
+			continue // spent concurrently
+		}
+
+		logger.Info("Ride credit used",
+			zap.String("rider_id", riderID.String()),
+			zap.String("ride_id", rideID.String()),
+			zap.String("redemption_id", credit.ID.String()),
+			zap.Float64("amount", amount),
+		)
+		return amount, &credit.ID, nil
+	}
+
+	return 0, nil, nil
+}
+
+// ReleaseRideCredit gives back a redemption UseRideCredit spent on a ride,
+// for a ride whose settlement was rolled back
+func (s *Service) ReleaseRideCredit(ctx context.Context, redemptionID, rideID uuid.UUID) error {
+	released, err := s.repo.ReleaseRedemption(ctx, redemptionID, rideID)
+	if err != nil {
+		return fmt.Errorf("release redemption: %w", err)
+	}
+	if released {
+		logger.Info("Ride credit released",
+			zap.String("ride_id", rideID.String()),
+			zap.String("redemption_id", redemptionID.String()),
+		)
+	}
+	return nil
+}
+
+// rideCreditValue returns how much of a fare up to maxAmount a reward covers.
+// A free ride without a value covers the whole fare.
+func rideCreditValue(reward *RewardCatalogItem, maxAmount float64) float64 {
+	if reward == nil {
+		return 0
+	}
+	if reward.Value == nil {
+		if reward.RewardType == "free_ride" {
+			return maxAmount
+		}
+		return 0
+	}
+	return math.Min(*reward.Value, maxAmount)
+}
+
 // GetAllTiers returns all loyalty tiers
 func (s *Service) GetAllTiers(ctx context.Context) ([]*LoyaltyTier, error) {
 	return s.repo.GetAllTiers(ctx)
@@ -107,6 +107,24 @@ func (m *mockLoyaltyRepository) IncrementRewardRedemptionCount(ctx context.Conte
 	return args.Error(0)
 }
 
+func (m *mockLoyaltyRepository) GetActiveRideCredits(ctx context.Context, riderID uuid.UUID) ([]*Redemption, error) {
+	args := m.Called(ctx, riderID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*Redemption), args.Error(1)
+}
+
+func (m *mockLoyaltyRepository) MarkRedemptionUsed(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, redemptionID, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockLoyaltyRepository) ReleaseRedemption(ctx context.Context, redemptionID, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, redemptionID, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockLoyaltyRepository) GetActiveChallenges(ctx context.Context, tierID *uuid.UUID) ([]*RiderChallenge, error) {
 	args := m.Called(ctx, tierID)
 	challenges, _ := args.Get(0).([]*RiderChallenge)
@@ -720,6 +738,57 @@ func TestRedeemPoints_RewardNotFound(t *testing.T) {
 	repo.AssertExpectations(t)
 }
 
+// ========================================
+// UseRideCredit TESTS
+// ========================================
+
+func TestUseRideCredit_SpendsFirstUsableRedemption(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockLoyaltyRepository)
+	service := NewService(repo)
+	riderID, rideID := uuid.New(), uuid.New()
+	value := 5.0
+
+	taken := &Redemption{ID: uuid.New(), Reward: &RewardCatalogItem{RewardType: "discount", Value: &value}}
+	freeRide := &Redemption{ID: uuid.New(), Reward: &RewardCatalogItem{RewardType: "free_ride"}}
+
+	repo.On("GetActiveRideCredits", ctx, riderID).Return([]*Redemption{taken, freeRide}, nil).Once()
+	repo.On("MarkRedemptionUsed", ctx, taken.ID, rideID).Return(false, nil).Once() // spent concurrently
+	repo.On("MarkRedemptionUsed", ctx, freeRide.ID, rideID).Return(true, nil).Once()
+
+	amount, redemptionID, err := service.UseRideCredit(ctx, riderID, rideID, 18.40)
+
+	require.NoError(t, err)
+	assert.Equal(t, 18.40, amount)
+	assert.Equal(t, &freeRide.ID, redemptionID)
+	repo.AssertExpectations(t)
+}
+
+func TestUseRideCredit_NoCredits(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockLoyaltyRepository)
+	service := NewService(repo)
+	riderID := uuid.New()
+
+	repo.On("GetActiveRideCredits", ctx, riderID).Return([]*Redemption{}, nil).Once()
+
+	amount, redemptionID, err := service.UseRideCredit(ctx, riderID, uuid.New(), 20)
+
+	require.NoError(t, err)
+	assert.Zero(t, amount)
+	assert.Nil(t, redemptionID)
+	repo.AssertExpectations(t)
+}
+
+func TestRideCreditValue(t *testing.T) {
+	value := 7.5
+	assert.Equal(t, 7.5, rideCreditValue(&RewardCatalogItem{RewardType: "discount", Value: &value}, 20))
+	assert.Equal(t, 4.0, rideCreditValue(&RewardCatalogItem{RewardType: "discount", Value: &value}, 4))
+	assert.Equal(t, 12.0, rideCreditValue(&RewardCatalogItem{RewardType: "free_ride"}, 12))
+	assert.Zero(t, rideCreditValue(&RewardCatalogItem{RewardType: "discount"}, 12))
+	assert.Zero(t, rideCreditValue(nil, 12))
+}
+
 // ========================================
 // checkTierUpgrade TESTS
 // ========================================
@@ -470,6 +470,9 @@ func (g *GoogleMapsProvider) convertDirectionsResponse(resp *googleDirectionsRes
 			Summary:         r.Summary,
 			Warnings:        r.Warnings,
 		}
+		if coords, err := DecodePolyline(r.OverviewPolyline.Points); err == nil {
+			route.Coordinates = coords
+		}
 
 		// Aggregate totals from legs
 		for _, leg := range r.Legs {
@@ -0,0 +1,65 @@
+package maps
+
+import (
+	"errors"
+)
+
+// ErrInvalidPolyline is returned when an encoded polyline is truncated or malformed
+var ErrInvalidPolyline = errors.New("invalid encoded polyline")
+
+// DecodePolyline decodes a route encoded with Google's polyline algorithm
+// (5 decimal places). HERE's flexible polylines use a different encoding and
+// can't be decoded with it.
+func DecodePolyline(encoded string) ([]Coordinate, error) {
+	var coords []Coordinate
+	var latitude, longitude int64
+
+	for i := 0; i < len(encoded); {
+		deltaLatitude, next, err := decodePolylineValue(encoded, i)
+		if err != nil {
+			return nil, err
+		}
+		deltaLongitude, next, err := decodePolylineValue(encoded, next)
+		if err != nil {
+			return nil, err
+		}
+		i = next
+
+		latitude += deltaLatitude
+		longitude += deltaLongitude
+		coords = append(coords, Coordinate{
+			Latitude:  float64(latitude) / 1e5,
+			Longitude: float64(longitude) / 1e5,
+		})
+	}
+
+	return coords, nil
+}
+
+// decodePolylineValue reads one zigzag-encoded value starting at i and
+// returns it with the index just past it
+func decodePolylineValue(encoded string, i int) (int64, int, error) {
+	var result int64
+	var shift uint
+
+	for {
+		if i >= len(encoded) || shift > 60 {
+			return 0, 0, ErrInvalidPolyline
+		}
+		b := int64(encoded[i]) - 63
+		i++
+		if b < 0 || b > 0x3f {
+			return 0, 0, ErrInvalidPolyline
+		}
+		result |= (b & 0x1f) << shift
+		shift += 5
+		if b < 0x20 {
+			break
+		}
+	}
+
+	if result&1 != 0 {
+		return ^(result >> 1), i, nil
+	}
+	return result >> 1, i, nil
+}
@@ -0,0 +1,44 @@
+package maps
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	coords, err := DecodePolyline("_p~iF~ps|U_ulLnnqC_mqNvxq`@")
+	require.NoError(t, err)
+	require.Len(t, coords, 3)
+
+	expected := []Coordinate{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	for i, c := range expected {
+		assert.InDelta(t, c.Latitude, coords[i].Latitude, 1e-6)
+		assert.InDelta(t, c.Longitude, coords[i].Longitude, 1e-6)
+	}
+}
+
+func TestDecodePolyline_Empty(t *testing.T) {
+	coords, err := DecodePolyline("")
+	require.NoError(t, err)
+	assert.Empty(t, coords)
+}
+
+func TestDecodePolyline_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"truncated value":        "_p~iF~ps|",
+		"missing longitude":      "_p~iF",
+		"character out of range": "_p~iF ps|U",
+	}
+	for name, encoded := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := DecodePolyline(encoded)
+			assert.ErrorIs(t, err, ErrInvalidPolyline)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package matching
+
+import "math"
+
+// infeasibleCost marks a ride/driver pair that must never be assigned, such as
+// a driver outside the ride's search results or beyond the pickup ETA limit.
+const infeasibleCost = 1e9
+
+// solveAssignment finds the minimum total cost assignment of rows (rides) to
+// columns (drivers) using the Hungarian algorithm. It returns the assigned
+// column for each row, or -1 when the row is left unassigned because there are
+// fewer columns than rows or every remaining pair is infeasible.
+func solveAssignment(cost [][]float64) []int {
+	rows := len(cost)
+	if rows == 0 {
+		return nil
+	}
+	cols := len(cost[0])
+
+	result := make([]int, rows)
+	for i := range result {
+		result[i] = -1
+	}
+	if cols == 0 {
+		return result
+	}
+
+	// The algorithm needs at least as many columns as rows
+	if rows > cols {
+		transposed := make([][]float64, cols)
+		for j := range transposed {
+			transposed[j] = make([]float64, rows)
+			for i := range cost {
+				transposed[j][i] = cost[i][j]
+			}
+		}
+		for j, i := range hungarian(transposed) {
+			if i >= 0 && cost[i][j] < infeasibleCost {
+				result[i] = j
+			}
+		}
+		return result
+	}
+
+	for i, j := range hungarian(cost) {
+		if j >= 0 && cost[i][j] < infeasibleCost {
+			result[i] = j
+		}
+	}
+	return result
+}
+
+// hungarian solves a rows <= cols assignment problem with row and column
+// potentials. Indexes are 1-based internally; column 0 is a sentinel.
+func hungarian(cost [][]float64) []int {
+	rows, cols := len(cost), len(cost[0])
+	u := make([]float64, rows+1)
+	v := make([]float64, cols+1)
+	match := make([]int, cols+1) // row matched to each column
+	way := make([]int, cols+1)
+
+	for i := 1; i <= rows; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]float64, cols+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		used := make([]bool, cols+1)
+
+		for {
+			used[j0] = true
+			i0 := match[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= cols; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= cols; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if match[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	result := make([]int, rows)
+	for i := range result {
+		result[i] = -1
+	}
+	for j := 1; j <= cols; j++ {
+		if match[j] != 0 {
+			result[match[j]-1] = j - 1
+		}
+	}
+	return result
+}
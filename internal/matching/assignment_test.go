@@ -0,0 +1,65 @@
+package matching
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assignmentCost(cost [][]float64, result []int) float64 {
+	var total float64
+	for i, j := range result {
+		if j >= 0 {
+			total += cost[i][j]
+		}
+	}
+	return total
+}
+
+func TestSolveAssignment_MinimisesTotalCost(t *testing.T) {
+	// Greedy would give ride 0 driver 0 (1 min) and leave ride 1 with driver 1 (10 min)
+	cost := [][]float64{
+		{1, 2},
+		{2, 10},
+	}
+	result := solveAssignment(cost)
+	assert.Equal(t, []int{1, 0}, result)
+	assert.Equal(t, 4.0, assignmentCost(cost, result))
+
+	cost = [][]float64{
+		{4, 1, 3},
+		{2, 0, 5},
+		{3, 2, 2},
+	}
+	result = solveAssignment(cost)
+	assert.Equal(t, []int{1, 0, 2}, result)
+	assert.Equal(t, 5.0, assignmentCost(cost, result))
+}
+
+func TestSolveAssignment_Rectangular(t *testing.T) {
+	// More drivers than rides
+	result := solveAssignment([][]float64{
+		{5, 3, 9, 1},
+		{2, 8, 7, 1.5},
+	})
+	assert.Equal(t, []int{3, 0}, result)
+
+	// More rides than drivers leaves the costliest ride unassigned
+	result = solveAssignment([][]float64{
+		{3},
+		{1},
+		{7},
+	})
+	assert.Equal(t, []int{-1, 0, -1}, result)
+}
+
+func TestSolveAssignment_Infeasible(t *testing.T) {
+	result := solveAssignment([][]float64{
+		{infeasibleCost, 4},
+		{infeasibleCost, 6},
+	})
+	assert.Equal(t, []int{1, -1}, result)
+
+	assert.Equal(t, []int{-1, -1}, solveAssignment([][]float64{{}, {}}))
+	assert.Nil(t, solveAssignment(nil))
+}
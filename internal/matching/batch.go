@@ -0,0 +1,302 @@
+package matching
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/geo"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ETAEstimator estimates how long a driver needs to reach a pickup point.
+// Production wires an adapter over maps or mleta; simulations can plug in
+// deterministic estimates.
+type ETAEstimator interface {
+	EstimatePickupETA(ctx context.Context, driver DriverLocation, pickupLatitude, pickupLongitude float64) (float64, error)
+}
+
+// rideBatch collects ride requests from one H3 cell during a batch window
+type rideBatch struct {
+	cell   string
+	events []*RideRequestedEvent
+}
+
+// batchAssignment is the driver chosen for a ride by a batch solve
+type batchAssignment struct {
+	Event      *RideRequestedEvent
+	Driver     DriverLocation
+	ETAMinutes float64
+}
+
+// SetETAEstimator sets the pickup ETA source for batch matching. Without one,
+// ETAs are estimated from straight-line distance.
+func (s *Service) SetETAEstimator(estimator ETAEstimator) {
+	s.etaEstimator = estimator
+}
+
+// enqueueBatch adds a ride request to its cell's batch. The first request in
+// a cell opens the window; the batch is solved when the window closes.
+func (s *Service) enqueueBatch(ctx context.Context, event *RideRequestedEvent) {
+	cell := geo.LatLngToCell(event.PickupLatitude, event.PickupLongitude, s.config.BatchH3Resolution).String()
+
+	s.batchMu.Lock()
+	batch, open := s.batches[cell]
+	if !open {
+		batch = &rideBatch{cell: cell}
+		s.batches[cell] = batch
+	}
+	batch.events = append(batch.events, event)
+	s.batchMu.Unlock()
+
+	logger.Info("Queued ride request for batch matching",
+		zap.String("ride_id", event.RideID.String()),
+		zap.String("cell", cell))
+
+	if !open {
+		s.afterFunc(s.config.BatchWindow, func() {
+			s.flushBatch(ctx, cell)
+		})
+	}
+}
+
+// removeFromBatch drops a ride that was cancelled while waiting in a batch
+func (s *Service) removeFromBatch(rideID uuid.UUID) bool {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	for _, batch := range s.batches {
+		for i, event := range batch.events {
+			if event.RideID == rideID {
+				batch.events = append(batch.events[:i], batch.events[i+1:]...)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flushBatch closes a cell's window, assigns drivers to its rides and sends
+// each ride a single offer. Rides left without a driver fall back to the
+// broadcast flow using drivers the batch did not take.
+func (s *Service) flushBatch(ctx context.Context, cell string) {
+	s.batchMu.Lock()
+	batch := s.batches[cell]
+	delete(s.batches, cell)
+	s.batchMu.Unlock()
+
+	if batch == nil || len(batch.events) == 0 {
+		return
+	}
+
+	candidates := make(map[uuid.UUID][]DriverLocation, len(batch.events))
+	ineligible := make(map[uuid.UUID]vehicle.IneligibleReason)
+	for _, event := range batch.events {
+		drivers, err := s.findAvailableDrivers(ctx, event.PickupLatitude, event.PickupLongitude)
+		if err != nil {
+			logger.Error("Failed to find nearby drivers for batch", zap.Error(err),
+				zap.String("ride_id", event.RideID.String()))
+			continue
+		}
+		drivers, reason := s.filterEligible(ctx, event, drivers)
+		drivers = s.applyRiderDriverLists(ctx, event.RiderID, drivers)
+		candidates[event.RideID] = drivers
+		if reason != "" {
+			ineligible[event.RideID] = reason
+		}
+	}
+
+	assignments := s.matchBatch(ctx, batch.events, candidates)
+
+	expiresAt := time.Now().Add(time.Duration(s.config.OfferTimeoutSeconds) * time.Second)
+	assignedDrivers := make(map[uuid.UUID]bool, len(assignments))
+	assignedRides := make(map[uuid.UUID]bool, len(assignments))
+	for _, a := range assignments {
+		driver := a.Driver
+		driver.ETAMinutes = a.ETAMinutes
+		s.sendOfferToDriver(ctx, a.Event, driver, expiresAt)
+		assignedDrivers[driver.DriverID] = true
+		assignedRides[a.Event.RideID] = true
+	}
+
+	logger.Info("Solved matching batch",
+		zap.String("cell", cell),
+		zap.Int("rides", len(batch.events)),
+		zap.Int("assigned", len(assignments)))
+
+	for _, event := range batch.events {
+		if assignedRides[event.RideID] {
+			continue
+		}
+		drivers, searched := candidates[event.RideID]
+		if !searched {
+			continue
+		}
+
+		var remaining []DriverLocation
+		for _, d := range drivers {
+			if !assignedDrivers[d.DriverID] {
+				remaining = append(remaining, d)
+			}
+		}
+		if len(remaining) == 0 {
+			logger.Warn("No drivers left for ride after batch matching",
+				zap.String("ride_id", event.RideID.String()))
+			s.notifyRiderNoDrivers(event.RiderID, event.RideID, ineligible[event.RideID])
+			continue
+		}
+		s.sendOffersToDrivers(ctx, event, remaining)
+	}
+}
+
+// matchBatch builds a rides × drivers pickup ETA matrix and returns the
+// assignment with the lowest total ETA. A driver is only considered for the
+// rides whose search returned them, and pairs above MaxPickupETAMinutes are
+// never assigned. Drivers the rider trusts are costed TrustedDriverETABonus
+// minutes lower so they win close calls.
+func (s *Service) matchBatch(ctx context.Context, events []*RideRequestedEvent, candidates map[uuid.UUID][]DriverLocation) []batchAssignment {
+	var drivers []DriverLocation
+	driverIndex := make(map[uuid.UUID]int)
+	for _, event := range events {
+		for _, d := range candidates[event.RideID] {
+			if _, seen := driverIndex[d.DriverID]; !seen {
+				driverIndex[d.DriverID] = len(drivers)
+				drivers = append(drivers, d)
+			}
+		}
+	}
+	if len(drivers) == 0 {
+		return nil
+	}
+
+	pickupETAs := s.estimatePickupETAs(ctx, events, candidates)
+	cost := make([][]float64, len(events))
+	etas := make([][]float64, len(events))
+	for i, event := range events {
+		cost[i] = make([]float64, len(drivers))
+		etas[i] = make([]float64, len(drivers))
+		for j := range cost[i] {
+			cost[i][j] = infeasibleCost
+		}
+		for k, d := range candidates[event.RideID] {
+			eta := pickupETAs[i][k]
+			if s.config.MaxPickupETAMinutes > 0 && eta > s.config.MaxPickupETAMinutes {
+				continue
+			}
+			j := driverIndex[d.DriverID]
+			etas[i][j] = eta
+			cost[i][j] = eta
+			if d.Trusted {
+				cost[i][j] = math.Max(0, eta-s.config.TrustedDriverETABonus)
+			}
+		}
+	}
+
+	var assignments []batchAssignment
+	for i, j := range solveAssignment(cost) {
+		if j < 0 {
+			continue
+		}
+		assignments = append(assignments, batchAssignment{
+			Event:      events[i],
+			Driver:     drivers[j],
+			ETAMinutes: etas[i][j],
+		})
+	}
+	return assignments
+}
+
+// estimatePickupETAs returns the pickup ETA of each ride's candidates, in
+// candidate order. Estimates run BatchETAConcurrency at a time and must
+// finish within BatchETATimeout of the flush; pairs whose estimate failed or
+// is still outstanding then use the straight-line estimate, so a slow
+// estimator cannot hold up the batch.
+func (s *Service) estimatePickupETAs(ctx context.Context, events []*RideRequestedEvent, candidates map[uuid.UUID][]DriverLocation) [][]float64 {
+	etas := make([][]float64, len(events))
+	estimated := make([][]bool, len(events))
+	for i, event := range events {
+		etas[i] = make([]float64, len(candidates[event.RideID]))
+		estimated[i] = make([]bool, len(candidates[event.RideID]))
+	}
+
+	if s.etaEstimator != nil {
+		if s.config.BatchETATimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.config.BatchETATimeout)
+			defer cancel()
+		}
+
+		var (
+			mu     sync.Mutex
+			wg     sync.WaitGroup
+			closed bool // set at the deadline; later estimates are discarded
+		)
+		sem := make(chan struct{}, max(s.config.BatchETAConcurrency, 1))
+	pairs:
+		for i, event := range events {
+			for k, d := range candidates[event.RideID] {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					break pairs
+				}
+				wg.Add(1)
+				go func(i, k int, driver DriverLocation, pickupLatitude, pickupLongitude float64) {
+					defer wg.Done()
+					defer func() { <-sem }()
+					eta, err := s.etaEstimator.EstimatePickupETA(ctx, driver, pickupLatitude, pickupLongitude)
+					if err != nil {
+						// Timeouts are logged once for the batch
+						if ctx.Err() != nil {
+							return
+						}
+						logger.Warn("Pickup ETA estimate failed, using distance",
+							zap.String("driver_id", driver.DriverID.String()), zap.Error(err))
+						return
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					if !closed {
+						etas[i][k] = eta
+						estimated[i][k] = true
+					}
+				}(i, k, d, event.PickupLatitude, event.PickupLongitude)
+			}
+		}
+
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			logger.Warn("Pickup ETA estimates timed out, using distance for the rest",
+				zap.Duration("timeout", s.config.BatchETATimeout))
+		}
+		mu.Lock()
+		closed = true
+		mu.Unlock()
+	}
+
+	for i, event := range events {
+		for k, d := range candidates[event.RideID] {
+			if !estimated[i][k] {
+				etas[i][k] = s.distancePickupETA(d, event.PickupLatitude, event.PickupLongitude)
+			}
+		}
+	}
+	return etas
+}
+
+// distancePickupETA estimates the driver's pickup ETA in minutes from
+// straight-line distance at 30 km/h
+func (s *Service) distancePickupETA(driver DriverLocation, pickupLatitude, pickupLongitude float64) float64 {
+	distance := s.geoService.CalculateDistance(driver.Latitude, driver.Longitude, pickupLatitude, pickupLongitude)
+	return math.Round(distance/30.0*60*100) / 100
+}
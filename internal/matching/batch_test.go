@@ -0,0 +1,207 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeETAEstimator returns fixed pickup ETAs keyed by driver and ride pickup
+type fakeETAEstimator struct {
+	etas map[uuid.UUID]map[float64]float64 // driver -> pickup latitude -> minutes
+}
+
+func (f *fakeETAEstimator) EstimatePickupETA(_ context.Context, driver DriverLocation, pickupLatitude, _ float64) (float64, error) {
+	eta, ok := f.etas[driver.DriverID][pickupLatitude]
+	if !ok {
+		return 0, errors.New("no route")
+	}
+	return eta, nil
+}
+
+func newBatchTestService(t *testing.T) (*Service, *mockGeoService, *mockRedis) {
+	t.Helper()
+	svc, geo, _, redis, _ := newTestService(t)
+	svc.config.BatchMatchingEnabled = true
+	svc.config.MaxPickupETAMinutes = 15
+	return svc, geo, redis
+}
+
+func TestMatchBatch_AvoidsAssigningNearestDriverTwice(t *testing.T) {
+	svc, _, _ := newBatchTestService(t)
+	ctx := context.Background()
+
+	rideA := newRideRequestedEvent()
+	rideA.PickupLatitude = 37.7749
+	rideB := newRideRequestedEvent()
+	rideB.PickupLatitude = 37.7760
+
+	near := DriverLocation{DriverID: uuid.New()}
+	far := DriverLocation{DriverID: uuid.New()}
+
+	// The near driver is closest to both rides, but ride B has no good
+	// alternative while ride A does
+	svc.SetETAEstimator(&fakeETAEstimator{etas: map[uuid.UUID]map[float64]float64{
+		near.DriverID: {rideA.PickupLatitude: 2, rideB.PickupLatitude: 3},
+		far.DriverID:  {rideA.PickupLatitude: 4, rideB.PickupLatitude: 12},
+	}})
+
+	assignments := svc.matchBatch(ctx, []*RideRequestedEvent{rideA, rideB}, map[uuid.UUID][]DriverLocation{
+		rideA.RideID: {near, far},
+		rideB.RideID: {near, far},
+	})
+
+	require.Len(t, assignments, 2)
+	byRide := map[uuid.UUID]batchAssignment{}
+	for _, a := range assignments {
+		byRide[a.Event.RideID] = a
+	}
+	assert.Equal(t, far.DriverID, byRide[rideA.RideID].Driver.DriverID)
+	assert.Equal(t, 4.0, byRide[rideA.RideID].ETAMinutes)
+	assert.Equal(t, near.DriverID, byRide[rideB.RideID].Driver.DriverID)
+}
+
+func TestMatchBatch_RespectsCandidatesAndETALimit(t *testing.T) {
+	svc, _, _ := newBatchTestService(t)
+	ctx := context.Background()
+
+	rideA := newRideRequestedEvent()
+	rideA.PickupLatitude = 37.70
+	rideB := newRideRequestedEvent()
+	rideB.PickupLatitude = 37.80
+
+	d1 := DriverLocation{DriverID: uuid.New()}
+	d2 := DriverLocation{DriverID: uuid.New()}
+	svc.SetETAEstimator(&fakeETAEstimator{etas: map[uuid.UUID]map[float64]float64{
+		d1.DriverID: {rideA.PickupLatitude: 5, rideB.PickupLatitude: 1},
+		d2.DriverID: {rideA.PickupLatitude: 20},
+	}})
+
+	// d1 was only found by ride A's search and d2 is over the ETA limit
+	assignments := svc.matchBatch(ctx, []*RideRequestedEvent{rideA, rideB}, map[uuid.UUID][]DriverLocation{
+		rideA.RideID: {d1, d2},
+		rideB.RideID: {},
+	})
+
+	require.Len(t, assignments, 1)
+	assert.Equal(t, rideA.RideID, assignments[0].Event.RideID)
+	assert.Equal(t, d1.DriverID, assignments[0].Driver.DriverID)
+}
+
+func TestMatchBatch_FallsBackToDistanceETA(t *testing.T) {
+	svc, geo, _ := newBatchTestService(t)
+	ctx := context.Background()
+
+	ride := newRideRequestedEvent()
+	driver := DriverLocation{DriverID: uuid.New(), Latitude: 37.78, Longitude: -122.42}
+	geo.On("CalculateDistance", driver.Latitude, driver.Longitude, ride.PickupLatitude, ride.PickupLongitude).Return(2.5)
+
+	assignments := svc.matchBatch(ctx, []*RideRequestedEvent{ride}, map[uuid.UUID][]DriverLocation{
+		ride.RideID: {driver},
+	})
+
+	require.Len(t, assignments, 1)
+	assert.Equal(t, 5.0, assignments[0].ETAMinutes)
+	geo.AssertExpectations(t)
+}
+
+// slowETAEstimator answers only once its context is done
+type slowETAEstimator struct{}
+
+func (slowETAEstimator) EstimatePickupETA(ctx context.Context, _ DriverLocation, _, _ float64) (float64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func TestMatchBatch_FallsBackToDistanceAfterETADeadline(t *testing.T) {
+	svc, geo, _ := newBatchTestService(t)
+	svc.config.BatchETATimeout = 20 * time.Millisecond
+	svc.config.BatchETAConcurrency = 1
+	svc.SetETAEstimator(slowETAEstimator{})
+	ctx := context.Background()
+
+	ride := newRideRequestedEvent()
+	near := DriverLocation{DriverID: uuid.New(), Latitude: 37.78, Longitude: -122.42}
+	far := DriverLocation{DriverID: uuid.New(), Latitude: 37.80, Longitude: -122.40}
+	geo.On("CalculateDistance", near.Latitude, near.Longitude, ride.PickupLatitude, ride.PickupLongitude).Return(2.5)
+	geo.On("CalculateDistance", far.Latitude, far.Longitude, ride.PickupLatitude, ride.PickupLongitude).Return(5.0)
+
+	start := time.Now()
+	assignments := svc.matchBatch(ctx, []*RideRequestedEvent{ride}, map[uuid.UUID][]DriverLocation{
+		ride.RideID: {far, near},
+	})
+
+	assert.Less(t, time.Since(start), time.Second)
+	require.Len(t, assignments, 1)
+	assert.Equal(t, near.DriverID, assignments[0].Driver.DriverID)
+	assert.Equal(t, 5.0, assignments[0].ETAMinutes)
+}
+
+func TestOnRideRequested_BatchModeQueuesUntilWindowCloses(t *testing.T) {
+	svc, geo, redis := newBatchTestService(t)
+	hub := svc.wsHub
+	ctx := context.Background()
+
+	var scheduled []func()
+	svc.afterFunc = func(d time.Duration, f func()) *time.Timer {
+		assert.Equal(t, svc.config.BatchWindow, d)
+		scheduled = append(scheduled, f)
+		return nil
+	}
+
+	rideA := newRideRequestedEvent()
+	rideB := newRideRequestedEvent()
+	rideB.PickupLatitude += 0.0001
+	svc.onRideRequested(ctx, rideA)
+	svc.onRideRequested(ctx, rideB)
+
+	// Both requests share a cell, so only one window is opened and nothing is sent yet
+	require.Len(t, scheduled, 1)
+	assert.Empty(t, drainBroadcast(hub))
+
+	driverA := uuid.New()
+	driverB := uuid.New()
+	geo.On("FindAvailableDrivers", ctx, mock.AnythingOfType("float64"), mock.AnythingOfType("float64"), 10).Return([]*GeoDriverLocation{
+		{DriverID: driverA, Latitude: 37.775, Longitude: -122.419},
+		{DriverID: driverB, Latitude: 37.790, Longitude: -122.430},
+	}, nil)
+	geo.On("CalculateDistance", mock.AnythingOfType("float64"), mock.AnythingOfType("float64"), mock.AnythingOfType("float64"), mock.AnythingOfType("float64")).Return(1.0)
+	redis.On("SetWithExpiration", ctx, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Duration")).Return(nil)
+	redis.On("GetString", ctx, mock.AnythingOfType("string")).Return("", errors.New("not found"))
+
+	scheduled[0]()
+
+	msgs := drainBroadcast(hub)
+	require.Len(t, msgs, 2)
+	assert.NotEqual(t, msgs[0].TargetID, msgs[1].TargetID)
+	for _, msg := range msgs {
+		assert.Equal(t, "ride.offer", msg.Message.Type)
+	}
+	assert.Empty(t, svc.batches)
+}
+
+func TestForgetCancelledRide_RemovesRideFromBatch(t *testing.T) {
+	svc, _, _ := newBatchTestService(t)
+	ctx := context.Background()
+	svc.afterFunc = func(time.Duration, func()) *time.Timer { return nil }
+
+	kept := newRideRequestedEvent()
+	cancelled := newRideRequestedEvent()
+	svc.onRideRequested(ctx, kept)
+	svc.onRideRequested(ctx, cancelled)
+
+	svc.forgetCancelledRide(&RideCancelledEvent{RideID: cancelled.RideID})
+
+	require.Len(t, svc.batches, 1)
+	for _, batch := range svc.batches {
+		require.Len(t, batch.events, 1)
+		assert.Equal(t, kept.RideID, batch.events[0].RideID)
+	}
+	assert.False(t, svc.removeFromBatch(cancelled.RideID))
+}
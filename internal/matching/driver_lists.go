@@ -0,0 +1,115 @@
+package matching
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// RiderDriverLists holds the drivers a rider blocked or marked as trusted
+type RiderDriverLists struct {
+	Blocked map[uuid.UUID]bool
+	Trusted map[uuid.UUID]bool
+}
+
+// DriverListStore loads a rider's blocked and trusted drivers in one lookup
+type DriverListStore interface {
+	GetRiderDriverLists(ctx context.Context, riderID uuid.UUID) (*RiderDriverLists, error)
+}
+
+// cachedDriverLists is a rider's lists with the time they stop being fresh
+type cachedDriverLists struct {
+	lists     *RiderDriverLists
+	expiresAt time.Time
+}
+
+// SetDriverListStore keeps riders from being offered to drivers they blocked
+// and puts their trusted drivers first.
+func (s *Service) SetDriverListStore(store DriverListStore) {
+	s.driverLists = store
+}
+
+// riderDriverLists returns the rider's lists, loading them at most once per
+// DriverListCacheTTL. If loading fails, the lists last loaded are used even
+// when expired, as they are closer to the truth than none.
+func (s *Service) riderDriverLists(ctx context.Context, riderID uuid.UUID) (*RiderDriverLists, error) {
+	now := time.Now()
+
+	s.driverListsMu.Lock()
+	cached, ok := s.driverListCache[riderID]
+	s.driverListsMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.lists, nil
+	}
+
+	lists, err := s.driverLists.GetRiderDriverLists(ctx, riderID)
+	if err != nil {
+		if ok {
+			logger.Warn("Failed to reload rider's blocked and trusted drivers, using expired lists", zap.Error(err),
+				zap.String("rider_id", riderID.String()))
+			return cached.lists, nil
+		}
+		return nil, err
+	}
+
+	s.driverListsMu.Lock()
+	for id, entry := range s.driverListCache {
+		if !now.Before(entry.expiresAt) {
+			delete(s.driverListCache, id)
+		}
+	}
+	s.driverListCache[riderID] = cachedDriverLists{lists: lists, expiresAt: now.Add(s.config.DriverListCacheTTL)}
+	s.driverListsMu.Unlock()
+
+	return lists, nil
+}
+
+// invalidateRiderDriverLists drops a rider's cached lists after they changed
+func (s *Service) invalidateRiderDriverLists(riderID uuid.UUID) {
+	s.driverListsMu.Lock()
+	delete(s.driverListCache, riderID)
+	s.driverListsMu.Unlock()
+}
+
+// applyRiderDriverLists removes drivers the rider blocked and moves trusted
+// drivers to the front, keeping the existing order otherwise. If the lists
+// cannot be loaded no driver is kept, so a rider is never offered to a
+// driver they blocked.
+func (s *Service) applyRiderDriverLists(ctx context.Context, riderID uuid.UUID, drivers []DriverLocation) []DriverLocation {
+	if s.driverLists == nil || len(drivers) == 0 {
+		return drivers
+	}
+
+	lists, err := s.riderDriverLists(ctx, riderID)
+	if err != nil {
+		logger.Error("Failed to load rider's blocked and trusted drivers, skipping all drivers", zap.Error(err),
+			zap.String("rider_id", riderID.String()))
+		return nil
+	}
+	if len(lists.Blocked) == 0 && len(lists.Trusted) == 0 {
+		return drivers
+	}
+
+	kept := make([]DriverLocation, 0, len(drivers))
+	for _, d := range drivers {
+		if lists.Blocked[d.DriverID] {
+			continue
+		}
+		d.Trusted = lists.Trusted[d.DriverID]
+		kept = append(kept, d)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Trusted && !kept[j].Trusted
+	})
+
+	if len(kept) < len(drivers) {
+		logger.Info("Skipped drivers blocked by rider",
+			zap.String("rider_id", riderID.String()),
+			zap.Int("count", len(drivers)-len(kept)))
+	}
+	return kept
+}
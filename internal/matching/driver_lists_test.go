@@ -0,0 +1,144 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriverLists serves fixed lists and counts lookups
+type fakeDriverLists struct {
+	lists RiderDriverLists
+	err   error
+	calls int
+}
+
+func (f *fakeDriverLists) GetRiderDriverLists(_ context.Context, _ uuid.UUID) (*RiderDriverLists, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &f.lists, nil
+}
+
+func TestApplyRiderDriverLists_SkipsBlockedAndPutsTrustedFirst(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	nearest, blocked, trusted := uuid.New(), uuid.New(), uuid.New()
+	store := &fakeDriverLists{lists: RiderDriverLists{
+		Blocked: map[uuid.UUID]bool{blocked: true},
+		Trusted: map[uuid.UUID]bool{trusted: true},
+	}}
+	svc.SetDriverListStore(store)
+
+	drivers := svc.applyRiderDriverLists(context.Background(), uuid.New(),
+		[]DriverLocation{{DriverID: nearest}, {DriverID: blocked}, {DriverID: trusted}})
+
+	require.Len(t, drivers, 2)
+	assert.Equal(t, trusted, drivers[0].DriverID)
+	assert.True(t, drivers[0].Trusted)
+	assert.Equal(t, nearest, drivers[1].DriverID)
+}
+
+func TestApplyRiderDriverLists_CachesPerRider(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	store := &fakeDriverLists{}
+	svc.SetDriverListStore(store)
+	ctx := context.Background()
+	riderID := uuid.New()
+	drivers := []DriverLocation{{DriverID: uuid.New()}}
+
+	svc.applyRiderDriverLists(ctx, riderID, drivers)
+	svc.applyRiderDriverLists(ctx, riderID, drivers)
+	assert.Equal(t, 1, store.calls)
+
+	svc.applyRiderDriverLists(ctx, uuid.New(), drivers)
+	assert.Equal(t, 2, store.calls)
+}
+
+func TestApplyRiderDriverLists_SkipsAllDriversWhenLookupFails(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	svc.SetDriverListStore(&fakeDriverLists{err: errors.New("db down")})
+	drivers := []DriverLocation{{DriverID: uuid.New()}}
+
+	assert.Empty(t, svc.applyRiderDriverLists(context.Background(), uuid.New(), drivers))
+}
+
+func TestApplyRiderDriverLists_UsesExpiredListsWhenReloadFails(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	svc.config.DriverListCacheTTL = 0 // every lookup reloads
+	blocked := uuid.New()
+	store := &fakeDriverLists{lists: RiderDriverLists{Blocked: map[uuid.UUID]bool{blocked: true}}}
+	svc.SetDriverListStore(store)
+	ctx := context.Background()
+	riderID := uuid.New()
+	drivers := []DriverLocation{{DriverID: uuid.New()}, {DriverID: blocked}}
+
+	require.Len(t, svc.applyRiderDriverLists(ctx, riderID, drivers), 1)
+
+	store.err = errors.New("db down")
+	kept := svc.applyRiderDriverLists(ctx, riderID, drivers)
+	require.Len(t, kept, 1)
+	assert.NotEqual(t, blocked, kept[0].DriverID)
+	assert.Equal(t, 2, store.calls)
+}
+
+func TestInvalidateRiderDriverLists_ReloadsOnNextLookup(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	driverID := uuid.New()
+	store := &fakeDriverLists{}
+	svc.SetDriverListStore(store)
+	ctx := context.Background()
+	riderID := uuid.New()
+	drivers := []DriverLocation{{DriverID: driverID}}
+
+	require.Len(t, svc.applyRiderDriverLists(ctx, riderID, drivers), 1)
+
+	// The rider blocks the driver within the cache TTL
+	store.lists.Blocked = map[uuid.UUID]bool{driverID: true}
+	svc.invalidateRiderDriverLists(riderID)
+
+	assert.Empty(t, svc.applyRiderDriverLists(ctx, riderID, drivers))
+	assert.Equal(t, 2, store.calls)
+}
+
+func TestSendOffersToDrivers_AllBlocked_NotifiesRider(t *testing.T) {
+	svc, _, _, _, hub := newTestService(t)
+	event := newRideRequestedEvent()
+	driverID := uuid.New()
+	svc.SetDriverListStore(&fakeDriverLists{lists: RiderDriverLists{
+		Blocked: map[uuid.UUID]bool{driverID: true},
+	}})
+
+	svc.sendOffersToDrivers(context.Background(), event, []DriverLocation{{DriverID: driverID}})
+
+	msgs := drainBroadcast(hub)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, event.RiderID.String(), msgs[0].TargetID)
+	assert.Equal(t, "ride.no_drivers", msgs[0].Message.Type)
+}
+
+func TestMatchBatch_PrefersTrustedDriverOnCloseCall(t *testing.T) {
+	svc, _, _ := newBatchTestService(t)
+	svc.config.TrustedDriverETABonus = 2
+
+	ride := newRideRequestedEvent()
+	closer := DriverLocation{DriverID: uuid.New()}
+	trusted := DriverLocation{DriverID: uuid.New(), Trusted: true}
+	svc.SetETAEstimator(&fakeETAEstimator{etas: map[uuid.UUID]map[float64]float64{
+		closer.DriverID:  {ride.PickupLatitude: 4},
+		trusted.DriverID: {ride.PickupLatitude: 5},
+	}})
+
+	assignments := svc.matchBatch(context.Background(), []*RideRequestedEvent{ride}, map[uuid.UUID][]DriverLocation{
+		ride.RideID: {closer, trusted},
+	})
+
+	require.Len(t, assignments, 1)
+	assert.Equal(t, trusted.DriverID, assignments[0].Driver.DriverID)
+	// The offer still shows the real ETA
+	assert.Equal(t, 5.0, assignments[0].ETAMinutes)
+}
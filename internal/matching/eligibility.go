@@ -0,0 +1,74 @@
+package matching
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// EligibilityChecker loads what a ride needs from a driver's vehicle and what
+// nearby drivers can offer
+type EligibilityChecker interface {
+	GetRideRequirements(ctx context.Context, event *RideRequestedEvent) (*vehicle.RideRequirements, error)
+	GetDriverEquipment(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]*vehicle.DriverEquipment, error)
+}
+
+// SetEligibilityChecker limits offers to drivers whose primary vehicle and
+// capabilities suit the ride type and the rider's needs.
+func (s *Service) SetEligibilityChecker(checker EligibilityChecker) {
+	s.eligibility = checker
+}
+
+// filterEligible removes drivers who cannot serve the ride. When none are
+// left it also returns the reason that excluded the most drivers. Drivers are
+// kept if the lookup fails.
+func (s *Service) filterEligible(ctx context.Context, event *RideRequestedEvent, drivers []DriverLocation) ([]DriverLocation, vehicle.IneligibleReason) {
+	if s.eligibility == nil || len(drivers) == 0 {
+		return drivers, ""
+	}
+
+	requirements, err := s.eligibility.GetRideRequirements(ctx, event)
+	if err != nil {
+		logger.Warn("Failed to load ride requirements", zap.Error(err),
+			zap.String("ride_id", event.RideID.String()))
+		return drivers, ""
+	}
+
+	ids := make([]uuid.UUID, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.DriverID
+	}
+	equipment, err := s.eligibility.GetDriverEquipment(ctx, ids)
+	if err != nil {
+		logger.Warn("Failed to load driver equipment", zap.Error(err),
+			zap.String("ride_id", event.RideID.String()))
+		return drivers, ""
+	}
+
+	kept := make([]DriverLocation, 0, len(drivers))
+	reasons := make(map[vehicle.IneligibleReason]int)
+	for _, d := range drivers {
+		var e vehicle.DriverEquipment
+		if found := equipment[d.DriverID]; found != nil {
+			e = *found
+		}
+		if reason := requirements.Check(e); reason != "" {
+			reasons[reason]++
+			continue
+		}
+		kept = append(kept, d)
+	}
+
+	if len(kept) < len(drivers) {
+		logger.Info("Skipped drivers not eligible for ride",
+			zap.String("ride_id", event.RideID.String()),
+			zap.Int("count", len(drivers)-len(kept)))
+	}
+	if len(kept) == 0 {
+		return kept, vehicle.MostCommonReason(reasons)
+	}
+	return kept, ""
+}
@@ -0,0 +1,87 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEligibility returns fixed requirements and equipment
+type fakeEligibility struct {
+	requirements vehicle.RideRequirements
+	equipment    map[uuid.UUID]*vehicle.DriverEquipment
+	err          error
+}
+
+func (f *fakeEligibility) GetRideRequirements(_ context.Context, _ *RideRequestedEvent) (*vehicle.RideRequirements, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &f.requirements, nil
+}
+
+func (f *fakeEligibility) GetDriverEquipment(_ context.Context, _ []uuid.UUID) (map[uuid.UUID]*vehicle.DriverEquipment, error) {
+	return f.equipment, f.err
+}
+
+func TestFilterEligible_DropsDriversThatCannotServeRide(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	xl, sedan, noVehicle := uuid.New(), uuid.New(), uuid.New()
+	svc.SetEligibilityChecker(&fakeEligibility{
+		requirements: vehicle.RideRequirements{Categories: []vehicle.VehicleCategory{vehicle.VehicleCategoryXL}},
+		equipment: map[uuid.UUID]*vehicle.DriverEquipment{
+			xl:    {HasVehicle: true, Category: vehicle.VehicleCategoryXL, MaxPassengers: 6},
+			sedan: {HasVehicle: true, Category: vehicle.VehicleCategoryEconomy, MaxPassengers: 4},
+		},
+	})
+
+	drivers, reason := svc.filterEligible(context.Background(), newRideRequestedEvent(),
+		[]DriverLocation{{DriverID: sedan}, {DriverID: xl}, {DriverID: noVehicle}})
+
+	require.Len(t, drivers, 1)
+	assert.Equal(t, xl, drivers[0].DriverID)
+	assert.Empty(t, reason)
+}
+
+func TestFilterEligible_KeepsDriversWhenLookupFails(t *testing.T) {
+	svc, _, _, _, _ := newTestService(t)
+	svc.SetEligibilityChecker(&fakeEligibility{err: errors.New("db down")})
+	drivers := []DriverLocation{{DriverID: uuid.New()}}
+
+	kept, reason := svc.filterEligible(context.Background(), newRideRequestedEvent(), drivers)
+
+	assert.Equal(t, drivers, kept)
+	assert.Empty(t, reason)
+}
+
+func TestOnRideRequested_NoEligibleDrivers_NotifiesRiderWithReason(t *testing.T) {
+	svc, geo, _, _, hub := newTestService(t)
+	ctx := context.Background()
+	event := newRideRequestedEvent()
+
+	driverID := uuid.New()
+	geo.On("FindAvailableDrivers", ctx, event.PickupLatitude, event.PickupLongitude, 10).Return([]*GeoDriverLocation{
+		{DriverID: driverID, Latitude: 37.78, Longitude: -122.42},
+	}, nil)
+	svc.SetEligibilityChecker(&fakeEligibility{
+		requirements: vehicle.RideRequirements{WheelchairAccess: true},
+		equipment: map[uuid.UUID]*vehicle.DriverEquipment{
+			driverID: {HasVehicle: true, Category: vehicle.VehicleCategoryEconomy, MaxPassengers: 4},
+		},
+	})
+
+	svc.onRideRequested(ctx, event)
+
+	msgs := drainBroadcast(hub)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, event.RiderID.String(), msgs[0].TargetID)
+	assert.Equal(t, "ride.no_drivers", msgs[0].Message.Type)
+	assert.Equal(t, string(vehicle.IneligibleWheelchairAccess), msgs[0].Message.Data["reason"])
+	assert.Equal(t, vehicle.NoEligibleDriverMessage(vehicle.IneligibleWheelchairAccess), msgs[0].Message.Data["message"])
+	geo.AssertExpectations(t)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/geo"
 )
 
 // RideRequestedEvent represents a ride request event from NATS
@@ -45,11 +46,13 @@ type RideCancelledEvent struct {
 
 // DriverLocation represents a driver's location from Redis geo
 type DriverLocation struct {
-	UserID    uuid.UUID
-	DriverID  uuid.UUID
-	Latitude  float64
-	Longitude float64
-	Distance  float64 // Distance to pickup in km
+	UserID     uuid.UUID
+	DriverID   uuid.UUID
+	Latitude   float64
+	Longitude  float64
+	Distance   float64 // Distance to pickup in km
+	ETAMinutes float64 // Pickup ETA when already estimated by batch matching
+	Trusted    bool    // The rider marked this driver as trusted
 }
 
 // RideOffer represents an offer sent to a driver
@@ -84,6 +87,12 @@ type OfferTracking struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// PendingOffer is an offer still waiting on the driver's response
+type PendingOffer struct {
+	RideID   uuid.UUID
+	DriverID uuid.UUID
+}
+
 // MatchingConfig holds configuration for the matching service
 type MatchingConfig struct {
 	SearchRadiusKm      float64       // Initial search radius in km
@@ -93,6 +102,23 @@ type MatchingConfig struct {
 	OfferTimeoutSeconds int           // Timeout for driver to accept offer
 	RetryDelaySeconds   int           // Delay before sending to next batch of drivers
 	FirstBatchSize      int           // Number of drivers to notify first
+
+	// Batch matching
+	BatchMatchingEnabled bool          // Collect requests per H3 cell and assign drivers globally
+	BatchWindow          time.Duration // How long a cell collects requests before it is solved
+	BatchH3Resolution    int           // H3 resolution of the batching cells
+	MaxPickupETAMinutes  float64       // Never assign a driver further away than this
+	BatchETATimeout      time.Duration // Deadline for a batch's pickup ETA estimates; the rest use distance
+	BatchETAConcurrency  int           // Pickup ETA estimates run at once per batch
+
+	// Offer response tracking
+	OfferCooldownAfterIgnored int           // Pause offers after this many consecutive expired offers (0 disables)
+	OfferCooldown             time.Duration // How long offers stay paused
+	OfferExpiryInterval       time.Duration // How often expired offers are counted as ignored
+
+	// Rider blocked and trusted drivers
+	DriverListCacheTTL    time.Duration // How long a rider's blocked and trusted drivers are cached
+	TrustedDriverETABonus float64       // Minutes taken off a trusted driver's pickup ETA in batch matching
 }
 
 // DefaultMatchingConfig returns default configuration
@@ -105,5 +131,19 @@ func DefaultMatchingConfig() MatchingConfig {
 		OfferTimeoutSeconds: 30,   // 30 seconds to accept
 		RetryDelaySeconds:   10,   // Wait 10 seconds before next batch
 		FirstBatchSize:      3,    // Send to 3 closest drivers first
+
+		BatchMatchingEnabled: false,
+		BatchWindow:          2 * time.Second,
+		BatchH3Resolution:    geo.H3ResolutionDemand,
+		MaxPickupETAMinutes:  15,
+		BatchETATimeout:      time.Second,
+		BatchETAConcurrency:  16,
+
+		OfferCooldownAfterIgnored: 3,
+		OfferCooldown:             10 * time.Minute,
+		OfferExpiryInterval:       5 * time.Second,
+
+		DriverListCacheTTL:    time.Minute,
+		TrustedDriverETABonus: 2,
 	}
 }
@@ -0,0 +1,211 @@
+package matching
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+// OfferStatsStore persists how drivers respond to ride offers and the offers
+// still waiting on a response, shared by every matching replica
+type OfferStatsStore interface {
+	RecordOfferSent(ctx context.Context, driverID uuid.UUID) error
+	RecordOfferOutcome(ctx context.Context, driverID uuid.UUID, outcome models.OfferOutcome) (*models.DriverOfferStats, error)
+	StartCooldown(ctx context.Context, driverID uuid.UUID, until time.Time) error
+	GetDriversInCooldown(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	AddPendingOffer(ctx context.Context, rideID, driverID uuid.UUID, expiresAt time.Time) error
+	TakePendingOffer(ctx context.Context, rideID, driverID uuid.UUID) (bool, error)
+	DeletePendingOffers(ctx context.Context, rideID uuid.UUID) error
+	TakeExpiredOffers(ctx context.Context, now time.Time, limit int) ([]PendingOffer, error)
+}
+
+// expiredOfferBatch caps how many expired offers one sweep resolves
+const expiredOfferBatch = 100
+
+// SetOfferStatsStore enables offer outcome tracking and the ignored-offer
+// cool-down.
+func (s *Service) SetOfferStatsStore(store OfferStatsStore) {
+	s.offerStats = store
+}
+
+// recordOfferSent counts an offer and stores it as pending until expiresAt.
+// An offer still pending when it expires counts as ignored.
+func (s *Service) recordOfferSent(ctx context.Context, rideID, driverID uuid.UUID, expiresAt time.Time) {
+	if s.offerStats == nil {
+		return
+	}
+
+	if err := s.offerStats.RecordOfferSent(ctx, driverID); err != nil {
+		logger.Error("Failed to record offer sent", zap.Error(err),
+			zap.String("driver_id", driverID.String()))
+	}
+	if err := s.offerStats.AddPendingOffer(ctx, rideID, driverID, expiresAt); err != nil {
+		logger.Error("Failed to store pending offer", zap.Error(err),
+			zap.String("ride_id", rideID.String()),
+			zap.String("driver_id", driverID.String()))
+	}
+}
+
+// resolveOffer records the driver's response to a pending offer. It returns
+// false if the offer was already resolved or withdrawn, on this replica or
+// another.
+func (s *Service) resolveOffer(ctx context.Context, rideID, driverID uuid.UUID, outcome models.OfferOutcome) bool {
+	if s.offerStats == nil {
+		return false
+	}
+
+	pending, err := s.offerStats.TakePendingOffer(ctx, rideID, driverID)
+	if err != nil {
+		logger.Error("Failed to take pending offer", zap.Error(err),
+			zap.String("ride_id", rideID.String()),
+			zap.String("driver_id", driverID.String()))
+		return false
+	}
+	if !pending {
+		return false
+	}
+
+	s.recordOfferOutcome(ctx, driverID, outcome)
+	return true
+}
+
+// recordOfferOutcome counts a resolved offer against the driver and pauses
+// their offers after too many ignored in a row
+func (s *Service) recordOfferOutcome(ctx context.Context, driverID uuid.UUID, outcome models.OfferOutcome) {
+	stats, err := s.offerStats.RecordOfferOutcome(ctx, driverID, outcome)
+	if err != nil {
+		logger.Error("Failed to record offer outcome", zap.Error(err),
+			zap.String("driver_id", driverID.String()),
+			zap.String("outcome", string(outcome)))
+		return
+	}
+
+	threshold := s.config.OfferCooldownAfterIgnored
+	if outcome != models.OfferOutcomeExpired || threshold <= 0 || stats.ConsecutiveIgnored < threshold {
+		return
+	}
+
+	until := time.Now().Add(s.config.OfferCooldown)
+	if err := s.offerStats.StartCooldown(ctx, driverID, until); err != nil {
+		logger.Error("Failed to start offer cool-down", zap.Error(err),
+			zap.String("driver_id", driverID.String()))
+		return
+	}
+	logger.Info("Paused offers to driver after ignored offers",
+		zap.String("driver_id", driverID.String()),
+		zap.Int("consecutive_ignored", stats.ConsecutiveIgnored),
+		zap.Time("cooldown_until", until))
+}
+
+// withdrawOffers drops the ride's pending offers without counting them
+// against the drivers, e.g. when another driver took the ride.
+func (s *Service) withdrawOffers(ctx context.Context, rideID uuid.UUID) {
+	if s.offerStats == nil {
+		return
+	}
+
+	if err := s.offerStats.DeletePendingOffers(ctx, rideID); err != nil {
+		logger.Error("Failed to withdraw pending offers", zap.Error(err),
+			zap.String("ride_id", rideID.String()))
+	}
+}
+
+// expireOffers periodically counts offers that expired without a response as
+// ignored. Every replica sweeps; each expired offer is taken by one of them.
+func (s *Service) expireOffers(ctx context.Context) {
+	interval := s.config.OfferExpiryInterval
+	if interval <= 0 {
+		interval = DefaultMatchingConfig().OfferExpiryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.expireOfferBatch(ctx, time.Now())
+		}
+	}
+}
+
+// expireOfferBatch resolves up to one batch of offers that expired before now
+func (s *Service) expireOfferBatch(ctx context.Context, now time.Time) {
+	offers, err := s.offerStats.TakeExpiredOffers(ctx, now, expiredOfferBatch)
+	if err != nil {
+		logger.Error("Failed to take expired offers", zap.Error(err))
+		return
+	}
+
+	for _, offer := range offers {
+		s.recordOfferOutcome(ctx, offer.DriverID, models.OfferOutcomeExpired)
+	}
+}
+
+// handleOfferDecline records a driver declining a ride offer over WebSocket
+func (s *Service) handleOfferDecline(client *websocket.Client, msg *websocket.Message) {
+	if client.Role != "driver" {
+		return
+	}
+
+	driverID, err := uuid.Parse(client.ID)
+	if err != nil {
+		return
+	}
+	rideIDStr := msg.RideID
+	if rideIDStr == "" {
+		rideIDStr, _ = msg.Data["ride_id"].(string)
+	}
+	rideID, err := uuid.Parse(rideIDStr)
+	if err != nil {
+		logger.Warn("Invalid ride ID in offer decline", zap.String("driver_id", client.ID))
+		return
+	}
+
+	ctx := context.Background()
+	if s.resolveOffer(ctx, rideID, driverID, models.OfferOutcomeDeclined) {
+		offerKey := fmt.Sprintf("ride_offer:%s:%s", rideID.String(), driverID.String())
+		_ = s.redis.Delete(ctx, offerKey)
+
+		logger.Info("Driver declined ride offer",
+			zap.String("ride_id", rideID.String()),
+			zap.String("driver_id", driverID.String()))
+	}
+}
+
+// filterCoolingDown removes drivers whose offers are paused after ignoring
+// too many in a row. Drivers are kept if the lookup fails.
+func (s *Service) filterCoolingDown(ctx context.Context, drivers []DriverLocation) []DriverLocation {
+	if s.offerStats == nil || s.config.OfferCooldownAfterIgnored <= 0 || len(drivers) == 0 {
+		return drivers
+	}
+
+	ids := make([]uuid.UUID, len(drivers))
+	for i, d := range drivers {
+		ids[i] = d.DriverID
+	}
+	cooling, err := s.offerStats.GetDriversInCooldown(ctx, ids)
+	if err != nil {
+		logger.Warn("Failed to check driver offer cool-downs", zap.Error(err))
+		return drivers
+	}
+	if len(cooling) == 0 {
+		return drivers
+	}
+
+	kept := make([]DriverLocation, 0, len(drivers))
+	for _, d := range drivers {
+		if !cooling[d.DriverID] {
+			kept = append(kept, d)
+		}
+	}
+	logger.Info("Skipped drivers in offer cool-down", zap.Int("count", len(drivers)-len(kept)))
+	return kept
+}
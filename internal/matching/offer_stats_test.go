@@ -0,0 +1,240 @@
+package matching
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOfferStats keeps offer stats and pending offers in memory
+type fakeOfferStats struct {
+	stats     map[uuid.UUID]*models.DriverOfferStats
+	cooldowns map[uuid.UUID]time.Time
+	pending   map[PendingOffer]time.Time
+	err       error
+}
+
+func newFakeOfferStats() *fakeOfferStats {
+	return &fakeOfferStats{
+		stats:     make(map[uuid.UUID]*models.DriverOfferStats),
+		cooldowns: make(map[uuid.UUID]time.Time),
+		pending:   make(map[PendingOffer]time.Time),
+	}
+}
+
+func (f *fakeOfferStats) get(driverID uuid.UUID) *models.DriverOfferStats {
+	if f.stats[driverID] == nil {
+		f.stats[driverID] = &models.DriverOfferStats{DriverID: driverID}
+	}
+	return f.stats[driverID]
+}
+
+func (f *fakeOfferStats) RecordOfferSent(_ context.Context, driverID uuid.UUID) error {
+	f.get(driverID).OffersSent++
+	return nil
+}
+
+func (f *fakeOfferStats) RecordOfferOutcome(_ context.Context, driverID uuid.UUID, outcome models.OfferOutcome) (*models.DriverOfferStats, error) {
+	s := f.get(driverID)
+	switch outcome {
+	case models.OfferOutcomeAccepted:
+		s.OffersAccepted++
+		s.ConsecutiveIgnored = 0
+	case models.OfferOutcomeDeclined:
+		s.OffersDeclined++
+		s.ConsecutiveIgnored = 0
+	case models.OfferOutcomeExpired:
+		s.OffersExpired++
+		s.ConsecutiveIgnored++
+	}
+	return s, nil
+}
+
+func (f *fakeOfferStats) StartCooldown(_ context.Context, driverID uuid.UUID, until time.Time) error {
+	f.cooldowns[driverID] = until
+	f.get(driverID).ConsecutiveIgnored = 0
+	return nil
+}
+
+func (f *fakeOfferStats) GetDriversInCooldown(_ context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	cooling := make(map[uuid.UUID]bool)
+	for _, id := range driverIDs {
+		if until, ok := f.cooldowns[id]; ok && until.After(time.Now()) {
+			cooling[id] = true
+		}
+	}
+	return cooling, nil
+}
+
+func (f *fakeOfferStats) AddPendingOffer(_ context.Context, rideID, driverID uuid.UUID, expiresAt time.Time) error {
+	f.pending[PendingOffer{RideID: rideID, DriverID: driverID}] = expiresAt
+	return nil
+}
+
+func (f *fakeOfferStats) TakePendingOffer(_ context.Context, rideID, driverID uuid.UUID) (bool, error) {
+	key := PendingOffer{RideID: rideID, DriverID: driverID}
+	_, ok := f.pending[key]
+	delete(f.pending, key)
+	return ok, nil
+}
+
+func (f *fakeOfferStats) DeletePendingOffers(_ context.Context, rideID uuid.UUID) error {
+	for key := range f.pending {
+		if key.RideID == rideID {
+			delete(f.pending, key)
+		}
+	}
+	return nil
+}
+
+func (f *fakeOfferStats) TakeExpiredOffers(_ context.Context, now time.Time, limit int) ([]PendingOffer, error) {
+	var offers []PendingOffer
+	for key, expiresAt := range f.pending {
+		if len(offers) < limit && !expiresAt.After(now) {
+			offers = append(offers, key)
+			delete(f.pending, key)
+		}
+	}
+	return offers, nil
+}
+
+func newOfferStatsTestService(t *testing.T) (*Service, *fakeOfferStats) {
+	t.Helper()
+	svc, _, _, _, _ := newTestService(t)
+	store := newFakeOfferStats()
+	svc.SetOfferStatsStore(store)
+	return svc, store
+}
+
+func TestOfferOutcomes_CountedOnce(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	ctx := context.Background()
+	rideID, driverID := uuid.New(), uuid.New()
+
+	svc.recordOfferSent(ctx, rideID, driverID, time.Now().Add(30*time.Second))
+	assert.Equal(t, 1, store.get(driverID).OffersSent)
+
+	assert.True(t, svc.resolveOffer(ctx, rideID, driverID, models.OfferOutcomeDeclined))
+	// A late expiry for the same offer is ignored
+	assert.False(t, svc.resolveOffer(ctx, rideID, driverID, models.OfferOutcomeExpired))
+
+	stats := store.get(driverID)
+	assert.Equal(t, 1, stats.OffersDeclined)
+	assert.Equal(t, 0, stats.OffersExpired)
+}
+
+func TestOfferOutcomes_CooldownAfterConsecutiveIgnored(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	svc.config.OfferCooldownAfterIgnored = 2
+	svc.config.OfferCooldown = 10 * time.Minute
+	ctx := context.Background()
+	driverID := uuid.New()
+
+	for i := 0; i < 2; i++ {
+		rideID := uuid.New()
+		svc.recordOfferSent(ctx, rideID, driverID, time.Now().Add(30*time.Second))
+		svc.resolveOffer(ctx, rideID, driverID, models.OfferOutcomeExpired)
+	}
+
+	until, cooling := store.cooldowns[driverID]
+	require.True(t, cooling)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), until, 5*time.Second)
+
+	drivers := svc.filterCoolingDown(ctx, []DriverLocation{{DriverID: driverID}, {DriverID: uuid.New()}})
+	require.Len(t, drivers, 1)
+	assert.NotEqual(t, driverID, drivers[0].DriverID)
+}
+
+func TestOfferOutcomes_ResponseResetsIgnoredRun(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	svc.config.OfferCooldownAfterIgnored = 2
+	ctx := context.Background()
+	driverID := uuid.New()
+
+	outcomes := []models.OfferOutcome{models.OfferOutcomeExpired, models.OfferOutcomeAccepted, models.OfferOutcomeExpired}
+	for _, outcome := range outcomes {
+		rideID := uuid.New()
+		svc.recordOfferSent(ctx, rideID, driverID, time.Now().Add(30*time.Second))
+		svc.resolveOffer(ctx, rideID, driverID, outcome)
+	}
+
+	assert.Empty(t, store.cooldowns)
+	assert.Equal(t, 1, store.get(driverID).ConsecutiveIgnored)
+}
+
+func TestOfferOutcomes_ExpiredOffersCountedAsIgnored(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	ctx := context.Background()
+	rideID, expired, waiting := uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+
+	svc.recordOfferSent(ctx, rideID, expired, now.Add(-time.Second))
+	svc.recordOfferSent(ctx, rideID, waiting, now.Add(30*time.Second))
+
+	svc.expireOfferBatch(ctx, now)
+
+	assert.Equal(t, 1, store.get(expired).OffersExpired)
+	assert.Equal(t, 0, store.get(waiting).Resolved())
+	// The expired offer can no longer be answered
+	assert.False(t, svc.resolveOffer(ctx, rideID, expired, models.OfferOutcomeDeclined))
+	assert.True(t, svc.resolveOffer(ctx, rideID, waiting, models.OfferOutcomeDeclined))
+}
+
+func TestOnRideAccepted_WithdrawsOtherOffers(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	ctx := context.Background()
+	rideID, winner, other := uuid.New(), uuid.New(), uuid.New()
+
+	svc.recordOfferSent(ctx, rideID, winner, time.Now().Add(30*time.Second))
+	svc.recordOfferSent(ctx, rideID, other, time.Now().Add(30*time.Second))
+
+	redis := svc.redis.(*mockRedis)
+	redis.On("GetString", ctx, "ride_offer_drivers:"+rideID.String()).Return("", errors.New("not found"))
+
+	svc.onRideAccepted(ctx, &RideAcceptedEvent{RideID: rideID, DriverID: winner})
+
+	assert.Equal(t, 1, store.get(winner).OffersAccepted)
+	// The other driver's offer was withdrawn, not ignored
+	assert.False(t, svc.resolveOffer(ctx, rideID, other, models.OfferOutcomeExpired))
+	assert.Equal(t, 0, store.get(other).Resolved())
+	assert.Empty(t, store.pending)
+}
+
+func TestHandleOfferDecline(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	ctx := context.Background()
+	rideID, driverID := uuid.New(), uuid.New()
+	svc.recordOfferSent(ctx, rideID, driverID, time.Now().Add(30*time.Second))
+
+	redis := svc.redis.(*mockRedis)
+	redis.On("Delete", mock.Anything, []string{"ride_offer:" + rideID.String() + ":" + driverID.String()}).Return(nil)
+
+	// Riders cannot decline offers
+	svc.handleOfferDecline(&websocket.Client{ID: uuid.New().String(), Role: "rider"},
+		&websocket.Message{Type: "ride.offer_decline", RideID: rideID.String()})
+	assert.Equal(t, 0, store.get(driverID).OffersDeclined)
+
+	svc.handleOfferDecline(&websocket.Client{ID: driverID.String(), Role: "driver"},
+		&websocket.Message{Type: "ride.offer_decline", Data: map[string]interface{}{"ride_id": rideID.String()}})
+	assert.Equal(t, 1, store.get(driverID).OffersDeclined)
+	redis.AssertExpectations(t)
+}
+
+func TestFilterCoolingDown_KeepsDriversWhenLookupFails(t *testing.T) {
+	svc, store := newOfferStatsTestService(t)
+	store.err = errors.New("db down")
+	drivers := []DriverLocation{{DriverID: uuid.New()}}
+
+	assert.Equal(t, drivers, svc.filterCoolingDown(context.Background(), drivers))
+}
@@ -0,0 +1,348 @@
+package matching
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/models"
+)
+
+// OfferStatsRepository stores driver offer stats in PostgreSQL
+type OfferStatsRepository struct {
+	db *sql.DB
+}
+
+// NewOfferStatsRepository creates a new offer stats repository
+func NewOfferStatsRepository(db *sql.DB) *OfferStatsRepository {
+	return &OfferStatsRepository{db: db}
+}
+
+// RecordOfferSent counts an offer sent to a driver
+func (r *OfferStatsRepository) RecordOfferSent(ctx context.Context, driverID uuid.UUID) error {
+	query := `
+		INSERT INTO driver_offer_stats (driver_id, offers_sent, last_offer_at, updated_at)
+		VALUES ($1, 1, NOW(), NOW())
+		ON CONFLICT (driver_id) DO UPDATE SET
+			offers_sent = driver_offer_stats.offers_sent + 1,
+			last_offer_at = NOW(),
+			updated_at = NOW()
+	`
+	if _, err := r.db.ExecContext(ctx, query, driverID); err != nil {
+		return fmt.Errorf("failed to record offer sent: %w", err)
+	}
+	return nil
+}
+
+// RecordOfferOutcome counts the driver's response to an offer. Expired offers
+// extend the run of consecutive ignored offers; any response resets it.
+func (r *OfferStatsRepository) RecordOfferOutcome(ctx context.Context, driverID uuid.UUID, outcome models.OfferOutcome) (*models.DriverOfferStats, error) {
+	var accepted, declined, expired int
+	switch outcome {
+	case models.OfferOutcomeAccepted:
+		accepted = 1
+	case models.OfferOutcomeDeclined:
+		declined = 1
+	case models.OfferOutcomeExpired:
+		expired = 1
+	default:
+		return nil, fmt.Errorf("unknown offer outcome %q", outcome)
+	}
+
+	query := `
+		INSERT INTO driver_offer_stats (driver_id, offers_accepted, offers_declined, offers_expired, consecutive_ignored, updated_at)
+		VALUES ($1, $2, $3, $4, $4, NOW())
+		ON CONFLICT (driver_id) DO UPDATE SET
+			offers_accepted = driver_offer_stats.offers_accepted + $2,
+			offers_declined = driver_offer_stats.offers_declined + $3,
+			offers_expired = driver_offer_stats.offers_expired + $4,
+			consecutive_ignored = CASE WHEN $4 = 1 THEN driver_offer_stats.consecutive_ignored + 1 ELSE 0 END,
+			updated_at = NOW()
+		RETURNING driver_id, offers_sent, offers_accepted, offers_declined, offers_expired,
+			consecutive_ignored, cooldown_until, last_offer_at, updated_at
+	`
+
+	stats := &models.DriverOfferStats{}
+	var cooldownUntil, lastOfferAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, driverID, accepted, declined, expired).Scan(
+		&stats.DriverID, &stats.OffersSent, &stats.OffersAccepted, &stats.OffersDeclined, &stats.OffersExpired,
+		&stats.ConsecutiveIgnored, &cooldownUntil, &lastOfferAt, &stats.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record offer outcome: %w", err)
+	}
+	if cooldownUntil.Valid {
+		stats.CooldownUntil = &cooldownUntil.Time
+	}
+	if lastOfferAt.Valid {
+		stats.LastOfferAt = &lastOfferAt.Time
+	}
+
+	return stats, nil
+}
+
+// AddPendingOffer records an offer waiting on the driver until expiresAt
+func (r *OfferStatsRepository) AddPendingOffer(ctx context.Context, rideID, driverID uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO pending_ride_offers (ride_id, driver_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (ride_id, driver_id) DO UPDATE SET expires_at = EXCLUDED.expires_at
+	`
+	if _, err := r.db.ExecContext(ctx, query, rideID, driverID, expiresAt); err != nil {
+		return fmt.Errorf("failed to add pending offer: %w", err)
+	}
+	return nil
+}
+
+// TakePendingOffer removes an offer and reports whether it was still pending,
+// so only one replica resolves it
+func (r *OfferStatsRepository) TakePendingOffer(ctx context.Context, rideID, driverID uuid.UUID) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM pending_ride_offers WHERE ride_id = $1 AND driver_id = $2`, rideID, driverID)
+	if err != nil {
+		return false, fmt.Errorf("failed to take pending offer: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to take pending offer: %w", err)
+	}
+	return n > 0, nil
+}
+
+// DeletePendingOffers drops every pending offer for a ride
+func (r *OfferStatsRepository) DeletePendingOffers(ctx context.Context, rideID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM pending_ride_offers WHERE ride_id = $1`, rideID); err != nil {
+		return fmt.Errorf("failed to delete pending offers: %w", err)
+	}
+	return nil
+}
+
+// TakeExpiredOffers removes and returns up to limit offers that expired
+// before now. Rows locked by another replica's sweep are skipped.
+func (r *OfferStatsRepository) TakeExpiredOffers(ctx context.Context, now time.Time, limit int) ([]PendingOffer, error) {
+	query := `
+		DELETE FROM pending_ride_offers
+		WHERE (ride_id, driver_id) IN (
+			SELECT ride_id, driver_id FROM pending_ride_offers
+			WHERE expires_at <= $1
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ride_id, driver_id
+	`
+	rows, err := r.db.QueryContext(ctx, query, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take expired offers: %w", err)
+	}
+	defer rows.Close()
+
+	var offers []PendingOffer
+	for rows.Next() {
+		var o PendingOffer
+		if err := rows.Scan(&o.RideID, &o.DriverID); err != nil {
+			return nil, fmt.Errorf("failed to scan expired offer: %w", err)
+		}
+		offers = append(offers, o)
+	}
+	return offers, rows.Err()
+}
+
+// StartCooldown pauses offers to a driver until the given time and starts a
+// new run of ignored offers.
+func (r *OfferStatsRepository) StartCooldown(ctx context.Context, driverID uuid.UUID, until time.Time) error {
+	query := `
+		UPDATE driver_offer_stats
+		SET cooldown_until = $2, consecutive_ignored = 0, updated_at = NOW()
+		WHERE driver_id = $1
+	`
+	if _, err := r.db.ExecContext(ctx, query, driverID, until); err != nil {
+		return fmt.Errorf("failed to start offer cool-down: %w", err)
+	}
+	return nil
+}
+
+// GetDriversInCooldown returns which of the given drivers are currently
+// paused from receiving offers.
+func (r *OfferStatsRepository) GetDriversInCooldown(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	cooling := make(map[uuid.UUID]bool)
+	if len(driverIDs) == 0 {
+		return cooling, nil
+	}
+
+	ids := make([]string, len(driverIDs))
+	for i, id := range driverIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT driver_id FROM driver_offer_stats
+		WHERE driver_id = ANY($1::uuid[]) AND cooldown_until > NOW()
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver cool-downs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan driver cool-down: %w", err)
+		}
+		cooling[id] = true
+	}
+
+	return cooling, rows.Err()
+}
+
+// EligibilityRepository loads ride requirements and driver equipment from
+// PostgreSQL
+type EligibilityRepository struct {
+	db *sql.DB
+}
+
+// NewEligibilityRepository creates a new eligibility repository
+func NewEligibilityRepository(db *sql.DB) *EligibilityRepository {
+	return &EligibilityRepository{db: db}
+}
+
+// GetRideRequirements returns what the ride needs from the driver's vehicle:
+// the ride type's vehicle categories and capacity, plus the rider's saved
+// preferences with any per-ride overrides applied.
+func (r *EligibilityRepository) GetRideRequirements(ctx context.Context, event *RideRequestedEvent) (*vehicle.RideRequirements, error) {
+	var rideTypeID *uuid.UUID
+	if event.RideTypeID != uuid.Nil {
+		rideTypeID = &event.RideTypeID
+	}
+
+	query := `
+		SELECT
+			COALESCE(rt.vehicle_categories, '{}'),
+			COALESCE(rt.capacity, 0),
+			COALESCE(rp.max_passengers, 0),
+			COALESCE(rp.wheelchair_access, false),
+			COALESCE(o.child_seat, rp.child_seat, false),
+			COALESCE(o.pet_friendly, rp.pet_friendly, false)
+		FROM (SELECT 1) AS one
+		LEFT JOIN ride_types rt ON rt.id = $1
+		LEFT JOIN rider_preferences rp ON rp.user_id = $2
+		LEFT JOIN ride_preference_overrides o ON o.ride_id = $3
+	`
+
+	var categories []string
+	var capacity, partySize int
+	req := &vehicle.RideRequirements{}
+	err := r.db.QueryRowContext(ctx, query, rideTypeID, event.RiderID, event.RideID).Scan(
+		pq.Array(&categories), &capacity, &partySize, &req.WheelchairAccess, &req.ChildSeat, &req.PetFriendly,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride requirements: %w", err)
+	}
+
+	for _, c := range categories {
+		req.Categories = append(req.Categories, vehicle.VehicleCategory(c))
+	}
+	req.MinPassengers = capacity
+	if partySize > req.MinPassengers {
+		req.MinPassengers = partySize
+	}
+
+	return req, nil
+}
+
+// GetDriverEquipment returns each driver's primary approved vehicle combined
+// with the capabilities they declared
+func (r *EligibilityRepository) GetDriverEquipment(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]*vehicle.DriverEquipment, error) {
+	equipment := make(map[uuid.UUID]*vehicle.DriverEquipment, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return equipment, nil
+	}
+
+	ids := make([]string, len(driverIDs))
+	for i, id := range driverIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT
+			d.id,
+			v.id IS NOT NULL,
+			COALESCE(v.category, ''),
+			COALESCE(v.max_passengers, 0),
+			COALESCE(v.has_wheelchair_access, false) OR COALESCE(dc.wheelchair_access, false),
+			COALESCE(v.has_child_seat, false) OR COALESCE(dc.has_child_seat, false),
+			COALESCE(v.pet_friendly, false) OR COALESCE(dc.pet_friendly, false)
+		FROM UNNEST($1::uuid[]) AS d(id)
+		LEFT JOIN vehicles v ON v.driver_id = d.id
+			AND v.is_primary = true AND v.is_active = true AND v.status = 'approved'
+		LEFT JOIN driver_capabilities dc ON dc.driver_id = d.id
+	`
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver equipment: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var driverID uuid.UUID
+		var category string
+		e := &vehicle.DriverEquipment{}
+		if err := rows.Scan(&driverID, &e.HasVehicle, &category, &e.MaxPassengers,
+			&e.WheelchairAccess, &e.ChildSeat, &e.PetFriendly); err != nil {
+			return nil, fmt.Errorf("failed to scan driver equipment: %w", err)
+		}
+		e.Category = vehicle.VehicleCategory(category)
+		equipment[driverID] = e
+	}
+
+	return equipment, rows.Err()
+}
+
+// DriverListRepository reads riders' blocked and trusted drivers from
+// PostgreSQL
+type DriverListRepository struct {
+	db *sql.DB
+}
+
+// NewDriverListRepository creates a new driver list repository
+func NewDriverListRepository(db *sql.DB) *DriverListRepository {
+	return &DriverListRepository{db: db}
+}
+
+// GetRiderDriverLists returns every driver the rider blocked or trusts
+func (r *DriverListRepository) GetRiderDriverLists(ctx context.Context, riderID uuid.UUID) (*RiderDriverLists, error) {
+	query := `
+		SELECT driver_id, true FROM blocked_drivers WHERE rider_id = $1
+		UNION ALL
+		SELECT driver_id, false FROM trusted_drivers WHERE rider_id = $1
+	`
+	rows, err := r.db.QueryContext(ctx, query, riderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rider driver lists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := &RiderDriverLists{
+		Blocked: make(map[uuid.UUID]bool),
+		Trusted: make(map[uuid.UUID]bool),
+	}
+	for rows.Next() {
+		var driverID uuid.UUID
+		var blocked bool
+		if err := rows.Scan(&driverID, &blocked); err != nil {
+			return nil, fmt.Errorf("failed to scan rider driver list: %w", err)
+		}
+		if blocked {
+			lists.Blocked[driverID] = true
+		} else {
+			lists.Trusted[driverID] = true
+		}
+	}
+
+	return lists, rows.Err()
+}
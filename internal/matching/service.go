@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	redisClient "github.com/richxcame/ride-hailing/pkg/redis"
 	"github.com/richxcame/ride-hailing/pkg/websocket"
 	"go.uber.org/zap"
@@ -34,6 +39,19 @@ type Service struct {
 	eventBus    *nats.Conn
 	redis       redisClient.ClientInterface
 	config      MatchingConfig
+
+	etaEstimator ETAEstimator
+	batchMu      sync.Mutex
+	batches      map[string]*rideBatch
+	afterFunc    func(time.Duration, func()) *time.Timer
+
+	offerStats OfferStatsStore
+
+	eligibility EligibilityChecker
+
+	driverLists     DriverListStore
+	driverListsMu   sync.Mutex
+	driverListCache map[uuid.UUID]cachedDriverLists
 }
 
 // NewService creates a new matching service
@@ -52,82 +70,101 @@ func NewService(
 		eventBus:    eventBus,
 		redis:       redis,
 		config:      config,
+		batches:     make(map[string]*rideBatch),
+		afterFunc:   time.AfterFunc,
+
+		driverListCache: make(map[uuid.UUID]cachedDriverLists),
 	}
 }
 
-// Start begins listening for ride events
+// matchingQueue is the NATS queue group shared by matching replicas, so each
+// ride is matched, and its offers withdrawn, by a single replica
+const matchingQueue = "matching"
+
+// Start begins listening for ride events. Matching, offer resolution and the
+// driver notifications that follow run on one replica per event; batches and
+// driver list caches are local to each replica, so every replica hears
+// cancelled rides and driver list changes to clear its own.
 func (s *Service) Start(ctx context.Context) error {
 	logger.Info("Starting matching service")
 
-	// Subscribe to rides.requested events
-	_, err := s.eventBus.Subscribe("rides.requested", func(msg *nats.Msg) {
-		// First unmarshal the event envelope
-		var envelope struct {
-			Data json.RawMessage `json:"data"`
-		}
-		if err := json.Unmarshal(msg.Data, &envelope); err != nil {
-			logger.Error("Failed to unmarshal event envelope", zap.Error(err))
-			return
-		}
-
-		// Then unmarshal the actual event data
-		var event RideRequestedEvent
-		if err := json.Unmarshal(envelope.Data, &event); err != nil {
-			logger.Error("Failed to unmarshal rides.requested event data", zap.Error(err), zap.String("raw_data", string(envelope.Data)))
-			return
-		}
-		s.onRideRequested(ctx, &event)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to rides.requested: %w", err)
+	subscriptions := []struct {
+		subject string
+		queue   string
+		handler nats.MsgHandler
+	}{
+		{"rides.requested", matchingQueue, func(msg *nats.Msg) {
+			var event RideRequestedEvent
+			if decodeEventData(msg, &event) {
+				s.onRideRequested(ctx, &event)
+			}
+		}},
+		{"rides.accepted", matchingQueue, func(msg *nats.Msg) {
+			var event RideAcceptedEvent
+			if decodeEventData(msg, &event) {
+				s.onRideAccepted(ctx, &event)
+			}
+		}},
+		{"rides.cancelled", matchingQueue, func(msg *nats.Msg) {
+			var event RideCancelledEvent
+			if decodeEventData(msg, &event) {
+				s.onRideCancelled(ctx, &event)
+			}
+		}},
+		{"rides.cancelled", "", func(msg *nats.Msg) {
+			var event RideCancelledEvent
+			if decodeEventData(msg, &event) {
+				s.forgetCancelledRide(&event)
+			}
+		}},
+		// Riders' blocked and trusted drivers changed on another service
+		{eventbus.SubjectRiderDriverListsChanged, "", func(msg *nats.Msg) {
+			var event eventbus.RiderDriverListsChangedData
+			if decodeEventData(msg, &event) {
+				s.invalidateRiderDriverLists(event.RiderID)
+			}
+		}},
 	}
-
-	// Subscribe to rides.accepted events
-	_, err = s.eventBus.Subscribe("rides.accepted", func(msg *nats.Msg) {
-		var envelope struct {
-			Data json.RawMessage `json:"data"`
-		}
-		if err := json.Unmarshal(msg.Data, &envelope); err != nil {
-			logger.Error("Failed to unmarshal event envelope", zap.Error(err))
-			return
+	for _, sub := range subscriptions {
+		var err error
+		if sub.queue != "" {
+			_, err = s.eventBus.QueueSubscribe(sub.subject, sub.queue, sub.handler)
+		} else {
+			_, err = s.eventBus.Subscribe(sub.subject, sub.handler)
 		}
-
-		var event RideAcceptedEvent
-		if err := json.Unmarshal(envelope.Data, &event); err != nil {
-			logger.Error("Failed to unmarshal rides.accepted event data", zap.Error(err))
-			return
+		if err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", sub.subject, err)
 		}
-		s.onRideAccepted(ctx, &event)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to rides.accepted: %w", err)
 	}
 
-	// Subscribe to rides.cancelled events
-	_, err = s.eventBus.Subscribe("rides.cancelled", func(msg *nats.Msg) {
-		var envelope struct {
-			Data json.RawMessage `json:"data"`
-		}
-		if err := json.Unmarshal(msg.Data, &envelope); err != nil {
-			logger.Error("Failed to unmarshal event envelope", zap.Error(err))
-			return
-		}
+	// Drivers decline offers over their WebSocket connection
+	s.wsHub.RegisterHandler("ride.offer_decline", s.handleOfferDecline)
 
-		var event RideCancelledEvent
-		if err := json.Unmarshal(envelope.Data, &event); err != nil {
-			logger.Error("Failed to unmarshal rides.cancelled event data", zap.Error(err))
-			return
-		}
-		s.onRideCancelled(ctx, &event)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to subscribe to rides.cancelled: %w", err)
+	if s.offerStats != nil {
+		go s.expireOffers(ctx)
 	}
 
 	logger.Info("Matching service started successfully")
 	return nil
 }
 
+// decodeEventData unmarshals the data of an event envelope into v
+func decodeEventData(msg *nats.Msg, v interface{}) bool {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(msg.Data, &envelope); err != nil {
+		logger.Error("Failed to unmarshal event envelope", zap.Error(err), zap.String("subject", msg.Subject))
+		return false
+	}
+	if err := json.Unmarshal(envelope.Data, v); err != nil {
+		logger.Error("Failed to unmarshal event data", zap.Error(err),
+			zap.String("subject", msg.Subject), zap.String("raw_data", string(envelope.Data)))
+		return false
+	}
+	return true
+}
+
 // onRideRequested handles new ride requests by finding and notifying drivers
 func (s *Service) onRideRequested(ctx context.Context, event *RideRequestedEvent) {
 	logger.Info("Processing ride request",
@@ -135,6 +172,11 @@ func (s *Service) onRideRequested(ctx context.Context, event *RideRequestedEvent
 		zap.Float64("pickup_latitude", event.PickupLatitude),
 		zap.Float64("pickup_longitude", event.PickupLongitude))
 
+	if s.config.BatchMatchingEnabled {
+		s.enqueueBatch(ctx, event)
+		return
+	}
+
 	// Find nearby drivers
 	drivers, err := s.findAvailableDrivers(ctx, event.PickupLatitude, event.PickupLongitude)
 	if err != nil {
@@ -146,7 +188,16 @@ func (s *Service) onRideRequested(ctx context.Context, event *RideRequestedEvent
 		logger.Warn("No drivers available for ride",
 			zap.String("ride_id", event.RideID.String()),
 			zap.Float64("radius_km", s.config.MaxSearchRadiusKm))
-		s.notifyRiderNoDrivers(event.RiderID, event.RideID)
+		s.notifyRiderNoDrivers(event.RiderID, event.RideID, "")
+		return
+	}
+
+	drivers, reason := s.filterEligible(ctx, event, drivers)
+	if len(drivers) == 0 {
+		logger.Warn("No eligible drivers for ride",
+			zap.String("ride_id", event.RideID.String()),
+			zap.String("reason", string(reason)))
+		s.notifyRiderNoDrivers(event.RiderID, event.RideID, reason)
 		return
 	}
 
@@ -190,14 +241,25 @@ func (s *Service) findAvailableDrivers(ctx context.Context, latitude, longitude
 		}
 	}
 
+	drivers = s.filterCoolingDown(ctx, drivers)
+
 	logger.Info("Found available drivers",
 		zap.Int("count", len(drivers)))
 
 	return drivers, nil
 }
 
-// sendOffersToDrivers sends ride offers to a batch of drivers
+// sendOffersToDrivers sends ride offers to a batch of drivers. Drivers the
+// rider blocked are skipped and trusted drivers are offered the ride first.
 func (s *Service) sendOffersToDrivers(ctx context.Context, event *RideRequestedEvent, drivers []DriverLocation) {
+	drivers = s.applyRiderDriverLists(ctx, event.RiderID, drivers)
+	if len(drivers) == 0 {
+		logger.Warn("All nearby drivers are blocked by rider",
+			zap.String("ride_id", event.RideID.String()))
+		s.notifyRiderNoDrivers(event.RiderID, event.RideID, "")
+		return
+	}
+
 	expiresAt := time.Now().Add(time.Duration(s.config.OfferTimeoutSeconds) * time.Second)
 
 	// Send to first batch (closest drivers)
@@ -251,6 +313,9 @@ func (s *Service) sendOfferToDriver(ctx context.Context, event *RideRequestedEve
 
 	// Calculate ETA to pickup (simple estimation: distance / 30 km/h average speed)
 	etaMinutes := int((distance / 30.0) * 60)
+	if driver.ETAMinutes > 0 {
+		etaMinutes = int(math.Ceil(driver.ETAMinutes))
+	}
 
 	// Build ride offer
 	offer := RideOffer{
@@ -283,6 +348,7 @@ func (s *Service) sendOfferToDriver(ctx context.Context, event *RideRequestedEve
 		logger.Error("Failed to track offer in Redis", zap.Error(err))
 		// Continue anyway - we still want to send the WebSocket message
 	}
+	s.recordOfferSent(ctx, event.RideID, driver.DriverID, expiresAt)
 
 	// Send WebSocket message to driver
 	msg := &websocket.Message{
@@ -348,16 +414,22 @@ func (s *Service) trackOffer(ctx context.Context, rideID, driverID uuid.UUID, ex
 }
 
 // notifyRiderNoDrivers sends a WebSocket message to the rider when no drivers are available.
-func (s *Service) notifyRiderNoDrivers(riderID, rideID uuid.UUID) {
+func (s *Service) notifyRiderNoDrivers(riderID, rideID uuid.UUID, reason vehicle.IneligibleReason) {
+	data := map[string]interface{}{
+		"ride_id": rideID.String(),
+		"message": "No drivers are available in your area. Please try again shortly.",
+	}
+	if reason != "" {
+		data["reason"] = string(reason)
+		data["message"] = vehicle.NoEligibleDriverMessage(reason)
+	}
+
 	msg := &websocket.Message{
 		Type:      "ride.no_drivers",
 		RideID:    rideID.String(),
 		UserID:    riderID.String(),
 		Timestamp: time.Now(),
-		Data: map[string]interface{}{
-			"ride_id": rideID.String(),
-			"message": "No drivers are available in your area. Please try again shortly.",
-		},
+		Data:      data,
 	}
 	s.wsHub.SendToUser(riderID.String(), msg)
 	logger.Info("Notified rider of no available drivers",
@@ -371,6 +443,9 @@ func (s *Service) onRideAccepted(ctx context.Context, event *RideAcceptedEvent)
 		zap.String("ride_id", event.RideID.String()),
 		zap.String("driver_id", event.DriverID.String()))
 
+	s.resolveOffer(ctx, event.RideID, event.DriverID, models.OfferOutcomeAccepted)
+	s.withdrawOffers(ctx, event.RideID)
+
 	// Cancel all pending offers for this ride
 	if err := s.cancelPendingOffers(ctx, event.RideID, event.DriverID); err != nil {
 		logger.Error("Failed to cancel pending offers", zap.Error(err))
@@ -383,12 +458,22 @@ func (s *Service) onRideCancelled(ctx context.Context, event *RideCancelledEvent
 		zap.String("ride_id", event.RideID.String()),
 		zap.String("reason", event.Reason))
 
+	s.withdrawOffers(ctx, event.RideID)
+
 	// Cancel all pending offers for this ride
 	if err := s.cancelPendingOffers(ctx, event.RideID, uuid.Nil); err != nil {
 		logger.Error("Failed to cancel pending offers", zap.Error(err))
 	}
 }
 
+// forgetCancelledRide drops a cancelled ride from this replica's batches
+func (s *Service) forgetCancelledRide(event *RideCancelledEvent) {
+	if s.removeFromBatch(event.RideID) {
+		logger.Info("Removed cancelled ride from matching batch",
+			zap.String("ride_id", event.RideID.String()))
+	}
+}
+
 // cancelPendingOffers notifies drivers that the ride is no longer available
 func (s *Service) cancelPendingOffers(ctx context.Context, rideID, acceptedDriverID uuid.UUID) error {
 	driversKey := fmt.Sprintf("ride_offer_drivers:%s", rideID.String())
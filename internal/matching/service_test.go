@@ -274,7 +274,7 @@ func TestNotifyRiderNoDrivers_SendsCorrectMessage(t *testing.T) {
 	riderID := uuid.New()
 	rideID := uuid.New()
 
-	svc.notifyRiderNoDrivers(riderID, rideID)
+	svc.notifyRiderNoDrivers(riderID, rideID, "")
 
 	msgs := drainBroadcast(hub)
 	require.Len(t, msgs, 1)
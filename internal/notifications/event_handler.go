@@ -56,11 +56,14 @@ func (h *EventHandler) onRideRequested(ctx context.Context, event *eventbus.Even
 	}
 
 	lang := h.service.userLang(ctx, data.RiderID)
+	rendered := h.service.renderNotification(ctx, TemplateRideRequested, "push", lang, map[string]interface{}{
+		"pickup_location": data.PickupAddress,
+	})
 	_, err := h.service.SendNotification(ctx, data.RiderID,
 		"ride_requested", "push",
-		i18n.Translate("notification.ride.requested.title", lang),
-		i18n.Translate("notification.ride.requested.body", lang, data.PickupAddress),
-		map[string]interface{}{"ride_id": data.RideID.String()},
+		rendered.Title,
+		rendered.Body,
+		rendered.annotate(map[string]interface{}{"ride_id": data.RideID.String()}),
 	)
 	if err != nil {
 		logger.Warn("failed to send ride_requested notification", zap.Error(err))
@@ -98,11 +101,12 @@ func (h *EventHandler) onRideStarted(ctx context.Context, event *eventbus.Event)
 	}
 
 	lang := h.service.userLang(ctx, data.RiderID)
+	rendered := h.service.renderNotification(ctx, TemplateRideStarted, "push", lang, map[string]interface{}{})
 	_, err := h.service.SendNotification(ctx, data.RiderID,
 		"ride_started", "push",
-		i18n.Translate("notification.ride.started.title", lang),
-		i18n.Translate("notification.ride.started.body", lang),
-		map[string]interface{}{"ride_id": data.RideID.String()},
+		rendered.Title,
+		rendered.Body,
+		rendered.annotate(map[string]interface{}{"ride_id": data.RideID.String()}),
 	)
 	if err != nil {
 		logger.Warn("failed to send ride_started notification", zap.Error(err))
@@ -128,34 +132,44 @@ func (h *EventHandler) onRideCompleted(ctx context.Context, event *eventbus.Even
 	// Rider: push notification
 	riderLang := h.service.userLang(ctx, data.RiderID)
 	formattedFare := i18n.FormatAmount(data.FareAmount, currency)
+	riderCopy := h.service.renderNotification(ctx, TemplateRideCompletedRider, "push", riderLang, map[string]interface{}{
+		"fare": formattedFare,
+	})
 	_, err := h.service.SendNotification(ctx, data.RiderID,
 		"ride_completed", "push",
-		i18n.Translate("notification.ride.completed.title.rider", riderLang),
-		i18n.Translate("notification.ride.completed.body.rider", riderLang, formattedFare),
-		map[string]interface{}{
+		riderCopy.Title,
+		riderCopy.Body,
+		riderCopy.annotate(map[string]interface{}{
 			"ride_id":     data.RideID.String(),
 			"fare_amount": data.FareAmount,
 			"currency":    currency,
 			"distance_km": data.DistanceKm,
-		},
+		}),
 	)
 	if err != nil {
 		logger.Warn("failed to send ride_completed push notification", zap.Error(err))
 	}
 
 	// Rider: receipt email
+	receipt := h.service.renderNotification(ctx, TemplateRideReceipt, "email", riderLang, map[string]interface{}{
+		"fare": formattedFare,
+		"date": event.Timestamp.Format("Jan 02, 2006 3:04 PM"),
+	})
+	if receipt.Template == nil {
+		receipt.Body = fmt.Sprintf("Ride completed. Distance: %.1f km, Duration: %.0f min, Fare: %s",
+			data.DistanceKm, data.DurationMin, formattedFare)
+	}
 	_, err = h.service.SendNotification(ctx, data.RiderID,
 		"ride_receipt", "email",
-		"Your Ride Receipt",
-		fmt.Sprintf("Ride completed. Distance: %.1f km, Duration: %.0f min, Fare: %s",
-			data.DistanceKm, data.DurationMin, formattedFare),
-		map[string]interface{}{
+		receipt.Title,
+		receipt.Body,
+		receipt.annotate(map[string]interface{}{
 			"ride_id":      data.RideID.String(),
 			"fare_amount":  data.FareAmount,
 			"currency":     currency,
 			"distance_km":  data.DistanceKm,
 			"duration_min": data.DurationMin,
-		},
+		}),
 	)
 	if err != nil {
 		logger.Warn("failed to send ride receipt email", zap.Error(err))
@@ -164,15 +178,18 @@ func (h *EventHandler) onRideCompleted(ctx context.Context, event *eventbus.Even
 	// Driver: earnings notification
 	driverLang := h.service.userLang(ctx, data.DriverID)
 	formattedEarnings := i18n.FormatAmount(driverEarnings, currency)
+	driverCopy := h.service.renderNotification(ctx, TemplateRideCompletedDriver, "push", driverLang, map[string]interface{}{
+		"earnings": formattedEarnings,
+	})
 	_, err = h.service.SendNotification(ctx, data.DriverID,
 		"payment_received", "push",
-		i18n.Translate("notification.ride.completed.title.driver", driverLang),
-		i18n.Translate("notification.ride.completed.body.driver", driverLang, formattedEarnings),
-		map[string]interface{}{
+		driverCopy.Title,
+		driverCopy.Body,
+		driverCopy.annotate(map[string]interface{}{
 			"ride_id":  data.RideID.String(),
 			"amount":   driverEarnings,
 			"currency": currency,
-		},
+		}),
 	)
 	if err != nil {
 		logger.Warn("failed to send payment notification to driver", zap.Error(err))
@@ -187,10 +204,17 @@ func (h *EventHandler) onRideCancelled(ctx context.Context, event *eventbus.Even
 		return fmt.Errorf("unmarshal ride cancelled: %w", err)
 	}
 
+	if data.Reason == eventbus.CancelReasonPaymentDeclined {
+		h.notifyPaymentDeclined(ctx, data)
+	}
+
 	var recipientID uuid.UUID
 	var cancelledByKey string
 
-	if data.CancelledBy == "rider" && data.DriverID != uuid.Nil {
+	if data.CancelledBy == "system" && data.DriverID != uuid.Nil {
+		recipientID = data.DriverID
+		cancelledByKey = "system"
+	} else if data.CancelledBy == "rider" && data.DriverID != uuid.Nil {
 		recipientID = data.DriverID
 		cancelledByKey = "rider"
 	} else if data.CancelledBy == "driver" {
@@ -203,18 +227,40 @@ func (h *EventHandler) onRideCancelled(ctx context.Context, event *eventbus.Even
 	lang := h.service.userLang(ctx, recipientID)
 	translatedBy := i18n.Translate("notification.ride.cancelled.by."+cancelledByKey, lang)
 
+	rendered := h.service.renderNotification(ctx, TemplateRideCancelled, "push", lang, map[string]interface{}{
+		"cancelled_by": translatedBy,
+	})
 	_, err := h.service.SendNotification(ctx, recipientID,
 		"ride_cancelled", "push",
-		i18n.Translate("notification.ride.cancelled.title", lang),
-		i18n.Translate("notification.ride.cancelled.body", lang, translatedBy),
-		map[string]interface{}{
+		rendered.Title,
+		rendered.Body,
+		rendered.annotate(map[string]interface{}{
 			"ride_id":      data.RideID.String(),
 			"cancelled_by": data.CancelledBy,
 			"reason":       data.Reason,
-		},
+		}),
 	)
 	if err != nil {
 		logger.Warn("failed to send ride_cancelled notification", zap.Error(err))
 	}
 	return nil
 }
+
+// notifyPaymentDeclined tells the rider their ride was cancelled because the
+// card declined the fare hold, and to add a new payment method
+func (h *EventHandler) notifyPaymentDeclined(ctx context.Context, data eventbus.RideCancelledData) {
+	lang := h.service.userLang(ctx, data.RiderID)
+	rendered := h.service.renderNotification(ctx, TemplateRidePaymentDeclined, "push", lang, map[string]interface{}{})
+	_, err := h.service.SendNotification(ctx, data.RiderID,
+		"ride_payment_declined", "push",
+		rendered.Title,
+		rendered.Body,
+		rendered.annotate(map[string]interface{}{
+			"ride_id": data.RideID.String(),
+			"action":  "update_payment_method",
+		}),
+	)
+	if err != nil {
+		logger.Warn("failed to send ride_payment_declined notification", zap.Error(err))
+	}
+}
@@ -0,0 +1,47 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnRideCancelled_PaymentDeclinedNotifiesRiderAndDriver(t *testing.T) {
+	service, repo, store := newTemplateTestService()
+	handler := NewEventHandler(service)
+	ctx := context.Background()
+	riderID, driverID := uuid.New(), uuid.New()
+
+	repo.On("GetUserLanguage", ctx, mock.Anything).Return("en", nil)
+	repo.On("GetUserDeviceTokens", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+	repo.On("UpdateNotificationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.On("GetActiveTemplate", ctx, mock.Anything, "push", "en").Return(nil, nil)
+	repo.On("CreateNotification", ctx, mock.MatchedBy(func(n *models.Notification) bool {
+		return n.UserID == riderID && n.Type == "ride_payment_declined" &&
+			n.Title == "Payment Declined" && n.Data["action"] == "update_payment_method"
+	})).Return(nil).Once()
+	repo.On("CreateNotification", ctx, mock.MatchedBy(func(n *models.Notification) bool {
+		return n.UserID == driverID && n.Type == "ride_cancelled" && n.Body == "Ride was cancelled by the system"
+	})).Return(nil).Once()
+
+	raw, err := json.Marshal(eventbus.RideCancelledData{
+		RideID:      uuid.New(),
+		RiderID:     riderID,
+		DriverID:    driverID,
+		CancelledBy: "system",
+		Reason:      eventbus.CancelReasonPaymentDeclined,
+	})
+	require.NoError(t, err)
+
+	err = handler.onRideCancelled(ctx, &eventbus.Event{ID: uuid.New().String(), Type: "ride.cancelled", Timestamp: time.Now(), Data: raw})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+	time.Sleep(10 * time.Millisecond)
+}
@@ -49,6 +49,14 @@ func (h *Handler) RegisterRoutes(router *gin.Engine, jwtProvider jwtkeys.KeyProv
 	admin.Use(middleware.RequireRole("admin"))
 	{
 		admin.POST("/notifications/bulk", h.SendBulkNotification)
+
+		// Notification templates
+		admin.GET("/notification-templates", h.ListTemplates)
+		admin.GET("/notification-templates/catalog", h.GetTemplateCatalog)
+		admin.POST("/notification-templates", h.CreateTemplate)
+		admin.POST("/notification-templates/preview", h.PreviewTemplate)
+		admin.POST("/notification-templates/:id/activate", h.ActivateTemplate)
+		admin.POST("/notification-templates/:id/deactivate", h.DeactivateTemplate)
 	}
 }
 
@@ -425,3 +433,118 @@ func (h *Handler) SendBulkNotification(c *gin.Context) {
 
 	common.SuccessResponseWithStatus(c, http.StatusOK, gin.H{"sent": len(userIDs)}, "Bulk notifications sent")
 }
+
+// GetTemplateCatalog lists the notifications that can be templated and their variables (admin)
+func (h *Handler) GetTemplateCatalog(c *gin.Context) {
+	common.SuccessResponse(c, gin.H{"templates": h.service.TemplateCatalog()})
+}
+
+// ListTemplates lists notification template versions (admin)
+func (h *Handler) ListTemplates(c *gin.Context) {
+	filter := TemplateFilter{
+		NotificationType: c.Query("notification_type"),
+		Channel:          c.Query("channel"),
+		Language:         c.Query("language"),
+	}
+
+	templates, err := h.service.ListTemplates(c.Request.Context(), filter)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to list notification templates")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"templates": templates, "count": len(templates)})
+}
+
+// CreateTemplate adds a notification template version (admin)
+func (h *Handler) CreateTemplate(c *gin.Context) {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tmpl, err := h.service.CreateTemplate(c.Request.Context(), adminID, &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to create notification template")
+		return
+	}
+
+	common.CreatedResponse(c, tmpl)
+}
+
+// PreviewTemplate renders a draft template or the copy currently sent (admin)
+func (h *Handler) PreviewTemplate(c *gin.Context) {
+	var req PreviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	preview, err := h.service.PreviewTemplate(c.Request.Context(), &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to preview notification template")
+		return
+	}
+
+	common.SuccessResponse(c, preview)
+}
+
+// ActivateTemplate makes a template version live (admin)
+func (h *Handler) ActivateTemplate(c *gin.Context) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid template ID")
+		return
+	}
+
+	tmpl, err := h.service.ActivateTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to activate notification template")
+		return
+	}
+
+	common.SuccessResponseWithStatus(c, http.StatusOK, tmpl, "Notification template activated")
+}
+
+// DeactivateTemplate takes a template version out of use (admin)
+func (h *Handler) DeactivateTemplate(c *gin.Context) {
+	templateID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid template ID")
+		return
+	}
+
+	if err := h.service.DeactivateTemplate(c.Request.Context(), templateID); err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to deactivate notification template")
+		return
+	}
+
+	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "Notification template deactivated")
+}
@@ -2,9 +2,12 @@ package notifications
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/models"
@@ -302,3 +305,209 @@ func (r *Repository) GetUserLanguage(ctx context.Context, userID uuid.UUID) (str
 
 	return *lang, nil
 }
+
+// ========================================
+// NOTIFICATION TEMPLATES
+// ========================================
+
+const notificationTemplateColumns = `id, notification_type, channel, language, version, title, body,
+	is_active, created_by, created_at, activated_at`
+
+func scanNotificationTemplate(row interface{ Scan(dest ...any) error }) (*NotificationTemplate, error) {
+	tmpl := &NotificationTemplate{}
+	err := row.Scan(
+		&tmpl.ID,
+		&tmpl.NotificationType,
+		&tmpl.Channel,
+		&tmpl.Language,
+		&tmpl.Version,
+		&tmpl.Title,
+		&tmpl.Body,
+		&tmpl.IsActive,
+		&tmpl.CreatedBy,
+		&tmpl.CreatedAt,
+		&tmpl.ActivatedAt,
+	)
+	return tmpl, err
+}
+
+// GetActiveTemplate retrieves the active template version for a notification
+// type, channel and language. Returns nil when there is none.
+func (r *Repository) GetActiveTemplate(ctx context.Context, notificationType, channel, language string) (*NotificationTemplate, error) {
+	query := `SELECT ` + notificationTemplateColumns + `
+		FROM notification_templates
+		WHERE notification_type = $1 AND channel = $2 AND language = $3 AND is_active`
+
+	tmpl, err := scanNotificationTemplate(r.db.QueryRow(ctx, query, notificationType, channel, language))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewInternalError("failed to get notification template", err)
+	}
+
+	return tmpl, nil
+}
+
+// GetTemplate retrieves a template version by ID
+func (r *Repository) GetTemplate(ctx context.Context, id uuid.UUID) (*NotificationTemplate, error) {
+	query := `SELECT ` + notificationTemplateColumns + `
+		FROM notification_templates
+		WHERE id = $1`
+
+	tmpl, err := scanNotificationTemplate(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, common.NewNotFoundError("notification template not found", err)
+	}
+
+	return tmpl, nil
+}
+
+// ListTemplates retrieves template versions, newest first. Empty filter
+// fields match everything.
+func (r *Repository) ListTemplates(ctx context.Context, filter TemplateFilter) ([]*NotificationTemplate, error) {
+	query := `SELECT ` + notificationTemplateColumns + `
+		FROM notification_templates
+		WHERE ($1 = '' OR notification_type = $1)
+		  AND ($2 = '' OR channel = $2)
+		  AND ($3 = '' OR language = $3)
+		ORDER BY notification_type, channel, language, version DESC`
+
+	rows, err := r.db.Query(ctx, query, filter.NotificationType, filter.Channel, filter.Language)
+	if err != nil {
+		return nil, common.NewInternalError("failed to list notification templates", err)
+	}
+	defer rows.Close()
+
+	templates := make([]*NotificationTemplate, 0)
+	for rows.Next() {
+		tmpl, err := scanNotificationTemplate(rows)
+		if err != nil {
+			return nil, common.NewInternalError("failed to scan notification template", err)
+		}
+		templates = append(templates, tmpl)
+	}
+
+	return templates, rows.Err()
+}
+
+// CreateTemplate stores a new version of a template, numbered after the
+// latest one for its type, channel and language. With activate it replaces
+// the active version.
+func (r *Repository) CreateTemplate(ctx context.Context, tmpl *NotificationTemplate, activate bool) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return common.NewInternalError("failed to begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if activate {
+		if err := deactivateTemplates(ctx, tx, tmpl.NotificationType, tmpl.Channel, tmpl.Language); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO notification_templates (id, notification_type, channel, language, version,
+			title, body, is_active, created_by, activated_at)
+		SELECT $1::uuid, $2::varchar, $3::varchar, $4::varchar, COALESCE(MAX(version), 0) + 1,
+			$5::text, $6::text, $7::boolean, $8::uuid, CASE WHEN $7::boolean THEN NOW() END
+		FROM notification_templates
+		WHERE notification_type = $2 AND channel = $3 AND language = $4
+		RETURNING version, is_active, created_at, activated_at`
+
+	err = tx.QueryRow(ctx, query,
+		tmpl.ID,
+		tmpl.NotificationType,
+		tmpl.Channel,
+		tmpl.Language,
+		tmpl.Title,
+		tmpl.Body,
+		activate,
+		tmpl.CreatedBy,
+	).Scan(&tmpl.Version, &tmpl.IsActive, &tmpl.CreatedAt, &tmpl.ActivatedAt)
+	if err != nil {
+		return templateWriteError("failed to create notification template", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return common.NewInternalError("failed to commit notification template", err)
+	}
+	return nil
+}
+
+// ActivateTemplate makes a template version the active one for its type,
+// channel and language. Activating an older version rolls the copy back.
+func (r *Repository) ActivateTemplate(ctx context.Context, id uuid.UUID) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return common.NewInternalError("failed to begin transaction", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var notificationType, channel, language string
+	err = tx.QueryRow(ctx, `
+		SELECT notification_type, channel, language
+		FROM notification_templates
+		WHERE id = $1
+		FOR UPDATE`, id).Scan(&notificationType, &channel, &language)
+	if err != nil {
+		return common.NewNotFoundError("notification template not found", err)
+	}
+
+	if err := deactivateTemplates(ctx, tx, notificationType, channel, language); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE notification_templates
+		SET is_active = true, activated_at = NOW()
+		WHERE id = $1`, id)
+	if err != nil {
+		return templateWriteError("failed to activate notification template", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return common.NewInternalError("failed to commit notification template", err)
+	}
+	return nil
+}
+
+// DeactivateTemplate takes a template version out of use, so notifications
+// fall back to the default copy
+func (r *Repository) DeactivateTemplate(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `
+		UPDATE notification_templates
+		SET is_active = false
+		WHERE id = $1`, id)
+	if err != nil {
+		return common.NewInternalError("failed to deactivate notification template", err)
+	}
+	if result.RowsAffected() == 0 {
+		return common.NewNotFoundError("notification template not found", nil)
+	}
+
+	return nil
+}
+
+func deactivateTemplates(ctx context.Context, tx pgx.Tx, notificationType, channel, language string) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE notification_templates
+		SET is_active = false
+		WHERE notification_type = $1 AND channel = $2 AND language = $3 AND is_active`,
+		notificationType, channel, language)
+	if err != nil {
+		return common.NewInternalError("failed to deactivate notification templates", err)
+	}
+	return nil
+}
+
+// templateWriteError reports a concurrent edit of the same template (a
+// duplicate version or a second active version) as a conflict
+func templateWriteError(message string, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return common.NewConflictError("notification template was changed concurrently, try again")
+	}
+	return common.NewInternalError(message, err)
+}
@@ -27,6 +27,8 @@ type Service struct {
 	firebaseBreaker *resilience.CircuitBreaker
 	twilioBreaker   *resilience.CircuitBreaker
 	emailBreaker    *resilience.CircuitBreaker
+	templates       TemplateStore
+	templateCache   *templateCache
 }
 
 func NewService(repo RepositoryInterface, firebaseClient FirebaseClientInterface, twilioClient TwilioClientInterface, emailClient EmailClientInterface) *Service {
@@ -196,6 +198,11 @@ func (s *Service) sendEmailNotification(ctx context.Context, notification *model
 	}
 
 	return s.executeWithBreaker(ctx, s.emailBreaker, notification, "email", func() error {
+		// Email templates from the database are rendered to HTML up front
+		if _, ok := notification.Data["template_id"]; ok {
+			return s.emailClient.SendHTMLEmail(email, notification.Title, notification.Body)
+		}
+
 		switch notification.Type {
 		case "ride_confirmed":
 			if data, ok := notification.Data["details"].(map[string]interface{}); ok {
@@ -225,24 +232,23 @@ func (s *Service) userLang(ctx context.Context, userID uuid.UUID) string {
 // NotifyRideRequested notifies driver about a new ride request
 func (s *Service) NotifyRideRequested(ctx context.Context, driverID, rideID uuid.UUID, pickupLocation string) error {
 	lang := s.userLang(ctx, driverID)
+	vars := map[string]interface{}{"pickup_location": pickupLocation}
 	data := map[string]interface{}{
 		"ride_id":         rideID.String(),
 		"pickup_location": pickupLocation,
 		"action":          "ride_requested",
 	}
 
+	push := s.renderNotification(ctx, TemplateRideRequested, "push", lang, vars)
 	_, err := s.SendNotification(ctx, driverID, "ride_requested", "push",
-		i18n.Translate("notification.ride.requested.title", lang),
-		i18n.Translate("notification.ride.requested.body", lang, pickupLocation),
-		data)
+		push.Title, push.Body, push.annotate(data))
 	if err != nil {
 		return err
 	}
 
+	sms := s.renderNotification(ctx, TemplateRideRequested, "sms", lang, vars)
 	if _, err := s.SendNotification(ctx, driverID, "ride_requested", "sms",
-		i18n.Translate("notification.ride.requested.title", lang),
-		i18n.Translate("notification.ride.requested.sms", lang),
-		data); err != nil {
+		sms.Title, sms.Body, sms.annotate(data)); err != nil {
 		logger.Get().Warn("Failed to send SMS notification for ride request",
 			zap.String("driver_id", driverID.String()),
 			zap.Error(err))
@@ -260,10 +266,12 @@ func (s *Service) NotifyRideAccepted(ctx context.Context, riderID uuid.UUID, dri
 		"action":      "ride_accepted",
 	}
 
+	rendered := s.renderNotification(ctx, TemplateRideAccepted, "push", lang, map[string]interface{}{
+		"driver_name": driverName,
+		"eta":         eta,
+	})
 	_, err := s.SendNotification(ctx, riderID, "ride_accepted", "push",
-		i18n.Translate("notification.ride.accepted.title", lang),
-		i18n.Translate("notification.ride.accepted.body", lang, driverName, eta),
-		data)
+		rendered.Title, rendered.Body, rendered.annotate(data))
 
 	return err
 }
@@ -275,10 +283,9 @@ func (s *Service) NotifyRideStarted(ctx context.Context, riderID uuid.UUID) erro
 		"action": "ride_started",
 	}
 
+	rendered := s.renderNotification(ctx, TemplateRideStarted, "push", lang, map[string]interface{}{})
 	_, err := s.SendNotification(ctx, riderID, "ride_started", "push",
-		i18n.Translate("notification.ride.started.title", lang),
-		i18n.Translate("notification.ride.started.body", lang),
-		data)
+		rendered.Title, rendered.Body, rendered.annotate(data))
 
 	return err
 }
@@ -300,26 +307,31 @@ func (s *Service) NotifyRideCompleted(ctx context.Context, riderID, driverID uui
 		"action":   "ride_completed",
 	}
 
+	riderCopy := s.renderNotification(ctx, TemplateRideCompletedRider, "push", riderLang, map[string]interface{}{
+		"fare": formattedFare,
+	})
 	_, err := s.SendNotification(ctx, riderID, "ride_completed", "push",
-		i18n.Translate("notification.ride.completed.title.rider", riderLang),
-		i18n.Translate("notification.ride.completed.body.rider", riderLang, formattedFare),
-		riderData)
+		riderCopy.Title, riderCopy.Body, riderCopy.annotate(riderData))
 	if err != nil {
 		logger.Get().Error("Failed to notify rider of ride completion", zap.Error(err))
 	}
 
-	// Send receipt email to rider
+	// Send receipt email to rider; without a receipt template the built-in
+	// receipt email is used
+	completedAt := time.Now().Format("Jan 02, 2006 3:04 PM")
 	receiptData := map[string]interface{}{
 		"receipt": map[string]interface{}{
 			"Fare":   formattedFare,
-			"Date":   time.Now().Format("Jan 02, 2006 3:04 PM"),
+			"Date":   completedAt,
 			"Status": "Completed",
 		},
 	}
+	receipt := s.renderNotification(ctx, TemplateRideReceipt, "email", riderLang, map[string]interface{}{
+		"fare": formattedFare,
+		"date": completedAt,
+	})
 	if _, err := s.SendNotification(ctx, riderID, "ride_receipt", "email",
-		"Your Ride Receipt",
-		"",
-		receiptData); err != nil {
+		receipt.Title, receipt.Body, receipt.annotate(receiptData)); err != nil {
 		logger.Get().Warn("Failed to send ride receipt email",
 			zap.String("rider_id", riderID.String()),
 			zap.Error(err))
@@ -334,10 +346,11 @@ func (s *Service) NotifyRideCompleted(ctx context.Context, riderID, driverID uui
 		"action":   "ride_completed",
 	}
 
+	driverCopy := s.renderNotification(ctx, TemplateRideCompletedDriver, "push", driverLang, map[string]interface{}{
+		"earnings": formattedEarnings,
+	})
 	_, err = s.SendNotification(ctx, driverID, "ride_completed", "push",
-		i18n.Translate("notification.ride.completed.title.driver", driverLang),
-		i18n.Translate("notification.ride.completed.body.driver", driverLang, formattedEarnings),
-		driverData)
+		driverCopy.Title, driverCopy.Body, driverCopy.annotate(driverData))
 
 	return err
 }
@@ -352,10 +365,11 @@ func (s *Service) NotifyRideCancelled(ctx context.Context, userID uuid.UUID, can
 		"action":       "ride_cancelled",
 	}
 
+	rendered := s.renderNotification(ctx, TemplateRideCancelled, "push", lang, map[string]interface{}{
+		"cancelled_by": translatedBy,
+	})
 	_, err := s.SendNotification(ctx, userID, "ride_cancelled", "push",
-		i18n.Translate("notification.ride.cancelled.title", lang),
-		i18n.Translate("notification.ride.cancelled.body", lang, translatedBy),
-		data)
+		rendered.Title, rendered.Body, rendered.annotate(data))
 
 	return err
 }
@@ -374,10 +388,11 @@ func (s *Service) NotifyPaymentReceived(ctx context.Context, userID uuid.UUID, a
 		"action":   "payment_received",
 	}
 
+	rendered := s.renderNotification(ctx, TemplatePaymentReceived, "push", lang, map[string]interface{}{
+		"amount": formattedAmount,
+	})
 	_, err := s.SendNotification(ctx, userID, "payment_received", "push",
-		i18n.Translate("notification.payment.received.title", lang),
-		i18n.Translate("notification.payment.received.body", lang, formattedAmount),
-		data)
+		rendered.Title, rendered.Body, rendered.annotate(data))
 
 	return err
 }
@@ -0,0 +1,534 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/i18n"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Template types. Most match the notification type they render; ride
+// completion is split by audience because riders and drivers get different
+// copy.
+const (
+	TemplateRideRequested       = "ride_requested"
+	TemplateRideAccepted        = "ride_accepted"
+	TemplateRideStarted         = "ride_started"
+	TemplateRideCompletedRider  = "ride_completed_rider"
+	TemplateRideCompletedDriver = "ride_completed_driver"
+	TemplateRideReceipt         = "ride_receipt"
+	TemplateRideCancelled       = "ride_cancelled"
+	TemplateRidePaymentDeclined = "ride_payment_declined"
+	TemplatePaymentReceived     = "payment_received"
+)
+
+// How long an active template lookup is reused before the database is asked
+// again. Edits made on this node take effect immediately; other nodes pick
+// them up within this time.
+const templateCacheTTL = time.Minute
+
+// NotificationTemplate is one version of the copy for a notification type,
+// channel and language. Title and body are Go templates over the type's
+// variables, e.g. "{{.driver_name}} is on the way".
+type NotificationTemplate struct {
+	ID               uuid.UUID  `json:"id"`
+	NotificationType string     `json:"notification_type"`
+	Channel          string     `json:"channel"`
+	Language         string     `json:"language"`
+	Version          int        `json:"version"`
+	Title            string     `json:"title"`
+	Body             string     `json:"body"`
+	IsActive         bool       `json:"is_active"`
+	CreatedBy        *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ActivatedAt      *time.Time `json:"activated_at,omitempty"`
+}
+
+// TemplateFilter narrows a template listing; empty fields match everything
+type TemplateFilter struct {
+	NotificationType string
+	Channel          string
+	Language         string
+}
+
+// TemplateStore persists notification templates
+type TemplateStore interface {
+	GetActiveTemplate(ctx context.Context, notificationType, channel, language string) (*NotificationTemplate, error)
+	GetTemplate(ctx context.Context, id uuid.UUID) (*NotificationTemplate, error)
+	ListTemplates(ctx context.Context, filter TemplateFilter) ([]*NotificationTemplate, error)
+	CreateTemplate(ctx context.Context, tmpl *NotificationTemplate, activate bool) error
+	ActivateTemplate(ctx context.Context, id uuid.UUID) error
+	DeactivateTemplate(ctx context.Context, id uuid.UUID) error
+}
+
+// TemplateVariable is a value a template can use, with an example that
+// previews are rendered with
+type TemplateVariable struct {
+	Name    string      `json:"name"`
+	Example interface{} `json:"example"`
+}
+
+// TemplateDefinition describes a notification that can be templated and
+// where its default copy comes from in pkg/i18n
+type TemplateDefinition struct {
+	NotificationType string             `json:"notification_type"`
+	Channel          string             `json:"channel"`
+	Variables        []TemplateVariable `json:"variables"`
+
+	titleKey string
+	bodyKey  string   // empty when the default body is built by the caller
+	args     []string // variables passed to the i18n body, in order
+}
+
+var templateDefinitions = []TemplateDefinition{
+	{
+		NotificationType: TemplateRideRequested,
+		Channel:          "push",
+		Variables:        []TemplateVariable{{Name: "pickup_location", Example: "123 Main St"}},
+		titleKey:         "notification.ride.requested.title",
+		bodyKey:          "notification.ride.requested.body",
+		args:             []string{"pickup_location"},
+	},
+	{
+		NotificationType: TemplateRideRequested,
+		Channel:          "sms",
+		Variables:        []TemplateVariable{{Name: "pickup_location", Example: "123 Main St"}},
+		titleKey:         "notification.ride.requested.title",
+		bodyKey:          "notification.ride.requested.sms",
+	},
+	{
+		NotificationType: TemplateRideAccepted,
+		Channel:          "push",
+		Variables:        []TemplateVariable{{Name: "driver_name", Example: "Alex"}, {Name: "eta", Example: 5}},
+		titleKey:         "notification.ride.accepted.title",
+		bodyKey:          "notification.ride.accepted.body",
+		args:             []string{"driver_name", "eta"},
+	},
+	{
+		NotificationType: TemplateRideStarted,
+		Channel:          "push",
+		Variables:        []TemplateVariable{},
+		titleKey:         "notification.ride.started.title",
+		bodyKey:          "notification.ride.started.body",
+	},
+	{
+		NotificationType: TemplateRideCompletedRider,
+		Channel:          "push",
+		Variables:        []TemplateVariable{{Name: "fare", Example: "$12.50"}},
+		titleKey:         "notification.ride.completed.title.rider",
+		bodyKey:          "notification.ride.completed.body.rider",
+		args:             []string{"fare"},
+	},
+	{
+		NotificationType: TemplateRideCompletedDriver,
+		Channel:          "push",
+		Variables:        []TemplateVariable{{Name: "earnings", Example: "$10.00"}},
+		titleKey:         "notification.ride.completed.title.driver",
+		bodyKey:          "notification.ride.completed.body.driver",
+		args:             []string{"earnings"},
+	},
+	{
+		// The default body is the built-in receipt email
+		NotificationType: TemplateRideReceipt,
+		Channel:          "email",
+		Variables:        []TemplateVariable{{Name: "fare", Example: "$12.50"}, {Name: "date", Example: "Jan 02, 2026 3:04 PM"}},
+		titleKey:         "notification.ride.receipt.subject",
+	},
+	{
+		NotificationType: TemplateRideCancelled,
+		Channel:          "push",
+		Variables:        []TemplateVariable{{Name: "cancelled_by", Example: "driver"}},
+		titleKey:         "notification.ride.cancelled.title",
+		bodyKey:          "notification.ride.cancelled.body",
+		args:             []string{"cancelled_by"},
+	},
+	{
+		NotificationType: TemplateRidePaymentDeclined,
+		Channel:          "push",
+		Variables:        []TemplateVariable{},
+		titleKey:         "notification.ride.payment_declined.title",
+		bodyKey:          "notification.ride.payment_declined.body",
+	},
+	{
+		NotificationType: TemplatePaymentReceived,
+		Channel:          "push",
+		Variables:        []TemplateVariable{{Name: "amount", Example: "$12.50"}},
+		titleKey:         "notification.payment.received.title",
+		bodyKey:          "notification.payment.received.body",
+		args:             []string{"amount"},
+	},
+}
+
+func findTemplateDefinition(notificationType, channel string) (*TemplateDefinition, bool) {
+	for i := range templateDefinitions {
+		def := &templateDefinitions[i]
+		if def.NotificationType == notificationType && def.Channel == channel {
+			return def, true
+		}
+	}
+	return nil, false
+}
+
+// exampleVariables returns the variables previews and validation render with
+func (d *TemplateDefinition) exampleVariables() map[string]interface{} {
+	vars := make(map[string]interface{}, len(d.Variables))
+	for _, v := range d.Variables {
+		vars[v.Name] = v.Example
+	}
+	return vars
+}
+
+// defaults renders the compiled-in pkg/i18n copy
+func (d *TemplateDefinition) defaults(lang string, vars map[string]interface{}) *renderedNotification {
+	rendered := &renderedNotification{Title: i18n.Translate(d.titleKey, lang)}
+	if d.bodyKey != "" {
+		args := make([]interface{}, len(d.args))
+		for i, name := range d.args {
+			args[i] = vars[name]
+		}
+		rendered.Body = i18n.Translate(d.bodyKey, lang, args...)
+	}
+	return rendered
+}
+
+// renderedNotification is the title and body a notification is sent with
+type renderedNotification struct {
+	Title string
+	Body  string
+	// Template is the version that was rendered; nil when the defaults were used
+	Template *NotificationTemplate
+}
+
+// annotate returns the notification data with the template version the
+// copy came from. data itself is left alone since callers share it between
+// notifications.
+func (r *renderedNotification) annotate(data map[string]interface{}) map[string]interface{} {
+	if r.Template == nil {
+		return data
+	}
+	annotated := make(map[string]interface{}, len(data)+2)
+	for key, value := range data {
+		annotated[key] = value
+	}
+	annotated["template_id"] = r.Template.ID.String()
+	annotated["template_version"] = r.Template.Version
+	return annotated
+}
+
+// renderTemplate executes a template's title and body with vars. Email
+// bodies are HTML and escape their variables. A variable the template uses
+// but vars lacks is an error rather than a blank.
+func renderTemplate(channel, title, body string, vars map[string]interface{}) (string, string, error) {
+	titleTmpl, err := template.New("title").Option("missingkey=error").Parse(title)
+	if err != nil {
+		return "", "", err
+	}
+	var titleBuf bytes.Buffer
+	if err := titleTmpl.Execute(&titleBuf, vars); err != nil {
+		return "", "", err
+	}
+
+	var bodyBuf bytes.Buffer
+	if channel == "email" {
+		bodyTmpl, err := htmltemplate.New("body").Option("missingkey=error").Parse(body)
+		if err != nil {
+			return "", "", err
+		}
+		if err := bodyTmpl.Execute(&bodyBuf, vars); err != nil {
+			return "", "", err
+		}
+	} else {
+		bodyTmpl, err := template.New("body").Option("missingkey=error").Parse(body)
+		if err != nil {
+			return "", "", err
+		}
+		if err := bodyTmpl.Execute(&bodyBuf, vars); err != nil {
+			return "", "", err
+		}
+	}
+
+	return titleBuf.String(), bodyBuf.String(), nil
+}
+
+// SetTemplateStore enables database notification templates. Without it all
+// notifications use the pkg/i18n defaults.
+func (s *Service) SetTemplateStore(store TemplateStore) {
+	s.templates = store
+	s.templateCache = newTemplateCache(templateCacheTTL)
+}
+
+// renderNotification returns the copy for a notification in lang: the
+// active database template when there is one and it renders, otherwise the
+// pkg/i18n defaults
+func (s *Service) renderNotification(ctx context.Context, notificationType, channel, lang string, vars map[string]interface{}) *renderedNotification {
+	def, ok := findTemplateDefinition(notificationType, channel)
+	if !ok {
+		// Every caller passes a defined type; this is a programming error
+		logger.Get().Error("No template definition for notification",
+			zap.String("type", notificationType),
+			zap.String("channel", channel))
+		return &renderedNotification{}
+	}
+
+	if tmpl := s.activeTemplate(ctx, def, lang); tmpl != nil {
+		title, body, err := renderTemplate(tmpl.Channel, tmpl.Title, tmpl.Body, vars)
+		if err == nil {
+			return &renderedNotification{Title: title, Body: body, Template: tmpl}
+		}
+		logger.Get().Warn("Failed to render notification template, using default copy",
+			zap.String("template_id", tmpl.ID.String()),
+			zap.Int("version", tmpl.Version),
+			zap.Error(err))
+	}
+
+	return def.defaults(lang, vars)
+}
+
+// activeTemplate finds the template to use for lang. A language the
+// defaults aren't translated into uses the English template before the
+// English defaults.
+func (s *Service) activeTemplate(ctx context.Context, def *TemplateDefinition, lang string) *NotificationTemplate {
+	if s.templates == nil {
+		return nil
+	}
+
+	if tmpl := s.lookupTemplate(ctx, def, lang); tmpl != nil {
+		return tmpl
+	}
+	if lang != i18n.DefaultLang && !i18n.HasTranslation(def.titleKey, lang) {
+		return s.lookupTemplate(ctx, def, i18n.DefaultLang)
+	}
+	return nil
+}
+
+func (s *Service) lookupTemplate(ctx context.Context, def *TemplateDefinition, lang string) *NotificationTemplate {
+	key := def.NotificationType + "|" + def.Channel + "|" + lang
+	if tmpl, ok := s.templateCache.get(key); ok {
+		return tmpl
+	}
+
+	tmpl, err := s.templates.GetActiveTemplate(ctx, def.NotificationType, def.Channel, lang)
+	if err != nil {
+		// Not cached, so the next notification tries again
+		logger.Get().Warn("Failed to load notification template, using default copy",
+			zap.String("type", def.NotificationType),
+			zap.String("channel", def.Channel),
+			zap.String("language", lang),
+			zap.Error(err))
+		return nil
+	}
+
+	s.templateCache.set(key, tmpl)
+	return tmpl
+}
+
+// ========================================
+// TEMPLATE MANAGEMENT (admin)
+// ========================================
+
+// CreateTemplateRequest adds a template version
+type CreateTemplateRequest struct {
+	NotificationType string `json:"notification_type" binding:"required"`
+	Channel          string `json:"channel" binding:"required,oneof=push sms email"`
+	Language         string `json:"language" binding:"required"`
+	Title            string `json:"title"`
+	Body             string `json:"body" binding:"required"`
+	// Activate makes the new version live right away; otherwise it is a
+	// draft until activated
+	Activate bool `json:"activate"`
+}
+
+// PreviewTemplateRequest renders a draft, or without a body, what would be
+// sent today. Variables default to the type's examples.
+type PreviewTemplateRequest struct {
+	NotificationType string                 `json:"notification_type" binding:"required"`
+	Channel          string                 `json:"channel" binding:"required,oneof=push sms email"`
+	Language         string                 `json:"language"`
+	Title            string                 `json:"title"`
+	Body             string                 `json:"body"`
+	Variables        map[string]interface{} `json:"variables"`
+}
+
+// TemplatePreview is rendered notification copy
+type TemplatePreview struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Source is "draft", "template" or "default"
+	Source     string     `json:"source"`
+	TemplateID *uuid.UUID `json:"template_id,omitempty"`
+	Version    int        `json:"version,omitempty"`
+}
+
+// TemplateCatalog lists the notifications that can be templated and their
+// variables
+func (s *Service) TemplateCatalog() []TemplateDefinition {
+	return templateDefinitions
+}
+
+// ListTemplates retrieves template versions
+func (s *Service) ListTemplates(ctx context.Context, filter TemplateFilter) ([]*NotificationTemplate, error) {
+	if s.templates == nil {
+		return nil, common.NewServiceUnavailableError("notification templates are not configured")
+	}
+	filter.Language = normalizeLanguage(filter.Language)
+	return s.templates.ListTemplates(ctx, filter)
+}
+
+// CreateTemplate validates and stores a new template version
+func (s *Service) CreateTemplate(ctx context.Context, adminID uuid.UUID, req *CreateTemplateRequest) (*NotificationTemplate, error) {
+	if s.templates == nil {
+		return nil, common.NewServiceUnavailableError("notification templates are not configured")
+	}
+
+	def, ok := findTemplateDefinition(req.NotificationType, req.Channel)
+	if !ok {
+		return nil, common.NewBadRequestError("unknown notification type for channel", nil)
+	}
+	lang := normalizeLanguage(req.Language)
+	if len(lang) < 2 || len(lang) > 10 {
+		return nil, common.NewBadRequestError("invalid language", nil)
+	}
+	if req.Channel != "sms" && strings.TrimSpace(req.Title) == "" {
+		return nil, common.NewBadRequestError("title is required for push and email templates", nil)
+	}
+	// Rendering with the examples catches syntax errors and unknown variables
+	if _, _, err := renderTemplate(req.Channel, req.Title, req.Body, def.exampleVariables()); err != nil {
+		return nil, common.NewBadRequestError("invalid template: "+err.Error(), err)
+	}
+
+	tmpl := &NotificationTemplate{
+		ID:               uuid.New(),
+		NotificationType: req.NotificationType,
+		Channel:          req.Channel,
+		Language:         lang,
+		Title:            req.Title,
+		Body:             req.Body,
+		CreatedBy:        &adminID,
+	}
+	if err := s.templates.CreateTemplate(ctx, tmpl, req.Activate); err != nil {
+		return nil, err
+	}
+	if req.Activate {
+		s.templateCache.clear()
+	}
+
+	logger.Get().Info("Notification template version created",
+		zap.String("template_id", tmpl.ID.String()),
+		zap.String("type", tmpl.NotificationType),
+		zap.String("channel", tmpl.Channel),
+		zap.String("language", tmpl.Language),
+		zap.Int("version", tmpl.Version),
+		zap.Bool("active", tmpl.IsActive))
+	return tmpl, nil
+}
+
+// ActivateTemplate makes a template version live, replacing the active one
+func (s *Service) ActivateTemplate(ctx context.Context, id uuid.UUID) (*NotificationTemplate, error) {
+	if s.templates == nil {
+		return nil, common.NewServiceUnavailableError("notification templates are not configured")
+	}
+	if err := s.templates.ActivateTemplate(ctx, id); err != nil {
+		return nil, err
+	}
+	s.templateCache.clear()
+	return s.templates.GetTemplate(ctx, id)
+}
+
+// DeactivateTemplate takes a template version out of use; the notification
+// goes back to its default copy
+func (s *Service) DeactivateTemplate(ctx context.Context, id uuid.UUID) error {
+	if s.templates == nil {
+		return common.NewServiceUnavailableError("notification templates are not configured")
+	}
+	if err := s.templates.DeactivateTemplate(ctx, id); err != nil {
+		return err
+	}
+	s.templateCache.clear()
+	return nil
+}
+
+// PreviewTemplate renders a draft template, or the copy currently sent for
+// the type, channel and language
+func (s *Service) PreviewTemplate(ctx context.Context, req *PreviewTemplateRequest) (*TemplatePreview, error) {
+	def, ok := findTemplateDefinition(req.NotificationType, req.Channel)
+	if !ok {
+		return nil, common.NewBadRequestError("unknown notification type for channel", nil)
+	}
+	lang := normalizeLanguage(req.Language)
+	if lang == "" {
+		lang = i18n.DefaultLang
+	}
+	vars := def.exampleVariables()
+	for name, value := range req.Variables {
+		vars[name] = value
+	}
+
+	if req.Body != "" {
+		title, body, err := renderTemplate(req.Channel, req.Title, req.Body, vars)
+		if err != nil {
+			return nil, common.NewBadRequestError("invalid template: "+err.Error(), err)
+		}
+		return &TemplatePreview{Title: title, Body: body, Source: "draft"}, nil
+	}
+
+	rendered := s.renderNotification(ctx, req.NotificationType, req.Channel, lang, vars)
+	preview := &TemplatePreview{Title: rendered.Title, Body: rendered.Body, Source: "default"}
+	if rendered.Template != nil {
+		preview.Source = "template"
+		preview.TemplateID = &rendered.Template.ID
+		preview.Version = rendered.Template.Version
+	}
+	return preview, nil
+}
+
+func normalizeLanguage(lang string) string {
+	return strings.ToLower(strings.TrimSpace(lang))
+}
+
+// templateCache holds active template lookups, including misses, for a
+// short while since every notification sent looks its template up
+type templateCache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]templateCacheEntry
+}
+
+type templateCacheEntry struct {
+	template  *NotificationTemplate
+	expiresAt time.Time
+}
+
+func newTemplateCache(ttl time.Duration) *templateCache {
+	return &templateCache{ttl: ttl, entries: make(map[string]templateCacheEntry)}
+}
+
+func (c *templateCache) get(key string) (*NotificationTemplate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.template, true
+}
+
+func (c *templateCache) set(key string, tmpl *NotificationTemplate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = templateCacheEntry{template: tmpl, expiresAt: time.Now().Add(c.ttl)}
+}
+
+func (c *templateCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]templateCacheEntry)
+}
@@ -0,0 +1,341 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTemplateStore is a mock implementation of TemplateStore
+type mockTemplateStore struct {
+	mock.Mock
+}
+
+func (m *mockTemplateStore) GetActiveTemplate(ctx context.Context, notificationType, channel, language string) (*NotificationTemplate, error) {
+	args := m.Called(ctx, notificationType, channel, language)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*NotificationTemplate), args.Error(1)
+}
+
+func (m *mockTemplateStore) GetTemplate(ctx context.Context, id uuid.UUID) (*NotificationTemplate, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*NotificationTemplate), args.Error(1)
+}
+
+func (m *mockTemplateStore) ListTemplates(ctx context.Context, filter TemplateFilter) ([]*NotificationTemplate, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*NotificationTemplate), args.Error(1)
+}
+
+func (m *mockTemplateStore) CreateTemplate(ctx context.Context, tmpl *NotificationTemplate, activate bool) error {
+	args := m.Called(ctx, tmpl, activate)
+	return args.Error(0)
+}
+
+func (m *mockTemplateStore) ActivateTemplate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockTemplateStore) DeactivateTemplate(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func newTemplateTestService() (*Service, *mocks.MockNotificationsRepository, *mockTemplateStore) {
+	repo := new(mocks.MockNotificationsRepository)
+	store := new(mockTemplateStore)
+	service := NewService(repo, nil, nil, nil)
+	service.SetTemplateStore(store)
+	return service, repo, store
+}
+
+func activeTemplate(notificationType, channel, language, title, body string) *NotificationTemplate {
+	return &NotificationTemplate{
+		ID:               uuid.New(),
+		NotificationType: notificationType,
+		Channel:          channel,
+		Language:         language,
+		Version:          3,
+		Title:            title,
+		Body:             body,
+		IsActive:         true,
+	}
+}
+
+func TestRenderNotification_UsesActiveTemplate(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	tmpl := activeTemplate(TemplateRideAccepted, "push", "ru", "Водитель едет", "{{.driver_name}} будет через {{.eta}} мин")
+	store.On("GetActiveTemplate", ctx, TemplateRideAccepted, "push", "ru").Return(tmpl, nil).Once()
+
+	rendered := service.renderNotification(ctx, TemplateRideAccepted, "push", "ru", map[string]interface{}{
+		"driver_name": "Alex",
+		"eta":         4,
+	})
+
+	assert.Equal(t, "Водитель едет", rendered.Title)
+	assert.Equal(t, "Alex будет через 4 мин", rendered.Body)
+	assert.Equal(t, tmpl, rendered.Template)
+
+	// The lookup is cached
+	service.renderNotification(ctx, TemplateRideAccepted, "push", "ru", map[string]interface{}{"driver_name": "Sam", "eta": 2})
+	store.AssertExpectations(t)
+}
+
+func TestRenderNotification_FallsBackToI18nDefaults(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	store.On("GetActiveTemplate", ctx, TemplateRideAccepted, "push", "tr").Return(nil, nil)
+
+	rendered := service.renderNotification(ctx, TemplateRideAccepted, "push", "tr", map[string]interface{}{
+		"driver_name": "Alex",
+		"eta":         4,
+	})
+
+	assert.Equal(t, "Sürücü Bulundu!", rendered.Title)
+	assert.Equal(t, "Alex sizi 4 dakika içinde alacak", rendered.Body)
+	assert.Nil(t, rendered.Template)
+	// The i18n defaults are translated into Turkish, so no English template is tried
+	store.AssertNotCalled(t, "GetActiveTemplate", ctx, TemplateRideAccepted, "push", "en")
+}
+
+func TestRenderNotification_UntranslatedLanguageUsesEnglishTemplate(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	tmpl := activeTemplate(TemplateRideStarted, "push", "en", "On your way", "Enjoy the ride")
+	store.On("GetActiveTemplate", ctx, TemplateRideStarted, "push", "de").Return(nil, nil)
+	store.On("GetActiveTemplate", ctx, TemplateRideStarted, "push", "en").Return(tmpl, nil)
+
+	rendered := service.renderNotification(ctx, TemplateRideStarted, "push", "de", map[string]interface{}{})
+
+	assert.Equal(t, "On your way", rendered.Title)
+	assert.Equal(t, tmpl, rendered.Template)
+}
+
+func TestRenderNotification_BrokenTemplateUsesDefaults(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	// References a variable the notification doesn't provide
+	tmpl := activeTemplate(TemplatePaymentReceived, "push", "en", "Paid", "{{.amount}} via {{.method}}")
+	store.On("GetActiveTemplate", ctx, TemplatePaymentReceived, "push", "en").Return(tmpl, nil)
+
+	rendered := service.renderNotification(ctx, TemplatePaymentReceived, "push", "en", map[string]interface{}{"amount": "$5.00"})
+
+	assert.Equal(t, "Payment Received", rendered.Title)
+	assert.Equal(t, "Payment of $5.00 has been received", rendered.Body)
+	assert.Nil(t, rendered.Template)
+}
+
+func TestRenderNotification_StoreErrorUsesDefaultsAndRetries(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	store.On("GetActiveTemplate", ctx, TemplateRideStarted, "push", "en").Return(nil, errors.New("connection refused")).Twice()
+
+	rendered := service.renderNotification(ctx, TemplateRideStarted, "push", "en", map[string]interface{}{})
+	assert.Equal(t, "Ride Started", rendered.Title)
+
+	service.renderNotification(ctx, TemplateRideStarted, "push", "en", map[string]interface{}{})
+	store.AssertExpectations(t)
+}
+
+func TestNotifyRideAccepted_SendsTemplatedCopy(t *testing.T) {
+	service, repo, store := newTemplateTestService()
+	ctx := context.Background()
+	riderID := uuid.New()
+
+	tmpl := activeTemplate(TemplateRideAccepted, "push", "en", "{{.driver_name}} accepted", "Arriving in {{.eta}} min")
+	repo.On("GetUserLanguage", ctx, riderID).Return("en", nil)
+	repo.On("GetUserDeviceTokens", mock.Anything, mock.Anything).Return([]string{}, nil).Maybe()
+	repo.On("UpdateNotificationStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	store.On("GetActiveTemplate", ctx, TemplateRideAccepted, "push", "en").Return(tmpl, nil)
+	repo.On("CreateNotification", ctx, mock.MatchedBy(func(n *models.Notification) bool {
+		return n.Title == "Alex accepted" && n.Body == "Arriving in 3 min" &&
+			n.Data["template_id"] == tmpl.ID.String() && n.Data["template_version"] == 3 &&
+			n.Data["driver_name"] == "Alex"
+	})).Return(nil)
+
+	err := service.NotifyRideAccepted(ctx, riderID, "Alex", 3)
+
+	assert.NoError(t, err)
+	repo.AssertExpectations(t)
+	time.Sleep(10 * time.Millisecond)
+}
+
+func TestSendEmailNotification_TemplatedEmailIsHTML(t *testing.T) {
+	mockRepo := new(mocks.MockNotificationsRepository)
+	mockEmail := new(mocks.MockEmailClient)
+	service := NewService(mockRepo, nil, nil, mockEmail)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	notification := &models.Notification{
+		ID:      uuid.New(),
+		UserID:  userID,
+		Channel: "email",
+		Type:    "ride_receipt",
+		Title:   "Receipt",
+		Body:    "<p>You paid $12.50</p>",
+		Data: map[string]interface{}{
+			"receipt":     map[string]interface{}{"Fare": "$12.50"},
+			"template_id": uuid.New().String(),
+		},
+	}
+
+	mockRepo.On("GetUserEmail", ctx, userID).Return("user@example.com", nil)
+	mockEmail.On("SendHTMLEmail", "user@example.com", "Receipt", "<p>You paid $12.50</p>").Return(nil)
+
+	err := service.sendEmailNotification(ctx, notification)
+
+	assert.NoError(t, err)
+	mockEmail.AssertExpectations(t)
+}
+
+func TestCreateTemplate(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+	adminID := uuid.New()
+
+	store.On("CreateTemplate", ctx, mock.MatchedBy(func(tmpl *NotificationTemplate) bool {
+		return tmpl.Language == "ru" && *tmpl.CreatedBy == adminID
+	}), true).Run(func(args mock.Arguments) {
+		args.Get(1).(*NotificationTemplate).Version = 2
+	}).Return(nil)
+
+	tmpl, err := service.CreateTemplate(ctx, adminID, &CreateTemplateRequest{
+		NotificationType: TemplateRideCompletedRider,
+		Channel:          "push",
+		Language:         " RU ",
+		Title:            "Поездка завершена",
+		Body:             "Итого: {{.fare}}",
+		Activate:         true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, tmpl.Version)
+	store.AssertExpectations(t)
+}
+
+func TestCreateTemplate_Invalid(t *testing.T) {
+	tests := map[string]*CreateTemplateRequest{
+		"unknown type": {
+			NotificationType: "ride_teleported", Channel: "push", Language: "en", Title: "Hi", Body: "Hi",
+		},
+		"type not sent on channel": {
+			NotificationType: TemplateRideStarted, Channel: "sms", Language: "en", Body: "Hi",
+		},
+		"unknown variable": {
+			NotificationType: TemplateRideCompletedRider, Channel: "push", Language: "en", Title: "Done", Body: "{{.tip}}",
+		},
+		"syntax error": {
+			NotificationType: TemplateRideCompletedRider, Channel: "push", Language: "en", Title: "Done", Body: "{{.fare",
+		},
+		"missing push title": {
+			NotificationType: TemplateRideStarted, Channel: "push", Language: "en", Body: "Go",
+		},
+		"bad language": {
+			NotificationType: TemplateRideStarted, Channel: "push", Language: "x", Title: "Go", Body: "Go",
+		},
+	}
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			service, _, store := newTemplateTestService()
+
+			_, err := service.CreateTemplate(context.Background(), uuid.New(), req)
+
+			var appErr *common.AppError
+			require.ErrorAs(t, err, &appErr)
+			assert.Equal(t, 400, appErr.Code)
+			store.AssertNotCalled(t, "CreateTemplate", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestActivateTemplate_ClearsCache(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	old := activeTemplate(TemplateRideStarted, "push", "en", "Old", "Old")
+	rollback := activeTemplate(TemplateRideStarted, "push", "en", "New", "New")
+	store.On("GetActiveTemplate", ctx, TemplateRideStarted, "push", "en").Return(old, nil).Once()
+	store.On("ActivateTemplate", ctx, rollback.ID).Return(nil)
+	store.On("GetTemplate", ctx, rollback.ID).Return(rollback, nil)
+	store.On("GetActiveTemplate", ctx, TemplateRideStarted, "push", "en").Return(rollback, nil).Once()
+
+	assert.Equal(t, "Old", service.renderNotification(ctx, TemplateRideStarted, "push", "en", nil).Title)
+
+	_, err := service.ActivateTemplate(ctx, rollback.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "New", service.renderNotification(ctx, TemplateRideStarted, "push", "en", nil).Title)
+	store.AssertExpectations(t)
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	service, _, store := newTemplateTestService()
+	ctx := context.Background()
+
+	t.Run("draft with example variables", func(t *testing.T) {
+		preview, err := service.PreviewTemplate(ctx, &PreviewTemplateRequest{
+			NotificationType: TemplateRideAccepted,
+			Channel:          "push",
+			Title:            "Ride accepted",
+			Body:             "{{.driver_name}} arrives in {{.eta}} min",
+			Variables:        map[string]interface{}{"driver_name": "Sam"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "draft", preview.Source)
+		assert.Equal(t, "Sam arrives in 5 min", preview.Body)
+	})
+
+	t.Run("email drafts escape variables", func(t *testing.T) {
+		preview, err := service.PreviewTemplate(ctx, &PreviewTemplateRequest{
+			NotificationType: TemplateRideReceipt,
+			Channel:          "email",
+			Title:            "Receipt",
+			Body:             "<p>{{.fare}}</p>",
+			Variables:        map[string]interface{}{"fare": "<b>$1</b>"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "<p>&lt;b&gt;$1&lt;/b&gt;</p>", preview.Body)
+	})
+
+	t.Run("current copy", func(t *testing.T) {
+		store.On("GetActiveTemplate", ctx, TemplateRideCancelled, "push", "ru").Return(nil, nil)
+
+		preview, err := service.PreviewTemplate(ctx, &PreviewTemplateRequest{
+			NotificationType: TemplateRideCancelled,
+			Channel:          "push",
+			Language:         "ru",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "default", preview.Source)
+		assert.Equal(t, "Поездка отменена", preview.Title)
+		assert.Equal(t, "Поездка отменена пользователем: driver", preview.Body)
+	})
+}
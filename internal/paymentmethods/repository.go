@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 // Repository handles payment method data access
@@ -31,21 +32,29 @@ func (r *Repository) CreatePaymentMethod(ctx context.Context, pm *PaymentMethod)
 			WHERE user_id = $1`, pm.UserID)
 	}
 
+	var balanceMinor *int64
+	if pm.WalletBalance != nil {
+		balance := money.FromMajor(*pm.WalletBalance, pm.Currency)
+		major := balance.Major()
+		pm.WalletBalance = &major
+		balanceMinor = &balance.Amount
+	}
+
 	_, err := r.db.Exec(ctx, `
 		INSERT INTO payment_methods (
 			id, user_id, type, is_default, is_active,
 			card_brand, card_last4, card_exp_month, card_exp_year, card_holder_name,
-			wallet_balance, provider_id, provider_type,
+			wallet_balance, wallet_balance_minor, provider_id, provider_type,
 			nickname, currency, country, created_at, updated_at
 		) VALUES (
 			$1, $2, $3, $4, $5,
 			$6, $7, $8, $9, $10,
-			$11, $12, $13,
-			$14, $15, $16, $17, $18
+			$11, $12, $13, $14,
+			$15, $16, $17, $18, $19
 		)`,
 		pm.ID, pm.UserID, pm.Type, pm.IsDefault, pm.IsActive,
 		pm.CardBrand, pm.CardLast4, pm.CardExpMonth, pm.CardExpYear, pm.CardHolderName,
-		pm.WalletBalance, pm.ProviderID, pm.ProviderType,
+		pm.WalletBalance, balanceMinor, pm.ProviderID, pm.ProviderType,
 		pm.Nickname, pm.Currency, pm.Country, pm.CreatedAt, pm.UpdatedAt,
 	)
 	return err
@@ -56,7 +65,7 @@ func (r *Repository) GetPaymentMethodsByUser(ctx context.Context, userID uuid.UU
 	rows, err := r.db.Query(ctx, `
 		SELECT id, user_id, type, is_default, is_active,
 			card_brand, card_last4, card_exp_month, card_exp_year, card_holder_name,
-			wallet_balance, provider_id, provider_type,
+			wallet_balance_minor, provider_id, provider_type,
 			nickname, currency, country, created_at, updated_at
 		FROM payment_methods
 		WHERE user_id = $1 AND is_active = true
@@ -70,14 +79,16 @@ func (r *Repository) GetPaymentMethodsByUser(ctx context.Context, userID uuid.UU
 	var methods []PaymentMethod
 	for rows.Next() {
 		pm := PaymentMethod{}
+		var balanceMinor *int64
 		if err := rows.Scan(
 			&pm.ID, &pm.UserID, &pm.Type, &pm.IsDefault, &pm.IsActive,
 			&pm.CardBrand, &pm.CardLast4, &pm.CardExpMonth, &pm.CardExpYear, &pm.CardHolderName,
-			&pm.WalletBalance, &pm.ProviderID, &pm.ProviderType,
+			&balanceMinor, &pm.ProviderID, &pm.ProviderType,
 			&pm.Nickname, &pm.Currency, &pm.Country, &pm.CreatedAt, &pm.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
+		pm.WalletBalance = walletBalanceFromMinor(balanceMinor, pm.Currency)
 		methods = append(methods, pm)
 	}
 	return methods, nil
@@ -86,65 +97,71 @@ func (r *Repository) GetPaymentMethodsByUser(ctx context.Context, userID uuid.UU
 // GetPaymentMethodByID retrieves a payment method by ID
 func (r *Repository) GetPaymentMethodByID(ctx context.Context, id uuid.UUID) (*PaymentMethod, error) {
 	pm := &PaymentMethod{}
+	var balanceMinor *int64
 	err := r.db.QueryRow(ctx, `
 		SELECT id, user_id, type, is_default, is_active,
 			card_brand, card_last4, card_exp_month, card_exp_year, card_holder_name,
-			wallet_balance, provider_id, provider_type,
+			wallet_balance_minor, provider_id, provider_type,
 			nickname, currency, country, created_at, updated_at
 		FROM payment_methods WHERE id = $1`, id,
 	).Scan(
 		&pm.ID, &pm.UserID, &pm.Type, &pm.IsDefault, &pm.IsActive,
 		&pm.CardBrand, &pm.CardLast4, &pm.CardExpMonth, &pm.CardExpYear, &pm.CardHolderName,
-		&pm.WalletBalance, &pm.ProviderID, &pm.ProviderType,
+		&balanceMinor, &pm.ProviderID, &pm.ProviderType,
 		&pm.Nickname, &pm.Currency, &pm.Country, &pm.CreatedAt, &pm.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	pm.WalletBalance = walletBalanceFromMinor(balanceMinor, pm.Currency)
 	return pm, nil
 }
 
 // GetDefaultPaymentMethod returns the user's default payment method
 func (r *Repository) GetDefaultPaymentMethod(ctx context.Context, userID uuid.UUID) (*PaymentMethod, error) {
 	pm := &PaymentMethod{}
+	var balanceMinor *int64
 	err := r.db.QueryRow(ctx, `
 		SELECT id, user_id, type, is_default, is_active,
 			card_brand, card_last4, card_exp_month, card_exp_year, card_holder_name,
-			wallet_balance, provider_id, provider_type,
+			wallet_balance_minor, provider_id, provider_type,
 			nickname, currency, country, created_at, updated_at
 		FROM payment_methods
 		WHERE user_id = $1 AND is_default = true AND is_active = true`, userID,
 	).Scan(
 		&pm.ID, &pm.UserID, &pm.Type, &pm.IsDefault, &pm.IsActive,
 		&pm.CardBrand, &pm.CardLast4, &pm.CardExpMonth, &pm.CardExpYear, &pm.CardHolderName,
-		&pm.WalletBalance, &pm.ProviderID, &pm.ProviderType,
+		&balanceMinor, &pm.ProviderID, &pm.ProviderType,
 		&pm.Nickname, &pm.Currency, &pm.Country, &pm.CreatedAt, &pm.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	pm.WalletBalance = walletBalanceFromMinor(balanceMinor, pm.Currency)
 	return pm, nil
 }
 
 // GetWallet returns the user's wallet payment method
 func (r *Repository) GetWallet(ctx context.Context, userID uuid.UUID) (*PaymentMethod, error) {
 	pm := &PaymentMethod{}
+	var balanceMinor *int64
 	err := r.db.QueryRow(ctx, `
 		SELECT id, user_id, type, is_default, is_active,
 			card_brand, card_last4, card_exp_month, card_exp_year, card_holder_name,
-			wallet_balance, provider_id, provider_type,
+			wallet_balance_minor, provider_id, provider_type,
 			nickname, currency, country, created_at, updated_at
 		FROM payment_methods
 		WHERE user_id = $1 AND type = 'wallet' AND is_active = true`, userID,
 	).Scan(
 		&pm.ID, &pm.UserID, &pm.Type, &pm.IsDefault, &pm.IsActive,
 		&pm.CardBrand, &pm.CardLast4, &pm.CardExpMonth, &pm.CardExpYear, &pm.CardHolderName,
-		&pm.WalletBalance, &pm.ProviderID, &pm.ProviderType,
+		&balanceMinor, &pm.ProviderID, &pm.ProviderType,
 		&pm.Nickname, &pm.Currency, &pm.Country, &pm.CreatedAt, &pm.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	pm.WalletBalance = walletBalanceFromMinor(balanceMinor, pm.Currency)
 	return pm, nil
 }
 
@@ -179,27 +196,41 @@ func (r *Repository) DeactivatePaymentMethod(ctx context.Context, id, userID uui
 // WALLET
 // ========================================
 
-// UpdateWalletBalance updates the wallet balance atomically
+// UpdateWalletBalance updates the wallet balance atomically. The delta is
+// converted to the wallet currency's minor units (half away from zero) and
+// added to wallet_balance_minor; the legacy wallet_balance column follows it.
 func (r *Repository) UpdateWalletBalance(ctx context.Context, walletID uuid.UUID, delta float64) (float64, error) {
-	var newBalance float64
+	var newBalance int64
+	var currency string
 	err := r.db.QueryRow(ctx, `
 		UPDATE payment_methods
-		SET wallet_balance = wallet_balance + $2, updated_at = NOW()
+		SET wallet_balance_minor = COALESCE(wallet_balance_minor, 0) + to_minor_units($2, currency),
+			wallet_balance = from_minor_units(COALESCE(wallet_balance_minor, 0) + to_minor_units($2, currency), currency),
+			updated_at = NOW()
 		WHERE id = $1 AND type = 'wallet'
-		RETURNING wallet_balance`,
+		RETURNING wallet_balance_minor, currency`,
 		walletID, delta,
-	).Scan(&newBalance)
-	return newBalance, err
+	).Scan(&newBalance, &currency)
+	if err != nil {
+		return 0, err
+	}
+	return money.New(newBalance, currency).Major(), nil
 }
 
-// CreateWalletTransaction records a wallet transaction
+// CreateWalletTransaction records a wallet transaction. Minor-unit columns
+// are derived using the wallet's currency.
 func (r *Repository) CreateWalletTransaction(ctx context.Context, tx *WalletTransaction) error {
 	_, err := r.db.Exec(ctx, `
+		WITH pm AS (SELECT COALESCE((SELECT currency FROM payment_methods WHERE id = $3), 'USD') AS currency)
 		INSERT INTO wallet_transactions (
 			id, user_id, payment_method_id, type, amount,
 			balance_before, balance_after, description,
-			ride_id, reference_id, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			ride_id, reference_id, created_at,
+			amount_minor, balance_before_minor, balance_after_minor
+		)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11,
+			to_minor_units($5, pm.currency), to_minor_units($6, pm.currency), to_minor_units($7, pm.currency)
+		FROM pm`,
 		tx.ID, tx.UserID, tx.PaymentMethodID, tx.Type, tx.Amount,
 		tx.BalanceBefore, tx.BalanceAfter, tx.Description,
 		tx.RideID, tx.ReferenceID, tx.CreatedAt,
@@ -210,12 +241,13 @@ func (r *Repository) CreateWalletTransaction(ctx context.Context, tx *WalletTran
 // GetWalletTransactions returns recent wallet transactions
 func (r *Repository) GetWalletTransactions(ctx context.Context, userID uuid.UUID, limit int) ([]WalletTransaction, error) {
 	rows, err := r.db.Query(ctx, `
-		SELECT id, user_id, payment_method_id, type, amount,
-			balance_before, balance_after, description,
-			ride_id, reference_id, created_at
-		FROM wallet_transactions
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		SELECT wt.id, wt.user_id, wt.payment_method_id, wt.type, wt.amount_minor,
+			wt.balance_before_minor, wt.balance_after_minor, wt.description,
+			wt.ride_id, wt.reference_id, wt.created_at, pm.currency
+		FROM wallet_transactions wt
+		JOIN payment_methods pm ON pm.id = wt.payment_method_id
+		WHERE wt.user_id = $1
+		ORDER BY wt.created_at DESC
 		LIMIT $2`,
 		userID, limit,
 	)
@@ -227,13 +259,18 @@ func (r *Repository) GetWalletTransactions(ctx context.Context, userID uuid.UUID
 	var transactions []WalletTransaction
 	for rows.Next() {
 		tx := WalletTransaction{}
+		var amountMinor, beforeMinor, afterMinor int64
+		var currency string
 		if err := rows.Scan(
-			&tx.ID, &tx.UserID, &tx.PaymentMethodID, &tx.Type, &tx.Amount,
-			&tx.BalanceBefore, &tx.BalanceAfter, &tx.Description,
-			&tx.RideID, &tx.ReferenceID, &tx.CreatedAt,
+			&tx.ID, &tx.UserID, &tx.PaymentMethodID, &tx.Type, &amountMinor,
+			&beforeMinor, &afterMinor, &tx.Description,
+			&tx.RideID, &tx.ReferenceID, &tx.CreatedAt, &currency,
 		); err != nil {
 			return nil, err
 		}
+		tx.Amount = money.New(amountMinor, currency).Major()
+		tx.BalanceBefore = money.New(beforeMinor, currency).Major()
+		tx.BalanceAfter = money.New(afterMinor, currency).Major()
 		transactions = append(transactions, tx)
 	}
 	return transactions, nil
@@ -241,17 +278,18 @@ func (r *Repository) GetWalletTransactions(ctx context.Context, userID uuid.UUID
 
 // GetWalletBalance returns the current wallet balance
 func (r *Repository) GetWalletBalance(ctx context.Context, userID uuid.UUID) (float64, error) {
-	var balance float64
+	var balance int64
+	var currency string
 	err := r.db.QueryRow(ctx, `
-		SELECT COALESCE(wallet_balance, 0)
+		SELECT COALESCE(wallet_balance_minor, 0), currency
 		FROM payment_methods
 		WHERE user_id = $1 AND type = 'wallet' AND is_active = true`,
 		userID,
-	).Scan(&balance)
+	).Scan(&balance, &currency)
 	if err != nil {
 		return 0, nil // No wallet = 0 balance
 	}
-	return balance, nil
+	return money.New(balance, currency).Major(), nil
 }
 
 // EnsureWalletExists creates a wallet if the user doesn't have one
@@ -282,3 +320,13 @@ func (r *Repository) EnsureWalletExists(ctx context.Context, userID uuid.UUID) (
 	}
 	return wallet, nil
 }
+
+// walletBalanceFromMinor converts a nullable minor-unit balance to the
+// major-unit value exposed on PaymentMethod.
+func walletBalanceFromMinor(minor *int64, currency string) *float64 {
+	if minor == nil {
+		return nil
+	}
+	balance := money.New(*minor, currency).Major()
+	return &balance
+}
@@ -4,15 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	"go.uber.org/zap"
 )
 
 // EventHandler processes ride events and triggers payment operations.
 type EventHandler struct {
 	service *Service
+	bus     *eventbus.Bus
 }
 
 // NewEventHandler creates an event handler backed by the payment service.
@@ -20,12 +24,24 @@ func NewEventHandler(service *Service) *EventHandler {
 	return &EventHandler{service: service}
 }
 
-// RegisterSubscriptions subscribes to ride completion events on the bus.
+// RegisterSubscriptions subscribes to ride events on the bus: requests place
+// card holds, completions record driver earnings and capture the hold, and
+// cancellations release it.
 func (h *EventHandler) RegisterSubscriptions(ctx context.Context, bus *eventbus.Bus) error {
-	if err := bus.Subscribe(ctx, "rides.completed", "payments-ride-completed", h.handleRideCompleted); err != nil {
+	h.bus = bus
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideCompleted, "payments-ride-completed", h.handleRideCompleted); err != nil {
 		return fmt.Errorf("subscribe to rides.completed: %w", err)
 	}
-	logger.Info("payments: subscribed to ride completion events for driver earnings")
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideRequested, "payments-ride-requested", h.handleRideRequested); err != nil {
+		return fmt.Errorf("subscribe to rides.requested: %w", err)
+	}
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideCompleted, "payments-ride-capture", h.handleRideCapture); err != nil {
+		return fmt.Errorf("subscribe to rides.completed: %w", err)
+	}
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideCancelled, "payments-ride-cancelled", h.handleRideCancelled); err != nil {
+		return fmt.Errorf("subscribe to rides.cancelled: %w", err)
+	}
+	logger.Info("payments: subscribed to ride events for card holds and driver earnings")
 	return nil
 }
 
@@ -42,6 +58,7 @@ func (h *EventHandler) handleRideCompleted(ctx context.Context, event *eventbus.
 		return nil
 	}
 
+	// Drivers earn on the gross fare; rider discounts are funded by the platform
 	logger.Info("payments: recording driver earning for completed ride",
 		zap.String("ride_id", data.RideID.String()),
 		zap.String("driver_id", data.DriverID.String()),
@@ -64,3 +81,136 @@ func (h *EventHandler) handleRideCompleted(ctx context.Context, event *eventbus.
 	)
 	return nil
 }
+
+// handleRideRequested places an authorization hold for card rides. A declined
+// card is acked and announced on payments.failed, where the rides service
+// cancels the ride before it is matched; Stripe being unavailable is retried.
+func (h *EventHandler) handleRideRequested(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideRequestedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride requested: %w", err)
+	}
+	if !holdsCard(data.PaymentMethod) || data.EstimatedFare <= 0 {
+		return nil
+	}
+
+	currency := data.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	hold, err := h.service.AuthorizeRide(ctx, data.RideID, data.RiderID, data.EstimatedFare, currency)
+	if hold == nil {
+		if err != nil {
+			return fmt.Errorf("authorize ride: %w", err)
+		}
+		return nil
+	}
+	if hold.Status == models.PaymentHoldFailed {
+		h.publishHoldFailed(ctx, hold)
+	}
+	return nil
+}
+
+// handleRideCapture captures the card hold for the amount due once discounts
+// and gift card credit are taken off the fare, and releases it when they
+// cover the whole fare. Rides without a hold are paid through the payments
+// API as before.
+func (h *EventHandler) handleRideCapture(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideCompletedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride completed: %w", err)
+	}
+	if data.FareAmount <= 0 {
+		return nil
+	}
+
+	currency := data.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	covered := FareCoverage{Discount: data.Discount, Credit: data.Credit}
+
+	amountDue := data.ChargeAmount()
+	if amountDue <= 0 {
+		if _, err := h.service.ReleaseRideHold(ctx, data.RideID, data.DriverID, false); err != nil {
+			logger.Error("payments: failed to release hold of fully discounted ride",
+				zap.String("ride_id", data.RideID.String()),
+				zap.Error(err),
+			)
+			return fmt.Errorf("release ride hold: %w", err)
+		}
+		h.service.RecordCoveredFare(ctx, data.RideID, data.DriverID, currency, covered)
+		return nil
+	}
+
+	payment, err := h.service.CaptureRide(ctx, data.RideID, data.DriverID, amountDue, covered)
+	if err != nil {
+		logger.Error("payments: failed to capture ride hold",
+			zap.String("ride_id", data.RideID.String()),
+			zap.Error(err),
+		)
+		return fmt.Errorf("capture ride hold: %w", err)
+	}
+	if payment != nil {
+		h.publish(ctx, eventbus.SubjectPaymentProcessed, "payment.processed", eventbus.PaymentProcessedData{
+			PaymentID:   payment.ID,
+			RideID:      payment.RideID,
+			RiderID:     payment.RiderID,
+			DriverID:    payment.DriverID,
+			Amount:      payment.Amount,
+			Currency:    payment.Currency,
+			Method:      payment.PaymentMethod,
+			ProcessedAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+// handleRideCancelled releases the card hold. Riders who cancel after a
+// driver was assigned pay the cancellation fee out of the hold, unless the
+// cancellation policy waived it.
+func (h *EventHandler) handleRideCancelled(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideCancelledData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride cancelled: %w", err)
+	}
+
+	chargeFee := data.CancelledBy == "rider" && data.DriverID != uuid.Nil && !data.FeeWaived
+	if _, err := h.service.ReleaseRideHold(ctx, data.RideID, data.DriverID, chargeFee); err != nil {
+		logger.Error("payments: failed to release ride hold",
+			zap.String("ride_id", data.RideID.String()),
+			zap.Error(err),
+		)
+		return fmt.Errorf("release ride hold: %w", err)
+	}
+	return nil
+}
+
+func (h *EventHandler) publishHoldFailed(ctx context.Context, hold *models.PaymentHold) {
+	reason := ""
+	if hold.FailureReason != nil {
+		reason = *hold.FailureReason
+	}
+	h.publish(ctx, eventbus.SubjectPaymentFailed, "payment.failed", eventbus.PaymentFailedData{
+		RideID:   hold.RideID,
+		RiderID:  hold.RiderID,
+		Amount:   hold.EstimatedAmount,
+		Error:    reason,
+		FailedAt: hold.CreatedAt,
+	})
+}
+
+// publish announces a payment event. Publishing is best effort: the payment
+// has already happened, so a failure is logged rather than retried.
+func (h *EventHandler) publish(ctx context.Context, subject, eventType string, data interface{}) {
+	if h.bus == nil {
+		return
+	}
+	evt, err := eventbus.NewEvent(eventType, "payments-service", data)
+	if err == nil {
+		err = h.bus.Publish(ctx, subject, evt)
+	}
+	if err != nil {
+		logger.Warn("payments: failed to publish event", zap.String("subject", subject), zap.Error(err))
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/richxcame/ride-hailing/test/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -147,7 +148,8 @@ func TestHandleRideCompleted_CommissionCalculation(t *testing.T) {
 	}{
 		{"100 fare 20% commission", 100.0, 20.0, 80.0},
 		{"50 fare 20% commission", 50.0, 10.0, 40.0},
-		{"6.78 fare 20% commission", 6.78, 6.78 * 0.20, 6.78 * 0.80},
+		{"6.78 fare 20% commission rounds to the cent", 6.78, 1.36, 5.42},
+		{"commission and net always sum to fare", 10.01, 2.0, 8.01},
 	}
 
 	for _, tt := range tests {
@@ -179,6 +181,164 @@ func TestHandleRideCompleted_CommissionCalculation(t *testing.T) {
 	}
 }
 
+// ─── card holds ──────────────────────────────────────────────────────────────
+
+func makeRideEvent(t *testing.T, eventType string, data interface{}) *eventbus.Event {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	return &eventbus.Event{ID: uuid.New().String(), Type: eventType, Source: "rides-service", Timestamp: time.Now(), Data: raw}
+}
+
+func TestHandleRideRequested_OnlyCardRidesHeld(t *testing.T) {
+	mockRepo := new(mocks.MockPaymentsRepository)
+	handler, mockStripe := newEventHandlerWithMock(mockRepo)
+
+	for _, method := range []string{"", "wallet", "cash"} {
+		err := handler.handleRideRequested(context.Background(), makeRideEvent(t, "ride.requested", eventbus.RideRequestedData{
+			RideID:        uuid.New(),
+			EstimatedFare: 20,
+			PaymentMethod: method,
+		}))
+		assert.NoError(t, err)
+	}
+	mockRepo.AssertExpectations(t)
+	mockStripe.AssertExpectations(t)
+}
+
+func TestHandleRideRequested_DeclineAcked(t *testing.T) {
+	mockRepo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	fake.DeclineHolds = true
+	handler := NewEventHandler(NewService(mockRepo, fake, nil))
+	rideID, riderID := uuid.New(), uuid.New()
+
+	mockRepo.On("GetPaymentHoldByRideID", mock.Anything, rideID).Return(nil, nil).Once()
+	mockRepo.On("GetRideStatus", mock.Anything, rideID).Return(models.RideStatusRequested, nil).Once()
+	mockRepo.On("GetRiderCard", mock.Anything, riderID).Return("cus_rider", "pm_card", nil).Once()
+	mockRepo.On("CreatePaymentHold", mock.Anything, mock.AnythingOfType("*models.PaymentHold")).Return(nil).Once()
+
+	err := handler.handleRideRequested(context.Background(), makeRideEvent(t, "ride.requested", eventbus.RideRequestedData{
+		RideID:        rideID,
+		RiderID:       riderID,
+		EstimatedFare: 20,
+		PaymentMethod: "card",
+	}))
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandleRideCancelled_FeeOnlyAfterDriverAssigned(t *testing.T) {
+	tests := []struct {
+		name        string
+		cancelledBy string
+		driverID    uuid.UUID
+		feeWaived   bool
+		wantFee     bool
+	}{
+		{"rider before assignment", "rider", uuid.Nil, false, false},
+		{"rider after assignment", "rider", uuid.New(), false, true},
+		{"rider fee waived by policy", "rider", uuid.New(), true, false},
+		{"driver", "driver", uuid.New(), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockPaymentsRepository)
+			fake := mocks.NewFakeStripeClient()
+			handler := NewEventHandler(NewService(mockRepo, fake, nil))
+			pi, err := fake.CreateAuthorizationHold(2400, "usd", "cus_rider", "pm_card", "Hold", nil)
+			require.NoError(t, err)
+			hold := &models.PaymentHold{ID: uuid.New(), RideID: uuid.New(), StripePaymentIntentID: &pi.ID,
+				Currency: "USD", EstimatedAmount: 20, AuthorizedAmount: 24, Status: models.PaymentHoldAuthorized}
+
+			mockRepo.On("GetPaymentHoldByRideID", mock.Anything, hold.RideID).Return(hold, nil).Once()
+			mockRepo.On("ClaimPaymentHold", mock.Anything, hold.ID).Return(true, nil).Once()
+			mockRepo.On("UpdatePaymentHold", mock.Anything, hold).Return(nil).Once()
+			if tt.wantFee {
+				mockRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+			}
+
+			err = handler.handleRideCancelled(context.Background(), makeRideEvent(t, "ride.cancelled", eventbus.RideCancelledData{
+				RideID:      hold.RideID,
+				DriverID:    tt.driverID,
+				CancelledBy: tt.cancelledBy,
+				FeeWaived:   tt.feeWaived,
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFee, hold.CapturedAmount > 0)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleRideCapture_ChargesAmountDue(t *testing.T) {
+	amountDue := 12.50
+	tests := []struct {
+		name        string
+		amountDue   *float64
+		wantCapture int64
+	}{
+		{"after discounts", &amountDue, 1250},
+		{"event without settlement", nil, 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockPaymentsRepository)
+			fake := mocks.NewFakeStripeClient()
+			handler := NewEventHandler(NewService(mockRepo, fake, nil))
+			pi, err := fake.CreateAuthorizationHold(2400, "usd", "cus_rider", "pm_card", "Hold", nil)
+			require.NoError(t, err)
+			hold := &models.PaymentHold{ID: uuid.New(), RideID: uuid.New(), StripePaymentIntentID: &pi.ID,
+				Currency: "USD", EstimatedAmount: 20, AuthorizedAmount: 24, Status: models.PaymentHoldAuthorized}
+
+			mockRepo.On("GetPaymentHoldByRideID", mock.Anything, hold.RideID).Return(hold, nil).Once()
+			mockRepo.On("ClaimPaymentHold", mock.Anything, hold.ID).Return(true, nil).Once()
+			mockRepo.On("CreatePayment", mock.Anything, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+			mockRepo.On("UpdatePaymentHold", mock.Anything, hold).Return(nil).Once()
+
+			err = handler.handleRideCapture(context.Background(), makeEvent(t, eventbus.RideCompletedData{
+				RideID:     hold.RideID,
+				DriverID:   uuid.New(),
+				FareAmount: 20,
+				AmountDue:  tt.amountDue,
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantCapture, fake.PaymentIntent(pi.ID).AmountReceived)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleRideCapture_FullyDiscountedReleasesHold(t *testing.T) {
+	mockRepo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	handler := NewEventHandler(NewService(mockRepo, fake, nil))
+	pi, err := fake.CreateAuthorizationHold(2400, "usd", "cus_rider", "pm_card", "Hold", nil)
+	require.NoError(t, err)
+	hold := &models.PaymentHold{ID: uuid.New(), RideID: uuid.New(), StripePaymentIntentID: &pi.ID,
+		Currency: "USD", EstimatedAmount: 20, AuthorizedAmount: 24, Status: models.PaymentHoldAuthorized}
+
+	mockRepo.On("GetPaymentHoldByRideID", mock.Anything, hold.RideID).Return(hold, nil).Once()
+	mockRepo.On("ClaimPaymentHold", mock.Anything, hold.ID).Return(true, nil).Once()
+	mockRepo.On("UpdatePaymentHold", mock.Anything, hold).Return(nil).Once()
+
+	nothingDue := 0.0
+	err = handler.handleRideCapture(context.Background(), makeEvent(t, eventbus.RideCompletedData{
+		RideID:     hold.RideID,
+		DriverID:   uuid.New(),
+		FareAmount: 20,
+		Credit:     20,
+		AmountDue:  &nothingDue,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, models.PaymentHoldReleased, hold.Status)
+	assert.Zero(t, fake.PaymentIntent(pi.ID).AmountReceived)
+	mockRepo.AssertNotCalled(t, "CreatePayment", mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
 // ─── NewEventHandler ─────────────────────────────────────────────────────────
 
 func TestNewEventHandler(t *testing.T) {
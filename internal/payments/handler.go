@@ -13,14 +13,16 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/middleware"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/richxcame/ride-hailing/pkg/pagination"
+	"github.com/stripe/stripe-go/v83"
 	"github.com/stripe/stripe-go/v83/webhook"
 	"go.uber.org/zap"
 )
 
 type Handler struct {
-	service       *Service
-	adminRepo     AdminRepositoryInterface
-	webhookSecret string
+	service         *Service
+	adminRepo       AdminRepositoryInterface
+	webhookSecrets  []string
+	allowUnverified bool
 }
 
 func NewHandler(service *Service) *Handler {
@@ -32,12 +34,25 @@ func NewHandler(service *Service) *Handler {
 	return &Handler{service: service, adminRepo: adminRepo}
 }
 
-// NewHandlerWithWebhookSecret creates a new handler with Stripe webhook secret for signature verification
-func NewHandlerWithWebhookSecret(service *Service, webhookSecret string) *Handler {
-	return &Handler{
-		service:       service,
-		webhookSecret: webhookSecret,
+// NewHandlerWithWebhookSecret creates a new handler that verifies Stripe
+// webhooks against the given signing secrets: the platform endpoint's and,
+// for events from connected accounts, the Connect endpoint's
+func NewHandlerWithWebhookSecret(service *Service, webhookSecrets ...string) *Handler {
+	h := NewHandler(service)
+	for _, secret := range webhookSecrets {
+		if secret != "" {
+			h.webhookSecrets = append(h.webhookSecrets, secret)
+		}
 	}
+	return h
+}
+
+// SetAllowUnsignedWebhooks accepts unsigned Stripe webhooks when no signing
+// secret is configured. For local development against the Stripe CLI only:
+// anyone who can reach the endpoint could then refund payments and hold
+// payouts.
+func (h *Handler) SetAllowUnsignedWebhooks(allow bool) {
+	h.allowUnverified = allow
 }
 
 // RegisterRoutes registers payment routes
@@ -318,7 +333,10 @@ func (h *Handler) RefundPayment(c *gin.Context) {
 	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "Refund processed successfully")
 }
 
-// HandleStripeWebhook handles Stripe webhook events with signature verification
+// HandleStripeWebhook handles Stripe webhook events. The signature is
+// checked against the webhook secrets, which also rejects events signed more
+// than five minutes ago, and each event is applied once per event ID. With
+// no secret configured every event is rejected with 503.
 func (h *Handler) HandleStripeWebhook(c *gin.Context) {
 	// Read the raw body for signature verification
 	payload, err := io.ReadAll(c.Request.Body)
@@ -328,11 +346,9 @@ func (h *Handler) HandleStripeWebhook(c *gin.Context) {
 		return
 	}
 
-	// Verify webhook signature if secret is configured
-	var eventType string
-	var paymentIntentID string
-
-	if h.webhookSecret != "" {
+	var event stripe.Event
+	switch {
+	case len(h.webhookSecrets) > 0:
 		sig := c.GetHeader("Stripe-Signature")
 		if sig == "" {
 			logger.Get().Warn("Missing Stripe-Signature header")
@@ -340,55 +356,36 @@ func (h *Handler) HandleStripeWebhook(c *gin.Context) {
 			return
 		}
 
-		event, err := webhook.ConstructEvent(payload, sig, h.webhookSecret)
+		event, err = h.constructEvent(payload, sig)
 		if err != nil {
 			logger.Get().Warn("Invalid webhook signature", zap.Error(err))
 			common.ErrorResponse(c, http.StatusUnauthorized, "invalid webhook signature")
 			return
 		}
-
-		eventType = string(event.Type)
-
-		// Extract payment intent ID from the verified event
-		if event.Data != nil && event.Data.Object != nil {
-			if id, ok := event.Data.Object["id"].(string); ok {
-				paymentIntentID = id
-			}
-		}
-
-		logger.Get().Info("Verified Stripe webhook",
-			zap.String("event_type", eventType),
-			zap.String("event_id", event.ID),
-		)
-	} else {
-		// Fallback for testing/development without signature verification
-		// WARNING: This should not be used in production
-		logger.Get().Warn("Webhook signature verification disabled - not recommended for production")
-
-		var event struct {
-			Type string                 `json:"type"`
-			Data map[string]interface{} `json:"data"`
-		}
+	case h.allowUnverified:
+		// Development only, see SetAllowUnsignedWebhooks
+		logger.Get().Warn("Accepting unsigned Stripe webhook - signature verification is disabled")
 
 		if err := json.Unmarshal(payload, &event); err != nil {
 			common.ErrorResponse(c, http.StatusBadRequest, "invalid webhook payload")
 			return
 		}
+	default:
+		// Without a secret nothing can be verified, so nothing is accepted
+		logger.Get().Error("Rejecting Stripe webhook: STRIPE_WEBHOOK_SECRET is not configured")
+		common.ErrorResponse(c, http.StatusServiceUnavailable, "webhook verification is not configured")
+		return
+	}
 
-		eventType = event.Type
-
-		// Extract payment intent ID
-		if obj, ok := event.Data["object"].(map[string]interface{}); ok {
-			if id, ok := obj["id"].(string); ok {
-				paymentIntentID = id
-			}
-		}
+	if event.ID == "" || event.Type == "" || event.Data == nil || len(event.Data.Raw) == 0 {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid webhook payload")
+		return
 	}
 
-	err = h.service.HandleStripeWebhook(c.Request.Context(), eventType, paymentIntentID)
-	if err != nil {
+	if err := h.service.HandleStripeEvent(c.Request.Context(), &event, payload); err != nil {
 		logger.Get().Error("Failed to handle webhook event",
-			zap.String("event_type", eventType),
+			zap.String("event_id", event.ID),
+			zap.String("event_type", string(event.Type)),
 			zap.Error(err),
 		)
 		common.ErrorResponse(c, http.StatusInternalServerError, "failed to handle webhook")
@@ -398,6 +395,18 @@ func (h *Handler) HandleStripeWebhook(c *gin.Context) {
 	common.SuccessResponse(c, gin.H{"received": true})
 }
 
+// constructEvent verifies a webhook against each secret in turn
+func (h *Handler) constructEvent(payload []byte, sig string) (stripe.Event, error) {
+	var err error
+	for _, secret := range h.webhookSecrets {
+		var event stripe.Event
+		if event, err = webhook.ConstructEvent(payload, sig, secret); err == nil {
+			return event, nil
+		}
+	}
+	return stripe.Event{}, err
+}
+
 // ========================================
 // ADMIN ENDPOINTS
 // ========================================
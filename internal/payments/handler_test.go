@@ -17,6 +17,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stripe/stripe-go/v83"
+	"github.com/stripe/stripe-go/v83/webhook"
 )
 
 // MockRepository is a mock implementation of RepositoryInterface
@@ -94,6 +95,16 @@ func (m *MockRepository) GetRideDriverID(ctx context.Context, rideID uuid.UUID)
 	return args.Get(0).(*uuid.UUID), args.Error(1)
 }
 
+func (m *MockRepository) GetRideStatus(ctx context.Context, rideID uuid.UUID) (models.RideStatus, error) {
+	args := m.Called(ctx, rideID)
+	return args.Get(0).(models.RideStatus), args.Error(1)
+}
+
+func (m *MockRepository) GetRiderCard(ctx context.Context, riderID uuid.UUID) (string, string, error) {
+	args := m.Called(ctx, riderID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
 func (m *MockRepository) GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID) ([]*models.Payment, error) {
 	args := m.Called(ctx, rideID)
 	if args.Get(0) == nil {
@@ -112,6 +123,55 @@ func (m *MockRepository) GetDriverEarningsSummary(ctx context.Context, driverID
 	return args.Get(0).(float64), args.Get(1).(float64), args.Get(2).(float64), args.Error(3)
 }
 
+func (m *MockRepository) CreatePaymentHold(ctx context.Context, hold *models.PaymentHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPaymentHoldByRideID(ctx context.Context, rideID uuid.UUID) (*models.PaymentHold, error) {
+	args := m.Called(ctx, rideID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentHold), args.Error(1)
+}
+
+func (m *MockRepository) ClaimPaymentHold(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePaymentHold(ctx context.Context, hold *models.PaymentHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetPaymentHoldByPaymentIntent(ctx context.Context, paymentIntentID string) (*models.PaymentHold, error) {
+	args := m.Called(ctx, paymentIntentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentHold), args.Error(1)
+}
+
+func (m *MockRepository) GetPaymentByStripePaymentID(ctx context.Context, paymentIntentID string) (*models.Payment, error) {
+	args := m.Called(ctx, paymentIntentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockRepository) ClaimWebhookEvent(ctx context.Context, event *models.StripeWebhookEvent) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRepository) FinishWebhookEvent(ctx context.Context, eventID string, handleErr error) error {
+	args := m.Called(ctx, eventID, handleErr)
+	return args.Error(0)
+}
+
 func (m *MockRepository) GetAllPayments(ctx context.Context, limit, offset int, filter *AdminPaymentFilter) ([]*models.Payment, int64, error) {
 	args := m.Called(ctx, limit, offset, filter)
 	if args.Get(0) == nil {
@@ -165,6 +225,30 @@ func (m *MockStripeClient) CapturePaymentIntent(paymentIntentID string) (*stripe
 	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
 }
 
+func (m *MockStripeClient) CreateAuthorizationHold(amount int64, currency, customerID, paymentMethodID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	args := m.Called(amount, currency, customerID, paymentMethodID, description, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
+
+func (m *MockStripeClient) CapturePaymentIntentAmount(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	args := m.Called(paymentIntentID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
+
+func (m *MockStripeClient) IncrementAuthorization(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	args := m.Called(paymentIntentID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
+
 func (m *MockStripeClient) CreateRefund(chargeID string, amount *int64, reason string) (*stripe.Refund, error) {
 	args := m.Called(chargeID, amount, reason)
 	if args.Get(0) == nil {
@@ -181,6 +265,14 @@ func (m *MockStripeClient) CreateTransfer(amount int64, currency, destination, d
 	return args.Get(0).(*stripe.Transfer), args.Error(1)
 }
 
+func (m *MockStripeClient) ListTransfers(createdFrom, createdTo int64) ([]*stripe.Transfer, error) {
+	args := m.Called(createdFrom, createdTo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stripe.Transfer), args.Error(1)
+}
+
 func (m *MockStripeClient) GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
 	args := m.Called(paymentIntentID)
 	if args.Get(0) == nil {
@@ -278,6 +370,14 @@ func createTestHandler(mockRepo *MockRepository, mockStripe *MockStripeClient) *
 	return NewHandler(service)
 }
 
+// createUnsignedWebhookTestHandler accepts webhooks without a signature, as
+// in local development
+func createUnsignedWebhookTestHandler(mockRepo *MockRepository, mockStripe *MockStripeClient) *Handler {
+	handler := createTestHandler(mockRepo, mockStripe)
+	handler.SetAllowUnsignedWebhooks(true)
+	return handler
+}
+
 // ============================================================================
 // ProcessPayment Handler Tests
 // ============================================================================
@@ -331,6 +431,7 @@ func TestHandler_ProcessPayment_Success_Stripe(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideDriverID", mock.Anything, rideID).Return(&driverID, nil)
+	mockRepo.On("GetPaymentHoldByRideID", mock.Anything, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", int64(2500), "usd", "", mock.Anything, mock.Anything).Return(&stripe.PaymentIntent{
 		ID:     "pi_test_123",
 		Status: stripe.PaymentIntentStatusRequiresPaymentMethod,
@@ -657,6 +758,7 @@ func TestHandler_ProcessPayment_StripeError(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideDriverID", mock.Anything, rideID).Return(&driverID, nil)
+	mockRepo.On("GetPaymentHoldByRideID", mock.Anything, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", int64(2500), "usd", "", mock.Anything, mock.Anything).Return(nil, errors.New("stripe API error"))
 
 	c, w := setupTestContext("POST", "/api/v1/payments/process", reqBody)
@@ -685,6 +787,7 @@ func TestHandler_ProcessPayment_DatabaseError(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideDriverID", mock.Anything, rideID).Return(&driverID, nil)
+	mockRepo.On("GetPaymentHoldByRideID", mock.Anything, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", int64(2500), "usd", "", mock.Anything, mock.Anything).Return(&stripe.PaymentIntent{
 		ID:     "pi_test_123",
 		Status: stripe.PaymentIntentStatusRequiresPaymentMethod,
@@ -1767,21 +1870,54 @@ func TestHandler_RefundPayment_StripeError(t *testing.T) {
 // HandleStripeWebhook Handler Tests
 // ============================================================================
 
+// webhookEventBody builds a Stripe event delivery
+func webhookEventBody(eventID, eventType string, object map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          eventID,
+		"object":      "event",
+		"type":        eventType,
+		"api_version": stripe.APIVersion,
+		"data":        map[string]interface{}{"object": object},
+	}
+}
+
+// expectWebhookApplied expects the event to be claimed and finished without error
+func expectWebhookApplied(mockRepo *MockRepository, eventID string) {
+	mockRepo.On("ClaimWebhookEvent", mock.Anything, mock.MatchedBy(func(e *models.StripeWebhookEvent) bool {
+		return e.EventID == eventID
+	})).Return(true, nil).Once()
+	mockRepo.On("FinishWebhookEvent", mock.Anything, eventID, nil).Return(nil).Once()
+}
+
+// signedWebhookRequest signs body with secret as Stripe does at timestamp
+func signedWebhookRequest(t *testing.T, body map[string]interface{}, secret string, timestamp time.Time) (*gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	assert.NoError(t, err)
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   payload,
+		Secret:    secret,
+		Timestamp: timestamp,
+	})
+
+	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", nil)
+	c.Request = httptest.NewRequest("POST", "/api/v1/webhooks/stripe", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Request.Header.Set("Stripe-Signature", signed.Header)
+	return c, w
+}
+
 func TestHandler_HandleStripeWebhook_Success_PaymentSucceeded(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	expectWebhookApplied(mockRepo, "evt_1")
 
-	webhookBody := map[string]interface{}{
-		"type": "payment_intent.succeeded",
-		"data": map[string]interface{}{
-			"object": map[string]interface{}{
-				"id": "pi_test_123",
-			},
-		},
-	}
+	webhookBody := webhookEventBody("evt_1", "payment_intent.succeeded", map[string]interface{}{
+		"id": "pi_test_123",
+	})
 
 	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
 
@@ -1790,6 +1926,7 @@ func TestHandler_HandleStripeWebhook_Success_PaymentSucceeded(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	response := parseResponse(w)
 	assert.True(t, response["success"].(bool))
+	mockRepo.AssertExpectations(t)
 }
 
 func TestHandler_HandleStripeWebhook_Success_PaymentFailed(t *testing.T) {
@@ -1797,16 +1934,12 @@ func TestHandler_HandleStripeWebhook_Success_PaymentFailed(t *testing.T) {
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	expectWebhookApplied(mockRepo, "evt_1")
 
-	webhookBody := map[string]interface{}{
-		"type": "payment_intent.payment_failed",
-		"data": map[string]interface{}{
-			"object": map[string]interface{}{
-				"id": "pi_test_123",
-			},
-		},
-	}
+	webhookBody := webhookEventBody("evt_1", "payment_intent.payment_failed", map[string]interface{}{
+		"id": "pi_test_123",
+	})
 
 	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
 
@@ -1820,22 +1953,21 @@ func TestHandler_HandleStripeWebhook_Success_ChargeRefunded(t *testing.T) {
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	expectWebhookApplied(mockRepo, "evt_1")
+	mockRepo.On("GetPaymentByStripePaymentID", mock.Anything, "pi_test_123").Return(nil, nil)
 
-	webhookBody := map[string]interface{}{
-		"type": "charge.refunded",
-		"data": map[string]interface{}{
-			"object": map[string]interface{}{
-				"id": "ch_test_123",
-			},
-		},
-	}
+	webhookBody := webhookEventBody("evt_1", "charge.refunded", map[string]interface{}{
+		"id":             "ch_test_123",
+		"payment_intent": "pi_test_123",
+	})
 
 	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
 
 	handler.HandleStripeWebhook(c)
 
 	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
 }
 
 func TestHandler_HandleStripeWebhook_InvalidBody(t *testing.T) {
@@ -1843,7 +1975,7 @@ func TestHandler_HandleStripeWebhook_InvalidBody(t *testing.T) {
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
 
 	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", nil)
 	c.Request = httptest.NewRequest("POST", "/api/v1/webhooks/stripe", bytes.NewReader([]byte("invalid json")))
@@ -1861,16 +1993,12 @@ func TestHandler_HandleStripeWebhook_UnknownEventType(t *testing.T) {
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	expectWebhookApplied(mockRepo, "evt_1")
 
-	webhookBody := map[string]interface{}{
-		"type": "unknown.event",
-		"data": map[string]interface{}{
-			"object": map[string]interface{}{
-				"id": "test_123",
-			},
-		},
-	}
+	webhookBody := webhookEventBody("evt_1", "unknown.event", map[string]interface{}{
+		"id": "test_123",
+	})
 
 	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
 
@@ -1884,9 +2012,10 @@ func TestHandler_HandleStripeWebhook_EmptyData(t *testing.T) {
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
 
 	webhookBody := map[string]interface{}{
+		"id":   "evt_1",
 		"type": "payment_intent.succeeded",
 		"data": map[string]interface{}{},
 	}
@@ -1895,7 +2024,27 @@ func TestHandler_HandleStripeWebhook_EmptyData(t *testing.T) {
 
 	handler.HandleStripeWebhook(c)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "ClaimWebhookEvent", mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleStripeWebhook_MissingEventID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+
+	webhookBody := webhookEventBody("", "payment_intent.succeeded", map[string]interface{}{
+		"id": "pi_test_123",
+	})
+
+	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
+
+	handler.HandleStripeWebhook(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "ClaimWebhookEvent", mock.Anything, mock.Anything)
 }
 
 func TestHandler_HandleStripeWebhook_MissingPaymentIntentID(t *testing.T) {
@@ -1903,22 +2052,149 @@ func TestHandler_HandleStripeWebhook_MissingPaymentIntentID(t *testing.T) {
 
 	mockRepo := new(MockRepository)
 	mockStripe := new(MockStripeClient)
-	handler := createTestHandler(mockRepo, mockStripe)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	expectWebhookApplied(mockRepo, "evt_1")
 
-	webhookBody := map[string]interface{}{
-		"type": "payment_intent.succeeded",
-		"data": map[string]interface{}{
-			"object": map[string]interface{}{
-				"amount": 2500,
-			},
-		},
-	}
+	webhookBody := webhookEventBody("evt_1", "payment_intent.succeeded", map[string]interface{}{
+		"amount": 2500,
+	})
+
+	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
+
+	handler.HandleStripeWebhook(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestHandler_HandleStripeWebhook_Duplicate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	mockRepo.On("ClaimWebhookEvent", mock.Anything, mock.Anything).Return(false, nil).Once()
+
+	webhookBody := webhookEventBody("evt_1", "charge.refunded", map[string]interface{}{
+		"id":             "ch_test_123",
+		"payment_intent": "pi_test_123",
+	})
+
+	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
+
+	handler.HandleStripeWebhook(c)
+
+	// Redeliveries are acknowledged so Stripe stops retrying
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertNotCalled(t, "GetPaymentByStripePaymentID", mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleStripeWebhook_HandlingFailed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := createUnsignedWebhookTestHandler(mockRepo, mockStripe)
+	mockRepo.On("ClaimWebhookEvent", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mockRepo.On("GetPaymentByStripePaymentID", mock.Anything, "pi_test_123").Return(nil, errors.New("db error"))
+	mockRepo.On("FinishWebhookEvent", mock.Anything, "evt_1", mock.Anything).Return(nil).Once()
+
+	webhookBody := webhookEventBody("evt_1", "charge.refunded", map[string]interface{}{
+		"id":             "ch_test_123",
+		"payment_intent": "pi_test_123",
+	})
 
 	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", webhookBody)
 
 	handler.HandleStripeWebhook(c)
 
+	// A failed event is answered with an error so Stripe delivers it again
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_HandleStripeWebhook_ValidSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := NewHandlerWithWebhookSecret(NewService(mockRepo, mockStripe, nil), "whsec_platform", "whsec_connect")
+	expectWebhookApplied(mockRepo, "evt_1")
+
+	// Connect events are signed with the second endpoint's secret
+	body := webhookEventBody("evt_1", "payment_intent.succeeded", map[string]interface{}{"id": "pi_test_123"})
+	c, w := signedWebhookRequest(t, body, "whsec_connect", time.Now())
+
+	handler.HandleStripeWebhook(c)
+
 	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestHandler_HandleStripeWebhook_InvalidSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := NewHandlerWithWebhookSecret(NewService(mockRepo, mockStripe, nil), "whsec_platform")
+
+	body := webhookEventBody("evt_1", "payment_intent.succeeded", map[string]interface{}{"id": "pi_test_123"})
+	c, w := signedWebhookRequest(t, body, "whsec_other", time.Now())
+
+	handler.HandleStripeWebhook(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockRepo.AssertNotCalled(t, "ClaimWebhookEvent", mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleStripeWebhook_MissingSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := NewHandlerWithWebhookSecret(NewService(mockRepo, mockStripe, nil), "whsec_platform")
+
+	body := webhookEventBody("evt_1", "payment_intent.succeeded", map[string]interface{}{"id": "pi_test_123"})
+	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", body)
+
+	handler.HandleStripeWebhook(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockRepo.AssertNotCalled(t, "ClaimWebhookEvent", mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleStripeWebhook_NoSecretConfigured(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := createTestHandler(mockRepo, mockStripe)
+
+	// Unsigned events could refund payments and hold payouts, so without a
+	// secret they are refused unless explicitly allowed for development
+	body := webhookEventBody("evt_1", "charge.refunded", map[string]interface{}{"id": "ch_test_123", "payment_intent": "pi_test_123"})
+	c, w := setupTestContext("POST", "/api/v1/webhooks/stripe", body)
+
+	handler.HandleStripeWebhook(c)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	mockRepo.AssertNotCalled(t, "ClaimWebhookEvent", mock.Anything, mock.Anything)
+}
+
+func TestHandler_HandleStripeWebhook_ReplayedSignature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockRepo := new(MockRepository)
+	mockStripe := new(MockStripeClient)
+	handler := NewHandlerWithWebhookSecret(NewService(mockRepo, mockStripe, nil), "whsec_platform")
+
+	// A captured delivery replayed later is outside the signature tolerance
+	body := webhookEventBody("evt_1", "payment_intent.succeeded", map[string]interface{}{"id": "pi_test_123"})
+	c, w := signedWebhookRequest(t, body, "whsec_platform", time.Now().Add(-10*time.Minute))
+
+	handler.HandleStripeWebhook(c)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockRepo.AssertNotCalled(t, "ClaimWebhookEvent", mock.Anything, mock.Anything)
 }
 
 // ============================================================================
@@ -2076,6 +2352,7 @@ func TestHandler_ProcessPayment_DecimalPrecision(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideDriverID", mock.Anything, rideID).Return(&driverID, nil)
+	mockRepo.On("GetPaymentHoldByRideID", mock.Anything, rideID).Return(nil, nil)
 	// Verify correct conversion to cents (25.99 * 100 = 2599)
 	mockStripe.On("CreatePaymentIntent", int64(2599), "usd", "", mock.Anything, mock.Anything).Return(&stripe.PaymentIntent{
 		ID:     "pi_test_123",
@@ -2205,6 +2482,7 @@ func TestHandler_ProcessPayment_AllPaymentMethods(t *testing.T) {
 			if tt.paymentMethod == "wallet" {
 				mockRepo.On("ProcessPaymentWithWallet", mock.Anything, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("*models.WalletTransaction")).Return(nil)
 			} else {
+				mockRepo.On("GetPaymentHoldByRideID", mock.Anything, rideID).Return(nil, nil)
 				mockStripe.On("CreatePaymentIntent", int64(2500), "usd", "", mock.Anything, mock.Anything).Return(&stripe.PaymentIntent{
 					ID:     "pi_test_123",
 					Status: stripe.PaymentIntentStatusRequiresPaymentMethod,
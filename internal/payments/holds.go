@@ -0,0 +1,435 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stripe/stripe-go/v83"
+	"go.uber.org/zap"
+)
+
+// shortfallPaymentType marks the payment for the part of a fare its hold
+// could not cover
+const shortfallPaymentType = "hold_shortfall"
+
+// Payment metadata recording the part of a ride's fare settled before the
+// card was charged, in minor units of the payment's currency
+const (
+	coveredDiscountMetadata = "covered_discount"
+	coveredCreditMetadata   = "covered_credit"
+)
+
+// FareCoverage is the part of a ride's fare paid by discounts and gift card
+// credit rather than the rider's card, in major units of the fare's currency
+type FareCoverage struct {
+	Discount float64 // platform-funded promo, subscription and loyalty discounts
+	Credit   float64 // gift card balance
+}
+
+// metadata records the coverage on the payment that charged the rest of the
+// fare, so the ledger can post the gross fare
+func (c FareCoverage) metadata(currency string) map[string]interface{} {
+	return map[string]interface{}{
+		coveredDiscountMetadata: money.FromMajor(c.Discount, currency).Amount,
+		coveredCreditMetadata:   money.FromMajor(c.Credit, currency).Amount,
+	}
+}
+
+// paymentCoverage reads back the discounts and credit recorded on a payment.
+// Metadata loaded from the database holds JSON numbers as float64.
+func paymentCoverage(payment *models.Payment) (discount, credit money.Money) {
+	currency := payment.AmountMoney().Currency
+	minor := func(key string) int64 {
+		switch v := payment.Metadata[key].(type) {
+		case int64:
+			return v
+		case float64:
+			return int64(v)
+		}
+		return 0
+	}
+	return money.New(minor(coveredDiscountMetadata), currency), money.New(minor(coveredCreditMetadata), currency)
+}
+
+// holdsCard reports whether rides paid with the method get an authorization
+// hold at request time
+func holdsCard(paymentMethod string) bool {
+	return paymentMethod == "stripe" || paymentMethod == string(models.PaymentMethodCard)
+}
+
+// holdAmount is the amount to authorize for an estimated fare, rounded up so
+// the buffer is never short
+func holdAmount(estimate money.Money, bufferRate float64) money.Money {
+	return estimate.MulRate(1+bufferRate, money.RoundUp)
+}
+
+// isCardDecline reports whether err is the card being declined, as opposed
+// to Stripe being unavailable
+func isCardDecline(err error) bool {
+	var stripeErr *stripe.Error
+	return errors.As(err, &stripeErr) && stripeErr.Type == stripe.ErrorTypeCard
+}
+
+// AuthorizeRide places a hold on the rider's card for the estimated fare plus
+// the hold buffer, so a declined card surfaces before the trip. A ride keeps
+// its first hold, so a redelivered request returns it instead of holding
+// twice. A declined card is recorded as a failed hold and returned alongside
+// a payment-required error. Rides that have already ended, and riders without
+// a saved card, get no hold.
+func (s *Service) AuthorizeRide(ctx context.Context, rideID, riderID uuid.UUID, estimatedFare float64, currency string) (*models.PaymentHold, error) {
+	hold, err := s.repo.GetPaymentHoldByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if hold == nil {
+		// Requests, cancellations and completions arrive on separate
+		// consumers, so the ride may have ended before its request is handled
+		if ended, err := s.rideEnded(ctx, rideID); err != nil || ended {
+			return nil, err
+		}
+		customerID, paymentMethodID, err := s.repo.GetRiderCard(ctx, riderID)
+		if err != nil {
+			return nil, err
+		}
+		if customerID == "" || paymentMethodID == "" {
+			logger.Get().Info("Rider has no saved card, skipping authorization hold",
+				zap.String("ride_id", rideID.String()),
+				zap.String("rider_id", riderID.String()))
+			return nil, nil
+		}
+		hold, err = s.placeHold(ctx, rideID, riderID, customerID, paymentMethodID, money.FromMajor(estimatedFare, currency))
+		if err != nil || hold.Status != models.PaymentHoldAuthorized {
+			return hold, err
+		}
+	} else if hold.Status != models.PaymentHoldAuthorized {
+		return hold, nil
+	}
+
+	// A cancellation or completion handled while the hold was being placed
+	// found nothing to settle, so the hold is released here instead. One that
+	// ends the ride after this check finds the saved hold.
+	ended, err := s.rideEnded(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if ended {
+		if _, err := s.ReleaseRideHold(ctx, rideID, uuid.Nil, false); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+	return hold, nil
+}
+
+// placeHold authorizes the estimate plus the hold buffer on the rider's saved
+// card and saves the hold
+func (s *Service) placeHold(ctx context.Context, rideID, riderID uuid.UUID, customerID, paymentMethodID string, estimate money.Money) (*models.PaymentHold, error) {
+	amount := holdAmount(estimate, s.holdBufferRate)
+	hold := &models.PaymentHold{
+		ID:               uuid.New(),
+		RideID:           rideID,
+		RiderID:          riderID,
+		Currency:         estimate.Currency,
+		EstimatedAmount:  estimate.Major(),
+		AuthorizedAmount: amount.Major(),
+		Status:           models.PaymentHoldAuthorized,
+	}
+
+	metadata := map[string]string{
+		"ride_id":              rideID.String(),
+		"rider_id":             riderID.String(),
+		"type":                 "ride_hold",
+		idempotencyKeyMetadata: "hold:" + rideID.String(),
+	}
+	pi, err := s.stripeClient.CreateAuthorizationHold(
+		amount.Amount,
+		strings.ToLower(estimate.Currency),
+		customerID,
+		paymentMethodID,
+		fmt.Sprintf("Hold for ride %s", rideID),
+		metadata,
+	)
+	if err != nil {
+		if !isCardDecline(err) {
+			logger.Get().Error("Failed to create authorization hold", zap.Error(err), zap.String("ride_id", rideID.String()))
+			return nil, wrapStripeError(err, "failed to authorize payment")
+		}
+
+		reason := err.Error()
+		hold.Status = models.PaymentHoldFailed
+		hold.AuthorizedAmount = 0
+		hold.FailureReason = &reason
+		if err := s.repo.CreatePaymentHold(ctx, hold); err != nil {
+			return nil, err
+		}
+		logger.Get().Warn("Authorization hold declined", zap.String("ride_id", rideID.String()), zap.Error(err))
+		return hold, common.NewErrorWithCode(http.StatusPaymentRequired, common.ErrCodePaymentFailed, "card was declined", err)
+	}
+
+	hold.StripePaymentIntentID = &pi.ID
+	if err := s.repo.CreatePaymentHold(ctx, hold); err != nil {
+		return nil, err
+	}
+
+	logger.Get().Info("Authorization hold placed",
+		zap.String("ride_id", rideID.String()),
+		zap.String("stripe_pi", pi.ID),
+		zap.Float64("estimated_fare", hold.EstimatedAmount),
+		zap.Float64("authorized", hold.AuthorizedAmount))
+	return hold, nil
+}
+
+// rideEnded reports whether the ride has been completed or cancelled
+func (s *Service) rideEnded(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	status, err := s.repo.GetRideStatus(ctx, rideID)
+	if err != nil {
+		return false, err
+	}
+	return status == models.RideStatusCompleted || status == models.RideStatusCancelled, nil
+}
+
+// RecordCoveredFare posts a fare paid in full by discounts and gift card
+// credit to the ledger. No payment is made for such a ride, so the entry is
+// keyed by the ride.
+func (s *Service) RecordCoveredFare(ctx context.Context, rideID, driverID uuid.UUID, currency string, covered FareCoverage) {
+	p := s.withCoverage(ledger.RidePayment{RideID: rideID, DriverID: driverID},
+		money.FromMajor(covered.Discount, currency), money.FromMajor(covered.Credit, currency))
+	entry, err := ledger.NewCoveredFareEntry(p)
+	s.postToLedger(ctx, entry, err)
+}
+
+// CaptureRide captures the amount due from the ride's hold and records the
+// payment along with what discounts and gift card credit covered. When the fare outgrows the hold the authorization is incremented
+// first; cards that refuse the increment have the whole hold captured and
+// the difference charged separately; a failed charge of the difference is
+// returned so the redelivered completion charges it again. It returns nil
+// when the ride has no hold to capture, and the recorded payment when the
+// hold was already captured.
+func (s *Service) CaptureRide(ctx context.Context, rideID, driverID uuid.UUID, finalFare float64, covered FareCoverage) (*models.Payment, error) {
+	hold, err := s.repo.GetPaymentHoldByRideID(ctx, rideID)
+	if err != nil || hold == nil {
+		return nil, err
+	}
+
+	switch hold.Status {
+	case models.PaymentHoldCaptured:
+		if hold.PaymentID == nil {
+			return nil, nil
+		}
+		payment, err := s.repo.GetPaymentByID(ctx, *hold.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.chargeShortfall(ctx, hold, driverID, money.FromMajor(finalFare, hold.Currency)); err != nil {
+			return nil, err
+		}
+		return payment, nil
+	case models.PaymentHoldAuthorized, models.PaymentHoldProcessing:
+	default:
+		return nil, nil
+	}
+
+	claimed, err := s.repo.ClaimPaymentHold(ctx, hold.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, common.NewConflictError("ride payment is already being settled")
+	}
+
+	fare := money.FromMajor(finalFare, hold.Currency)
+	authorized := hold.AuthorizedMoney()
+	capture := fare
+	if fare.Amount > authorized.Amount {
+		if _, err := s.stripeClient.IncrementAuthorization(*hold.StripePaymentIntentID, fare.Amount); err != nil {
+			logger.Get().Warn("Incremental authorization refused, charging the difference separately",
+				zap.String("ride_id", rideID.String()),
+				zap.Float64("authorized", authorized.Major()),
+				zap.Float64("final_fare", fare.Major()),
+				zap.Error(err))
+			capture = authorized
+		} else {
+			hold.AuthorizedAmount = fare.Major()
+		}
+	}
+
+	payment, err := s.captureHold(ctx, hold, capture, driverID, covered.metadata(hold.Currency))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	hold.Status = models.PaymentHoldCaptured
+	hold.CapturedAmount = capture.Major()
+	hold.PaymentID = &payment.ID
+	hold.CapturedAt = &now
+	s.updateHold(ctx, hold)
+
+	if err := s.chargeShortfall(ctx, hold, driverID, fare); err != nil {
+		return nil, err
+	}
+
+	logger.Get().Info("Authorization hold captured",
+		zap.String("ride_id", rideID.String()),
+		zap.String("payment_id", payment.ID.String()),
+		zap.Float64("captured", capture.Major()),
+		zap.Float64("final_fare", fare.Major()))
+	return payment, nil
+}
+
+// chargeShortfall charges the part of the fare a captured hold did not cover
+// as a separate payment, unless it has already been charged
+func (s *Service) chargeShortfall(ctx context.Context, hold *models.PaymentHold, driverID uuid.UUID, fare money.Money) error {
+	captured := money.FromMajor(hold.CapturedAmount, hold.Currency)
+	if fare.Amount <= captured.Amount {
+		return nil
+	}
+
+	payments, err := s.repo.GetPaymentsByRideID(ctx, hold.RideID)
+	if err != nil {
+		return err
+	}
+	for _, p := range payments {
+		if p.Metadata["type"] == shortfallPaymentType {
+			return nil
+		}
+	}
+
+	shortfall := money.New(fare.Amount-captured.Amount, fare.Currency)
+	extra := &models.Payment{
+		ID:            uuid.New(),
+		RideID:        hold.RideID,
+		RiderID:       hold.RiderID,
+		DriverID:      driverID,
+		Amount:        shortfall.Major(),
+		Currency:      strings.ToLower(shortfall.Currency),
+		PaymentMethod: "stripe",
+		Status:        "pending",
+		Metadata:      map[string]interface{}{"type": shortfallPaymentType},
+	}
+	if _, err := s.processStripePayment(ctx, extra); err != nil {
+		logger.Get().Error("Failed to charge fare above hold",
+			zap.String("ride_id", hold.RideID.String()),
+			zap.Float64("amount", shortfall.Major()),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ReleaseRideHold releases a cancelled ride's hold. When chargeFee is set the
+// cancellation fee is captured from the hold for the driver and the rest is
+// released; the fee payment is returned. Rides without an open hold are
+// left alone.
+func (s *Service) ReleaseRideHold(ctx context.Context, rideID, driverID uuid.UUID, chargeFee bool) (*models.Payment, error) {
+	hold, err := s.repo.GetPaymentHoldByRideID(ctx, rideID)
+	if err != nil || hold == nil {
+		return nil, err
+	}
+	if hold.Status != models.PaymentHoldAuthorized && hold.Status != models.PaymentHoldProcessing {
+		return nil, nil
+	}
+
+	claimed, err := s.repo.ClaimPaymentHold(ctx, hold.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, common.NewConflictError("ride payment is already being settled")
+	}
+
+	var fee money.Money
+	if chargeFee {
+		fee, _ = money.FromMajor(hold.EstimatedAmount, hold.Currency).SplitRate(s.cancellationFeeRate)
+	}
+
+	var payment *models.Payment
+	if fee.IsPositive() {
+		payment, err = s.captureHold(ctx, hold, fee, driverID, map[string]interface{}{})
+		if err != nil {
+			return nil, err
+		}
+		hold.CapturedAmount = fee.Major()
+		hold.PaymentID = &payment.ID
+	} else if _, err := s.stripeClient.CancelPaymentIntent(*hold.StripePaymentIntentID); err != nil {
+		logger.Get().Error("Failed to release authorization hold", zap.Error(err), zap.String("ride_id", rideID.String()))
+		s.unclaimHold(ctx, hold)
+		return nil, wrapStripeError(err, "failed to release payment hold")
+	}
+
+	now := time.Now()
+	hold.Status = models.PaymentHoldReleased
+	hold.ReleasedAt = &now
+	s.updateHold(ctx, hold)
+
+	logger.Get().Info("Authorization hold released",
+		zap.String("ride_id", rideID.String()),
+		zap.Float64("cancellation_fee", fee.Major()))
+	return payment, nil
+}
+
+// captureHold captures amount from a claimed hold and records it as a
+// completed card payment with the given metadata. The hold is handed back for
+// a retry if Stripe refuses the capture.
+func (s *Service) captureHold(ctx context.Context, hold *models.PaymentHold, amount money.Money, driverID uuid.UUID, metadata map[string]interface{}) (*models.Payment, error) {
+	pi, err := s.stripeClient.CapturePaymentIntentAmount(*hold.StripePaymentIntentID, amount.Amount)
+	if err != nil {
+		logger.Get().Error("Failed to capture authorization hold", zap.Error(err), zap.String("ride_id", hold.RideID.String()))
+		s.unclaimHold(ctx, hold)
+		return nil, wrapStripeError(err, "failed to capture payment")
+	}
+
+	payment := &models.Payment{
+		ID:              uuid.New(),
+		RideID:          hold.RideID,
+		RiderID:         hold.RiderID,
+		DriverID:        driverID,
+		Amount:          amount.Major(),
+		Currency:        strings.ToLower(amount.Currency),
+		PaymentMethod:   "stripe",
+		Status:          "completed",
+		StripePaymentID: &pi.ID,
+		Metadata:        metadata,
+	}
+	if pi.LatestCharge != nil {
+		payment.StripeChargeID = &pi.LatestCharge.ID
+	}
+
+	// The money has moved, so a failed insert leaves the hold processing
+	// for finance to look at rather than handing it back to be captured again
+	if err := s.repo.CreatePayment(ctx, payment); err != nil {
+		logger.Get().Error("Failed to save captured payment", zap.Error(err), zap.String("stripe_pi", pi.ID))
+		return nil, err
+	}
+
+	entry, err := s.ridePaymentEntry(payment)
+	s.postToLedger(ctx, entry, err)
+	return payment, nil
+}
+
+// unclaimHold puts a hold whose capture or release failed back to authorized
+func (s *Service) unclaimHold(ctx context.Context, hold *models.PaymentHold) {
+	hold.Status = models.PaymentHoldAuthorized
+	s.updateHold(ctx, hold)
+}
+
+// updateHold saves a hold after the money movement it records has happened,
+// so a failed save is logged rather than returned
+func (s *Service) updateHold(ctx context.Context, hold *models.PaymentHold) {
+	if err := s.repo.UpdatePaymentHold(ctx, hold); err != nil {
+		logger.Get().Error("Failed to update payment hold",
+			zap.Error(err),
+			zap.String("hold_id", hold.ID.String()),
+			zap.String("status", string(hold.Status)))
+	}
+}
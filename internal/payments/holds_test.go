@@ -0,0 +1,434 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/richxcame/ride-hailing/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v83"
+)
+
+// newAuthorizedHold places a hold on the fake card and returns its record
+func newAuthorizedHold(t *testing.T, fake *mocks.FakeStripeClient, estimate, authorized float64) *models.PaymentHold {
+	t.Helper()
+	pi, err := fake.CreateAuthorizationHold(money.FromMajor(authorized, "USD").Amount, "usd", "cus_rider", "pm_card", "Hold", nil)
+	require.NoError(t, err)
+	return &models.PaymentHold{
+		ID:                    uuid.New(),
+		RideID:                uuid.New(),
+		RiderID:               uuid.New(),
+		StripePaymentIntentID: &pi.ID,
+		Currency:              "USD",
+		EstimatedAmount:       estimate,
+		AuthorizedAmount:      authorized,
+		Status:                models.PaymentHoldAuthorized,
+	}
+}
+
+func TestHoldAmount(t *testing.T) {
+	assert.Equal(t, money.New(2400, "USD"), holdAmount(money.New(2000, "USD"), 0.20))
+	// Rounded up: 10.01 * 1.2 = 12.012
+	assert.Equal(t, money.New(1202, "USD"), holdAmount(money.New(1001, "USD"), 0.20))
+	assert.Equal(t, money.New(1500, "JPY"), holdAmount(money.New(1000, "JPY"), 0.50))
+}
+
+func TestAuthorizeRide_PlacesHold(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	rideID, riderID := uuid.New(), uuid.New()
+
+	repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil).Once()
+	repo.On("GetRideStatus", ctx, rideID).Return(models.RideStatusRequested, nil).Twice()
+	repo.On("GetRiderCard", ctx, riderID).Return("cus_rider", "pm_card", nil).Once()
+	repo.On("CreatePaymentHold", ctx, mock.MatchedBy(func(h *models.PaymentHold) bool {
+		return h.RideID == rideID && h.Status == models.PaymentHoldAuthorized && h.StripePaymentIntentID != nil
+	})).Return(nil).Once()
+
+	hold, err := service.AuthorizeRide(ctx, rideID, riderID, 20.00, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 20.00, hold.EstimatedAmount)
+	assert.Equal(t, 24.00, hold.AuthorizedAmount)
+
+	pi := fake.PaymentIntent(*hold.StripePaymentIntentID)
+	require.NotNil(t, pi)
+	assert.Equal(t, stripe.PaymentIntentStatusRequiresCapture, pi.Status)
+	assert.Equal(t, int64(2400), pi.AmountCapturable)
+	assert.Equal(t, "cus_rider", pi.Customer.ID)
+	assert.Equal(t, "pm_card", pi.PaymentMethod.ID)
+	repo.AssertExpectations(t)
+}
+
+func TestAuthorizeRide_NoSavedCard(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	rideID, riderID := uuid.New(), uuid.New()
+
+	repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil).Once()
+	repo.On("GetRideStatus", ctx, rideID).Return(models.RideStatusRequested, nil).Once()
+	repo.On("GetRiderCard", ctx, riderID).Return("", "", nil).Once()
+
+	hold, err := service.AuthorizeRide(ctx, rideID, riderID, 20.00, "USD")
+	require.NoError(t, err)
+	assert.Nil(t, hold)
+	assert.Equal(t, 0, fake.PaymentIntents())
+	repo.AssertExpectations(t)
+}
+
+func TestAuthorizeRide_ExistingHold(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	existing := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, existing.RideID).Return(existing, nil).Once()
+	repo.On("GetRideStatus", ctx, existing.RideID).Return(models.RideStatusAccepted, nil).Once()
+
+	hold, err := service.AuthorizeRide(ctx, existing.RideID, existing.RiderID, 20.00, "USD")
+	require.NoError(t, err)
+	assert.Same(t, existing, hold)
+	assert.Equal(t, 1, fake.PaymentIntents())
+	repo.AssertExpectations(t)
+}
+
+func TestAuthorizeRide_RideAlreadyEnded(t *testing.T) {
+	for _, status := range []models.RideStatus{models.RideStatusCancelled, models.RideStatusCompleted} {
+		t.Run(string(status), func(t *testing.T) {
+			ctx := context.Background()
+			repo := new(mocks.MockPaymentsRepository)
+			fake := mocks.NewFakeStripeClient()
+			service := NewService(repo, fake, nil)
+			rideID := uuid.New()
+
+			repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil).Once()
+			repo.On("GetRideStatus", ctx, rideID).Return(status, nil).Once()
+
+			hold, err := service.AuthorizeRide(ctx, rideID, uuid.New(), 20.00, "USD")
+			require.NoError(t, err)
+			assert.Nil(t, hold)
+			assert.Equal(t, 0, fake.PaymentIntents())
+			repo.AssertNotCalled(t, "CreatePaymentHold", mock.Anything, mock.Anything)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuthorizeRide_ReleasesHoldWhenRideEndsWhilePlacing(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	rideID, riderID := uuid.New(), uuid.New()
+
+	var saved *models.PaymentHold
+	repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil).Once()
+	repo.On("GetRideStatus", ctx, rideID).Return(models.RideStatusRequested, nil).Once()
+	repo.On("GetRiderCard", ctx, riderID).Return("cus_rider", "pm_card", nil).Once()
+	var reload *mock.Call
+	repo.On("CreatePaymentHold", ctx, mock.AnythingOfType("*models.PaymentHold")).
+		Run(func(args mock.Arguments) {
+			saved = args.Get(1).(*models.PaymentHold)
+			reload.ReturnArguments = mock.Arguments{saved, nil}
+		}).
+		Return(nil).Once()
+	// The cancellation lands while Stripe places the hold
+	repo.On("GetRideStatus", ctx, rideID).Return(models.RideStatusCancelled, nil).Once()
+	reload = repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, mock.Anything).Return(true, nil).Once()
+	repo.On("UpdatePaymentHold", ctx, mock.MatchedBy(func(h *models.PaymentHold) bool {
+		return h.Status == models.PaymentHoldReleased
+	})).Return(nil).Once()
+
+	hold, err := service.AuthorizeRide(ctx, rideID, riderID, 20.00, "USD")
+	require.NoError(t, err)
+	assert.Nil(t, hold)
+
+	require.NotNil(t, saved)
+	pi := fake.PaymentIntent(*saved.StripePaymentIntentID)
+	require.NotNil(t, pi)
+	assert.Equal(t, stripe.PaymentIntentStatusCanceled, pi.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestAuthorizeRide_Declined(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	fake.DeclineHolds = true
+	service := NewService(repo, fake, nil)
+	rideID, riderID := uuid.New(), uuid.New()
+
+	repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil).Once()
+	repo.On("GetRideStatus", ctx, rideID).Return(models.RideStatusRequested, nil).Once()
+	repo.On("GetRiderCard", ctx, riderID).Return("cus_rider", "pm_card", nil).Once()
+	repo.On("CreatePaymentHold", ctx, mock.MatchedBy(func(h *models.PaymentHold) bool {
+		return h.Status == models.PaymentHoldFailed && h.FailureReason != nil && h.StripePaymentIntentID == nil
+	})).Return(nil).Once()
+
+	hold, err := service.AuthorizeRide(ctx, rideID, riderID, 20.00, "USD")
+	require.NotNil(t, hold)
+	assert.Equal(t, models.PaymentHoldFailed, hold.Status)
+
+	var appErr *common.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, http.StatusPaymentRequired, appErr.Code)
+	repo.AssertExpectations(t)
+}
+
+func TestCaptureRide_WithinHold(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+	driverID := uuid.New()
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	payment, err := service.CaptureRide(ctx, hold.RideID, driverID, 18.50, FareCoverage{})
+	require.NoError(t, err)
+	assert.Equal(t, 18.50, payment.Amount)
+	assert.Equal(t, "completed", payment.Status)
+	assert.Equal(t, driverID, payment.DriverID)
+	require.NotNil(t, payment.StripeChargeID)
+
+	assert.Equal(t, models.PaymentHoldCaptured, hold.Status)
+	assert.Equal(t, 18.50, hold.CapturedAmount)
+	assert.Equal(t, payment.ID, *hold.PaymentID)
+
+	// The rest of the hold is released
+	pi := fake.PaymentIntent(*hold.StripePaymentIntentID)
+	assert.Equal(t, stripe.PaymentIntentStatusSucceeded, pi.Status)
+	assert.Equal(t, int64(1850), pi.AmountReceived)
+	assert.Zero(t, pi.AmountCapturable)
+	repo.AssertExpectations(t)
+}
+
+func TestCaptureRide_PostsGrossFareWithCoverage(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	journal := &recordingLedger{}
+	service.SetLedger(journal)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	// A 20.00 fare with 3.00 off and 2.00 of gift card credit charges 15.00
+	_, err := service.CaptureRide(ctx, hold.RideID, uuid.New(), 15.00, FareCoverage{Discount: 3, Credit: 2})
+	require.NoError(t, err)
+
+	require.Len(t, journal.entries, 1)
+	amounts := map[ledger.AccountCode]int64{}
+	for _, p := range journal.entries[0].Postings {
+		amounts[p.AccountCode] += p.Amount.Amount
+	}
+	assert.Equal(t, int64(1500), amounts[ledger.AccountStripeClearing])
+	assert.Equal(t, int64(300), amounts[ledger.AccountPromoExpense])
+	assert.Equal(t, int64(200), amounts[ledger.AccountGiftCardLiability])
+	assert.Equal(t, int64(1600), amounts[ledger.AccountDriverPayable])
+	assert.Equal(t, int64(400), amounts[ledger.AccountPlatformRevenue])
+	repo.AssertExpectations(t)
+}
+
+func TestCaptureRide_IncrementsAuthorization(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	payment, err := service.CaptureRide(ctx, hold.RideID, uuid.New(), 30.00, FareCoverage{})
+	require.NoError(t, err)
+	assert.Equal(t, 30.00, payment.Amount)
+	assert.Equal(t, 30.00, hold.AuthorizedAmount)
+	assert.Equal(t, int64(3000), fake.PaymentIntent(*hold.StripePaymentIntentID).AmountReceived)
+	assert.Equal(t, 1, fake.PaymentIntents())
+	repo.AssertExpectations(t)
+}
+
+func TestCaptureRide_IncrementRefusedChargesDifference(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	fake.DeclineIncrements = true
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *models.Payment) bool { return p.Amount == 24 })).Return(nil).Once()
+	repo.On("GetPaymentsByRideID", ctx, hold.RideID).Return([]*models.Payment{}, nil).Once()
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *models.Payment) bool {
+		return p.Amount == 6 && p.Metadata["type"] == shortfallPaymentType
+	})).Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	payment, err := service.CaptureRide(ctx, hold.RideID, uuid.New(), 30.00, FareCoverage{})
+	require.NoError(t, err)
+	assert.Equal(t, 24.00, payment.Amount)
+	assert.Equal(t, 24.00, hold.CapturedAmount)
+	assert.Equal(t, int64(2400), fake.PaymentIntent(*hold.StripePaymentIntentID).AmountReceived)
+	assert.Equal(t, 2, fake.PaymentIntents())
+	repo.AssertExpectations(t)
+}
+
+func TestCaptureRide_RetriesFailedDifference(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	fake.DeclineIncrements = true
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+	driverID := uuid.New()
+
+	var captured *models.Payment
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Twice()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *models.Payment) bool { return p.Amount == 24 })).
+		Run(func(args mock.Arguments) { captured = args.Get(1).(*models.Payment) }).
+		Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+	repo.On("GetPaymentsByRideID", ctx, hold.RideID).Return([]*models.Payment{}, nil).Once()
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *models.Payment) bool { return p.Amount == 6 })).
+		Return(errors.New("db down")).Once()
+
+	payment, err := service.CaptureRide(ctx, hold.RideID, driverID, 30.00, FareCoverage{})
+	require.Error(t, err)
+	assert.Nil(t, payment)
+	assert.Equal(t, models.PaymentHoldCaptured, hold.Status)
+
+	// The redelivered completion charges the difference without capturing
+	// the hold again
+	repo.On("GetPaymentByID", ctx, captured.ID).Return(captured, nil).Once()
+	repo.On("GetPaymentsByRideID", ctx, hold.RideID).Return([]*models.Payment{captured}, nil).Once()
+	repo.On("CreatePayment", ctx, mock.MatchedBy(func(p *models.Payment) bool { return p.Amount == 6 })).Return(nil).Once()
+
+	payment, err = service.CaptureRide(ctx, hold.RideID, driverID, 30.00, FareCoverage{})
+	require.NoError(t, err)
+	assert.Same(t, captured, payment)
+	assert.Equal(t, int64(2400), fake.PaymentIntent(*hold.StripePaymentIntentID).AmountReceived)
+	repo.AssertExpectations(t)
+}
+
+func TestCaptureRide_AlreadySettling(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(false, nil).Once()
+
+	payment, err := service.CaptureRide(ctx, hold.RideID, uuid.New(), 18.50, FareCoverage{})
+	assert.Nil(t, payment)
+	var appErr *common.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, http.StatusConflict, appErr.Code)
+	assert.Equal(t, stripe.PaymentIntentStatusRequiresCapture, fake.PaymentIntent(*hold.StripePaymentIntentID).Status)
+	repo.AssertExpectations(t)
+}
+
+func TestProcessRidePayment_CapturesHold(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	payment, err := service.ProcessRidePayment(ctx, hold.RideID, hold.RiderID, uuid.New(), 21.00, "stripe")
+	require.NoError(t, err)
+	assert.Equal(t, *hold.StripePaymentIntentID, *payment.StripePaymentID)
+	assert.Equal(t, 1, fake.PaymentIntents())
+	repo.AssertExpectations(t)
+}
+
+func TestReleaseRideHold_ChargesCancellationFee(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+	driverID := uuid.New()
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	payment, err := service.ReleaseRideHold(ctx, hold.RideID, driverID, true)
+	require.NoError(t, err)
+	require.NotNil(t, payment)
+	assert.Equal(t, 2.00, payment.Amount)
+	assert.Equal(t, driverID, payment.DriverID)
+	assert.Equal(t, models.PaymentHoldReleased, hold.Status)
+	assert.Equal(t, 2.00, hold.CapturedAmount)
+	assert.NotNil(t, hold.ReleasedAt)
+	assert.Equal(t, int64(200), fake.PaymentIntent(*hold.StripePaymentIntentID).AmountReceived)
+	repo.AssertExpectations(t)
+}
+
+func TestReleaseRideHold_WithoutFee(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	fake := mocks.NewFakeStripeClient()
+	service := NewService(repo, fake, nil)
+	hold := newAuthorizedHold(t, fake, 20, 24)
+
+	repo.On("GetPaymentHoldByRideID", ctx, hold.RideID).Return(hold, nil).Once()
+	repo.On("ClaimPaymentHold", ctx, hold.ID).Return(true, nil).Once()
+	repo.On("UpdatePaymentHold", ctx, hold).Return(nil).Once()
+
+	payment, err := service.ReleaseRideHold(ctx, hold.RideID, uuid.Nil, false)
+	require.NoError(t, err)
+	assert.Nil(t, payment)
+	assert.Equal(t, models.PaymentHoldReleased, hold.Status)
+	assert.Equal(t, stripe.PaymentIntentStatusCanceled, fake.PaymentIntent(*hold.StripePaymentIntentID).Status)
+	repo.AssertExpectations(t)
+}
+
+func TestReleaseRideHold_SettledHoldIgnored(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, mocks.NewFakeStripeClient(), nil)
+	rideID := uuid.New()
+
+	repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(&models.PaymentHold{RideID: rideID, Status: models.PaymentHoldCaptured}, nil).Once()
+
+	payment, err := service.ReleaseRideHold(ctx, rideID, uuid.New(), true)
+	assert.NoError(t, err)
+	assert.Nil(t, payment)
+	repo.AssertExpectations(t)
+}
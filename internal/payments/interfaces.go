@@ -4,6 +4,9 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/disputes"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/stripe/stripe-go/v83"
 )
@@ -21,10 +24,31 @@ type RepositoryInterface interface {
 	GetWalletTransactions(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.WalletTransaction, error)
 	GetWalletTransactionsWithTotal(ctx context.Context, walletID uuid.UUID, limit, offset int) ([]*models.WalletTransaction, int64, error)
 	GetRideDriverID(ctx context.Context, rideID uuid.UUID) (*uuid.UUID, error)
+	GetRideStatus(ctx context.Context, rideID uuid.UUID) (models.RideStatus, error)
+	// GetRiderCard returns the Stripe customer and default saved card to hold
+	// rides on, or empty strings when the rider has none
+	GetRiderCard(ctx context.Context, riderID uuid.UUID) (customerID, paymentMethodID string, err error)
 	GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID) ([]*models.Payment, error)
 	RecordRideEarning(ctx context.Context, driverID, rideID uuid.UUID, grossAmount, commission, netAmount float64, description string) error
 	// GetDriverEarningsSummary returns (dailyEarnings, weeklyEarnings, pendingWithdrawals).
 	GetDriverEarningsSummary(ctx context.Context, driverID uuid.UUID) (daily, weekly, pending float64, err error)
+	CreatePaymentHold(ctx context.Context, hold *models.PaymentHold) error
+	// GetPaymentHoldByRideID returns nil when the ride has no hold
+	GetPaymentHoldByRideID(ctx context.Context, rideID uuid.UUID) (*models.PaymentHold, error)
+	// ClaimPaymentHold moves an authorized hold to processing; false means
+	// another worker is settling it or it is already settled
+	ClaimPaymentHold(ctx context.Context, id uuid.UUID) (bool, error)
+	UpdatePaymentHold(ctx context.Context, hold *models.PaymentHold) error
+	// GetPaymentHoldByPaymentIntent returns nil when no hold uses the intent
+	GetPaymentHoldByPaymentIntent(ctx context.Context, paymentIntentID string) (*models.PaymentHold, error)
+	// GetPaymentByStripePaymentID returns nil when no payment uses the intent
+	GetPaymentByStripePaymentID(ctx context.Context, paymentIntentID string) (*models.Payment, error)
+	// ClaimWebhookEvent stores a new event, or takes back one whose handling
+	// failed or stalled; false means it was already applied or is being applied
+	ClaimWebhookEvent(ctx context.Context, event *models.StripeWebhookEvent) (bool, error)
+	// FinishWebhookEvent marks a claimed event processed, or failed when
+	// handleErr is set
+	FinishWebhookEvent(ctx context.Context, eventID string, handleErr error) error
 }
 
 // AdminRepositoryInterface extends RepositoryInterface with admin-only methods
@@ -40,8 +64,37 @@ type StripeClientInterface interface {
 	CreatePaymentIntent(amount int64, currency, customerID, description string, metadata map[string]string) (*stripe.PaymentIntent, error)
 	ConfirmPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error)
 	CapturePaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error)
+	// CreateAuthorizationHold places a manual-capture hold on one of the
+	// customer's saved cards. Like CreateTransfer, it is idempotent when metadata carries an
+	// idempotency_key.
+	CreateAuthorizationHold(amount int64, currency, customerID, paymentMethodID, description string, metadata map[string]string) (*stripe.PaymentIntent, error)
+	CapturePaymentIntentAmount(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error)
+	IncrementAuthorization(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error)
 	CreateRefund(chargeID string, amount *int64, reason string) (*stripe.Refund, error)
+	// CreateTransfer sends an idempotent request when metadata carries an
+	// idempotency_key
 	CreateTransfer(amount int64, currency, destination, description string, metadata map[string]string) (*stripe.Transfer, error)
+	// ListTransfers returns the transfers created in [createdFrom, createdTo), Unix seconds
+	ListTransfers(createdFrom, createdTo int64) ([]*stripe.Transfer, error)
 	GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error)
 	CancelPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error)
 }
+
+// LedgerPoster records double-entry journal entries for money movements.
+// Implemented by ledger.Service.
+type LedgerPoster interface {
+	Post(ctx context.Context, entry *ledger.JournalEntry) error
+}
+
+// DisputeRecorder opens and resolves fare disputes for card chargebacks.
+// Implemented by disputes.Service.
+type DisputeRecorder interface {
+	OpenChargeback(ctx context.Context, cb *disputes.Chargeback) (*disputes.Dispute, error)
+	CloseChargeback(ctx context.Context, cb *disputes.Chargeback, won bool) (*disputes.Dispute, error)
+}
+
+// BankPayoutRecorder applies bank payouts from drivers' connected accounts.
+// Implemented by earnings.Service.
+type BankPayoutRecorder interface {
+	HandleProviderPayout(ctx context.Context, payout *earnings.ProviderPayout) error
+}
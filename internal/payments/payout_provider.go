@@ -0,0 +1,94 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stripe/stripe-go/v83"
+)
+
+// StripePayoutProvider pays driver payouts out to their Stripe Connect
+// accounts. It implements earnings.PayoutProvider.
+type StripePayoutProvider struct {
+	client StripeClientInterface
+}
+
+// NewStripePayoutProvider creates a payout provider over a Stripe client
+func NewStripePayoutProvider(client StripeClientInterface) *StripePayoutProvider {
+	return &StripePayoutProvider{client: client}
+}
+
+// Transfer creates the transfer for a payout. The payout's idempotency key is
+// sent with the request, so retrying a payout returns its first transfer.
+func (p *StripePayoutProvider) Transfer(_ context.Context, req *earnings.TransferRequest) (*earnings.ProviderTransfer, error) {
+	metadata := map[string]string{
+		"payout_id":            req.PayoutID.String(),
+		"driver_id":            req.DriverID.String(),
+		idempotencyKeyMetadata: req.IdempotencyKey,
+	}
+
+	t, err := p.client.CreateTransfer(req.Amount.Amount, strings.ToLower(req.Amount.Currency), req.Destination, req.Description, metadata)
+	if err != nil {
+		if isTransferRejection(err) {
+			return nil, fmt.Errorf("create transfer: %w: %w", earnings.ErrTransferRejected, err)
+		}
+		return nil, fmt.Errorf("create transfer: %w", err)
+	}
+	transfer := providerTransfer(t)
+	return &transfer, nil
+}
+
+// isTransferRejection reports whether Stripe refused a transfer outright, so
+// it is certain nothing was paid. Timeouts, rate limits, idempotency
+// conflicts and server errors are not: the transfer may have gone through.
+func isTransferRejection(err error) bool {
+	var stripeErr *stripe.Error
+	if !errors.As(err, &stripeErr) {
+		return false
+	}
+	switch stripeErr.Type {
+	case stripe.ErrorTypeCard:
+		return true
+	case stripe.ErrorTypeInvalidRequest:
+		return stripeErr.HTTPStatusCode >= 400 && stripeErr.HTTPStatusCode < 500 &&
+			stripeErr.HTTPStatusCode != 408 && stripeErr.HTTPStatusCode != 409 && stripeErr.HTTPStatusCode != 429
+	}
+	return false
+}
+
+// ListTransfers returns the transfers created in [from, to)
+func (p *StripePayoutProvider) ListTransfers(_ context.Context, from, to time.Time) ([]earnings.ProviderTransfer, error) {
+	transfers, err := p.client.ListTransfers(from.Unix(), to.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("list transfers: %w", err)
+	}
+
+	result := make([]earnings.ProviderTransfer, 0, len(transfers))
+	for _, t := range transfers {
+		result = append(result, providerTransfer(t))
+	}
+	return result, nil
+}
+
+// providerTransfer converts a Stripe transfer, whose amount is in minor units
+func providerTransfer(t *stripe.Transfer) earnings.ProviderTransfer {
+	transfer := earnings.ProviderTransfer{
+		ID:        t.ID,
+		Amount:    money.New(t.Amount, string(t.Currency)),
+		Reversed:  t.Reversed || t.AmountReversed > 0,
+		CreatedAt: time.Unix(t.Created, 0),
+	}
+	if t.Destination != nil {
+		transfer.Destination = t.Destination.ID
+	}
+	if id, err := uuid.Parse(t.Metadata["payout_id"]); err == nil {
+		transfer.PayoutID = &id
+	}
+	return transfer
+}
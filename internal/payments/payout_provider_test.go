@@ -0,0 +1,111 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v83"
+)
+
+func TestStripePayoutProvider_Transfer(t *testing.T) {
+	client := new(MockStripeClient)
+	provider := NewStripePayoutProvider(client)
+	payoutID, driverID := uuid.New(), uuid.New()
+
+	client.On("CreateTransfer", int64(12345), "usd", "acct_123", "Driver payout PAY-1", mock.MatchedBy(func(metadata map[string]string) bool {
+		return metadata["payout_id"] == payoutID.String() &&
+			metadata["driver_id"] == driverID.String() &&
+			metadata[idempotencyKeyMetadata] == "daily:key"
+	})).Return(&stripe.Transfer{
+		ID:       "tr_1",
+		Amount:   12345,
+		Currency: stripe.CurrencyUSD,
+		Created:  1772582400,
+		Metadata: map[string]string{"payout_id": payoutID.String()},
+	}, nil).Once()
+
+	transfer, err := provider.Transfer(context.Background(), &earnings.TransferRequest{
+		PayoutID:       payoutID,
+		DriverID:       driverID,
+		Amount:         money.New(12345, "USD"),
+		Destination:    "acct_123",
+		Description:    "Driver payout PAY-1",
+		IdempotencyKey: "daily:key",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tr_1", transfer.ID)
+	assert.Equal(t, money.New(12345, "USD"), transfer.Amount)
+	require.NotNil(t, transfer.PayoutID)
+	assert.Equal(t, payoutID, *transfer.PayoutID)
+	client.AssertExpectations(t)
+}
+
+func TestStripePayoutProvider_TransferError(t *testing.T) {
+	client := new(MockStripeClient)
+	provider := NewStripePayoutProvider(client)
+
+	client.On("CreateTransfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, errors.New("insufficient funds")).Once()
+
+	transfer, err := provider.Transfer(context.Background(), &earnings.TransferRequest{Amount: money.New(1000, "USD")})
+	assert.Nil(t, transfer)
+	assert.ErrorContains(t, err, "insufficient funds")
+}
+
+func TestStripePayoutProvider_TransferRejection(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		rejected bool
+	}{
+		{"invalid request", &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, HTTPStatusCode: 400}, true},
+		{"card error", &stripe.Error{Type: stripe.ErrorTypeCard, HTTPStatusCode: 402}, true},
+		{"rate limited", &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, HTTPStatusCode: 429}, false},
+		{"idempotency conflict", &stripe.Error{Type: stripe.ErrorTypeIdempotency, HTTPStatusCode: 409}, false},
+		{"server error", &stripe.Error{Type: stripe.ErrorTypeAPI, HTTPStatusCode: 500}, false},
+		{"timeout", context.DeadlineExceeded, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := new(MockStripeClient)
+			provider := NewStripePayoutProvider(client)
+			client.On("CreateTransfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return(nil, tt.err).Once()
+
+			_, err := provider.Transfer(context.Background(), &earnings.TransferRequest{Amount: money.New(1000, "USD")})
+			require.Error(t, err)
+			assert.Equal(t, tt.rejected, errors.Is(err, earnings.ErrTransferRejected))
+		})
+	}
+}
+
+func TestStripePayoutProvider_ListTransfers(t *testing.T) {
+	client := new(MockStripeClient)
+	provider := NewStripePayoutProvider(client)
+	from := time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	client.On("ListTransfers", from.Unix(), to.Unix()).Return([]*stripe.Transfer{
+		{ID: "tr_1", Amount: 5000, Currency: stripe.CurrencyUSD, Destination: &stripe.Account{ID: "acct_1"}},
+		{ID: "tr_2", Amount: 2000, AmountReversed: 2000, Currency: stripe.CurrencyUSD, Metadata: map[string]string{"payout_id": "not-a-uuid"}},
+	}, nil).Once()
+
+	transfers, err := provider.ListTransfers(context.Background(), from, to)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	assert.Equal(t, money.New(5000, "USD"), transfers[0].Amount)
+	assert.Equal(t, "acct_1", transfers[0].Destination)
+	assert.False(t, transfers[0].Reversed)
+	assert.True(t, transfers[1].Reversed)
+	assert.Nil(t, transfers[1].PayoutID)
+	client.AssertExpectations(t)
+}
@@ -6,10 +6,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/database"
 	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 type Repository struct {
@@ -22,10 +24,13 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 
 // CreatePayment creates a new payment record
 func (r *Repository) CreatePayment(ctx context.Context, payment *models.Payment) error {
+	amount := payment.AmountMoney()
+	payment.Amount = amount.Major()
+
 	query := `
-		INSERT INTO payments (id, ride_id, rider_id, driver_id, amount, currency,
-			payment_method, status, stripe_payment_id, stripe_charge_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO payments (id, ride_id, rider_id, driver_id, amount, amount_minor, currency,
+			payment_method, status, stripe_payment_id, stripe_charge_id, metadata)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING created_at, updated_at`
 
 	err := r.db.QueryRow(ctx, query,
@@ -34,11 +39,13 @@ func (r *Repository) CreatePayment(ctx context.Context, payment *models.Payment)
 		payment.RiderID,
 		payment.DriverID,
 		payment.Amount,
+		amount.Amount,
 		payment.Currency,
 		payment.PaymentMethod,
 		payment.Status,
 		payment.StripePaymentID,
 		payment.StripeChargeID,
+		payment.Metadata,
 	).Scan(&payment.CreatedAt, &payment.UpdatedAt)
 
 	if err != nil {
@@ -51,8 +58,9 @@ func (r *Repository) CreatePayment(ctx context.Context, payment *models.Payment)
 // GetPaymentByID retrieves a payment by ID
 func (r *Repository) GetPaymentByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
 	payment := &models.Payment{}
+	var amountMinor int64
 	query := `
-		SELECT id, ride_id, rider_id, driver_id, amount, currency, payment_method,
+		SELECT id, ride_id, rider_id, driver_id, amount_minor, currency, payment_method,
 			status, stripe_payment_id, stripe_charge_id, metadata,
 			created_at, updated_at
 		FROM payments
@@ -63,7 +71,7 @@ func (r *Repository) GetPaymentByID(ctx context.Context, id uuid.UUID) (*models.
 		&payment.RideID,
 		&payment.RiderID,
 		&payment.DriverID,
-		&payment.Amount,
+		&amountMinor,
 		&payment.Currency,
 		&payment.PaymentMethod,
 		&payment.Status,
@@ -77,6 +85,7 @@ func (r *Repository) GetPaymentByID(ctx context.Context, id uuid.UUID) (*models.
 	if err != nil {
 		return nil, common.NewNotFoundError("payment not found", err)
 	}
+	payment.Amount = money.New(amountMinor, payment.Currency).Major()
 
 	return payment, nil
 }
@@ -106,10 +115,43 @@ func (r *Repository) GetRideDriverID(ctx context.Context, rideID uuid.UUID) (*uu
 	return driverID, nil
 }
 
+// GetRideStatus retrieves the current status of a ride
+func (r *Repository) GetRideStatus(ctx context.Context, rideID uuid.UUID) (models.RideStatus, error) {
+	var status models.RideStatus
+	err := r.db.QueryRow(ctx, "SELECT status FROM rides WHERE id = $1", rideID).Scan(&status)
+	if err != nil {
+		return "", common.NewNotFoundError("ride not found", err)
+	}
+	return status, nil
+}
+
+// GetRiderCard retrieves the rider's Stripe customer and the Stripe payment
+// method of their default active card
+func (r *Repository) GetRiderCard(ctx context.Context, riderID uuid.UUID) (string, string, error) {
+	var customerID, paymentMethodID string
+	err := r.db.QueryRow(ctx, `
+		SELECT u.stripe_customer_id, pm.provider_id
+		FROM users u
+		JOIN payment_methods pm ON pm.user_id = u.id
+		WHERE u.id = $1
+			AND u.stripe_customer_id IS NOT NULL
+			AND pm.type = 'card' AND pm.is_default = true AND pm.is_active = true
+			AND pm.provider_type = 'stripe' AND pm.provider_id IS NOT NULL`,
+		riderID,
+	).Scan(&customerID, &paymentMethodID)
+	if err == pgx.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", common.NewInternalError("failed to get rider card", err)
+	}
+	return customerID, paymentMethodID, nil
+}
+
 // GetPaymentsByRideID retrieves payments for a specific ride
 func (r *Repository) GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID) ([]*models.Payment, error) {
 	query := `
-		SELECT id, ride_id, rider_id, driver_id, amount, currency, payment_method,
+		SELECT id, ride_id, rider_id, driver_id, amount_minor, currency, payment_method,
 			status, stripe_payment_id, stripe_charge_id, metadata,
 			created_at, updated_at
 		FROM payments
@@ -125,12 +167,13 @@ func (r *Repository) GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID)
 	payments := make([]*models.Payment, 0)
 	for rows.Next() {
 		payment := &models.Payment{}
+		var amountMinor int64
 		err := rows.Scan(
 			&payment.ID,
 			&payment.RideID,
 			&payment.RiderID,
 			&payment.DriverID,
-			&payment.Amount,
+			&amountMinor,
 			&payment.Currency,
 			&payment.PaymentMethod,
 			&payment.Status,
@@ -143,6 +186,7 @@ func (r *Repository) GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID)
 		if err != nil {
 			return nil, common.NewInternalError("failed to scan payment", err)
 		}
+		payment.Amount = money.New(amountMinor, payment.Currency).Major()
 		payments = append(payments, payment)
 	}
 
@@ -154,15 +198,16 @@ func (r *Repository) GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID)
 // GetWalletByUserID retrieves a user's wallet
 func (r *Repository) GetWalletByUserID(ctx context.Context, userID uuid.UUID) (*models.Wallet, error) {
 	wallet := &models.Wallet{}
+	var balanceMinor int64
 	query := `
-		SELECT id, user_id, balance, currency, is_active, created_at, updated_at
+		SELECT id, user_id, balance_minor, currency, is_active, created_at, updated_at
 		FROM wallets
 		WHERE user_id = $1`
 
 	err := r.db.QueryRow(ctx, query, userID).Scan(
 		&wallet.ID,
 		&wallet.UserID,
-		&wallet.Balance,
+		&balanceMinor,
 		&wallet.Currency,
 		&wallet.IsActive,
 		&wallet.CreatedAt,
@@ -172,21 +217,26 @@ func (r *Repository) GetWalletByUserID(ctx context.Context, userID uuid.UUID) (*
 	if err != nil {
 		return nil, common.NewNotFoundError("wallet not found", err)
 	}
+	wallet.Balance = money.New(balanceMinor, wallet.Currency).Major()
 
 	return wallet, nil
 }
 
 // CreateWallet creates a new wallet for a user
 func (r *Repository) CreateWallet(ctx context.Context, wallet *models.Wallet) error {
+	balance := wallet.BalanceMoney()
+	wallet.Balance = balance.Major()
+
 	query := `
-		INSERT INTO wallets (id, user_id, balance, currency, is_active)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO wallets (id, user_id, balance, balance_minor, currency, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING created_at, updated_at`
 
 	err := r.db.QueryRow(ctx, query,
 		wallet.ID,
 		wallet.UserID,
 		wallet.Balance,
+		balance.Amount,
 		wallet.Currency,
 		wallet.IsActive,
 	).Scan(&wallet.CreatedAt, &wallet.UpdatedAt)
@@ -198,15 +248,19 @@ func (r *Repository) CreateWallet(ctx context.Context, wallet *models.Wallet) er
 	return nil
 }
 
-// UpdateWalletBalance updates wallet balance atomically
+// UpdateWalletBalance updates wallet balance atomically. The amount is
+// converted to the wallet currency's minor units (half away from zero) and
+// added to balance_minor; the legacy balance column is derived from it.
 func (r *Repository) UpdateWalletBalance(ctx context.Context, walletID uuid.UUID, amount float64) error {
 	query := `
 		UPDATE wallets
-		SET balance = balance + $1, updated_at = NOW()
+		SET balance_minor = balance_minor + to_minor_units($1, currency),
+			balance = from_minor_units(balance_minor + to_minor_units($1, currency), currency),
+			updated_at = NOW()
 		WHERE id = $2 AND is_active = true
-		RETURNING balance`
+		RETURNING balance_minor`
 
-	var newBalance float64
+	var newBalance int64
 	err := r.db.QueryRow(ctx, query, amount, walletID).Scan(&newBalance)
 	if err != nil {
 		return common.NewInternalError("failed to update wallet balance", err)
@@ -220,12 +274,17 @@ func (r *Repository) UpdateWalletBalance(ctx context.Context, walletID uuid.UUID
 	return nil
 }
 
-// CreateWalletTransaction creates a wallet transaction record
+// CreateWalletTransaction creates a wallet transaction record. Minor-unit
+// columns are derived using the wallet's currency.
 func (r *Repository) CreateWalletTransaction(ctx context.Context, tx *models.WalletTransaction) error {
 	query := `
+		WITH w AS (SELECT COALESCE((SELECT currency FROM wallets WHERE id = $2), 'USD') AS currency)
 		INSERT INTO wallet_transactions (id, wallet_id, type, amount, description,
-			reference_type, reference_id, balance_before, balance_after)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			reference_type, reference_id, balance_before, balance_after,
+			amount_minor, balance_before_minor, balance_after_minor)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9,
+			to_minor_units($4, w.currency), to_minor_units($8, w.currency), to_minor_units($9, w.currency)
+		FROM w
 		RETURNING created_at`
 
 	err := r.db.QueryRow(ctx, query,
@@ -254,11 +313,13 @@ func (r *Repository) GetWalletTransactions(ctx context.Context, walletID uuid.UU
 	// New schema columns: id, user_id, payment_method_id, type, amount, balance_before,
 	//                     balance_after, description, ride_id, reference_id, created_at
 	query := `
-		SELECT id, user_id, type, amount, description, reference_id,
-			balance_before, balance_after, created_at, ride_id
-		FROM wallet_transactions
-		WHERE user_id = $1
-		ORDER BY created_at DESC
+		SELECT wt.id, wt.user_id, wt.type, wt.amount_minor, wt.description, wt.reference_id,
+			wt.balance_before_minor, wt.balance_after_minor, wt.created_at, wt.ride_id,
+			pm.currency
+		FROM wallet_transactions wt
+		JOIN payment_methods pm ON pm.id = wt.payment_method_id
+		WHERE wt.user_id = $1
+		ORDER BY wt.created_at DESC
 		LIMIT $2 OFFSET $3`
 
 	rows, err := r.db.Query(ctx, query, walletID, limit, offset)
@@ -272,21 +333,27 @@ func (r *Repository) GetWalletTransactions(ctx context.Context, walletID uuid.UU
 		tx := &models.WalletTransaction{}
 		var referenceID *string
 		var rideID *uuid.UUID
+		var amountMinor, beforeMinor, afterMinor int64
+		var currency string
 		err := rows.Scan(
 			&tx.ID,
 			&tx.WalletID, // Actually user_id in new schema, but we keep field name for compatibility
 			&tx.Type,
-			&tx.Amount,
+			&amountMinor,
 			&tx.Description,
 			&referenceID, // VARCHAR(255) in new schema
-			&tx.BalanceBefore,
-			&tx.BalanceAfter,
+			&beforeMinor,
+			&afterMinor,
 			&tx.CreatedAt,
 			&rideID,
+			&currency,
 		)
 		if err != nil {
 			return nil, common.NewInternalError("failed to scan wallet transaction", err)
 		}
+		tx.Amount = money.New(amountMinor, currency).Major()
+		tx.BalanceBefore = money.New(beforeMinor, currency).Major()
+		tx.BalanceAfter = money.New(afterMinor, currency).Major()
 		// Set reference type and ID based on ride_id or reference_id
 		if rideID != nil {
 			tx.ReferenceType = "ride"
@@ -373,7 +440,7 @@ func (r *Repository) GetAllPayments(ctx context.Context, limit, offset int, filt
 	}
 
 	query := fmt.Sprintf(`
-		SELECT p.id, p.ride_id, p.rider_id, p.driver_id, p.amount, p.currency,
+		SELECT p.id, p.ride_id, p.rider_id, p.driver_id, p.amount_minor, p.currency,
 			p.payment_method, p.status, p.stripe_payment_id, p.stripe_charge_id,
 			p.metadata, p.created_at, p.updated_at
 		FROM payments p
@@ -392,15 +459,17 @@ func (r *Repository) GetAllPayments(ctx context.Context, limit, offset int, filt
 	payments := make([]*models.Payment, 0)
 	for rows.Next() {
 		payment := &models.Payment{}
+		var amountMinor int64
 		err := rows.Scan(
 			&payment.ID, &payment.RideID, &payment.RiderID, &payment.DriverID,
-			&payment.Amount, &payment.Currency, &payment.PaymentMethod,
+			&amountMinor, &payment.Currency, &payment.PaymentMethod,
 			&payment.Status, &payment.StripePaymentID, &payment.StripeChargeID,
 			&payment.Metadata, &payment.CreatedAt, &payment.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, common.NewInternalError("failed to scan payment", err)
 		}
+		payment.Amount = money.New(amountMinor, payment.Currency).Major()
 		payments = append(payments, payment)
 	}
 
@@ -483,48 +552,60 @@ func (r *Repository) ProcessPaymentWithWallet(ctx context.Context, payment *mode
 	}
 
 	// Get current wallet balance
-	var currentBalance float64
+	var balanceMinor int64
 	var walletID uuid.UUID
-	query := `SELECT id, balance FROM wallets WHERE user_id = $1 AND is_active = true FOR UPDATE`
-	err = tx.QueryRow(ctx, query, payment.RiderID).Scan(&walletID, &currentBalance)
+	var walletCurrency string
+	query := `SELECT id, balance_minor, currency FROM wallets WHERE user_id = $1 AND is_active = true FOR UPDATE`
+	err = tx.QueryRow(ctx, query, payment.RiderID).Scan(&walletID, &balanceMinor, &walletCurrency)
 	if err != nil {
 		return common.NewNotFoundError("wallet not found", err)
 	}
 
+	amount := payment.AmountMoney()
+	currentBalance := money.New(balanceMinor, walletCurrency)
+	if !amount.SameCurrency(currentBalance) {
+		return common.NewBadRequestError("payment currency does not match wallet currency", nil)
+	}
+
 	// Check sufficient balance
-	if currentBalance < payment.Amount {
+	newBalance, _ := currentBalance.Sub(amount)
+	if newBalance.IsNegative() {
 		return common.NewBadRequestError("insufficient wallet balance", nil)
 	}
 
 	// Update wallet balance
-	newBalance := currentBalance - payment.Amount
-	_, err = tx.Exec(ctx, `UPDATE wallets SET balance = $1, updated_at = NOW() WHERE id = $2`, newBalance, walletID)
+	_, err = tx.Exec(ctx, `UPDATE wallets SET balance = $1, balance_minor = $2, updated_at = NOW() WHERE id = $3`,
+		newBalance.Major(), newBalance.Amount, walletID)
 	if err != nil {
 		return common.NewInternalError("failed to update wallet balance", err)
 	}
 
 	// Create payment record
+	payment.Amount = amount.Major()
 	_, err = tx.Exec(ctx, `
-		INSERT INTO payments (id, ride_id, rider_id, driver_id, amount, currency,
+		INSERT INTO payments (id, ride_id, rider_id, driver_id, amount, amount_minor, currency,
 			payment_method, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())`,
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())`,
 		payment.ID, payment.RideID, payment.RiderID, payment.DriverID,
-		payment.Amount, payment.Currency, payment.PaymentMethod, "completed")
+		payment.Amount, amount.Amount, payment.Currency, payment.PaymentMethod, "completed")
 	if err != nil {
 		return common.NewInternalError("failed to create payment", err)
 	}
 
 	// Create wallet transaction record
 	walletTx.WalletID = walletID
-	walletTx.BalanceBefore = currentBalance
-	walletTx.BalanceAfter = newBalance
+	walletTx.Amount = amount.Major()
+	walletTx.BalanceBefore = currentBalance.Major()
+	walletTx.BalanceAfter = newBalance.Major()
 	_, err = tx.Exec(ctx, `
 		INSERT INTO wallet_transactions (id, wallet_id, type, amount, description,
-			reference_type, reference_id, balance_before, balance_after, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())`,
+			reference_type, reference_id, balance_before, balance_after,
+			amount_minor, balance_before_minor, balance_after_minor, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())`,
 		walletTx.ID, walletTx.WalletID, walletTx.Type, walletTx.Amount,
 		walletTx.Description, walletTx.ReferenceType, walletTx.ReferenceID,
-		walletTx.BalanceBefore, walletTx.BalanceAfter)
+		walletTx.BalanceBefore, walletTx.BalanceAfter,
+		amount.Amount, currentBalance.Amount, newBalance.Amount)
 	if err != nil {
 		return common.NewInternalError("failed to create wallet transaction", err)
 	}
@@ -544,14 +625,22 @@ func (r *Repository) ProcessPaymentWithWallet(ctx context.Context, payment *mode
 // RecordRideEarning inserts a ride_fare row into driver_earnings.
 // Uses ON CONFLICT DO NOTHING so re-delivered NATS events are safe.
 func (r *Repository) RecordRideEarning(ctx context.Context, driverID, rideID uuid.UUID, grossAmount, commission, netAmount float64, description string) error {
+	gross := money.FromMajor(grossAmount, "USD")
+	comm := money.FromMajor(commission, "USD")
+	net := money.FromMajor(netAmount, "USD")
+
 	_, err := r.db.Exec(ctx, `
 		INSERT INTO driver_earnings (
 			id, driver_id, ride_id, type,
 			gross_amount, commission, net_amount,
+			gross_amount_minor, commission_minor, net_amount_minor,
 			currency, description, is_paid_out, created_at
-		) VALUES ($1, $2, $3, 'ride_fare', $4, $5, $6, 'USD', $7, false, NOW())
+		) VALUES ($1, $2, $3, 'ride_fare', $4, $5, $6, $7, $8, $9, 'USD', $10, false, NOW())
 		ON CONFLICT (ride_id, type) WHERE ride_id IS NOT NULL DO NOTHING`,
-		uuid.New(), driverID, rideID, grossAmount, commission, netAmount, description,
+		uuid.New(), driverID, rideID,
+		gross.Major(), comm.Major(), net.Major(),
+		gross.Amount, comm.Amount, net.Amount,
+		description,
 	)
 	return err
 }
@@ -569,3 +658,176 @@ func (r *Repository) GetDriverEarningsSummary(ctx context.Context, driverID uuid
 	`, driverID).Scan(&daily, &weekly, &pending)
 	return
 }
+
+// CreatePaymentHold records a ride's authorization hold. A ride has at most
+// one hold; a concurrent duplicate for the same ride is ignored.
+func (r *Repository) CreatePaymentHold(ctx context.Context, hold *models.PaymentHold) error {
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO payment_holds (id, ride_id, rider_id, stripe_payment_intent_id, currency,
+			estimated_amount_minor, authorized_amount_minor, status, failure_reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (ride_id) DO NOTHING
+		RETURNING created_at, updated_at`,
+		hold.ID, hold.RideID, hold.RiderID, hold.StripePaymentIntentID, hold.Currency,
+		money.FromMajor(hold.EstimatedAmount, hold.Currency).Amount,
+		hold.AuthorizedMoney().Amount,
+		hold.Status, hold.FailureReason,
+	).Scan(&hold.CreatedAt, &hold.UpdatedAt)
+	if err != nil && err != pgx.ErrNoRows {
+		return common.NewInternalError("failed to create payment hold", err)
+	}
+	return nil
+}
+
+// GetPaymentHoldByRideID retrieves a ride's hold, or nil if it has none
+func (r *Repository) GetPaymentHoldByRideID(ctx context.Context, rideID uuid.UUID) (*models.PaymentHold, error) {
+	return r.getPaymentHold(ctx, "ride_id = $1", rideID)
+}
+
+// GetPaymentHoldByPaymentIntent retrieves the hold placed with a Stripe
+// payment intent, or nil if there is none
+func (r *Repository) GetPaymentHoldByPaymentIntent(ctx context.Context, paymentIntentID string) (*models.PaymentHold, error) {
+	return r.getPaymentHold(ctx, "stripe_payment_intent_id = $1", paymentIntentID)
+}
+
+func (r *Repository) getPaymentHold(ctx context.Context, where string, arg interface{}) (*models.PaymentHold, error) {
+	hold := &models.PaymentHold{}
+	var estimated, authorized, captured int64
+	err := r.db.QueryRow(ctx, `
+		SELECT id, ride_id, rider_id, stripe_payment_intent_id, currency,
+			estimated_amount_minor, authorized_amount_minor, captured_amount_minor,
+			status, payment_id, failure_reason, captured_at, released_at,
+			created_at, updated_at
+		FROM payment_holds
+		WHERE `+where, arg,
+	).Scan(
+		&hold.ID, &hold.RideID, &hold.RiderID, &hold.StripePaymentIntentID, &hold.Currency,
+		&estimated, &authorized, &captured,
+		&hold.Status, &hold.PaymentID, &hold.FailureReason, &hold.CapturedAt, &hold.ReleasedAt,
+		&hold.CreatedAt, &hold.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewInternalError("failed to get payment hold", err)
+	}
+	hold.EstimatedAmount = money.New(estimated, hold.Currency).Major()
+	hold.AuthorizedAmount = money.New(authorized, hold.Currency).Major()
+	hold.CapturedAmount = money.New(captured, hold.Currency).Major()
+	return hold, nil
+}
+
+// ClaimPaymentHold moves an authorized hold to processing so only one
+// caller captures or releases it
+func (r *Repository) ClaimPaymentHold(ctx context.Context, id uuid.UUID) (bool, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE payment_holds
+		SET status = 'processing', updated_at = NOW()
+		WHERE id = $1 AND status = 'authorized'`, id)
+	if err != nil {
+		return false, common.NewInternalError("failed to claim payment hold", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// UpdatePaymentHold saves a hold's amounts, status and settlement
+func (r *Repository) UpdatePaymentHold(ctx context.Context, hold *models.PaymentHold) error {
+	_, err := database.RetryableExec(ctx, r.db, `
+		UPDATE payment_holds
+		SET authorized_amount_minor = $2, captured_amount_minor = $3, status = $4,
+			payment_id = $5, failure_reason = $6, captured_at = $7, released_at = $8,
+			updated_at = NOW()
+		WHERE id = $1`,
+		hold.ID,
+		hold.AuthorizedMoney().Amount,
+		money.FromMajor(hold.CapturedAmount, hold.Currency).Amount,
+		hold.Status, hold.PaymentID, hold.FailureReason, hold.CapturedAt, hold.ReleasedAt,
+	)
+	if err != nil {
+		return common.NewInternalError("failed to update payment hold", err)
+	}
+	return nil
+}
+
+// GetPaymentByStripePaymentID retrieves the payment made with a Stripe
+// payment intent, or nil if there is none
+func (r *Repository) GetPaymentByStripePaymentID(ctx context.Context, paymentIntentID string) (*models.Payment, error) {
+	payment := &models.Payment{}
+	var amountMinor int64
+	err := r.db.QueryRow(ctx, `
+		SELECT id, ride_id, rider_id, driver_id, amount_minor, currency, payment_method,
+			status, stripe_payment_id, stripe_charge_id, metadata,
+			created_at, updated_at
+		FROM payments
+		WHERE stripe_payment_id = $1
+		ORDER BY created_at
+		LIMIT 1`, paymentIntentID,
+	).Scan(
+		&payment.ID, &payment.RideID, &payment.RiderID, &payment.DriverID,
+		&amountMinor, &payment.Currency, &payment.PaymentMethod,
+		&payment.Status, &payment.StripePaymentID, &payment.StripeChargeID, &payment.Metadata,
+		&payment.CreatedAt, &payment.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewInternalError("failed to get payment", err)
+	}
+	payment.Amount = money.New(amountMinor, payment.Currency).Major()
+	return payment, nil
+}
+
+// ClaimWebhookEvent stores a webhook event the first time it is delivered.
+// A redelivered event is taken back only if its handling failed, or stalled
+// for staleWebhookAfter; otherwise it returns false.
+func (r *Repository) ClaimWebhookEvent(ctx context.Context, event *models.StripeWebhookEvent) (bool, error) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO stripe_webhook_events (id, event_id, event_type, account_id, livemode, payload)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (event_id) DO UPDATE SET
+			status = 'processing',
+			attempts = stripe_webhook_events.attempts + 1,
+			updated_at = NOW()
+		WHERE stripe_webhook_events.status = 'failed'
+		   OR (stripe_webhook_events.status = 'processing' AND stripe_webhook_events.updated_at < $7)
+		RETURNING id, status, attempts, received_at`,
+		event.ID, event.EventID, event.Type, event.AccountID, event.Livemode, event.Payload,
+		time.Now().Add(-staleWebhookAfter),
+	).Scan(&event.ID, &event.Status, &event.Attempts, &event.ReceivedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, common.NewInternalError("failed to record webhook event", err)
+	}
+	return true, nil
+}
+
+// FinishWebhookEvent records the outcome of handling a claimed event
+func (r *Repository) FinishWebhookEvent(ctx context.Context, eventID string, handleErr error) error {
+	status := models.WebhookEventProcessed
+	var lastError *string
+	var processedAt *time.Time
+	if handleErr != nil {
+		msg := handleErr.Error()
+		status, lastError = models.WebhookEventFailed, &msg
+	} else {
+		now := time.Now()
+		processedAt = &now
+	}
+	_, err := database.RetryableExec(ctx, r.db, `
+		UPDATE stripe_webhook_events
+		SET status = $2, last_error = $3, processed_at = $4, updated_at = NOW()
+		WHERE event_id = $1`,
+		eventID, status, lastError, processedAt,
+	)
+	if err != nil {
+		return common.NewInternalError("failed to update webhook event", err)
+	}
+	return nil
+}
@@ -175,6 +175,82 @@ func (r *ResilientStripeClient) CapturePaymentIntent(paymentIntentID string) (*s
 	return paymentIntent, nil
 }
 
+// CreateAuthorizationHold places a card hold with resilience. Retries are
+// only safe for holds whose metadata carries an idempotency key.
+func (r *ResilientStripeClient) CreateAuthorizationHold(amount int64, currency, customerID, paymentMethodID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	ctx := context.Background()
+
+	result, err := resilience.RetryWithBreaker(ctx, r.retry, r.breaker, func(ctx context.Context) (interface{}, error) {
+		return r.client.CreateAuthorizationHold(amount, currency, customerID, paymentMethodID, description, metadata)
+	})
+
+	if err != nil {
+		logger.Get().Error("Failed to create authorization hold after retries",
+			zap.Error(err),
+			zap.Int64("amount", amount),
+			zap.String("currency", currency),
+		)
+		return nil, err
+	}
+
+	paymentIntent, ok := result.(*stripe.PaymentIntent)
+	if !ok {
+		return nil, common.NewInternalError("unexpected response type from Stripe", nil)
+	}
+
+	return paymentIntent, nil
+}
+
+// CapturePaymentIntentAmount captures part of a payment intent with resilience
+func (r *ResilientStripeClient) CapturePaymentIntentAmount(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	ctx := context.Background()
+
+	result, err := resilience.RetryWithBreaker(ctx, r.retry, r.breaker, func(ctx context.Context) (interface{}, error) {
+		return r.client.CapturePaymentIntentAmount(paymentIntentID, amount)
+	})
+
+	if err != nil {
+		logger.Get().Error("Failed to capture payment intent after retries",
+			zap.Error(err),
+			zap.String("payment_intent_id", paymentIntentID),
+			zap.Int64("amount", amount),
+		)
+		return nil, err
+	}
+
+	paymentIntent, ok := result.(*stripe.PaymentIntent)
+	if !ok {
+		return nil, common.NewInternalError("unexpected response type from Stripe", nil)
+	}
+
+	return paymentIntent, nil
+}
+
+// IncrementAuthorization raises a hold with resilience
+func (r *ResilientStripeClient) IncrementAuthorization(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	ctx := context.Background()
+
+	result, err := resilience.RetryWithBreaker(ctx, r.retry, r.breaker, func(ctx context.Context) (interface{}, error) {
+		return r.client.IncrementAuthorization(paymentIntentID, amount)
+	})
+
+	if err != nil {
+		logger.Get().Error("Failed to increment authorization after retries",
+			zap.Error(err),
+			zap.String("payment_intent_id", paymentIntentID),
+			zap.Int64("amount", amount),
+		)
+		return nil, err
+	}
+
+	paymentIntent, ok := result.(*stripe.PaymentIntent)
+	if !ok {
+		return nil, common.NewInternalError("unexpected response type from Stripe", nil)
+	}
+
+	return paymentIntent, nil
+}
+
 // CreateRefund creates a refund with resilience
 func (r *ResilientStripeClient) CreateRefund(chargeID string, amount *int64, reason string) (*stripe.Refund, error) {
 	ctx := context.Background()
@@ -199,7 +275,8 @@ func (r *ResilientStripeClient) CreateRefund(chargeID string, amount *int64, rea
 	return refund, nil
 }
 
-// CreateTransfer creates a transfer with resilience
+// CreateTransfer creates a transfer with resilience. Retries are only safe
+// for transfers whose metadata carries an idempotency key.
 func (r *ResilientStripeClient) CreateTransfer(amount int64, currency, destination, description string, metadata map[string]string) (*stripe.Transfer, error) {
 	ctx := context.Background()
 
@@ -224,6 +301,31 @@ func (r *ResilientStripeClient) CreateTransfer(amount int64, currency, destinati
 	return transfer, nil
 }
 
+// ListTransfers lists transfers with resilience
+func (r *ResilientStripeClient) ListTransfers(createdFrom, createdTo int64) ([]*stripe.Transfer, error) {
+	ctx := context.Background()
+
+	result, err := resilience.RetryWithBreaker(ctx, r.retry, r.breaker, func(ctx context.Context) (interface{}, error) {
+		return r.client.ListTransfers(createdFrom, createdTo)
+	})
+
+	if err != nil {
+		logger.Get().Error("Failed to list transfers after retries",
+			zap.Error(err),
+			zap.Int64("created_from", createdFrom),
+			zap.Int64("created_to", createdTo),
+		)
+		return nil, err
+	}
+
+	transfers, ok := result.([]*stripe.Transfer)
+	if !ok {
+		return nil, common.NewInternalError("unexpected response type from Stripe", nil)
+	}
+
+	return transfers, nil
+}
+
 // GetPaymentIntent retrieves a payment intent with resilience
 func (r *ResilientStripeClient) GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
 	ctx := context.Background()
@@ -8,16 +8,20 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stripe/stripe-go/v83"
 )
 
 // Default rates used when no config is provided
 const (
 	defaultCommissionRate      = 0.20 // 20% platform commission
 	defaultCancellationFeeRate = 0.10 // 10% cancellation fee
+	defaultAuthHoldBufferRate  = 0.20 // 20% held above the estimated fare
 )
 
 type Service struct {
@@ -25,11 +29,16 @@ type Service struct {
 	stripeClient        StripeClientInterface
 	commissionRate      float64
 	cancellationFeeRate float64
+	holdBufferRate      float64
+	ledger              LedgerPoster
+	disputes            DisputeRecorder
+	bankPayouts         BankPayoutRecorder
 }
 
 func NewService(repo RepositoryInterface, stripeClient StripeClientInterface, cfg *config.BusinessConfig) *Service {
 	commissionRate := defaultCommissionRate
 	cancellationFeeRate := defaultCancellationFeeRate
+	holdBufferRate := defaultAuthHoldBufferRate
 
 	if cfg != nil {
 		if cfg.CommissionRate > 0 {
@@ -38,6 +47,9 @@ func NewService(repo RepositoryInterface, stripeClient StripeClientInterface, cf
 		if cfg.CancellationFeeRate > 0 {
 			cancellationFeeRate = cfg.CancellationFeeRate
 		}
+		if cfg.AuthHoldBufferRate > 0 {
+			holdBufferRate = cfg.AuthHoldBufferRate
+		}
 	}
 
 	return &Service{
@@ -45,9 +57,27 @@ func NewService(repo RepositoryInterface, stripeClient StripeClientInterface, cf
 		stripeClient:        stripeClient,
 		commissionRate:      commissionRate,
 		cancellationFeeRate: cancellationFeeRate,
+		holdBufferRate:      holdBufferRate,
 	}
 }
 
+// SetLedger enables double-entry journal entries for wallet, payout and
+// commission movements.
+func (s *Service) SetLedger(l LedgerPoster) {
+	s.ledger = l
+}
+
+// SetDisputes opens fare disputes for card chargebacks reported by Stripe
+func (s *Service) SetDisputes(d DisputeRecorder) {
+	s.disputes = d
+}
+
+// SetBankPayouts passes Stripe payouts from drivers' connected accounts on
+// to earnings, so a failed bank payout holds the driver's payouts
+func (s *Service) SetBankPayouts(b BankPayoutRecorder) {
+	s.bankPayouts = b
+}
+
 // GetRideDriverID retrieves the driver ID for a given ride
 func (s *Service) GetRideDriverID(ctx context.Context, rideID uuid.UUID) (*uuid.UUID, error) {
 	return s.repo.GetRideDriverID(ctx, rideID)
@@ -55,10 +85,10 @@ func (s *Service) GetRideDriverID(ctx context.Context, rideID uuid.UUID) (*uuid.
 
 // RecordRideEarning records a ride_fare earning for a driver on ride completion.
 func (s *Service) RecordRideEarning(ctx context.Context, driverID, rideID uuid.UUID, fareAmount float64) error {
-	commission := fareAmount * s.commissionRate
-	netAmount := fareAmount - commission
+	fare := money.FromMajor(fareAmount, "USD")
+	commission, net := fare.SplitRate(s.commissionRate)
 	description := fmt.Sprintf("Earnings from ride %s (%.0f%% commission)", rideID, s.commissionRate*100)
-	return s.repo.RecordRideEarning(ctx, driverID, rideID, fareAmount, commission, netAmount, description)
+	return s.repo.RecordRideEarning(ctx, driverID, rideID, fare.Major(), commission.Major(), net.Major(), description)
 }
 
 // ProcessRidePayment processes payment for a completed ride
@@ -79,6 +109,12 @@ func (s *Service) ProcessRidePayment(ctx context.Context, rideID, riderID, drive
 	case "wallet":
 		return s.processWalletPayment(ctx, payment)
 	case "stripe":
+		// Rides requested by card already hold the fare; capture it rather
+		// than charging the card a second time
+		captured, err := s.CaptureRide(ctx, rideID, driverID, amount, FareCoverage{})
+		if err != nil || captured != nil {
+			return captured, err
+		}
 		return s.processStripePayment(ctx, payment)
 	default:
 		return nil, common.NewBadRequestError("invalid payment method", nil)
@@ -102,14 +138,17 @@ func (s *Service) processWalletPayment(ctx context.Context, payment *models.Paym
 		return nil, err
 	}
 
+	entry, err := s.ridePaymentEntry(payment)
+	s.postToLedger(ctx, entry, err)
+
 	logger.Get().Info("Wallet payment processed successfully", zap.String("payment_id", payment.ID.String()), zap.Float64("amount", payment.Amount))
 	return payment, nil
 }
 
 // processStripePayment processes payment using Stripe
 func (s *Service) processStripePayment(ctx context.Context, payment *models.Payment) (*models.Payment, error) {
-	// Convert amount to cents for Stripe
-	amountCents := int64(payment.Amount * 100)
+	// Stripe takes amounts in the currency's minor unit
+	amountCents := payment.AmountMoney().Amount
 
 	// Create payment intent
 	metadata := map[string]string{
@@ -141,6 +180,12 @@ func (s *Service) processStripePayment(ctx context.Context, payment *models.Paym
 		return nil, err
 	}
 
+	// Intents that still need confirmation are posted once the payment completes
+	if pi.Status == stripe.PaymentIntentStatusSucceeded {
+		entry, err := s.ridePaymentEntry(payment)
+		s.postToLedger(ctx, entry, err)
+	}
+
 	logger.Get().Info("Stripe payment created successfully", zap.String("payment_id", payment.ID.String()), zap.String("stripe_pi", pi.ID))
 	return payment, nil
 }
@@ -165,7 +210,7 @@ func (s *Service) TopUpWallet(ctx context.Context, userID uuid.UUID, amount floa
 	}
 
 	// Create Stripe payment intent for top-up
-	amountCents := int64(amount * 100)
+	amountCents := money.FromMajor(amount, wallet.Currency).Amount
 	metadata := map[string]string{
 		"user_id":   userID.String(),
 		"wallet_id": wallet.ID.String(),
@@ -235,7 +280,7 @@ func (s *Service) ConfirmWalletTopUp(ctx context.Context, userID uuid.UUID, amou
 		ReferenceType: "stripe_payment",
 		ReferenceID:   &referenceID,
 		BalanceBefore: wallet.Balance,
-		BalanceAfter:  wallet.Balance + amount,
+		BalanceAfter:  balanceAfter(wallet, amount),
 	}
 
 	err = s.repo.CreateWalletTransaction(ctx, walletTx)
@@ -243,6 +288,9 @@ func (s *Service) ConfirmWalletTopUp(ctx context.Context, userID uuid.UUID, amou
 		return err
 	}
 
+	entry, err := ledger.NewWalletTopUpEntry(userID, walletTx.ID, money.FromMajor(amount, wallet.Currency))
+	s.postToLedger(ctx, entry, err)
+
 	logger.Get().Info("Wallet top-up confirmed", zap.String("user_id", userID.String()), zap.Float64("amount", amount))
 	return nil
 }
@@ -258,9 +306,16 @@ func (s *Service) PayoutToDriver(ctx context.Context, paymentID uuid.UUID) error
 		return common.NewBadRequestError("payment not completed", nil)
 	}
 
+	// Make sure the captured fare is on the books before crediting its
+	// earnings; card payments complete asynchronously, so this may be the
+	// first time the payment is seen as completed. Already-posted payments
+	// are skipped by idempotency key.
+	entry, err := s.ridePaymentEntry(payment)
+	s.postToLedger(ctx, entry, err)
+
 	// Calculate driver earnings (total - commission)
-	commission := payment.Amount * s.commissionRate
-	driverEarnings := payment.Amount - commission
+	commissionMoney, earningsMoney := payment.AmountMoney().SplitRate(s.commissionRate)
+	commission, driverEarnings := commissionMoney.Major(), earningsMoney.Major()
 
 	// Get driver's wallet
 	driverWallet, err := s.repo.GetWalletByUserID(ctx, payment.DriverID)
@@ -295,7 +350,7 @@ func (s *Service) PayoutToDriver(ctx context.Context, paymentID uuid.UUID) error
 		ReferenceType: "ride",
 		ReferenceID:   &payment.RideID,
 		BalanceBefore: driverWallet.Balance,
-		BalanceAfter:  driverWallet.Balance + driverEarnings,
+		BalanceAfter:  balanceAfter(driverWallet, driverEarnings),
 	}
 
 	err = s.repo.CreateWalletTransaction(ctx, walletTx)
@@ -324,23 +379,28 @@ func (s *Service) ProcessRefund(ctx context.Context, paymentID uuid.UUID, reason
 		return common.NewBadRequestError("payment already refunded", nil)
 	}
 
-	var refundAmount = payment.Amount
+	refund := payment.AmountMoney()
 
 	// Apply cancellation fee if applicable
 	if reason == "rider_cancelled" {
-		cancellationFee := payment.Amount * s.cancellationFeeRate
-		refundAmount = payment.Amount - cancellationFee
-		logger.Get().Info("Applying cancellation fee", zap.String("payment_id", paymentID.String()), zap.Float64("fee", cancellationFee))
+		var cancellationFee money.Money
+		cancellationFee, refund = refund.SplitRate(s.cancellationFeeRate)
+		logger.Get().Info("Applying cancellation fee", zap.String("payment_id", paymentID.String()), zap.Float64("fee", cancellationFee.Major()))
 	}
+	refundAmount := refund.Major()
 
+	var stripeRefundID string
 	if payment.PaymentMethod == "stripe" && payment.StripePaymentID != nil {
 		// Process Stripe refund
-		refundAmountCents := int64(refundAmount * 100)
-		_, err := s.stripeClient.CreateRefund(*payment.StripeChargeID, &refundAmountCents, reason)
+		refundAmountCents := refund.Amount
+		stripeRefund, err := s.stripeClient.CreateRefund(*payment.StripeChargeID, &refundAmountCents, reason)
 		if err != nil {
 			logger.Get().Error("Failed to create Stripe refund", zap.Error(err))
 			return common.NewInternalError("failed to process refund", err)
 		}
+		if stripeRefund != nil {
+			stripeRefundID = stripeRefund.ID
+		}
 	} else if payment.PaymentMethod == "wallet" {
 		// Refund to wallet
 		wallet, err := s.repo.GetWalletByUserID(ctx, payment.RiderID)
@@ -363,7 +423,7 @@ func (s *Service) ProcessRefund(ctx context.Context, paymentID uuid.UUID, reason
 			ReferenceType: "ride",
 			ReferenceID:   &payment.RideID,
 			BalanceBefore: wallet.Balance,
-			BalanceAfter:  wallet.Balance + refundAmount,
+			BalanceAfter:  balanceAfter(wallet, refundAmount),
 		}
 
 		err = s.repo.CreateWalletTransaction(ctx, walletTx)
@@ -372,6 +432,20 @@ func (s *Service) ProcessRefund(ctx context.Context, paymentID uuid.UUID, reason
 		}
 	}
 
+	// Only captured payments are on the books; reversing anything else would
+	// leave the driver payable and revenue accounts short.
+	if payment.Status == "completed" && refund.IsPositive() {
+		entry, err := s.ridePaymentEntry(payment)
+		s.postToLedger(ctx, entry, err)
+		// Keyed like the charge.refunded webhook, which reports the same refund
+		if stripeRefundID != "" {
+			entry, err = ledger.NewStripeRefundEntry(s.ridePayment(payment), stripeRefundID, refund)
+		} else {
+			entry, err = ledger.NewRideRefundEntry(s.ridePayment(payment), refund)
+		}
+		s.postToLedger(ctx, entry, err)
+	}
+
 	// Update payment status
 	status := "refunded"
 	err = s.repo.UpdatePaymentStatus(ctx, paymentID, status, nil)
@@ -400,34 +474,6 @@ func (s *Service) GetWalletTransactions(ctx context.Context, userID uuid.UUID, l
 	return s.repo.GetWalletTransactionsWithTotal(ctx, userID, limit, offset)
 }
 
-// HandleStripeWebhook handles Stripe webhook events
-func (s *Service) HandleStripeWebhook(ctx context.Context, eventType string, paymentIntentID string) error {
-	logger.Get().Info("Handling Stripe webhook", zap.String("event_type", eventType), zap.String("payment_intent_id", paymentIntentID))
-
-	switch eventType {
-	case "payment_intent.succeeded":
-		// Payment succeeded - update payment status
-		// In a real implementation, you would:
-		// 1. Retrieve payment by stripe_payment_id
-		// 2. Update payment status to 'completed'
-		// 3. If it's a wallet top-up, credit the wallet
-		logger.Get().Info("Payment intent succeeded", zap.String("payment_intent_id", paymentIntentID))
-
-	case "payment_intent.payment_failed":
-		// Payment failed - update status
-		logger.Get().Warn("Payment intent failed", zap.String("payment_intent_id", paymentIntentID))
-
-	case "charge.refunded":
-		// Refund processed
-		logger.Get().Info("Charge refunded", zap.String("payment_intent_id", paymentIntentID))
-
-	default:
-		logger.Get().Debug("Unhandled webhook event", zap.String("event_type", eventType))
-	}
-
-	return nil
-}
-
 // PayoutSummary holds driver payout information.
 type PayoutSummary struct {
 	DriverID          uuid.UUID `json:"driver_id"`
@@ -503,7 +549,7 @@ func (s *Service) RequestWithdrawal(ctx context.Context, driverID uuid.UUID, amo
 		Description:   fmt.Sprintf("Withdrawal request (pending transfer) for driver %s", driverID),
 		ReferenceType: "withdrawal",
 		BalanceBefore: wallet.Balance,
-		BalanceAfter:  wallet.Balance - amount,
+		BalanceAfter:  balanceAfter(wallet, -amount),
 		CreatedAt:     time.Now(),
 	}
 
@@ -511,6 +557,9 @@ func (s *Service) RequestWithdrawal(ctx context.Context, driverID uuid.UUID, amo
 		return err
 	}
 
+	entry, err := ledger.NewDriverWithdrawalEntry(driverID, walletTx.ID, money.FromMajor(amount, wallet.Currency))
+	s.postToLedger(ctx, entry, err)
+
 	logger.Get().Info("Withdrawal requested",
 		zap.String("driver_id", driverID.String()),
 		zap.Float64("amount", amount),
@@ -520,6 +569,78 @@ func (s *Service) RequestWithdrawal(ctx context.Context, driverID uuid.UUID, amo
 	return nil
 }
 
+// ridePayment describes how a payment's charge is funded and split for the
+// ledger. Refunds go back to the card or wallet, so they reverse this.
+func (s *Service) ridePayment(payment *models.Payment) ledger.RidePayment {
+	fare := payment.AmountMoney()
+	commission, _ := fare.SplitRate(s.commissionRate)
+
+	funding := ledger.CardFunding(fare)
+	if payment.PaymentMethod == "wallet" {
+		funding = ledger.WalletFunding(payment.RiderID, fare)
+	}
+	return ledger.RidePayment{
+		PaymentID:  payment.ID,
+		RideID:     payment.RideID,
+		DriverID:   payment.DriverID,
+		Funding:    []ledger.Funding{funding},
+		Commission: commission,
+	}
+}
+
+// ridePaymentEntry builds the journal entry for a captured ride payment. The
+// discounts and gift card credit recorded on the payment fund the rest of the
+// gross fare the driver earns on.
+func (s *Service) ridePaymentEntry(payment *models.Payment) (*ledger.JournalEntry, error) {
+	discount, credit := paymentCoverage(payment)
+	return ledger.NewRidePaymentEntry(s.withCoverage(s.ridePayment(payment), discount, credit))
+}
+
+// withCoverage adds discounts and gift card credit to a ride payment's funding
+// and takes the commission from the resulting gross fare.
+func (s *Service) withCoverage(p ledger.RidePayment, discount, credit money.Money) ledger.RidePayment {
+	if discount.IsPositive() {
+		p.Funding = append(p.Funding, ledger.PromoFunding(discount))
+	}
+	if credit.IsPositive() {
+		p.Funding = append(p.Funding, ledger.GiftCardFunding(credit))
+	}
+	gross := money.Zero(discount.Currency)
+	for _, f := range p.Funding {
+		gross, _ = gross.Add(f.Amount)
+	}
+	p.Commission, _ = gross.SplitRate(s.commissionRate)
+	return p
+}
+
+// postToLedger records a journal entry for a money movement that has already
+// been applied. The movement is not rolled back if posting fails: the ledger
+// queues entries it cannot store and retries them, keyed by idempotency key so
+// nothing is counted twice. Only an entry that could not be built or queued
+// is left to the error log.
+func (s *Service) postToLedger(ctx context.Context, entry *ledger.JournalEntry, err error) {
+	if s.ledger == nil {
+		return
+	}
+	if err == nil {
+		err = s.ledger.Post(ctx, entry)
+	}
+	if err != nil {
+		fields := []zap.Field{zap.Error(err)}
+		if entry != nil {
+			fields = append(fields, zap.String("entry_type", string(entry.Type)), zap.String("idempotency_key", entry.IdempotencyKey))
+		}
+		logger.Get().Error("Failed to post ledger entry", fields...)
+	}
+}
+
+// balanceAfter returns the wallet balance after applying delta, computed in
+// minor units so repeated credits and debits don't drift.
+func balanceAfter(wallet *models.Wallet, delta float64) float64 {
+	balance := wallet.BalanceMoney()
+	return money.New(balance.Amount+money.FromMajor(delta, wallet.Currency).Amount, balance.Currency).Major()
+}
+
 func wrapStripeError(err error, fallbackMessage string) error {
 	if err == nil {
 		return nil
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/ledger"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/config"
 	"github.com/richxcame/ride-hailing/pkg/models"
@@ -59,6 +60,7 @@ func TestService_ProcessRidePayment_Stripe_Success(t *testing.T) {
 		Status: stripe.PaymentIntentStatusSucceeded,
 	}
 
+	mockRepo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", int64(2550), "usd", "", mock.Anything, mock.Anything).Return(mockPI, nil)
 	mockRepo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil)
 
@@ -109,6 +111,7 @@ func TestService_ProcessRidePayment_Stripe_CreatePaymentIntentFails(t *testing.T
 	driverID := uuid.New()
 	amount := 25.50
 
+	mockRepo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(nil, errors.New("stripe error"))
 
@@ -468,32 +471,38 @@ func TestService_ConfirmWalletTopUp_Success(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-func TestService_HandleStripeWebhook_PaymentIntentSucceeded(t *testing.T) {
+func TestService_HandleStripeEvent_PaymentIntentSucceeded(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockPaymentsRepository)
 	mockStripe := new(mocks.MockStripeClient)
 	service := NewService(mockRepo, mockStripe, nil)
 	ctx := context.Background()
+	event, payload := newStripeEvent(t, "payment_intent.succeeded", "", map[string]interface{}{"id": "pi_test123"})
+	expectEventClaimed(mockRepo, event, false)
 
 	// Act
-	err := service.HandleStripeWebhook(ctx, "payment_intent.succeeded", "pi_test123")
+	err := service.HandleStripeEvent(ctx, event, payload)
 
 	// Assert
 	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
 }
 
-func TestService_HandleStripeWebhook_PaymentIntentFailed(t *testing.T) {
+func TestService_HandleStripeEvent_PaymentIntentFailed(t *testing.T) {
 	// Arrange
 	mockRepo := new(mocks.MockPaymentsRepository)
 	mockStripe := new(mocks.MockStripeClient)
 	service := NewService(mockRepo, mockStripe, nil)
 	ctx := context.Background()
+	event, payload := newStripeEvent(t, "payment_intent.payment_failed", "", map[string]interface{}{"id": "pi_test123"})
+	expectEventClaimed(mockRepo, event, false)
 
 	// Act
-	err := service.HandleStripeWebhook(ctx, "payment_intent.payment_failed", "pi_test123")
+	err := service.HandleStripeEvent(ctx, event, payload)
 
 	// Assert
 	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
 }
 
 func TestCommissionCalculation(t *testing.T) {
@@ -593,6 +602,7 @@ func TestService_ProcessRidePayment_Stripe_CreatePaymentIntentError(t *testing.T
 	riderID := uuid.New()
 	driverID := uuid.New()
 
+	mockRepo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", mock.AnythingOfType("int64"), "usd", "", mock.Anything, mock.Anything).
 		Return(nil, errors.New("stripe API error"))
 
@@ -622,6 +632,7 @@ func TestService_ProcessRidePayment_Stripe_CreatePaymentError(t *testing.T) {
 		Status: stripe.PaymentIntentStatusRequiresPaymentMethod,
 	}
 
+	mockRepo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil)
 	mockStripe.On("CreatePaymentIntent", mock.AnythingOfType("int64"), "usd", "", mock.Anything, mock.Anything).
 		Return(pi, nil)
 	mockRepo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).
@@ -1705,27 +1716,35 @@ func TestPaymentStatusTransitions(t *testing.T) {
 // Handle Stripe Webhook Additional Tests
 // ============================================================================
 
-func TestService_HandleStripeWebhook_ChargeRefunded(t *testing.T) {
+func TestService_HandleStripeEvent_ChargeRefundedUnknownPayment(t *testing.T) {
 	mockRepo := new(mocks.MockPaymentsRepository)
 	mockStripe := new(mocks.MockStripeClient)
 	service := NewService(mockRepo, mockStripe, nil)
 	ctx := context.Background()
+	event, payload := newStripeEvent(t, "charge.refunded", "", map[string]interface{}{"id": "ch_test123", "payment_intent": "pi_test123"})
+	expectEventClaimed(mockRepo, event, false)
+	mockRepo.On("GetPaymentByStripePaymentID", ctx, "pi_test123").Return(nil, nil)
 
-	err := service.HandleStripeWebhook(ctx, "charge.refunded", "pi_test123")
+	// Charges made outside the app are acknowledged and left alone
+	err := service.HandleStripeEvent(ctx, event, payload)
 
 	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
 }
 
-func TestService_HandleStripeWebhook_UnhandledEvent(t *testing.T) {
+func TestService_HandleStripeEvent_UnhandledEvent(t *testing.T) {
 	mockRepo := new(mocks.MockPaymentsRepository)
 	mockStripe := new(mocks.MockStripeClient)
 	service := NewService(mockRepo, mockStripe, nil)
 	ctx := context.Background()
+	event, payload := newStripeEvent(t, "unknown.event.type", "", map[string]interface{}{"id": "pi_test123"})
+	expectEventClaimed(mockRepo, event, false)
 
 	// Unknown event types should not fail
-	err := service.HandleStripeWebhook(ctx, "unknown.event.type", "pi_test123")
+	err := service.HandleStripeEvent(ctx, event, payload)
 
 	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
 }
 
 // ============================================================================
@@ -1913,6 +1932,7 @@ func TestService_ProcessRidePayment_TableDriven(t *testing.T) {
 					ID:     "pi_test",
 					Status: stripe.PaymentIntentStatusSucceeded,
 				}
+				repo.On("GetPaymentHoldByRideID", ctx, rideID).Return(nil, nil)
 				stripeClient.On("CreatePaymentIntent", int64(7500), "usd", "", mock.Anything, mock.Anything).Return(pi, nil)
 				repo.On("CreatePayment", ctx, mock.AnythingOfType("*models.Payment")).Return(nil)
 			},
@@ -2144,3 +2164,103 @@ func TestService_GetWalletTransactions_EmptyResult(t *testing.T) {
 	assert.Equal(t, int64(0), total)
 	mockRepo.AssertExpectations(t)
 }
+
+// recordingLedger is a LedgerPoster that keeps every posted entry.
+type recordingLedger struct {
+	entries []*ledger.JournalEntry
+	err     error
+}
+
+func (l *recordingLedger) Post(_ context.Context, entry *ledger.JournalEntry) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func TestService_ProcessRidePayment_Wallet_PostsLedgerEntry(t *testing.T) {
+	mockRepo := new(mocks.MockPaymentsRepository)
+	service := NewService(mockRepo, new(mocks.MockStripeClient), nil)
+	journal := &recordingLedger{}
+	service.SetLedger(journal)
+	ctx := context.Background()
+
+	riderID, driverID := uuid.New(), uuid.New()
+	mockRepo.On("ProcessPaymentWithWallet", ctx, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("*models.WalletTransaction")).Return(nil)
+
+	payment, err := service.ProcessRidePayment(ctx, uuid.New(), riderID, driverID, 19.99, "wallet")
+	assert.NoError(t, err)
+
+	if assert.Len(t, journal.entries, 1) {
+		entry := journal.entries[0]
+		assert.Equal(t, ledger.EntryRidePayment, entry.Type)
+		assert.Equal(t, payment.ID, *entry.ReferenceID)
+		assert.NoError(t, entry.Validate())
+
+		amounts := map[ledger.AccountCode]int64{}
+		for _, p := range entry.Postings {
+			amounts[p.AccountCode] = p.Amount.Amount
+		}
+		assert.Equal(t, int64(1999), amounts[ledger.AccountRiderWallet])
+		assert.Equal(t, int64(1599), amounts[ledger.AccountDriverPayable])
+		assert.Equal(t, int64(400), amounts[ledger.AccountPlatformRevenue])
+	}
+}
+
+func TestService_ProcessRidePayment_LedgerFailureDoesNotFailPayment(t *testing.T) {
+	mockRepo := new(mocks.MockPaymentsRepository)
+	service := NewService(mockRepo, new(mocks.MockStripeClient), nil)
+	service.SetLedger(&recordingLedger{err: errors.New("ledger unavailable")})
+	ctx := context.Background()
+
+	mockRepo.On("ProcessPaymentWithWallet", ctx, mock.AnythingOfType("*models.Payment"), mock.AnythingOfType("*models.WalletTransaction")).Return(nil)
+
+	payment, err := service.ProcessRidePayment(ctx, uuid.New(), uuid.New(), uuid.New(), 25.50, "wallet")
+	assert.NoError(t, err)
+	assert.NotNil(t, payment)
+}
+
+func TestService_ProcessRefund_PostsReversal(t *testing.T) {
+	mockRepo := new(mocks.MockPaymentsRepository)
+	service := NewService(mockRepo, new(mocks.MockStripeClient), nil)
+	journal := &recordingLedger{}
+	service.SetLedger(journal)
+	ctx := context.Background()
+
+	paymentID, riderID, walletID := uuid.New(), uuid.New(), uuid.New()
+	payment := &models.Payment{
+		ID:            paymentID,
+		RideID:        uuid.New(),
+		RiderID:       riderID,
+		DriverID:      uuid.New(),
+		Amount:        100.0,
+		Currency:      "usd",
+		Status:        "completed",
+		PaymentMethod: "wallet",
+	}
+	wallet := &models.Wallet{ID: walletID, UserID: riderID, Currency: "usd", IsActive: true}
+
+	mockRepo.On("GetPaymentByID", ctx, paymentID).Return(payment, nil)
+	mockRepo.On("GetWalletByUserID", ctx, riderID).Return(wallet, nil)
+	mockRepo.On("UpdateWalletBalance", ctx, walletID, 90.0).Return(nil)
+	mockRepo.On("CreateWalletTransaction", ctx, mock.AnythingOfType("*models.WalletTransaction")).Return(nil)
+	mockRepo.On("UpdatePaymentStatus", ctx, paymentID, "refunded", mock.Anything).Return(nil)
+
+	err := service.ProcessRefund(ctx, paymentID, "rider_cancelled")
+	assert.NoError(t, err)
+
+	// The payment is (re-)posted idempotently, then reversed less the fee
+	if assert.Len(t, journal.entries, 2) {
+		assert.Equal(t, ledger.EntryRidePayment, journal.entries[0].Type)
+		refund := journal.entries[1]
+		assert.Equal(t, ledger.EntryRideRefund, refund.Type)
+		assert.NoError(t, refund.Validate())
+		for _, p := range refund.Postings {
+			if p.AccountCode == ledger.AccountRiderWallet {
+				assert.Equal(t, ledger.Credit, p.Direction)
+				assert.Equal(t, int64(9000), p.Amount.Amount)
+			}
+		}
+	}
+}
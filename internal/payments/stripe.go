@@ -87,6 +87,68 @@ func (s *StripeClient) CapturePaymentIntent(paymentIntentID string) (*stripe.Pay
 	return pi, nil
 }
 
+// CreateAuthorizationHold places a hold on the customer's saved card for
+// amount without charging it. The hold is confirmed off-session and asks for
+// incremental authorization where the card network supports it.
+func (s *StripeClient) CreateAuthorizationHold(amount int64, currency, customerID, paymentMethodID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentParams{
+		Amount:        stripe.Int64(amount),
+		Currency:      stripe.String(currency),
+		Customer:      stripe.String(customerID),
+		PaymentMethod: stripe.String(paymentMethodID),
+		Description:   stripe.String(description),
+		CaptureMethod: stripe.String(string(stripe.PaymentIntentCaptureMethodManual)),
+		Confirm:       stripe.Bool(true),
+		OffSession:    stripe.Bool(true),
+		PaymentMethodOptions: &stripe.PaymentIntentPaymentMethodOptionsParams{
+			Card: &stripe.PaymentIntentPaymentMethodOptionsCardParams{
+				RequestIncrementalAuthorization: stripe.String("if_available"),
+			},
+		},
+	}
+
+	for key, value := range metadata {
+		params.AddMetadata(key, value)
+	}
+	if key := metadata[idempotencyKeyMetadata]; key != "" {
+		params.SetIdempotencyKey(key)
+	}
+
+	pi, err := paymentintent.New(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create authorization hold: %w", err)
+	}
+
+	return pi, nil
+}
+
+// CapturePaymentIntentAmount captures part of an authorized payment intent;
+// Stripe releases the rest of the hold
+func (s *StripeClient) CapturePaymentIntentAmount(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentCaptureParams{
+		AmountToCapture: stripe.Int64(amount),
+	}
+	pi, err := paymentintent.Capture(paymentIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture payment intent: %w", err)
+	}
+
+	return pi, nil
+}
+
+// IncrementAuthorization raises an authorized payment intent's hold to amount
+func (s *StripeClient) IncrementAuthorization(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	params := &stripe.PaymentIntentIncrementAuthorizationParams{
+		Amount: stripe.Int64(amount),
+	}
+	pi, err := paymentintent.IncrementAuthorization(paymentIntentID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to increment authorization: %w", err)
+	}
+
+	return pi, nil
+}
+
 // CreateCharge creates a direct charge (legacy method, prefer PaymentIntent)
 func (s *StripeClient) CreateCharge(amount int64, currency, customerID, description string, metadata map[string]string) (*stripe.Charge, error) {
 	params := &stripe.ChargeParams{
@@ -130,6 +192,11 @@ func (s *StripeClient) CreateRefund(chargeID string, amount *int64, reason strin
 	return r, nil
 }
 
+// idempotencyKeyMetadata is the metadata key whose value CreateTransfer and
+// CreateAuthorizationHold send as the request's idempotency key, so a
+// retried request moves money once
+const idempotencyKeyMetadata = "idempotency_key"
+
 // CreateTransfer creates a transfer to a driver's connected account
 func (s *StripeClient) CreateTransfer(amount int64, currency, destination, description string, metadata map[string]string) (*stripe.Transfer, error) {
 	params := &stripe.TransferParams{
@@ -142,6 +209,9 @@ func (s *StripeClient) CreateTransfer(amount int64, currency, destination, descr
 	for key, value := range metadata {
 		params.AddMetadata(key, value)
 	}
+	if key := metadata[idempotencyKeyMetadata]; key != "" {
+		params.SetIdempotencyKey(key)
+	}
 
 	t, err := transfer.New(params)
 	if err != nil {
@@ -151,6 +221,28 @@ func (s *StripeClient) CreateTransfer(amount int64, currency, destination, descr
 	return t, nil
 }
 
+// ListTransfers lists the transfers created in [createdFrom, createdTo)
+func (s *StripeClient) ListTransfers(createdFrom, createdTo int64) ([]*stripe.Transfer, error) {
+	params := &stripe.TransferListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: createdFrom,
+			LesserThan:         createdTo,
+		},
+	}
+	params.Limit = stripe.Int64(100)
+
+	var transfers []*stripe.Transfer
+	iter := transfer.List(params)
+	for iter.Next() {
+		transfers = append(transfers, iter.Transfer())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
 // GetPaymentIntent retrieves a payment intent
 func (s *StripeClient) GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
 	pi, err := paymentintent.Get(paymentIntentID, nil)
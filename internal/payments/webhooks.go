@@ -0,0 +1,292 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/disputes"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stripe/stripe-go/v83"
+	"go.uber.org/zap"
+)
+
+// A webhook event left processing this long was interrupted and is applied
+// again when Stripe redelivers it
+const staleWebhookAfter = 5 * time.Minute
+
+// HandleStripeEvent stores a verified Stripe webhook event and applies it.
+// Stripe delivers events at least once, so each event ID is applied once: a
+// redelivered event that was applied, or is being applied, is acknowledged
+// without effect, while one whose handling failed is applied again.
+func (s *Service) HandleStripeEvent(ctx context.Context, event *stripe.Event, payload []byte) error {
+	record := &models.StripeWebhookEvent{
+		EventID:  event.ID,
+		Type:     string(event.Type),
+		Livemode: event.Livemode,
+		Payload:  payload,
+	}
+	if event.Account != "" {
+		record.AccountID = &event.Account
+	}
+
+	claimed, err := s.repo.ClaimWebhookEvent(ctx, record)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		logger.Get().Info("Duplicate Stripe webhook ignored",
+			zap.String("event_id", event.ID), zap.String("event_type", string(event.Type)))
+		return nil
+	}
+
+	handleErr := s.applyStripeEvent(ctx, event)
+	if handleErr != nil {
+		logger.Get().Error("Failed to apply Stripe webhook",
+			zap.String("event_id", event.ID),
+			zap.String("event_type", string(event.Type)),
+			zap.Int("attempt", record.Attempts),
+			zap.Error(handleErr))
+	}
+	// An event whose outcome is not saved stays processing and is applied
+	// again if redelivered after staleWebhookAfter
+	if err := s.repo.FinishWebhookEvent(ctx, event.ID, handleErr); err != nil {
+		logger.Get().Error("Failed to record Stripe webhook outcome", zap.String("event_id", event.ID), zap.Error(err))
+	}
+	return handleErr
+}
+
+func (s *Service) applyStripeEvent(ctx context.Context, event *stripe.Event) error {
+	logger.Get().Info("Handling Stripe webhook", zap.String("event_id", event.ID), zap.String("event_type", string(event.Type)))
+
+	switch event.Type {
+	case "payment_intent.succeeded", "payment_intent.payment_failed":
+		// Charges are recorded when they are made; these only confirm them
+		return nil
+	case "payment_intent.requires_action":
+		return s.handlePaymentRequiresAction(ctx, event)
+	case "charge.refunded":
+		return s.handleChargeRefunded(ctx, event)
+	case "charge.dispute.created":
+		return s.handleChargeDispute(ctx, event, false)
+	case "charge.dispute.closed":
+		return s.handleChargeDispute(ctx, event, true)
+	case "payout.paid", "payout.failed":
+		return s.handleBankPayout(ctx, event)
+	default:
+		logger.Get().Debug("Unhandled webhook event", zap.String("event_type", string(event.Type)))
+		return nil
+	}
+}
+
+// decodeEventObject decodes the object an event is about
+func decodeEventObject(event *stripe.Event, v interface{}) error {
+	if event.Data == nil || len(event.Data.Raw) == 0 {
+		return fmt.Errorf("%s event %s has no object", event.Type, event.ID)
+	}
+	if err := json.Unmarshal(event.Data.Raw, v); err != nil {
+		return fmt.Errorf("decode %s event %s: %w", event.Type, event.ID, err)
+	}
+	return nil
+}
+
+// paymentForIntent returns the payment made with a payment intent, or nil
+// when it is not one of ours
+func (s *Service) paymentForIntent(ctx context.Context, event *stripe.Event, pi *stripe.PaymentIntent) (*models.Payment, error) {
+	if pi == nil || pi.ID == "" {
+		logger.Get().Warn("Stripe webhook has no payment intent", zap.String("event_id", event.ID))
+		return nil, nil
+	}
+	payment, err := s.repo.GetPaymentByStripePaymentID(ctx, pi.ID)
+	if err != nil {
+		return nil, err
+	}
+	if payment == nil {
+		logger.Get().Warn("Stripe webhook for unknown payment intent",
+			zap.String("event_id", event.ID), zap.String("stripe_pi", pi.ID))
+	}
+	return payment, nil
+}
+
+// handlePaymentRequiresAction fails an authorization hold whose card asks
+// for authentication the rider is not there to give, so the ride is charged
+// separately on completion instead of capturing a hold that never came
+// through
+func (s *Service) handlePaymentRequiresAction(ctx context.Context, event *stripe.Event) error {
+	var pi stripe.PaymentIntent
+	if err := decodeEventObject(event, &pi); err != nil {
+		return err
+	}
+
+	hold, err := s.repo.GetPaymentHoldByPaymentIntent(ctx, pi.ID)
+	if err != nil {
+		return err
+	}
+	if hold == nil || hold.Status != models.PaymentHoldAuthorized {
+		logger.Get().Info("Payment awaiting customer authentication", zap.String("stripe_pi", pi.ID))
+		return nil
+	}
+
+	reason := "card requires authentication"
+	hold.Status = models.PaymentHoldFailed
+	hold.FailureReason = &reason
+	if err := s.repo.UpdatePaymentHold(ctx, hold); err != nil {
+		return err
+	}
+	logger.Get().Warn("Authorization hold requires authentication, hold failed",
+		zap.String("ride_id", hold.RideID.String()), zap.String("stripe_pi", pi.ID))
+	return nil
+}
+
+// handleChargeRefunded records refunds of a charge, including those issued
+// outside the app such as from the Stripe dashboard. Each succeeded refund
+// is posted under its Stripe refund ID, so refunds ProcessRefund already
+// posted and repeated partial refunds are each recorded once. The payment is
+// marked refunded only once the whole charge has been refunded.
+func (s *Service) handleChargeRefunded(ctx context.Context, event *stripe.Event) error {
+	var charge stripe.Charge
+	if err := decodeEventObject(event, &charge); err != nil {
+		return err
+	}
+
+	payment, err := s.paymentForIntent(ctx, event, charge.PaymentIntent)
+	if err != nil || payment == nil {
+		return err
+	}
+
+	// Only captured payments are on the books
+	if payment.Status == "completed" || payment.Status == "refunded" {
+		s.postStripeRefunds(ctx, payment, &charge)
+	}
+
+	if !charge.Refunded || payment.Status == "refunded" {
+		logger.Get().Info("Refund issued on Stripe recorded",
+			zap.String("payment_id", payment.ID.String()),
+			zap.Float64("refunded_amount", money.New(charge.AmountRefunded, payment.Currency).Major()),
+			zap.Bool("full_refund", charge.Refunded))
+		return nil
+	}
+
+	if err := s.repo.UpdatePaymentStatus(ctx, payment.ID, "refunded", &charge.ID); err != nil {
+		return err
+	}
+	logger.Get().Info("Payment fully refunded on Stripe",
+		zap.String("payment_id", payment.ID.String()),
+		zap.Float64("refunded_amount", money.New(charge.AmountRefunded, payment.Currency).Major()))
+	return nil
+}
+
+// postStripeRefunds posts each succeeded refund listed on the charge
+func (s *Service) postStripeRefunds(ctx context.Context, payment *models.Payment, charge *stripe.Charge) {
+	if charge.Refunds == nil || len(charge.Refunds.Data) == 0 {
+		if charge.AmountRefunded > 0 {
+			logger.Get().Warn("Refunded charge lists no refunds",
+				zap.String("payment_id", payment.ID.String()), zap.String("charge_id", charge.ID))
+		}
+		return
+	}
+
+	entry, err := s.ridePaymentEntry(payment)
+	s.postToLedger(ctx, entry, err)
+	for _, refund := range charge.Refunds.Data {
+		if refund == nil || refund.Status != stripe.RefundStatusSucceeded || refund.Amount <= 0 {
+			continue
+		}
+		entry, err := ledger.NewStripeRefundEntry(s.ridePayment(payment), refund.ID,
+			money.New(refund.Amount, payment.Currency))
+		s.postToLedger(ctx, entry, err)
+	}
+}
+
+// handleChargeDispute opens a fare dispute for a chargeback, or resolves it
+// with the card issuer's decision once the chargeback closes
+func (s *Service) handleChargeDispute(ctx context.Context, event *stripe.Event, closed bool) error {
+	var dispute stripe.Dispute
+	if err := decodeEventObject(event, &dispute); err != nil {
+		return err
+	}
+	if s.disputes == nil {
+		logger.Get().Warn("Chargeback received but disputes are not configured", zap.String("dispute_id", dispute.ID))
+		return nil
+	}
+
+	payment, err := s.paymentForIntent(ctx, event, dispute.PaymentIntent)
+	if err != nil || payment == nil {
+		return err
+	}
+
+	cb := &disputes.Chargeback{
+		ProviderDisputeID: dispute.ID,
+		RideID:            payment.RideID,
+		UserID:            payment.RiderID,
+		Fare:              payment.Amount,
+		Amount:            money.New(dispute.Amount, string(dispute.Currency)).Major(),
+		ProviderReason:    string(dispute.Reason),
+	}
+	if payment.DriverID != uuid.Nil {
+		driverID := payment.DriverID
+		cb.DriverID = &driverID
+	}
+	if dispute.EvidenceDetails != nil && dispute.EvidenceDetails.DueBy > 0 {
+		due := time.Unix(dispute.EvidenceDetails.DueBy, 0)
+		cb.EvidenceDueBy = &due
+	}
+
+	if !closed {
+		opened, err := s.disputes.OpenChargeback(ctx, cb)
+		if err != nil {
+			return err
+		}
+		logger.Get().Warn("Chargeback opened",
+			zap.String("dispute_id", dispute.ID),
+			zap.String("fare_dispute", opened.DisputeNumber),
+			zap.String("payment_id", payment.ID.String()),
+			zap.Float64("amount", cb.Amount))
+		return nil
+	}
+
+	// An inquiry closed without a chargeback leaves the charge in place too
+	won := dispute.Status == stripe.DisputeStatusWon || dispute.Status == stripe.DisputeStatusWarningClosed
+	resolved, err := s.disputes.CloseChargeback(ctx, cb, won)
+	if err != nil {
+		return err
+	}
+	logger.Get().Info("Chargeback closed",
+		zap.String("dispute_id", dispute.ID),
+		zap.String("fare_dispute", resolved.DisputeNumber),
+		zap.String("status", string(dispute.Status)))
+	return nil
+}
+
+// handleBankPayout passes payouts from drivers' connected accounts to their
+// banks on to earnings. The platform's own payouts need nothing.
+func (s *Service) handleBankPayout(ctx context.Context, event *stripe.Event) error {
+	var payout stripe.Payout
+	if err := decodeEventObject(event, &payout); err != nil {
+		return err
+	}
+	if event.Account == "" {
+		logger.Get().Info("Platform payout", zap.String("payout_id", payout.ID), zap.String("event_type", string(event.Type)))
+		return nil
+	}
+	if s.bankPayouts == nil {
+		logger.Get().Warn("Driver bank payout received but earnings are not configured",
+			zap.String("payout_id", payout.ID), zap.String("account_id", event.Account))
+		return nil
+	}
+
+	return s.bankPayouts.HandleProviderPayout(ctx, &earnings.ProviderPayout{
+		ID:             payout.ID,
+		AccountID:      event.Account,
+		Amount:         money.New(payout.Amount, string(payout.Currency)),
+		Paid:           event.Type == "payout.paid",
+		FailureCode:    string(payout.FailureCode),
+		FailureMessage: payout.FailureMessage,
+	})
+}
@@ -0,0 +1,291 @@
+package payments
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/disputes"
+	"github.com/richxcame/ride-hailing/internal/earnings"
+	"github.com/richxcame/ride-hailing/internal/ledger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/richxcame/ride-hailing/test/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/stripe/stripe-go/v83"
+)
+
+type mockDisputeRecorder struct {
+	mock.Mock
+}
+
+func (m *mockDisputeRecorder) OpenChargeback(ctx context.Context, cb *disputes.Chargeback) (*disputes.Dispute, error) {
+	args := m.Called(ctx, cb)
+	return args.Get(0).(*disputes.Dispute), args.Error(1)
+}
+
+func (m *mockDisputeRecorder) CloseChargeback(ctx context.Context, cb *disputes.Chargeback, won bool) (*disputes.Dispute, error) {
+	args := m.Called(ctx, cb, won)
+	return args.Get(0).(*disputes.Dispute), args.Error(1)
+}
+
+type mockBankPayouts struct {
+	mock.Mock
+}
+
+func (m *mockBankPayouts) HandleProviderPayout(ctx context.Context, payout *earnings.ProviderPayout) error {
+	return m.Called(ctx, payout).Error(0)
+}
+
+// newStripeEvent builds an event the way it arrives in a webhook
+func newStripeEvent(t *testing.T, eventType, account string, object map[string]interface{}) (*stripe.Event, []byte) {
+	t.Helper()
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":      "evt_" + uuid.NewString(),
+		"object":  "event",
+		"type":    eventType,
+		"account": account,
+		"data":    map[string]interface{}{"object": object},
+	})
+	require.NoError(t, err)
+	var event stripe.Event
+	require.NoError(t, json.Unmarshal(payload, &event))
+	return &event, payload
+}
+
+// expectEventClaimed expects the event to be claimed and then finished,
+// with an error when failed is set
+func expectEventClaimed(repo *mocks.MockPaymentsRepository, event *stripe.Event, failed bool) {
+	repo.On("ClaimWebhookEvent", mock.Anything, mock.MatchedBy(func(e *models.StripeWebhookEvent) bool {
+		return e.EventID == event.ID && e.Type == string(event.Type) && len(e.Payload) > 0
+	})).Return(true, nil).Once()
+	repo.On("FinishWebhookEvent", mock.Anything, event.ID, mock.MatchedBy(func(err error) bool {
+		return (err != nil) == failed
+	})).Return(nil).Once()
+}
+
+func TestHandleStripeEvent_DuplicateIgnored(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	event, payload := newStripeEvent(t, "charge.refunded", "", map[string]interface{}{"id": "ch_1", "payment_intent": "pi_1"})
+
+	repo.On("ClaimWebhookEvent", ctx, mock.Anything).Return(false, nil).Once()
+
+	require.NoError(t, service.HandleStripeEvent(ctx, event, payload))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "GetPaymentByStripePaymentID", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "FinishWebhookEvent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleStripeEvent_FailureRecordedForRetry(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	event, payload := newStripeEvent(t, "charge.refunded", "", map[string]interface{}{"id": "ch_1", "payment_intent": "pi_1"})
+
+	expectEventClaimed(repo, event, true)
+	repo.On("GetPaymentByStripePaymentID", ctx, "pi_1").Return(nil, errors.New("db down")).Once()
+
+	err := service.HandleStripeEvent(ctx, event, payload)
+	assert.ErrorContains(t, err, "db down")
+	repo.AssertExpectations(t)
+}
+
+// stripeRefunds lists succeeded refunds as they appear on a charge
+func stripeRefunds(amounts map[string]int64) map[string]interface{} {
+	data := make([]map[string]interface{}, 0, len(amounts))
+	for id, amount := range amounts {
+		data = append(data, map[string]interface{}{"id": id, "object": "refund", "amount": amount, "status": "succeeded"})
+	}
+	return map[string]interface{}{"object": "list", "data": data}
+}
+
+func TestHandleStripeEvent_ChargePartiallyRefundedOnStripe(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	journal := &recordingLedger{}
+	service.SetLedger(journal)
+
+	payment := &models.Payment{
+		ID:            uuid.New(),
+		RideID:        uuid.New(),
+		RiderID:       uuid.New(),
+		DriverID:      uuid.New(),
+		Amount:        30.00,
+		Currency:      "usd",
+		PaymentMethod: "stripe",
+		Status:        "completed",
+	}
+	event, payload := newStripeEvent(t, "charge.refunded", "", map[string]interface{}{
+		"id": "ch_1", "object": "charge", "payment_intent": "pi_1", "amount_refunded": 1200, "refunded": false,
+		"refunds": stripeRefunds(map[string]int64{"re_1": 500, "re_2": 700}),
+	})
+
+	expectEventClaimed(repo, event, false)
+	repo.On("GetPaymentByStripePaymentID", ctx, "pi_1").Return(payment, nil).Once()
+
+	require.NoError(t, service.HandleStripeEvent(ctx, event, payload))
+	repo.AssertExpectations(t)
+	// A partial refund leaves the payment completed
+	repo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	if assert.Len(t, journal.entries, 3) {
+		keys := map[string]bool{}
+		for _, entry := range journal.entries[1:] {
+			assert.Equal(t, ledger.EntryRideRefund, entry.Type)
+			assert.NoError(t, entry.Validate())
+			keys[entry.IdempotencyKey] = true
+		}
+		assert.Len(t, keys, 2)
+	}
+}
+
+func TestHandleStripeEvent_ChargeFullyRefundedOnStripe(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	journal := &recordingLedger{}
+	service.SetLedger(journal)
+
+	payment := &models.Payment{
+		ID:            uuid.New(),
+		RideID:        uuid.New(),
+		RiderID:       uuid.New(),
+		DriverID:      uuid.New(),
+		Amount:        30.00,
+		Currency:      "usd",
+		PaymentMethod: "stripe",
+		Status:        "completed",
+	}
+	event, payload := newStripeEvent(t, "charge.refunded", "", map[string]interface{}{
+		"id": "ch_1", "object": "charge", "payment_intent": "pi_1", "amount_refunded": 3000, "refunded": true,
+		"refunds": stripeRefunds(map[string]int64{"re_1": 3000}),
+	})
+
+	expectEventClaimed(repo, event, false)
+	repo.On("GetPaymentByStripePaymentID", ctx, "pi_1").Return(payment, nil).Once()
+	repo.On("UpdatePaymentStatus", ctx, payment.ID, "refunded", mock.MatchedBy(func(chargeID *string) bool {
+		return chargeID != nil && *chargeID == "ch_1"
+	})).Return(nil).Once()
+
+	require.NoError(t, service.HandleStripeEvent(ctx, event, payload))
+	repo.AssertExpectations(t)
+	if assert.Len(t, journal.entries, 2) {
+		assert.Equal(t, ledger.EntryRideRefund, journal.entries[1].Type)
+		assert.Contains(t, journal.entries[1].IdempotencyKey, "re_1")
+	}
+}
+
+func TestHandleStripeEvent_ChargeRefundedByApp(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	event, payload := newStripeEvent(t, "charge.refunded", "", map[string]interface{}{"id": "ch_1", "payment_intent": "pi_1"})
+
+	// ProcessRefund already marked the payment refunded
+	expectEventClaimed(repo, event, false)
+	repo.On("GetPaymentByStripePaymentID", ctx, "pi_1").Return(&models.Payment{ID: uuid.New(), Status: "refunded"}, nil).Once()
+
+	require.NoError(t, service.HandleStripeEvent(ctx, event, payload))
+	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdatePaymentStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHandleStripeEvent_Chargeback(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	recorder := new(mockDisputeRecorder)
+	service.SetDisputes(recorder)
+
+	payment := &models.Payment{ID: uuid.New(), RideID: uuid.New(), RiderID: uuid.New(), DriverID: uuid.New(), Amount: 42.00, Currency: "usd", Status: "completed"}
+	object := map[string]interface{}{
+		"id": "dp_1", "object": "dispute", "amount": 4200, "currency": "usd", "payment_intent": "pi_1",
+		"reason": "fraudulent", "status": "needs_response", "evidence_details": map[string]interface{}{"due_by": 1775001600},
+	}
+	matchesChargeback := mock.MatchedBy(func(cb *disputes.Chargeback) bool {
+		return cb.ProviderDisputeID == "dp_1" && cb.RideID == payment.RideID && cb.UserID == payment.RiderID &&
+			cb.Amount == 42.00 && cb.Fare == 42.00 && cb.ProviderReason == "fraudulent" &&
+			cb.DriverID != nil && *cb.DriverID == payment.DriverID &&
+			cb.EvidenceDueBy != nil && cb.EvidenceDueBy.Unix() == 1775001600
+	})
+	repo.On("GetPaymentByStripePaymentID", ctx, "pi_1").Return(payment, nil).Twice()
+
+	created, payload := newStripeEvent(t, "charge.dispute.created", "", object)
+	expectEventClaimed(repo, created, false)
+	recorder.On("OpenChargeback", ctx, matchesChargeback).Return(&disputes.Dispute{DisputeNumber: "DSP-000001"}, nil).Once()
+	require.NoError(t, service.HandleStripeEvent(ctx, created, payload))
+
+	object["status"] = "lost"
+	closed, payload := newStripeEvent(t, "charge.dispute.closed", "", object)
+	expectEventClaimed(repo, closed, false)
+	recorder.On("CloseChargeback", ctx, matchesChargeback, false).Return(&disputes.Dispute{DisputeNumber: "DSP-000001"}, nil).Once()
+	require.NoError(t, service.HandleStripeEvent(ctx, closed, payload))
+
+	repo.AssertExpectations(t)
+	recorder.AssertExpectations(t)
+}
+
+func TestHandleStripeEvent_RequiresActionFailsHold(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	piID := "pi_hold"
+	hold := &models.PaymentHold{ID: uuid.New(), RideID: uuid.New(), StripePaymentIntentID: &piID, Currency: "USD", Status: models.PaymentHoldAuthorized}
+	event, payload := newStripeEvent(t, "payment_intent.requires_action", "", map[string]interface{}{
+		"id": piID, "object": "payment_intent", "status": "requires_action",
+	})
+
+	expectEventClaimed(repo, event, false)
+	repo.On("GetPaymentHoldByPaymentIntent", ctx, piID).Return(hold, nil).Once()
+	repo.On("UpdatePaymentHold", ctx, mock.MatchedBy(func(h *models.PaymentHold) bool {
+		return h.ID == hold.ID && h.Status == models.PaymentHoldFailed && h.FailureReason != nil
+	})).Return(nil).Once()
+
+	require.NoError(t, service.HandleStripeEvent(ctx, event, payload))
+	repo.AssertExpectations(t)
+}
+
+func TestHandleStripeEvent_BankPayouts(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mocks.MockPaymentsRepository)
+	service := NewService(repo, new(mocks.MockStripeClient), nil)
+	payouts := new(mockBankPayouts)
+	service.SetBankPayouts(payouts)
+
+	failed, payload := newStripeEvent(t, "payout.failed", "acct_driver", map[string]interface{}{
+		"id": "po_1", "object": "payout", "amount": 8250, "currency": "usd", "status": "failed",
+		"failure_code": "account_closed", "failure_message": "The bank account has been closed.",
+	})
+	expectEventClaimed(repo, failed, false)
+	payouts.On("HandleProviderPayout", ctx, &earnings.ProviderPayout{
+		ID:             "po_1",
+		AccountID:      "acct_driver",
+		Amount:         money.New(8250, "USD"),
+		FailureCode:    "account_closed",
+		FailureMessage: "The bank account has been closed.",
+	}).Return(nil).Once()
+	require.NoError(t, service.HandleStripeEvent(ctx, failed, payload))
+
+	paid, payload := newStripeEvent(t, "payout.paid", "acct_driver", map[string]interface{}{
+		"id": "po_2", "object": "payout", "amount": 8250, "currency": "usd", "status": "paid",
+	})
+	expectEventClaimed(repo, paid, false)
+	payouts.On("HandleProviderPayout", ctx, mock.MatchedBy(func(p *earnings.ProviderPayout) bool {
+		return p.ID == "po_2" && p.Paid
+	})).Return(nil).Once()
+	require.NoError(t, service.HandleStripeEvent(ctx, paid, payload))
+
+	// The platform's own payouts are not a driver's
+	platform, payload := newStripeEvent(t, "payout.paid", "", map[string]interface{}{"id": "po_3", "object": "payout"})
+	expectEventClaimed(repo, platform, false)
+	require.NoError(t, service.HandleStripeEvent(ctx, platform, payload))
+
+	repo.AssertExpectations(t)
+	payouts.AssertExpectations(t)
+}
@@ -2,6 +2,7 @@ package pricing
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -22,12 +23,7 @@ func NewAdminHandler(repo RepositoryInterface, service *Service) *AdminHandler {
 
 // getAdminID extracts the authenticated admin user ID from the request context
 func getAdminID(c *gin.Context) uuid.UUID {
-	if id, ok := c.Get("user_id"); ok {
-		if uid, ok := id.(uuid.UUID); ok {
-			return uid
-		}
-	}
-	return uuid.Nil
+	return getUserID(c)
 }
 
 // RegisterRoutes registers pricing admin routes
@@ -39,10 +35,15 @@ func (h *AdminHandler) RegisterRoutes(rg *gin.RouterGroup) {
 		{
 			versions.GET("", h.ListVersions)
 			versions.POST("", h.CreateVersion)
+			versions.GET("/performance", h.GetVersionPerformance)
+			versions.GET("/history", h.ListVersionHistory)
 			versions.GET("/:id", h.GetVersion)
 			versions.PUT("/:id", h.UpdateVersion)
 			versions.POST("/:id/activate", h.ActivateVersion)
 			versions.POST("/:id/archive", h.ArchiveVersion)
+			versions.POST("/:id/ab-test", h.StartABTest)
+			versions.POST("/:id/schedule", h.ScheduleVersion)
+			versions.POST("/:id/simulate", h.SimulateVersion)
 			versions.POST("/:id/clone", h.CloneVersion)
 
 			// Configs under version
@@ -256,6 +257,163 @@ func (h *AdminHandler) ArchiveVersion(c *gin.Context) {
 	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "Version archived successfully")
 }
 
+func (h *AdminHandler) ScheduleVersion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "Invalid version ID")
+		return
+	}
+
+	var req ScheduleVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.EffectiveUntil != nil && !req.EffectiveUntil.After(req.EffectiveFrom) {
+		common.ErrorResponse(c, http.StatusBadRequest, "effective_until must be after effective_from")
+		return
+	}
+
+	version, err := h.repo.GetVersionByID(c.Request.Context(), id)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusNotFound, "Version not found")
+		return
+	}
+	if version.Status != VersionStatusDraft {
+		common.ErrorResponse(c, http.StatusBadRequest, "Only draft versions can be scheduled")
+		return
+	}
+
+	adminID := getAdminID(c)
+	if err := h.repo.ScheduleVersion(c.Request.Context(), id, req.EffectiveFrom, req.EffectiveUntil, req.RollbackGuard, adminID); err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to schedule version")
+		return
+	}
+
+	newValues := map[string]interface{}{"effective_from": req.EffectiveFrom, "effective_until": req.EffectiveUntil}
+	if req.RollbackGuard != nil {
+		newValues["rollback_guard"] = req.RollbackGuard
+	}
+	h.repo.InsertPricingAuditLog(c.Request.Context(), adminID, "schedule_version", "pricing_config_version", id, nil, newValues, "")
+	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "Version scheduled successfully")
+}
+
+// ListVersionHistory lists version switches, optionally only those affecting
+// one city
+func (h *AdminHandler) ListVersionHistory(c *gin.Context) {
+	params := pagination.ParseParams(c)
+
+	var cityID *uuid.UUID
+	if raw := c.Query("city_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "Invalid city ID")
+			return
+		}
+		cityID = &id
+	}
+
+	entries, total, err := h.repo.ListVersionHistory(c.Request.Context(), cityID, params.Limit, params.Offset)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch version history")
+		return
+	}
+
+	meta := pagination.BuildMeta(params.Limit, params.Offset, total)
+	common.SuccessResponseWithMeta(c, entries, meta)
+}
+
+// SimulateVersion replays past completed rides against a version and returns
+// the fare and driver earnings impact without changing any config
+func (h *AdminHandler) SimulateVersion(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "Invalid version ID")
+		return
+	}
+
+	var req SimulateVersionRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if _, err := h.repo.GetVersionByID(c.Request.Context(), id); err != nil {
+		common.ErrorResponse(c, http.StatusNotFound, "Version not found")
+		return
+	}
+
+	result, err := h.service.SimulateVersion(c.Request.Context(), id, req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to simulate version")
+		return
+	}
+
+	common.SuccessResponse(c, result)
+}
+
+func (h *AdminHandler) StartABTest(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "Invalid version ID")
+		return
+	}
+
+	var req StartABTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := h.repo.GetVersionByID(c.Request.Context(), id)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusNotFound, "Version not found")
+		return
+	}
+	if version.Status != VersionStatusDraft {
+		common.ErrorResponse(c, http.StatusBadRequest, "Only draft versions can be A/B tested")
+		return
+	}
+
+	adminID := getAdminID(c)
+	if err := h.repo.StartABTest(c.Request.Context(), id, req.Percentage, adminID); err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to start A/B test")
+		return
+	}
+
+	h.repo.InsertPricingAuditLog(c.Request.Context(), adminID, "start_ab_test", "pricing_config_version", id, nil,
+		map[string]interface{}{"ab_test_percentage": req.Percentage}, "")
+	common.SuccessResponseWithStatus(c, http.StatusOK, nil, "A/B test started successfully")
+}
+
+// GetVersionPerformance compares conversion and revenue between pricing
+// versions, e.g. an A/B test version against the active one
+func (h *AdminHandler) GetVersionPerformance(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+	if raw := c.Query("since"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = parsed
+	}
+
+	results, err := h.repo.GetVersionPerformance(c.Request.Context(), since)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "Failed to fetch version performance")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"since": since, "versions": results})
+}
+
 func (h *AdminHandler) CloneVersion(c *gin.Context) {
 	sourceID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
@@ -38,6 +38,24 @@ type CalculateInput struct {
 	DemandSupplyRatio float64 // Optional: demand/supply ratio for surge
 	NegotiatedFare   *float64 // Optional: pre-negotiated fare
 	Currency         string   // Target currency
+
+	// Multi-stop rides. When Legs is set, distance and time are charged per
+	// leg and DistanceKm/DurationMin are ignored.
+	Legs            []LegInput // Optional: route legs from pickup through each stop to dropoff
+	StopWaitMinutes int        // Optional: total wait time at intermediate stops
+
+	// Pricing version. VersionID pins the version a ride was quoted with;
+	// otherwise RiderID buckets the rider into any running A/B test version.
+	RiderID   *uuid.UUID // Optional: rider being priced
+	VersionID *uuid.UUID // Optional: version to price with
+
+	PricedAt time.Time // Optional: when the ride is priced, e.g. to replay past rides; defaults to now
+}
+
+// LegInput is one leg of a multi-stop route
+type LegInput struct {
+	DistanceKm  float64
+	DurationMin int
 }
 
 // Calculate performs a complete fare calculation
@@ -79,16 +97,13 @@ func (c *Calculator) Calculate(ctx context.Context, input CalculateInput) (*Fare
 		CityID:     cityID,
 		ZoneID:     pickupZoneID,
 		RideTypeID: input.RideTypeID,
+		RiderID:    input.RiderID,
+		VersionID:  input.VersionID,
 	})
 	if err != nil {
 		return nil, err
 	}
-
-	// Get version ID
-	versionID, err := c.repo.GetActiveVersionID(ctx)
-	if err != nil {
-		versionID = uuid.Nil
-	}
+	versionID := pricing.VersionID
 
 	// Calculate base components
 	result := &FareCalculation{
@@ -100,15 +115,27 @@ func (c *Calculator) Calculate(ctx context.Context, input CalculateInput) (*Fare
 		TimeCharge:       float64(input.DurationMin) * pricing.PerMinuteRate,
 		BookingFee:       pricing.BookingFee,
 		PricingVersionID: versionID,
+		ABTestVersion:    pricing.ABTest,
+	}
+	if len(input.Legs) > 0 {
+		result.applyLegs(input.Legs, pricing)
+	}
+	if input.StopWaitMinutes > 0 {
+		result.StopWaitMinutes = input.StopWaitMinutes
+		result.StopWaitCharge = float64(input.StopWaitMinutes) * pricing.PerMinuteRate
+	}
+
+	now := input.PricedAt
+	if now.IsZero() {
+		now = time.Now()
 	}
 
 	// Calculate zone fees
 	result.ZoneFeesTotal, result.ZoneFeesBreakdown = c.calculateZoneFees(
-		ctx, versionID, pickupZoneID, dropoffZoneID, input.RideTypeID,
+		ctx, versionID, pickupZoneID, dropoffZoneID, input.RideTypeID, now,
 	)
 
 	// Calculate multipliers
-	now := time.Now()
 	result.TimeMultiplier = c.getTimeMultiplier(ctx, versionID, countryID, regionID, cityID, now)
 	result.WeatherMultiplier = c.getWeatherMultiplier(ctx, versionID, countryID, regionID, cityID, input.WeatherCondition)
 	result.EventMultiplier = c.getEventMultiplier(ctx, versionID, cityID, pickupZoneID, now)
@@ -118,7 +145,7 @@ func (c *Calculator) Calculate(ctx context.Context, input CalculateInput) (*Fare
 	result.TotalMultiplier = result.TimeMultiplier * result.WeatherMultiplier * result.EventMultiplier * result.SurgeMultiplier
 
 	// Calculate subtotal
-	baseAmount := result.BaseFare + result.DistanceCharge + result.TimeCharge + result.BookingFee
+	baseAmount := result.BaseFare + result.DistanceCharge + result.TimeCharge + result.StopWaitCharge + result.BookingFee
 	result.Subtotal = (baseAmount * result.TotalMultiplier) + result.ZoneFeesTotal
 
 	// Apply minimum fare
@@ -157,7 +184,7 @@ func (c *Calculator) Calculate(ctx context.Context, input CalculateInput) (*Fare
 }
 
 // calculateZoneFees calculates fees for pickup and dropoff zones
-func (c *Calculator) calculateZoneFees(ctx context.Context, versionID uuid.UUID, pickupZoneID, dropoffZoneID *uuid.UUID, rideTypeID *uuid.UUID) (float64, []ZoneFeeBreakdown) {
+func (c *Calculator) calculateZoneFees(ctx context.Context, versionID uuid.UUID, pickupZoneID, dropoffZoneID *uuid.UUID, rideTypeID *uuid.UUID, now time.Time) (float64, []ZoneFeeBreakdown) {
 	if versionID == uuid.Nil {
 		return 0, nil
 	}
@@ -180,7 +207,7 @@ func (c *Calculator) calculateZoneFees(ctx context.Context, versionID uuid.UUID,
 		}
 
 		// Check schedule if applicable
-		if fee.Schedule != nil && !c.isWithinSchedule(fee.Schedule) {
+		if fee.Schedule != nil && !c.isWithinSchedule(fee.Schedule, now) {
 			continue
 		}
 
@@ -272,13 +299,12 @@ func (c *Calculator) getSurgeMultiplier(ctx context.Context, versionID uuid.UUID
 	return 1.0
 }
 
-// isWithinSchedule checks if the current time is within a fee schedule
-func (c *Calculator) isWithinSchedule(schedule *FeeSchedule) bool {
+// isWithinSchedule checks if now is within a fee schedule
+func (c *Calculator) isWithinSchedule(schedule *FeeSchedule, now time.Time) bool {
 	if schedule == nil {
 		return true
 	}
 
-	now := time.Now()
 	dayOfWeek := int(now.Weekday())
 
 	// Check day
@@ -306,11 +332,37 @@ func (c *Calculator) isWithinSchedule(schedule *FeeSchedule) bool {
 	return currentTime >= startTime && currentTime <= endTime
 }
 
+// applyLegs charges distance and time leg by leg and replaces the trip totals
+// with the sum over all legs.
+func (f *FareCalculation) applyLegs(legs []LegInput, pricing *ResolvedPricing) {
+	f.DistanceKm = 0
+	f.DurationMin = 0
+	f.DistanceCharge = 0
+	f.TimeCharge = 0
+	f.Legs = make([]LegFare, 0, len(legs))
+
+	for i, leg := range legs {
+		lf := LegFare{
+			Leg:            i + 1,
+			DistanceKm:     leg.DistanceKm,
+			DurationMin:    leg.DurationMin,
+			DistanceCharge: leg.DistanceKm * pricing.PerKmRate,
+			TimeCharge:     float64(leg.DurationMin) * pricing.PerMinuteRate,
+		}
+		f.DistanceKm += lf.DistanceKm
+		f.DurationMin += lf.DurationMin
+		f.DistanceCharge += lf.DistanceCharge
+		f.TimeCharge += lf.TimeCharge
+		f.Legs = append(f.Legs, lf)
+	}
+}
+
 // roundValues rounds all monetary values to 2 decimal places
 func (f *FareCalculation) roundValues() {
 	f.BaseFare = math.Round(f.BaseFare*100) / 100
 	f.DistanceCharge = math.Round(f.DistanceCharge*100) / 100
 	f.TimeCharge = math.Round(f.TimeCharge*100) / 100
+	f.StopWaitCharge = math.Round(f.StopWaitCharge*100) / 100
 	f.BookingFee = math.Round(f.BookingFee*100) / 100
 	f.ZoneFeesTotal = math.Round(f.ZoneFeesTotal*100) / 100
 	f.Subtotal = math.Round(f.Subtotal*100) / 100
@@ -322,6 +374,10 @@ func (f *FareCalculation) roundValues() {
 	for i := range f.ZoneFeesBreakdown {
 		f.ZoneFeesBreakdown[i].Amount = math.Round(f.ZoneFeesBreakdown[i].Amount*100) / 100
 	}
+	for i := range f.Legs {
+		f.Legs[i].DistanceCharge = math.Round(f.Legs[i].DistanceCharge*100) / 100
+		f.Legs[i].TimeCharge = math.Round(f.Legs[i].TimeCharge*100) / 100
+	}
 }
 
 // QuickEstimate performs a quick fare estimate without zone fees or event multipliers
@@ -40,6 +40,7 @@ func (h *Handler) GetEstimate(c *gin.Context) {
 		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	req.RiderID = getUserID(c)
 
 	estimate, err := h.service.GetEstimate(c.Request.Context(), req)
 	if err != nil {
@@ -57,6 +58,7 @@ func (h *Handler) GetBulkEstimate(c *gin.Context) {
 		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	req.RiderID = getUserID(c)
 
 	// Get available ride types for the pickup location
 	var rideTypes []RideTypeInfo
@@ -104,6 +106,16 @@ func (h *Handler) GetBulkEstimate(c *gin.Context) {
 	common.SuccessResponse(c, bulkEstimate)
 }
 
+// getUserID returns the authenticated user's ID, or uuid.Nil
+func getUserID(c *gin.Context) uuid.UUID {
+	if id, ok := c.Get("user_id"); ok {
+		if uid, ok := id.(uuid.UUID); ok {
+			return uid
+		}
+	}
+	return uuid.Nil
+}
+
 // Helper functions for type conversion
 func getString(m map[string]interface{}, key string) string {
 	if v, ok := m[key].(string); ok {
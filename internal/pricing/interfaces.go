@@ -11,6 +11,7 @@ import (
 type RepositoryInterface interface {
 	// Existing read operations
 	GetActiveVersionID(ctx context.Context) (uuid.UUID, error)
+	GetABTestVersions(ctx context.Context) ([]*PricingConfigVersion, error)
 	GetPricingConfigsForResolution(ctx context.Context, versionID uuid.UUID, countryID, regionID, cityID, zoneID, rideTypeID *uuid.UUID) ([]*PricingConfig, error)
 	GetZoneFees(ctx context.Context, versionID uuid.UUID, pickupZoneID, dropoffZoneID *uuid.UUID, rideTypeID *uuid.UUID) ([]*ZoneFee, error)
 	GetTimeMultipliers(ctx context.Context, versionID uuid.UUID, countryID, regionID, cityID *uuid.UUID, t time.Time) ([]*TimeMultiplier, error)
@@ -27,6 +28,18 @@ type RepositoryInterface interface {
 	ActivateVersion(ctx context.Context, id uuid.UUID, adminID uuid.UUID) error
 	ArchiveVersion(ctx context.Context, id uuid.UUID) error
 	CloneVersion(ctx context.Context, sourceID uuid.UUID, name string, adminID uuid.UUID) (*PricingConfigVersion, error)
+	StartABTest(ctx context.Context, id uuid.UUID, percentage int, adminID uuid.UUID) error
+	GetVersionPerformance(ctx context.Context, since time.Time) ([]*VersionPerformance, error)
+
+	// Version schedule
+	ScheduleVersion(ctx context.Context, id uuid.UUID, effectiveFrom time.Time, effectiveUntil *time.Time, guard *RollbackGuard, adminID uuid.UUID) error
+	GetDueScheduledVersions(ctx context.Context) ([]*PricingConfigVersion, error)
+	GetActiveVersion(ctx context.Context) (*PricingConfigVersion, error)
+	SwitchActiveVersion(ctx context.Context, sw VersionSwitch) error
+	ArchiveExpiredABTests(ctx context.Context) (int64, error)
+	MarkRollbackChecked(ctx context.Context, id uuid.UUID) error
+	GetRideConversion(ctx context.Context, metric string, from, to time.Time) (float64, int, error)
+	ListVersionHistory(ctx context.Context, cityID *uuid.UUID, limit, offset int) ([]*VersionHistoryEntry, int64, error)
 
 	// Config CRUD
 	CreateConfig(ctx context.Context, config *PricingConfig) error
@@ -8,20 +8,20 @@ import (
 
 // PricingConfig represents a pricing configuration at any hierarchy level
 type PricingConfig struct {
-	ID                   uuid.UUID  `json:"id" db:"id"`
-	VersionID            uuid.UUID  `json:"version_id" db:"version_id"`
-	CountryID            *uuid.UUID `json:"country_id,omitempty" db:"country_id"`
-	RegionID             *uuid.UUID `json:"region_id,omitempty" db:"region_id"`
-	CityID               *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
-	ZoneID               *uuid.UUID `json:"zone_id,omitempty" db:"zone_id"`
-	RideTypeID           *uuid.UUID `json:"ride_type_id,omitempty" db:"ride_type_id"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	VersionID  uuid.UUID  `json:"version_id" db:"version_id"`
+	CountryID  *uuid.UUID `json:"country_id,omitempty" db:"country_id"`
+	RegionID   *uuid.UUID `json:"region_id,omitempty" db:"region_id"`
+	CityID     *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
+	ZoneID     *uuid.UUID `json:"zone_id,omitempty" db:"zone_id"`
+	RideTypeID *uuid.UUID `json:"ride_type_id,omitempty" db:"ride_type_id"`
 
 	// Core pricing (nullable for inheritance)
-	BaseFare          *float64 `json:"base_fare,omitempty" db:"base_fare"`
-	PerKmRate         *float64 `json:"per_km_rate,omitempty" db:"per_km_rate"`
-	PerMinuteRate     *float64 `json:"per_minute_rate,omitempty" db:"per_minute_rate"`
-	MinimumFare       *float64 `json:"minimum_fare,omitempty" db:"minimum_fare"`
-	BookingFee        *float64 `json:"booking_fee,omitempty" db:"booking_fee"`
+	BaseFare      *float64 `json:"base_fare,omitempty" db:"base_fare"`
+	PerKmRate     *float64 `json:"per_km_rate,omitempty" db:"per_km_rate"`
+	PerMinuteRate *float64 `json:"per_minute_rate,omitempty" db:"per_minute_rate"`
+	MinimumFare   *float64 `json:"minimum_fare,omitempty" db:"minimum_fare"`
+	BookingFee    *float64 `json:"booking_fee,omitempty" db:"booking_fee"`
 
 	// Commission
 	PlatformCommissionPct *float64 `json:"platform_commission_pct,omitempty" db:"platform_commission_pct"`
@@ -54,6 +54,7 @@ type CancellationFee struct {
 type ResolvedPricing struct {
 	// Source IDs for audit
 	VersionID  uuid.UUID  `json:"version_id"`
+	ABTest     bool       `json:"ab_test,omitempty"` // VersionID is an A/B test version the rider was bucketed into
 	CountryID  *uuid.UUID `json:"country_id,omitempty"`
 	RegionID   *uuid.UUID `json:"region_id,omitempty"`
 	CityID     *uuid.UUID `json:"city_id,omitempty"`
@@ -61,17 +62,17 @@ type ResolvedPricing struct {
 	RideTypeID *uuid.UUID `json:"ride_type_id,omitempty"`
 
 	// Resolved values (guaranteed non-nil)
-	BaseFare              float64 `json:"base_fare"`
-	PerKmRate             float64 `json:"per_km_rate"`
-	PerMinuteRate         float64 `json:"per_minute_rate"`
-	MinimumFare           float64 `json:"minimum_fare"`
-	BookingFee            float64 `json:"booking_fee"`
-	PlatformCommissionPct float64 `json:"platform_commission_pct"`
-	DriverIncentivePct    float64 `json:"driver_incentive_pct"`
-	SurgeMinMultiplier    float64 `json:"surge_min_multiplier"`
-	SurgeMaxMultiplier    float64 `json:"surge_max_multiplier"`
-	TaxRatePct            float64 `json:"tax_rate_pct"`
-	TaxInclusive          bool    `json:"tax_inclusive"`
+	BaseFare              float64           `json:"base_fare"`
+	PerKmRate             float64           `json:"per_km_rate"`
+	PerMinuteRate         float64           `json:"per_minute_rate"`
+	MinimumFare           float64           `json:"minimum_fare"`
+	BookingFee            float64           `json:"booking_fee"`
+	PlatformCommissionPct float64           `json:"platform_commission_pct"`
+	DriverIncentivePct    float64           `json:"driver_incentive_pct"`
+	SurgeMinMultiplier    float64           `json:"surge_min_multiplier"`
+	SurgeMaxMultiplier    float64           `json:"surge_max_multiplier"`
+	TaxRatePct            float64           `json:"tax_rate_pct"`
+	TaxInclusive          bool              `json:"tax_inclusive"`
 	CancellationFees      []CancellationFee `json:"cancellation_fees"`
 
 	// Inheritance chain for debugging
@@ -80,19 +81,19 @@ type ResolvedPricing struct {
 
 // ZoneFee represents an additional fee for a pricing zone
 type ZoneFee struct {
-	ID            uuid.UUID  `json:"id" db:"id"`
-	ZoneID        uuid.UUID  `json:"zone_id" db:"zone_id"`
-	VersionID     uuid.UUID  `json:"version_id" db:"version_id"`
-	FeeType       string     `json:"fee_type" db:"fee_type"` // pickup_fee, dropoff_fee, toll, etc.
-	RideTypeID    *uuid.UUID `json:"ride_type_id,omitempty" db:"ride_type_id"`
-	Amount        float64    `json:"amount" db:"amount"`
-	IsPercentage  bool       `json:"is_percentage" db:"is_percentage"`
-	AppliesPickup bool       `json:"applies_pickup" db:"applies_pickup"`
-	AppliesDropoff bool      `json:"applies_dropoff" db:"applies_dropoff"`
-	Schedule      *FeeSchedule `json:"schedule,omitempty" db:"schedule"`
-	IsActive      bool       `json:"is_active" db:"is_active"`
-	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+	ID             uuid.UUID    `json:"id" db:"id"`
+	ZoneID         uuid.UUID    `json:"zone_id" db:"zone_id"`
+	VersionID      uuid.UUID    `json:"version_id" db:"version_id"`
+	FeeType        string       `json:"fee_type" db:"fee_type"` // pickup_fee, dropoff_fee, toll, etc.
+	RideTypeID     *uuid.UUID   `json:"ride_type_id,omitempty" db:"ride_type_id"`
+	Amount         float64      `json:"amount" db:"amount"`
+	IsPercentage   bool         `json:"is_percentage" db:"is_percentage"`
+	AppliesPickup  bool         `json:"applies_pickup" db:"applies_pickup"`
+	AppliesDropoff bool         `json:"applies_dropoff" db:"applies_dropoff"`
+	Schedule       *FeeSchedule `json:"schedule,omitempty" db:"schedule"`
+	IsActive       bool         `json:"is_active" db:"is_active"`
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`
 }
 
 // FeeSchedule defines when a fee applies
@@ -104,20 +105,20 @@ type FeeSchedule struct {
 
 // TimeMultiplier represents a time-based pricing multiplier
 type TimeMultiplier struct {
-	ID          uuid.UUID  `json:"id" db:"id"`
-	VersionID   uuid.UUID  `json:"version_id" db:"version_id"`
-	CountryID   *uuid.UUID `json:"country_id,omitempty" db:"country_id"`
-	RegionID    *uuid.UUID `json:"region_id,omitempty" db:"region_id"`
-	CityID      *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
-	Name        string     `json:"name" db:"name"`
-	DaysOfWeek  []int      `json:"days_of_week" db:"days_of_week"` // 0=Sunday, 6=Saturday
-	StartTime   string     `json:"start_time" db:"start_time"`
-	EndTime     string     `json:"end_time" db:"end_time"`
-	Multiplier  float64    `json:"multiplier" db:"multiplier"`
-	Priority    int        `json:"priority" db:"priority"`
-	IsActive    bool       `json:"is_active" db:"is_active"`
-	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	VersionID  uuid.UUID  `json:"version_id" db:"version_id"`
+	CountryID  *uuid.UUID `json:"country_id,omitempty" db:"country_id"`
+	RegionID   *uuid.UUID `json:"region_id,omitempty" db:"region_id"`
+	CityID     *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
+	Name       string     `json:"name" db:"name"`
+	DaysOfWeek []int      `json:"days_of_week" db:"days_of_week"` // 0=Sunday, 6=Saturday
+	StartTime  string     `json:"start_time" db:"start_time"`
+	EndTime    string     `json:"end_time" db:"end_time"`
+	Multiplier float64    `json:"multiplier" db:"multiplier"`
+	Priority   int        `json:"priority" db:"priority"`
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // WeatherMultiplier represents a weather-based pricing multiplier
@@ -135,36 +136,36 @@ type WeatherMultiplier struct {
 
 // EventMultiplier represents an event-based pricing multiplier
 type EventMultiplier struct {
+	ID                     uuid.UUID  `json:"id" db:"id"`
+	VersionID              uuid.UUID  `json:"version_id" db:"version_id"`
+	ZoneID                 *uuid.UUID `json:"zone_id,omitempty" db:"zone_id"`
+	CityID                 *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
+	EventName              string     `json:"event_name" db:"event_name"`
+	EventType              string     `json:"event_type" db:"event_type"` // sports, concert, conference, etc.
+	StartsAt               time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt                 time.Time  `json:"ends_at" db:"ends_at"`
+	PreEventMinutes        int        `json:"pre_event_minutes" db:"pre_event_minutes"`
+	PostEventMinutes       int        `json:"post_event_minutes" db:"post_event_minutes"`
+	Multiplier             float64    `json:"multiplier" db:"multiplier"`
+	ExpectedDemandIncrease *int       `json:"expected_demand_increase,omitempty" db:"expected_demand_increase"`
+	IsActive               bool       `json:"is_active" db:"is_active"`
+	CreatedAt              time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SurgeThreshold represents a demand/supply ratio threshold
+type SurgeThreshold struct {
 	ID                   uuid.UUID  `json:"id" db:"id"`
 	VersionID            uuid.UUID  `json:"version_id" db:"version_id"`
-	ZoneID               *uuid.UUID `json:"zone_id,omitempty" db:"zone_id"`
+	CountryID            *uuid.UUID `json:"country_id,omitempty" db:"country_id"`
+	RegionID             *uuid.UUID `json:"region_id,omitempty" db:"region_id"`
 	CityID               *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
-	EventName            string     `json:"event_name" db:"event_name"`
-	EventType            string     `json:"event_type" db:"event_type"` // sports, concert, conference, etc.
-	StartsAt             time.Time  `json:"starts_at" db:"starts_at"`
-	EndsAt               time.Time  `json:"ends_at" db:"ends_at"`
-	PreEventMinutes      int        `json:"pre_event_minutes" db:"pre_event_minutes"`
-	PostEventMinutes     int        `json:"post_event_minutes" db:"post_event_minutes"`
+	DemandSupplyRatioMin float64    `json:"demand_supply_ratio_min" db:"demand_supply_ratio_min"`
+	DemandSupplyRatioMax *float64   `json:"demand_supply_ratio_max,omitempty" db:"demand_supply_ratio_max"`
 	Multiplier           float64    `json:"multiplier" db:"multiplier"`
-	ExpectedDemandIncrease *int     `json:"expected_demand_increase,omitempty" db:"expected_demand_increase"`
 	IsActive             bool       `json:"is_active" db:"is_active"`
 	CreatedAt            time.Time  `json:"created_at" db:"created_at"`
 }
 
-// SurgeThreshold represents a demand/supply ratio threshold
-type SurgeThreshold struct {
-	ID                  uuid.UUID  `json:"id" db:"id"`
-	VersionID           uuid.UUID  `json:"version_id" db:"version_id"`
-	CountryID           *uuid.UUID `json:"country_id,omitempty" db:"country_id"`
-	RegionID            *uuid.UUID `json:"region_id,omitempty" db:"region_id"`
-	CityID              *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
-	DemandSupplyRatioMin float64   `json:"demand_supply_ratio_min" db:"demand_supply_ratio_min"`
-	DemandSupplyRatioMax *float64  `json:"demand_supply_ratio_max,omitempty" db:"demand_supply_ratio_max"`
-	Multiplier          float64    `json:"multiplier" db:"multiplier"`
-	IsActive            bool       `json:"is_active" db:"is_active"`
-	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
-}
-
 // FareCalculation represents a complete fare calculation
 type FareCalculation struct {
 	// Input
@@ -178,6 +179,11 @@ type FareCalculation struct {
 	TimeCharge     float64 `json:"time_charge"`
 	BookingFee     float64 `json:"booking_fee"`
 
+	// Multi-stop rides
+	Legs            []LegFare `json:"legs,omitempty"`
+	StopWaitMinutes int       `json:"stop_wait_minutes,omitempty"`
+	StopWaitCharge  float64   `json:"stop_wait_charge,omitempty"`
+
 	// Zone fees
 	ZoneFeesTotal     float64            `json:"zone_fees_total"`
 	ZoneFeesBreakdown []ZoneFeeBreakdown `json:"zone_fees_breakdown,omitempty"`
@@ -202,10 +208,20 @@ type FareCalculation struct {
 
 	// Metadata
 	PricingVersionID uuid.UUID `json:"pricing_version_id"`
+	ABTestVersion    bool      `json:"ab_test_version,omitempty"`
 	WasNegotiated    bool      `json:"was_negotiated"`
 	NegotiatedFare   *float64  `json:"negotiated_fare,omitempty"`
 }
 
+// LegFare is the distance and time charge for one leg of a multi-stop ride
+type LegFare struct {
+	Leg            int     `json:"leg"`
+	DistanceKm     float64 `json:"distance_km"`
+	DurationMin    int     `json:"duration_min"`
+	DistanceCharge float64 `json:"distance_charge"`
+	TimeCharge     float64 `json:"time_charge"`
+}
+
 // ZoneFeeBreakdown represents a breakdown of an applied zone fee
 type ZoneFeeBreakdown struct {
 	ZoneID   uuid.UUID `json:"zone_id"`
@@ -221,26 +237,30 @@ type EstimateRequest struct {
 	DropoffLatitude  float64    `json:"dropoff_latitude" binding:"required"`
 	DropoffLongitude float64    `json:"dropoff_longitude" binding:"required"`
 	RideTypeID       *uuid.UUID `json:"ride_type_id,omitempty"`
+	RiderID          uuid.UUID  `json:"-"` // set from the authenticated user; binds the quote
 }
 
 // EstimateResponse represents a fare estimate response
 type EstimateResponse struct {
-	Currency         string  `json:"currency"`
-	EstimatedFare    float64 `json:"estimated_fare"`
-	MinimumFare      float64 `json:"minimum_fare"`
-	SurgeMultiplier  float64 `json:"surge_multiplier"`
-	DistanceKm       float64 `json:"distance_km"`
-	EstimatedMinutes int     `json:"estimated_minutes"`
+	Currency         string           `json:"currency"`
+	EstimatedFare    float64          `json:"estimated_fare"`
+	MinimumFare      float64          `json:"minimum_fare"`
+	SurgeMultiplier  float64          `json:"surge_multiplier"`
+	DistanceKm       float64          `json:"distance_km"`
+	EstimatedMinutes int              `json:"estimated_minutes"`
 	FareBreakdown    *FareCalculation `json:"fare_breakdown,omitempty"`
-	FormattedFare    string  `json:"formatted_fare"`
+	FormattedFare    string           `json:"formatted_fare"`
+	QuoteToken       string           `json:"quote_token,omitempty"` // redeem in the ride request to lock the fare
+	QuoteExpiresAt   *time.Time       `json:"quote_expires_at,omitempty"`
 }
 
 // BulkEstimateRequest represents a request for estimates across all available ride types
 type BulkEstimateRequest struct {
-	PickupLatitude   float64 `json:"pickup_latitude" binding:"required"`
-	PickupLongitude  float64 `json:"pickup_longitude" binding:"required"`
-	DropoffLatitude  float64 `json:"dropoff_latitude" binding:"required"`
-	DropoffLongitude float64 `json:"dropoff_longitude" binding:"required"`
+	PickupLatitude   float64   `json:"pickup_latitude" binding:"required"`
+	PickupLongitude  float64   `json:"pickup_longitude" binding:"required"`
+	DropoffLatitude  float64   `json:"dropoff_latitude" binding:"required"`
+	DropoffLongitude float64   `json:"dropoff_longitude" binding:"required"`
+	RiderID          uuid.UUID `json:"-"`
 }
 
 // RideTypeInfo contains basic ride type information for bulk estimates
@@ -254,28 +274,50 @@ type RideTypeInfo struct {
 
 // RideTypeEstimate represents a ride type with its fare estimate
 type RideTypeEstimate struct {
-	RideTypeID       uuid.UUID        `json:"ride_type_id"`
-	RideTypeName     string           `json:"ride_type_name"`
-	Description      string           `json:"description"`
-	Capacity         int              `json:"capacity"`
-	IconURL          *string          `json:"icon_url,omitempty"`
-	Currency         string           `json:"currency"`
-	EstimatedFare    float64          `json:"estimated_fare"`
-	MinimumFare      float64          `json:"minimum_fare"`
-	SurgeMultiplier  float64          `json:"surge_multiplier"`
-	FareBreakdown    *FareCalculation `json:"fare_breakdown,omitempty"`
-	FormattedFare    string           `json:"formatted_fare"`
-	ETAMinutes       *int             `json:"eta_minutes,omitempty"`
-	AvailableVehicles *int            `json:"available_vehicles,omitempty"`
+	RideTypeID        uuid.UUID        `json:"ride_type_id"`
+	RideTypeName      string           `json:"ride_type_name"`
+	Description       string           `json:"description"`
+	Capacity          int              `json:"capacity"`
+	IconURL           *string          `json:"icon_url,omitempty"`
+	Currency          string           `json:"currency"`
+	EstimatedFare     float64          `json:"estimated_fare"`
+	MinimumFare       float64          `json:"minimum_fare"`
+	SurgeMultiplier   float64          `json:"surge_multiplier"`
+	FareBreakdown     *FareCalculation `json:"fare_breakdown,omitempty"`
+	FormattedFare     string           `json:"formatted_fare"`
+	ETAMinutes        *int             `json:"eta_minutes,omitempty"`
+	AvailableVehicles *int             `json:"available_vehicles,omitempty"`
+	QuoteToken        string           `json:"quote_token,omitempty"`
+	QuoteExpiresAt    *time.Time       `json:"quote_expires_at,omitempty"`
 }
 
 // BulkEstimateResponse represents the response with all ride type estimates
 type BulkEstimateResponse struct {
-	PickupAddress    string              `json:"pickup_address,omitempty"`
-	DropoffAddress   string              `json:"dropoff_address,omitempty"`
-	DistanceKm       float64             `json:"distance_km"`
-	EstimatedMinutes int                 `json:"estimated_minutes"`
-	RideOptions      []RideTypeEstimate  `json:"ride_options"`
+	PickupAddress    string             `json:"pickup_address,omitempty"`
+	DropoffAddress   string             `json:"dropoff_address,omitempty"`
+	DistanceKm       float64            `json:"distance_km"`
+	EstimatedMinutes int                `json:"estimated_minutes"`
+	RideOptions      []RideTypeEstimate `json:"ride_options"`
+}
+
+// FareQuote is an upfront price the rider can lock in by redeeming its token
+// when requesting the ride
+type FareQuote struct {
+	ID               uuid.UUID  `json:"id"`
+	RiderID          uuid.UUID  `json:"rider_id"`
+	RideTypeID       *uuid.UUID `json:"ride_type_id,omitempty"`
+	PickupLatitude   float64    `json:"pickup_latitude"`
+	PickupLongitude  float64    `json:"pickup_longitude"`
+	DropoffLatitude  float64    `json:"dropoff_latitude"`
+	DropoffLongitude float64    `json:"dropoff_longitude"`
+	DistanceKm       float64    `json:"distance_km"`
+	DurationMin      int        `json:"duration_min"`
+	Fare             float64    `json:"fare"`
+	Currency         string     `json:"currency"`
+	SurgeMultiplier  float64    `json:"surge_multiplier"`
+	PricingVersionID *uuid.UUID `json:"pricing_version_id,omitempty"`
+	IssuedAt         time.Time  `json:"issued_at"`
+	ExpiresAt        time.Time  `json:"expires_at"`
 }
 
 // WeatherCondition constants
@@ -302,19 +344,41 @@ const (
 
 // PricingConfigVersion represents a version of pricing configuration
 type PricingConfigVersion struct {
-	ID               uuid.UUID  `json:"id" db:"id"`
-	VersionNumber    int        `json:"version_number" db:"version_number"`
-	Name             string     `json:"name" db:"name"`
-	Description      *string    `json:"description,omitempty" db:"description"`
-	Status           string     `json:"status" db:"status"` // draft, active, archived, ab_test
-	ABTestPercentage *int       `json:"ab_test_percentage,omitempty" db:"ab_test_percentage"`
-	EffectiveFrom    *time.Time `json:"effective_from,omitempty" db:"effective_from"`
-	EffectiveUntil   *time.Time `json:"effective_until,omitempty" db:"effective_until"`
-	CreatedBy        *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
-	ApprovedBy       *uuid.UUID `json:"approved_by,omitempty" db:"approved_by"`
-	ApprovedAt       *time.Time `json:"approved_at,omitempty" db:"approved_at"`
-	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	ID                    uuid.UUID  `json:"id" db:"id"`
+	VersionNumber         int        `json:"version_number" db:"version_number"`
+	Name                  string     `json:"name" db:"name"`
+	Description           *string    `json:"description,omitempty" db:"description"`
+	Status                string     `json:"status" db:"status"` // draft, scheduled, active, archived, ab_test
+	ABTestPercentage      *int       `json:"ab_test_percentage,omitempty" db:"ab_test_percentage"`
+	EffectiveFrom         *time.Time `json:"effective_from,omitempty" db:"effective_from"`
+	EffectiveUntil        *time.Time `json:"effective_until,omitempty" db:"effective_until"`
+	ActivatedAt           *time.Time `json:"activated_at,omitempty" db:"activated_at"`
+	PreviousVersionID     *uuid.UUID `json:"previous_version_id,omitempty" db:"previous_version_id"`
+	RollbackMetric        *string    `json:"rollback_metric,omitempty" db:"rollback_metric"`
+	RollbackMaxDropPct    *float64   `json:"rollback_max_drop_pct,omitempty" db:"rollback_max_drop_pct"`
+	RollbackWindowMinutes *int       `json:"rollback_window_minutes,omitempty" db:"rollback_window_minutes"`
+	RollbackCheckedAt     *time.Time `json:"rollback_checked_at,omitempty" db:"rollback_checked_at"`
+	CreatedBy             *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	ApprovedBy            *uuid.UUID `json:"approved_by,omitempty" db:"approved_by"`
+	ApprovedAt            *time.Time `json:"approved_at,omitempty" db:"approved_at"`
+	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// VersionHistoryEntry records a pricing version switch for one city, or for
+// the global configs when CityID is nil
+type VersionHistoryEntry struct {
+	ID                uuid.UUID  `json:"id" db:"id"`
+	VersionID         uuid.UUID  `json:"version_id" db:"version_id"`
+	PreviousVersionID *uuid.UUID `json:"previous_version_id,omitempty" db:"previous_version_id"`
+	CityID            *uuid.UUID `json:"city_id,omitempty" db:"city_id"`
+	Event             string     `json:"event" db:"event"` // activated, expired, rolled_back
+	Reason            *string    `json:"reason,omitempty" db:"reason"`
+	MetricName        *string    `json:"metric_name,omitempty" db:"metric_name"`
+	BaselineValue     *float64   `json:"baseline_value,omitempty" db:"baseline_value"`
+	ObservedValue     *float64   `json:"observed_value,omitempty" db:"observed_value"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 }
 
 // PricingAuditLog represents a pricing audit trail entry
@@ -332,10 +396,36 @@ type PricingAuditLog struct {
 
 // Version status constants
 const (
-	VersionStatusDraft    = "draft"
-	VersionStatusActive   = "active"
-	VersionStatusArchived = "archived"
-	VersionStatusABTest   = "ab_test"
+	VersionStatusDraft     = "draft"
+	VersionStatusScheduled = "scheduled"
+	VersionStatusActive    = "active"
+	VersionStatusArchived  = "archived"
+	VersionStatusABTest    = "ab_test"
+)
+
+// VersionSwitch describes a change of the active pricing version
+type VersionSwitch struct {
+	FromVersionID *uuid.UUID // Optional: only switch if this is still the active version
+	ToVersionID   uuid.UUID  // uuid.Nil archives the active version without a replacement
+	Event         string
+	Reason        string
+	AdminID       *uuid.UUID // nil when the scheduler switches
+	MetricName    *string    // Rollbacks: the guard metric and its values
+	BaselineValue *float64
+	ObservedValue *float64
+}
+
+// Version history events
+const (
+	VersionEventActivated  = "activated"
+	VersionEventExpired    = "expired"
+	VersionEventRolledBack = "rolled_back"
+)
+
+// Rollback guard metrics, measured over rides requested in the window
+const (
+	RollbackMetricAcceptRate     = "accept_rate"     // accepted / requested
+	RollbackMetricCompletionRate = "completion_rate" // completed / requested
 )
 
 // Admin request DTOs
@@ -361,26 +451,137 @@ type CloneVersionRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
+// ScheduleVersionRequest is the request body for scheduling a draft version
+type ScheduleVersionRequest struct {
+	EffectiveFrom  time.Time      `json:"effective_from" binding:"required"`
+	EffectiveUntil *time.Time     `json:"effective_until,omitempty"`
+	RollbackGuard  *RollbackGuard `json:"rollback_guard,omitempty"`
+}
+
+// RollbackGuard restores the previous version if Metric, measured over
+// WindowMinutes after the switch, drops by more than MaxDropPct percent
+// against the same window before it
+type RollbackGuard struct {
+	Metric        string  `json:"metric" binding:"required,oneof=accept_rate completion_rate"`
+	MaxDropPct    float64 `json:"max_drop_pct" binding:"required,gt=0,lte=100"`
+	WindowMinutes int     `json:"window_minutes" binding:"required,min=1"`
+}
+
+// SimulateVersionRequest is the request body for replaying past rides against a version
+type SimulateVersionRequest struct {
+	From              *time.Time `json:"from,omitempty"`                // default: 7 days ago
+	To                *time.Time `json:"to,omitempty"`                  // default: now
+	SampleSize        int        `json:"sample_size,omitempty"`         // default 200, max 500
+	BaselineVersionID *uuid.UUID `json:"baseline_version_id,omitempty"` // default: active version
+	WeatherCondition  string     `json:"weather_condition,omitempty"`   // applied to every ride
+	DemandSupplyRatio float64    `json:"demand_supply_ratio,omitempty"` // applied to every ride, for surge thresholds
+}
+
+// StartABTestRequest is the request body for putting a draft version into an A/B test
+type StartABTestRequest struct {
+	Percentage int `json:"percentage" binding:"required,min=1,max=100"`
+}
+
+// SimulationRide is a completed ride replayed by the pricing simulator
+type SimulationRide struct {
+	RideID           uuid.UUID
+	RideTypeID       *uuid.UUID
+	PickupLatitude   float64
+	PickupLongitude  float64
+	DropoffLatitude  float64
+	DropoffLongitude float64
+	DistanceKm       float64
+	DurationMin      int
+	Currency         string
+	RequestedAt      time.Time
+	ChargedFare      float64
+}
+
+// SimulatedRide compares one past ride's fare under the baseline and the simulated version
+type SimulatedRide struct {
+	RideID                  uuid.UUID `json:"ride_id"`
+	RequestedAt             time.Time `json:"requested_at"`
+	DistanceKm              float64   `json:"distance_km"`
+	DurationMin             int       `json:"duration_min"`
+	ChargedFare             float64   `json:"charged_fare"`
+	BaselineFare            float64   `json:"baseline_fare"`
+	SimulatedFare           float64   `json:"simulated_fare"`
+	FareChange              float64   `json:"fare_change"`
+	FareChangePct           float64   `json:"fare_change_pct"`
+	BaselineDriverEarnings  float64   `json:"baseline_driver_earnings"`
+	SimulatedDriverEarnings float64   `json:"simulated_driver_earnings"`
+}
+
+// FareStats summarizes a fare distribution
+type FareStats struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	Total  float64 `json:"total"`
+}
+
+// SimulationSummary aggregates a simulation across all replayed rides
+type SimulationSummary struct {
+	Rides                   int       `json:"rides"`
+	RidesCheaper            int       `json:"rides_cheaper"`
+	RidesPricier            int       `json:"rides_pricier"`
+	RidesUnchanged          int       `json:"rides_unchanged"`
+	BaselineFares           FareStats `json:"baseline_fares"`
+	SimulatedFares          FareStats `json:"simulated_fares"`
+	FareChangePct           float64   `json:"fare_change_pct"`
+	BaselineDriverEarnings  float64   `json:"baseline_driver_earnings"`
+	SimulatedDriverEarnings float64   `json:"simulated_driver_earnings"`
+	DriverEarningsChangePct float64   `json:"driver_earnings_change_pct"`
+}
+
+// SimulationResult is the outcome of replaying past rides against a version
+type SimulationResult struct {
+	VersionID         uuid.UUID         `json:"version_id"`
+	BaselineVersionID uuid.UUID         `json:"baseline_version_id"`
+	From              time.Time         `json:"from"`
+	To                time.Time         `json:"to"`
+	Summary           SimulationSummary `json:"summary"`
+	Rides             []SimulatedRide   `json:"rides"`
+}
+
+// VersionPerformance compares how riders priced by one version converted and
+// how much revenue their rides brought in
+type VersionPerformance struct {
+	VersionID        uuid.UUID `json:"version_id"`
+	VersionNumber    int       `json:"version_number"`
+	Name             string    `json:"name"`
+	Status           string    `json:"status"`
+	ABTestPercentage *int      `json:"ab_test_percentage,omitempty"`
+	RidesRequested   int       `json:"rides_requested"`
+	RidesCompleted   int       `json:"rides_completed"`
+	RidesCancelled   int       `json:"rides_cancelled"`
+	ConversionRate   float64   `json:"conversion_rate"` // completed / requested
+	Revenue          float64   `json:"revenue"`
+	AverageFare      float64   `json:"average_fare"`
+}
+
 // CreateConfigRequest is the request body for creating a pricing config
 type CreateConfigRequest struct {
-	CountryID            *uuid.UUID        `json:"country_id,omitempty"`
-	RegionID             *uuid.UUID        `json:"region_id,omitempty"`
-	CityID               *uuid.UUID        `json:"city_id,omitempty"`
-	ZoneID               *uuid.UUID        `json:"zone_id,omitempty"`
-	RideTypeID           *uuid.UUID        `json:"ride_type_id,omitempty"`
-	BaseFare             *float64          `json:"base_fare,omitempty"`
-	PerKmRate            *float64          `json:"per_km_rate,omitempty"`
-	PerMinuteRate        *float64          `json:"per_minute_rate,omitempty"`
-	MinimumFare          *float64          `json:"minimum_fare,omitempty"`
-	BookingFee           *float64          `json:"booking_fee,omitempty"`
-	PlatformCommissionPct *float64         `json:"platform_commission_pct,omitempty"`
-	DriverIncentivePct   *float64          `json:"driver_incentive_pct,omitempty"`
-	SurgeMinMultiplier   *float64          `json:"surge_min_multiplier,omitempty"`
-	SurgeMaxMultiplier   *float64          `json:"surge_max_multiplier,omitempty"`
-	TaxRatePct           *float64          `json:"tax_rate_pct,omitempty"`
-	TaxInclusive         *bool             `json:"tax_inclusive,omitempty"`
-	CancellationFees     []CancellationFee `json:"cancellation_fees,omitempty"`
-	IsActive             bool              `json:"is_active"`
+	CountryID             *uuid.UUID        `json:"country_id,omitempty"`
+	RegionID              *uuid.UUID        `json:"region_id,omitempty"`
+	CityID                *uuid.UUID        `json:"city_id,omitempty"`
+	ZoneID                *uuid.UUID        `json:"zone_id,omitempty"`
+	RideTypeID            *uuid.UUID        `json:"ride_type_id,omitempty"`
+	BaseFare              *float64          `json:"base_fare,omitempty"`
+	PerKmRate             *float64          `json:"per_km_rate,omitempty"`
+	PerMinuteRate         *float64          `json:"per_minute_rate,omitempty"`
+	MinimumFare           *float64          `json:"minimum_fare,omitempty"`
+	BookingFee            *float64          `json:"booking_fee,omitempty"`
+	PlatformCommissionPct *float64          `json:"platform_commission_pct,omitempty"`
+	DriverIncentivePct    *float64          `json:"driver_incentive_pct,omitempty"`
+	SurgeMinMultiplier    *float64          `json:"surge_min_multiplier,omitempty"`
+	SurgeMaxMultiplier    *float64          `json:"surge_max_multiplier,omitempty"`
+	TaxRatePct            *float64          `json:"tax_rate_pct,omitempty"`
+	TaxInclusive          *bool             `json:"tax_inclusive,omitempty"`
+	CancellationFees      []CancellationFee `json:"cancellation_fees,omitempty"`
+	IsActive              bool              `json:"is_active"`
 }
 
 // UpdateConfigRequest is the request body for updating a pricing config
@@ -436,15 +637,15 @@ type UpdateEventMultiplierRequest = CreateEventMultiplierRequest
 
 // CreateZoneFeeRequest is the request body for creating a zone fee
 type CreateZoneFeeRequest struct {
-	ZoneID        uuid.UUID  `json:"zone_id" binding:"required"`
-	FeeType       string     `json:"fee_type" binding:"required"`
-	RideTypeID    *uuid.UUID `json:"ride_type_id,omitempty"`
-	Amount        float64    `json:"amount" binding:"required"`
-	IsPercentage  bool       `json:"is_percentage"`
-	AppliesPickup bool       `json:"applies_pickup"`
-	AppliesDropoff bool      `json:"applies_dropoff"`
-	Schedule      *FeeSchedule `json:"schedule,omitempty"`
-	IsActive      bool       `json:"is_active"`
+	ZoneID         uuid.UUID    `json:"zone_id" binding:"required"`
+	FeeType        string       `json:"fee_type" binding:"required"`
+	RideTypeID     *uuid.UUID   `json:"ride_type_id,omitempty"`
+	Amount         float64      `json:"amount" binding:"required"`
+	IsPercentage   bool         `json:"is_percentage"`
+	AppliesPickup  bool         `json:"applies_pickup"`
+	AppliesDropoff bool         `json:"applies_dropoff"`
+	Schedule       *FeeSchedule `json:"schedule,omitempty"`
+	IsActive       bool         `json:"is_active"`
 }
 
 // UpdateZoneFeeRequest is the request body for updating a zone fee
@@ -0,0 +1,73 @@
+package pricing
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+// DefaultQuoteTTL is how long a fare quote can be redeemed after it is issued
+const DefaultQuoteTTL = 5 * time.Minute
+
+const quoteTokenSubject = "fare_quote"
+
+// QuoteSigner issues and verifies fare quote tokens. A token is an HS256 JWT
+// carrying the whole quote, so quotes need no storage until a ride redeems one.
+type QuoteSigner struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewQuoteSigner creates a quote signer. ttl defaults to DefaultQuoteTTL.
+func NewQuoteSigner(secret string, ttl time.Duration) *QuoteSigner {
+	if ttl <= 0 {
+		ttl = DefaultQuoteTTL
+	}
+	return &QuoteSigner{secret: []byte(secret), ttl: ttl, now: time.Now}
+}
+
+type quoteClaims struct {
+	Quote FareQuote `json:"quote"`
+	jwt.RegisteredClaims
+}
+
+// Sign stamps the quote with an ID and validity window and returns its token
+func (s *QuoteSigner) Sign(quote *FareQuote) (string, error) {
+	now := s.now()
+	quote.ID = uuid.New()
+	quote.IssuedAt = now
+	quote.ExpiresAt = now.Add(s.ttl)
+
+	claims := quoteClaims{
+		Quote: *quote,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        quote.ID.String(),
+			Subject:   quoteTokenSubject,
+			IssuedAt:  jwt.NewNumericDate(quote.IssuedAt),
+			ExpiresAt: jwt.NewNumericDate(quote.ExpiresAt),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+// Verify checks the token's signature and expiry and returns its quote
+func (s *QuoteSigner) Verify(token string) (*FareQuote, error) {
+	claims := &quoteClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(s.now))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeQuoteExpired,
+			"fare quote has expired, please request a new estimate", nil)
+	}
+	if err != nil || claims.Subject != quoteTokenSubject {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeQuoteInvalid,
+			"invalid fare quote", err)
+	}
+	return &claims.Quote, nil
+}
@@ -0,0 +1,116 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuoteSigner(secret string, now time.Time) *QuoteSigner {
+	s := NewQuoteSigner(secret, 5*time.Minute)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func testQuote(riderID uuid.UUID) *FareQuote {
+	return &FareQuote{
+		RiderID:          riderID,
+		PickupLatitude:   40.7128,
+		PickupLongitude:  -74.0060,
+		DropoffLatitude:  40.7580,
+		DropoffLongitude: -73.9855,
+		DistanceKm:       5.4,
+		DurationMin:      9,
+		Fare:             18.75,
+		Currency:         "USD",
+		SurgeMultiplier:  1.2,
+	}
+}
+
+func assertQuoteError(t *testing.T, err error, code string) {
+	t.Helper()
+	appErr, ok := err.(*common.AppError)
+	require.True(t, ok, "expected *common.AppError, got %T", err)
+	assert.Equal(t, code, appErr.ErrorCode)
+}
+
+func TestQuoteSigner_RoundTrip(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	signer := newTestQuoteSigner("quote-secret", now)
+	quote := testQuote(uuid.New())
+
+	token, err := signer.Sign(quote)
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, quote.ID)
+	assert.Equal(t, now.Add(5*time.Minute), quote.ExpiresAt)
+
+	verified, err := signer.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, quote.ID, verified.ID)
+	assert.Equal(t, quote.Fare, verified.Fare)
+	assert.Equal(t, quote.RiderID, verified.RiderID)
+}
+
+func TestQuoteSigner_RejectsExpiredToken(t *testing.T) {
+	now := time.Now()
+	token, err := newTestQuoteSigner("quote-secret", now).Sign(testQuote(uuid.New()))
+	require.NoError(t, err)
+
+	_, err = newTestQuoteSigner("quote-secret", now.Add(6*time.Minute)).Verify(token)
+	assertQuoteError(t, err, common.ErrCodeQuoteExpired)
+}
+
+func TestQuoteSigner_RejectsForgedToken(t *testing.T) {
+	now := time.Now()
+	token, err := newTestQuoteSigner("someone-else", now).Sign(testQuote(uuid.New()))
+	require.NoError(t, err)
+
+	_, err = newTestQuoteSigner("quote-secret", now).Verify(token)
+	assertQuoteError(t, err, common.ErrCodeQuoteInvalid)
+
+	_, err = newTestQuoteSigner("quote-secret", now).Verify("not-a-token")
+	assertQuoteError(t, err, common.ErrCodeQuoteInvalid)
+}
+
+func TestRedeemQuote(t *testing.T) {
+	riderID := uuid.New()
+	svc := &Service{quotes: NewQuoteSigner("quote-secret", 0)}
+	token, err := svc.quotes.Sign(testQuote(riderID))
+	require.NoError(t, err)
+
+	quote, err := svc.RedeemQuote(token, riderID)
+	require.NoError(t, err)
+	assert.Equal(t, 18.75, quote.Fare)
+
+	_, err = svc.RedeemQuote(token, uuid.New())
+	assertQuoteError(t, err, common.ErrCodeQuoteInvalid)
+
+	_, err = (&Service{}).RedeemQuote(token, riderID)
+	assert.Error(t, err)
+}
+
+func TestIssueQuote_CopiesCalculation(t *testing.T) {
+	versionID := uuid.New()
+	calc := &FareCalculation{TotalFare: 21.4, Currency: "EUR", TotalMultiplier: 1.5, PricingVersionID: versionID}
+
+	token, expiresAt := (&Service{}).issueQuote(*testQuote(uuid.Nil), calc)
+	assert.Empty(t, token)
+	assert.Nil(t, expiresAt)
+
+	svc := &Service{quotes: NewQuoteSigner("quote-secret", 0)}
+	token, expiresAt = svc.issueQuote(*testQuote(uuid.Nil), calc)
+	require.NotEmpty(t, token)
+	require.NotNil(t, expiresAt)
+
+	quote, err := svc.quotes.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, 21.4, quote.Fare)
+	assert.Equal(t, "EUR", quote.Currency)
+	assert.Equal(t, 1.5, quote.SurgeMultiplier)
+	require.NotNil(t, quote.PricingVersionID)
+	assert.Equal(t, versionID, *quote.PricingVersionID)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"go.uber.org/zap"
@@ -42,6 +43,28 @@ func (r *Repository) GetActiveVersionID(ctx context.Context) (uuid.UUID, error)
 	return versionID, nil
 }
 
+// GetABTestVersions returns the versions currently running as A/B tests
+// against the active version, newest first
+func (r *Repository) GetABTestVersions(ctx context.Context) ([]*PricingConfigVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM pricing_config_versions
+		WHERE status = 'ab_test'
+		  AND COALESCE(ab_test_percentage, 0) > 0
+		  AND (effective_from IS NULL OR effective_from <= NOW())
+		  AND (effective_until IS NULL OR effective_until > NOW())
+		ORDER BY version_number DESC
+	`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get A/B test versions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanVersions(rows)
+}
+
 // GetPricingConfigsForResolution retrieves all configs needed to resolve pricing for a location
 func (r *Repository) GetPricingConfigsForResolution(ctx context.Context, versionID uuid.UUID, countryID, regionID, cityID, zoneID, rideTypeID *uuid.UUID) ([]*PricingConfig, error) {
 	query := `
@@ -356,6 +379,40 @@ func (r *Repository) GetZoneName(ctx context.Context, zoneID uuid.UUID) (string,
 // Version CRUD
 // ============================================================
 
+// versionColumns lists the pricing_config_versions columns read by scanVersion
+const versionColumns = `id, version_number, name, description, status, ab_test_percentage,
+		       effective_from, effective_until, activated_at, previous_version_id,
+		       rollback_metric, rollback_max_drop_pct, rollback_window_minutes, rollback_checked_at,
+		       created_by, approved_by, approved_at, created_at, updated_at`
+
+// scanVersion scans a row selected with versionColumns
+func scanVersion(row pgx.Row) (*PricingConfigVersion, error) {
+	v := &PricingConfigVersion{}
+	err := row.Scan(
+		&v.ID, &v.VersionNumber, &v.Name, &v.Description, &v.Status,
+		&v.ABTestPercentage, &v.EffectiveFrom, &v.EffectiveUntil, &v.ActivatedAt, &v.PreviousVersionID,
+		&v.RollbackMetric, &v.RollbackMaxDropPct, &v.RollbackWindowMinutes, &v.RollbackCheckedAt,
+		&v.CreatedBy, &v.ApprovedBy, &v.ApprovedAt, &v.CreatedAt, &v.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// scanVersions scans all rows selected with versionColumns
+func scanVersions(rows pgx.Rows) ([]*PricingConfigVersion, error) {
+	versions := make([]*PricingConfigVersion, 0)
+	for rows.Next() {
+		v, err := scanVersion(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
 // CreateVersion creates a new pricing config version
 func (r *Repository) CreateVersion(ctx context.Context, version *PricingConfigVersion) error {
 	query := `
@@ -378,19 +435,8 @@ func (r *Repository) CreateVersion(ctx context.Context, version *PricingConfigVe
 
 // GetVersionByID retrieves a pricing config version by ID
 func (r *Repository) GetVersionByID(ctx context.Context, id uuid.UUID) (*PricingConfigVersion, error) {
-	query := `
-		SELECT id, version_number, name, description, status, ab_test_percentage,
-		       effective_from, effective_until, created_by, approved_by, approved_at,
-		       created_at, updated_at
-		FROM pricing_config_versions
-		WHERE id = $1
-	`
-	v := &PricingConfigVersion{}
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&v.ID, &v.VersionNumber, &v.Name, &v.Description, &v.Status,
-		&v.ABTestPercentage, &v.EffectiveFrom, &v.EffectiveUntil,
-		&v.CreatedBy, &v.ApprovedBy, &v.ApprovedAt, &v.CreatedAt, &v.UpdatedAt,
-	)
+	query := `SELECT ` + versionColumns + ` FROM pricing_config_versions WHERE id = $1`
+	v, err := scanVersion(r.db.QueryRow(ctx, query, id))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get version: %w", err)
 	}
@@ -416,13 +462,11 @@ func (r *Repository) ListVersions(ctx context.Context, limit, offset int, status
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, version_number, name, description, status, ab_test_percentage,
-		       effective_from, effective_until, created_by, approved_by, approved_at,
-		       created_at, updated_at
+		SELECT %s
 		FROM pricing_config_versions %s
 		ORDER BY version_number DESC
 		LIMIT $%d OFFSET $%d
-	`, whereClause, argIndex, argIndex+1)
+	`, versionColumns, whereClause, argIndex, argIndex+1)
 	args = append(args, limit, offset)
 
 	rows, err := r.db.Query(ctx, query, args...)
@@ -431,20 +475,10 @@ func (r *Repository) ListVersions(ctx context.Context, limit, offset int, status
 	}
 	defer rows.Close()
 
-	versions := make([]*PricingConfigVersion, 0)
-	for rows.Next() {
-		v := &PricingConfigVersion{}
-		err := rows.Scan(
-			&v.ID, &v.VersionNumber, &v.Name, &v.Description, &v.Status,
-			&v.ABTestPercentage, &v.EffectiveFrom, &v.EffectiveUntil,
-			&v.CreatedBy, &v.ApprovedBy, &v.ApprovedAt, &v.CreatedAt, &v.UpdatedAt,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan version: %w", err)
-		}
-		versions = append(versions, v)
+	versions, err := scanVersions(rows)
+	if err != nil {
+		return nil, 0, err
 	}
-
 	return versions, total, nil
 }
 
@@ -469,12 +503,38 @@ func (r *Repository) UpdateVersion(ctx context.Context, version *PricingConfigVe
 
 // ActivateVersion activates a version (archives the current active one)
 func (r *Repository) ActivateVersion(ctx context.Context, id uuid.UUID, adminID uuid.UUID) error {
+	return r.SwitchActiveVersion(ctx, VersionSwitch{
+		ToVersionID: id,
+		Event:       VersionEventActivated,
+		AdminID:     &adminID,
+	})
+}
+
+// SwitchActiveVersion archives the active version and activates another one
+// in a single transaction, recording the switch in the version history for
+// every city either version has configs for. Activations take draft,
+// scheduled or A/B test versions; expiries and rollbacks restore an archived
+// one.
+func (r *Repository) SwitchActiveVersion(ctx context.Context, sw VersionSwitch) error {
 	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback(ctx)
 
+	var currentID *uuid.UUID
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM pricing_config_versions WHERE status = 'active'
+		ORDER BY activated_at DESC NULLS LAST LIMIT 1
+		FOR UPDATE
+	`).Scan(&currentID)
+	if err != nil && err != pgx.ErrNoRows {
+		return fmt.Errorf("failed to get current version: %w", err)
+	}
+	if sw.FromVersionID != nil && (currentID == nil || *currentID != *sw.FromVersionID) {
+		return fmt.Errorf("active version changed, switch from %s skipped", sw.FromVersionID)
+	}
+
 	// Archive currently active version(s)
 	_, err = tx.Exec(ctx, `
 		UPDATE pricing_config_versions SET status = 'archived', updated_at = NOW()
@@ -484,14 +544,61 @@ func (r *Repository) ActivateVersion(ctx context.Context, id uuid.UUID, adminID
 		return fmt.Errorf("failed to archive current version: %w", err)
 	}
 
-	// Activate the new version
+	if sw.ToVersionID != uuid.Nil {
+		// Only forward activations remember what they replaced, so a
+		// restored version keeps pointing at its own predecessor
+		fromStatuses := []string{VersionStatusArchived}
+		var previousID *uuid.UUID
+		if sw.Event == VersionEventActivated {
+			fromStatuses = []string{VersionStatusDraft, VersionStatusScheduled, VersionStatusABTest}
+			previousID = currentID
+		}
+
+		tag, err := tx.Exec(ctx, `
+			UPDATE pricing_config_versions SET
+				status = 'active', activated_at = NOW(), rollback_checked_at = NULL,
+				previous_version_id = COALESCE($3, previous_version_id),
+				approved_by = COALESCE($4, approved_by),
+				approved_at = CASE WHEN $4::uuid IS NULL THEN approved_at ELSE NOW() END,
+				updated_at = NOW()
+			WHERE id = $1 AND status = ANY($2)
+		`, sw.ToVersionID, fromStatuses, previousID, sw.AdminID)
+		if err != nil {
+			return fmt.Errorf("failed to activate version: %w", err)
+		}
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("version %s cannot be activated from its current status", sw.ToVersionID)
+		}
+	}
+
+	historyVersionID := sw.ToVersionID
+	historyPreviousID := currentID
+	if historyVersionID == uuid.Nil {
+		// Nothing replaces an expired version: record the expiry against it
+		if currentID == nil {
+			return tx.Commit(ctx)
+		}
+		historyVersionID, historyPreviousID = *currentID, nil
+	}
+
 	_, err = tx.Exec(ctx, `
-		UPDATE pricing_config_versions SET
-			status = 'active', approved_by = $2, approved_at = NOW(), updated_at = NOW()
-		WHERE id = $1 AND status = 'draft'
-	`, id, adminID)
+		INSERT INTO pricing_version_history (version_id, previous_version_id, city_id, event, reason,
+		            metric_name, baseline_value, observed_value, created_by)
+		SELECT $1, $2, c.city_id, $3, NULLIF($4, ''), $5, $6, $7, $8
+		FROM (
+			SELECT NULL::uuid AS city_id
+			UNION
+			SELECT pc.city_id FROM pricing_configs pc
+			WHERE pc.version_id IN ($1, $2) AND pc.city_id IS NOT NULL
+			UNION
+			SELECT z.city_id FROM pricing_configs pc
+			JOIN pricing_zones z ON z.id = pc.zone_id
+			WHERE pc.version_id IN ($1, $2)
+		) c
+	`, historyVersionID, historyPreviousID, sw.Event, sw.Reason,
+		sw.MetricName, sw.BaselineValue, sw.ObservedValue, sw.AdminID)
 	if err != nil {
-		return fmt.Errorf("failed to activate version: %w", err)
+		return fmt.Errorf("failed to record version history: %w", err)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -500,6 +607,174 @@ func (r *Repository) ActivateVersion(ctx context.Context, id uuid.UUID, adminID
 	return nil
 }
 
+// ScheduleVersion marks a draft version to be activated by the scheduler at
+// effectiveFrom, optionally guarded by an automatic rollback
+func (r *Repository) ScheduleVersion(ctx context.Context, id uuid.UUID, effectiveFrom time.Time, effectiveUntil *time.Time, guard *RollbackGuard, adminID uuid.UUID) error {
+	var metric *string
+	var maxDropPct *float64
+	var windowMinutes *int
+	if guard != nil {
+		metric, maxDropPct, windowMinutes = &guard.Metric, &guard.MaxDropPct, &guard.WindowMinutes
+	}
+
+	tag, err := r.db.Exec(ctx, `
+		UPDATE pricing_config_versions SET
+			status = 'scheduled', effective_from = $2, effective_until = $3,
+			rollback_metric = $4, rollback_max_drop_pct = $5, rollback_window_minutes = $6,
+			approved_by = $7, approved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+	`, id, effectiveFrom, effectiveUntil, metric, maxDropPct, windowMinutes, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to schedule version: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("version %s is not a draft", id)
+	}
+	return nil
+}
+
+// GetDueScheduledVersions returns scheduled versions whose effective window
+// has started, oldest first
+func (r *Repository) GetDueScheduledVersions(ctx context.Context) ([]*PricingConfigVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM pricing_config_versions
+		WHERE status = 'scheduled'
+		  AND effective_from <= NOW()
+		  AND (effective_until IS NULL OR effective_until > NOW())
+		ORDER BY effective_from ASC
+	`
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due versions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanVersions(rows)
+}
+
+// GetActiveVersion returns the active version regardless of its effective
+// window, or nil if there is none
+func (r *Repository) GetActiveVersion(ctx context.Context) (*PricingConfigVersion, error) {
+	query := `
+		SELECT ` + versionColumns + `
+		FROM pricing_config_versions
+		WHERE status = 'active'
+		ORDER BY activated_at DESC NULLS LAST
+		LIMIT 1
+	`
+	v, err := scanVersion(r.db.QueryRow(ctx, query))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active version: %w", err)
+	}
+	return v, nil
+}
+
+// ArchiveExpiredABTests archives A/B test versions past their effective window
+func (r *Repository) ArchiveExpiredABTests(ctx context.Context) (int64, error) {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE pricing_config_versions SET status = 'archived', updated_at = NOW()
+		WHERE status = 'ab_test' AND effective_until <= NOW()
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to archive expired A/B tests: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// MarkRollbackChecked records that a version's rollback guard was evaluated
+func (r *Repository) MarkRollbackChecked(ctx context.Context, id uuid.UUID) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE pricing_config_versions SET rollback_checked_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark rollback checked: %w", err)
+	}
+	return nil
+}
+
+// GetRideConversion returns a rollback guard metric over rides requested in
+// [from, to), along with how many rides were requested
+func (r *Repository) GetRideConversion(ctx context.Context, metric string, from, to time.Time) (float64, int, error) {
+	var converted string
+	switch metric {
+	case RollbackMetricAcceptRate:
+		converted = "accepted_at IS NOT NULL"
+	case RollbackMetricCompletionRate:
+		converted = "status = 'completed'"
+	default:
+		return 0, 0, fmt.Errorf("unknown rollback metric %q", metric)
+	}
+
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE ` + converted + `)
+		FROM rides
+		WHERE requested_at >= $1 AND requested_at < $2
+	`
+	var requested, convertedCount int
+	if err := r.db.QueryRow(ctx, query, from, to).Scan(&requested, &convertedCount); err != nil {
+		return 0, 0, fmt.Errorf("failed to get ride conversion: %w", err)
+	}
+	if requested == 0 {
+		return 0, 0, nil
+	}
+	return float64(convertedCount) / float64(requested), requested, nil
+}
+
+// ListVersionHistory lists version switches affecting a city, including
+// switches of the global configs. A nil cityID lists every switch.
+func (r *Repository) ListVersionHistory(ctx context.Context, cityID *uuid.UUID, limit, offset int) ([]*VersionHistoryEntry, int64, error) {
+	whereClause := "WHERE 1=1"
+	args := make([]interface{}, 0)
+	argIndex := 1
+
+	if cityID != nil {
+		whereClause += fmt.Sprintf(" AND (city_id = $%d OR city_id IS NULL)", argIndex)
+		args = append(args, *cityID)
+		argIndex++
+	}
+
+	var total int64
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM pricing_version_history %s", whereClause)
+	if err := r.db.QueryRow(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count version history: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, version_id, previous_version_id, city_id, event, reason,
+		       metric_name, baseline_value, observed_value, created_by, created_at
+		FROM pricing_version_history %s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause, argIndex, argIndex+1)
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list version history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*VersionHistoryEntry, 0)
+	for rows.Next() {
+		e := &VersionHistoryEntry{}
+		err := rows.Scan(
+			&e.ID, &e.VersionID, &e.PreviousVersionID, &e.CityID, &e.Event, &e.Reason,
+			&e.MetricName, &e.BaselineValue, &e.ObservedValue, &e.CreatedBy, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan version history: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, total, rows.Err()
+}
+
 // ArchiveVersion archives a version
 func (r *Repository) ArchiveVersion(ctx context.Context, id uuid.UUID) error {
 	_, err := r.db.Exec(ctx, `
@@ -512,6 +787,67 @@ func (r *Repository) ArchiveVersion(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// StartABTest moves a draft version into an A/B test that prices the given
+// percentage of riders
+func (r *Repository) StartABTest(ctx context.Context, id uuid.UUID, percentage int, adminID uuid.UUID) error {
+	tag, err := r.db.Exec(ctx, `
+		UPDATE pricing_config_versions SET
+			status = 'ab_test', ab_test_percentage = $2,
+			approved_by = $3, approved_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'draft'
+	`, id, percentage, adminID)
+	if err != nil {
+		return fmt.Errorf("failed to start A/B test: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("version %s is not a draft", id)
+	}
+	return nil
+}
+
+// GetVersionPerformance compares conversion and revenue of rides priced by
+// each version since the given time
+func (r *Repository) GetVersionPerformance(ctx context.Context, since time.Time) ([]*VersionPerformance, error) {
+	query := `
+		SELECT v.id, v.version_number, v.name, v.status, v.ab_test_percentage,
+		       COUNT(r.id),
+		       COUNT(r.id) FILTER (WHERE r.status = 'completed'),
+		       COUNT(r.id) FILTER (WHERE r.status = 'cancelled'),
+		       COALESCE(SUM(r.final_fare) FILTER (WHERE r.status = 'completed'), 0),
+		       COALESCE(AVG(r.final_fare) FILTER (WHERE r.status = 'completed'), 0)
+		FROM rides r
+		JOIN pricing_config_versions v ON v.id = r.pricing_version_id
+		WHERE r.requested_at >= $1
+		GROUP BY v.id, v.version_number, v.name, v.status, v.ab_test_percentage
+		ORDER BY v.version_number DESC
+	`
+
+	rows, err := r.db.Query(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get version performance: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]*VersionPerformance, 0)
+	for rows.Next() {
+		p := &VersionPerformance{}
+		err := rows.Scan(
+			&p.VersionID, &p.VersionNumber, &p.Name, &p.Status, &p.ABTestPercentage,
+			&p.RidesRequested, &p.RidesCompleted, &p.RidesCancelled,
+			&p.Revenue, &p.AverageFare,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan version performance: %w", err)
+		}
+		if p.RidesRequested > 0 {
+			p.ConversionRate = float64(p.RidesCompleted) / float64(p.RidesRequested)
+		}
+		results = append(results, p)
+	}
+
+	return results, rows.Err()
+}
+
 // CloneVersion deep-copies a version with all its configs, multipliers, thresholds, and zone fees
 func (r *Repository) CloneVersion(ctx context.Context, sourceID uuid.UUID, name string, adminID uuid.UUID) (*PricingConfigVersion, error) {
 	tx, err := r.db.Begin(ctx)
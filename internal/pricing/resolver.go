@@ -2,14 +2,26 @@ package pricing
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // Resolver handles hierarchical pricing resolution
 type Resolver struct {
-	repo RepositoryInterface
+	repo       RepositoryInterface
+	abAssigner ABTestAssigner
+}
+
+// ABTestAssigner places riders into A/B test pricing versions, typically
+// through an experiment. ok is false when it has no assignment for the rider,
+// in which case the version's ABTestPercentage decides.
+type ABTestAssigner interface {
+	AssignABTest(ctx context.Context, versionID, riderID uuid.UUID) (inTest bool, ok bool)
 }
 
 // NewResolver creates a new pricing resolver
@@ -24,12 +36,19 @@ type ResolveOptions struct {
 	CityID     *uuid.UUID
 	ZoneID     *uuid.UUID
 	RideTypeID *uuid.UUID
+	RiderID    *uuid.UUID // Optional: buckets the rider into A/B test versions
+	VersionID  *uuid.UUID // Optional: pins the version, e.g. the one a ride was quoted with
+}
+
+// SetABTestAssigner lets an experiment decide which riders see A/B test
+// pricing versions instead of the versions' own percentage split.
+func (r *Resolver) SetABTestAssigner(assigner ABTestAssigner) {
+	r.abAssigner = assigner
 }
 
 // Resolve resolves pricing for the given location hierarchy
 func (r *Resolver) Resolve(ctx context.Context, opts ResolveOptions) (*ResolvedPricing, error) {
-	// Get active pricing version
-	versionID, err := r.repo.GetActiveVersionID(ctx)
+	versionID, abTest, err := r.resolveVersion(ctx, opts)
 	if err != nil {
 		// Fall back to default pricing if no version found
 		resolved := DefaultPricing
@@ -48,6 +67,7 @@ func (r *Resolver) Resolve(ctx context.Context, opts ResolveOptions) (*ResolvedP
 	// Start with defaults
 	resolved := DefaultPricing
 	resolved.VersionID = versionID
+	resolved.ABTest = abTest
 	resolved.CountryID = opts.CountryID
 	resolved.RegionID = opts.RegionID
 	resolved.CityID = opts.CityID
@@ -65,6 +85,52 @@ func (r *Resolver) Resolve(ctx context.Context, opts ResolveOptions) (*ResolvedP
 	return &resolved, nil
 }
 
+// resolveVersion picks the pricing version to use: the pinned version if
+// given, an A/B test version the rider falls into, or the active version.
+func (r *Resolver) resolveVersion(ctx context.Context, opts ResolveOptions) (uuid.UUID, bool, error) {
+	if opts.VersionID != nil && *opts.VersionID != uuid.Nil {
+		return *opts.VersionID, false, nil
+	}
+
+	if opts.RiderID != nil {
+		versions, err := r.repo.GetABTestVersions(ctx)
+		if err != nil {
+			logger.Warn("Failed to load A/B test pricing versions, using active version", zap.Error(err))
+		} else if v := r.chooseABTestVersion(ctx, versions, *opts.RiderID); v != nil {
+			return v.ID, true, nil
+		}
+	}
+
+	versionID, err := r.repo.GetActiveVersionID(ctx)
+	return versionID, false, err
+}
+
+// chooseABTestVersion returns the first A/B test version the rider is
+// bucketed into, or nil if they should see the active version.
+func (r *Resolver) chooseABTestVersion(ctx context.Context, versions []*PricingConfigVersion, riderID uuid.UUID) *PricingConfigVersion {
+	for _, v := range versions {
+		if r.abAssigner != nil {
+			if inTest, ok := r.abAssigner.AssignABTest(ctx, v.ID, riderID); ok {
+				if inTest {
+					return v
+				}
+				continue
+			}
+		}
+		if v.ABTestPercentage != nil && abTestBucket(v.ID, riderID) < *v.ABTestPercentage {
+			return v
+		}
+	}
+	return nil
+}
+
+// abTestBucket deterministically maps a rider to a bucket from 0 to 99 for a
+// version, so a rider keeps seeing the same prices for the whole test.
+func abTestBucket(versionID, riderID uuid.UUID) int {
+	sum := sha256.Sum256([]byte("pricing_ab:" + versionID.String() + ":" + riderID.String()))
+	return int(binary.BigEndian.Uint32(sum[:4]) % 100)
+}
+
 // applyConfig applies a single config to the resolved pricing, overriding non-nil values
 func (r *Resolver) applyConfig(resolved *ResolvedPricing, config *PricingConfig) {
 	// Track inheritance
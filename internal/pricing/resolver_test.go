@@ -0,0 +1,76 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeABTestAssigner returns a fixed assignment for every rider
+type fakeABTestAssigner struct {
+	inTest bool
+	ok     bool
+}
+
+func (f *fakeABTestAssigner) AssignABTest(_ context.Context, _, _ uuid.UUID) (bool, bool) {
+	return f.inTest, f.ok
+}
+
+func abTestVersion(percentage int) *PricingConfigVersion {
+	return &PricingConfigVersion{ID: uuid.New(), Status: VersionStatusABTest, ABTestPercentage: &percentage}
+}
+
+func TestABTestBucket_IsDeterministic(t *testing.T) {
+	versionID, riderID := uuid.New(), uuid.New()
+
+	bucket := abTestBucket(versionID, riderID)
+	assert.GreaterOrEqual(t, bucket, 0)
+	assert.Less(t, bucket, 100)
+	assert.Equal(t, bucket, abTestBucket(versionID, riderID))
+}
+
+func TestABTestBucket_SplitsRidersByPercentage(t *testing.T) {
+	versionID := uuid.New()
+	const riders = 10000
+
+	inTest := 0
+	for i := 0; i < riders; i++ {
+		if abTestBucket(versionID, uuid.New()) < 20 {
+			inTest++
+		}
+	}
+
+	assert.InDelta(t, 0.20, float64(inTest)/riders, 0.02)
+}
+
+func TestChooseABTestVersion(t *testing.T) {
+	ctx := context.Background()
+	riderID := uuid.New()
+	r := &Resolver{}
+
+	assert.Nil(t, r.chooseABTestVersion(ctx, nil, riderID))
+	assert.Nil(t, r.chooseABTestVersion(ctx, []*PricingConfigVersion{abTestVersion(0)}, riderID))
+
+	everyone := abTestVersion(100)
+	assert.Equal(t, everyone, r.chooseABTestVersion(ctx, []*PricingConfigVersion{abTestVersion(0), everyone}, riderID))
+}
+
+func TestChooseABTestVersion_AssignerOverridesPercentage(t *testing.T) {
+	ctx := context.Background()
+	riderID := uuid.New()
+	r := &Resolver{}
+
+	r.SetABTestAssigner(&fakeABTestAssigner{inTest: false, ok: true})
+	assert.Nil(t, r.chooseABTestVersion(ctx, []*PricingConfigVersion{abTestVersion(100)}, riderID))
+
+	none := abTestVersion(0)
+	r.SetABTestAssigner(&fakeABTestAssigner{inTest: true, ok: true})
+	assert.Equal(t, none, r.chooseABTestVersion(ctx, []*PricingConfigVersion{none}, riderID))
+
+	// Without an assignment the version's percentage decides
+	everyone := abTestVersion(100)
+	r.SetABTestAssigner(&fakeABTestAssigner{ok: false})
+	assert.Equal(t, everyone, r.chooseABTestVersion(ctx, []*PricingConfigVersion{everyone}, riderID))
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/internal/currency"
 	"github.com/richxcame/ride-hailing/internal/geography"
+	"github.com/richxcame/ride-hailing/pkg/common"
 	pkggeo "github.com/richxcame/ride-hailing/pkg/geo"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
 )
 
 // Service handles pricing business logic
@@ -18,6 +23,8 @@ type Service struct {
 	calculator  *Calculator
 	geoSvc      *geography.Service
 	currencySvc *currency.Service
+	rideSamples RideSampleSource
+	quotes      *QuoteSigner
 }
 
 // NewService creates a new pricing service
@@ -34,6 +41,18 @@ func NewService(repo RepositoryInterface, geoSvc *geography.Service, currencySvc
 	}
 }
 
+// SetABTestAssigner lets an experiment decide which riders are priced by A/B
+// test versions.
+func (s *Service) SetABTestAssigner(assigner ABTestAssigner) {
+	s.resolver.SetABTestAssigner(assigner)
+}
+
+// SetQuoteSigner makes estimates carry fare quote tokens that ride requests
+// can redeem to lock the price.
+func (s *Service) SetQuoteSigner(signer *QuoteSigner) {
+	s.quotes = signer
+}
+
 // CalculateFare calculates the fare for a ride
 func (s *Service) CalculateFare(ctx context.Context, input CalculateInput) (*FareCalculation, error) {
 	return s.calculator.Calculate(ctx, input)
@@ -71,6 +90,7 @@ func (s *Service) GetEstimate(ctx context.Context, req EstimateRequest) (*Estima
 		DurationMin:      durationMin,
 		RideTypeID:       req.RideTypeID,
 		Currency:         currencyCode,
+		RiderID:          optionalID(req.RiderID),
 	})
 	if err != nil {
 		return nil, err
@@ -94,7 +114,7 @@ func (s *Service) GetEstimate(ctx context.Context, req EstimateRequest) (*Estima
 		}
 	}
 
-	return &EstimateResponse{
+	estimate := &EstimateResponse{
 		Currency:         currencyCode,
 		EstimatedFare:    calculation.TotalFare,
 		MinimumFare:      minFare,
@@ -103,7 +123,68 @@ func (s *Service) GetEstimate(ctx context.Context, req EstimateRequest) (*Estima
 		EstimatedMinutes: durationMin,
 		FareBreakdown:    calculation,
 		FormattedFare:    formattedFare,
-	}, nil
+	}
+	estimate.QuoteToken, estimate.QuoteExpiresAt = s.issueQuote(FareQuote{
+		RiderID:          req.RiderID,
+		RideTypeID:       req.RideTypeID,
+		PickupLatitude:   req.PickupLatitude,
+		PickupLongitude:  req.PickupLongitude,
+		DropoffLatitude:  req.DropoffLatitude,
+		DropoffLongitude: req.DropoffLongitude,
+		DistanceKm:       distanceKm,
+		DurationMin:      durationMin,
+	}, calculation)
+
+	return estimate, nil
+}
+
+// issueQuote signs a quote for the calculated fare. It returns no token when
+// quotes are disabled or signing fails, so estimates still go out.
+func (s *Service) issueQuote(quote FareQuote, calculation *FareCalculation) (string, *time.Time) {
+	if s.quotes == nil {
+		return "", nil
+	}
+
+	quote.Fare = calculation.TotalFare
+	quote.Currency = calculation.Currency
+	quote.SurgeMultiplier = calculation.TotalMultiplier
+	if calculation.PricingVersionID != uuid.Nil {
+		versionID := calculation.PricingVersionID
+		quote.PricingVersionID = &versionID
+	}
+
+	token, err := s.quotes.Sign(&quote)
+	if err != nil {
+		logger.Warn("Failed to sign fare quote", zap.Error(err))
+		return "", nil
+	}
+	return token, &quote.ExpiresAt
+}
+
+// RedeemQuote verifies a quote token from a ride request and returns the
+// quote. A quote issued to another rider is rejected.
+func (s *Service) RedeemQuote(token string, riderID uuid.UUID) (*FareQuote, error) {
+	if s.quotes == nil {
+		return nil, common.NewServiceUnavailableError("fare quotes are not enabled")
+	}
+
+	quote, err := s.quotes.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if quote.RiderID != uuid.Nil && quote.RiderID != riderID {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeQuoteInvalid,
+			"fare quote was issued to another rider", nil)
+	}
+	return quote, nil
+}
+
+// optionalID returns nil for uuid.Nil
+func optionalID(id uuid.UUID) *uuid.UUID {
+	if id == uuid.Nil {
+		return nil
+	}
+	return &id
 }
 
 // GetPricing returns the resolved pricing for a location
@@ -147,6 +228,7 @@ func (s *Service) GetBulkEstimate(ctx context.Context, req BulkEstimateRequest,
 			DurationMin:      durationMin,
 			RideTypeID:       &rideTypeID,
 			Currency:         currencyCode,
+			RiderID:          optionalID(req.RiderID),
 		})
 		if err != nil {
 			// Skip ride types that fail calculation
@@ -171,7 +253,7 @@ func (s *Service) GetBulkEstimate(ctx context.Context, req BulkEstimateRequest,
 			}
 		}
 
-		estimates = append(estimates, RideTypeEstimate{
+		estimate := RideTypeEstimate{
 			RideTypeID:      rideType.ID,
 			RideTypeName:    rideType.Name,
 			Description:     rideType.Description,
@@ -183,7 +265,18 @@ func (s *Service) GetBulkEstimate(ctx context.Context, req BulkEstimateRequest,
 			SurgeMultiplier: calculation.TotalMultiplier,
 			FareBreakdown:   calculation,
 			FormattedFare:   formattedFare,
-		})
+		}
+		estimate.QuoteToken, estimate.QuoteExpiresAt = s.issueQuote(FareQuote{
+			RiderID:          req.RiderID,
+			RideTypeID:       &rideTypeID,
+			PickupLatitude:   req.PickupLatitude,
+			PickupLongitude:  req.PickupLongitude,
+			DropoffLatitude:  req.DropoffLatitude,
+			DropoffLongitude: req.DropoffLongitude,
+			DistanceKm:       distanceKm,
+			DurationMin:      durationMin,
+		}, calculation)
+		estimates = append(estimates, estimate)
 	}
 
 	return &BulkEstimateResponse{
@@ -156,6 +156,28 @@ func TestFareCalculation_RoundValues(t *testing.T) {
 	}
 }
 
+func TestFareCalculation_ApplyLegs(t *testing.T) {
+	pricing := &ResolvedPricing{PerKmRate: 1.5, PerMinuteRate: 0.25}
+	f := FareCalculation{DistanceKm: 99, DurationMin: 99}
+
+	f.applyLegs([]LegInput{
+		{DistanceKm: 2.0, DurationMin: 6},
+		{DistanceKm: 3.5, DurationMin: 10},
+		{DistanceKm: 1.0, DurationMin: 4},
+	}, pricing)
+
+	assert.Len(t, f.Legs, 3)
+	assert.Equal(t, 1, f.Legs[0].Leg)
+	assert.Equal(t, 5.25, f.Legs[1].DistanceCharge)
+	assert.Equal(t, 2.5, f.Legs[1].TimeCharge)
+
+	// Trip totals are the sum of the legs, not the caller's straight-line values
+	assert.InDelta(t, 6.5, f.DistanceKm, 1e-9)
+	assert.Equal(t, 20, f.DurationMin)
+	assert.InDelta(t, 9.75, f.DistanceCharge, 1e-9)
+	assert.InDelta(t, 5.0, f.TimeCharge, 1e-9)
+}
+
 func TestGetCancellationFee(t *testing.T) {
 	tests := []struct {
 		name                string
@@ -0,0 +1,205 @@
+package pricing
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/common"
+)
+
+const (
+	defaultSimulationSample = 200
+	maxSimulationSample     = 500
+	defaultSimulationPeriod = 7 * 24 * time.Hour
+)
+
+// RideSampleSource loads a random sample of completed rides to replay
+type RideSampleSource interface {
+	SampleCompletedRides(ctx context.Context, from, to time.Time, limit int) ([]SimulationRide, error)
+}
+
+// SetRideSampleSource enables SimulateVersion.
+func (s *Service) SetRideSampleSource(source RideSampleSource) {
+	s.rideSamples = source
+}
+
+// SimulateVersion replays a sample of completed rides against a version and
+// compares the fares and driver earnings with a baseline version, by default
+// the active one. Each ride is priced at the time it was requested. Nothing
+// is written, so drafts can be tried before they are activated.
+func (s *Service) SimulateVersion(ctx context.Context, versionID uuid.UUID, req SimulateVersionRequest) (*SimulationResult, error) {
+	if s.rideSamples == nil {
+		return nil, common.NewServiceUnavailableError("ride history is not available for simulation")
+	}
+
+	to := time.Now()
+	if req.To != nil {
+		to = *req.To
+	}
+	from := to.Add(-defaultSimulationPeriod)
+	if req.From != nil {
+		from = *req.From
+	}
+	if !from.Before(to) {
+		return nil, common.NewBadRequestError("from must be before to", nil)
+	}
+
+	sampleSize := req.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = defaultSimulationSample
+	}
+	if sampleSize > maxSimulationSample {
+		sampleSize = maxSimulationSample
+	}
+
+	baselineID := uuid.Nil
+	if req.BaselineVersionID != nil {
+		baselineID = *req.BaselineVersionID
+	} else if activeID, err := s.repo.GetActiveVersionID(ctx); err == nil {
+		baselineID = activeID
+	}
+
+	rides, err := s.rideSamples.SampleCompletedRides(ctx, from, to, sampleSize)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to load rides to simulate")
+	}
+
+	result := &SimulationResult{
+		VersionID:         versionID,
+		BaselineVersionID: baselineID,
+		From:              from,
+		To:                to,
+		Rides:             make([]SimulatedRide, 0, len(rides)),
+	}
+	for _, ride := range rides {
+		baseline, err := s.calculator.Calculate(ctx, simulationInput(ride, req, baselineID))
+		if err != nil {
+			return nil, common.NewInternalServerError("failed to price ride with baseline version")
+		}
+		simulated, err := s.calculator.Calculate(ctx, simulationInput(ride, req, versionID))
+		if err != nil {
+			return nil, common.NewInternalServerError("failed to price ride with simulated version")
+		}
+
+		sr := SimulatedRide{
+			RideID:                  ride.RideID,
+			RequestedAt:             ride.RequestedAt,
+			DistanceKm:              ride.DistanceKm,
+			DurationMin:             ride.DurationMin,
+			ChargedFare:             ride.ChargedFare,
+			BaselineFare:            baseline.TotalFare,
+			SimulatedFare:           simulated.TotalFare,
+			FareChange:              roundCents(simulated.TotalFare - baseline.TotalFare),
+			BaselineDriverEarnings:  baseline.DriverEarnings,
+			SimulatedDriverEarnings: simulated.DriverEarnings,
+		}
+		sr.FareChangePct = percentChange(baseline.TotalFare, simulated.TotalFare)
+		result.Rides = append(result.Rides, sr)
+	}
+	result.Summary = summarizeSimulation(result.Rides)
+
+	return result, nil
+}
+
+// simulationInput builds the calculator input that replays ride under a version
+func simulationInput(ride SimulationRide, req SimulateVersionRequest, versionID uuid.UUID) CalculateInput {
+	input := CalculateInput{
+		PickupLatitude:    ride.PickupLatitude,
+		PickupLongitude:   ride.PickupLongitude,
+		DropoffLatitude:   ride.DropoffLatitude,
+		DropoffLongitude:  ride.DropoffLongitude,
+		DistanceKm:        ride.DistanceKm,
+		DurationMin:       ride.DurationMin,
+		RideTypeID:        ride.RideTypeID,
+		WeatherCondition:  req.WeatherCondition,
+		DemandSupplyRatio: req.DemandSupplyRatio,
+		Currency:          ride.Currency,
+		PricedAt:          ride.RequestedAt,
+	}
+	if versionID != uuid.Nil {
+		input.VersionID = &versionID
+	}
+	return input
+}
+
+// summarizeSimulation aggregates fares and driver earnings across rides
+func summarizeSimulation(rides []SimulatedRide) SimulationSummary {
+	summary := SimulationSummary{Rides: len(rides)}
+	baseline := make([]float64, len(rides))
+	simulated := make([]float64, len(rides))
+
+	for i, r := range rides {
+		baseline[i] = r.BaselineFare
+		simulated[i] = r.SimulatedFare
+		summary.BaselineDriverEarnings += r.BaselineDriverEarnings
+		summary.SimulatedDriverEarnings += r.SimulatedDriverEarnings
+
+		switch {
+		case r.FareChange > 0:
+			summary.RidesPricier++
+		case r.FareChange < 0:
+			summary.RidesCheaper++
+		default:
+			summary.RidesUnchanged++
+		}
+	}
+
+	summary.BaselineFares = fareStats(baseline)
+	summary.SimulatedFares = fareStats(simulated)
+	summary.FareChangePct = percentChange(summary.BaselineFares.Total, summary.SimulatedFares.Total)
+	summary.BaselineDriverEarnings = roundCents(summary.BaselineDriverEarnings)
+	summary.SimulatedDriverEarnings = roundCents(summary.SimulatedDriverEarnings)
+	summary.DriverEarningsChangePct = percentChange(summary.BaselineDriverEarnings, summary.SimulatedDriverEarnings)
+
+	return summary
+}
+
+// fareStats returns the distribution of fares
+func fareStats(fares []float64) FareStats {
+	if len(fares) == 0 {
+		return FareStats{}
+	}
+
+	sorted := append([]float64(nil), fares...)
+	sort.Float64s(sorted)
+
+	var total float64
+	for _, f := range sorted {
+		total += f
+	}
+
+	return FareStats{
+		Min:    sorted[0],
+		Max:    sorted[len(sorted)-1],
+		Mean:   roundCents(total / float64(len(sorted))),
+		Median: percentile(sorted, 50),
+		P90:    percentile(sorted, 90),
+		Total:  roundCents(total),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// percentChange returns the change from before to after in percent, rounded
+// to two decimals
+func percentChange(before, after float64) float64 {
+	if before == 0 {
+		return 0
+	}
+	return roundCents((after - before) / before * 100)
+}
+
+// roundCents rounds to two decimals
+func roundCents(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,64 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFareStats(t *testing.T) {
+	stats := fareStats([]float64{30, 10, 20, 40, 50, 60, 70, 80, 90, 100})
+
+	assert.Equal(t, 10.0, stats.Min)
+	assert.Equal(t, 100.0, stats.Max)
+	assert.Equal(t, 55.0, stats.Mean)
+	assert.Equal(t, 50.0, stats.Median)
+	assert.Equal(t, 90.0, stats.P90)
+	assert.Equal(t, 550.0, stats.Total)
+
+	assert.Equal(t, FareStats{}, fareStats(nil))
+}
+
+func TestPercentChange(t *testing.T) {
+	assert.Equal(t, 10.0, percentChange(20, 22))
+	assert.Equal(t, -33.33, percentChange(30, 20))
+	assert.Equal(t, 0.0, percentChange(0, 15))
+}
+
+func TestSummarizeSimulation(t *testing.T) {
+	summary := summarizeSimulation([]SimulatedRide{
+		{BaselineFare: 10, SimulatedFare: 12, FareChange: 2, BaselineDriverEarnings: 8, SimulatedDriverEarnings: 9.6},
+		{BaselineFare: 20, SimulatedFare: 18, FareChange: -2, BaselineDriverEarnings: 16, SimulatedDriverEarnings: 14.4},
+		{BaselineFare: 30, SimulatedFare: 30, BaselineDriverEarnings: 24, SimulatedDriverEarnings: 24},
+	})
+
+	assert.Equal(t, 3, summary.Rides)
+	assert.Equal(t, 1, summary.RidesPricier)
+	assert.Equal(t, 1, summary.RidesCheaper)
+	assert.Equal(t, 1, summary.RidesUnchanged)
+	assert.Equal(t, 60.0, summary.BaselineFares.Total)
+	assert.Equal(t, 60.0, summary.SimulatedFares.Total)
+	assert.Equal(t, 0.0, summary.FareChangePct)
+	assert.Equal(t, 48.0, summary.BaselineDriverEarnings)
+	assert.Equal(t, 48.0, summary.SimulatedDriverEarnings)
+}
+
+func TestSimulationInput_PricesAtRequestTime(t *testing.T) {
+	requestedAt := time.Date(2026, 3, 6, 23, 30, 0, 0, time.UTC)
+	ride := SimulationRide{RideID: uuid.New(), DistanceKm: 5, DurationMin: 12, Currency: "EUR", RequestedAt: requestedAt}
+	versionID := uuid.New()
+
+	input := simulationInput(ride, SimulateVersionRequest{WeatherCondition: "rain"}, versionID)
+
+	assert.Equal(t, requestedAt, input.PricedAt)
+	assert.Equal(t, "rain", input.WeatherCondition)
+	assert.Equal(t, "EUR", input.Currency)
+	require.NotNil(t, input.VersionID)
+	assert.Equal(t, versionID, *input.VersionID)
+
+	// Without a baseline the calculator resolves the active version
+	assert.Nil(t, simulationInput(ride, SimulateVersionRequest{}, uuid.Nil).VersionID)
+}
@@ -0,0 +1,174 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// minRollbackSample is the fewest rides each side of a switch needs before a
+// rollback guard trusts the comparison
+const minRollbackSample = 30
+
+// VersionScheduler activates and retires pricing versions at their effective
+// times and rolls back versions whose rollback guard trips
+type VersionScheduler struct {
+	repo RepositoryInterface
+	now  func() time.Time
+}
+
+// NewVersionScheduler creates a new pricing version scheduler
+func NewVersionScheduler(repo RepositoryInterface) *VersionScheduler {
+	return &VersionScheduler{repo: repo, now: time.Now}
+}
+
+// Run performs one pass: activates due scheduled versions, retires the active
+// version once its effective window ends, archives finished A/B tests and
+// evaluates the active version's rollback guard.
+func (s *VersionScheduler) Run(ctx context.Context) error {
+	if err := s.activateDueVersions(ctx); err != nil {
+		return err
+	}
+	if err := s.expireActiveVersion(ctx); err != nil {
+		return err
+	}
+	if archived, err := s.repo.ArchiveExpiredABTests(ctx); err != nil {
+		return err
+	} else if archived > 0 {
+		logger.Info("Archived finished pricing A/B tests", zap.Int64("count", archived))
+	}
+	return s.checkRollbackGuard(ctx)
+}
+
+func (s *VersionScheduler) activateDueVersions(ctx context.Context) error {
+	due, err := s.repo.GetDueScheduledVersions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range due {
+		err := s.repo.SwitchActiveVersion(ctx, VersionSwitch{
+			ToVersionID: v.ID,
+			Event:       VersionEventActivated,
+			Reason:      "scheduled activation",
+		})
+		if err != nil {
+			return fmt.Errorf("activate scheduled version %s: %w", v.ID, err)
+		}
+		logger.Info("Activated scheduled pricing version",
+			zap.String("version_id", v.ID.String()), zap.Int("version_number", v.VersionNumber))
+	}
+	return nil
+}
+
+// expireActiveVersion restores the previous version once the active one's
+// effective window has ended, or just retires it if there is nothing to
+// restore.
+func (s *VersionScheduler) expireActiveVersion(ctx context.Context) error {
+	active, err := s.repo.GetActiveVersion(ctx)
+	if err != nil || active == nil {
+		return err
+	}
+	now := s.now()
+	if active.EffectiveUntil == nil || now.Before(*active.EffectiveUntil) {
+		return nil
+	}
+
+	restore := uuid.Nil
+	if active.PreviousVersionID != nil {
+		previous, err := s.repo.GetVersionByID(ctx, *active.PreviousVersionID)
+		if err != nil {
+			return err
+		}
+		if previous.EffectiveUntil == nil || now.Before(*previous.EffectiveUntil) {
+			restore = previous.ID
+		}
+	}
+
+	err = s.repo.SwitchActiveVersion(ctx, VersionSwitch{
+		FromVersionID: &active.ID,
+		ToVersionID:   restore,
+		Event:         VersionEventExpired,
+		Reason:        "effective window ended",
+	})
+	if err != nil {
+		return fmt.Errorf("expire version %s: %w", active.ID, err)
+	}
+	logger.Info("Expired pricing version",
+		zap.String("version_id", active.ID.String()), zap.String("restored_version_id", restore.String()))
+	return nil
+}
+
+// checkRollbackGuard compares the guard metric over the window after the
+// active version's switch with the same window before it, and restores the
+// previous version if it dropped by more than the allowed percentage. Each
+// activation is checked once.
+func (s *VersionScheduler) checkRollbackGuard(ctx context.Context) error {
+	active, err := s.repo.GetActiveVersion(ctx)
+	if err != nil || active == nil {
+		return err
+	}
+	if active.RollbackMetric == nil || active.RollbackMaxDropPct == nil || active.RollbackWindowMinutes == nil ||
+		active.ActivatedAt == nil || active.RollbackCheckedAt != nil {
+		return nil
+	}
+
+	window := time.Duration(*active.RollbackWindowMinutes) * time.Minute
+	switchedAt := *active.ActivatedAt
+	if s.now().Before(switchedAt.Add(window)) {
+		return nil
+	}
+
+	metric := *active.RollbackMetric
+	baseline, baselineRides, err := s.repo.GetRideConversion(ctx, metric, switchedAt.Add(-window), switchedAt)
+	if err != nil {
+		return err
+	}
+	observed, observedRides, err := s.repo.GetRideConversion(ctx, metric, switchedAt, switchedAt.Add(window))
+	if err != nil {
+		return err
+	}
+
+	if baselineRides < minRollbackSample || observedRides < minRollbackSample {
+		logger.Info("Too few rides to evaluate pricing rollback guard",
+			zap.String("version_id", active.ID.String()),
+			zap.Int("baseline_rides", baselineRides), zap.Int("observed_rides", observedRides))
+		return s.repo.MarkRollbackChecked(ctx, active.ID)
+	}
+
+	if !shouldRollBack(baseline, observed, *active.RollbackMaxDropPct) || active.PreviousVersionID == nil {
+		return s.repo.MarkRollbackChecked(ctx, active.ID)
+	}
+
+	err = s.repo.SwitchActiveVersion(ctx, VersionSwitch{
+		FromVersionID: &active.ID,
+		ToVersionID:   *active.PreviousVersionID,
+		Event:         VersionEventRolledBack,
+		Reason: fmt.Sprintf("%s fell from %.1f%% to %.1f%% (max drop %.1f%%)",
+			metric, baseline*100, observed*100, *active.RollbackMaxDropPct),
+		MetricName:    &metric,
+		BaselineValue: &baseline,
+		ObservedValue: &observed,
+	})
+	if err != nil {
+		return fmt.Errorf("roll back version %s: %w", active.ID, err)
+	}
+	logger.Warn("Rolled back pricing version after guard metric dropped",
+		zap.String("version_id", active.ID.String()),
+		zap.String("restored_version_id", active.PreviousVersionID.String()),
+		zap.String("metric", metric), zap.Float64("baseline", baseline), zap.Float64("observed", observed))
+	return s.repo.MarkRollbackChecked(ctx, active.ID)
+}
+
+// shouldRollBack reports whether observed dropped by more than maxDropPct
+// percent relative to baseline
+func shouldRollBack(baseline, observed, maxDropPct float64) bool {
+	if baseline <= 0 {
+		return false
+	}
+	return (baseline-observed)/baseline*100 > maxDropPct
+}
@@ -0,0 +1,184 @@
+package pricing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScheduleRepo implements the repository calls made by VersionScheduler.
+// Other RepositoryInterface methods are left nil and panic if used.
+type fakeScheduleRepo struct {
+	RepositoryInterface
+	due         []*PricingConfigVersion
+	active      *PricingConfigVersion
+	versions    map[uuid.UUID]*PricingConfigVersion
+	conversions map[time.Time]float64 // by window start
+	rides       int
+	switches    []VersionSwitch
+	checked     []uuid.UUID
+}
+
+func (f *fakeScheduleRepo) GetDueScheduledVersions(_ context.Context) ([]*PricingConfigVersion, error) {
+	return f.due, nil
+}
+
+func (f *fakeScheduleRepo) GetActiveVersion(_ context.Context) (*PricingConfigVersion, error) {
+	return f.active, nil
+}
+
+func (f *fakeScheduleRepo) GetVersionByID(_ context.Context, id uuid.UUID) (*PricingConfigVersion, error) {
+	return f.versions[id], nil
+}
+
+func (f *fakeScheduleRepo) SwitchActiveVersion(_ context.Context, sw VersionSwitch) error {
+	f.switches = append(f.switches, sw)
+	return nil
+}
+
+func (f *fakeScheduleRepo) ArchiveExpiredABTests(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeScheduleRepo) MarkRollbackChecked(_ context.Context, id uuid.UUID) error {
+	f.checked = append(f.checked, id)
+	return nil
+}
+
+func (f *fakeScheduleRepo) GetRideConversion(_ context.Context, _ string, from, _ time.Time) (float64, int, error) {
+	return f.conversions[from], f.rides, nil
+}
+
+func newTestVersionScheduler(repo *fakeScheduleRepo, now time.Time) *VersionScheduler {
+	s := NewVersionScheduler(repo)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+// guardedVersion is an active version switched to at activatedAt whose
+// accept rate may drop by at most 10% over a 60 minute window
+func guardedVersion(activatedAt time.Time, previousID uuid.UUID) *PricingConfigVersion {
+	metric, maxDrop, window := RollbackMetricAcceptRate, 10.0, 60
+	return &PricingConfigVersion{
+		ID:                    uuid.New(),
+		Status:                VersionStatusActive,
+		ActivatedAt:           &activatedAt,
+		PreviousVersionID:     &previousID,
+		RollbackMetric:        &metric,
+		RollbackMaxDropPct:    &maxDrop,
+		RollbackWindowMinutes: &window,
+	}
+}
+
+func TestVersionScheduler_ActivatesDueVersions(t *testing.T) {
+	due := &PricingConfigVersion{ID: uuid.New(), Status: VersionStatusScheduled}
+	repo := &fakeScheduleRepo{due: []*PricingConfigVersion{due}}
+
+	require.NoError(t, newTestVersionScheduler(repo, time.Now()).Run(context.Background()))
+
+	require.Len(t, repo.switches, 1)
+	assert.Equal(t, due.ID, repo.switches[0].ToVersionID)
+	assert.Equal(t, VersionEventActivated, repo.switches[0].Event)
+	assert.Nil(t, repo.switches[0].AdminID)
+}
+
+func TestVersionScheduler_ExpiredVersionRestoresPrevious(t *testing.T) {
+	now := time.Now()
+	ended := now.Add(-time.Minute)
+	previous := &PricingConfigVersion{ID: uuid.New(), Status: VersionStatusArchived}
+	active := &PricingConfigVersion{ID: uuid.New(), Status: VersionStatusActive, EffectiveUntil: &ended, PreviousVersionID: &previous.ID}
+	repo := &fakeScheduleRepo{active: active, versions: map[uuid.UUID]*PricingConfigVersion{previous.ID: previous}}
+
+	require.NoError(t, newTestVersionScheduler(repo, now).expireActiveVersion(context.Background()))
+
+	require.Len(t, repo.switches, 1)
+	assert.Equal(t, VersionEventExpired, repo.switches[0].Event)
+	assert.Equal(t, active.ID, *repo.switches[0].FromVersionID)
+	assert.Equal(t, previous.ID, repo.switches[0].ToVersionID)
+}
+
+func TestVersionScheduler_ExpiredVersionWithoutValidPrevious(t *testing.T) {
+	now := time.Now()
+	ended := now.Add(-time.Minute)
+	previous := &PricingConfigVersion{ID: uuid.New(), Status: VersionStatusArchived, EffectiveUntil: &ended}
+	active := &PricingConfigVersion{ID: uuid.New(), Status: VersionStatusActive, EffectiveUntil: &ended, PreviousVersionID: &previous.ID}
+	repo := &fakeScheduleRepo{active: active, versions: map[uuid.UUID]*PricingConfigVersion{previous.ID: previous}}
+
+	require.NoError(t, newTestVersionScheduler(repo, now).expireActiveVersion(context.Background()))
+
+	require.Len(t, repo.switches, 1)
+	assert.Equal(t, uuid.Nil, repo.switches[0].ToVersionID)
+}
+
+func TestVersionScheduler_RollsBackWhenMetricDrops(t *testing.T) {
+	activatedAt := time.Now().Add(-2 * time.Hour)
+	previousID := uuid.New()
+	active := guardedVersion(activatedAt, previousID)
+	repo := &fakeScheduleRepo{
+		active: active,
+		rides:  100,
+		conversions: map[time.Time]float64{
+			activatedAt.Add(-time.Hour): 0.80,
+			activatedAt:                 0.60,
+		},
+	}
+
+	require.NoError(t, newTestVersionScheduler(repo, time.Now()).checkRollbackGuard(context.Background()))
+
+	require.Len(t, repo.switches, 1)
+	sw := repo.switches[0]
+	assert.Equal(t, VersionEventRolledBack, sw.Event)
+	assert.Equal(t, previousID, sw.ToVersionID)
+	assert.InDelta(t, 0.80, *sw.BaselineValue, 1e-9)
+	assert.InDelta(t, 0.60, *sw.ObservedValue, 1e-9)
+	assert.Equal(t, []uuid.UUID{active.ID}, repo.checked)
+}
+
+func TestVersionScheduler_KeepsVersionWithinThreshold(t *testing.T) {
+	activatedAt := time.Now().Add(-2 * time.Hour)
+	active := guardedVersion(activatedAt, uuid.New())
+	repo := &fakeScheduleRepo{
+		active: active,
+		rides:  100,
+		conversions: map[time.Time]float64{
+			activatedAt.Add(-time.Hour): 0.80,
+			activatedAt:                 0.75,
+		},
+	}
+
+	require.NoError(t, newTestVersionScheduler(repo, time.Now()).checkRollbackGuard(context.Background()))
+
+	assert.Empty(t, repo.switches)
+	assert.Equal(t, []uuid.UUID{active.ID}, repo.checked)
+}
+
+func TestVersionScheduler_GuardWaitsForWindowAndSample(t *testing.T) {
+	ctx := context.Background()
+
+	// Window still open
+	repo := &fakeScheduleRepo{active: guardedVersion(time.Now().Add(-30*time.Minute), uuid.New()), rides: 100}
+	require.NoError(t, newTestVersionScheduler(repo, time.Now()).checkRollbackGuard(ctx))
+	assert.Empty(t, repo.checked)
+
+	// Too few rides to compare
+	activatedAt := time.Now().Add(-2 * time.Hour)
+	repo = &fakeScheduleRepo{
+		active:      guardedVersion(activatedAt, uuid.New()),
+		rides:       minRollbackSample - 1,
+		conversions: map[time.Time]float64{activatedAt.Add(-time.Hour): 0.80, activatedAt: 0.10},
+	}
+	require.NoError(t, newTestVersionScheduler(repo, time.Now()).checkRollbackGuard(ctx))
+	assert.Empty(t, repo.switches)
+	assert.Len(t, repo.checked, 1)
+}
+
+func TestShouldRollBack(t *testing.T) {
+	assert.True(t, shouldRollBack(0.80, 0.70, 10))
+	assert.False(t, shouldRollBack(0.80, 0.73, 10))
+	assert.False(t, shouldRollBack(0.80, 0.90, 10))
+	assert.False(t, shouldRollBack(0, 0, 10))
+}
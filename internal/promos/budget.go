@@ -0,0 +1,211 @@
+package promos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// burnRateWindow is the period spend is measured over for burn-rate alerts
+const burnRateWindow = time.Hour
+
+// BudgetError is returned by CreatePromoCodeUse when the promo code's or its
+// campaign's budget has nothing left for the use
+type BudgetError struct {
+	Scope  string // "promo" or "campaign"
+	Reason string // ReasonBudget or ReasonDailyBudget
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s %s", e.Scope, e.Reason)
+}
+
+// Message is the rider-facing explanation
+func (e *BudgetError) Message() string {
+	if e.Reason == ReasonDailyBudget {
+		return "This promo code has reached its limit for today"
+	}
+	return "This promo code is no longer available"
+}
+
+// budgetLeft returns how much discount a total and daily budget still allow,
+// and which of the two is binding. Unset budgets allow any amount.
+func budgetLeft(budget, dailyBudget *float64, spent, spentToday float64) (float64, string) {
+	left, reason := math.Inf(1), ""
+	if budget != nil {
+		left, reason = math.Max(*budget-spent, 0), ReasonBudget
+	}
+	if dailyBudget != nil && *dailyBudget-spentToday < left {
+		left, reason = math.Max(*dailyBudget-spentToday, 0), ReasonDailyBudget
+	}
+	return left, reason
+}
+
+// budgetCovers caps discount to what the budgets allow. It returns the
+// rejection if there is less than a cent left.
+func budgetCovers(discount, left float64, reason, scope string) (float64, *BudgetError) {
+	if left < 0.01 {
+		return 0, &BudgetError{Scope: scope, Reason: reason}
+	}
+	if discount > left {
+		discount = math.Floor(left*100) / 100
+	}
+	return discount, nil
+}
+
+// validateBudget checks the budget settings of a promo code or campaign
+func validateBudget(budget, dailyBudget, burnRateAlert *float64) error {
+	if budget != nil && *budget <= 0 {
+		return fmt.Errorf("budget must be greater than 0")
+	}
+	if dailyBudget != nil && *dailyBudget <= 0 {
+		return fmt.Errorf("daily_budget must be greater than 0")
+	}
+	if budget != nil && dailyBudget != nil && *dailyBudget > *budget {
+		return fmt.Errorf("daily_budget cannot exceed budget")
+	}
+	if burnRateAlert != nil && *burnRateAlert <= 0 {
+		return fmt.Errorf("burn_rate_alert must be greater than 0")
+	}
+	return nil
+}
+
+// checkBudgets caps a promo code's discount to what the code's and its
+// campaign's budgets have left, as of the last use. ApplyPromoCode enforces
+// the budgets atomically; this lets validation quote the right discount and
+// reject spent codes early.
+func (s *Service) checkBudgets(ctx context.Context, promo *PromoCode, discount float64) (float64, *PromoCodeValidation, error) {
+	left, reason := budgetLeft(promo.Budget, promo.DailyBudget, promo.Spent, promo.SpentToday)
+	discount, budgetErr := budgetCovers(discount, left, reason, "promo")
+	if budgetErr != nil {
+		return 0, rejected(budgetErr.Reason, budgetErr.Message()), nil
+	}
+
+	if promo.CampaignID == nil {
+		return discount, nil, nil
+	}
+	campaign, err := s.repo.GetPromoCampaignByID(ctx, *promo.CampaignID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get promo campaign: %w", err)
+	}
+	if !campaign.IsActive {
+		return 0, rejected(ReasonInactive, "This promo code is no longer active"), nil
+	}
+	left, reason = budgetLeft(campaign.Budget, campaign.DailyBudget, campaign.Spent, campaign.SpentToday)
+	discount, budgetErr = budgetCovers(discount, left, reason, "campaign")
+	if budgetErr != nil {
+		return 0, rejected(budgetErr.Reason, budgetErr.Message()), nil
+	}
+	return discount, nil, nil
+}
+
+// checkBurnRate raises an alert when the promo code or its campaign has spent
+// more in the last hour than its configured burn rate. At most one alert per
+// code or campaign is raised an hour. Failures are logged: the use has
+// already been recorded.
+func (s *Service) checkBurnRate(ctx context.Context, promo *PromoCode) {
+	since := time.Now().Add(-burnRateWindow)
+
+	if promo.BurnRateAlert != nil {
+		spend, err := s.repo.GetPromoSpendSince(ctx, promo.ID, since)
+		if err != nil {
+			logger.WarnContext(ctx, "failed to check promo burn rate", zap.String("promo_code_id", promo.ID.String()), zap.Error(err))
+		} else if spend > *promo.BurnRateAlert {
+			s.raiseBurnRateAlert(ctx, &PromoBudgetAlert{
+				PromoCodeID:  &promo.ID,
+				SpendPerHour: spend,
+				Threshold:    *promo.BurnRateAlert,
+				Message:      fmt.Sprintf("Promo code %s spent %.2f in the last hour, above its alert rate of %.2f", promo.Code, spend, *promo.BurnRateAlert),
+			})
+		}
+	}
+
+	if promo.CampaignID == nil {
+		return
+	}
+	campaign, err := s.repo.GetPromoCampaignByID(ctx, *promo.CampaignID)
+	if err != nil || campaign.BurnRateAlert == nil {
+		return
+	}
+	spend, err := s.repo.GetCampaignSpendSince(ctx, campaign.ID, since)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to check campaign burn rate", zap.String("campaign_id", campaign.ID.String()), zap.Error(err))
+		return
+	}
+	if spend > *campaign.BurnRateAlert {
+		s.raiseBurnRateAlert(ctx, &PromoBudgetAlert{
+			CampaignID:   &campaign.ID,
+			SpendPerHour: spend,
+			Threshold:    *campaign.BurnRateAlert,
+			Message:      fmt.Sprintf("Campaign %q spent %.2f in the last hour, above its alert rate of %.2f", campaign.Name, spend, *campaign.BurnRateAlert),
+		})
+	}
+}
+
+func (s *Service) raiseBurnRateAlert(ctx context.Context, alert *PromoBudgetAlert) {
+	alert.AlertType = AlertBurnRate
+	created, err := s.repo.RecordBurnRateAlert(ctx, alert, time.Now().Add(-burnRateWindow))
+	if err != nil {
+		logger.WarnContext(ctx, "failed to record promo burn rate alert", zap.Error(err))
+		return
+	}
+	if created {
+		logger.WarnContext(ctx, "promo spend above burn rate", zap.String("alert", alert.Message))
+	}
+}
+
+// asBudgetError reports whether err is a *BudgetError
+func asBudgetError(err error) (*BudgetError, bool) {
+	var budgetErr *BudgetError
+	ok := errors.As(err, &budgetErr)
+	return budgetErr, ok
+}
+
+// CreatePromoCampaign creates a promo campaign (admin only)
+func (s *Service) CreatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error {
+	campaign.Name = strings.TrimSpace(campaign.Name)
+	if campaign.Name == "" {
+		return fmt.Errorf("campaign name cannot be empty")
+	}
+	if err := validateBudget(campaign.Budget, campaign.DailyBudget, campaign.BurnRateAlert); err != nil {
+		return err
+	}
+	return s.repo.CreatePromoCampaign(ctx, campaign)
+}
+
+// UpdatePromoCampaign updates a promo campaign's name, budgets and status
+// (admin only). Raising the budget of an exhausted campaign and setting
+// is_active reopens it.
+func (s *Service) UpdatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error {
+	campaign.Name = strings.TrimSpace(campaign.Name)
+	if campaign.Name == "" {
+		return fmt.Errorf("campaign name cannot be empty")
+	}
+	if err := validateBudget(campaign.Budget, campaign.DailyBudget, campaign.BurnRateAlert); err != nil {
+		return err
+	}
+	return s.repo.UpdatePromoCampaign(ctx, campaign)
+}
+
+// GetPromoCampaignByID retrieves a promo campaign by ID
+func (s *Service) GetPromoCampaignByID(ctx context.Context, campaignID uuid.UUID) (*PromoCampaign, error) {
+	return s.repo.GetPromoCampaignByID(ctx, campaignID)
+}
+
+// GetAllPromoCampaigns retrieves all promo campaigns with pagination
+func (s *Service) GetAllPromoCampaigns(ctx context.Context, limit, offset int) ([]*PromoCampaign, int, error) {
+	return s.repo.GetAllPromoCampaigns(ctx, limit, offset)
+}
+
+// GetPromoCampaignUsageStats retrieves spend and burn-rate statistics for a
+// promo campaign
+func (s *Service) GetPromoCampaignUsageStats(ctx context.Context, campaignID uuid.UUID) (map[string]interface{}, error) {
+	return s.repo.GetPromoCampaignUsageStats(ctx, campaignID)
+}
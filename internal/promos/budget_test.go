@@ -0,0 +1,220 @@
+package promos
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestBudgetLeft(t *testing.T) {
+	left, reason := budgetLeft(nil, nil, 500, 50)
+	assert.True(t, math.IsInf(left, 1))
+	assert.Empty(t, reason)
+
+	left, reason = budgetLeft(floatPtr(100), nil, 80, 0)
+	assert.Equal(t, 20.0, left)
+	assert.Equal(t, ReasonBudget, reason)
+
+	// The daily budget binds when it has less left than the total
+	left, reason = budgetLeft(floatPtr(100), floatPtr(30), 50, 25)
+	assert.Equal(t, 5.0, left)
+	assert.Equal(t, ReasonDailyBudget, reason)
+
+	left, _ = budgetLeft(floatPtr(100), nil, 120, 0)
+	assert.Zero(t, left)
+}
+
+func TestBudgetCovers(t *testing.T) {
+	discount, err := budgetCovers(5, 20, ReasonBudget, "promo")
+	assert.Nil(t, err)
+	assert.Equal(t, 5.0, discount)
+
+	discount, err = budgetCovers(5, 3.457, ReasonBudget, "promo")
+	assert.Nil(t, err)
+	assert.Equal(t, 3.45, discount)
+
+	_, err = budgetCovers(5, 0.004, ReasonDailyBudget, "campaign")
+	require.NotNil(t, err)
+	assert.Equal(t, "campaign", err.Scope)
+	assert.Equal(t, ReasonDailyBudget, err.Reason)
+}
+
+func TestValidateBudget(t *testing.T) {
+	assert.NoError(t, validateBudget(nil, nil, nil))
+	assert.NoError(t, validateBudget(floatPtr(1000), floatPtr(100), floatPtr(50)))
+	assert.Error(t, validateBudget(floatPtr(0), nil, nil))
+	assert.Error(t, validateBudget(nil, floatPtr(-5), nil))
+	assert.Error(t, validateBudget(floatPtr(100), floatPtr(200), nil))
+	assert.Error(t, validateBudget(nil, nil, floatPtr(0)))
+}
+
+func TestValidatePromoCodeCapsDiscountToBudget(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID := uuid.New()
+	promo := validFixedPromo()
+	promo.Budget = floatPtr(100)
+	promo.Spent = 98
+
+	repo.On("GetPromoCodeByCode", ctx, "WELCOME5").Return(promo, nil).Once()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+
+	result, err := service.ValidatePromoCode(ctx, "WELCOME5", userID, 50, RideContext{})
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.InDelta(t, 2.0, result.DiscountAmount, 0.0001)
+	assert.InDelta(t, 48.0, result.FinalAmount, 0.0001)
+	repo.AssertExpectations(t)
+}
+
+func TestValidatePromoCodeCampaignDailyBudgetReached(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID := uuid.New()
+	campaign := &PromoCampaign{ID: uuid.New(), Name: "Spring", IsActive: true, DailyBudget: floatPtr(200), SpentToday: 200}
+	promo := validFixedPromo()
+	promo.CampaignID = &campaign.ID
+
+	repo.On("GetPromoCodeByCode", ctx, "WELCOME5").Return(promo, nil).Once()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+	repo.On("GetPromoCampaignByID", ctx, campaign.ID).Return(campaign, nil).Once()
+
+	result, err := service.ValidatePromoCode(ctx, "WELCOME5", userID, 50, RideContext{})
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ReasonDailyBudget, result.Reason)
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPromoCodeBudgetExhausted(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID := uuid.New()
+	promo := validFixedPromo()
+	promo.Budget = floatPtr(100)
+	promo.Spent = 90
+
+	// Another use spent the rest of the budget between validation and apply
+	repo.On("GetPromoCodeByCode", ctx, "WELCOME5").Return(promo, nil).Twice()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+	repo.On("CreatePromoCodeUse", ctx, mock.Anything).Return(&BudgetError{Scope: "promo", Reason: ReasonBudget}).Once()
+
+	use, err := service.ApplyPromoCode(ctx, "WELCOME5", userID, uuid.New(), 50, RideContext{})
+	assert.Nil(t, use)
+	assert.EqualError(t, err, "This promo code is no longer available")
+	repo.AssertExpectations(t)
+}
+
+func TestApplyPromoCodeRaisesBurnRateAlert(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID := uuid.New()
+	promo := validFixedPromo()
+	promo.BurnRateAlert = floatPtr(40)
+
+	repo.On("GetPromoCodeByCode", ctx, "WELCOME5").Return(promo, nil).Twice()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+	repo.On("CreatePromoCodeUse", ctx, mock.Anything).Return(nil).Once()
+	repo.On("GetPromoSpendSince", ctx, promo.ID, mock.Anything).Return(55.0, nil).Once()
+	repo.On("RecordBurnRateAlert", ctx, mock.MatchedBy(func(alert *PromoBudgetAlert) bool {
+		return alert.AlertType == AlertBurnRate &&
+			alert.PromoCodeID != nil && *alert.PromoCodeID == promo.ID &&
+			alert.SpendPerHour == 55 && alert.Threshold == 40
+	}), mock.Anything).Return(true, nil).Once()
+
+	_, err := service.ApplyPromoCode(ctx, "WELCOME5", userID, uuid.New(), 50, RideContext{})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestRedeemPromoCode(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID, rideID := uuid.New(), uuid.New()
+	promo := validFixedPromo()
+	promo.BurnRateAlert = floatPtr(40)
+
+	// The budget had 3.00 left of the 5.00 validated at request time
+	repo.On("GetPromoCodeByID", ctx, promo.ID).Return(promo, nil).Once()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+	repo.On("CreatePromoCodeUse", ctx, mock.MatchedBy(func(use *PromoCodeUse) bool {
+		return use.RideID == rideID && use.UserID == userID && use.DiscountAmount == 5 && use.OriginalAmount == 50
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*PromoCodeUse).DiscountAmount = 3
+	}).Return(nil).Once()
+	repo.On("GetPromoSpendSince", ctx, promo.ID, mock.Anything).Return(10.0, nil).Once()
+
+	granted, err := service.RedeemPromoCode(ctx, promo.ID, userID, rideID, "card", 50, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 3.0, granted)
+	repo.AssertExpectations(t)
+}
+
+func TestRedeemPromoCodeGrantsNothingPastLimits(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("user limit reached by another ride", func(t *testing.T) {
+		repo := new(mockPromosRepository)
+		promo := validFixedPromo()
+		repo.On("GetPromoCodeByID", ctx, promo.ID).Return(promo, nil).Once()
+		repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(promo.UsesPerUser, nil).Once()
+
+		granted, err := NewService(repo).RedeemPromoCode(ctx, promo.ID, userID, uuid.New(), "card", 50, 5)
+		require.NoError(t, err)
+		assert.Zero(t, granted)
+		repo.AssertNotCalled(t, "CreatePromoCodeUse", mock.Anything, mock.Anything)
+	})
+
+	t.Run("budget exhausted", func(t *testing.T) {
+		repo := new(mockPromosRepository)
+		promo := validFixedPromo()
+		repo.On("GetPromoCodeByID", ctx, promo.ID).Return(promo, nil).Once()
+		repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+		repo.On("CreatePromoCodeUse", ctx, mock.Anything).Return(&BudgetError{Scope: "campaign", Reason: ReasonBudget}).Once()
+
+		granted, err := NewService(repo).RedeemPromoCode(ctx, promo.ID, userID, uuid.New(), "card", 50, 5)
+		require.NoError(t, err)
+		assert.Zero(t, granted)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("ride paid another way", func(t *testing.T) {
+		repo := new(mockPromosRepository)
+		promo := validFixedPromo()
+		promo.Rules = &PromoRules{PaymentMethods: []string{"wallet"}}
+		repo.On("GetPromoCodeByID", ctx, promo.ID).Return(promo, nil).Once()
+
+		granted, err := NewService(repo).RedeemPromoCode(ctx, promo.ID, userID, uuid.New(), "card", 50, 5)
+		require.NoError(t, err)
+		assert.Zero(t, granted)
+		repo.AssertNotCalled(t, "CreatePromoCodeUse", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreatePromoCampaignValidation(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+
+	assert.Error(t, service.CreatePromoCampaign(ctx, &PromoCampaign{Name: "  "}))
+	assert.Error(t, service.CreatePromoCampaign(ctx, &PromoCampaign{Name: "Launch", Budget: floatPtr(100), DailyBudget: floatPtr(500)}))
+
+	campaign := &PromoCampaign{Name: " Launch ", Budget: floatPtr(5000), DailyBudget: floatPtr(500)}
+	repo.On("CreatePromoCampaign", ctx, campaign).Return(nil).Once()
+	require.NoError(t, service.CreatePromoCampaign(ctx, campaign))
+	assert.Equal(t, "Launch", campaign.Name)
+	repo.AssertExpectations(t)
+}
@@ -31,6 +31,7 @@ func (h *Handler) ValidatePromoCode(c *gin.Context) {
 	var req struct {
 		Code       string  `json:"code" binding:"required"`
 		RideAmount float64 `json:"ride_amount" binding:"required,gt=0"`
+		RideContext
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -38,7 +39,7 @@ func (h *Handler) ValidatePromoCode(c *gin.Context) {
 		return
 	}
 
-	validation, err := h.service.ValidatePromoCode(c.Request.Context(), req.Code, userID, req.RideAmount)
+	validation, err := h.service.ValidatePromoCode(c.Request.Context(), req.Code, userID, req.RideAmount, req.RideContext)
 	if err != nil {
 		common.ErrorResponse(c, http.StatusInternalServerError, "failed to validate promo code")
 		return
@@ -300,6 +301,98 @@ func (h *Handler) GetPromoCodeUsageStats(c *gin.Context) {
 	common.SuccessResponse(c, stats)
 }
 
+// CreatePromoCampaign creates a promo campaign (admin only)
+func (h *Handler) CreatePromoCampaign(c *gin.Context) {
+	var campaign PromoCampaign
+	if err := c.ShouldBindJSON(&campaign); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	campaign.CreatedBy = &userID
+
+	if err := h.service.CreatePromoCampaign(c.Request.Context(), &campaign); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	common.CreatedResponse(c, campaign)
+}
+
+// GetAllPromoCampaigns returns all promo campaigns with pagination (admin only)
+func (h *Handler) GetAllPromoCampaigns(c *gin.Context) {
+	params := pagination.ParseParams(c)
+
+	campaigns, total, err := h.service.GetAllPromoCampaigns(c.Request.Context(), params.Limit, params.Offset)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get promo campaigns")
+		return
+	}
+
+	meta := pagination.BuildMeta(params.Limit, params.Offset, int64(total))
+	common.SuccessResponseWithMeta(c, campaigns, meta)
+}
+
+// GetPromoCampaign returns a specific promo campaign (admin only)
+func (h *Handler) GetPromoCampaign(c *gin.Context) {
+	campaignID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid campaign ID")
+		return
+	}
+
+	campaign, err := h.service.GetPromoCampaignByID(c.Request.Context(), campaignID)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusNotFound, "promo campaign not found")
+		return
+	}
+
+	common.SuccessResponse(c, campaign)
+}
+
+// UpdatePromoCampaign updates a promo campaign (admin only)
+func (h *Handler) UpdatePromoCampaign(c *gin.Context) {
+	campaignID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid campaign ID")
+		return
+	}
+
+	var campaign PromoCampaign
+	if err := c.ShouldBindJSON(&campaign); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	campaign.ID = campaignID
+
+	if err := h.service.UpdatePromoCampaign(c.Request.Context(), &campaign); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	common.SuccessResponse(c, campaign)
+}
+
+// GetPromoCampaignUsageStats returns spend and burn-rate statistics for a
+// promo campaign (admin only)
+func (h *Handler) GetPromoCampaignUsageStats(c *gin.Context) {
+	campaignID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid campaign ID")
+		return
+	}
+
+	stats, err := h.service.GetPromoCampaignUsageStats(c.Request.Context(), campaignID)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get promo campaign stats")
+		return
+	}
+
+	common.SuccessResponse(c, stats)
+}
+
 // GetReferralDetails returns detailed information about a referral (admin only)
 func (h *Handler) GetReferralDetails(c *gin.Context) {
 	referralID, err := uuid.Parse(c.Param("id"))
@@ -346,6 +439,15 @@ func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup) {
 		promos.GET("/:id/usage-stats", h.GetPromoCodeUsageStats)
 	}
 
+	campaigns := rg.Group("/promo-campaigns")
+	{
+		campaigns.POST("", h.CreatePromoCampaign)
+		campaigns.GET("", h.GetAllPromoCampaigns)
+		campaigns.GET("/:id", h.GetPromoCampaign)
+		campaigns.PUT("/:id", h.UpdatePromoCampaign)
+		campaigns.GET("/:id/usage-stats", h.GetPromoCampaignUsageStats)
+	}
+
 	referrals := rg.Group("/referrals")
 	{
 		referrals.GET("", h.GetAllReferralCodes)
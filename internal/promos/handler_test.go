@@ -25,16 +25,16 @@ type MockService struct {
 	mock.Mock
 }
 
-func (m *MockService) ValidatePromoCode(ctx context.Context, code string, userID uuid.UUID, rideAmount float64) (*PromoCodeValidation, error) {
-	args := m.Called(ctx, code, userID, rideAmount)
+func (m *MockService) ValidatePromoCode(ctx context.Context, code string, userID uuid.UUID, rideAmount float64, ride RideContext) (*PromoCodeValidation, error) {
+	args := m.Called(ctx, code, userID, rideAmount, ride)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*PromoCodeValidation), args.Error(1)
 }
 
-func (m *MockService) ApplyPromoCode(ctx context.Context, code string, userID, rideID uuid.UUID, originalAmount float64) (*PromoCodeUse, error) {
-	args := m.Called(ctx, code, userID, rideID, originalAmount)
+func (m *MockService) ApplyPromoCode(ctx context.Context, code string, userID, rideID uuid.UUID, originalAmount float64, ride RideContext) (*PromoCodeUse, error) {
+	args := m.Called(ctx, code, userID, rideID, originalAmount, ride)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -244,7 +244,7 @@ func TestHandler_ValidatePromoCode_Success(t *testing.T) {
 		FinalAmount:    40.0,
 	}
 
-	th.mockService.On("ValidatePromoCode", mock.Anything, "SAVE20", userID, 50.0).Return(expectedValidation, nil)
+	th.mockService.On("ValidatePromoCode", mock.Anything, "SAVE20", userID, 50.0, mock.Anything).Return(expectedValidation, nil)
 
 	c, w := setupTestContext("POST", "/api/v1/promos/validate", reqBody)
 	setUserContext(c, userID)
@@ -347,7 +347,7 @@ func TestHandler_ValidatePromoCode_ServiceError(t *testing.T) {
 		"ride_amount": 50.0,
 	}
 
-	th.mockService.On("ValidatePromoCode", mock.Anything, "SAVE20", userID, 50.0).Return(nil, errors.New("database error"))
+	th.mockService.On("ValidatePromoCode", mock.Anything, "SAVE20", userID, 50.0, mock.Anything).Return(nil, errors.New("database error"))
 
 	c, w := setupTestContext("POST", "/api/v1/promos/validate", reqBody)
 	setUserContext(c, userID)
@@ -373,7 +373,7 @@ func TestHandler_ValidatePromoCode_InvalidPromo(t *testing.T) {
 		Message: "This promo code has expired",
 	}
 
-	th.mockService.On("ValidatePromoCode", mock.Anything, "EXPIRED", userID, 50.0).Return(expectedValidation, nil)
+	th.mockService.On("ValidatePromoCode", mock.Anything, "EXPIRED", userID, 50.0, mock.Anything).Return(expectedValidation, nil)
 
 	c, w := setupTestContext("POST", "/api/v1/promos/validate", reqBody)
 	setUserContext(c, userID)
@@ -400,6 +400,7 @@ func validatePromoCodeHandler(c *gin.Context, svc *MockService) {
 	var req struct {
 		Code       string  `json:"code" binding:"required"`
 		RideAmount float64 `json:"ride_amount" binding:"required,gt=0"`
+		RideContext
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -407,7 +408,7 @@ func validatePromoCodeHandler(c *gin.Context, svc *MockService) {
 		return
 	}
 
-	validation, err := svc.ValidatePromoCode(c.Request.Context(), req.Code, userID.(uuid.UUID), req.RideAmount)
+	validation, err := svc.ValidatePromoCode(c.Request.Context(), req.Code, userID.(uuid.UUID), req.RideAmount, req.RideContext)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": 500, "message": "failed to validate promo code"}})
 		return
@@ -1649,7 +1650,7 @@ func TestHandler_ValidatePromoCode_TableDriven(t *testing.T) {
 			code:       "SAVE20",
 			rideAmount: 100.0,
 			setupMock: func(svc *MockService, userID uuid.UUID) {
-				svc.On("ValidatePromoCode", mock.Anything, "SAVE20", userID, 100.0).Return(&PromoCodeValidation{
+				svc.On("ValidatePromoCode", mock.Anything, "SAVE20", userID, 100.0, mock.Anything).Return(&PromoCodeValidation{
 					Valid:          true,
 					DiscountAmount: 20.0,
 					FinalAmount:    80.0,
@@ -1663,7 +1664,7 @@ func TestHandler_ValidatePromoCode_TableDriven(t *testing.T) {
 			code:       "EXPIRED",
 			rideAmount: 50.0,
 			setupMock: func(svc *MockService, userID uuid.UUID) {
-				svc.On("ValidatePromoCode", mock.Anything, "EXPIRED", userID, 50.0).Return(&PromoCodeValidation{
+				svc.On("ValidatePromoCode", mock.Anything, "EXPIRED", userID, 50.0, mock.Anything).Return(&PromoCodeValidation{
 					Valid:   false,
 					Message: "This promo code has expired",
 				}, nil)
@@ -1676,7 +1677,7 @@ func TestHandler_ValidatePromoCode_TableDriven(t *testing.T) {
 			code:       "MAXED",
 			rideAmount: 50.0,
 			setupMock: func(svc *MockService, userID uuid.UUID) {
-				svc.On("ValidatePromoCode", mock.Anything, "MAXED", userID, 50.0).Return(&PromoCodeValidation{
+				svc.On("ValidatePromoCode", mock.Anything, "MAXED", userID, 50.0, mock.Anything).Return(&PromoCodeValidation{
 					Valid:   false,
 					Message: "This promo code has reached its maximum usage limit",
 				}, nil)
@@ -1689,7 +1690,7 @@ func TestHandler_ValidatePromoCode_TableDriven(t *testing.T) {
 			code:       "MINRIDE",
 			rideAmount: 10.0,
 			setupMock: func(svc *MockService, userID uuid.UUID) {
-				svc.On("ValidatePromoCode", mock.Anything, "MINRIDE", userID, 10.0).Return(&PromoCodeValidation{
+				svc.On("ValidatePromoCode", mock.Anything, "MINRIDE", userID, 10.0, mock.Anything).Return(&PromoCodeValidation{
 					Valid:   false,
 					Message: "Minimum ride amount of $25.00 required",
 				}, nil)
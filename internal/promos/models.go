@@ -8,22 +8,89 @@ import (
 
 // PromoCode represents a promotional discount code
 type PromoCode struct {
-	ID                uuid.UUID  `json:"id"`
-	Code              string     `json:"code"`
-	Description       string     `json:"description"`
-	DiscountType      string     `json:"discount_type"` // "percentage" or "fixed_amount"
-	DiscountValue     float64    `json:"discount_value"`
-	MaxDiscountAmount *float64   `json:"max_discount_amount,omitempty"`
-	MinRideAmount     *float64   `json:"min_ride_amount,omitempty"`
-	MaxUses           *int       `json:"max_uses,omitempty"`
-	TotalUses         int        `json:"total_uses"`
-	UsesPerUser       int        `json:"uses_per_user"`
-	ValidFrom         time.Time  `json:"valid_from"`
-	ValidUntil        time.Time  `json:"valid_until"`
-	IsActive          bool       `json:"is_active"`
-	CreatedBy         *uuid.UUID `json:"created_by,omitempty"`
-	CreatedAt         time.Time  `json:"created_at"`
-	UpdatedAt         time.Time  `json:"updated_at"`
+	ID                uuid.UUID   `json:"id"`
+	Code              string      `json:"code"`
+	Description       string      `json:"description"`
+	DiscountType      string      `json:"discount_type"` // "percentage" or "fixed_amount"
+	DiscountValue     float64     `json:"discount_value"`
+	MaxDiscountAmount *float64    `json:"max_discount_amount,omitempty"`
+	MinRideAmount     *float64    `json:"min_ride_amount,omitempty"`
+	MaxUses           *int        `json:"max_uses,omitempty"`
+	TotalUses         int         `json:"total_uses"`
+	UsesPerUser       int         `json:"uses_per_user"`
+	ValidFrom         time.Time   `json:"valid_from"`
+	ValidUntil        time.Time   `json:"valid_until"`
+	IsActive          bool        `json:"is_active"`
+	Rules             *PromoRules `json:"rules,omitempty"`
+	CampaignID        *uuid.UUID  `json:"campaign_id,omitempty"`
+	Budget            *float64    `json:"budget,omitempty"`       // total discount the code may give, NULL = unlimited
+	DailyBudget       *float64    `json:"daily_budget,omitempty"` // discount the code may give per day (UTC)
+	Spent             float64     `json:"spent"`
+	SpentToday        float64     `json:"spent_today"`
+	BurnRateAlert     *float64    `json:"burn_rate_alert,omitempty"` // alert when more than this is spent in an hour
+	CreatedBy         *uuid.UUID  `json:"created_by,omitempty"`
+	CreatedAt         time.Time   `json:"created_at"`
+	UpdatedAt         time.Time   `json:"updated_at"`
+}
+
+// PromoCampaign groups promo codes under a shared discount budget
+type PromoCampaign struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name" binding:"required"`
+	Description   string     `json:"description"`
+	Budget        *float64   `json:"budget,omitempty"`       // total discount the campaign's codes may give
+	DailyBudget   *float64   `json:"daily_budget,omitempty"` // discount they may give per day (UTC)
+	Spent         float64    `json:"spent"`
+	SpentToday    float64    `json:"spent_today"`
+	BurnRateAlert *float64   `json:"burn_rate_alert,omitempty"` // alert when more than this is spent in an hour
+	IsActive      bool       `json:"is_active"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Budget alert types
+const (
+	AlertBurnRate        = "burn_rate"
+	AlertBudgetExhausted = "budget_exhausted"
+)
+
+// PromoBudgetAlert records a promo code or campaign spending faster than its
+// configured burn rate, or running out of budget
+type PromoBudgetAlert struct {
+	ID           uuid.UUID  `json:"id"`
+	PromoCodeID  *uuid.UUID `json:"promo_code_id,omitempty"`
+	CampaignID   *uuid.UUID `json:"campaign_id,omitempty"`
+	AlertType    string     `json:"alert_type"`
+	SpendPerHour float64    `json:"spend_per_hour"`
+	Threshold    float64    `json:"threshold"`
+	Message      string     `json:"message"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// PromoRules restricts which rides and riders a promo code applies to. Every
+// rule that is set must match; unset rules match any ride.
+type PromoRules struct {
+	RideTypeIDs    []uuid.UUID `json:"ride_type_ids,omitempty"`
+	CityIDs        []uuid.UUID `json:"city_ids,omitempty"`        // the ride must be in one of these cities
+	RegionIDs      []uuid.UUID `json:"region_ids,omitempty"`      // or in one of these regions
+	FirstNRides    *int        `json:"first_n_rides,omitempty"`   // only the rider's first N completed rides
+	DaysOfWeek     []int       `json:"days_of_week,omitempty"`    // 0 = Sunday
+	StartHour      *int        `json:"start_hour,omitempty"`      // 0-23, inclusive
+	EndHour        *int        `json:"end_hour,omitempty"`        // 0-23, exclusive; before start_hour wraps past midnight
+	Timezone       string      `json:"timezone,omitempty"`        // IANA zone for day and hour rules, default UTC
+	PaymentMethods []string    `json:"payment_methods,omitempty"` // e.g. ["card", "wallet"]
+	UserSegments   []string    `json:"user_segments,omitempty"`   // rider needs one of these, e.g. ["loyalty_gold", "subscriber"]
+}
+
+// RideContext describes the ride a promo code is validated for. A targeting
+// rule that needs a detail the context does not carry rejects the code.
+type RideContext struct {
+	RideTypeID    *uuid.UUID `json:"ride_type_id,omitempty"`
+	CityID        *uuid.UUID `json:"city_id,omitempty"`
+	RegionID      *uuid.UUID `json:"region_id,omitempty"`
+	PaymentMethod string     `json:"payment_method,omitempty"`
+	RideAt        *time.Time `json:"ride_at,omitempty"` // pickup time of a scheduled ride, defaults to now
 }
 
 // PromoCodeUse represents a single use of a promo code
@@ -78,10 +145,32 @@ type RideType struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
+// Rejection reasons reported by ValidatePromoCode
+const (
+	ReasonInvalidCode   = "invalid_code"
+	ReasonInactive      = "inactive"
+	ReasonNotYetValid   = "not_yet_valid"
+	ReasonExpired       = "expired"
+	ReasonUsageLimit    = "usage_limit"
+	ReasonUserLimit     = "user_limit"
+	ReasonMinRideAmount = "min_ride_amount"
+	ReasonRideType      = "ride_type"
+	ReasonLocation      = "location"
+	ReasonFirstRides    = "first_rides"
+	ReasonDayOfWeek     = "day_of_week"
+	ReasonTimeOfDay     = "time_of_day"
+	ReasonPaymentMethod = "payment_method"
+	ReasonUserSegment   = "user_segment"
+	ReasonBudget        = "budget_exhausted"
+	ReasonDailyBudget   = "daily_budget"
+)
+
 // PromoCodeValidation contains validation result
 type PromoCodeValidation struct {
-	Valid          bool    `json:"valid"`
-	Message        string  `json:"message,omitempty"`
-	DiscountAmount float64 `json:"discount_amount"`
-	FinalAmount    float64 `json:"final_amount"`
+	Valid          bool       `json:"valid"`
+	PromoCodeID    *uuid.UUID `json:"promo_code_id,omitempty"`
+	Reason         string     `json:"reason,omitempty"` // why the code was rejected
+	Message        string     `json:"message,omitempty"`
+	DiscountAmount float64    `json:"discount_amount"`
+	FinalAmount    float64    `json:"final_amount"`
 }
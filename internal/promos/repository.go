@@ -2,10 +2,14 @@ package promos
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -27,16 +31,22 @@ func (r *Repository) CreatePromoCode(ctx context.Context, promo *PromoCode) erro
 	query := `
 		INSERT INTO promo_codes (id, code, description, discount_type, discount_value,
 			max_discount_amount, min_ride_amount, max_uses, uses_per_user, valid_from,
-			valid_until, is_active, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			valid_until, is_active, rules, campaign_id, budget, daily_budget, burn_rate_alert,
+			created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 	`
 
+	rulesJSON, err := marshalPromoRules(promo.Rules)
+	if err != nil {
+		return err
+	}
+
 	promo.ID = uuid.New()
 	now := time.Now()
 	promo.CreatedAt = now
 	promo.UpdatedAt = now
 
-	_, err := r.db.Exec(ctx, query,
+	_, err = r.db.Exec(ctx, query,
 		promo.ID,
 		promo.Code,
 		promo.Description,
@@ -49,6 +59,11 @@ func (r *Repository) CreatePromoCode(ctx context.Context, promo *PromoCode) erro
 		promo.ValidFrom,
 		promo.ValidUntil,
 		promo.IsActive,
+		rulesJSON,
+		promo.CampaignID,
+		promo.Budget,
+		promo.DailyBudget,
+		promo.BurnRateAlert,
 		promo.CreatedBy,
 		promo.CreatedAt,
 		promo.UpdatedAt,
@@ -66,12 +81,14 @@ func (r *Repository) GetPromoCodeByCode(ctx context.Context, code string) (*Prom
 	query := `
 		SELECT id, code, description, discount_type, discount_value, max_discount_amount,
 			min_ride_amount, max_uses, total_uses, uses_per_user, valid_from, valid_until,
-			is_active, created_by, created_at, updated_at
+			is_active, rules, campaign_id, budget, daily_budget, spent, ` + spentTodayColumn + `,
+			burn_rate_alert, created_by, created_at, updated_at
 		FROM promo_codes
 		WHERE code = $1
 	`
 
 	promo := &PromoCode{}
+	var rulesJSON []byte
 	err := r.db.QueryRow(ctx, query, code).Scan(
 		&promo.ID,
 		&promo.Code,
@@ -86,6 +103,13 @@ func (r *Repository) GetPromoCodeByCode(ctx context.Context, code string) (*Prom
 		&promo.ValidFrom,
 		&promo.ValidUntil,
 		&promo.IsActive,
+		&rulesJSON,
+		&promo.CampaignID,
+		&promo.Budget,
+		&promo.DailyBudget,
+		&promo.Spent,
+		&promo.SpentToday,
+		&promo.BurnRateAlert,
 		&promo.CreatedBy,
 		&promo.CreatedAt,
 		&promo.UpdatedAt,
@@ -95,6 +119,10 @@ func (r *Repository) GetPromoCodeByCode(ctx context.Context, code string) (*Prom
 		return nil, fmt.Errorf("failed to get promo code: %w", err)
 	}
 
+	if promo.Rules, err = unmarshalPromoRules(rulesJSON); err != nil {
+		return nil, err
+	}
+
 	return promo, nil
 }
 
@@ -111,18 +139,52 @@ func (r *Repository) GetPromoCodeUsesByUser(ctx context.Context, promoCodeID, us
 	return count, nil
 }
 
-// CreatePromoCodeUse records a promo code use
+// CreatePromoCodeUse records a promo code use and reserves its discount
+// against the code's and its campaign's budgets in one transaction. The
+// budget rows stay locked until commit, so concurrent uses cannot overspend.
+// A discount larger than the budget left is reduced to what is left; if
+// nothing is left the use is not recorded and a *BudgetError is returned. A
+// code or campaign whose total budget runs out is deactivated.
 func (r *Repository) CreatePromoCodeUse(ctx context.Context, use *PromoCodeUse) error {
-	query := `
-		INSERT INTO promo_code_uses (id, promo_code_id, user_id, ride_id, discount_amount,
-			original_amount, final_amount, used_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var campaignID *uuid.UUID
+	promo, err := lockBudget(ctx, tx, "promo_codes", use.PromoCodeID, &campaignID)
+	if err != nil {
+		return err
+	}
+	left, reason := budgetLeft(promo.Budget, promo.DailyBudget, promo.Spent, promo.SpentToday)
+	discount, budgetErr := budgetCovers(use.DiscountAmount, left, reason, "promo")
+	if budgetErr != nil {
+		return budgetErr
+	}
 
+	if campaignID != nil {
+		campaign, err := lockBudget(ctx, tx, "promo_campaigns", *campaignID, nil)
+		if err != nil {
+			return err
+		}
+		left, reason = budgetLeft(campaign.Budget, campaign.DailyBudget, campaign.Spent, campaign.SpentToday)
+		if discount, budgetErr = budgetCovers(discount, left, reason, "campaign"); budgetErr != nil {
+			return budgetErr
+		}
+	}
+
+	if discount < use.DiscountAmount {
+		use.DiscountAmount = discount
+		use.FinalAmount = use.OriginalAmount - discount
+	}
 	use.ID = uuid.New()
 	use.UsedAt = time.Now()
 
-	_, err := r.db.Exec(ctx, query,
+	_, err = tx.Exec(ctx, `
+		INSERT INTO promo_code_uses (id, promo_code_id, user_id, ride_id, discount_amount,
+			original_amount, final_amount, used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
 		use.ID,
 		use.PromoCodeID,
 		use.UserID,
@@ -132,17 +194,173 @@ func (r *Repository) CreatePromoCodeUse(ctx context.Context, use *PromoCodeUse)
 		use.FinalAmount,
 		use.UsedAt,
 	)
-
 	if err != nil {
 		return fmt.Errorf("failed to create promo code use: %w", err)
 	}
 
 	// Increment total uses
-	_, err = r.db.Exec(ctx, `UPDATE promo_codes SET total_uses = total_uses + 1 WHERE id = $1`, use.PromoCodeID)
+	_, err = tx.Exec(ctx, `UPDATE promo_codes SET total_uses = total_uses + 1 WHERE id = $1`, use.PromoCodeID)
 	if err != nil {
 		return fmt.Errorf("failed to update promo code uses: %w", err)
 	}
 
+	if err := spendBudget(ctx, tx, "promo_codes", use.PromoCodeID, use.DiscountAmount); err != nil {
+		return err
+	}
+	if campaignID != nil {
+		if err := spendBudget(ctx, tx, "promo_campaigns", *campaignID, use.DiscountAmount); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
+
+// DeletePromoCodeUse removes a ride's promo code use and gives its discount
+// back to the code's and its campaign's budgets. Spend is taken off the
+// daily budget only on the day it was spent. A code deactivated when its
+// budget ran out stays inactive. It returns false if the ride has no use.
+func (r *Repository) DeletePromoCodeUse(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var promoCodeID uuid.UUID
+	var amount float64
+	var usedAt time.Time
+	err = tx.QueryRow(ctx, `
+		DELETE FROM promo_code_uses
+		WHERE ride_id = $1
+		RETURNING promo_code_id, discount_amount, used_at`,
+		rideID,
+	).Scan(&promoCodeID, &amount, &usedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete promo code use: %w", err)
+	}
+
+	var campaignID *uuid.UUID
+	err = tx.QueryRow(ctx, `
+		UPDATE promo_codes
+		SET total_uses = GREATEST(total_uses - 1, 0)
+		WHERE id = $1
+		RETURNING campaign_id`,
+		promoCodeID,
+	).Scan(&campaignID)
+	if err != nil {
+		return false, fmt.Errorf("failed to update promo code uses: %w", err)
+	}
+
+	if err := refundBudget(ctx, tx, "promo_codes", promoCodeID, amount, usedAt); err != nil {
+		return false, err
+	}
+	if campaignID != nil {
+		if err := refundBudget(ctx, tx, "promo_campaigns", *campaignID, amount, usedAt); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
+
+// refundBudget takes amount, spent at spentAt, off a promo code's or
+// campaign's spend
+func refundBudget(ctx context.Context, tx pgx.Tx, table string, id uuid.UUID, amount float64, spentAt time.Time) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE `+table+`
+		SET spent = GREATEST(spent - $2, 0),
+		    daily_spent = CASE WHEN spend_date = ($3 AT TIME ZONE 'UTC')::date
+		                       THEN GREATEST(daily_spent - $2, 0) ELSE daily_spent END,
+		    updated_at = NOW()
+		WHERE id = $1`,
+		id, amount, spentAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refund %s spend: %w", table, err)
+	}
+	return nil
+}
+
+// spentTodayColumn is a budget row's spend for the current UTC day.
+// daily_spent belongs to spend_date and is stale on any other day.
+const spentTodayColumn = `CASE WHEN spend_date = (NOW() AT TIME ZONE 'UTC')::date THEN daily_spent ELSE 0 END`
+
+// budgetRow is the budget state of a promo code or campaign
+type budgetRow struct {
+	Budget      *float64
+	DailyBudget *float64
+	Spent       float64
+	SpentToday  float64
+}
+
+// lockBudget locks a promo_codes or promo_campaigns row for the transaction
+// and reads its budget. For promo codes campaignID receives the code's
+// campaign.
+func lockBudget(ctx context.Context, tx pgx.Tx, table string, id uuid.UUID, campaignID **uuid.UUID) (*budgetRow, error) {
+	row := &budgetRow{}
+	dest := []interface{}{&row.Budget, &row.DailyBudget, &row.Spent, &row.SpentToday}
+	columns := "budget, daily_budget, spent, " + spentTodayColumn
+	if campaignID != nil {
+		columns += ", campaign_id"
+		dest = append(dest, campaignID)
+	}
+
+	err := tx.QueryRow(ctx, `SELECT `+columns+` FROM `+table+` WHERE id = $1 FOR UPDATE`, id).Scan(dest...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock %s budget: %w", table, err)
+	}
+	return row, nil
+}
+
+// spendBudget adds amount to a promo code's or campaign's spend, deactivating
+// it and raising an alert when its total budget runs out
+func spendBudget(ctx context.Context, tx pgx.Tx, table string, id uuid.UUID, amount float64) error {
+	var exhausted bool
+	var budget *float64
+	err := tx.QueryRow(ctx, `
+		UPDATE `+table+`
+		SET spent = spent + $2,
+		    daily_spent = `+spentTodayColumn+` + $2,
+		    spend_date = (NOW() AT TIME ZONE 'UTC')::date,
+		    is_active = is_active AND (budget IS NULL OR spent + $2 < budget - 0.005),
+		    updated_at = NOW()
+		WHERE id = $1
+		RETURNING budget IS NOT NULL AND spent >= budget - 0.005, budget`,
+		id, amount,
+	).Scan(&exhausted, &budget)
+	if err != nil {
+		return fmt.Errorf("failed to update %s spend: %w", table, err)
+	}
+	if !exhausted {
+		return nil
+	}
+
+	alert := &PromoBudgetAlert{AlertType: AlertBudgetExhausted, Threshold: *budget}
+	if table == "promo_campaigns" {
+		alert.CampaignID = &id
+		alert.Message = fmt.Sprintf("Campaign budget of %.2f is spent; the campaign has been deactivated", *budget)
+	} else {
+		alert.PromoCodeID = &id
+		alert.Message = fmt.Sprintf("Promo code budget of %.2f is spent; the code has been deactivated", *budget)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO promo_budget_alerts (id, promo_code_id, campaign_id, alert_type, threshold, message, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+		uuid.New(), alert.PromoCodeID, alert.CampaignID, alert.AlertType, alert.Threshold, alert.Message,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record budget alert: %w", err)
+	}
 	return nil
 }
 
@@ -374,6 +592,54 @@ func (r *Repository) IsFirstCompletedRide(ctx context.Context, userID uuid.UUID,
 	return count == 0, nil
 }
 
+// CountCompletedRides counts the rides a rider has completed
+func (r *Repository) CountCompletedRides(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `SELECT COUNT(*) FROM rides WHERE rider_id = $1 AND status = 'completed'`
+
+	var count int
+	err := r.db.QueryRow(ctx, query, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count completed rides: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetUserSegments returns the segments promo rules can target for a rider:
+// "loyalty_<tier>" for their loyalty tier and "subscriber" while they have an
+// active subscription
+func (r *Repository) GetUserSegments(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	query := `
+		SELECT 'loyalty_' || LOWER(lt.name)
+		FROM rider_loyalty rl
+		JOIN loyalty_tiers lt ON lt.id = rl.current_tier_id
+		WHERE rl.rider_id = $1
+		UNION ALL
+		SELECT 'subscriber'
+		WHERE EXISTS (
+			SELECT 1 FROM subscriptions
+			WHERE user_id = $1 AND status = 'active' AND current_period_end > NOW()
+		)
+	`
+
+	rows, err := r.db.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user segments: %w", err)
+	}
+	defer rows.Close()
+
+	segments := []string{}
+	for rows.Next() {
+		var segment string
+		if err := rows.Scan(&segment); err != nil {
+			return nil, fmt.Errorf("failed to scan user segment: %w", err)
+		}
+		segments = append(segments, segment)
+	}
+
+	return segments, rows.Err()
+}
+
 // MarkReferralBonusesApplied marks the referral bonuses as applied
 func (r *Repository) MarkReferralBonusesApplied(ctx context.Context, referralID uuid.UUID, rideID uuid.UUID) error {
 	query := `
@@ -407,7 +673,8 @@ func (r *Repository) GetAllPromoCodes(ctx context.Context, limit, offset int) ([
 	query := `
 		SELECT id, code, description, discount_type, discount_value, max_discount_amount,
 			min_ride_amount, max_uses, total_uses, uses_per_user, valid_from, valid_until,
-			is_active, created_by, created_at, updated_at
+			is_active, rules, campaign_id, budget, daily_budget, spent, ` + spentTodayColumn + `,
+			burn_rate_alert, created_by, created_at, updated_at
 		FROM promo_codes
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -422,6 +689,7 @@ func (r *Repository) GetAllPromoCodes(ctx context.Context, limit, offset int) ([
 	promoCodes := []*PromoCode{}
 	for rows.Next() {
 		promo := &PromoCode{}
+		var rulesJSON []byte
 		err := rows.Scan(
 			&promo.ID,
 			&promo.Code,
@@ -436,6 +704,13 @@ func (r *Repository) GetAllPromoCodes(ctx context.Context, limit, offset int) ([
 			&promo.ValidFrom,
 			&promo.ValidUntil,
 			&promo.IsActive,
+			&rulesJSON,
+			&promo.CampaignID,
+			&promo.Budget,
+			&promo.DailyBudget,
+			&promo.Spent,
+			&promo.SpentToday,
+			&promo.BurnRateAlert,
 			&promo.CreatedBy,
 			&promo.CreatedAt,
 			&promo.UpdatedAt,
@@ -443,6 +718,9 @@ func (r *Repository) GetAllPromoCodes(ctx context.Context, limit, offset int) ([
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan promo code: %w", err)
 		}
+		if promo.Rules, err = unmarshalPromoRules(rulesJSON); err != nil {
+			return nil, 0, err
+		}
 		promoCodes = append(promoCodes, promo)
 	}
 
@@ -507,11 +785,21 @@ func (r *Repository) UpdatePromoCode(ctx context.Context, promo *PromoCode) erro
 		    valid_from = $9,
 		    valid_until = $10,
 		    is_active = $11,
+		    rules = $12,
+		    campaign_id = $13,
+		    budget = $14,
+		    daily_budget = $15,
+		    burn_rate_alert = $16,
 		    updated_at = NOW()
 		WHERE id = $1
 	`
 
-	_, err := r.db.Exec(ctx, query,
+	rulesJSON, err := marshalPromoRules(promo.Rules)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, query,
 		promo.ID,
 		promo.Description,
 		promo.DiscountType,
@@ -523,6 +811,11 @@ func (r *Repository) UpdatePromoCode(ctx context.Context, promo *PromoCode) erro
 		promo.ValidFrom,
 		promo.ValidUntil,
 		promo.IsActive,
+		rulesJSON,
+		promo.CampaignID,
+		promo.Budget,
+		promo.DailyBudget,
+		promo.BurnRateAlert,
 	)
 
 	return err
@@ -547,12 +840,14 @@ func (r *Repository) GetPromoCodeByID(ctx context.Context, promoID uuid.UUID) (*
 		SELECT id, code, description, discount_type, discount_value,
 		       max_discount_amount, min_ride_amount, max_uses, total_uses,
 		       uses_per_user, valid_from, valid_until, is_active,
-		       created_by, created_at, updated_at
+		       rules, campaign_id, budget, daily_budget, spent, ` + spentTodayColumn + `,
+		       burn_rate_alert, created_by, created_at, updated_at
 		FROM promo_codes
 		WHERE id = $1
 	`
 
 	promo := &PromoCode{}
+	var rulesJSON []byte
 	err := r.db.QueryRow(ctx, query, promoID).Scan(
 		&promo.ID,
 		&promo.Code,
@@ -567,6 +862,13 @@ func (r *Repository) GetPromoCodeByID(ctx context.Context, promoID uuid.UUID) (*
 		&promo.ValidFrom,
 		&promo.ValidUntil,
 		&promo.IsActive,
+		&rulesJSON,
+		&promo.CampaignID,
+		&promo.Budget,
+		&promo.DailyBudget,
+		&promo.Spent,
+		&promo.SpentToday,
+		&promo.BurnRateAlert,
 		&promo.CreatedBy,
 		&promo.CreatedAt,
 		&promo.UpdatedAt,
@@ -576,6 +878,10 @@ func (r *Repository) GetPromoCodeByID(ctx context.Context, promoID uuid.UUID) (*
 		return nil, fmt.Errorf("failed to get promo code: %w", err)
 	}
 
+	if promo.Rules, err = unmarshalPromoRules(rulesJSON); err != nil {
+		return nil, err
+	}
+
 	return promo, nil
 }
 
@@ -624,6 +930,23 @@ func (r *Repository) GetPromoCodeUsageStats(ctx context.Context, promoID uuid.UU
 		"total_final_amount":    stats.TotalFinalAmount,
 	}
 
+	promo, err := r.GetPromoCodeByID(ctx, promoID)
+	if err != nil {
+		return nil, err
+	}
+	spendLastHour, err := r.GetPromoSpendSince(ctx, promoID, time.Now().Add(-burnRateWindow))
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := r.getBudgetAlerts(ctx, "promo_code_id", promoID, 20)
+	if err != nil {
+		return nil, err
+	}
+	result["is_active"] = promo.IsActive
+	result["campaign_id"] = promo.CampaignID
+	result["budget"] = budgetStats(promo.Budget, promo.DailyBudget, promo.BurnRateAlert,
+		promo.Spent, promo.SpentToday, spendLastHour, alerts)
+
 	return result, nil
 }
 
@@ -703,3 +1026,309 @@ func (r *Repository) GetReferralEarnings(ctx context.Context, userID uuid.UUID)
 
 	return result, nil
 }
+
+// CreatePromoCampaign creates a promo campaign
+func (r *Repository) CreatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error {
+	campaign.ID = uuid.New()
+	campaign.IsActive = true
+	now := time.Now()
+	campaign.CreatedAt = now
+	campaign.UpdatedAt = now
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO promo_campaigns (id, name, description, budget, daily_budget, burn_rate_alert,
+			is_active, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		campaign.ID,
+		campaign.Name,
+		campaign.Description,
+		campaign.Budget,
+		campaign.DailyBudget,
+		campaign.BurnRateAlert,
+		campaign.IsActive,
+		campaign.CreatedBy,
+		campaign.CreatedAt,
+		campaign.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create promo campaign: %w", err)
+	}
+	return nil
+}
+
+// UpdatePromoCampaign updates a promo campaign's name, budgets and status
+func (r *Repository) UpdatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE promo_campaigns
+		SET name = $2,
+		    description = $3,
+		    budget = $4,
+		    daily_budget = $5,
+		    burn_rate_alert = $6,
+		    is_active = $7,
+		    updated_at = NOW()
+		WHERE id = $1`,
+		campaign.ID,
+		campaign.Name,
+		campaign.Description,
+		campaign.Budget,
+		campaign.DailyBudget,
+		campaign.BurnRateAlert,
+		campaign.IsActive,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update promo campaign: %w", err)
+	}
+	return nil
+}
+
+const promoCampaignColumns = `id, name, COALESCE(description, ''), budget, daily_budget, spent, ` + spentTodayColumn + `,
+		burn_rate_alert, is_active, created_by, created_at, updated_at`
+
+func scanPromoCampaign(row pgx.Row) (*PromoCampaign, error) {
+	campaign := &PromoCampaign{}
+	err := row.Scan(
+		&campaign.ID,
+		&campaign.Name,
+		&campaign.Description,
+		&campaign.Budget,
+		&campaign.DailyBudget,
+		&campaign.Spent,
+		&campaign.SpentToday,
+		&campaign.BurnRateAlert,
+		&campaign.IsActive,
+		&campaign.CreatedBy,
+		&campaign.CreatedAt,
+		&campaign.UpdatedAt,
+	)
+	return campaign, err
+}
+
+// GetPromoCampaignByID retrieves a promo campaign by ID
+func (r *Repository) GetPromoCampaignByID(ctx context.Context, campaignID uuid.UUID) (*PromoCampaign, error) {
+	campaign, err := scanPromoCampaign(r.db.QueryRow(ctx,
+		`SELECT `+promoCampaignColumns+` FROM promo_campaigns WHERE id = $1`, campaignID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo campaign: %w", err)
+	}
+	return campaign, nil
+}
+
+// GetAllPromoCampaigns retrieves all promo campaigns with pagination
+func (r *Repository) GetAllPromoCampaigns(ctx context.Context, limit, offset int) ([]*PromoCampaign, int, error) {
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM promo_campaigns`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count promo campaigns: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT `+promoCampaignColumns+`
+		FROM promo_campaigns
+		ORDER BY created_at DESC
+		LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get promo campaigns: %w", err)
+	}
+	defer rows.Close()
+
+	campaigns := []*PromoCampaign{}
+	for rows.Next() {
+		campaign, err := scanPromoCampaign(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan promo campaign: %w", err)
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, total, rows.Err()
+}
+
+// GetPromoSpendSince returns the discount a promo code has given since a time
+func (r *Repository) GetPromoSpendSince(ctx context.Context, promoID uuid.UUID, since time.Time) (float64, error) {
+	var spend float64
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(discount_amount), 0)
+		FROM promo_code_uses
+		WHERE promo_code_id = $1 AND used_at >= $2`,
+		promoID, since,
+	).Scan(&spend)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get promo spend: %w", err)
+	}
+	return spend, nil
+}
+
+// GetCampaignSpendSince returns the discount a campaign's promo codes have
+// given since a time
+func (r *Repository) GetCampaignSpendSince(ctx context.Context, campaignID uuid.UUID, since time.Time) (float64, error) {
+	var spend float64
+	err := r.db.QueryRow(ctx, `
+		SELECT COALESCE(SUM(u.discount_amount), 0)
+		FROM promo_code_uses u
+		JOIN promo_codes pc ON pc.id = u.promo_code_id
+		WHERE pc.campaign_id = $1 AND u.used_at >= $2`,
+		campaignID, since,
+	).Scan(&spend)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get campaign spend: %w", err)
+	}
+	return spend, nil
+}
+
+// RecordBurnRateAlert records a burn-rate alert unless the same promo code or
+// campaign already has one since the given time. It reports whether the
+// alert was recorded.
+func (r *Repository) RecordBurnRateAlert(ctx context.Context, alert *PromoBudgetAlert, since time.Time) (bool, error) {
+	alert.ID = uuid.New()
+	alert.CreatedAt = time.Now()
+
+	tag, err := r.db.Exec(ctx, `
+		INSERT INTO promo_budget_alerts (id, promo_code_id, campaign_id, alert_type, spend_per_hour, threshold, message, created_at)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8
+		WHERE NOT EXISTS (
+			SELECT 1 FROM promo_budget_alerts
+			WHERE alert_type = $4
+			  AND promo_code_id IS NOT DISTINCT FROM $2
+			  AND campaign_id IS NOT DISTINCT FROM $3
+			  AND created_at >= $9
+		)`,
+		alert.ID,
+		alert.PromoCodeID,
+		alert.CampaignID,
+		alert.AlertType,
+		alert.SpendPerHour,
+		alert.Threshold,
+		alert.Message,
+		alert.CreatedAt,
+		since,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record burn rate alert: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// getBudgetAlerts returns the latest budget alerts for a promo code or
+// campaign, whichever column is given
+func (r *Repository) getBudgetAlerts(ctx context.Context, column string, id uuid.UUID, limit int) ([]*PromoBudgetAlert, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, promo_code_id, campaign_id, alert_type, spend_per_hour, threshold, message, created_at
+		FROM promo_budget_alerts
+		WHERE `+column+` = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		id, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budget alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []*PromoBudgetAlert{}
+	for rows.Next() {
+		alert := &PromoBudgetAlert{}
+		if err := rows.Scan(
+			&alert.ID,
+			&alert.PromoCodeID,
+			&alert.CampaignID,
+			&alert.AlertType,
+			&alert.SpendPerHour,
+			&alert.Threshold,
+			&alert.Message,
+			&alert.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan budget alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
+// budgetStats summarises a promo code's or campaign's budget for usage stats
+func budgetStats(budget, dailyBudget, burnRateAlert *float64, spent, spentToday, spendLastHour float64, alerts []*PromoBudgetAlert) map[string]interface{} {
+	stats := map[string]interface{}{
+		"budget":             budget,
+		"daily_budget":       dailyBudget,
+		"spent":              spent,
+		"spent_today":        spentToday,
+		"spend_last_hour":    spendLastHour,
+		"burn_rate_alert":    burnRateAlert,
+		"burn_rate_exceeded": burnRateAlert != nil && spendLastHour > *burnRateAlert,
+		"alerts":             alerts,
+	}
+	if budget != nil {
+		stats["budget_remaining"] = math.Max(*budget-spent, 0)
+	}
+	if dailyBudget != nil {
+		stats["daily_budget_remaining"] = math.Max(*dailyBudget-spentToday, 0)
+	}
+	return stats
+}
+
+// GetPromoCampaignUsageStats retrieves spend and burn-rate statistics for a
+// promo campaign across its promo codes
+func (r *Repository) GetPromoCampaignUsageStats(ctx context.Context, campaignID uuid.UUID) (map[string]interface{}, error) {
+	campaign, err := r.GetPromoCampaignByID(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	var promoCodes, uniqueUsers, totalUses int
+	err = r.db.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM promo_codes WHERE campaign_id = $1),
+			COUNT(DISTINCT u.user_id),
+			COUNT(u.id)
+		FROM promo_code_uses u
+		JOIN promo_codes pc ON pc.id = u.promo_code_id
+		WHERE pc.campaign_id = $1`,
+		campaignID,
+	).Scan(&promoCodes, &uniqueUsers, &totalUses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promo campaign usage stats: %w", err)
+	}
+
+	spendLastHour, err := r.GetCampaignSpendSince(ctx, campaignID, time.Now().Add(-burnRateWindow))
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := r.getBudgetAlerts(ctx, "campaign_id", campaignID, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"is_active":    campaign.IsActive,
+		"promo_codes":  promoCodes,
+		"unique_users": uniqueUsers,
+		"total_uses":   totalUses,
+		"budget": budgetStats(campaign.Budget, campaign.DailyBudget, campaign.BurnRateAlert,
+			campaign.Spent, campaign.SpentToday, spendLastHour, alerts),
+	}
+	return result, nil
+}
+
+// marshalPromoRules encodes promo rules for the JSONB column, NULL when unset
+func marshalPromoRules(rules *PromoRules) ([]byte, error) {
+	if rules == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode promo rules: %w", err)
+	}
+	return data, nil
+}
+
+func unmarshalPromoRules(data []byte) (*PromoRules, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var rules PromoRules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode promo rules: %w", err)
+	}
+	return &rules, nil
+}
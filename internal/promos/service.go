@@ -23,6 +23,7 @@ type PromosRepository interface {
 	GetPromoCodeByID(ctx context.Context, promoID uuid.UUID) (*PromoCode, error)
 	GetPromoCodeUsesByUser(ctx context.Context, promoID uuid.UUID, userID uuid.UUID) (int, error)
 	CreatePromoCodeUse(ctx context.Context, use *PromoCodeUse) error
+	DeletePromoCodeUse(ctx context.Context, rideID uuid.UUID) (bool, error)
 	CreatePromoCode(ctx context.Context, promo *PromoCode) error
 	UpdatePromoCode(ctx context.Context, promo *PromoCode) error
 	DeactivatePromoCode(ctx context.Context, promoID uuid.UUID) error
@@ -39,7 +40,17 @@ type PromosRepository interface {
 	GetRideTypeByID(ctx context.Context, id uuid.UUID) (*RideType, error)
 	GetReferralByReferredID(ctx context.Context, userID uuid.UUID) (*Referral, error)
 	IsFirstCompletedRide(ctx context.Context, userID uuid.UUID, rideID uuid.UUID) (bool, error)
+	CountCompletedRides(ctx context.Context, userID uuid.UUID) (int, error)
+	GetUserSegments(ctx context.Context, userID uuid.UUID) ([]string, error)
 	MarkReferralBonusesApplied(ctx context.Context, referralID uuid.UUID, rideID uuid.UUID) error
+	CreatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error
+	UpdatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error
+	GetPromoCampaignByID(ctx context.Context, campaignID uuid.UUID) (*PromoCampaign, error)
+	GetAllPromoCampaigns(ctx context.Context, limit, offset int) ([]*PromoCampaign, int, error)
+	GetPromoCampaignUsageStats(ctx context.Context, campaignID uuid.UUID) (map[string]interface{}, error)
+	GetPromoSpendSince(ctx context.Context, promoID uuid.UUID, since time.Time) (float64, error)
+	GetCampaignSpendSince(ctx context.Context, campaignID uuid.UUID, since time.Time) (float64, error)
+	RecordBurnRateAlert(ctx context.Context, alert *PromoBudgetAlert, since time.Time) (bool, error)
 }
 
 // Service handles promo code and referral business logic
@@ -53,46 +64,32 @@ func NewService(repo PromosRepository) *Service {
 	return &Service{repo: repo}
 }
 
-// ValidatePromoCode validates a promo code and calculates the discount
-func (s *Service) ValidatePromoCode(ctx context.Context, code string, userID uuid.UUID, rideAmount float64) (*PromoCodeValidation, error) {
+// ValidatePromoCode validates a promo code for a ride and calculates the
+// discount. A rejected code comes back with Valid false and a Reason.
+func (s *Service) ValidatePromoCode(ctx context.Context, code string, userID uuid.UUID, rideAmount float64, ride RideContext) (*PromoCodeValidation, error) {
 	// Get promo code
 	promo, err := s.repo.GetPromoCodeByCode(ctx, code)
 	if err != nil {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: "Invalid promo code",
-		}, nil
+		return rejected(ReasonInvalidCode, "Invalid promo code"), nil
 	}
 
 	// Check if active
 	if !promo.IsActive {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: "This promo code is no longer active",
-		}, nil
+		return rejected(ReasonInactive, "This promo code is no longer active"), nil
 	}
 
 	// Check validity dates
 	now := time.Now()
 	if now.Before(promo.ValidFrom) {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: "This promo code is not yet valid",
-		}, nil
+		return rejected(ReasonNotYetValid, "This promo code is not yet valid"), nil
 	}
 	if now.After(promo.ValidUntil) {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: "This promo code has expired",
-		}, nil
+		return rejected(ReasonExpired, "This promo code has expired"), nil
 	}
 
 	// Check max uses
 	if promo.MaxUses != nil && promo.TotalUses >= *promo.MaxUses {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: "This promo code has reached its maximum usage limit",
-		}, nil
+		return rejected(ReasonUsageLimit, "This promo code has reached its maximum usage limit"), nil
 	}
 
 	// Check uses per user
@@ -102,18 +99,22 @@ func (s *Service) ValidatePromoCode(ctx context.Context, code string, userID uui
 	}
 
 	if userUses >= promo.UsesPerUser {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: "You have already used this promo code the maximum number of times",
-		}, nil
+		return rejected(ReasonUserLimit, "You have already used this promo code the maximum number of times"), nil
 	}
 
 	// Check minimum ride amount
 	if promo.MinRideAmount != nil && rideAmount < *promo.MinRideAmount {
-		return &PromoCodeValidation{
-			Valid:   false,
-			Message: fmt.Sprintf("Minimum ride amount of $%.2f required to use this promo code", *promo.MinRideAmount),
-		}, nil
+		return rejected(ReasonMinRideAmount,
+			fmt.Sprintf("Minimum ride amount of $%.2f required to use this promo code", *promo.MinRideAmount)), nil
+	}
+
+	// Check targeting rules
+	rejection, err := s.checkTargeting(ctx, promo, userID, ride, now)
+	if err != nil {
+		return nil, err
+	}
+	if rejection != nil {
+		return rejection, nil
 	}
 
 	// Calculate discount
@@ -134,19 +135,29 @@ func (s *Service) ValidatePromoCode(ctx context.Context, code string, userID uui
 		discountAmount = rideAmount
 	}
 
+	// Cap the discount to what the code's and campaign's budgets have left
+	discountAmount, rejection, err = s.checkBudgets(ctx, promo, discountAmount)
+	if err != nil {
+		return nil, err
+	}
+	if rejection != nil {
+		return rejection, nil
+	}
+
 	finalAmount := rideAmount - discountAmount
 
 	return &PromoCodeValidation{
 		Valid:          true,
+		PromoCodeID:    &promo.ID,
 		DiscountAmount: discountAmount,
 		FinalAmount:    finalAmount,
 	}, nil
 }
 
 // ApplyPromoCode applies a promo code to a ride
-func (s *Service) ApplyPromoCode(ctx context.Context, code string, userID, rideID uuid.UUID, originalAmount float64) (*PromoCodeUse, error) {
+func (s *Service) ApplyPromoCode(ctx context.Context, code string, userID, rideID uuid.UUID, originalAmount float64, ride RideContext) (*PromoCodeUse, error) {
 	// Validate first
-	validation, err := s.ValidatePromoCode(ctx, code, userID, originalAmount)
+	validation, err := s.ValidatePromoCode(ctx, code, userID, originalAmount, ride)
 	if err != nil {
 		return nil, err
 	}
@@ -171,14 +182,78 @@ func (s *Service) ApplyPromoCode(ctx context.Context, code string, userID, rideI
 		FinalAmount:    validation.FinalAmount,
 	}
 
+	// The repository reserves the discount against the budgets and may
+	// reduce it to what is left
 	err = s.repo.CreatePromoCodeUse(ctx, use)
+	if budgetErr, ok := asBudgetError(err); ok {
+		return nil, fmt.Errorf("%s", budgetErr.Message())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply promo code: %w", err)
 	}
 
+	s.checkBurnRate(ctx, promo)
+
 	return use, nil
 }
 
+// RedeemPromoCode records a promo code's use on a ride being completed, for
+// the discount validated when the ride was requested. The code's usage
+// limits and budgets are enforced here, as other rides may have used it
+// since, and a payment method rule is checked against the method recorded
+// on the ride rather than the one the client sent when validating. It
+// returns the discount granted: reduced to what the budgets have left, or
+// nothing when the code ran out of uses or budget or the ride is paid
+// another way.
+func (s *Service) RedeemPromoCode(ctx context.Context, promoCodeID, userID, rideID uuid.UUID, paymentMethod string, originalAmount, discount float64) (float64, error) {
+	promo, err := s.repo.GetPromoCodeByID(ctx, promoCodeID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get promo code: %w", err)
+	}
+	if promo.Rules != nil && len(promo.Rules.PaymentMethods) > 0 && !containsFold(promo.Rules.PaymentMethods, paymentMethod) {
+		return 0, nil
+	}
+	if promo.MaxUses != nil && promo.TotalUses >= *promo.MaxUses {
+		return 0, nil
+	}
+	userUses, err := s.repo.GetPromoCodeUsesByUser(ctx, promo.ID, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check user uses: %w", err)
+	}
+	if userUses >= promo.UsesPerUser {
+		return 0, nil
+	}
+
+	use := &PromoCodeUse{
+		PromoCodeID:    promo.ID,
+		UserID:         userID,
+		RideID:         rideID,
+		DiscountAmount: discount,
+		OriginalAmount: originalAmount,
+		FinalAmount:    originalAmount - discount,
+	}
+	err = s.repo.CreatePromoCodeUse(ctx, use)
+	if _, ok := asBudgetError(err); ok {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to record promo code use: %w", err)
+	}
+
+	s.checkBurnRate(ctx, promo)
+
+	return use.DiscountAmount, nil
+}
+
+// ReleasePromoCodeUse gives back the promo code use recorded for a ride that
+// did not complete after all, along with its spend against the budgets
+func (s *Service) ReleasePromoCodeUse(ctx context.Context, rideID uuid.UUID) error {
+	if _, err := s.repo.DeletePromoCodeUse(ctx, rideID); err != nil {
+		return fmt.Errorf("failed to release promo code use: %w", err)
+	}
+	return nil
+}
+
 // CreatePromoCode creates a new promo code (admin only)
 func (s *Service) CreatePromoCode(ctx context.Context, promo *PromoCode) error {
 	// Validate promo code
@@ -205,6 +280,16 @@ func (s *Service) CreatePromoCode(ctx context.Context, promo *PromoCode) error {
 		return fmt.Errorf("valid_from must be before valid_until")
 	}
 
+	if promo.Rules != nil {
+		if err := promo.Rules.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateBudget(promo.Budget, promo.DailyBudget, promo.BurnRateAlert); err != nil {
+		return err
+	}
+
 	return s.repo.CreatePromoCode(ctx, promo)
 }
 
@@ -424,6 +509,16 @@ func (s *Service) UpdatePromoCode(ctx context.Context, promo *PromoCode) error {
 		return fmt.Errorf("valid_from must be before valid_until")
 	}
 
+	if promo.Rules != nil {
+		if err := promo.Rules.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := validateBudget(promo.Budget, promo.DailyBudget, promo.BurnRateAlert); err != nil {
+		return err
+	}
+
 	return s.repo.UpdatePromoCode(ctx, promo)
 }
 
@@ -31,6 +31,11 @@ func (m *mockPromosRepository) CreatePromoCodeUse(ctx context.Context, use *Prom
 	return args.Error(0)
 }
 
+func (m *mockPromosRepository) DeletePromoCodeUse(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockPromosRepository) CreatePromoCode(ctx context.Context, promo *PromoCode) error {
 	args := m.Called(ctx, promo)
 	return args.Error(0)
@@ -81,6 +86,60 @@ func (m *mockPromosRepository) IsFirstCompletedRide(ctx context.Context, userID
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *mockPromosRepository) CountCompletedRides(ctx context.Context, userID uuid.UUID) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockPromosRepository) GetUserSegments(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	args := m.Called(ctx, userID)
+	segments, _ := args.Get(0).([]string)
+	return segments, args.Error(1)
+}
+
+func (m *mockPromosRepository) CreatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error {
+	args := m.Called(ctx, campaign)
+	return args.Error(0)
+}
+
+func (m *mockPromosRepository) UpdatePromoCampaign(ctx context.Context, campaign *PromoCampaign) error {
+	args := m.Called(ctx, campaign)
+	return args.Error(0)
+}
+
+func (m *mockPromosRepository) GetPromoCampaignByID(ctx context.Context, campaignID uuid.UUID) (*PromoCampaign, error) {
+	args := m.Called(ctx, campaignID)
+	campaign, _ := args.Get(0).(*PromoCampaign)
+	return campaign, args.Error(1)
+}
+
+func (m *mockPromosRepository) GetAllPromoCampaigns(ctx context.Context, limit, offset int) ([]*PromoCampaign, int, error) {
+	args := m.Called(ctx, limit, offset)
+	campaigns, _ := args.Get(0).([]*PromoCampaign)
+	return campaigns, args.Int(1), args.Error(2)
+}
+
+func (m *mockPromosRepository) GetPromoCampaignUsageStats(ctx context.Context, campaignID uuid.UUID) (map[string]interface{}, error) {
+	args := m.Called(ctx, campaignID)
+	stats, _ := args.Get(0).(map[string]interface{})
+	return stats, args.Error(1)
+}
+
+func (m *mockPromosRepository) GetPromoSpendSince(ctx context.Context, promoID uuid.UUID, since time.Time) (float64, error) {
+	args := m.Called(ctx, promoID, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *mockPromosRepository) GetCampaignSpendSince(ctx context.Context, campaignID uuid.UUID, since time.Time) (float64, error) {
+	args := m.Called(ctx, campaignID, since)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *mockPromosRepository) RecordBurnRateAlert(ctx context.Context, alert *PromoBudgetAlert, since time.Time) (bool, error) {
+	args := m.Called(ctx, alert, since)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockPromosRepository) MarkReferralBonusesApplied(ctx context.Context, referralID uuid.UUID, rideID uuid.UUID) error {
 	args := m.Called(ctx, referralID, rideID)
 	return args.Error(0)
@@ -142,7 +201,7 @@ func TestValidatePromoCodePercentageDiscount(t *testing.T) {
 	repo.On("GetPromoCodeByCode", ctx, "SAVE20").Return(promo, nil).Once()
 	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
 
-	result, err := service.ValidatePromoCode(ctx, "SAVE20", userID, 50)
+	result, err := service.ValidatePromoCode(ctx, "SAVE20", userID, 50, RideContext{})
 	assert.NoError(t, err)
 	assert.True(t, result.Valid)
 	assert.InDelta(t, 10.0, result.DiscountAmount, 0.0001)
@@ -162,7 +221,7 @@ func TestValidatePromoCodeMinRideAmountFailure(t *testing.T) {
 	repo.On("GetPromoCodeByCode", ctx, "SAVE20").Return(promo, nil).Once()
 	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
 
-	result, err := service.ValidatePromoCode(ctx, "SAVE20", userID, 50)
+	result, err := service.ValidatePromoCode(ctx, "SAVE20", userID, 50, RideContext{})
 	assert.NoError(t, err)
 	assert.False(t, result.Valid)
 	assert.Contains(t, result.Message, "Minimum ride amount")
@@ -187,7 +246,7 @@ func TestApplyPromoCodeCreatesUseRecord(t *testing.T) {
 			use.FinalAmount == 45
 	})).Return(nil).Once()
 
-	use, err := service.ApplyPromoCode(ctx, "WELCOME5", userID, rideID, 50)
+	use, err := service.ApplyPromoCode(ctx, "WELCOME5", userID, rideID, 50, RideContext{})
 	assert.NoError(t, err)
 	assert.Equal(t, promo.ID, use.PromoCodeID)
 	assert.InDelta(t, 5.0, use.DiscountAmount, 0.0001)
@@ -205,7 +264,7 @@ func TestApplyPromoCodeInvalidWhenNotActive(t *testing.T) {
 
 	repo.On("GetPromoCodeByCode", ctx, "WELCOME5").Return(promo, nil).Once()
 
-	use, err := service.ApplyPromoCode(ctx, "WELCOME5", userID, rideID, 50)
+	use, err := service.ApplyPromoCode(ctx, "WELCOME5", userID, rideID, 50, RideContext{})
 	assert.Error(t, err)
 	assert.Nil(t, use)
 	repo.AssertExpectations(t)
@@ -0,0 +1,192 @@
+package promos
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var weekdayNames = [7]string{"Sundays", "Mondays", "Tuesdays", "Wednesdays", "Thursdays", "Fridays", "Saturdays"}
+
+// rejected builds the validation result for a rejected promo code
+func rejected(reason, message string) *PromoCodeValidation {
+	return &PromoCodeValidation{
+		Valid:   false,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// checkTargeting evaluates the promo code's targeting rules for the ride and
+// rider. It returns the rejection, or nil if the ride is eligible.
+func (s *Service) checkTargeting(ctx context.Context, promo *PromoCode, userID uuid.UUID, ride RideContext, now time.Time) (*PromoCodeValidation, error) {
+	rules := promo.Rules
+	if rules == nil {
+		return nil, nil
+	}
+
+	if reason, message := rules.matchRide(ride, now); reason != "" {
+		return rejected(reason, message), nil
+	}
+
+	if rules.FirstNRides != nil {
+		completed, err := s.repo.CountCompletedRides(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count completed rides: %w", err)
+		}
+		if completed >= *rules.FirstNRides {
+			return rejected(ReasonFirstRides,
+				fmt.Sprintf("This promo code is only valid for your first %d rides", *rules.FirstNRides)), nil
+		}
+	}
+
+	if len(rules.UserSegments) > 0 {
+		segments, err := s.repo.GetUserSegments(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user segments: %w", err)
+		}
+		if !containsAny(rules.UserSegments, segments) {
+			return rejected(ReasonUserSegment, "This promo code is not available for your account"), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// matchRide checks the rules that depend only on the ride. It returns the
+// rejection reason and message, or "" if the ride matches.
+func (r *PromoRules) matchRide(ride RideContext, now time.Time) (string, string) {
+	if len(r.RideTypeIDs) > 0 && (ride.RideTypeID == nil || !containsID(r.RideTypeIDs, *ride.RideTypeID)) {
+		return ReasonRideType, "This promo code is not valid for this ride type"
+	}
+
+	if len(r.CityIDs) > 0 || len(r.RegionIDs) > 0 {
+		inCity := ride.CityID != nil && containsID(r.CityIDs, *ride.CityID)
+		inRegion := ride.RegionID != nil && containsID(r.RegionIDs, *ride.RegionID)
+		if !inCity && !inRegion {
+			return ReasonLocation, "This promo code is not valid in this area"
+		}
+	}
+
+	if len(r.PaymentMethods) > 0 && !containsFold(r.PaymentMethods, ride.PaymentMethod) {
+		return ReasonPaymentMethod, fmt.Sprintf("This promo code requires paying by %s", strings.Join(r.PaymentMethods, " or "))
+	}
+
+	// Day and hour windows apply at pickup, in the promo's timezone
+	at := now
+	if ride.RideAt != nil && ride.RideAt.After(now) {
+		at = *ride.RideAt
+	}
+	at = at.In(r.location())
+
+	if len(r.DaysOfWeek) > 0 && !containsInt(r.DaysOfWeek, int(at.Weekday())) {
+		days := make([]string, 0, len(r.DaysOfWeek))
+		for _, d := range r.DaysOfWeek {
+			days = append(days, weekdayNames[d])
+		}
+		return ReasonDayOfWeek, fmt.Sprintf("This promo code is only valid on %s", strings.Join(days, ", "))
+	}
+
+	if r.StartHour != nil && r.EndHour != nil && !inHourWindow(at.Hour(), *r.StartHour, *r.EndHour) {
+		return ReasonTimeOfDay, fmt.Sprintf("This promo code is only valid between %02d:00 and %02d:00", *r.StartHour, *r.EndHour)
+	}
+
+	return "", ""
+}
+
+// location returns the rules' timezone. validate rejects unknown zones, so
+// the UTC fallback only covers rules stored before a zone was removed.
+func (r *PromoRules) location() *time.Location {
+	if r.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(r.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// inHourWindow reports whether hour falls in [start, end). A window whose end
+// is before its start wraps past midnight.
+func inHourWindow(hour, start, end int) bool {
+	if start < end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+// validate checks the rules an admin saves on a promo code
+func (r *PromoRules) validate() error {
+	if r.FirstNRides != nil && *r.FirstNRides < 1 {
+		return fmt.Errorf("first_n_rides must be at least 1")
+	}
+	for _, d := range r.DaysOfWeek {
+		if d < 0 || d > 6 {
+			return fmt.Errorf("days_of_week must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+	if (r.StartHour == nil) != (r.EndHour == nil) {
+		return fmt.Errorf("start_hour and end_hour must be set together")
+	}
+	if r.StartHour != nil {
+		if *r.StartHour < 0 || *r.StartHour > 23 || *r.EndHour < 0 || *r.EndHour > 23 {
+			return fmt.Errorf("start_hour and end_hour must be between 0 and 23")
+		}
+		if *r.StartHour == *r.EndHour {
+			return fmt.Errorf("start_hour and end_hour must differ")
+		}
+	}
+	if r.Timezone != "" {
+		if _, err := time.LoadLocation(r.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", r.Timezone)
+		}
+	}
+	for i, m := range r.PaymentMethods {
+		m = strings.ToLower(strings.TrimSpace(m))
+		if m == "" {
+			return fmt.Errorf("payment_methods cannot contain empty values")
+		}
+		r.PaymentMethods[i] = m
+	}
+	return nil
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, v string) bool {
+	for _, x := range values {
+		if strings.EqualFold(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(want, have []string) bool {
+	for _, w := range want {
+		if containsFold(have, w) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package promos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestPromoRules_MatchRideTypeAndLocation(t *testing.T) {
+	economy, premium := uuid.New(), uuid.New()
+	city, otherCity, region := uuid.New(), uuid.New(), uuid.New()
+	rules := &PromoRules{
+		RideTypeIDs: []uuid.UUID{economy},
+		CityIDs:     []uuid.UUID{city},
+		RegionIDs:   []uuid.UUID{region},
+	}
+	now := time.Now()
+
+	reason, _ := rules.matchRide(RideContext{RideTypeID: &economy, CityID: &city}, now)
+	assert.Empty(t, reason)
+
+	// A ride outside the listed cities still matches through its region
+	reason, _ = rules.matchRide(RideContext{RideTypeID: &economy, CityID: &otherCity, RegionID: &region}, now)
+	assert.Empty(t, reason)
+
+	reason, _ = rules.matchRide(RideContext{RideTypeID: &premium, CityID: &city}, now)
+	assert.Equal(t, ReasonRideType, reason)
+
+	reason, _ = rules.matchRide(RideContext{CityID: &city}, now)
+	assert.Equal(t, ReasonRideType, reason)
+
+	reason, _ = rules.matchRide(RideContext{RideTypeID: &economy, CityID: &otherCity}, now)
+	assert.Equal(t, ReasonLocation, reason)
+}
+
+func TestPromoRules_MatchPaymentMethod(t *testing.T) {
+	rules := &PromoRules{PaymentMethods: []string{"wallet"}}
+
+	reason, _ := rules.matchRide(RideContext{PaymentMethod: "Wallet"}, time.Now())
+	assert.Empty(t, reason)
+
+	reason, message := rules.matchRide(RideContext{PaymentMethod: "cash"}, time.Now())
+	assert.Equal(t, ReasonPaymentMethod, reason)
+	assert.Contains(t, message, "wallet")
+}
+
+func TestPromoRules_MatchTimeWindows(t *testing.T) {
+	// Friday 2026-03-06 23:30 in New York is Saturday 04:30 UTC
+	now := time.Date(2026, 3, 7, 4, 30, 0, 0, time.UTC)
+	rules := &PromoRules{
+		DaysOfWeek: []int{5}, // Friday
+		StartHour:  intPtr(22),
+		EndHour:    intPtr(2),
+		Timezone:   "America/New_York",
+	}
+
+	reason, _ := rules.matchRide(RideContext{}, now)
+	assert.Empty(t, reason)
+
+	rules.Timezone = ""
+	reason, _ = rules.matchRide(RideContext{}, now)
+	assert.Equal(t, ReasonDayOfWeek, reason)
+
+	// A scheduled pickup is checked at its pickup time
+	rules.DaysOfWeek = nil
+	pickup := now.Add(10 * time.Hour)
+	reason, _ = rules.matchRide(RideContext{RideAt: &pickup}, now)
+	assert.Equal(t, ReasonTimeOfDay, reason)
+}
+
+func TestInHourWindow(t *testing.T) {
+	assert.True(t, inHourWindow(7, 7, 10))
+	assert.False(t, inHourWindow(10, 7, 10))
+	assert.True(t, inHourWindow(23, 22, 2))
+	assert.True(t, inHourWindow(1, 22, 2))
+	assert.False(t, inHourWindow(2, 22, 2))
+}
+
+func TestPromoRules_Validate(t *testing.T) {
+	assert.NoError(t, (&PromoRules{StartHour: intPtr(22), EndHour: intPtr(2), Timezone: "Europe/Paris"}).validate())
+
+	assert.Error(t, (&PromoRules{FirstNRides: intPtr(0)}).validate())
+	assert.Error(t, (&PromoRules{DaysOfWeek: []int{7}}).validate())
+	assert.Error(t, (&PromoRules{StartHour: intPtr(8)}).validate())
+	assert.Error(t, (&PromoRules{StartHour: intPtr(8), EndHour: intPtr(8)}).validate())
+	assert.Error(t, (&PromoRules{StartHour: intPtr(8), EndHour: intPtr(24)}).validate())
+	assert.Error(t, (&PromoRules{Timezone: "Mars/Olympus"}).validate())
+
+	rules := &PromoRules{PaymentMethods: []string{" Card "}}
+	require.NoError(t, rules.validate())
+	assert.Equal(t, []string{"card"}, rules.PaymentMethods)
+}
+
+func TestValidatePromoCodeFirstRidesRule(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID := uuid.New()
+	promo := validPercentagePromo()
+	promo.Rules = &PromoRules{FirstNRides: intPtr(3)}
+
+	repo.On("GetPromoCodeByCode", ctx, "SAVE20").Return(promo, nil).Twice()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Twice()
+	repo.On("CountCompletedRides", ctx, userID).Return(2, nil).Once()
+	repo.On("CountCompletedRides", ctx, userID).Return(3, nil).Once()
+
+	result, err := service.ValidatePromoCode(ctx, "SAVE20", userID, 50, RideContext{})
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	require.NotNil(t, result.PromoCodeID)
+	assert.Equal(t, promo.ID, *result.PromoCodeID)
+
+	result, err = service.ValidatePromoCode(ctx, "SAVE20", userID, 50, RideContext{})
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ReasonFirstRides, result.Reason)
+	repo.AssertExpectations(t)
+}
+
+func TestValidatePromoCodeUserSegmentRule(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockPromosRepository)
+	service := NewService(repo)
+	userID := uuid.New()
+	promo := validPercentagePromo()
+	promo.Rules = &PromoRules{UserSegments: []string{"loyalty_gold", "loyalty_platinum"}}
+
+	repo.On("GetPromoCodeByCode", ctx, "SAVE20").Return(promo, nil).Once()
+	repo.On("GetPromoCodeUsesByUser", ctx, promo.ID, userID).Return(0, nil).Once()
+	repo.On("GetUserSegments", ctx, userID).Return([]string{"loyalty_silver", "subscriber"}, nil).Once()
+
+	result, err := service.ValidatePromoCode(ctx, "SAVE20", userID, 50, RideContext{})
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, ReasonUserSegment, result.Reason)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,78 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	ws "github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+// EventHandler relays ride events published by other services to the
+// WebSocket clients in each ride.
+type EventHandler struct {
+	service *Service
+}
+
+// NewEventHandler creates an event handler backed by the realtime service.
+func NewEventHandler(service *Service) *EventHandler {
+	return &EventHandler{service: service}
+}
+
+// RegisterSubscriptions subscribes to ride stop events on the bus.
+func (h *EventHandler) RegisterSubscriptions(ctx context.Context, bus *eventbus.Bus) error {
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideStopUpdated, "realtime-ride-stops", h.handleStopUpdated); err != nil {
+		return fmt.Errorf("subscribe to %s: %w", eventbus.SubjectRideStopUpdated, err)
+	}
+	h.service.logger.Info("realtime: subscribed to ride stop events")
+	return nil
+}
+
+func (h *EventHandler) handleStopUpdated(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideStopUpdatedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride stop updated: %w", err)
+	}
+
+	msgType := "ride_stop_update"
+	if event.Type == "ride.stop_added" {
+		msgType = "ride_stop_added"
+	}
+
+	payload := map[string]interface{}{
+		"ride_id":        data.RideID.String(),
+		"stop_id":        data.StopID.String(),
+		"stop_order":     data.StopOrder,
+		"latitude":       data.Latitude,
+		"longitude":      data.Longitude,
+		"address":        data.Address,
+		"status":         data.Status,
+		"wait_minutes":   data.WaitMinutes,
+		"added_mid_trip": data.AddedMidTrip,
+		"estimated_fare": data.EstimatedFare,
+	}
+	if data.ArrivedAt != nil {
+		payload["arrived_at"] = data.ArrivedAt
+	}
+	if data.DepartedAt != nil {
+		payload["departed_at"] = data.DepartedAt
+	}
+
+	rideID := data.RideID.String()
+	h.service.hub.SendToRide(rideID, &ws.Message{
+		Type:      msgType,
+		RideID:    rideID,
+		Timestamp: time.Now(),
+		Data:      payload,
+	})
+
+	h.service.logger.Debug("relayed ride stop event",
+		zap.String("ride_id", rideID),
+		zap.String("stop_id", data.StopID.String()),
+		zap.String("status", data.Status),
+	)
+	return nil
+}
@@ -0,0 +1,72 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/redis"
+	ws "github.com/richxcame/ride-hailing/pkg/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEventHandler_HandleStopUpdated(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	redisDB, _ := redismock.NewClientMock()
+	hub := ws.NewHub()
+	go hub.Run()
+
+	service := NewService(hub, db, &redis.Client{Client: redisDB}, nil, zap.NewNop())
+	handler := NewEventHandler(service)
+
+	rideID, stopID := uuid.New(), uuid.New()
+	client := ws.NewClient("rider-1", createTestWebSocketConn(t), hub, "rider", zap.NewNop())
+	hub.Register <- client
+	time.Sleep(10 * time.Millisecond)
+	hub.AddClientToRide(client.ID, rideID.String())
+
+	tests := []struct {
+		eventType string
+		wantType  string
+	}{
+		{"ride.stop_added", "ride_stop_added"},
+		{"ride.stop_updated", "ride_stop_update"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			event, err := eventbus.NewEvent(tt.eventType, "rides-service", eventbus.RideStopUpdatedData{
+				RideID:        rideID,
+				StopID:        stopID,
+				StopOrder:     2,
+				Status:        "arrived",
+				EstimatedFare: 18.5,
+			})
+			require.NoError(t, err)
+			require.NoError(t, handler.handleStopUpdated(context.Background(), event))
+
+			select {
+			case msg := <-client.Send:
+				assert.Equal(t, tt.wantType, msg.Type)
+				assert.Equal(t, rideID.String(), msg.RideID)
+				assert.Equal(t, stopID.String(), msg.Data["stop_id"])
+				assert.Equal(t, "arrived", msg.Data["status"])
+				assert.Equal(t, 2, msg.Data["stop_order"])
+			case <-time.After(time.Second):
+				t.Fatal("stop update was not relayed to the ride")
+			}
+		})
+	}
+
+	bad := &eventbus.Event{Type: "ride.stop_updated", Data: []byte("not json")}
+	assert.Error(t, handler.handleStopUpdated(context.Background(), bad))
+}
@@ -105,23 +105,18 @@ func (s *Service) handleLocationUpdate(client *ws.Client, msg *ws.Message) {
 	// If driver is in a ride, broadcast to rider
 	rideID := client.GetRide()
 	if rideID != "" {
-		clients := s.hub.GetClientsInRide(rideID)
-		for _, c := range clients {
-			if c.Role == "rider" {
-				c.SendMessage(&ws.Message{
-					Type:      "driver_location",
-					RideID:    rideID,
-					UserID:    client.ID,
-					Timestamp: time.Now(),
-					Data: map[string]interface{}{
-						"latitude":  latitude,
-						"longitude": longitude,
-						"heading":   heading,
-						"speed":     speed,
-					},
-				})
-			}
-		}
+		s.hub.SendToRideRole(rideID, "rider", &ws.Message{
+			Type:      "driver_location",
+			RideID:    rideID,
+			UserID:    client.ID,
+			Timestamp: time.Now(),
+			Data: map[string]interface{}{
+				"latitude":  latitude,
+				"longitude": longitude,
+				"heading":   heading,
+				"speed":     speed,
+			},
+		})
 	}
 }
 
@@ -185,22 +180,17 @@ func (s *Service) handleChatMessage(client *ws.Client, msg *ws.Message) {
 	s.redis.Expire(ctx, chatKey, 24*time.Hour)
 
 	// Broadcast to other clients in the ride
-	clients := s.hub.GetClientsInRide(rideID)
-	for _, c := range clients {
-		if c.ID != client.ID {
-			c.SendMessage(&ws.Message{
-				Type:      "chat_message",
-				RideID:    rideID,
-				UserID:    client.ID,
-				Timestamp: time.Now(),
-				Data: map[string]interface{}{
-					"message":     message,
-					"sender_id":   client.ID,
-					"sender_role": client.Role,
-				},
-			})
-		}
-	}
+	s.hub.SendToRideExcept(rideID, client.ID, &ws.Message{
+		Type:      "chat_message",
+		RideID:    rideID,
+		UserID:    client.ID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"message":     message,
+			"sender_id":   client.ID,
+			"sender_role": client.Role,
+		},
+	})
 }
 
 // handleTyping handles typing indicators
@@ -216,22 +206,17 @@ func (s *Service) handleTyping(client *ws.Client, msg *ws.Message) {
 	}
 
 	// Broadcast typing indicator to other clients in the ride
-	clients := s.hub.GetClientsInRide(rideID)
-	for _, c := range clients {
-		if c.ID != client.ID {
-			c.SendMessage(&ws.Message{
-				Type:      "typing_indicator",
-				RideID:    rideID,
-				UserID:    client.ID,
-				Timestamp: time.Now(),
-				Data: map[string]interface{}{
-					"is_typing":   isTyping,
-					"sender_id":   client.ID,
-					"sender_role": client.Role,
-				},
-			})
-		}
-	}
+	s.hub.SendToRideExcept(rideID, client.ID, &ws.Message{
+		Type:      "typing_indicator",
+		RideID:    rideID,
+		UserID:    client.ID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"is_typing":   isTyping,
+			"sender_id":   client.ID,
+			"sender_role": client.Role,
+		},
+	})
 }
 
 // handleJoinRide handles client joining a ride room
@@ -271,21 +256,16 @@ func (s *Service) handleJoinRide(client *ws.Client, msg *ws.Message) {
 	})
 
 	// Notify other clients in the ride
-	clients := s.hub.GetClientsInRide(msg.RideID)
-	for _, c := range clients {
-		if c.ID != client.ID {
-			c.SendMessage(&ws.Message{
-				Type:      "user_joined",
-				RideID:    msg.RideID,
-				UserID:    client.ID,
-				Timestamp: time.Now(),
-				Data: map[string]interface{}{
-					"user_id": client.ID,
-					"role":    client.Role,
-				},
-			})
-		}
-	}
+	s.hub.SendToRideExcept(msg.RideID, client.ID, &ws.Message{
+		Type:      "user_joined",
+		RideID:    msg.RideID,
+		UserID:    client.ID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"user_id": client.ID,
+			"role":    client.Role,
+		},
+	})
 }
 
 // handleLeaveRide handles client leaving a ride room
@@ -296,21 +276,16 @@ func (s *Service) handleLeaveRide(client *ws.Client, msg *ws.Message) {
 	}
 
 	// Notify other clients
-	clients := s.hub.GetClientsInRide(rideID)
-	for _, c := range clients {
-		if c.ID != client.ID {
-			c.SendMessage(&ws.Message{
-				Type:      "user_left",
-				RideID:    rideID,
-				UserID:    client.ID,
-				Timestamp: time.Now(),
-				Data: map[string]interface{}{
-					"user_id": client.ID,
-					"role":    client.Role,
-				},
-			})
-		}
-	}
+	s.hub.SendToRideExcept(rideID, client.ID, &ws.Message{
+		Type:      "user_left",
+		RideID:    rideID,
+		UserID:    client.ID,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"user_id": client.ID,
+			"role":    client.Role,
+		},
+	})
 
 	// Remove client from ride room
 	s.hub.RemoveClientFromRide(client.ID, rideID)
@@ -375,6 +350,7 @@ func (s *Service) GetHub() *ws.Hub {
 // GetStats returns connection statistics
 func (s *Service) GetStats() map[string]interface{} {
 	return map[string]interface{}{
+		"node_id":           s.hub.NodeID(),
 		"connected_clients": s.hub.GetClientCount(),
 		"active_rides":      s.hub.GetRideCount(),
 	}
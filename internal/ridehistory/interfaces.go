@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
 // RepositoryInterface defines the interface for ride history repository operations
@@ -14,4 +15,6 @@ type RepositoryInterface interface {
 	GetRideByID(ctx context.Context, rideID uuid.UUID) (*RideHistoryEntry, error)
 	GetRiderStats(ctx context.Context, riderID uuid.UUID, from, to time.Time) (*RideStats, error)
 	GetFrequentRoutes(ctx context.Context, riderID uuid.UUID, limit int) ([]FrequentRoute, error)
+	SampleCompletedRides(ctx context.Context, from, to time.Time, limit int) ([]RideHistoryEntry, error)
+	GetRideDiscountLines(ctx context.Context, rideID uuid.UUID) ([]*models.RideDiscountLine, error)
 }
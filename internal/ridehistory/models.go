@@ -11,6 +11,7 @@ type RideHistoryEntry struct {
 	ID               uuid.UUID  `json:"id" db:"id"`
 	RiderID          uuid.UUID  `json:"rider_id" db:"rider_id"`
 	DriverID         *uuid.UUID `json:"driver_id,omitempty" db:"driver_id"`
+	RideTypeID       *uuid.UUID `json:"ride_type_id,omitempty" db:"ride_type_id"`
 	Status           string     `json:"status" db:"status"`
 
 	// Route
@@ -66,9 +67,11 @@ type Receipt struct {
 	FareBreakdown   []FareLineItem   `json:"fare_breakdown"`
 	Subtotal        float64          `json:"subtotal"`
 	Discounts       float64          `json:"discounts"`
+	Credits         float64          `json:"credits"` // gift card balance applied
 	Fees            float64          `json:"fees"`
 	Tip             float64          `json:"tip"`
 	Total           float64          `json:"total"`
+	AmountDue       float64          `json:"amount_due"` // total less credits, charged to the payment method
 	Currency        string           `json:"currency"`
 
 	// Payment
@@ -88,7 +91,7 @@ type Receipt struct {
 type FareLineItem struct {
 	Label  string  `json:"label"`
 	Amount float64 `json:"amount"`
-	Type   string  `json:"type"` // charge, discount, fee, tip
+	Type   string  `json:"type"` // charge, discount, credit, fee, tip
 }
 
 // ========================================
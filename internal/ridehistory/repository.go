@@ -8,11 +8,12 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
 // Shared column list for ride history queries
 const rideHistoryColumns = `
-	r.id, r.rider_id, r.driver_id, r.status,
+	r.id, r.rider_id, r.driver_id, r.ride_type_id, r.status,
 	r.pickup_address, r.pickup_latitude, r.pickup_longitude,
 	r.dropoff_address, r.dropoff_latitude, r.dropoff_longitude,
 	COALESCE(r.actual_distance, r.estimated_distance, 0),
@@ -31,7 +32,7 @@ const rideHistoryColumns = `
 func scanRideHistoryEntry(scan func(dest ...interface{}) error) (RideHistoryEntry, error) {
 	e := RideHistoryEntry{}
 	err := scan(
-		&e.ID, &e.RiderID, &e.DriverID, &e.Status,
+		&e.ID, &e.RiderID, &e.DriverID, &e.RideTypeID, &e.Status,
 		&e.PickupAddress, &e.PickupLatitude, &e.PickupLongitude,
 		&e.DropoffAddress, &e.DropoffLatitude, &e.DropoffLongitude,
 		&e.Distance, &e.Duration,
@@ -149,6 +150,59 @@ func (r *Repository) GetRideByID(ctx context.Context, rideID uuid.UUID) (*RideHi
 	return &e, nil
 }
 
+// SampleCompletedRides returns a random sample of rides completed between
+// from and to, by request time
+func (r *Repository) SampleCompletedRides(ctx context.Context, from, to time.Time, limit int) ([]RideHistoryEntry, error) {
+	query := fmt.Sprintf(`
+		SELECT %s FROM rides r
+		WHERE r.status = 'completed' AND r.requested_at >= $1 AND r.requested_at < $2
+		ORDER BY random()
+		LIMIT $3`, rideHistoryColumns)
+
+	rows, err := r.db.Query(ctx, query, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rides []RideHistoryEntry
+	for rows.Next() {
+		e, err := scanRideHistoryEntry(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rides = append(rides, e)
+	}
+	return rides, rows.Err()
+}
+
+// GetRideDiscountLines returns the discounts and credits settled against a
+// ride's fare in the order they applied
+func (r *Repository) GetRideDiscountLines(ctx context.Context, rideID uuid.UUID) ([]*models.RideDiscountLine, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, ride_id, position, source, line_type, label, amount, reference_id, created_at
+		FROM ride_discount_lines
+		WHERE ride_id = $1
+		ORDER BY position`, rideID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []*models.RideDiscountLine
+	for rows.Next() {
+		line := &models.RideDiscountLine{}
+		if err := rows.Scan(
+			&line.ID, &line.RideID, &line.Position, &line.Source, &line.LineType,
+			&line.Label, &line.Amount, &line.ReferenceID, &line.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
 // GetRiderStats returns aggregated stats for a rider
 func (r *Repository) GetRiderStats(ctx context.Context, riderID uuid.UUID, from, to time.Time) (*RideStats, error) {
 	stats := &RideStats{Currency: "USD"}
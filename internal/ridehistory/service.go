@@ -117,7 +117,30 @@ func (s *Service) GetReceipt(ctx context.Context, rideID uuid.UUID, userID uuid.
 		})
 	}
 
-	if ride.DiscountAmount > 0 {
+	// Rides settled by the discount orchestrator itemize each promo,
+	// subscription, loyalty and gift card line; older rides only carry the
+	// promo discount they were requested with
+	lines, err := s.repo.GetRideDiscountLines(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts, credits := ride.DiscountAmount, 0.0
+	if len(lines) > 0 {
+		discounts = 0
+		for _, line := range lines {
+			breakdown = append(breakdown, FareLineItem{
+				Label:  line.Label,
+				Amount: -line.Amount,
+				Type:   line.LineType,
+			})
+			if line.LineType == "credit" {
+				credits += line.Amount
+			} else {
+				discounts += line.Amount
+			}
+		}
+	} else if ride.DiscountAmount > 0 {
 		breakdown = append(breakdown, FareLineItem{
 			Label:  "Discount",
 			Amount: -ride.DiscountAmount,
@@ -127,8 +150,10 @@ func (s *Service) GetReceipt(ctx context.Context, rideID uuid.UUID, userID uuid.
 
 	receipt.FareBreakdown = breakdown
 	receipt.Subtotal = total
-	receipt.Discounts = ride.DiscountAmount
-	receipt.Total = total - ride.DiscountAmount
+	receipt.Discounts = discounts
+	receipt.Credits = credits
+	receipt.Total = total - discounts
+	receipt.AmountDue = receipt.Total - credits
 
 	return receipt, nil
 }
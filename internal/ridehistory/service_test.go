@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -58,6 +59,22 @@ func (m *MockRepository) GetFrequentRoutes(ctx context.Context, riderID uuid.UUI
 	return args.Get(0).([]FrequentRoute), args.Error(1)
 }
 
+func (m *MockRepository) SampleCompletedRides(ctx context.Context, from, to time.Time, limit int) ([]RideHistoryEntry, error) {
+	args := m.Called(ctx, from, to, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]RideHistoryEntry), args.Error(1)
+}
+
+func (m *MockRepository) GetRideDiscountLines(ctx context.Context, rideID uuid.UUID) ([]*models.RideDiscountLine, error) {
+	args := m.Called(ctx, rideID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.RideDiscountLine), args.Error(1)
+}
+
 // ========================================
 // GET RIDER HISTORY TESTS
 // ========================================
@@ -453,6 +470,7 @@ func TestGetReceipt_Success(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideByID", ctx, rideID).Return(mockRide, nil)
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(nil, nil)
 
 	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
 
@@ -512,6 +530,7 @@ func TestGetReceipt_Success_MinimalFare(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideByID", ctx, rideID).Return(mockRide, nil)
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(nil, nil)
 
 	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
 
@@ -552,6 +571,7 @@ func TestGetReceipt_Success_WithDiscount(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideByID", ctx, rideID).Return(mockRide, nil)
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(nil, nil)
 
 	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
 
@@ -573,6 +593,50 @@ func TestGetReceipt_Success_WithDiscount(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetReceipt_Success_ItemizedDiscounts(t *testing.T) {
+	mockRepo := new(MockRepository)
+	svc := NewService(mockRepo)
+	ctx := context.Background()
+
+	riderID := uuid.New()
+	rideID := uuid.New()
+	completedAt := time.Now()
+	finalFare := 30.00
+
+	mockRide := &RideHistoryEntry{
+		ID:              rideID,
+		RiderID:         riderID,
+		Status:          "completed",
+		EstimatedFare:   28.00,
+		FinalFare:       &finalFare,
+		SurgeMultiplier: 1.0,
+		DiscountAmount:  7.50,
+		Currency:        "USD",
+		RequestedAt:     time.Now().Add(-40 * time.Minute),
+		CompletedAt:     &completedAt,
+	}
+	lines := []*models.RideDiscountLine{
+		{RideID: rideID, Position: 1, Source: "subscription", LineType: "discount", Label: "Subscription", Amount: 4.50},
+		{RideID: rideID, Position: 2, Source: "promo", LineType: "discount", Label: "Promo code", Amount: 3.00},
+		{RideID: rideID, Position: 3, Source: "gift_card", LineType: "credit", Label: "Gift card", Amount: 10.00},
+	}
+
+	mockRepo.On("GetRideByID", ctx, rideID).Return(mockRide, nil)
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(lines, nil)
+
+	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
+
+	assert.NoError(t, err)
+	assert.Len(t, receipt.FareBreakdown, 4) // Fare + three settled lines
+	assert.Equal(t, FareLineItem{Label: "Subscription", Amount: -4.50, Type: "discount"}, receipt.FareBreakdown[1])
+	assert.Equal(t, FareLineItem{Label: "Gift card", Amount: -10.00, Type: "credit"}, receipt.FareBreakdown[3])
+	assert.InDelta(t, 7.50, receipt.Discounts, 0.001)
+	assert.InDelta(t, 10.00, receipt.Credits, 0.001)
+	assert.InDelta(t, 22.50, receipt.Total, 0.001)
+	assert.InDelta(t, 12.50, receipt.AmountDue, 0.001)
+	mockRepo.AssertExpectations(t)
+}
+
 func TestGetReceipt_NotCompleted(t *testing.T) {
 	statuses := []string{"requested", "accepted", "in_progress", "cancelled"}
 
@@ -682,6 +746,7 @@ func TestGetReceipt_TimeFormatting(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideByID", ctx, rideID).Return(mockRide, nil)
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(nil, nil)
 
 	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
 
@@ -712,6 +777,7 @@ func TestGetReceipt_NoCompletedAt(t *testing.T) {
 	}
 
 	mockRepo.On("GetRideByID", ctx, rideID).Return(mockRide, nil)
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(nil, nil)
 
 	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
 
@@ -1052,6 +1118,7 @@ func TestService_FullRideHistoryFlow(t *testing.T) {
 	assert.Equal(t, rideID, rideDetail.ID)
 
 	// Step 3: Get receipt
+	mockRepo.On("GetRideDiscountLines", ctx, rideID).Return(nil, nil)
 	receipt, err := svc.GetReceipt(ctx, rideID, riderID)
 	assert.NoError(t, err)
 	assert.Equal(t, 35.00, receipt.Subtotal)
@@ -0,0 +1,93 @@
+package rides
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/discounts"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"go.uber.org/zap"
+)
+
+// discountCheckout describes a ride about to be completed for the discount
+// orchestrator. It returns nil when no orchestrator is configured.
+func (s *Service) discountCheckout(ctx context.Context, ride *models.Ride, finalFare float64, currency string) *discounts.Checkout {
+	if s.discounts == nil {
+		return nil
+	}
+
+	checkout := &discounts.Checkout{
+		RideID:        ride.ID,
+		RiderID:       ride.RiderID,
+		Fare:          money.FromMajor(finalFare, currency),
+		PromoCodeID:   ride.PromoCodeID,
+		PromoDiscount: money.Zero(currency),
+	}
+	if ride.PromoCodeID != nil {
+		checkout.PromoDiscount = money.FromMajor(ride.DiscountAmount, currency)
+	}
+	if ride.PaymentMethod != nil {
+		checkout.PaymentMethod = *ride.PaymentMethod
+	}
+	if ride.RideTypeID != nil && s.rideTypeNameFetcher != nil {
+		if name, err := s.rideTypeNameFetcher(ctx, *ride.RideTypeID); err == nil {
+			checkout.RideType = name
+		}
+	}
+	return checkout
+}
+
+// releaseDiscounts gives back a settlement whose ride did not complete, so
+// the rider keeps the balances and credits it spent. Sources that cannot be
+// released are logged for manual correction.
+func (s *Service) releaseDiscounts(ctx context.Context, checkout *discounts.Checkout, result *discounts.Result) {
+	if checkout == nil || result == nil || len(result.Lines) == 0 {
+		return
+	}
+	if err := s.discounts.Release(ctx, checkout, result); err != nil {
+		logger.ErrorContext(ctx, "failed to release ride discounts",
+			zap.String("ride_id", checkout.RideID.String()), zap.Error(err))
+	}
+}
+
+// discountLines converts a settlement into the ride's receipt lines
+func discountLines(rideID uuid.UUID, result *discounts.Result, now time.Time) []*models.RideDiscountLine {
+	lines := make([]*models.RideDiscountLine, 0, len(result.Lines))
+	for i, item := range result.Lines {
+		lines = append(lines, &models.RideDiscountLine{
+			ID:          uuid.New(),
+			RideID:      rideID,
+			Position:    i + 1,
+			Source:      string(item.Source),
+			LineType:    item.Type,
+			Label:       item.Label,
+			Amount:      item.Amount.Major(),
+			ReferenceID: item.ReferenceID,
+			CreatedAt:   now,
+		})
+	}
+	return lines
+}
+
+// withSettlement adds a settlement to a ride's completed event, so payments
+// charge the amount due rather than the gross fare
+func withSettlement(data eventbus.RideCompletedData, result *discounts.Result) eventbus.RideCompletedData {
+	amountDue := result.AmountDue.Major()
+	data.Discount = result.Discount.Major()
+	data.Credit = result.Credit.Major()
+	data.AmountDue = &amountDue
+	data.DiscountLines = make([]eventbus.FareAdjustment, 0, len(result.Lines))
+	for _, item := range result.Lines {
+		data.DiscountLines = append(data.DiscountLines, eventbus.FareAdjustment{
+			Source: string(item.Source),
+			Type:   item.Type,
+			Label:  item.Label,
+			Amount: item.Amount.Major(),
+		})
+	}
+	return data
+}
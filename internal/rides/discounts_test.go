@@ -0,0 +1,66 @@
+package rides
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/discounts"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/money"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscountLines(t *testing.T) {
+	rideID, promoID := uuid.New(), uuid.New()
+	now := time.Now()
+	result := &discounts.Result{
+		Fare: money.New(2400, "USD"),
+		Lines: []discounts.LineItem{
+			{Source: discounts.SourcePromo, Type: discounts.LineDiscount, Label: "Promo code", Amount: money.New(400, "USD"), ReferenceID: &promoID},
+			{Source: discounts.SourceGiftCard, Type: discounts.LineCredit, Label: "Gift card", Amount: money.New(2000, "USD")},
+		},
+	}
+
+	lines := discountLines(rideID, result, now)
+
+	require.Len(t, lines, 2)
+	assert.Equal(t, rideID, lines[0].RideID)
+	assert.Equal(t, 1, lines[0].Position)
+	assert.Equal(t, "promo", lines[0].Source)
+	assert.Equal(t, &promoID, lines[0].ReferenceID)
+	assert.Equal(t, 2, lines[1].Position)
+	assert.Equal(t, "credit", lines[1].LineType)
+	assert.Equal(t, 20.0, lines[1].Amount)
+	assert.Equal(t, now, lines[1].CreatedAt)
+	assert.NotEqual(t, lines[0].ID, lines[1].ID)
+}
+
+func TestWithSettlement(t *testing.T) {
+	result := &discounts.Result{
+		Fare: money.New(2400, "USD"),
+		Lines: []discounts.LineItem{
+			{Source: discounts.SourceLoyalty, Type: discounts.LineDiscount, Label: "Loyalty reward", Amount: money.New(500, "USD")},
+			{Source: discounts.SourceGiftCard, Type: discounts.LineCredit, Label: "Gift card", Amount: money.New(1000, "USD")},
+		},
+		Discount:  money.New(500, "USD"),
+		Credit:    money.New(1000, "USD"),
+		AmountDue: money.New(900, "USD"),
+	}
+
+	data := withSettlement(eventbus.RideCompletedData{FareAmount: 24}, result)
+
+	assert.Equal(t, 24.0, data.FareAmount)
+	assert.Equal(t, 5.0, data.Discount)
+	assert.Equal(t, 10.0, data.Credit)
+	require.NotNil(t, data.AmountDue)
+	assert.Equal(t, 9.0, data.ChargeAmount())
+	require.Len(t, data.DiscountLines, 2)
+	assert.Equal(t, eventbus.FareAdjustment{Source: "gift_card", Type: "credit", Label: "Gift card", Amount: 10}, data.DiscountLines[1])
+
+	// A fully covered fare leaves nothing to charge rather than the whole fare
+	result.AmountDue = money.Zero("USD")
+	data = withSettlement(eventbus.RideCompletedData{FareAmount: 24}, result)
+	assert.Equal(t, 0.0, data.ChargeAmount())
+}
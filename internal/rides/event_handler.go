@@ -0,0 +1,56 @@
+package rides
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// declinedRideCanceller is implemented by Service
+type declinedRideCanceller interface {
+	CancelRideForDeclinedPayment(ctx context.Context, rideID uuid.UUID) (bool, error)
+}
+
+// EventHandler reacts to payment events that change a ride's course.
+type EventHandler struct {
+	rides declinedRideCanceller
+}
+
+// NewEventHandler creates an event handler backed by the rides service.
+func NewEventHandler(service *Service) *EventHandler {
+	return &EventHandler{rides: service}
+}
+
+// RegisterSubscriptions subscribes to declined card holds on the bus, which
+// cancel the ride before a driver is matched or drives to the pickup.
+func (h *EventHandler) RegisterSubscriptions(ctx context.Context, bus *eventbus.Bus) error {
+	if err := bus.Subscribe(ctx, eventbus.SubjectPaymentFailed, "rides-payment-failed", h.handlePaymentFailed); err != nil {
+		return fmt.Errorf("subscribe to %s: %w", eventbus.SubjectPaymentFailed, err)
+	}
+	logger.Info("rides: subscribed to declined card holds")
+	return nil
+}
+
+func (h *EventHandler) handlePaymentFailed(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.PaymentFailedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal payment failed: %w", err)
+	}
+	if data.RideID == uuid.Nil {
+		return nil
+	}
+
+	if _, err := h.rides.CancelRideForDeclinedPayment(ctx, data.RideID); err != nil {
+		logger.Error("rides: failed to cancel ride with declined card",
+			zap.String("ride_id", data.RideID.String()),
+			zap.Error(err),
+		)
+		return fmt.Errorf("cancel ride with declined card: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package rides
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDeclinedRideCanceller struct {
+	mock.Mock
+}
+
+func (m *mockDeclinedRideCanceller) CancelRideForDeclinedPayment(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
+func paymentFailedEvent(t *testing.T, data eventbus.PaymentFailedData) *eventbus.Event {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	require.NoError(t, err)
+	return &eventbus.Event{ID: uuid.New().String(), Type: "payment.failed", Source: "payments-service", Timestamp: time.Now(), Data: raw}
+}
+
+func TestHandlePaymentFailed_CancelsRide(t *testing.T) {
+	canceller := new(mockDeclinedRideCanceller)
+	handler := &EventHandler{rides: canceller}
+	rideID := uuid.New()
+
+	canceller.On("CancelRideForDeclinedPayment", mock.Anything, rideID).Return(true, nil).Once()
+
+	err := handler.handlePaymentFailed(context.Background(), paymentFailedEvent(t, eventbus.PaymentFailedData{
+		RideID: rideID,
+		Error:  "card_declined",
+	}))
+	require.NoError(t, err)
+	canceller.AssertExpectations(t)
+}
+
+func TestHandlePaymentFailed_RetriesOnError(t *testing.T) {
+	canceller := new(mockDeclinedRideCanceller)
+	handler := &EventHandler{rides: canceller}
+	rideID := uuid.New()
+
+	canceller.On("CancelRideForDeclinedPayment", mock.Anything, rideID).Return(false, errors.New("db down")).Once()
+
+	err := handler.handlePaymentFailed(context.Background(), paymentFailedEvent(t, eventbus.PaymentFailedData{RideID: rideID}))
+	assert.Error(t, err)
+	canceller.AssertExpectations(t)
+}
+
+func TestHandlePaymentFailed_IgnoresPaymentsWithoutRide(t *testing.T) {
+	canceller := new(mockDeclinedRideCanceller)
+	handler := &EventHandler{rides: canceller}
+
+	err := handler.handlePaymentFailed(context.Background(), paymentFailedEvent(t, eventbus.PaymentFailedData{PaymentID: uuid.New()}))
+	assert.NoError(t, err)
+	canceller.AssertNotCalled(t, "CancelRideForDeclinedPayment", mock.Anything, mock.Anything)
+}
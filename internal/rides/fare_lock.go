@@ -0,0 +1,149 @@
+package rides
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/pricing"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"go.uber.org/zap"
+)
+
+// ErrQuoteAlreadyRedeemed is returned when a fare quote was already used by
+// another ride
+var ErrQuoteAlreadyRedeemed = errors.New("fare quote already redeemed")
+
+// FareLockConfig sets how far a ride may stray from its fare quote before the
+// quote is released and the ride is priced like any other
+type FareLockConfig struct {
+	MaxEndpointDriftMeters  float64 // requested pickup or dropoff moved further than this from the quoted one
+	MaxDistanceDeviationPct float64 // driven distance differs from the quoted distance by more than this
+}
+
+// DefaultFareLockConfig returns default fare lock tolerances. The quoted
+// distance is a straight line, so driven distance gets a wide margin.
+func DefaultFareLockConfig() *FareLockConfig {
+	return &FareLockConfig{
+		MaxEndpointDriftMeters:  200,
+		MaxDistanceDeviationPct: 50,
+	}
+}
+
+// SetFareLockConfig sets custom fare lock tolerances
+func (s *Service) SetFareLockConfig(config *FareLockConfig) {
+	if config != nil {
+		s.fareLockConfig = config
+	}
+}
+
+func (s *Service) fareLock() *FareLockConfig {
+	if s.fareLockConfig == nil {
+		return DefaultFareLockConfig()
+	}
+	return s.fareLockConfig
+}
+
+// redeemQuote verifies the quote token of a ride request and checks it was
+// issued for the requested ride type
+func (s *Service) redeemQuote(riderID uuid.UUID, req *models.RideRequest) (*pricing.FareQuote, error) {
+	if s.pricingService == nil {
+		return nil, common.NewServiceUnavailableError("fare quotes are not enabled")
+	}
+
+	quote, err := s.pricingService.RedeemQuote(req.QuoteToken, riderID)
+	if err != nil {
+		return nil, err
+	}
+	if !sameRideType(quote.RideTypeID, req.RideTypeID) {
+		return nil, common.NewErrorWithCode(http.StatusBadRequest, common.ErrCodeQuoteInvalid,
+			"fare quote is for a different ride type", nil)
+	}
+	return quote, nil
+}
+
+func sameRideType(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// newRideFareQuote records a redeemed quote. It starts locked unless reason
+// says why the request no longer matches it.
+func newRideFareQuote(quote *pricing.FareQuote, reason string, now time.Time) *models.RideFareQuote {
+	fq := &models.RideFareQuote{
+		QuoteID:          quote.ID,
+		QuotedFare:       quote.Fare,
+		CurrencyCode:     quote.Currency,
+		SurgeMultiplier:  quote.SurgeMultiplier,
+		DistanceKm:       quote.DistanceKm,
+		DurationMinutes:  quote.DurationMin,
+		PricingVersionID: quote.PricingVersionID,
+		IssuedAt:         quote.IssuedAt,
+		ExpiresAt:        quote.ExpiresAt,
+		Locked:           reason == "",
+	}
+	if reason != "" {
+		fq.ReleaseReason = &reason
+		fq.ReleasedAt = &now
+	}
+	return fq
+}
+
+// requestDeviation returns why a ride request no longer matches its quote, or
+// "" if the quoted fare can be locked
+func requestDeviation(cfg *FareLockConfig, quote *pricing.FareQuote, req *models.RideRequest, stops []*models.RideStop) string {
+	if len(stops) > 0 {
+		return "route has stops the quote did not include"
+	}
+	if drift := calculateDistance(quote.PickupLatitude, quote.PickupLongitude, req.PickupLatitude, req.PickupLongitude) * 1000; drift > cfg.MaxEndpointDriftMeters {
+		return fmt.Sprintf("pickup is %.0f m from the quoted pickup", drift)
+	}
+	if drift := calculateDistance(quote.DropoffLatitude, quote.DropoffLongitude, req.DropoffLatitude, req.DropoffLongitude) * 1000; drift > cfg.MaxEndpointDriftMeters {
+		return fmt.Sprintf("dropoff is %.0f m from the quoted dropoff", drift)
+	}
+	return ""
+}
+
+// completionDeviation returns why a completed trip no longer matches its
+// locked quote, or "" if the quoted fare stands
+func completionDeviation(cfg *FareLockConfig, fq *models.RideFareQuote, actualDistance float64, stops []*models.RideStop) string {
+	if len(stops) > 0 {
+		return "stops were added to the route"
+	}
+	if fq.DistanceKm <= 0 {
+		return ""
+	}
+	deviationPct := math.Abs(actualDistance-fq.DistanceKm) / fq.DistanceKm * 100
+	if deviationPct > cfg.MaxDistanceDeviationPct {
+		return fmt.Sprintf("driven distance %.1f km differs from the quoted %.1f km by %.0f%%",
+			actualDistance, fq.DistanceKm, deviationPct)
+	}
+	return ""
+}
+
+// releaseFareQuote unlocks the ride's quote so it is re-priced. Failing to
+// record the release is logged; the caller has already re-priced.
+func (s *Service) releaseFareQuote(ctx context.Context, rideID uuid.UUID, reason string) {
+	if err := s.repo.ReleaseRideFareQuote(ctx, rideID, reason, time.Now()); err != nil {
+		logger.WarnContext(ctx, "failed to release ride fare quote",
+			zap.String("ride_id", rideID.String()), zap.String("reason", reason), zap.Error(err))
+	}
+}
+
+// lockedDriverEarnings returns the driver's share of a locked fare
+func (s *Service) lockedDriverEarnings(ctx context.Context, ride *models.Ride, fare float64) float64 {
+	if s.pricingService != nil {
+		if earnings, err := s.pricingService.CalculateDriverEarnings(ctx, ride.PickupLatitude, ride.PickupLongitude, fare); err == nil {
+			return earnings
+		}
+	}
+	return fare * 0.80 // flat 20% commission fallback
+}
@@ -0,0 +1,82 @@
+package rides
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/pricing"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func quotedRoute() *pricing.FareQuote {
+	return &pricing.FareQuote{
+		ID:               uuid.New(),
+		PickupLatitude:   40.7128,
+		PickupLongitude:  -74.0060,
+		DropoffLatitude:  40.7580,
+		DropoffLongitude: -73.9855,
+		DistanceKm:       5.4,
+		DurationMin:      9,
+		Fare:             18.75,
+		Currency:         "USD",
+		SurgeMultiplier:  1.2,
+	}
+}
+
+func TestRequestDeviation(t *testing.T) {
+	cfg := DefaultFareLockConfig()
+	quote := quotedRoute()
+	req := &models.RideRequest{
+		PickupLatitude:   40.7129, // ~10 m away
+		PickupLongitude:  -74.0060,
+		DropoffLatitude:  40.7580,
+		DropoffLongitude: -73.9855,
+	}
+
+	assert.Empty(t, requestDeviation(cfg, quote, req, nil))
+
+	assert.Contains(t, requestDeviation(cfg, quote, req, []*models.RideStop{{}}), "stops")
+
+	moved := *req
+	moved.DropoffLatitude = 40.7620 // ~450 m north
+	assert.Contains(t, requestDeviation(cfg, quote, &moved, nil), "dropoff")
+}
+
+func TestCompletionDeviation(t *testing.T) {
+	cfg := DefaultFareLockConfig()
+	fq := &models.RideFareQuote{DistanceKm: 10, Locked: true}
+
+	assert.Empty(t, completionDeviation(cfg, fq, 13.5, nil))
+	assert.Empty(t, completionDeviation(cfg, fq, 6, nil))
+	assert.Contains(t, completionDeviation(cfg, fq, 16, nil), "driven distance")
+	assert.Contains(t, completionDeviation(cfg, fq, 4, nil), "driven distance")
+	assert.Contains(t, completionDeviation(cfg, fq, 10, []*models.RideStop{{}}), "stops")
+}
+
+func TestNewRideFareQuote(t *testing.T) {
+	quote := quotedRoute()
+	now := time.Now()
+
+	locked := newRideFareQuote(quote, "", now)
+	assert.True(t, locked.Locked)
+	assert.Equal(t, quote.ID, locked.QuoteID)
+	assert.Equal(t, 18.75, locked.QuotedFare)
+	assert.Nil(t, locked.ReleaseReason)
+
+	released := newRideFareQuote(quote, "pickup moved", now)
+	assert.False(t, released.Locked)
+	require.NotNil(t, released.ReleaseReason)
+	assert.Equal(t, "pickup moved", *released.ReleaseReason)
+	assert.Equal(t, &now, released.ReleasedAt)
+}
+
+func TestSameRideType(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	assert.True(t, sameRideType(nil, nil))
+	assert.True(t, sameRideType(&a, &a))
+	assert.False(t, sameRideType(&a, &b))
+	assert.False(t, sameRideType(&a, nil))
+}
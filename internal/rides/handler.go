@@ -101,6 +101,105 @@ func (h *Handler) AcceptRide(c *gin.Context) {
 	common.SuccessResponse(c, ride)
 }
 
+// MarkDriverArrived records that the assigned driver is waiting at pickup.
+func (h *Handler) MarkDriverArrived(c *gin.Context) {
+	driverID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rideID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid ride ID")
+		return
+	}
+
+	ride, err := h.service.MarkDriverArrived(c.Request.Context(), rideID, driverID)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to mark driver arrived")
+		return
+	}
+
+	common.SuccessResponse(c, ride)
+}
+
+// AddStop lets the rider add an intermediate stop to an active ride.
+func (h *Handler) AddStop(c *gin.Context) {
+	riderID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rideID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid ride ID")
+		return
+	}
+
+	var req models.RideStopInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ride, err := h.service.AddStop(c.Request.Context(), rideID, riderID, &req)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to add stop")
+		return
+	}
+
+	common.SuccessResponse(c, ride)
+}
+
+// UpdateStopStatus records the driver's progress through an intermediate stop.
+func (h *Handler) UpdateStopStatus(c *gin.Context) {
+	driverID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rideID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid ride ID")
+		return
+	}
+
+	stopID, err := uuid.Parse(c.Param("stopId"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid stop ID")
+		return
+	}
+
+	var req models.RideStopStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stop, err := h.service.UpdateStopStatus(c.Request.Context(), rideID, stopID, driverID, req.Status)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to update stop status")
+		return
+	}
+
+	common.SuccessResponse(c, stop)
+}
+
 // StartRide marks the accepted ride as in progress for the assigned driver.
 func (h *Handler) StartRide(c *gin.Context) {
 	driverID, err := middleware.GetUserID(c)
@@ -403,8 +502,15 @@ func (h *Handler) GetSurgeInfo(c *gin.Context) {
 	common.SuccessResponse(c, surgeInfo)
 }
 
-// MatchDrivers returns the best-scored drivers for a pickup location.
+// MatchDrivers returns the best-scored drivers for a pickup location whose
+// vehicle suits the optional ride type and the rider's preferences.
 func (h *Handler) MatchDrivers(c *gin.Context) {
+	riderID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	latStr := c.Query("latitude")
 	lngStr := c.Query("longitude")
 	if latStr == "" || lngStr == "" {
@@ -423,7 +529,17 @@ func (h *Handler) MatchDrivers(c *gin.Context) {
 		return
 	}
 
-	candidates, err := h.service.MatchDrivers(c.Request.Context(), latitude, longitude)
+	var rideTypeID *uuid.UUID
+	if rideTypeStr := c.Query("ride_type_id"); rideTypeStr != "" {
+		id, err := uuid.Parse(rideTypeStr)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid ride_type_id")
+			return
+		}
+		rideTypeID = &id
+	}
+
+	candidates, err := h.service.MatchDrivers(c.Request.Context(), riderID, latitude, longitude, rideTypeID)
 	if err != nil {
 		if appErr, ok := err.(*common.AppError); ok {
 			common.AppErrorResponse(c, appErr)
@@ -439,6 +555,32 @@ func (h *Handler) MatchDrivers(c *gin.Context) {
 	})
 }
 
+// GetOfferStats returns how the driver has responded to ride offers
+func (h *Handler) GetOfferStats(c *gin.Context) {
+	driverID, err := middleware.GetUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	stats, err := h.service.GetDriverOfferStats(c.Request.Context(), driverID)
+	if err != nil {
+		if appErr, ok := err.(*common.AppError); ok {
+			common.AppErrorResponse(c, appErr)
+			return
+		}
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get offer stats")
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{
+		"stats":           stats,
+		"acceptance_rate": stats.AcceptanceRate(),
+		"ignored_rate":    stats.IgnoredRate(),
+		"in_cooldown":     stats.InCooldown(time.Now()),
+	})
+}
+
 // RegisterRoutes registers ride routes
 func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider, limiter *ratelimit.Limiter, rateCfg config.RateLimitConfig) {
 	api := r.Group("/api/v1")
@@ -458,6 +600,7 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider,
 		riders.GET("/surge-info", h.GetSurgeInfo)
 		riders.POST("/:id/cancel", h.CancelRide)
 		riders.POST("/:id/rate", h.RateRide)
+		riders.POST("/:id/stops", h.AddStop)
 		riders.GET("/match-drivers", h.MatchDrivers)
 	}
 
@@ -466,8 +609,11 @@ func (h *Handler) RegisterRoutes(r *gin.Engine, jwtProvider jwtkeys.KeyProvider,
 	drivers.Use(middleware.RequireRole(models.RoleDriver))
 	{
 		drivers.GET("/available", h.GetAvailableRides)
+		drivers.GET("/offer-stats", h.GetOfferStats)
 		drivers.POST("/:id/accept", h.AcceptRide)
+		drivers.POST("/:id/arrived", h.MarkDriverArrived)
 		drivers.POST("/:id/start", h.StartRide)
+		drivers.POST("/:id/stops/:stopId/status", h.UpdateStopStatus)
 		drivers.POST("/:id/complete", h.CompleteRide)
 	}
 }
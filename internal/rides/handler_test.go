@@ -33,7 +33,7 @@ type ServiceInterface interface {
 	GetAvailableRides(ctx context.Context) ([]*models.Ride, error)
 	GetUserProfile(ctx context.Context, userID uuid.UUID) (*models.User, error)
 	UpdateUserProfile(ctx context.Context, userID uuid.UUID, firstName, lastName, phoneNumber string) error
-	MatchDrivers(ctx context.Context, pickupLatitude, pickupLongitude float64) ([]*DriverCandidate, error)
+	MatchDrivers(ctx context.Context, riderID uuid.UUID, pickupLatitude, pickupLongitude float64, rideTypeID *uuid.UUID) ([]*DriverCandidate, error)
 }
 
 // MockService is a mock implementation of ServiceInterface
@@ -131,8 +131,8 @@ func (m *MockService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, f
 	return args.Error(0)
 }
 
-func (m *MockService) MatchDrivers(ctx context.Context, pickupLatitude, pickupLongitude float64) ([]*DriverCandidate, error) {
-	args := m.Called(ctx, pickupLatitude, pickupLongitude)
+func (m *MockService) MatchDrivers(ctx context.Context, riderID uuid.UUID, pickupLatitude, pickupLongitude float64, rideTypeID *uuid.UUID) ([]*DriverCandidate, error) {
+	args := m.Called(ctx, riderID, pickupLatitude, pickupLongitude, rideTypeID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -577,6 +577,12 @@ func (h *MockableHandler) GetSurgeInfo(c *gin.Context) {
 
 // MatchDrivers returns best-scored drivers - testable version
 func (h *MockableHandler) MatchDrivers(c *gin.Context) {
+	riderID, exists := c.Get("user_id")
+	if !exists {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
 	latStr := c.Query("latitude")
 	lngStr := c.Query("longitude")
 	if latStr == "" || lngStr == "" {
@@ -594,7 +600,17 @@ func (h *MockableHandler) MatchDrivers(c *gin.Context) {
 		return
 	}
 
-	candidates, err := h.service.MatchDrivers(c.Request.Context(), latitude, longitude)
+	var rideTypeID *uuid.UUID
+	if rideTypeStr := c.Query("ride_type_id"); rideTypeStr != "" {
+		id, err := uuid.Parse(rideTypeStr)
+		if err != nil {
+			common.ErrorResponse(c, http.StatusBadRequest, "invalid ride_type_id")
+			return
+		}
+		rideTypeID = &id
+	}
+
+	candidates, err := h.service.MatchDrivers(c.Request.Context(), riderID.(uuid.UUID), latitude, longitude, rideTypeID)
 	if err != nil {
 		if appErr, ok := err.(*common.AppError); ok {
 			common.AppErrorResponse(c, appErr)
@@ -1925,17 +1941,19 @@ func TestHandler_MatchDrivers_Success(t *testing.T) {
 
 	mockService := new(MockService)
 	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
 
 	candidates := []*DriverCandidate{
 		{DriverID: uuid.New(), DistanceKm: 1.5, Rating: 4.8, Score: 0.95},
 		{DriverID: uuid.New(), DistanceKm: 2.0, Rating: 4.5, Score: 0.85},
 	}
 
-	mockService.On("MatchDrivers", mock.Anything, 40.7128, -74.0060).Return(candidates, nil)
+	mockService.On("MatchDrivers", mock.Anything, riderID, 40.7128, -74.0060, (*uuid.UUID)(nil)).Return(candidates, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
 	req := httptest.NewRequest("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
 	c.Request = req
+	setUserContext(c, riderID, models.RoleRider)
 
 	handler.MatchDrivers(c)
 
@@ -1952,10 +1970,12 @@ func TestHandler_MatchDrivers_MissingLatitude(t *testing.T) {
 
 	mockService := new(MockService)
 	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
 
 	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?longitude=-74.0060", nil)
 	req := httptest.NewRequest("GET", "/api/v1/rides/match-drivers?longitude=-74.0060", nil)
 	c.Request = req
+	setUserContext(c, riderID, models.RoleRider)
 
 	handler.MatchDrivers(c)
 
@@ -1967,10 +1987,12 @@ func TestHandler_MatchDrivers_MissingLongitude(t *testing.T) {
 
 	mockService := new(MockService)
 	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
 
 	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=40.7128", nil)
 	req := httptest.NewRequest("GET", "/api/v1/rides/match-drivers?latitude=40.7128", nil)
 	c.Request = req
+	setUserContext(c, riderID, models.RoleRider)
 
 	handler.MatchDrivers(c)
 
@@ -1982,10 +2004,12 @@ func TestHandler_MatchDrivers_InvalidLatitude(t *testing.T) {
 
 	mockService := new(MockService)
 	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
 
 	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=invalid&longitude=-74.0060", nil)
 	req := httptest.NewRequest("GET", "/api/v1/rides/match-drivers?latitude=invalid&longitude=-74.0060", nil)
 	c.Request = req
+	setUserContext(c, riderID, models.RoleRider)
 
 	handler.MatchDrivers(c)
 
@@ -1997,12 +2021,14 @@ func TestHandler_MatchDrivers_NoDriversAvailable(t *testing.T) {
 
 	mockService := new(MockService)
 	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
 
-	mockService.On("MatchDrivers", mock.Anything, 40.7128, -74.0060).Return([]*DriverCandidate{}, nil)
+	mockService.On("MatchDrivers", mock.Anything, riderID, 40.7128, -74.0060, (*uuid.UUID)(nil)).Return([]*DriverCandidate{}, nil)
 
 	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
 	req := httptest.NewRequest("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
 	c.Request = req
+	setUserContext(c, riderID, models.RoleRider)
 
 	handler.MatchDrivers(c)
 
@@ -2018,12 +2044,14 @@ func TestHandler_MatchDrivers_ServiceError(t *testing.T) {
 
 	mockService := new(MockService)
 	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
 
-	mockService.On("MatchDrivers", mock.Anything, 40.7128, -74.0060).Return(nil, common.NewInternalServerError("matching engine not configured"))
+	mockService.On("MatchDrivers", mock.Anything, riderID, 40.7128, -74.0060, (*uuid.UUID)(nil)).Return(nil, common.NewInternalServerError("matching engine not configured"))
 
 	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
 	req := httptest.NewRequest("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
 	c.Request = req
+	setUserContext(c, riderID, models.RoleRider)
 
 	handler.MatchDrivers(c)
 
@@ -2031,6 +2059,63 @@ func TestHandler_MatchDrivers_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestHandler_MatchDrivers_WithRideType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
+	rideTypeID := uuid.New()
+
+	candidates := []*DriverCandidate{{DriverID: uuid.New(), DistanceKm: 1.5, Rating: 4.8, Score: 0.95}}
+	mockService.On("MatchDrivers", mock.Anything, riderID, 40.7128, -74.0060, &rideTypeID).Return(candidates, nil)
+
+	path := "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060&ride_type_id=" + rideTypeID.String()
+	c, w := setupTestContext("GET", path, nil)
+	setUserContext(c, riderID, models.RoleRider)
+
+	handler.MatchDrivers(c)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestHandler_MatchDrivers_InvalidRideType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	handler := NewMockableHandler(mockService)
+
+	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060&ride_type_id=bad", nil)
+	setUserContext(c, uuid.New(), models.RoleRider)
+
+	handler.MatchDrivers(c)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "MatchDrivers")
+}
+
+func TestHandler_MatchDrivers_NoEligibleDriver(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	mockService := new(MockService)
+	handler := NewMockableHandler(mockService)
+	riderID := uuid.New()
+
+	appErr := common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNoEligibleDriver,
+		"No nearby drivers have a wheelchair accessible vehicle.", nil)
+	mockService.On("MatchDrivers", mock.Anything, riderID, 40.7128, -74.0060, (*uuid.UUID)(nil)).Return(nil, appErr)
+
+	c, w := setupTestContext("GET", "/api/v1/rides/match-drivers?latitude=40.7128&longitude=-74.0060", nil)
+	setUserContext(c, riderID, models.RoleRider)
+
+	handler.MatchDrivers(c)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), common.ErrCodeNoEligibleDriver)
+	mockService.AssertExpectations(t)
+}
+
 // ============================================================================
 // GetUserProfile Handler Tests
 // ============================================================================
@@ -3,45 +3,53 @@ package rides
 import (
 	"context"
 	"math"
+	"net/http"
 	"sort"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"go.uber.org/zap"
 )
 
 // MatchingConfig holds weights for the driver matching scoring algorithm.
 type MatchingConfig struct {
-	DistanceWeight    float64 // How much distance matters (default 0.40)
-	RatingWeight      float64 // How much driver rating matters (default 0.25)
-	AcceptanceWeight  float64 // How much acceptance rate matters (default 0.20)
-	IdleTimeWeight    float64 // How much idle time matters (default 0.15)
-	MaxDistanceKm     float64 // Max distance to consider (default 10km)
-	MaxCandidates     int     // Max candidates to evaluate (default 50)
-	MaxResults        int     // Max results to return (default 5)
+	DistanceWeight       float64 // How much distance matters (default 0.35)
+	RatingWeight         float64 // How much driver rating matters (default 0.20)
+	AcceptanceWeight     float64 // How much acceptance rate matters (default 0.20)
+	IdleTimeWeight       float64 // How much idle time matters (default 0.15)
+	ResponsivenessWeight float64 // How much answering offers matters (default 0.10)
+	MaxDistanceKm        float64 // Max distance to consider (default 10km)
+	MaxCandidates        int     // Max candidates to evaluate (default 50)
+	MaxResults           int     // Max results to return (default 5)
 }
 
 // DefaultMatchingConfig returns production-tuned matching weights.
 func DefaultMatchingConfig() MatchingConfig {
 	return MatchingConfig{
-		DistanceWeight:   0.40,
-		RatingWeight:     0.25,
-		AcceptanceWeight: 0.20,
-		IdleTimeWeight:   0.15,
-		MaxDistanceKm:    10.0,
-		MaxCandidates:    50,
-		MaxResults:       5,
+		DistanceWeight:       0.35,
+		RatingWeight:         0.20,
+		AcceptanceWeight:     0.20,
+		IdleTimeWeight:       0.15,
+		ResponsivenessWeight: 0.10,
+		MaxDistanceKm:        10.0,
+		MaxCandidates:        50,
+		MaxResults:           5,
 	}
 }
 
 // DriverCandidate represents a driver being evaluated for matching.
 type DriverCandidate struct {
-	DriverID       uuid.UUID `json:"driver_id"`
-	DistanceKm     float64   `json:"distance_km"`
-	Rating         float64   `json:"rating"`
-	AcceptanceRate float64   `json:"acceptance_rate"` // 0.0-1.0
-	IdleMinutes    float64   `json:"idle_minutes"`    // Minutes since last ride completed
-	Score          float64   `json:"score"`
+	DriverID         uuid.UUID `json:"driver_id"`
+	DistanceKm       float64   `json:"distance_km"`
+	Rating           float64   `json:"rating"`
+	AcceptanceRate   float64   `json:"acceptance_rate"`    // 0.0-1.0
+	IdleMinutes      float64   `json:"idle_minutes"`       // Minutes since last ride completed
+	IgnoredOfferRate float64   `json:"ignored_offer_rate"` // 0.0-1.0, share of offers left to expire
+	Score            float64   `json:"score"`
+
+	Equipment vehicle.DriverEquipment `json:"equipment"` // Primary vehicle and declared capabilities
 }
 
 // DriverDataProvider fetches driver metadata needed for scoring.
@@ -65,10 +73,14 @@ func NewMatcher(cfg MatchingConfig, provider DriverDataProvider) *Matcher {
 // FindBestDrivers returns the top-ranked drivers for a pickup location.
 // Each candidate is scored using a weighted multi-factor algorithm:
 //
-//	score = w_dist * distScore + w_rating * ratingScore + w_accept * acceptScore + w_idle * idleScore
+//	score = w_dist * distScore + w_rating * ratingScore + w_accept * acceptScore + w_idle * idleScore + w_resp * respScore
 //
 // Where each factor is normalized to [0, 1] with 1 being best.
-func (m *Matcher) FindBestDrivers(ctx context.Context, pickupLatitude, pickupLongitude float64) ([]*DriverCandidate, error) {
+//
+// When requirements are given, drivers whose vehicle or capabilities do not
+// meet them are dropped before scoring. If nearby drivers exist but none is
+// eligible, a not found error explains the most common reason.
+func (m *Matcher) FindBestDrivers(ctx context.Context, pickupLatitude, pickupLongitude float64, requirements *vehicle.RideRequirements) ([]*DriverCandidate, error) {
 	candidates, err := m.provider.GetNearbyDriverCandidates(ctx, pickupLatitude, pickupLongitude, m.cfg.MaxDistanceKm, m.cfg.MaxCandidates)
 	if err != nil {
 		return nil, err
@@ -78,6 +90,13 @@ func (m *Matcher) FindBestDrivers(ctx context.Context, pickupLatitude, pickupLon
 		return []*DriverCandidate{}, nil
 	}
 
+	if requirements != nil {
+		candidates, err = filterEligible(candidates, *requirements)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Find max values for normalization
 	maxDist := 0.0
 	maxIdle := 0.0
@@ -114,6 +133,26 @@ func (m *Matcher) FindBestDrivers(ctx context.Context, pickupLatitude, pickupLon
 	return candidates, nil
 }
 
+// filterEligible keeps the candidates whose equipment meets the requirements.
+func filterEligible(candidates []*DriverCandidate, requirements vehicle.RideRequirements) ([]*DriverCandidate, error) {
+	eligible := make([]*DriverCandidate, 0, len(candidates))
+	reasons := make(map[vehicle.IneligibleReason]int)
+	for _, c := range candidates {
+		if reason := requirements.Check(c.Equipment); reason != "" {
+			reasons[reason]++
+			continue
+		}
+		eligible = append(eligible, c)
+	}
+
+	if len(eligible) == 0 {
+		reason := vehicle.MostCommonReason(reasons)
+		return nil, common.NewErrorWithCode(http.StatusNotFound, common.ErrCodeNoEligibleDriver,
+			vehicle.NoEligibleDriverMessage(reason), nil)
+	}
+	return eligible, nil
+}
+
 // scoreCandidate computes a normalized weighted score for a single driver.
 func (m *Matcher) scoreCandidate(c *DriverCandidate, maxDist, maxIdle float64) float64 {
 	// Distance score: closer = better. Use inverse linear scaling.
@@ -131,6 +170,10 @@ func (m *Matcher) scoreCandidate(c *DriverCandidate, maxDist, maxIdle float64) f
 	// Acceptance rate score: direct [0, 1].
 	acceptScore := c.AcceptanceRate
 
+	// Responsiveness score: drivers who let offers expire waste the rider's
+	// time more than drivers who decline.
+	respScore := 1.0 - c.IgnoredOfferRate
+
 	// Idle time score: longer idle = higher priority (fairness).
 	// Use logarithmic scaling to prevent extreme values from dominating.
 	idleScore := 0.0
@@ -141,7 +184,8 @@ func (m *Matcher) scoreCandidate(c *DriverCandidate, maxDist, maxIdle float64) f
 	score := m.cfg.DistanceWeight*distScore +
 		m.cfg.RatingWeight*ratingScore +
 		m.cfg.AcceptanceWeight*acceptScore +
-		m.cfg.IdleTimeWeight*idleScore
+		m.cfg.IdleTimeWeight*idleScore +
+		m.cfg.ResponsivenessWeight*respScore
 
 	return math.Round(score*1000) / 1000
 }
@@ -51,7 +51,7 @@ func benchmarkMatcherN(b *testing.B, n int) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194)
+		_, _ = matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, nil)
 	}
 }
 
@@ -45,7 +45,8 @@ type geoAPIResponse struct {
 }
 
 // GetNearbyDriverCandidates fetches nearby available drivers from geo service,
-// then enriches them with DB stats (rating, acceptance rate, idle time).
+// then enriches them with DB stats (rating, acceptance rate, idle time) and
+// each driver's vehicle equipment.
 func (p *GeoMatchingProvider) GetNearbyDriverCandidates(ctx context.Context, latitude, longitude float64, maxDistance float64, limit int) ([]*DriverCandidate, error) {
 	// Call geo service for nearby drivers (with circuit breaker if configured)
 	path := fmt.Sprintf("/api/v1/geo/drivers/nearby?latitude=%f&longitude=%f&limit=%d", latitude, longitude, limit)
@@ -92,6 +93,13 @@ func (p *GeoMatchingProvider) GetNearbyDriverCandidates(ctx context.Context, lat
 		stats = make(map[uuid.UUID]*DriverMatchStats)
 	}
 
+	equipment, err := p.repo.GetDriverEquipment(ctx, driverIDs)
+	if err != nil {
+		// Without equipment every driver looks vehicle-less, so let the
+		// caller fail rather than report a misleading reason.
+		return nil, fmt.Errorf("failed to fetch driver equipment: %w", err)
+	}
+
 	// Build candidates
 	candidates := make([]*DriverCandidate, 0, len(resp.Data.Drivers))
 	for _, d := range resp.Data.Drivers {
@@ -105,13 +113,18 @@ func (p *GeoMatchingProvider) GetNearbyDriverCandidates(ctx context.Context, lat
 			s = &DriverMatchStats{Rating: 4.0, AcceptanceRate: 0.8, IdleMinutes: 30.0}
 		}
 
-		candidates = append(candidates, &DriverCandidate{
-			DriverID:       d.DriverID,
-			DistanceKm:     dist,
-			Rating:         s.Rating,
-			AcceptanceRate: s.AcceptanceRate,
-			IdleMinutes:    s.IdleMinutes,
-		})
+		c := &DriverCandidate{
+			DriverID:         d.DriverID,
+			DistanceKm:       dist,
+			Rating:           s.Rating,
+			AcceptanceRate:   s.AcceptanceRate,
+			IdleMinutes:      s.IdleMinutes,
+			IgnoredOfferRate: s.IgnoredOfferRate,
+		}
+		if e := equipment[d.DriverID]; e != nil {
+			c.Equipment = *e
+		}
+		candidates = append(candidates, c)
 	}
 
 	return candidates, nil
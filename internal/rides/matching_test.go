@@ -2,9 +2,12 @@ package rides
 
 import (
 	"context"
+	"net/http"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/common"
 )
 
 // mockDataProvider implements DriverDataProvider for testing.
@@ -21,7 +24,7 @@ func TestMatcher_FindBestDrivers_Empty(t *testing.T) {
 	provider := &mockDataProvider{candidates: []*DriverCandidate{}}
 	matcher := NewMatcher(DefaultMatchingConfig(), provider)
 
-	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194)
+	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -38,7 +41,7 @@ func TestMatcher_FindBestDrivers_SingleCandidate(t *testing.T) {
 	}
 	matcher := NewMatcher(DefaultMatchingConfig(), provider)
 
-	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194)
+	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -67,7 +70,7 @@ func TestMatcher_FindBestDrivers_RankedByScore(t *testing.T) {
 	}
 	matcher := NewMatcher(DefaultMatchingConfig(), provider)
 
-	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194)
+	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -97,7 +100,7 @@ func TestMatcher_FindBestDrivers_RespectsMaxResults(t *testing.T) {
 	provider := &mockDataProvider{candidates: candidates}
 	matcher := NewMatcher(cfg, provider)
 
-	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194)
+	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -106,6 +109,53 @@ func TestMatcher_FindBestDrivers_RespectsMaxResults(t *testing.T) {
 	}
 }
 
+func TestMatcher_FindBestDrivers_FiltersIneligible(t *testing.T) {
+	sedan := vehicle.DriverEquipment{HasVehicle: true, Category: vehicle.VehicleCategoryEconomy, MaxPassengers: 4}
+	van := vehicle.DriverEquipment{HasVehicle: true, Category: vehicle.VehicleCategoryXL, MaxPassengers: 6}
+	vanDriver := &DriverCandidate{DriverID: uuid.New(), DistanceKm: 4.0, Rating: 4.0, AcceptanceRate: 0.8, Equipment: van}
+
+	provider := &mockDataProvider{
+		candidates: []*DriverCandidate{
+			{DriverID: uuid.New(), DistanceKm: 1.0, Rating: 5.0, AcceptanceRate: 0.9, Equipment: sedan},
+			vanDriver,
+		},
+	}
+	matcher := NewMatcher(DefaultMatchingConfig(), provider)
+
+	requirements := &vehicle.RideRequirements{Categories: []vehicle.VehicleCategory{vehicle.VehicleCategoryXL}, MinPassengers: 6}
+	results, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, requirements)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].DriverID != vanDriver.DriverID {
+		t.Fatalf("expected only the XL driver, got %d results", len(results))
+	}
+}
+
+func TestMatcher_FindBestDrivers_NoEligibleDriver(t *testing.T) {
+	sedan := vehicle.DriverEquipment{HasVehicle: true, Category: vehicle.VehicleCategoryEconomy, MaxPassengers: 4}
+	provider := &mockDataProvider{
+		candidates: []*DriverCandidate{
+			{DriverID: uuid.New(), DistanceKm: 1.0, Rating: 5.0, Equipment: sedan},
+			{DriverID: uuid.New(), DistanceKm: 2.0, Rating: 4.0},
+			{DriverID: uuid.New(), DistanceKm: 3.0, Rating: 4.0, Equipment: sedan},
+		},
+	}
+	matcher := NewMatcher(DefaultMatchingConfig(), provider)
+
+	_, err := matcher.FindBestDrivers(context.Background(), 37.7749, -122.4194, &vehicle.RideRequirements{WheelchairAccess: true})
+	appErr, ok := err.(*common.AppError)
+	if !ok {
+		t.Fatalf("expected AppError, got %v", err)
+	}
+	if appErr.Code != http.StatusNotFound || appErr.ErrorCode != common.ErrCodeNoEligibleDriver {
+		t.Fatalf("expected 404 %s, got %d %s", common.ErrCodeNoEligibleDriver, appErr.Code, appErr.ErrorCode)
+	}
+	if appErr.Message != vehicle.NoEligibleDriverMessage(vehicle.IneligibleWheelchairAccess) {
+		t.Fatalf("expected wheelchair message, got %q", appErr.Message)
+	}
+}
+
 func TestMatcher_ScoreCandidate_DistanceWeight(t *testing.T) {
 	cfg := MatchingConfig{
 		DistanceWeight:   1.0,
@@ -166,6 +216,27 @@ func TestMatcher_ScoreCandidate_IdleTimeFairness(t *testing.T) {
 	}
 }
 
+func TestMatcher_ScoreCandidate_ResponsivenessWeight(t *testing.T) {
+	cfg := MatchingConfig{
+		DistanceWeight:       0.0,
+		RatingWeight:         0.0,
+		AcceptanceWeight:     0.0,
+		IdleTimeWeight:       0.0,
+		ResponsivenessWeight: 1.0,
+	}
+	matcher := &Matcher{cfg: cfg}
+
+	responsive := &DriverCandidate{DistanceKm: 5.0, Rating: 4.0, AcceptanceRate: 0.6, IgnoredOfferRate: 0.05}
+	ignoring := &DriverCandidate{DistanceKm: 5.0, Rating: 4.0, AcceptanceRate: 0.6, IgnoredOfferRate: 0.40}
+
+	responsiveScore := matcher.scoreCandidate(responsive, 10.0, 30.0)
+	ignoringScore := matcher.scoreCandidate(ignoring, 10.0, 30.0)
+
+	if responsiveScore <= ignoringScore {
+		t.Fatalf("driver who answers offers should score higher: responsive=%f, ignoring=%f", responsiveScore, ignoringScore)
+	}
+}
+
 func TestMatcher_ScoreCandidate_AllZeros(t *testing.T) {
 	cfg := DefaultMatchingConfig()
 	matcher := &Matcher{cfg: cfg}
@@ -0,0 +1,312 @@
+package rides
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	outboxPublishedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rides_outbox_published_total",
+		Help: "Total number of outbox events relayed to the event bus",
+	}, []string{"subject"})
+
+	outboxPublishFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rides_outbox_publish_failures_total",
+		Help: "Total number of failed outbox publish attempts",
+	}, []string{"subject"})
+
+	outboxRelayLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rides_outbox_relay_latency_seconds",
+		Help:    "Time between an outbox event being committed and published",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 14), // 50ms to ~7min
+	}, []string{"subject"})
+
+	outboxPendingGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "rides_outbox_pending",
+		Help: "Number of outbox events not yet published",
+	})
+
+	outboxLagGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "rides_outbox_lag_seconds",
+		Help: "Age of the oldest unpublished outbox event",
+	})
+
+	outboxStuckGauge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "rides_outbox_stuck",
+		Help: "Number of unpublished outbox events that exceeded the stuck attempt threshold",
+	})
+)
+
+// OutboxEvent is a ride lifecycle event staged in ride_event_outbox. It is
+// written in the same transaction as the ride state change so the event is
+// never lost between the database commit and the NATS publish.
+type OutboxEvent struct {
+	Seq         int64
+	AggregateID uuid.UUID // ride ID; events are relayed in order per aggregate
+	Subject     string
+	Event       *eventbus.Event
+	Attempts    int
+	CreatedAt   time.Time
+}
+
+// NewOutboxEvent wraps event data in an eventbus envelope keyed by ride ID.
+func NewOutboxEvent(rideID uuid.UUID, subject, eventType string, data interface{}) (*OutboxEvent, error) {
+	evt, err := eventbus.NewEvent(eventType, "rides-service", data)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxEvent{
+		AggregateID: rideID,
+		Subject:     subject,
+		Event:       evt,
+	}, nil
+}
+
+// OutboxStats summarises the unpublished backlog for metrics.
+type OutboxStats struct {
+	Pending    int64
+	OldestAge  time.Duration
+	StuckCount int64
+}
+
+// OutboxStore is the persistence used by the relay. Implemented by Repository.
+type OutboxStore interface {
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, seq int64) error
+	MarkOutboxEventFailed(ctx context.Context, seq int64, errMsg string, nextAttemptAt time.Time) error
+	GetOutboxStats(ctx context.Context, stuckAttempts int) (*OutboxStats, error)
+	PurgePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error)
+}
+
+// EventPublisher publishes an event to a subject. Implemented by eventbus.Bus
+// and eventbus.Dialer.
+type EventPublisher interface {
+	Publish(ctx context.Context, subject string, event *eventbus.Event) error
+}
+
+// connectionReporter is implemented by publishers that know whether they can
+// reach the bus. The relay leaves events unclaimed while they cannot, so an
+// outage does not use up their attempts and push out their next retry.
+type connectionReporter interface {
+	Connected() bool
+}
+
+// OutboxRelayConfig controls how the relay drains the outbox.
+type OutboxRelayConfig struct {
+	PollInterval   time.Duration // how often to look for pending events
+	BatchSize      int           // max events claimed per poll
+	Lease          time.Duration // how long a claimed event is hidden from other relays
+	PublishTimeout time.Duration // per-event publish timeout
+	BaseBackoff    time.Duration // first retry delay after a failed publish
+	MaxBackoff     time.Duration // retry delay cap
+	StuckAttempts  int           // attempts after which an event counts as stuck
+	Retention      time.Duration // how long published rows are kept
+}
+
+// DefaultOutboxRelayConfig returns sensible defaults for the outbox relay.
+func DefaultOutboxRelayConfig() OutboxRelayConfig {
+	return OutboxRelayConfig{
+		PollInterval:   500 * time.Millisecond,
+		BatchSize:      100,
+		Lease:          30 * time.Second,
+		PublishTimeout: 5 * time.Second,
+		BaseBackoff:    time.Second,
+		MaxBackoff:     5 * time.Minute,
+		StuckAttempts:  10,
+		Retention:      72 * time.Hour,
+	}
+}
+
+// OutboxRelay drains ride_event_outbox to the event bus with at-least-once
+// delivery. Several relays may run concurrently (one per rides replica): rows
+// are claimed with a lease and only the oldest pending event of each ride is
+// claimed at a time, so events for a single ride are published in order.
+// Consumers dedupe on the event ID, which is also used as the JetStream
+// message ID.
+type OutboxRelay struct {
+	store     OutboxStore
+	publisher EventPublisher
+	cfg       OutboxRelayConfig
+	done      chan struct{}
+	lastPurge time.Time
+}
+
+// NewOutboxRelay creates a new outbox relay.
+func NewOutboxRelay(store OutboxStore, publisher EventPublisher, cfg OutboxRelayConfig) *OutboxRelay {
+	defaults := DefaultOutboxRelayConfig()
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = defaults.Lease
+	}
+	if cfg.PublishTimeout <= 0 {
+		cfg.PublishTimeout = defaults.PublishTimeout
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = defaults.BaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaults.MaxBackoff
+	}
+	if cfg.StuckAttempts <= 0 {
+		cfg.StuckAttempts = defaults.StuckAttempts
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaults.Retention
+	}
+	return &OutboxRelay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start runs the relay loop until ctx is cancelled or Stop is called.
+func (r *OutboxRelay) Start(ctx context.Context) {
+	logger.Info("Starting ride event outbox relay",
+		zap.Duration("poll_interval", r.cfg.PollInterval),
+		zap.Int("batch_size", r.cfg.BatchSize),
+	)
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// Keep draining while full batches come back so a backlog clears quickly
+			for r.publisherConnected() {
+				n, err := r.relayBatch(ctx)
+				if err != nil {
+					logger.Warn("outbox relay batch failed", zap.Error(err))
+					break
+				}
+				if n < r.cfg.BatchSize {
+					break
+				}
+			}
+			r.recordStats(ctx)
+			r.purge(ctx)
+		case <-ctx.Done():
+			logger.Info("Ride event outbox relay stopped")
+			return
+		case <-r.done:
+			logger.Info("Ride event outbox relay shutdown requested")
+			return
+		}
+	}
+}
+
+// Stop gracefully stops the relay.
+func (r *OutboxRelay) Stop() {
+	close(r.done)
+}
+
+// publisherConnected reports whether the publisher can reach the bus.
+// Publishers that cannot tell are assumed connected.
+func (r *OutboxRelay) publisherConnected() bool {
+	reporter, ok := r.publisher.(connectionReporter)
+	return !ok || reporter.Connected()
+}
+
+// relayBatch claims a batch of pending events and publishes them. Returns the
+// number of events claimed.
+func (r *OutboxRelay) relayBatch(ctx context.Context) (int, error) {
+	events, err := r.store.ClaimOutboxEvents(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, evt := range events {
+		pubCtx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+		err := r.publisher.Publish(pubCtx, evt.Subject, evt.Event)
+		cancel()
+
+		if err != nil {
+			outboxPublishFailuresTotal.WithLabelValues(evt.Subject).Inc()
+			next := time.Now().Add(r.backoff(evt.Attempts))
+			logger.Warn("outbox publish failed, will retry",
+				zap.Int64("seq", evt.Seq),
+				zap.String("ride_id", evt.AggregateID.String()),
+				zap.String("subject", evt.Subject),
+				zap.Int("attempts", evt.Attempts),
+				zap.Time("next_attempt_at", next),
+				zap.Error(err),
+			)
+			if markErr := r.store.MarkOutboxEventFailed(ctx, evt.Seq, err.Error(), next); markErr != nil {
+				// The lease expires on its own; the event will be retried then.
+				logger.Warn("failed to record outbox publish failure", zap.Int64("seq", evt.Seq), zap.Error(markErr))
+			}
+			continue
+		}
+
+		if err := r.store.MarkOutboxEventPublished(ctx, evt.Seq); err != nil {
+			// Published but not marked: it will be re-sent after the lease
+			// expires, which consumers tolerate via event ID dedupe.
+			logger.Warn("failed to mark outbox event published", zap.Int64("seq", evt.Seq), zap.Error(err))
+			continue
+		}
+
+		outboxPublishedTotal.WithLabelValues(evt.Subject).Inc()
+		if !evt.CreatedAt.IsZero() {
+			outboxRelayLatency.WithLabelValues(evt.Subject).Observe(time.Since(evt.CreatedAt).Seconds())
+		}
+	}
+
+	return len(events), nil
+}
+
+// backoff returns the retry delay after the given number of attempts.
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := float64(r.cfg.BaseBackoff) * math.Pow(2, float64(attempts-1))
+	if delay > float64(r.cfg.MaxBackoff) {
+		return r.cfg.MaxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// recordStats refreshes the backlog gauges.
+func (r *OutboxRelay) recordStats(ctx context.Context) {
+	stats, err := r.store.GetOutboxStats(ctx, r.cfg.StuckAttempts)
+	if err != nil {
+		logger.Warn("failed to read outbox stats", zap.Error(err))
+		return
+	}
+	outboxPendingGauge.Set(float64(stats.Pending))
+	outboxLagGauge.Set(stats.OldestAge.Seconds())
+	outboxStuckGauge.Set(float64(stats.StuckCount))
+}
+
+// purge removes published rows past the retention window, at most hourly.
+func (r *OutboxRelay) purge(ctx context.Context) {
+	if time.Since(r.lastPurge) < time.Hour {
+		return
+	}
+	r.lastPurge = time.Now()
+
+	deleted, err := r.store.PurgePublishedOutboxEvents(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		logger.Warn("failed to purge published outbox events", zap.Error(err))
+		return
+	}
+	if deleted > 0 {
+		logger.Info("purged published outbox events", zap.Int64("count", deleted))
+	}
+}
@@ -0,0 +1,268 @@
+package rides
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOutboxStore is an in-memory OutboxStore that mimics the claim semantics
+// of the Postgres implementation (head-of-line per ride, lease on claim).
+type fakeOutboxStore struct {
+	mu        sync.Mutex
+	rows      []*fakeOutboxRow
+	nextSeq   int64
+	published []int64
+}
+
+type fakeOutboxRow struct {
+	evt           *OutboxEvent
+	publishedAt   *time.Time
+	nextAttemptAt time.Time
+	lastError     string
+}
+
+func (f *fakeOutboxStore) add(evt *OutboxEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextSeq++
+	evt.Seq = f.nextSeq
+	evt.CreatedAt = time.Now()
+	f.rows = append(f.rows, &fakeOutboxRow{evt: evt})
+}
+
+func (f *fakeOutboxStore) ClaimOutboxEvents(_ context.Context, limit int, lease time.Duration) ([]*OutboxEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	heads := make(map[uuid.UUID]bool)
+	var claimed []*OutboxEvent
+	for _, row := range f.rows {
+		if row.publishedAt != nil {
+			continue
+		}
+		if heads[row.evt.AggregateID] {
+			continue
+		}
+		heads[row.evt.AggregateID] = true
+		if row.nextAttemptAt.After(now) || len(claimed) >= limit {
+			continue
+		}
+		row.evt.Attempts++
+		row.nextAttemptAt = now.Add(lease)
+		claimed = append(claimed, row.evt)
+	}
+	return claimed, nil
+}
+
+func (f *fakeOutboxStore) MarkOutboxEventPublished(_ context.Context, seq int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range f.rows {
+		if row.evt.Seq == seq {
+			now := time.Now()
+			row.publishedAt = &now
+			f.published = append(f.published, seq)
+		}
+	}
+	return nil
+}
+
+func (f *fakeOutboxStore) MarkOutboxEventFailed(_ context.Context, seq int64, errMsg string, nextAttemptAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, row := range f.rows {
+		if row.evt.Seq == seq {
+			row.lastError = errMsg
+			row.nextAttemptAt = nextAttemptAt
+		}
+	}
+	return nil
+}
+
+func (f *fakeOutboxStore) GetOutboxStats(_ context.Context, stuckAttempts int) (*OutboxStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := &OutboxStats{}
+	for _, row := range f.rows {
+		if row.publishedAt != nil {
+			continue
+		}
+		stats.Pending++
+		if row.evt.Attempts >= stuckAttempts {
+			stats.StuckCount++
+		}
+		if age := time.Since(row.evt.CreatedAt); age > stats.OldestAge {
+			stats.OldestAge = age
+		}
+	}
+	return stats, nil
+}
+
+func (f *fakeOutboxStore) PurgePublishedOutboxEvents(_ context.Context, _ time.Time) (int64, error) {
+	return 0, nil
+}
+
+type fakePublisher struct {
+	mu       sync.Mutex
+	failFor  map[string]bool // event IDs that fail to publish
+	received []*eventbus.Event
+}
+
+func (p *fakePublisher) Publish(_ context.Context, _ string, event *eventbus.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failFor[event.ID] {
+		return errors.New("nats unavailable")
+	}
+	p.received = append(p.received, event)
+	return nil
+}
+
+// offlinePublisher is a publisher that reports whether it can reach the bus
+type offlinePublisher struct {
+	fakePublisher
+	connected atomic.Bool
+}
+
+func (p *offlinePublisher) Connected() bool {
+	return p.connected.Load()
+}
+
+func newTestOutboxEvent(t *testing.T, rideID uuid.UUID, subject string) *OutboxEvent {
+	t.Helper()
+	evt, err := NewOutboxEvent(rideID, subject, subject, map[string]string{"ride_id": rideID.String()})
+	require.NoError(t, err)
+	return evt
+}
+
+func TestNewOutboxEvent(t *testing.T) {
+	rideID := uuid.New()
+	evt, err := NewOutboxEvent(rideID, eventbus.SubjectRideRequested, "ride.requested", eventbus.RideRequestedData{RideID: rideID})
+	require.NoError(t, err)
+
+	assert.Equal(t, rideID, evt.AggregateID)
+	assert.Equal(t, eventbus.SubjectRideRequested, evt.Subject)
+	assert.Equal(t, "ride.requested", evt.Event.Type)
+	assert.Equal(t, "rides-service", evt.Event.Source)
+	assert.NotEmpty(t, evt.Event.ID)
+	assert.Contains(t, string(evt.Event.Data), rideID.String())
+}
+
+func TestOutboxRelay_PublishesInOrderPerRide(t *testing.T) {
+	store := &fakeOutboxStore{}
+	pub := &fakePublisher{}
+	relay := NewOutboxRelay(store, pub, DefaultOutboxRelayConfig())
+
+	rideID := uuid.New()
+	requested := newTestOutboxEvent(t, rideID, eventbus.SubjectRideRequested)
+	accepted := newTestOutboxEvent(t, rideID, eventbus.SubjectRideAccepted)
+	store.add(requested)
+	store.add(accepted)
+
+	// Only the head event of a ride is claimed per batch
+	n, err := relay.relayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	n, err = relay.relayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.Len(t, pub.received, 2)
+	assert.Equal(t, requested.Event.ID, pub.received[0].ID)
+	assert.Equal(t, accepted.Event.ID, pub.received[1].ID)
+	assert.Equal(t, []int64{requested.Seq, accepted.Seq}, store.published)
+}
+
+func TestOutboxRelay_FailureBlocksLaterEventsForSameRide(t *testing.T) {
+	store := &fakeOutboxStore{}
+	pub := &fakePublisher{failFor: map[string]bool{}}
+	relay := NewOutboxRelay(store, pub, DefaultOutboxRelayConfig())
+
+	blockedRide := uuid.New()
+	otherRide := uuid.New()
+	first := newTestOutboxEvent(t, blockedRide, eventbus.SubjectRideRequested)
+	second := newTestOutboxEvent(t, blockedRide, eventbus.SubjectRideCancelled)
+	other := newTestOutboxEvent(t, otherRide, eventbus.SubjectRideRequested)
+	store.add(first)
+	store.add(second)
+	store.add(other)
+	pub.failFor[first.Event.ID] = true
+
+	_, err := relay.relayBatch(context.Background())
+	require.NoError(t, err)
+
+	// The other ride is unaffected; the failed ride's later event is held back
+	require.Len(t, pub.received, 1)
+	assert.Equal(t, other.Event.ID, pub.received[0].ID)
+	assert.Equal(t, "nats unavailable", store.rows[0].lastError)
+	assert.True(t, store.rows[0].nextAttemptAt.After(time.Now()))
+
+	_, err = relay.relayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, pub.received, 1, "second event must wait for the first to publish")
+
+	stats, err := store.GetOutboxStats(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats.Pending)
+	assert.Equal(t, int64(1), stats.StuckCount)
+}
+
+func TestOutboxRelay_WaitsForBusToConnect(t *testing.T) {
+	store := &fakeOutboxStore{}
+	pub := &offlinePublisher{}
+	evt := newTestOutboxEvent(t, uuid.New(), eventbus.SubjectRideRequested)
+	store.add(evt)
+
+	runFor := func(d time.Duration) {
+		relay := NewOutboxRelay(store, pub, OutboxRelayConfig{PollInterval: time.Millisecond})
+		ctx, cancel := context.WithTimeout(context.Background(), d)
+		defer cancel()
+		relay.Start(ctx)
+	}
+
+	// The event stays unclaimed while the bus is down, so it keeps its attempts
+	runFor(20 * time.Millisecond)
+	assert.Zero(t, evt.Attempts)
+	assert.Empty(t, pub.received)
+
+	pub.connected.Store(true)
+	runFor(20 * time.Millisecond)
+	require.Len(t, pub.received, 1)
+	assert.Equal(t, evt.Event.ID, pub.received[0].ID)
+	assert.Equal(t, 1, evt.Attempts)
+}
+
+func TestOutboxRelay_Backoff(t *testing.T) {
+	relay := NewOutboxRelay(&fakeOutboxStore{}, &fakePublisher{}, OutboxRelayConfig{
+		BaseBackoff: time.Second,
+		MaxBackoff:  10 * time.Second,
+	})
+
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 8*time.Second, relay.backoff(4))
+	assert.Equal(t, 10*time.Second, relay.backoff(5))
+	assert.Equal(t, 10*time.Second, relay.backoff(30))
+}
+
+func TestNewOutboxRelay_AppliesDefaults(t *testing.T) {
+	relay := NewOutboxRelay(&fakeOutboxStore{}, &fakePublisher{}, OutboxRelayConfig{})
+	defaults := DefaultOutboxRelayConfig()
+
+	assert.Equal(t, defaults.PollInterval, relay.cfg.PollInterval)
+	assert.Equal(t, defaults.BatchSize, relay.cfg.BatchSize)
+	assert.Equal(t, defaults.Lease, relay.cfg.Lease)
+	assert.Equal(t, defaults.StuckAttempts, relay.cfg.StuckAttempts)
+}
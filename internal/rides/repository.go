@@ -2,11 +2,17 @@ package rides
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/richxcame/ride-hailing/internal/vehicle"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/models"
 )
 
@@ -20,8 +26,15 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 	return &Repository{db: db}
 }
 
-// CreateRide creates a new ride request
-func (r *Repository) CreateRide(ctx context.Context, ride *models.Ride) error {
+// CreateRide creates a new ride request. When evt is non-nil it is staged in
+// the event outbox in the same transaction.
+func (r *Repository) CreateRide(ctx context.Context, ride *models.Ride, evt *OutboxEvent) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	query := `
 		INSERT INTO rides (
 			id, rider_id, status, pickup_latitude, pickup_longitude, pickup_address,
@@ -30,14 +43,15 @@ func (r *Repository) CreateRide(ctx context.Context, ride *models.Ride) error {
 			ride_type_id, promo_code_id, discount_amount, scheduled_at, is_scheduled,
 			scheduled_notification_sent,
 			country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			payment_method
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
-				$21, $22, $23, $24, $25, $26, $27, $28, $29)
+				$21, $22, $23, $24, $25, $26, $27, $28, $29, $30)
 		RETURNING created_at, updated_at
 	`
 
-	err := r.db.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		ride.ID,
 		ride.RiderID,
 		ride.Status,
@@ -67,12 +81,55 @@ func (r *Repository) CreateRide(ctx context.Context, ride *models.Ride) error {
 		ride.PricingVersionID,
 		ride.WasNegotiated,
 		ride.NegotiationSessionID,
+		ride.PaymentMethod,
 	).Scan(&ride.CreatedAt, &ride.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create ride: %w", err)
 	}
 
+	for _, stop := range ride.Stops {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO ride_stops (id, ride_id, stop_order, latitude, longitude, address, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			stop.ID, ride.ID, stop.StopOrder, stop.Latitude, stop.Longitude, stop.Address, stop.Status, ride.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create ride stop: %w", err)
+		}
+		stop.CreatedAt = ride.CreatedAt
+	}
+
+	if fq := ride.FareQuote; fq != nil {
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO ride_fare_quotes (
+				ride_id, quote_id, quoted_fare, currency_code, surge_multiplier,
+				distance_km, duration_minutes, pricing_version_id, issued_at, expires_at,
+				locked, release_reason, released_at, created_at
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+			ON CONFLICT (quote_id) DO NOTHING`,
+			ride.ID, fq.QuoteID, fq.QuotedFare, fq.CurrencyCode, fq.SurgeMultiplier,
+			fq.DistanceKm, fq.DurationMinutes, fq.PricingVersionID, fq.IssuedAt, fq.ExpiresAt,
+			fq.Locked, fq.ReleaseReason, fq.ReleasedAt, ride.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create ride fare quote: %w", err)
+		}
+		if tag.RowsAffected() != 1 {
+			return ErrQuoteAlreadyRedeemed
+		}
+		fq.CreatedAt = ride.CreatedAt
+	}
+
+	if err := insertOutboxEvent(ctx, tx, evt); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit ride creation: %w", err)
+	}
+
 	return nil
 }
 
@@ -83,11 +140,12 @@ func (r *Repository) GetRideByID(ctx context.Context, id uuid.UUID) (*models.Rid
 			   pickup_address, dropoff_latitude, dropoff_longitude, dropoff_address,
 			   estimated_distance, estimated_duration, estimated_fare, actual_distance,
 			   actual_duration, final_fare, surge_multiplier, requested_at, accepted_at,
-			   started_at, completed_at, cancelled_at, cancellation_reason, rating,
+			   arrived_at, started_at, completed_at, cancelled_at, cancellation_reason, rating,
 			   feedback, created_at, updated_at, ride_type_id, promo_code_id,
 			   discount_amount, scheduled_at, is_scheduled, scheduled_notification_sent,
 			   country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			   payment_method
 		FROM rides
 		WHERE id = $1
 	`
@@ -113,6 +171,7 @@ func (r *Repository) GetRideByID(ctx context.Context, id uuid.UUID) (*models.Rid
 		&ride.SurgeMultiplier,
 		&ride.RequestedAt,
 		&ride.AcceptedAt,
+		&ride.ArrivedAt,
 		&ride.StartedAt,
 		&ride.CompletedAt,
 		&ride.CancelledAt,
@@ -136,6 +195,7 @@ func (r *Repository) GetRideByID(ctx context.Context, id uuid.UUID) (*models.Rid
 		&ride.PricingVersionID,
 		&ride.WasNegotiated,
 		&ride.NegotiationSessionID,
+		&ride.PaymentMethod,
 	)
 
 	if err != nil {
@@ -155,6 +215,9 @@ func (r *Repository) UpdateRideStatus(ctx context.Context, id uuid.UUID, status
 	case models.RideStatusAccepted:
 		query = `UPDATE rides SET status = $1, driver_id = $2, accepted_at = $3, updated_at = $4 WHERE id = $5`
 		args = []interface{}{status, driverID, now, now, id}
+	case models.RideStatusDriverArrived:
+		query = `UPDATE rides SET status = $1, arrived_at = $2, updated_at = $3 WHERE id = $4`
+		args = []interface{}{status, now, now, id}
 	case models.RideStatusInProgress:
 		query = `UPDATE rides SET status = $1, started_at = $2, updated_at = $3 WHERE id = $4`
 		args = []interface{}{status, now, now, id}
@@ -180,20 +243,56 @@ func (r *Repository) UpdateRideStatus(ctx context.Context, id uuid.UUID, status
 // AtomicAcceptRide atomically transitions a ride from "requested" to "accepted"
 // in a single UPDATE with a WHERE status guard. Returns false if the ride was
 // already accepted by another driver (prevents double-accept race condition).
-func (r *Repository) AtomicAcceptRide(ctx context.Context, rideID, driverID uuid.UUID) (bool, error) {
-	now := time.Now()
+// evt, when non-nil, is staged in the outbox only if the transition applies.
+func (r *Repository) AtomicAcceptRide(ctx context.Context, rideID, driverID uuid.UUID, acceptedAt time.Time, evt *OutboxEvent) (bool, error) {
 	query := `
 		UPDATE rides
 		SET status = $1, driver_id = $2, accepted_at = $3, updated_at = $3
-		WHERE id = $4 AND status = $5
+		WHERE id = $4 AND status = ANY($5)
 	`
-	tag, err := r.db.Exec(ctx, query,
-		models.RideStatusAccepted, driverID, now, rideID, models.RideStatusRequested,
+	ok, err := r.execRideTransition(ctx, evt, query,
+		models.RideStatusAccepted, driverID, acceptedAt, rideID, FromStatuses(models.RideStatusAccepted),
 	)
 	if err != nil {
 		return false, fmt.Errorf("failed to accept ride: %w", err)
 	}
-	return tag.RowsAffected() == 1, nil
+	return ok, nil
+}
+
+// AtomicMarkDriverArrived transitions an accepted ride to "driver_arrived" and
+// records the arrival time, with a status+driver guard. Returns false if the
+// guard rejected the update.
+func (r *Repository) AtomicMarkDriverArrived(ctx context.Context, rideID, driverID uuid.UUID, arrivedAt time.Time, evt *OutboxEvent) (bool, error) {
+	query := `
+		UPDATE rides
+		SET status = $1, arrived_at = $2, updated_at = $2
+		WHERE id = $3 AND status = ANY($4) AND driver_id = $5
+	`
+	ok, err := r.execRideTransition(ctx, evt, query,
+		models.RideStatusDriverArrived, arrivedAt, rideID, FromStatuses(models.RideStatusDriverArrived), driverID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark driver arrived: %w", err)
+	}
+	return ok, nil
+}
+
+// AtomicStartRide transitions an accepted or driver_arrived ride to
+// "in_progress" with a status+driver guard. Returns false if the guard
+// rejected the update.
+func (r *Repository) AtomicStartRide(ctx context.Context, rideID, driverID uuid.UUID, startedAt time.Time, evt *OutboxEvent) (bool, error) {
+	query := `
+		UPDATE rides
+		SET status = $1, started_at = $2, updated_at = $2
+		WHERE id = $3 AND status = ANY($4) AND driver_id = $5
+	`
+	ok, err := r.execRideTransition(ctx, evt, query,
+		models.RideStatusInProgress, startedAt, rideID, FromStatuses(models.RideStatusInProgress), driverID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to start ride: %w", err)
+	}
+	return ok, nil
 }
 
 // UpdateRideCompletion updates ride with actual data upon completion
@@ -212,25 +311,156 @@ func (r *Repository) UpdateRideCompletion(ctx context.Context, id uuid.UUID, act
 	return nil
 }
 
-// AtomicCompleteRide atomically sets completion data and status in a single UPDATE
-// with a status+driver guard. Returns false if the ride was not in_progress or
-// the driver doesn't match (prevents inconsistent state on partial failure).
-func (r *Repository) AtomicCompleteRide(ctx context.Context, rideID, driverID uuid.UUID, actualDistance float64, actualDuration int, finalFare float64) (bool, error) {
-	now := time.Now()
-	query := `
+// RideCompletion is what completing a ride writes
+type RideCompletion struct {
+	ActualDistance float64
+	ActualDuration int
+	FinalFare      float64
+	CompletedAt    time.Time
+	DiscountAmount *float64 // settled discount total; nil keeps the promo discount the ride was requested with
+	DiscountLines  []*models.RideDiscountLine
+	QuoteRelease   string // why the locked fare quote no longer holds; empty keeps it locked
+	Event          *OutboxEvent
+}
+
+// AtomicCompleteRide completes an in_progress ride assigned to driverID. The
+// ride row is locked first and prepare is called while the lock is held, so
+// work done in prepare (such as spending the rider's discounts) happens at
+// most once per ride; a concurrent completion waits and then finds the ride
+// completed. The completion, its discount lines, the release of its fare
+// quote and its event are written in the same transaction. Returns false, without calling prepare, if the ride
+// was not in_progress or the driver doesn't match.
+func (r *Repository) AtomicCompleteRide(ctx context.Context, rideID, driverID uuid.UUID, prepare func(ctx context.Context) (*RideCompletion, error)) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// NO KEY UPDATE still lets prepare insert rows referencing the ride
+	var locked uuid.UUID
+	err = tx.QueryRow(ctx, `
+		SELECT id FROM rides
+		WHERE id = $1 AND status = ANY($2) AND driver_id = $3
+		FOR NO KEY UPDATE`,
+		rideID, FromStatuses(models.RideStatusCompleted), driverID,
+	).Scan(&locked)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock ride: %w", err)
+	}
+
+	c, err := prepare(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx, `
 		UPDATE rides
 		SET status = $1, actual_distance = $2, actual_duration = $3,
-		    final_fare = $4, completed_at = $5, updated_at = $5
-		WHERE id = $6 AND status = $7 AND driver_id = $8
-	`
-	tag, err := r.db.Exec(ctx, query,
-		models.RideStatusCompleted, actualDistance, actualDuration, finalFare,
-		now, rideID, models.RideStatusInProgress, driverID,
+		    final_fare = $4, completed_at = $5, updated_at = $5,
+		    discount_amount = COALESCE($6, discount_amount)
+		WHERE id = $7`,
+		models.RideStatusCompleted, c.ActualDistance, c.ActualDuration, c.FinalFare,
+		c.CompletedAt, c.DiscountAmount, rideID,
 	)
 	if err != nil {
 		return false, fmt.Errorf("failed to complete ride: %w", err)
 	}
-	return tag.RowsAffected() == 1, nil
+
+	for _, line := range c.DiscountLines {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO ride_discount_lines (id, ride_id, position, source, line_type, label, amount, reference_id, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			line.ID, rideID, line.Position, line.Source, line.LineType, line.Label, line.Amount, line.ReferenceID, line.CreatedAt,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to insert ride discount line: %w", err)
+		}
+	}
+
+	if c.QuoteRelease != "" {
+		_, err := tx.Exec(ctx, `
+			UPDATE ride_fare_quotes
+			SET locked = false, release_reason = $1, released_at = $2
+			WHERE ride_id = $3 AND locked`,
+			c.QuoteRelease, c.CompletedAt, rideID,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to release ride fare quote: %w", err)
+		}
+	}
+
+	if err := insertOutboxEvent(ctx, tx, c.Event); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
+
+// AtomicCancelRide cancels a ride that is not already completed or cancelled.
+// Returns false if the ride reached a terminal state first.
+func (r *Repository) AtomicCancelRide(ctx context.Context, rideID uuid.UUID, reason string, cancelledAt time.Time, evt *OutboxEvent) (bool, error) {
+	return r.cancelRideFrom(ctx, rideID, reason, cancelledAt, FromStatuses(models.RideStatusCancelled), evt)
+}
+
+// AtomicCancelUnstartedRide cancels a ride that has not started yet. Returns
+// false if the trip already began or the ride reached a terminal state.
+func (r *Repository) AtomicCancelUnstartedRide(ctx context.Context, rideID uuid.UUID, reason string, cancelledAt time.Time, evt *OutboxEvent) (bool, error) {
+	from := []string{
+		string(models.RideStatusRequested),
+		string(models.RideStatusAccepted),
+		string(models.RideStatusDriverArrived),
+	}
+	return r.cancelRideFrom(ctx, rideID, reason, cancelledAt, from, evt)
+}
+
+func (r *Repository) cancelRideFrom(ctx context.Context, rideID uuid.UUID, reason string, cancelledAt time.Time, from []string, evt *OutboxEvent) (bool, error) {
+	query := `
+		UPDATE rides
+		SET status = $1, cancellation_reason = $2, cancelled_at = $3, updated_at = $3
+		WHERE id = $4 AND status = ANY($5)
+	`
+	ok, err := r.execRideTransition(ctx, evt, query,
+		models.RideStatusCancelled, reason, cancelledAt, rideID, from,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel ride: %w", err)
+	}
+	return ok, nil
+}
+
+// execRideTransition runs a guarded ride UPDATE and, when it affects exactly one
+// row, stages evt in the outbox within the same transaction. Returns false (and
+// writes nothing) when the guard rejected the update.
+func (r *Repository) execRideTransition(ctx context.Context, evt *OutboxEvent, query string, args ...interface{}) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+
+	if err := insertOutboxEvent(ctx, tx, evt); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
 }
 
 // UpdateRideRating updates ride rating and feedback
@@ -256,11 +486,12 @@ func (r *Repository) GetRidesByRider(ctx context.Context, riderID uuid.UUID, lim
 			   pickup_address, dropoff_latitude, dropoff_longitude, dropoff_address,
 			   estimated_distance, estimated_duration, estimated_fare, actual_distance,
 			   actual_duration, final_fare, surge_multiplier, requested_at, accepted_at,
-			   started_at, completed_at, cancelled_at, cancellation_reason, rating,
+			   arrived_at, started_at, completed_at, cancelled_at, cancellation_reason, rating,
 			   feedback, created_at, updated_at, ride_type_id, promo_code_id,
 			   discount_amount, scheduled_at, is_scheduled, scheduled_notification_sent,
 			   country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			   payment_method
 		FROM rides
 		WHERE rider_id = $1
 		ORDER BY created_at DESC
@@ -296,6 +527,7 @@ func (r *Repository) GetRidesByRider(ctx context.Context, riderID uuid.UUID, lim
 			&ride.SurgeMultiplier,
 			&ride.RequestedAt,
 			&ride.AcceptedAt,
+			&ride.ArrivedAt,
 			&ride.StartedAt,
 			&ride.CompletedAt,
 			&ride.CancelledAt,
@@ -319,6 +551,7 @@ func (r *Repository) GetRidesByRider(ctx context.Context, riderID uuid.UUID, lim
 			&ride.PricingVersionID,
 			&ride.WasNegotiated,
 			&ride.NegotiationSessionID,
+			&ride.PaymentMethod,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan ride: %w", err)
@@ -336,11 +569,12 @@ func (r *Repository) GetRidesByDriver(ctx context.Context, driverID uuid.UUID, l
 			   pickup_address, dropoff_latitude, dropoff_longitude, dropoff_address,
 			   estimated_distance, estimated_duration, estimated_fare, actual_distance,
 			   actual_duration, final_fare, surge_multiplier, requested_at, accepted_at,
-			   started_at, completed_at, cancelled_at, cancellation_reason, rating,
+			   arrived_at, started_at, completed_at, cancelled_at, cancellation_reason, rating,
 			   feedback, created_at, updated_at, ride_type_id, promo_code_id,
 			   discount_amount, scheduled_at, is_scheduled, scheduled_notification_sent,
 			   country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			   payment_method
 		FROM rides
 		WHERE driver_id = $1
 		ORDER BY created_at DESC
@@ -376,6 +610,7 @@ func (r *Repository) GetRidesByDriver(ctx context.Context, driverID uuid.UUID, l
 			&ride.SurgeMultiplier,
 			&ride.RequestedAt,
 			&ride.AcceptedAt,
+			&ride.ArrivedAt,
 			&ride.StartedAt,
 			&ride.CompletedAt,
 			&ride.CancelledAt,
@@ -399,6 +634,7 @@ func (r *Repository) GetRidesByDriver(ctx context.Context, driverID uuid.UUID, l
 			&ride.PricingVersionID,
 			&ride.WasNegotiated,
 			&ride.NegotiationSessionID,
+			&ride.PaymentMethod,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan ride: %w", err)
@@ -454,11 +690,12 @@ func (r *Repository) GetPendingRides(ctx context.Context) ([]*models.Ride, error
 			   pickup_address, dropoff_latitude, dropoff_longitude, dropoff_address,
 			   estimated_distance, estimated_duration, estimated_fare, actual_distance,
 			   actual_duration, final_fare, surge_multiplier, requested_at, accepted_at,
-			   started_at, completed_at, cancelled_at, cancellation_reason, rating,
+			   arrived_at, started_at, completed_at, cancelled_at, cancellation_reason, rating,
 			   feedback, created_at, updated_at, ride_type_id, promo_code_id,
 			   discount_amount, scheduled_at, is_scheduled, scheduled_notification_sent,
 			   country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			   payment_method
 		FROM rides
 		WHERE status = 'requested'
 		ORDER BY requested_at ASC
@@ -493,6 +730,7 @@ func (r *Repository) GetPendingRides(ctx context.Context) ([]*models.Ride, error
 			&ride.SurgeMultiplier,
 			&ride.RequestedAt,
 			&ride.AcceptedAt,
+			&ride.ArrivedAt,
 			&ride.StartedAt,
 			&ride.CompletedAt,
 			&ride.CancelledAt,
@@ -516,6 +754,7 @@ func (r *Repository) GetPendingRides(ctx context.Context) ([]*models.Ride, error
 			&ride.PricingVersionID,
 			&ride.WasNegotiated,
 			&ride.NegotiationSessionID,
+			&ride.PaymentMethod,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan ride: %w", err)
@@ -541,11 +780,12 @@ func (r *Repository) GetRidesByRiderWithFilters(ctx context.Context, riderID uui
 			   pickup_address, dropoff_latitude, dropoff_longitude, dropoff_address,
 			   estimated_distance, estimated_duration, estimated_fare, actual_distance,
 			   actual_duration, final_fare, surge_multiplier, requested_at, accepted_at,
-			   started_at, completed_at, cancelled_at, cancellation_reason, rating,
+			   arrived_at, started_at, completed_at, cancelled_at, cancellation_reason, rating,
 			   feedback, created_at, updated_at, ride_type_id, promo_code_id,
 			   discount_amount, scheduled_at, is_scheduled, scheduled_notification_sent,
 			   country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			   payment_method
 		FROM rides
 		WHERE rider_id = $1
 	`
@@ -618,6 +858,7 @@ func (r *Repository) GetRidesByRiderWithFilters(ctx context.Context, riderID uui
 			&ride.SurgeMultiplier,
 			&ride.RequestedAt,
 			&ride.AcceptedAt,
+			&ride.ArrivedAt,
 			&ride.StartedAt,
 			&ride.CompletedAt,
 			&ride.CancelledAt,
@@ -641,6 +882,7 @@ func (r *Repository) GetRidesByRiderWithFilters(ctx context.Context, riderID uui
 			&ride.PricingVersionID,
 			&ride.WasNegotiated,
 			&ride.NegotiationSessionID,
+			&ride.PaymentMethod,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan ride: %w", err)
@@ -658,11 +900,12 @@ func (r *Repository) GetRidesByDriverWithFilters(ctx context.Context, driverID u
 			   pickup_address, dropoff_latitude, dropoff_longitude, dropoff_address,
 			   estimated_distance, estimated_duration, estimated_fare, actual_distance,
 			   actual_duration, final_fare, surge_multiplier, requested_at, accepted_at,
-			   started_at, completed_at, cancelled_at, cancellation_reason, rating,
+			   arrived_at, started_at, completed_at, cancelled_at, cancellation_reason, rating,
 			   feedback, created_at, updated_at, ride_type_id, promo_code_id,
 			   discount_amount, scheduled_at, is_scheduled, scheduled_notification_sent,
 			   country_id, region_id, city_id, pickup_zone_id, dropoff_zone_id,
-			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id
+			   currency_code, pricing_version_id, was_negotiated, negotiation_session_id,
+			   payment_method
 		FROM rides
 		WHERE driver_id = $1
 	`
@@ -718,13 +961,14 @@ func (r *Repository) GetRidesByDriverWithFilters(ctx context.Context, driverID u
 			&ride.EstimatedDistance, &ride.EstimatedDuration, &ride.EstimatedFare,
 			&ride.ActualDistance, &ride.ActualDuration, &ride.FinalFare,
 			&ride.SurgeMultiplier, &ride.RequestedAt, &ride.AcceptedAt,
-			&ride.StartedAt, &ride.CompletedAt, &ride.CancelledAt, &ride.CancellationReason,
+			&ride.ArrivedAt, &ride.StartedAt, &ride.CompletedAt, &ride.CancelledAt, &ride.CancellationReason,
 			&ride.Rating, &ride.Feedback, &ride.CreatedAt, &ride.UpdatedAt,
 			&ride.RideTypeID, &ride.PromoCodeID, &ride.DiscountAmount,
 			&ride.ScheduledAt, &ride.IsScheduled, &ride.ScheduledNotificationSent,
 			&ride.CountryID, &ride.RegionID, &ride.CityID,
 			&ride.PickupZoneID, &ride.DropoffZoneID, &ride.CurrencyCode,
 			&ride.PricingVersionID, &ride.WasNegotiated, &ride.NegotiationSessionID,
+			&ride.PaymentMethod,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan ride: %w", err)
@@ -788,13 +1032,19 @@ func (r *Repository) UpdateUserProfile(ctx context.Context, userID uuid.UUID, fi
 
 // DriverMatchStats holds aggregated stats used by the matching algorithm.
 type DriverMatchStats struct {
-	DriverID       uuid.UUID
-	Rating         float64
-	AcceptanceRate float64
-	IdleMinutes    float64
+	DriverID         uuid.UUID
+	Rating           float64
+	AcceptanceRate   float64
+	IdleMinutes      float64
+	IgnoredOfferRate float64
 }
 
+// minOfferSample is the number of resolved offers needed before a driver's
+// offer responses replace the ride-based acceptance estimate.
+const minOfferSample = 10
+
 // GetDriverMatchStats returns acceptance rate and idle time for a list of driver IDs.
+// Drivers with enough resolved offers are scored on how they answered offers.
 func (r *Repository) GetDriverMatchStats(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]*DriverMatchStats, error) {
 	if len(driverIDs) == 0 {
 		return make(map[uuid.UUID]*DriverMatchStats), nil
@@ -805,18 +1055,22 @@ func (r *Repository) GetDriverMatchStats(ctx context.Context, driverIDs []uuid.U
 			u.id,
 			COALESCE(u.rating, 4.0) AS rating,
 			COALESCE(
-				CAST(SUM(CASE WHEN r.status IN ('accepted','started','completed') THEN 1 ELSE 0 END) AS FLOAT) /
+				CAST(SUM(CASE WHEN r.status IN ('accepted','driver_arrived','in_progress','completed') THEN 1 ELSE 0 END) AS FLOAT) /
 				NULLIF(COUNT(r.id), 0),
 				0.8
 			) AS acceptance_rate,
 			COALESCE(
 				EXTRACT(EPOCH FROM (NOW() - MAX(r.completed_at))) / 60.0,
 				30.0
-			) AS idle_minutes
+			) AS idle_minutes,
+			COALESCE(dos.offers_accepted, 0),
+			COALESCE(dos.offers_declined, 0),
+			COALESCE(dos.offers_expired, 0)
 		FROM users u
 		LEFT JOIN rides r ON r.driver_id = u.id AND r.created_at > NOW() - INTERVAL '30 days'
+		LEFT JOIN driver_offer_stats dos ON dos.driver_id = u.id
 		WHERE u.id = ANY($1)
-		GROUP BY u.id, u.rating
+		GROUP BY u.id, u.rating, dos.offers_accepted, dos.offers_declined, dos.offers_expired
 	`
 
 	rows, err := r.db.Query(ctx, query, driverIDs)
@@ -828,9 +1082,15 @@ func (r *Repository) GetDriverMatchStats(ctx context.Context, driverIDs []uuid.U
 	stats := make(map[uuid.UUID]*DriverMatchStats, len(driverIDs))
 	for rows.Next() {
 		s := &DriverMatchStats{}
-		if err := rows.Scan(&s.DriverID, &s.Rating, &s.AcceptanceRate, &s.IdleMinutes); err != nil {
+		offers := &models.DriverOfferStats{}
+		if err := rows.Scan(&s.DriverID, &s.Rating, &s.AcceptanceRate, &s.IdleMinutes,
+			&offers.OffersAccepted, &offers.OffersDeclined, &offers.OffersExpired); err != nil {
 			return nil, fmt.Errorf("failed to scan driver stats: %w", err)
 		}
+		if offers.Resolved() >= minOfferSample {
+			s.AcceptanceRate = offers.AcceptanceRate()
+			s.IgnoredOfferRate = offers.IgnoredRate()
+		}
 		stats[s.DriverID] = s
 	}
 
@@ -849,6 +1109,115 @@ func (r *Repository) GetDriverMatchStats(ctx context.Context, driverIDs []uuid.U
 	return stats, nil
 }
 
+// GetDriverOfferStats returns how a driver has responded to ride offers.
+// Drivers who have never been offered a ride get zero counts.
+func (r *Repository) GetDriverOfferStats(ctx context.Context, driverID uuid.UUID) (*models.DriverOfferStats, error) {
+	query := `
+		SELECT driver_id, offers_sent, offers_accepted, offers_declined, offers_expired,
+			consecutive_ignored, cooldown_until, last_offer_at, updated_at
+		FROM driver_offer_stats
+		WHERE driver_id = $1
+	`
+
+	stats := &models.DriverOfferStats{}
+	err := r.db.QueryRow(ctx, query, driverID).Scan(
+		&stats.DriverID, &stats.OffersSent, &stats.OffersAccepted, &stats.OffersDeclined, &stats.OffersExpired,
+		&stats.ConsecutiveIgnored, &stats.CooldownUntil, &stats.LastOfferAt, &stats.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &models.DriverOfferStats{DriverID: driverID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver offer stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetDriverEquipment returns what each driver can offer a ride: their primary
+// approved vehicle combined with the capabilities they declared. Drivers
+// without such a vehicle are returned with HasVehicle false.
+func (r *Repository) GetDriverEquipment(ctx context.Context, driverIDs []uuid.UUID) (map[uuid.UUID]*vehicle.DriverEquipment, error) {
+	equipment := make(map[uuid.UUID]*vehicle.DriverEquipment, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return equipment, nil
+	}
+
+	query := `
+		SELECT
+			d.id,
+			v.id IS NOT NULL,
+			COALESCE(v.category, ''),
+			COALESCE(v.max_passengers, 0),
+			COALESCE(v.has_wheelchair_access, false) OR COALESCE(dc.wheelchair_access, false),
+			COALESCE(v.has_child_seat, false) OR COALESCE(dc.has_child_seat, false),
+			COALESCE(v.pet_friendly, false) OR COALESCE(dc.pet_friendly, false)
+		FROM UNNEST($1::uuid[]) AS d(id)
+		LEFT JOIN vehicles v ON v.driver_id = d.id
+			AND v.is_primary = true AND v.is_active = true AND v.status = 'approved'
+		LEFT JOIN driver_capabilities dc ON dc.driver_id = d.id
+	`
+
+	rows, err := r.db.Query(ctx, query, driverIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get driver equipment: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var driverID uuid.UUID
+		var category string
+		e := &vehicle.DriverEquipment{}
+		if err := rows.Scan(&driverID, &e.HasVehicle, &category, &e.MaxPassengers,
+			&e.WheelchairAccess, &e.ChildSeat, &e.PetFriendly); err != nil {
+			return nil, fmt.Errorf("failed to scan driver equipment: %w", err)
+		}
+		e.Category = vehicle.VehicleCategory(category)
+		equipment[driverID] = e
+	}
+
+	return equipment, rows.Err()
+}
+
+// GetRideRequirements returns what a ride needs from the driver's vehicle:
+// the ride type's vehicle categories and capacity, plus the rider's
+// accessibility, child seat, pet and party size preferences. A nil ride type
+// places no category or capacity limit.
+func (r *Repository) GetRideRequirements(ctx context.Context, riderID uuid.UUID, rideTypeID *uuid.UUID) (*vehicle.RideRequirements, error) {
+	query := `
+		SELECT
+			COALESCE(rt.vehicle_categories, '{}'),
+			COALESCE(rt.capacity, 0),
+			COALESCE(rp.max_passengers, 0),
+			COALESCE(rp.wheelchair_access, false),
+			COALESCE(rp.child_seat, false),
+			COALESCE(rp.pet_friendly, false)
+		FROM (SELECT 1) AS one
+		LEFT JOIN ride_types rt ON rt.id = $1
+		LEFT JOIN rider_preferences rp ON rp.user_id = $2
+	`
+
+	var categories []string
+	var capacity, partySize int
+	req := &vehicle.RideRequirements{}
+	err := r.db.QueryRow(ctx, query, rideTypeID, riderID).Scan(
+		&categories, &capacity, &partySize, &req.WheelchairAccess, &req.ChildSeat, &req.PetFriendly,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride requirements: %w", err)
+	}
+
+	for _, c := range categories {
+		req.Categories = append(req.Categories, vehicle.VehicleCategory(c))
+	}
+	req.MinPassengers = capacity
+	if partySize > req.MinPassengers {
+		req.MinPassengers = partySize
+	}
+
+	return req, nil
+}
+
 // GetPaymentByRideID retrieves payment information for a ride
 func (r *Repository) GetPaymentByRideID(ctx context.Context, rideID uuid.UUID) (string, error) {
 	query := `SELECT method FROM payments WHERE ride_id = $1 LIMIT 1`
@@ -861,3 +1230,312 @@ func (r *Repository) GetPaymentByRideID(ctx context.Context, rideID uuid.UUID) (
 
 	return method, nil
 }
+
+// ========================================
+// RIDE STOPS
+// ========================================
+
+// GetRideStops returns the intermediate stops of a ride in visiting order
+func (r *Repository) GetRideStops(ctx context.Context, rideID uuid.UUID) ([]*models.RideStop, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, ride_id, stop_order, latitude, longitude, address, status,
+		       wait_minutes, added_mid_trip, arrived_at, departed_at, created_at
+		FROM ride_stops
+		WHERE ride_id = $1
+		ORDER BY stop_order`,
+		rideID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride stops: %w", err)
+	}
+	defer rows.Close()
+
+	var stops []*models.RideStop
+	for rows.Next() {
+		stop := &models.RideStop{}
+		if err := rows.Scan(
+			&stop.ID, &stop.RideID, &stop.StopOrder, &stop.Latitude, &stop.Longitude,
+			&stop.Address, &stop.Status, &stop.WaitMinutes, &stop.AddedMidTrip,
+			&stop.ArrivedAt, &stop.DepartedAt, &stop.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ride stop: %w", err)
+		}
+		stops = append(stops, stop)
+	}
+	return stops, rows.Err()
+}
+
+// GetRideFareQuote returns the quote a ride was requested with, or nil if it
+// was requested without one
+func (r *Repository) GetRideFareQuote(ctx context.Context, rideID uuid.UUID) (*models.RideFareQuote, error) {
+	fq := &models.RideFareQuote{}
+	err := r.db.QueryRow(ctx, `
+		SELECT ride_id, quote_id, quoted_fare, currency_code, surge_multiplier,
+		       distance_km, duration_minutes, pricing_version_id, issued_at, expires_at,
+		       locked, release_reason, released_at, created_at
+		FROM ride_fare_quotes
+		WHERE ride_id = $1`,
+		rideID,
+	).Scan(
+		&fq.RideID, &fq.QuoteID, &fq.QuotedFare, &fq.CurrencyCode, &fq.SurgeMultiplier,
+		&fq.DistanceKm, &fq.DurationMinutes, &fq.PricingVersionID, &fq.IssuedAt, &fq.ExpiresAt,
+		&fq.Locked, &fq.ReleaseReason, &fq.ReleasedAt, &fq.CreatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride fare quote: %w", err)
+	}
+	return fq, nil
+}
+
+// ReleaseRideFareQuote unlocks a ride's fare quote, recording why it no
+// longer applies. It does nothing if the quote is already released or the
+// ride has none.
+func (r *Repository) ReleaseRideFareQuote(ctx context.Context, rideID uuid.UUID, reason string, releasedAt time.Time) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE ride_fare_quotes
+		SET locked = false, release_reason = $1, released_at = $2
+		WHERE ride_id = $3 AND locked`,
+		reason, releasedAt, rideID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to release ride fare quote: %w", err)
+	}
+	return nil
+}
+
+// GetRideDiscountLines returns the reductions settled against a ride's fare
+// in the order they applied
+func (r *Repository) GetRideDiscountLines(ctx context.Context, rideID uuid.UUID) ([]*models.RideDiscountLine, error) {
+	rows, err := r.db.Query(ctx, `
+		SELECT id, ride_id, position, source, line_type, label, amount, reference_id, created_at
+		FROM ride_discount_lines
+		WHERE ride_id = $1
+		ORDER BY position`,
+		rideID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ride discount lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*models.RideDiscountLine
+	for rows.Next() {
+		line := &models.RideDiscountLine{}
+		if err := rows.Scan(
+			&line.ID, &line.RideID, &line.Position, &line.Source, &line.LineType,
+			&line.Label, &line.Amount, &line.ReferenceID, &line.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan ride discount line: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
+// AddRideStop inserts a stop at stop.StopOrder and updates the ride's
+// estimates in one transaction. When evt is non-nil it is staged in the outbox
+// with the change. Returns false if the ride is no longer active or another
+// stop took the same position first.
+func (r *Repository) AddRideStop(ctx context.Context, stop *models.RideStop, estimatedDistance float64, estimatedDuration int, estimatedFare float64, evt *OutboxEvent) (bool, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE rides
+		SET estimated_distance = $1, estimated_duration = $2, estimated_fare = $3, updated_at = $4
+		WHERE id = $5 AND status = ANY($6)`,
+		estimatedDistance, estimatedDuration, estimatedFare, stop.CreatedAt, stop.RideID, activeStatuses(),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update ride estimates: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+
+	tag, err = tx.Exec(ctx, `
+		INSERT INTO ride_stops (id, ride_id, stop_order, latitude, longitude, address, status, added_mid_trip, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (ride_id, stop_order) DO NOTHING`,
+		stop.ID, stop.RideID, stop.StopOrder, stop.Latitude, stop.Longitude, stop.Address,
+		stop.Status, stop.AddedMidTrip, stop.CreatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to add ride stop: %w", err)
+	}
+	if tag.RowsAffected() != 1 {
+		return false, nil
+	}
+
+	if err := insertOutboxEvent(ctx, tx, evt); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
+
+// UpdateRideStopStatus moves a stop to a new status if it is currently in one
+// of the from statuses, staging evt in the outbox with the change. Returns
+// false if the guard rejected the update.
+func (r *Repository) UpdateRideStopStatus(ctx context.Context, stop *models.RideStop, from []string, evt *OutboxEvent) (bool, error) {
+	query := `
+		UPDATE ride_stops
+		SET status = $1, arrived_at = $2, departed_at = $3, wait_minutes = $4
+		WHERE id = $5 AND ride_id = $6 AND status = ANY($7)
+	`
+	ok, err := r.execRideTransition(ctx, evt, query,
+		stop.Status, stop.ArrivedAt, stop.DepartedAt, stop.WaitMinutes, stop.ID, stop.RideID, from,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update ride stop: %w", err)
+	}
+	return ok, nil
+}
+
+// ========================================
+// EVENT OUTBOX
+// ========================================
+
+// insertOutboxEvent stages evt in ride_event_outbox using tx. A nil evt is a no-op.
+func insertOutboxEvent(ctx context.Context, tx pgx.Tx, evt *OutboxEvent) error {
+	if evt == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(evt.Event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox event: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO ride_event_outbox (event_id, aggregate_id, subject, event_type, payload)
+		VALUES ($1, $2, $3, $4, $5)`,
+		evt.Event.ID, evt.AggregateID, evt.Subject, evt.Event.Type, payload,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event: %w", err)
+	}
+	return nil
+}
+
+// ClaimOutboxEvents leases up to limit due events for publishing. Only the
+// oldest unpublished event of each ride is eligible, so concurrent relays can
+// never publish a ride's events out of order. Claimed rows are hidden from
+// other relays until the lease expires and their attempt counter is bumped.
+func (r *Repository) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]*OutboxEvent, error) {
+	query := `
+		WITH candidates AS (
+			SELECT o.seq
+			FROM ride_event_outbox o
+			WHERE o.published_at IS NULL
+			  AND o.next_attempt_at <= NOW()
+			  AND NOT EXISTS (
+				SELECT 1 FROM ride_event_outbox p
+				WHERE p.aggregate_id = o.aggregate_id
+				  AND p.published_at IS NULL
+				  AND p.seq < o.seq
+			  )
+			ORDER BY o.seq
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE ride_event_outbox o
+		SET attempts = o.attempts + 1,
+		    next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM candidates c
+		WHERE o.seq = c.seq
+		RETURNING o.seq, o.aggregate_id, o.subject, o.payload, o.attempts, o.created_at
+	`
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*OutboxEvent, 0)
+	for rows.Next() {
+		evt := &OutboxEvent{}
+		var payload []byte
+		if err := rows.Scan(&evt.Seq, &evt.AggregateID, &evt.Subject, &payload, &evt.Attempts, &evt.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		evt.Event = &eventbus.Event{}
+		if err := json.Unmarshal(payload, evt.Event); err != nil {
+			return nil, fmt.Errorf("failed to decode outbox event %d: %w", evt.Seq, err)
+		}
+		events = append(events, evt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate outbox events: %w", err)
+	}
+
+	// RETURNING does not guarantee order; publish in commit order
+	sort.Slice(events, func(i, j int) bool { return events[i].Seq < events[j].Seq })
+
+	return events, nil
+}
+
+// MarkOutboxEventPublished records a successful publish.
+func (r *Repository) MarkOutboxEventPublished(ctx context.Context, seq int64) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE ride_event_outbox SET published_at = NOW(), last_error = NULL WHERE seq = $1`,
+		seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+// MarkOutboxEventFailed records a failed publish and schedules the next attempt.
+func (r *Repository) MarkOutboxEventFailed(ctx context.Context, seq int64, errMsg string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(ctx,
+		`UPDATE ride_event_outbox SET last_error = $1, next_attempt_at = $2 WHERE seq = $3`,
+		errMsg, nextAttemptAt, seq,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event failed: %w", err)
+	}
+	return nil
+}
+
+// GetOutboxStats returns the size and age of the unpublished backlog.
+func (r *Repository) GetOutboxStats(ctx context.Context, stuckAttempts int) (*OutboxStats, error) {
+	query := `
+		SELECT COUNT(*),
+		       COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0),
+		       COUNT(*) FILTER (WHERE attempts >= $1)
+		FROM ride_event_outbox
+		WHERE published_at IS NULL
+	`
+
+	stats := &OutboxStats{}
+	var oldestSeconds float64
+	if err := r.db.QueryRow(ctx, query, stuckAttempts).Scan(&stats.Pending, &oldestSeconds, &stats.StuckCount); err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+	stats.OldestAge = time.Duration(oldestSeconds * float64(time.Second))
+	return stats, nil
+}
+
+// PurgePublishedOutboxEvents deletes published rows older than before.
+func (r *Repository) PurgePublishedOutboxEvents(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx,
+		`DELETE FROM ride_event_outbox WHERE published_at IS NOT NULL AND published_at < $1`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge outbox events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
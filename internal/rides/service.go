@@ -3,11 +3,15 @@ package rides
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/discounts"
 	"github.com/richxcame/ride-hailing/internal/pricing"
 	"github.com/richxcame/ride-hailing/pkg/common"
 	"github.com/richxcame/ride-hailing/pkg/eventbus"
@@ -48,11 +52,14 @@ type Service struct {
 	mlEtaClient         *httpclient.Client
 	mlEtaBreaker        *resilience.CircuitBreaker
 	matcher             *Matcher
-	eventBus            *eventbus.Bus
+	outboxEnabled       bool
 	pricingConfig       *PricingConfig
+	fareLockConfig      *FareLockConfig
 	pricingService      *pricing.Service
 	locationResolver    LocationResolver
 	rideTypeNameFetcher func(ctx context.Context, id uuid.UUID) (string, error)
+	waypointOptimizer   WaypointOptimizer
+	discounts           *discounts.Orchestrator
 }
 
 // SurgeCalculator defines the interface for surge pricing calculation
@@ -97,6 +104,7 @@ func NewService(repo *Repository, promosServiceURL string, breaker *resilience.C
 		promosBreaker:   breaker,
 		surgeCalculator: nil, // Will be set via SetSurgeCalculator
 		pricingConfig:   DefaultPricingConfig(),
+		fareLockConfig:  DefaultFareLockConfig(),
 	}
 }
 
@@ -117,9 +125,12 @@ func (s *Service) SetMatcher(matcher *Matcher) {
 	s.matcher = matcher
 }
 
-// SetEventBus sets the NATS event bus for publishing ride lifecycle events.
-func (s *Service) SetEventBus(bus *eventbus.Bus) {
-	s.eventBus = bus
+// EnableEventOutbox turns on staging of ride lifecycle events in the
+// transactional outbox. An OutboxRelay must be running to deliver them; it
+// holds them until the bus is reachable, so the outbox is enabled whether or
+// not the bus is up at startup.
+func (s *Service) EnableEventOutbox() {
+	s.outboxEnabled = true
 }
 
 // SetPricingService sets the hierarchical pricing service for fare calculation.
@@ -139,31 +150,55 @@ func (s *Service) SetRideTypeNameFetcher(fn func(ctx context.Context, id uuid.UU
 	s.rideTypeNameFetcher = fn
 }
 
-// publishEvent publishes an event asynchronously. Failures are logged but don't affect the caller.
-func (s *Service) publishEvent(subject string, eventType, source string, data interface{}) {
-	if s.eventBus == nil {
-		return
+// SetWaypointOptimizer enables reordering of intermediate stops for riders who
+// opt in with optimize_stops. Typically maps.Service.
+func (s *Service) SetWaypointOptimizer(o WaypointOptimizer) {
+	s.waypointOptimizer = o
+}
+
+// SetDiscountOrchestrator settles promo, subscription, loyalty and gift card
+// reductions against the final fare when a ride completes
+func (s *Service) SetDiscountOrchestrator(o *discounts.Orchestrator) {
+	s.discounts = o
+}
+
+// newOutboxEvent builds a lifecycle event to be written with the ride state
+// change. Returns nil when the outbox is disabled, in which case no event is sent.
+func (s *Service) newOutboxEvent(rideID uuid.UUID, subject, eventType string, data interface{}) *OutboxEvent {
+	if !s.outboxEnabled {
+		return nil
 	}
-	go func() {
-		evt, err := eventbus.NewEvent(eventType, source, data)
-		if err != nil {
-			logger.Warn("failed to create event", zap.String("type", eventType), zap.Error(err))
-			return
-		}
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := s.eventBus.Publish(ctx, subject, evt); err != nil {
-			logger.Warn("failed to publish event", zap.String("type", eventType), zap.Error(err))
-		}
-	}()
+	evt, err := NewOutboxEvent(rideID, subject, eventType, data)
+	if err != nil {
+		logger.Warn("failed to create event", zap.String("type", eventType), zap.Error(err))
+		return nil
+	}
+	return evt
 }
 
 // MatchDrivers finds and scores the best drivers for a pickup location.
-func (s *Service) MatchDrivers(ctx context.Context, pickupLatitude, pickupLongitude float64) ([]*DriverCandidate, error) {
+// Only drivers whose vehicle suits the ride type and the rider's needs are
+// returned.
+func (s *Service) MatchDrivers(ctx context.Context, riderID uuid.UUID, pickupLatitude, pickupLongitude float64, rideTypeID *uuid.UUID) ([]*DriverCandidate, error) {
 	if s.matcher == nil {
 		return nil, common.NewInternalServerError("matching engine not configured")
 	}
-	return s.matcher.FindBestDrivers(ctx, pickupLatitude, pickupLongitude)
+
+	requirements, err := s.repo.GetRideRequirements(ctx, riderID, rideTypeID)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to load ride requirements")
+	}
+
+	return s.matcher.FindBestDrivers(ctx, pickupLatitude, pickupLongitude, requirements)
+}
+
+// GetDriverOfferStats returns how a driver has responded to ride offers.
+func (s *Service) GetDriverOfferStats(ctx context.Context, driverID uuid.UUID) (*models.DriverOfferStats, error) {
+	stats, err := s.repo.GetDriverOfferStats(ctx, driverID)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to get offer stats")
+	}
+	return stats, nil
 }
 
 // EnableMLPredictions wires an optional ML ETA client with breaker protection.
@@ -183,15 +218,21 @@ func (s *Service) RequestRide(ctx context.Context, riderID uuid.UUID, req *model
 		tracing.LocationLongitudeKey.Float64(req.PickupLongitude),
 	)
 
-	// Calculate estimated values
-	distance := calculateDistance(
-		req.PickupLatitude, req.PickupLongitude,
-		req.DropoffLatitude, req.DropoffLongitude,
-	)
+	// Intermediate stops, optionally reordered for the shortest route
+	stops, err := s.buildRequestStops(ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
-	duration := estimateDuration(distance)
-	if mlDuration, ok := s.predictETAFromML(ctx, req); ok && mlDuration > 0 {
-		duration = int(math.Round(mlDuration))
+	// Calculate estimated values
+	legs := routeLegs(req.PickupLatitude, req.PickupLongitude, stops, req.DropoffLatitude, req.DropoffLongitude)
+	distance, duration := legTotals(legs)
+	if len(stops) == 0 {
+		if mlDuration, ok := s.predictETAFromML(ctx, req); ok && mlDuration > 0 {
+			duration = int(math.Round(mlDuration))
+		}
+	} else {
+		tracing.AddSpanAttributes(ctx, attribute.Int("stop_count", len(stops)))
 	}
 
 	var fare float64
@@ -231,52 +272,49 @@ func (s *Service) RequestRide(ctx context.Context, riderID uuid.UUID, req *model
 
 	rideTypeID = req.RideTypeID
 
-	// Use hierarchical pricing engine when available
-	if s.pricingService != nil {
-		calculation, err := s.pricingService.CalculateFare(ctx, pricing.CalculateInput{
-			PickupLatitude:   req.PickupLatitude,
-			PickupLongitude:  req.PickupLongitude,
-			DropoffLatitude:  req.DropoffLatitude,
-			DropoffLongitude: req.DropoffLongitude,
-			DistanceKm:       distance,
-			DurationMin:      duration,
-			RideTypeID:       rideTypeID,
-			Currency:         "USD", // Will be resolved by pricing engine
-		})
+	// A redeemed quote fixes the fare unless the request strays from it
+	var rideFareQuote *models.RideFareQuote
+	if req.QuoteToken != "" {
+		upfront, err := s.redeemQuote(riderID, req)
 		if err != nil {
-			logger.Warn("hierarchical pricing failed, falling back to flat pricing", zap.Error(err))
-			surgeMultiplier = calculateSurgeMultiplier(time.Now())
-			fare = s.calculateFare(distance, duration, surgeMultiplier)
-			currencyCode = "USD"
-		} else {
-			fare = calculation.TotalFare
-			surgeMultiplier = calculation.TotalMultiplier
-			currencyCode = calculation.Currency
-			if calculation.PricingVersionID != uuid.Nil {
-				pricingVersionID = &calculation.PricingVersionID
-			}
+			return nil, err
 		}
+		rideFareQuote = newRideFareQuote(upfront, requestDeviation(s.fareLock(), upfront, req, stops), time.Now())
+	}
+
+	if rideFareQuote != nil && rideFareQuote.Locked {
+		fare = rideFareQuote.QuotedFare
+		surgeMultiplier = rideFareQuote.SurgeMultiplier
+		currencyCode = rideFareQuote.CurrencyCode
+		pricingVersionID = rideFareQuote.PricingVersionID
 	} else {
-		// Fallback: use dynamic surge pricing if available, otherwise time-based
-		if s.surgeCalculator != nil {
-			var err error
-			surgeMultiplier, err = s.surgeCalculator.CalculateSurgeMultiplier(ctx, req.PickupLatitude, req.PickupLongitude)
-			if err != nil {
-				surgeMultiplier = calculateSurgeMultiplier(time.Now())
-			}
-		} else {
-			surgeMultiplier = calculateSurgeMultiplier(time.Now())
+		// Per-leg pricing only applies when the route has intermediate stops
+		var pricedLegs []pricing.LegInput
+		if len(stops) > 0 {
+			pricedLegs = legs
 		}
-
-		fare = s.calculateFare(distance, duration, surgeMultiplier)
-		currencyCode = "USD"
+		quote := s.quoteFare(ctx, req.PickupLatitude, req.PickupLongitude, req.DropoffLatitude, req.DropoffLongitude,
+			rideTypeID, riderID, nil, distance, duration, pricedLegs)
+		fare = quote.Fare
+		surgeMultiplier = quote.SurgeMultiplier
+		currencyCode = quote.Currency
+		pricingVersionID = quote.PricingVersionID
 	}
 
+	// Recorded on the ride, where settlement checks promo payment rules
+	paymentMethod := strings.ToLower(strings.TrimSpace(req.PaymentMethod))
+
 	// Apply promo code if provided
 	var promoCodeID *uuid.UUID
 	var discountAmount float64
 	if req.PromoCode != "" {
-		validation, err := s.validatePromoCode(ctx, req.PromoCode, riderID, fare)
+		validation, err := s.validatePromoCode(ctx, req.PromoCode, riderID, fare, promoRideContext{
+			RideTypeID:    rideTypeID,
+			CityID:        cityID,
+			RegionID:      regionID,
+			PaymentMethod: paymentMethod,
+			RideAt:        req.ScheduledAt,
+		})
 		if err == nil && validation.Valid {
 			promoCodeID = &validation.PromoCodeID
 			discountAmount = validation.DiscountAmount
@@ -309,6 +347,18 @@ func (s *Service) RequestRide(ctx context.Context, riderID uuid.UUID, req *model
 		DropoffZoneID:     dropoffZoneID,
 		CurrencyCode:      currencyCode,
 		PricingVersionID:  pricingVersionID,
+		FareQuote:         rideFareQuote,
+	}
+	if paymentMethod != "" {
+		ride.PaymentMethod = &paymentMethod
+	}
+
+	for _, stop := range stops {
+		stop.RideID = ride.ID
+	}
+	ride.Stops = stops
+	if rideFareQuote != nil {
+		rideFareQuote.RideID = ride.ID
 	}
 
 	// Handle scheduled rides
@@ -318,20 +368,6 @@ func (s *Service) RequestRide(ctx context.Context, riderID uuid.UUID, req *model
 		ride.ScheduledNotificationSent = false
 	}
 
-	if err := s.repo.CreateRide(ctx, ride); err != nil {
-		tracing.RecordError(ctx, err)
-		return nil, common.NewInternalServerError("failed to create ride request")
-	}
-
-	// Add final ride attributes to span
-	tracing.AddSpanAttributes(ctx,
-		tracing.RideIDKey.String(ride.ID.String()),
-		tracing.FareAmountKey.Float64(fare),
-		tracing.DistanceKey.Float64(distance),
-		tracing.DurationKey.Int(duration),
-		attribute.Float64("surge_multiplier", surgeMultiplier),
-	)
-
 	// Fetch rider info for event enrichment (non-blocking, with graceful fallbacks)
 	riderName := "Rider" // Default fallback
 	riderRating := 5.0   // Default fallback (new riders start at 5.0)
@@ -368,8 +404,8 @@ func (s *Service) RequestRide(ctx context.Context, riderID uuid.UUID, req *model
 		}
 	}
 
-	// Publish comprehensive event for matching service
-	s.publishEvent(eventbus.SubjectRideRequested, "ride.requested", "rides-service", eventbus.RideRequestedData{
+	// Comprehensive event for matching service, committed together with the ride
+	evt := s.newOutboxEvent(ride.ID, eventbus.SubjectRideRequested, "ride.requested", eventbus.RideRequestedData{
 		RideID:            ride.ID,
 		RiderID:           riderID,
 		RiderName:         riderName,
@@ -386,9 +422,28 @@ func (s *Service) RequestRide(ctx context.Context, riderID uuid.UUID, req *model
 		EstimatedDistance: distance,
 		EstimatedDuration: duration,
 		Currency:          currencyCode,
+		PaymentMethod:     paymentMethod,
 		RequestedAt:       ride.RequestedAt,
 	})
 
+	if err := s.repo.CreateRide(ctx, ride, evt); err != nil {
+		tracing.RecordError(ctx, err)
+		if errors.Is(err, ErrQuoteAlreadyRedeemed) {
+			return nil, common.NewErrorWithCode(http.StatusConflict, common.ErrCodeQuoteInvalid,
+				"fare quote has already been used", nil)
+		}
+		return nil, common.NewInternalServerError("failed to create ride request")
+	}
+
+	// Add final ride attributes to span
+	tracing.AddSpanAttributes(ctx,
+		tracing.RideIDKey.String(ride.ID.String()),
+		tracing.FareAmountKey.Float64(fare),
+		tracing.DistanceKey.Float64(distance),
+		tracing.DurationKey.Int(duration),
+		attribute.Float64("surge_multiplier", surgeMultiplier),
+	)
+
 	return ride, nil
 }
 
@@ -399,6 +454,26 @@ func (s *Service) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ride,
 		return nil, common.NewNotFoundError("ride not found", nil)
 	}
 
+	stops, err := s.repo.GetRideStops(ctx, rideID)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to get ride stops")
+	}
+	ride.Stops = stops
+
+	fareQuote, err := s.repo.GetRideFareQuote(ctx, rideID)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to get ride fare quote")
+	}
+	ride.FareQuote = fareQuote
+
+	if ride.Status == models.RideStatusCompleted {
+		lines, err := s.repo.GetRideDiscountLines(ctx, rideID)
+		if err != nil {
+			return nil, common.NewInternalServerError("failed to get ride discounts")
+		}
+		ride.DiscountLines = lines
+	}
+
 	return ride, nil
 }
 
@@ -412,8 +487,46 @@ func (s *Service) AcceptRide(ctx context.Context, rideID, driverID uuid.UUID) (*
 		tracing.DriverIDKey.String(driverID.String()),
 	)
 
+	ride, err := s.repo.GetRideByID(ctx, rideID)
+	if err != nil {
+		tracing.RecordError(ctx, err)
+		return nil, common.NewNotFoundError("ride not found", nil)
+	}
+
+	// Stops let subscribers follow the whole route, not just pickup to dropoff
+	stops, err := s.repo.GetRideStops(ctx, rideID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to load ride stops for accepted event",
+			zap.String("ride_id", rideID.String()), zap.Error(err))
+	}
+	stopPoints := make([]eventbus.RideStopPoint, 0, len(stops))
+	for _, stop := range stops {
+		if stop.Status == models.RideStopStatusSkipped {
+			continue
+		}
+		stopPoints = append(stopPoints, eventbus.RideStopPoint{
+			StopID:    stop.ID,
+			StopOrder: stop.StopOrder,
+			Latitude:  stop.Latitude,
+			Longitude: stop.Longitude,
+		})
+	}
+
+	now := time.Now()
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideAccepted, "ride.accepted", eventbus.RideAcceptedData{
+		RideID:           rideID,
+		RiderID:          ride.RiderID,
+		DriverID:         driverID,
+		PickupLatitude:   ride.PickupLatitude,
+		PickupLongitude:  ride.PickupLongitude,
+		DropoffLatitude:  ride.DropoffLatitude,
+		DropoffLongitude: ride.DropoffLongitude,
+		AcceptedAt:       now,
+		Stops:            stopPoints,
+	})
+
 	// Atomic accept: single UPDATE with status guard prevents double-accept race condition
-	accepted, err := s.repo.AtomicAcceptRide(ctx, rideID, driverID)
+	accepted, err := s.repo.AtomicAcceptRide(ctx, rideID, driverID, now, evt)
 	if err != nil {
 		tracing.RecordError(ctx, err)
 		return nil, common.NewInternalServerError("failed to accept ride")
@@ -423,29 +536,57 @@ func (s *Service) AcceptRide(ctx context.Context, rideID, driverID uuid.UUID) (*
 			"ride is no longer available for acceptance", nil)
 	}
 
-	// Re-fetch ride with updated fields
-	ride, err := s.repo.GetRideByID(ctx, rideID)
-	if err != nil {
-		tracing.RecordError(ctx, err)
-		return nil, common.NewInternalServerError("failed to fetch accepted ride")
-	}
+	ride.Status = models.RideStatusAccepted
+	ride.DriverID = &driverID
+	ride.AcceptedAt = &now
+	ride.UpdatedAt = now
 
 	tracing.AddSpanEvent(ctx, "ride_accepted",
 		attribute.String("ride_id", rideID.String()),
 		attribute.String("driver_id", driverID.String()),
 	)
 
-	s.publishEvent(eventbus.SubjectRideAccepted, "ride.accepted", "rides-service", eventbus.RideAcceptedData{
-		RideID:           rideID,
-		RiderID:          ride.RiderID,
-		DriverID:         driverID,
-		PickupLatitude:   ride.PickupLatitude,
-		PickupLongitude:  ride.PickupLongitude,
-		DropoffLatitude:  ride.DropoffLatitude,
-		DropoffLongitude: ride.DropoffLongitude,
-		AcceptedAt:       *ride.AcceptedAt,
+	return ride, nil
+}
+
+// MarkDriverArrived records that the assigned driver has reached the pickup
+// point. The arrival time it publishes starts the pickup wait timer and the
+// rider no-show window.
+func (s *Service) MarkDriverArrived(ctx context.Context, rideID, driverID uuid.UUID) (*models.Ride, error) {
+	ride, err := s.repo.GetRideByID(ctx, rideID)
+	if err != nil {
+		return nil, common.NewNotFoundError("ride not found", nil)
+	}
+
+	if err := ValidateTransition(ride.Status, models.RideStatusDriverArrived); err != nil {
+		return nil, transitionAppError(err)
+	}
+
+	if ride.DriverID == nil || *ride.DriverID != driverID {
+		return nil, common.NewBadRequestError("unauthorized driver", nil)
+	}
+
+	now := time.Now()
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideDriverArrived, "ride.driver_arrived", eventbus.RideDriverArrivedData{
+		RideID:    rideID,
+		RiderID:   ride.RiderID,
+		DriverID:  driverID,
+		ArrivedAt: now,
 	})
 
+	arrived, err := s.repo.AtomicMarkDriverArrived(ctx, rideID, driverID, now, evt)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to mark driver arrived")
+	}
+	if !arrived {
+		return nil, common.NewErrorWithCode(409, common.ErrCodeRideInvalidTransition,
+			"ride is no longer awaiting the driver", nil)
+	}
+
+	ride.Status = models.RideStatusDriverArrived
+	ride.ArrivedAt = &now
+	ride.UpdatedAt = now
+
 	return ride, nil
 }
 
@@ -456,29 +597,34 @@ func (s *Service) StartRide(ctx context.Context, rideID, driverID uuid.UUID) (*m
 		return nil, common.NewNotFoundError("ride not found", nil)
 	}
 
-	if ride.Status != models.RideStatusAccepted {
-		return nil, common.NewBadRequestError("ride has not been accepted", nil)
+	if err := ValidateTransition(ride.Status, models.RideStatusInProgress); err != nil {
+		return nil, transitionAppError(err)
 	}
 
 	if ride.DriverID == nil || *ride.DriverID != driverID {
 		return nil, common.NewBadRequestError("unauthorized driver", nil)
 	}
 
-	if err := s.repo.UpdateRideStatus(ctx, rideID, models.RideStatusInProgress, nil); err != nil {
-		return nil, common.NewInternalServerError("failed to start ride")
-	}
-
-	ride.Status = models.RideStatusInProgress
 	now := time.Now()
-	ride.StartedAt = &now
-
-	s.publishEvent(eventbus.SubjectRideStarted, "ride.started", "rides-service", eventbus.RideStartedData{
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideStarted, "ride.started", eventbus.RideStartedData{
 		RideID:    rideID,
 		RiderID:   ride.RiderID,
 		DriverID:  driverID,
 		StartedAt: now,
 	})
 
+	started, err := s.repo.AtomicStartRide(ctx, rideID, driverID, now, evt)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to start ride")
+	}
+	if !started {
+		return nil, common.NewErrorWithCode(409, common.ErrCodeRideNotAvailable,
+			"ride is no longer awaiting start", nil)
+	}
+
+	ride.Status = models.RideStatusInProgress
+	ride.StartedAt = &now
+
 	return ride, nil
 }
 
@@ -499,10 +645,9 @@ func (s *Service) CompleteRide(ctx context.Context, rideID, driverID uuid.UUID,
 		return nil, common.NewNotFoundError("ride not found", nil)
 	}
 
-	if ride.Status != models.RideStatusInProgress {
-		err := fmt.Errorf("ride status is %s, not in progress", ride.Status)
+	if err := ValidateTransition(ride.Status, models.RideStatusCompleted); err != nil {
 		tracing.RecordError(ctx, err)
-		return nil, common.NewBadRequestError("ride is not in progress", nil)
+		return nil, transitionAppError(err)
 	}
 
 	if ride.DriverID == nil || *ride.DriverID != driverID {
@@ -514,29 +659,65 @@ func (s *Service) CompleteRide(ctx context.Context, rideID, driverID uuid.UUID,
 	// Calculate actual duration
 	actualDuration := int(time.Since(*ride.StartedAt).Minutes())
 
+	// Time spent at intermediate stops is billed as stop wait, not driving time
+	stops, err := s.repo.GetRideStops(ctx, rideID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to load ride stops, billing without stop wait",
+			zap.String("ride_id", rideID.String()), zap.Error(err))
+	}
+	dwellMinutes, stopWaitMinutes := stopWaitTotals(stops)
+	drivingDuration := actualDuration - dwellMinutes
+	if drivingDuration < 0 {
+		drivingDuration = 0
+	}
+
+	// A locked quote holds unless the trip strayed from the quoted route. The
+	// release is recorded with the completion, so it only sticks if the ride
+	// completes.
+	fareQuote, err := s.repo.GetRideFareQuote(ctx, rideID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to load ride fare quote, billing actual trip",
+			zap.String("ride_id", rideID.String()), zap.Error(err))
+	}
+	var quoteRelease string
+	if fareQuote != nil && fareQuote.Locked {
+		if reason := completionDeviation(s.fareLock(), fareQuote, actualDistance, stops); reason != "" {
+			quoteRelease = reason
+			fareQuote.Locked = false
+			fareQuote.ReleaseReason = &reason
+		}
+	}
+
 	// Calculate final fare based on actual distance and duration
 	var finalFare, driverEarnings float64
-	if s.pricingService != nil {
+	if fareQuote != nil && fareQuote.Locked {
+		finalFare = fareQuote.QuotedFare
+		driverEarnings = s.lockedDriverEarnings(ctx, ride, finalFare)
+	} else if s.pricingService != nil {
 		calculation, err := s.pricingService.CalculateFare(ctx, pricing.CalculateInput{
 			PickupLatitude:   ride.PickupLatitude,
 			PickupLongitude:  ride.PickupLongitude,
 			DropoffLatitude:  ride.DropoffLatitude,
 			DropoffLongitude: ride.DropoffLongitude,
 			DistanceKm:       actualDistance,
-			DurationMin:      actualDuration,
+			DurationMin:      drivingDuration,
 			RideTypeID:       ride.RideTypeID,
 			Currency:         ride.CurrencyCode,
+			Legs:             completedLegs(ride, stops, actualDistance, drivingDuration),
+			StopWaitMinutes:  stopWaitMinutes,
+			RiderID:          &ride.RiderID,
+			VersionID:        ride.PricingVersionID, // bill with the version the rider was quoted
 		})
 		if err != nil {
 			logger.Warn("hierarchical pricing failed on completion, falling back to flat pricing", zap.Error(err))
-			finalFare = s.calculateFare(actualDistance, actualDuration, ride.SurgeMultiplier)
+			finalFare = s.calculateFare(actualDistance, drivingDuration+stopWaitMinutes, ride.SurgeMultiplier)
 			driverEarnings = finalFare * 0.80 // flat 20% commission fallback
 		} else {
 			finalFare = calculation.TotalFare
 			driverEarnings = calculation.DriverEarnings
 		}
 	} else {
-		finalFare = s.calculateFare(actualDistance, actualDuration, ride.SurgeMultiplier)
+		finalFare = s.calculateFare(actualDistance, drivingDuration+stopWaitMinutes, ride.SurgeMultiplier)
 		driverEarnings = finalFare * 0.80
 	}
 
@@ -546,8 +727,48 @@ func (s *Service) CompleteRide(ctx context.Context, rideID, driverID uuid.UUID,
 		attribute.Float64("estimated_fare", ride.EstimatedFare),
 	)
 
-	// Atomic completion: single UPDATE sets fare data + status with driver guard
-	completed, err := s.repo.AtomicCompleteRide(ctx, rideID, driverID, actualDistance, actualDuration, finalFare)
+	currency := ride.CurrencyCode
+	if currency == "" {
+		currency = "USD"
+	}
+	now := time.Now()
+	completedData := eventbus.RideCompletedData{
+		RideID:         rideID,
+		RiderID:        ride.RiderID,
+		DriverID:       driverID,
+		FareAmount:     finalFare,
+		DriverEarnings: driverEarnings,
+		Currency:       currency,
+		DistanceKm:     actualDistance,
+		DurationMin:    float64(actualDuration),
+		CompletedAt:    now,
+	}
+	completion := &RideCompletion{
+		ActualDistance: actualDistance,
+		ActualDuration: actualDuration,
+		FinalFare:      finalFare,
+		CompletedAt:    now,
+		QuoteRelease:   quoteRelease,
+	}
+
+	// Discounts are settled with the ride locked, before the completed event
+	// is staged, so payments charge only what the rider still owes
+	checkout := s.discountCheckout(ctx, ride, finalFare, currency)
+	var settlement *discounts.Result
+	completed, err := s.repo.AtomicCompleteRide(ctx, rideID, driverID, func(ctx context.Context) (*RideCompletion, error) {
+		if checkout != nil {
+			settlement = s.discounts.Settle(ctx, checkout)
+			discount := settlement.Discount.Major()
+			completion.DiscountAmount = &discount
+			completion.DiscountLines = discountLines(rideID, settlement, now)
+			completedData = withSettlement(completedData, settlement)
+		}
+		completion.Event = s.newOutboxEvent(rideID, eventbus.SubjectRideCompleted, "ride.completed", completedData)
+		return completion, nil
+	})
+	if err != nil || !completed {
+		s.releaseDiscounts(ctx, checkout, settlement)
+	}
 	if err != nil {
 		tracing.RecordError(ctx, err)
 		return nil, common.NewInternalServerError("failed to complete ride")
@@ -561,24 +782,16 @@ func (s *Service) CompleteRide(ctx context.Context, rideID, driverID uuid.UUID,
 	ride.ActualDistance = &actualDistance
 	ride.ActualDuration = &actualDuration
 	ride.FinalFare = &finalFare
-	now := time.Now()
 	ride.CompletedAt = &now
-
-	currency := ride.CurrencyCode
-	if currency == "" {
-		currency = "USD"
+	ride.Stops = stops
+	if quoteRelease != "" {
+		fareQuote.ReleasedAt = &now
+	}
+	ride.FareQuote = fareQuote
+	if settlement != nil {
+		ride.DiscountAmount = settlement.Discount.Major()
+		ride.DiscountLines = completion.DiscountLines
 	}
-	s.publishEvent(eventbus.SubjectRideCompleted, "ride.completed", "rides-service", eventbus.RideCompletedData{
-		RideID:         rideID,
-		RiderID:        ride.RiderID,
-		DriverID:       driverID,
-		FareAmount:     finalFare,
-		DriverEarnings: driverEarnings,
-		Currency:       currency,
-		DistanceKm:     actualDistance,
-		DurationMin:    float64(actualDuration),
-		CompletedAt:    now,
-	})
 
 	return ride, nil
 }
@@ -598,37 +811,103 @@ func (s *Service) CancelRide(ctx context.Context, rideID, userID uuid.UUID, reas
 		return nil, common.NewBadRequestError("unauthorized to cancel this ride", nil)
 	}
 
-	if ride.Status == models.RideStatusCompleted || ride.Status == models.RideStatusCancelled {
-		return nil, common.NewBadRequestError("cannot cancel completed or already cancelled ride", nil)
+	if err := ValidateTransition(ride.Status, models.RideStatusCancelled); err != nil {
+		return nil, transitionAppError(err)
 	}
 
-	if err := s.repo.UpdateRideStatus(ctx, rideID, models.RideStatusCancelled, nil); err != nil {
+	cancelledBy := "rider"
+	if isDriver {
+		cancelledBy = "driver"
+	}
+	driverID := uuid.Nil
+	if ride.DriverID != nil {
+		driverID = *ride.DriverID
+	}
+	now := time.Now()
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideCancelled, "ride.cancelled", eventbus.RideCancelledData{
+		RideID:      rideID,
+		RiderID:     ride.RiderID,
+		DriverID:    driverID,
+		CancelledBy: cancelledBy,
+		Reason:      reason,
+		CancelledAt: now,
+	})
+
+	cancelled, err := s.repo.AtomicCancelRide(ctx, rideID, reason, now, evt)
+	if err != nil {
 		return nil, common.NewInternalServerError("failed to cancel ride")
 	}
+	if !cancelled {
+		return nil, common.NewErrorWithCode(409, common.ErrCodeRideAlreadyDone,
+			"ride has already been completed or cancelled", nil)
+	}
 
 	ride.Status = models.RideStatusCancelled
-	now := time.Now()
 	ride.CancelledAt = &now
 	ride.CancellationReason = &reason
 
-	cancelledBy := "rider"
-	if isDriver {
-		cancelledBy = "driver"
+	return ride, nil
+}
+
+// CancelRideWithPolicy cancels a ride for the cancellation service, which
+// has already checked who may cancel and applied its fee policy. The
+// cancellation is staged on the outbox like CancelRide's, so the card hold,
+// matching and safety monitoring hear of it. Returns false when the ride was
+// completed or cancelled first.
+func (s *Service) CancelRideWithPolicy(ctx context.Context, ride *models.Ride, cancelledBy, reason string, feeWaived bool, cancelledAt time.Time) (bool, error) {
+	driverID := uuid.Nil
+	if ride.DriverID != nil {
+		driverID = *ride.DriverID
 	}
+	evt := s.newOutboxEvent(ride.ID, eventbus.SubjectRideCancelled, "ride.cancelled", eventbus.RideCancelledData{
+		RideID:      ride.ID,
+		RiderID:     ride.RiderID,
+		DriverID:    driverID,
+		CancelledBy: cancelledBy,
+		Reason:      reason,
+		FeeWaived:   feeWaived,
+		CancelledAt: cancelledAt,
+	})
+	return s.repo.AtomicCancelRide(ctx, ride.ID, reason, cancelledAt, evt)
+}
+
+// CancelRideForDeclinedPayment cancels a ride whose card hold was declined,
+// so it is neither matched nor driven on a card that cannot pay. The rider is
+// told to add a new payment method and request again. Rides that already
+// started are left alone and returns false.
+func (s *Service) CancelRideForDeclinedPayment(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	ride, err := s.repo.GetRideByID(ctx, rideID)
+	if err != nil {
+		return false, fmt.Errorf("get ride: %w", err)
+	}
+	if IsTerminal(ride.Status) || ride.Status == models.RideStatusInProgress {
+		return false, nil
+	}
+
 	driverID := uuid.Nil
 	if ride.DriverID != nil {
 		driverID = *ride.DriverID
 	}
-	s.publishEvent(eventbus.SubjectRideCancelled, "ride.cancelled", "rides-service", eventbus.RideCancelledData{
+	now := time.Now()
+	reason := eventbus.CancelReasonPaymentDeclined
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideCancelled, "ride.cancelled", eventbus.RideCancelledData{
 		RideID:      rideID,
 		RiderID:     ride.RiderID,
 		DriverID:    driverID,
-		CancelledBy: cancelledBy,
+		CancelledBy: "system",
 		Reason:      reason,
 		CancelledAt: now,
 	})
 
-	return ride, nil
+	cancelled, err := s.repo.AtomicCancelUnstartedRide(ctx, rideID, reason, now, evt)
+	if err != nil {
+		return false, err
+	}
+	if cancelled {
+		logger.InfoContext(ctx, "ride cancelled after its card hold was declined",
+			zap.String("ride_id", rideID.String()))
+	}
+	return cancelled, nil
 }
 
 // RateRide allows a rider to rate a completed ride
@@ -714,6 +993,73 @@ func (s *Service) calculateFare(distance float64, duration int, surgeMultiplier
 	return math.Round(fare*100) / 100 // Round to 2 decimal places
 }
 
+// fareQuote is the estimated fare for a route
+type fareQuote struct {
+	Fare             float64
+	SurgeMultiplier  float64
+	Currency         string
+	PricingVersionID *uuid.UUID
+}
+
+// quoteFare prices a route with the hierarchical pricing engine when available,
+// falling back to flat pricing. legs is only set for multi-stop routes, which
+// the pricing engine charges leg by leg. versionID pins the pricing version of
+// an existing ride; otherwise the rider may be bucketed into an A/B test version.
+func (s *Service) quoteFare(ctx context.Context, pickupLatitude, pickupLongitude, dropoffLatitude, dropoffLongitude float64, rideTypeID *uuid.UUID, riderID uuid.UUID, versionID *uuid.UUID, distance float64, duration int, legs []pricing.LegInput) fareQuote {
+	// Use hierarchical pricing engine when available
+	if s.pricingService != nil {
+		calculation, err := s.pricingService.CalculateFare(ctx, pricing.CalculateInput{
+			PickupLatitude:   pickupLatitude,
+			PickupLongitude:  pickupLongitude,
+			DropoffLatitude:  dropoffLatitude,
+			DropoffLongitude: dropoffLongitude,
+			DistanceKm:       distance,
+			DurationMin:      duration,
+			RideTypeID:       rideTypeID,
+			Currency:         "USD", // Will be resolved by pricing engine
+			Legs:             legs,
+			RiderID:          &riderID,
+			VersionID:        versionID,
+		})
+		if err == nil {
+			quote := fareQuote{
+				Fare:            calculation.TotalFare,
+				SurgeMultiplier: calculation.TotalMultiplier,
+				Currency:        calculation.Currency,
+			}
+			if calculation.PricingVersionID != uuid.Nil {
+				quote.PricingVersionID = &calculation.PricingVersionID
+			}
+			return quote
+		}
+		logger.Warn("hierarchical pricing failed, falling back to flat pricing", zap.Error(err))
+		surgeMultiplier := calculateSurgeMultiplier(time.Now())
+		return fareQuote{
+			Fare:            s.calculateFare(distance, duration, surgeMultiplier),
+			SurgeMultiplier: surgeMultiplier,
+			Currency:        "USD",
+		}
+	}
+
+	// Fallback: use dynamic surge pricing if available, otherwise time-based
+	var surgeMultiplier float64
+	if s.surgeCalculator != nil {
+		var err error
+		surgeMultiplier, err = s.surgeCalculator.CalculateSurgeMultiplier(ctx, pickupLatitude, pickupLongitude)
+		if err != nil {
+			surgeMultiplier = calculateSurgeMultiplier(time.Now())
+		}
+	} else {
+		surgeMultiplier = calculateSurgeMultiplier(time.Now())
+	}
+
+	return fareQuote{
+		Fare:            s.calculateFare(distance, duration, surgeMultiplier),
+		SurgeMultiplier: surgeMultiplier,
+		Currency:        "USD",
+	}
+}
+
 // calculateSurgeMultiplier calculates surge pricing multiplier based on time
 func calculateSurgeMultiplier(t time.Time) float64 {
 	hour := t.Hour()
@@ -747,15 +1093,26 @@ type PromoCodeValidation struct {
 	PromoCodeID    uuid.UUID `json:"promo_code_id"`
 	DiscountAmount float64   `json:"discount_amount"`
 	FinalAmount    float64   `json:"final_amount"`
+	Reason         string    `json:"reason"`
 	Message        string    `json:"message"`
 }
 
+// promoRideContext carries the ride details promo targeting rules check
+type promoRideContext struct {
+	RideTypeID    *uuid.UUID `json:"ride_type_id,omitempty"`
+	CityID        *uuid.UUID `json:"city_id,omitempty"`
+	RegionID      *uuid.UUID `json:"region_id,omitempty"`
+	PaymentMethod string     `json:"payment_method,omitempty"`
+	RideAt        *time.Time `json:"ride_at,omitempty"`
+}
+
 // validatePromoCode validates a promo code with the Promos service
-func (s *Service) validatePromoCode(ctx context.Context, code string, riderID uuid.UUID, rideAmount float64) (*PromoCodeValidation, error) {
-	requestBody := map[string]interface{}{
-		"code":        code,
-		"ride_amount": rideAmount,
-	}
+func (s *Service) validatePromoCode(ctx context.Context, code string, riderID uuid.UUID, rideAmount float64, ride promoRideContext) (*PromoCodeValidation, error) {
+	requestBody := struct {
+		Code       string  `json:"code"`
+		RideAmount float64 `json:"ride_amount"`
+		promoRideContext
+	}{code, rideAmount, ride}
 
 	// Get JWT token from context (if available)
 	headers := make(map[string]string)
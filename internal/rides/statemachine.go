@@ -0,0 +1,121 @@
+package rides
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/models"
+)
+
+var (
+	// ErrIllegalTransition is returned when a ride cannot move between two statuses.
+	ErrIllegalTransition = errors.New("illegal ride status transition")
+	// ErrRideTerminal is returned when a ride is already completed or cancelled.
+	ErrRideTerminal = errors.New("ride is already completed or cancelled")
+)
+
+// TransitionError describes a rejected ride status change. It wraps
+// ErrRideTerminal or ErrIllegalTransition so callers can use errors.Is.
+type TransitionError struct {
+	From models.RideStatus
+	To   models.RideStatus
+	Err  error
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%v: %s -> %s", e.Err, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// rideStatusOrder lists every ride status in lifecycle order.
+var rideStatusOrder = []models.RideStatus{
+	models.RideStatusRequested,
+	models.RideStatusAccepted,
+	models.RideStatusDriverArrived,
+	models.RideStatusInProgress,
+	models.RideStatusCompleted,
+	models.RideStatusCancelled,
+}
+
+// rideTransitions is the single source of truth for which status changes are
+// legal. Accepted rides may still be started directly so drivers on app
+// versions without the arrival button are not stuck.
+var rideTransitions = map[models.RideStatus][]models.RideStatus{
+	models.RideStatusRequested:     {models.RideStatusAccepted, models.RideStatusCancelled},
+	models.RideStatusAccepted:      {models.RideStatusDriverArrived, models.RideStatusInProgress, models.RideStatusCancelled},
+	models.RideStatusDriverArrived: {models.RideStatusInProgress, models.RideStatusCancelled},
+	models.RideStatusInProgress:    {models.RideStatusCompleted, models.RideStatusCancelled},
+	models.RideStatusCompleted:     {},
+	models.RideStatusCancelled:     {},
+}
+
+// IsTerminal reports whether no further transitions are possible from status.
+func IsTerminal(status models.RideStatus) bool {
+	next, ok := rideTransitions[status]
+	return ok && len(next) == 0
+}
+
+// CanTransition reports whether a ride may move from one status to another.
+func CanTransition(from, to models.RideStatus) bool {
+	for _, next := range rideTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTransition returns a *TransitionError if a ride may not move from
+// one status to another.
+func ValidateTransition(from, to models.RideStatus) error {
+	if CanTransition(from, to) {
+		return nil
+	}
+	if IsTerminal(from) {
+		return &TransitionError{From: from, To: to, Err: ErrRideTerminal}
+	}
+	return &TransitionError{From: from, To: to, Err: ErrIllegalTransition}
+}
+
+// FromStatuses returns the statuses a ride may move to status from, in
+// lifecycle order. Repositories use it to guard conditional UPDATEs so the
+// database enforces the same table as ValidateTransition.
+func FromStatuses(status models.RideStatus) []string {
+	var from []string
+	for _, s := range rideStatusOrder {
+		if CanTransition(s, status) {
+			from = append(from, string(s))
+		}
+	}
+	return from
+}
+
+// activeStatuses returns every non-terminal status in lifecycle order.
+func activeStatuses() []string {
+	var active []string
+	for _, s := range rideStatusOrder {
+		if !IsTerminal(s) {
+			active = append(active, string(s))
+		}
+	}
+	return active
+}
+
+// transitionAppError maps a transition error to the API error returned to
+// clients.
+func transitionAppError(err error) *common.AppError {
+	var te *TransitionError
+	if !errors.As(err, &te) {
+		return common.NewInternalServerError("failed to change ride status")
+	}
+	if errors.Is(err, ErrRideTerminal) {
+		return common.NewErrorWithCode(409, common.ErrCodeRideAlreadyDone,
+			fmt.Sprintf("ride is already %s", te.From), err)
+	}
+	return common.NewErrorWithCode(409, common.ErrCodeRideInvalidTransition,
+		fmt.Sprintf("ride cannot move from %s to %s", te.From, te.To), err)
+}
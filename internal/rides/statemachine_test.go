@@ -0,0 +1,84 @@
+package rides
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to models.RideStatus
+		want     bool
+	}{
+		{models.RideStatusRequested, models.RideStatusAccepted, true},
+		{models.RideStatusRequested, models.RideStatusCancelled, true},
+		{models.RideStatusRequested, models.RideStatusDriverArrived, false},
+		{models.RideStatusRequested, models.RideStatusInProgress, false},
+		{models.RideStatusAccepted, models.RideStatusDriverArrived, true},
+		{models.RideStatusAccepted, models.RideStatusInProgress, true},
+		{models.RideStatusAccepted, models.RideStatusCompleted, false},
+		{models.RideStatusDriverArrived, models.RideStatusInProgress, true},
+		{models.RideStatusDriverArrived, models.RideStatusCancelled, true},
+		{models.RideStatusDriverArrived, models.RideStatusAccepted, false},
+		{models.RideStatusInProgress, models.RideStatusCompleted, true},
+		{models.RideStatusInProgress, models.RideStatusDriverArrived, false},
+		{models.RideStatusCompleted, models.RideStatusCancelled, false},
+		{models.RideStatusCancelled, models.RideStatusAccepted, false},
+		{"unknown", models.RideStatusAccepted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.want, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestValidateTransition_TypedErrors(t *testing.T) {
+	assert.NoError(t, ValidateTransition(models.RideStatusAccepted, models.RideStatusDriverArrived))
+
+	err := ValidateTransition(models.RideStatusRequested, models.RideStatusInProgress)
+	var te *TransitionError
+	require.True(t, errors.As(err, &te))
+	assert.Equal(t, models.RideStatusRequested, te.From)
+	assert.Equal(t, models.RideStatusInProgress, te.To)
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+
+	err = ValidateTransition(models.RideStatusCompleted, models.RideStatusCancelled)
+	assert.ErrorIs(t, err, ErrRideTerminal)
+}
+
+func TestFromStatuses(t *testing.T) {
+	assert.Equal(t, []string{"requested"}, FromStatuses(models.RideStatusAccepted))
+	assert.Equal(t, []string{"accepted"}, FromStatuses(models.RideStatusDriverArrived))
+	assert.Equal(t, []string{"accepted", "driver_arrived"}, FromStatuses(models.RideStatusInProgress))
+	assert.Equal(t, []string{"in_progress"}, FromStatuses(models.RideStatusCompleted))
+	assert.Equal(t, []string{"requested", "accepted", "driver_arrived", "in_progress"}, FromStatuses(models.RideStatusCancelled))
+	assert.Empty(t, FromStatuses(models.RideStatusRequested))
+}
+
+func TestIsTerminal(t *testing.T) {
+	assert.True(t, IsTerminal(models.RideStatusCompleted))
+	assert.True(t, IsTerminal(models.RideStatusCancelled))
+	assert.False(t, IsTerminal(models.RideStatusDriverArrived))
+	assert.False(t, IsTerminal("unknown"))
+}
+
+func TestActiveStatuses(t *testing.T) {
+	assert.Equal(t, []string{"requested", "accepted", "driver_arrived", "in_progress"}, activeStatuses())
+}
+
+func TestTransitionAppError(t *testing.T) {
+	appErr := transitionAppError(ValidateTransition(models.RideStatusRequested, models.RideStatusCompleted))
+	assert.Equal(t, 409, appErr.Code)
+	assert.Equal(t, common.ErrCodeRideInvalidTransition, appErr.ErrorCode)
+
+	appErr = transitionAppError(ValidateTransition(models.RideStatusCancelled, models.RideStatusInProgress))
+	assert.Equal(t, 409, appErr.Code)
+	assert.Equal(t, common.ErrCodeRideAlreadyDone, appErr.ErrorCode)
+}
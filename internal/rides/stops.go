@@ -0,0 +1,372 @@
+package rides
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/maps"
+	"github.com/richxcame/ride-hailing/internal/pricing"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"go.uber.org/zap"
+)
+
+// stopFreeWaitMinutes is the wait at each intermediate stop that is not billed.
+const stopFreeWaitMinutes = 3
+
+// WaypointOptimizer orders intermediate stops for the shortest route.
+// Implemented by maps.Service.
+type WaypointOptimizer interface {
+	OptimizeWaypoints(ctx context.Context, origin maps.Coordinate, waypoints []maps.Coordinate, destination maps.Coordinate) ([]int, error)
+}
+
+// stopTransitions lists the legal status changes for an intermediate stop
+var stopTransitions = map[models.RideStopStatus][]models.RideStopStatus{
+	models.RideStopStatusPending: {models.RideStopStatusArrived, models.RideStopStatusSkipped},
+	models.RideStopStatusArrived: {models.RideStopStatusCompleted, models.RideStopStatusSkipped},
+}
+
+func canTransitionStop(from, to models.RideStopStatus) bool {
+	for _, next := range stopTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRequestStops turns the stops in a ride request into pending ride stops,
+// reordered by the waypoint optimizer when the rider asked for it.
+func (s *Service) buildRequestStops(ctx context.Context, req *models.RideRequest) ([]*models.RideStop, error) {
+	if len(req.Stops) == 0 {
+		return nil, nil
+	}
+	if len(req.Stops) > models.MaxRideStops {
+		return nil, common.NewBadRequestError(
+			fmt.Sprintf("a ride can have at most %d stops", models.MaxRideStops), nil)
+	}
+
+	inputs := req.Stops
+	if req.OptimizeStops && len(inputs) > 1 {
+		inputs = s.optimizeStopOrder(ctx, req, inputs)
+	}
+
+	stops := make([]*models.RideStop, 0, len(inputs))
+	for i, in := range inputs {
+		stops = append(stops, &models.RideStop{
+			ID:        uuid.New(),
+			StopOrder: i + 1,
+			Latitude:  in.Latitude,
+			Longitude: in.Longitude,
+			Address:   in.Address,
+			Status:    models.RideStopStatusPending,
+		})
+	}
+	return stops, nil
+}
+
+// optimizeStopOrder asks the waypoint optimizer for a visiting order. The
+// rider's order is kept if no optimizer is configured or it fails.
+func (s *Service) optimizeStopOrder(ctx context.Context, req *models.RideRequest, inputs []models.RideStopInput) []models.RideStopInput {
+	if s.waypointOptimizer == nil {
+		return inputs
+	}
+
+	waypoints := make([]maps.Coordinate, len(inputs))
+	for i, in := range inputs {
+		waypoints[i] = maps.Coordinate{Latitude: in.Latitude, Longitude: in.Longitude}
+	}
+
+	optimizeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	order, err := s.waypointOptimizer.OptimizeWaypoints(optimizeCtx,
+		maps.Coordinate{Latitude: req.PickupLatitude, Longitude: req.PickupLongitude},
+		waypoints,
+		maps.Coordinate{Latitude: req.DropoffLatitude, Longitude: req.DropoffLongitude},
+	)
+	if err != nil {
+		logger.WarnContext(ctx, "stop optimization failed, keeping rider order", zap.Error(err))
+		return inputs
+	}
+
+	ordered, ok := applyStopOrder(inputs, order)
+	if !ok {
+		logger.WarnContext(ctx, "stop optimizer returned an invalid order, keeping rider order",
+			zap.Ints("order", order))
+		return inputs
+	}
+	return ordered
+}
+
+// applyStopOrder reorders stops by index. It returns false unless order is a
+// permutation of the stop indexes.
+func applyStopOrder(stops []models.RideStopInput, order []int) ([]models.RideStopInput, bool) {
+	if len(order) != len(stops) {
+		return nil, false
+	}
+	seen := make([]bool, len(stops))
+	ordered := make([]models.RideStopInput, 0, len(stops))
+	for _, idx := range order {
+		if idx < 0 || idx >= len(stops) || seen[idx] {
+			return nil, false
+		}
+		seen[idx] = true
+		ordered = append(ordered, stops[idx])
+	}
+	return ordered, true
+}
+
+// routeLegs splits a route into legs from pickup through each stop to dropoff.
+// Skipped stops are left out of the route.
+func routeLegs(pickupLatitude, pickupLongitude float64, stops []*models.RideStop, dropoffLatitude, dropoffLongitude float64) []pricing.LegInput {
+	legs := make([]pricing.LegInput, 0, len(stops)+1)
+	fromLatitude, fromLongitude := pickupLatitude, pickupLongitude
+
+	addLeg := func(toLatitude, toLongitude float64) {
+		distance := calculateDistance(fromLatitude, fromLongitude, toLatitude, toLongitude)
+		legs = append(legs, pricing.LegInput{DistanceKm: distance, DurationMin: estimateDuration(distance)})
+		fromLatitude, fromLongitude = toLatitude, toLongitude
+	}
+
+	for _, stop := range stops {
+		if stop.Status == models.RideStopStatusSkipped {
+			continue
+		}
+		addLeg(stop.Latitude, stop.Longitude)
+	}
+	addLeg(dropoffLatitude, dropoffLongitude)
+
+	return legs
+}
+
+// legTotals sums the distance and duration of a route's legs
+func legTotals(legs []pricing.LegInput) (float64, int) {
+	var distance float64
+	var duration int
+	for _, leg := range legs {
+		distance += leg.DistanceKm
+		duration += leg.DurationMin
+	}
+	return distance, duration
+}
+
+// completedLegs splits a finished trip into legs through the stops the
+// driver reached, sharing the actual distance and driving time between the
+// legs in proportion to their straight-line length. It returns nil when no
+// stop was reached, as such trips are priced on their totals.
+func completedLegs(ride *models.Ride, stops []*models.RideStop, distanceKm float64, durationMin int) []pricing.LegInput {
+	visited := make([]*models.RideStop, 0, len(stops))
+	for _, stop := range stops {
+		if stop.ArrivedAt != nil && stop.Status != models.RideStopStatusSkipped {
+			visited = append(visited, stop)
+		}
+	}
+	if len(visited) == 0 {
+		return nil
+	}
+
+	legs := routeLegs(ride.PickupLatitude, ride.PickupLongitude, visited, ride.DropoffLatitude, ride.DropoffLongitude)
+	planned, _ := legTotals(legs)
+	if planned <= 0 {
+		return nil
+	}
+
+	remaining := durationMin
+	for i := range legs {
+		share := legs[i].DistanceKm / planned
+		legs[i].DistanceKm = distanceKm * share
+		// The last leg takes what rounding left, so the minutes add up
+		minutes := remaining
+		if i < len(legs)-1 {
+			minutes = min(int(math.Round(float64(durationMin)*share)), remaining)
+		}
+		legs[i].DurationMin = minutes
+		remaining -= minutes
+	}
+	return legs
+}
+
+// billableStopWait returns the minutes of a stop wait that exceed the free
+// allowance.
+func billableStopWait(arrivedAt, departedAt time.Time) int {
+	minutes := int(departedAt.Sub(arrivedAt).Minutes()) - stopFreeWaitMinutes
+	if minutes < 0 {
+		return 0
+	}
+	return minutes
+}
+
+// stopWaitTotals returns the total minutes spent at stops the driver has left
+// and the billable part of that wait.
+func stopWaitTotals(stops []*models.RideStop) (dwellMinutes, billableMinutes int) {
+	for _, stop := range stops {
+		if stop.ArrivedAt == nil || stop.DepartedAt == nil {
+			continue
+		}
+		dwellMinutes += int(stop.DepartedAt.Sub(*stop.ArrivedAt).Minutes())
+		billableMinutes += stop.WaitMinutes
+	}
+	return dwellMinutes, billableMinutes
+}
+
+// AddStop appends an intermediate stop to an active ride, before the dropoff,
+// and re-estimates the fare for the new route.
+func (s *Service) AddStop(ctx context.Context, rideID, riderID uuid.UUID, input *models.RideStopInput) (*models.Ride, error) {
+	ride, err := s.repo.GetRideByID(ctx, rideID)
+	if err != nil {
+		return nil, common.NewNotFoundError("ride not found", nil)
+	}
+
+	if ride.RiderID != riderID {
+		return nil, common.NewForbiddenError("not your ride")
+	}
+
+	if IsTerminal(ride.Status) {
+		return nil, common.NewErrorWithCode(409, common.ErrCodeRideAlreadyDone,
+			fmt.Sprintf("ride is already %s", ride.Status), nil)
+	}
+
+	stops, err := s.repo.GetRideStops(ctx, rideID)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to get ride stops")
+	}
+	if len(stops) >= models.MaxRideStops {
+		return nil, common.NewBadRequestError(
+			fmt.Sprintf("a ride can have at most %d stops", models.MaxRideStops), nil)
+	}
+
+	stop := &models.RideStop{
+		ID:           uuid.New(),
+		RideID:       rideID,
+		StopOrder:    len(stops) + 1,
+		Latitude:     input.Latitude,
+		Longitude:    input.Longitude,
+		Address:      input.Address,
+		Status:       models.RideStopStatusPending,
+		AddedMidTrip: ride.Status == models.RideStatusInProgress,
+		CreatedAt:    time.Now(),
+	}
+	stops = append(stops, stop)
+
+	legs := routeLegs(ride.PickupLatitude, ride.PickupLongitude, stops, ride.DropoffLatitude, ride.DropoffLongitude)
+	distance, duration := legTotals(legs)
+	quote := s.quoteFare(ctx, ride.PickupLatitude, ride.PickupLongitude, ride.DropoffLatitude, ride.DropoffLongitude,
+		ride.RideTypeID, ride.RiderID, ride.PricingVersionID, distance, duration, legs)
+
+	// The promo discount was fixed when the ride was requested
+	fare := quote.Fare - ride.DiscountAmount
+	if fare < 0 {
+		fare = 0
+	}
+
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideStopUpdated, "ride.stop_added", stopEventData(ride, stop, fare))
+	added, err := s.repo.AddRideStop(ctx, stop, distance, duration, fare, evt)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to add stop")
+	}
+	if !added {
+		return nil, common.NewConflictError("ride changed while adding the stop, please retry")
+	}
+
+	// The upfront quote did not cover the new route
+	s.releaseFareQuote(ctx, rideID, "stop added")
+
+	ride.EstimatedDistance = distance
+	ride.EstimatedDuration = duration
+	ride.EstimatedFare = fare
+	ride.Stops = stops
+
+	return ride, nil
+}
+
+// UpdateStopStatus records the assigned driver's progress through an
+// intermediate stop. Waiting beyond the free allowance is billed when the
+// driver leaves the stop.
+func (s *Service) UpdateStopStatus(ctx context.Context, rideID, stopID, driverID uuid.UUID, status models.RideStopStatus) (*models.RideStop, error) {
+	ride, err := s.repo.GetRideByID(ctx, rideID)
+	if err != nil {
+		return nil, common.NewNotFoundError("ride not found", nil)
+	}
+
+	if ride.DriverID == nil || *ride.DriverID != driverID {
+		return nil, common.NewBadRequestError("unauthorized driver", nil)
+	}
+
+	if ride.Status != models.RideStatusInProgress {
+		return nil, common.NewErrorWithCode(409, common.ErrCodeRideInvalidTransition,
+			"stops can only be updated while the ride is in progress", nil)
+	}
+
+	stops, err := s.repo.GetRideStops(ctx, rideID)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to get ride stops")
+	}
+	var stop *models.RideStop
+	for _, st := range stops {
+		if st.ID == stopID {
+			stop = st
+			break
+		}
+	}
+	if stop == nil {
+		return nil, common.NewNotFoundError("stop not found", nil)
+	}
+
+	from := stop.Status
+	if !canTransitionStop(from, status) {
+		return nil, common.NewConflictError(fmt.Sprintf("stop cannot move from %s to %s", from, status))
+	}
+
+	now := time.Now()
+	stop.Status = status
+	switch status {
+	case models.RideStopStatusArrived:
+		stop.ArrivedAt = &now
+	case models.RideStopStatusCompleted, models.RideStopStatusSkipped:
+		stop.DepartedAt = &now
+		if stop.ArrivedAt != nil {
+			stop.WaitMinutes = billableStopWait(*stop.ArrivedAt, now)
+		}
+	}
+
+	evt := s.newOutboxEvent(rideID, eventbus.SubjectRideStopUpdated, "ride.stop_updated", stopEventData(ride, stop, ride.EstimatedFare))
+	updated, err := s.repo.UpdateRideStopStatus(ctx, stop, []string{string(from)}, evt)
+	if err != nil {
+		return nil, common.NewInternalServerError("failed to update stop")
+	}
+	if !updated {
+		return nil, common.NewConflictError("stop was updated by another request, please retry")
+	}
+
+	return stop, nil
+}
+
+// stopEventData builds the stop event the realtime service relays to the
+// rider and driver.
+func stopEventData(ride *models.Ride, stop *models.RideStop, estimatedFare float64) eventbus.RideStopUpdatedData {
+	var driverID uuid.UUID
+	if ride.DriverID != nil {
+		driverID = *ride.DriverID
+	}
+	return eventbus.RideStopUpdatedData{
+		RideID:        ride.ID,
+		RiderID:       ride.RiderID,
+		DriverID:      driverID,
+		StopID:        stop.ID,
+		StopOrder:     stop.StopOrder,
+		Latitude:      stop.Latitude,
+		Longitude:     stop.Longitude,
+		Address:       stop.Address,
+		Status:        string(stop.Status),
+		WaitMinutes:   stop.WaitMinutes,
+		AddedMidTrip:  stop.AddedMidTrip,
+		ArrivedAt:     stop.ArrivedAt,
+		DepartedAt:    stop.DepartedAt,
+		EstimatedFare: estimatedFare,
+	}
+}
@@ -0,0 +1,184 @@
+package rides
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/richxcame/ride-hailing/internal/maps"
+	"github.com/richxcame/ride-hailing/pkg/common"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWaypointOptimizer struct {
+	order []int
+	err   error
+	calls int
+}
+
+func (f *fakeWaypointOptimizer) OptimizeWaypoints(_ context.Context, _ maps.Coordinate, _ []maps.Coordinate, _ maps.Coordinate) ([]int, error) {
+	f.calls++
+	return f.order, f.err
+}
+
+func stopRequest(optimize bool) *models.RideRequest {
+	return &models.RideRequest{
+		PickupLatitude:   40.7128,
+		PickupLongitude:  -74.0060,
+		DropoffLatitude:  40.7580,
+		DropoffLongitude: -73.9855,
+		Stops: []models.RideStopInput{
+			{Latitude: 40.7300, Longitude: -73.9950, Address: "A"},
+			{Latitude: 40.7200, Longitude: -74.0000, Address: "B"},
+			{Latitude: 40.7500, Longitude: -73.9900, Address: "C"},
+		},
+		OptimizeStops: optimize,
+	}
+}
+
+func stopAddresses(stops []*models.RideStop) []string {
+	var out []string
+	for _, s := range stops {
+		out = append(out, s.Address)
+	}
+	return out
+}
+
+func TestBuildRequestStops(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("keeps rider order by default", func(t *testing.T) {
+		opt := &fakeWaypointOptimizer{order: []int{1, 0, 2}}
+		svc := &Service{waypointOptimizer: opt}
+
+		stops, err := svc.buildRequestStops(ctx, stopRequest(false))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"A", "B", "C"}, stopAddresses(stops))
+		assert.Equal(t, 0, opt.calls)
+		for i, s := range stops {
+			assert.Equal(t, i+1, s.StopOrder)
+			assert.Equal(t, models.RideStopStatusPending, s.Status)
+		}
+	})
+
+	t.Run("applies optimized order when requested", func(t *testing.T) {
+		svc := &Service{waypointOptimizer: &fakeWaypointOptimizer{order: []int{1, 0, 2}}}
+
+		stops, err := svc.buildRequestStops(ctx, stopRequest(true))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"B", "A", "C"}, stopAddresses(stops))
+		assert.Equal(t, 1, stops[0].StopOrder)
+	})
+
+	t.Run("falls back to rider order when optimizer fails", func(t *testing.T) {
+		for _, opt := range []*fakeWaypointOptimizer{
+			{err: errors.New("maps down")},
+			{order: []int{0, 0, 1}},
+			{order: []int{2, 1}},
+		} {
+			svc := &Service{waypointOptimizer: opt}
+			stops, err := svc.buildRequestStops(ctx, stopRequest(true))
+			require.NoError(t, err)
+			assert.Equal(t, []string{"A", "B", "C"}, stopAddresses(stops))
+		}
+	})
+
+	t.Run("rejects too many stops", func(t *testing.T) {
+		req := stopRequest(false)
+		req.Stops = append(req.Stops, req.Stops[0])
+		_, err := (&Service{}).buildRequestStops(ctx, req)
+		var appErr *common.AppError
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, 400, appErr.Code)
+	})
+}
+
+func TestRouteLegs(t *testing.T) {
+	req := stopRequest(false)
+	stops, err := (&Service{}).buildRequestStops(context.Background(), req)
+	require.NoError(t, err)
+
+	legs := routeLegs(req.PickupLatitude, req.PickupLongitude, stops, req.DropoffLatitude, req.DropoffLongitude)
+	require.Len(t, legs, 4)
+	assert.InDelta(t, calculateDistance(req.PickupLatitude, req.PickupLongitude, 40.7300, -73.9950), legs[0].DistanceKm, 1e-9)
+	assert.InDelta(t, calculateDistance(40.7500, -73.9900, req.DropoffLatitude, req.DropoffLongitude), legs[3].DistanceKm, 1e-9)
+
+	distance, duration := legTotals(legs)
+	direct := calculateDistance(req.PickupLatitude, req.PickupLongitude, req.DropoffLatitude, req.DropoffLongitude)
+	assert.Greater(t, distance, direct)
+	assert.Equal(t, legs[0].DurationMin+legs[1].DurationMin+legs[2].DurationMin+legs[3].DurationMin, duration)
+
+	// Skipped stops drop out of the route
+	stops[1].Status = models.RideStopStatusSkipped
+	legs = routeLegs(req.PickupLatitude, req.PickupLongitude, stops, req.DropoffLatitude, req.DropoffLongitude)
+	assert.Len(t, legs, 3)
+	assert.InDelta(t, calculateDistance(40.7300, -73.9950, 40.7500, -73.9900), legs[1].DistanceKm, 1e-9)
+
+	// No stops is a single pickup-to-dropoff leg
+	legs = routeLegs(req.PickupLatitude, req.PickupLongitude, nil, req.DropoffLatitude, req.DropoffLongitude)
+	require.Len(t, legs, 1)
+	assert.InDelta(t, direct, legs[0].DistanceKm, 1e-9)
+}
+
+func TestCompletedLegs(t *testing.T) {
+	req := stopRequest(false)
+	stops, err := (&Service{}).buildRequestStops(context.Background(), req)
+	require.NoError(t, err)
+	ride := &models.Ride{
+		PickupLatitude: req.PickupLatitude, PickupLongitude: req.PickupLongitude,
+		DropoffLatitude: req.DropoffLatitude, DropoffLongitude: req.DropoffLongitude,
+	}
+
+	// No stop reached: priced on the trip totals
+	assert.Nil(t, completedLegs(ride, stops, 12, 30))
+
+	arrived := time.Now()
+	stops[0].ArrivedAt = &arrived
+	stops[0].Status = models.RideStopStatusCompleted
+	stops[2].ArrivedAt = &arrived
+	stops[2].Status = models.RideStopStatusCompleted
+	legs := completedLegs(ride, stops, 12, 31)
+	require.Len(t, legs, 3)
+
+	// Legs share the actual totals in proportion to the route through the visited stops
+	planned := routeLegs(req.PickupLatitude, req.PickupLongitude, []*models.RideStop{stops[0], stops[2]},
+		req.DropoffLatitude, req.DropoffLongitude)
+	plannedDistance, _ := legTotals(planned)
+	distance, duration := legTotals(legs)
+	assert.InDelta(t, 12, distance, 1e-9)
+	assert.Equal(t, 31, duration)
+	assert.InDelta(t, 12*planned[0].DistanceKm/plannedDistance, legs[0].DistanceKm, 1e-9)
+}
+
+func TestCanTransitionStop(t *testing.T) {
+	assert.True(t, canTransitionStop(models.RideStopStatusPending, models.RideStopStatusArrived))
+	assert.True(t, canTransitionStop(models.RideStopStatusPending, models.RideStopStatusSkipped))
+	assert.True(t, canTransitionStop(models.RideStopStatusArrived, models.RideStopStatusCompleted))
+	assert.False(t, canTransitionStop(models.RideStopStatusPending, models.RideStopStatusCompleted))
+	assert.False(t, canTransitionStop(models.RideStopStatusCompleted, models.RideStopStatusArrived))
+	assert.False(t, canTransitionStop(models.RideStopStatusSkipped, models.RideStopStatusArrived))
+}
+
+func TestStopWait(t *testing.T) {
+	arrived := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 0, billableStopWait(arrived, arrived.Add(2*time.Minute)))
+	assert.Equal(t, 0, billableStopWait(arrived, arrived.Add(stopFreeWaitMinutes*time.Minute)))
+	assert.Equal(t, 5, billableStopWait(arrived, arrived.Add(8*time.Minute+30*time.Second)))
+
+	departed1 := arrived.Add(8 * time.Minute)
+	arrived2 := arrived.Add(20 * time.Minute)
+	departed2 := arrived2.Add(2 * time.Minute)
+	stops := []*models.RideStop{
+		{ArrivedAt: &arrived, DepartedAt: &departed1, WaitMinutes: 5},
+		{ArrivedAt: &arrived2, DepartedAt: &departed2, WaitMinutes: 0},
+		{ArrivedAt: &arrived2}, // still at the stop
+		{},                     // not reached yet
+	}
+	dwell, billable := stopWaitTotals(stops)
+	assert.Equal(t, 10, dwell)
+	assert.Equal(t, 5, billable)
+}
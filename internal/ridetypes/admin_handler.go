@@ -73,12 +73,13 @@ func (h *AdminHandler) CreateRideType(c *gin.Context) {
 	}
 
 	rt := &RideType{
-		Name:        req.Name,
-		Description: req.Description,
-		Icon:        req.Icon,
-		Capacity:    req.Capacity,
-		SortOrder:   req.SortOrder,
-		IsActive:    req.IsActive,
+		Name:              req.Name,
+		Description:       req.Description,
+		Icon:              req.Icon,
+		Capacity:          req.Capacity,
+		VehicleCategories: req.VehicleCategories,
+		SortOrder:         req.SortOrder,
+		IsActive:          req.IsActive,
 	}
 
 	if err := h.service.CreateRideType(c.Request.Context(), rt); err != nil {
@@ -138,6 +139,9 @@ func (h *AdminHandler) UpdateRideType(c *gin.Context) {
 	if req.Capacity != nil {
 		rt.Capacity = *req.Capacity
 	}
+	if req.VehicleCategories != nil {
+		rt.VehicleCategories = *req.VehicleCategories
+	}
 	if req.SortOrder != nil {
 		rt.SortOrder = *req.SortOrder
 	}
@@ -10,15 +10,16 @@ import (
 // Pricing is managed through the hierarchical pricing engine (pricing_configs),
 // NOT on the ride type itself.
 type RideType struct {
-	ID          uuid.UUID `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description *string   `json:"description,omitempty" db:"description"`
-	Icon        *string   `json:"icon,omitempty" db:"icon"`
-	Capacity    int       `json:"capacity" db:"capacity"`
-	SortOrder   int       `json:"sort_order" db:"sort_order"`
-	IsActive    bool      `json:"is_active" db:"is_active"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID                uuid.UUID `json:"id" db:"id"`
+	Name              string    `json:"name" db:"name"`
+	Description       *string   `json:"description,omitempty" db:"description"`
+	Icon              *string   `json:"icon,omitempty" db:"icon"`
+	Capacity          int       `json:"capacity" db:"capacity"`
+	VehicleCategories []string  `json:"vehicle_categories" db:"vehicle_categories"` // Vehicle categories that can serve this type; empty allows any
+	SortOrder         int       `json:"sort_order" db:"sort_order"`
+	IsActive          bool      `json:"is_active" db:"is_active"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // CountryRideType maps a ride type to a country (country-level availability).
@@ -63,22 +64,24 @@ type CityRideTypeWithDetails struct {
 
 // CreateRideTypeRequest is the request body for creating a ride type
 type CreateRideTypeRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description *string `json:"description,omitempty"`
-	Icon        *string `json:"icon,omitempty"`
-	Capacity    int     `json:"capacity" binding:"required,gt=0"`
-	SortOrder   int     `json:"sort_order"`
-	IsActive    bool    `json:"is_active"`
+	Name              string   `json:"name" binding:"required"`
+	Description       *string  `json:"description,omitempty"`
+	Icon              *string  `json:"icon,omitempty"`
+	Capacity          int      `json:"capacity" binding:"required,gt=0"`
+	VehicleCategories []string `json:"vehicle_categories,omitempty" binding:"dive,oneof=economy comfort premium lux xl wav electric"`
+	SortOrder         int      `json:"sort_order"`
+	IsActive          bool     `json:"is_active"`
 }
 
 // UpdateRideTypeRequest is the request body for updating a ride type
 type UpdateRideTypeRequest struct {
-	Name        *string `json:"name,omitempty"`
-	Description *string `json:"description,omitempty"`
-	Icon        *string `json:"icon,omitempty"`
-	Capacity    *int    `json:"capacity,omitempty"`
-	SortOrder   *int    `json:"sort_order,omitempty"`
-	IsActive    *bool   `json:"is_active,omitempty"`
+	Name              *string   `json:"name,omitempty"`
+	Description       *string   `json:"description,omitempty"`
+	Icon              *string   `json:"icon,omitempty"`
+	Capacity          *int      `json:"capacity,omitempty"`
+	VehicleCategories *[]string `json:"vehicle_categories,omitempty" binding:"omitempty,dive,oneof=economy comfort premium lux xl wav electric"`
+	SortOrder         *int      `json:"sort_order,omitempty"`
+	IsActive          *bool     `json:"is_active,omitempty"`
 }
 
 // CountryRideTypeRequest is the request body for adding a ride type to a country
@@ -21,13 +21,13 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 // CreateRideType creates a new ride type
 func (r *Repository) CreateRideType(ctx context.Context, rt *RideType) error {
 	query := `
-		INSERT INTO ride_types (id, name, description, icon, capacity, sort_order, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO ride_types (id, name, description, icon, capacity, vehicle_categories, sort_order, is_active)
+		VALUES ($1, $2, $3, $4, $5, COALESCE($6::text[], '{}'), $7, $8)
 		RETURNING created_at, updated_at
 	`
 	rt.ID = uuid.New()
 	err := r.db.QueryRow(ctx, query,
-		rt.ID, rt.Name, rt.Description, rt.Icon, rt.Capacity, rt.SortOrder, rt.IsActive,
+		rt.ID, rt.Name, rt.Description, rt.Icon, rt.Capacity, rt.VehicleCategories, rt.SortOrder, rt.IsActive,
 	).Scan(&rt.CreatedAt, &rt.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create ride type: %w", err)
@@ -38,12 +38,12 @@ func (r *Repository) CreateRideType(ctx context.Context, rt *RideType) error {
 // GetRideTypeByID retrieves a ride type by ID
 func (r *Repository) GetRideTypeByID(ctx context.Context, id uuid.UUID) (*RideType, error) {
 	query := `
-		SELECT id, name, description, icon, capacity, sort_order, is_active, created_at, updated_at
+		SELECT id, name, description, icon, capacity, vehicle_categories, sort_order, is_active, created_at, updated_at
 		FROM ride_types WHERE id = $1
 	`
 	rt := &RideType{}
 	err := r.db.QueryRow(ctx, query, id).Scan(
-		&rt.ID, &rt.Name, &rt.Description, &rt.Icon, &rt.Capacity, &rt.SortOrder,
+		&rt.ID, &rt.Name, &rt.Description, &rt.Icon, &rt.Capacity, &rt.VehicleCategories, &rt.SortOrder,
 		&rt.IsActive, &rt.CreatedAt, &rt.UpdatedAt,
 	)
 	if err != nil {
@@ -66,7 +66,7 @@ func (r *Repository) ListRideTypes(ctx context.Context, limit, offset int, inclu
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, name, description, icon, capacity, sort_order, is_active, created_at, updated_at
+		SELECT id, name, description, icon, capacity, vehicle_categories, sort_order, is_active, created_at, updated_at
 		FROM ride_types %s
 		ORDER BY sort_order, name
 		LIMIT $1 OFFSET $2
@@ -82,7 +82,7 @@ func (r *Repository) ListRideTypes(ctx context.Context, limit, offset int, inclu
 	for rows.Next() {
 		rt := &RideType{}
 		err := rows.Scan(
-			&rt.ID, &rt.Name, &rt.Description, &rt.Icon, &rt.Capacity, &rt.SortOrder,
+			&rt.ID, &rt.Name, &rt.Description, &rt.Icon, &rt.Capacity, &rt.VehicleCategories, &rt.SortOrder,
 			&rt.IsActive, &rt.CreatedAt, &rt.UpdatedAt,
 		)
 		if err != nil {
@@ -98,12 +98,13 @@ func (r *Repository) UpdateRideType(ctx context.Context, rt *RideType) error {
 	query := `
 		UPDATE ride_types SET
 			name = $2, description = $3, icon = $4, capacity = $5,
-			sort_order = $6, is_active = $7, updated_at = CURRENT_TIMESTAMP
+			vehicle_categories = COALESCE($6::text[], '{}'),
+			sort_order = $7, is_active = $8, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
 		RETURNING updated_at
 	`
 	err := r.db.QueryRow(ctx, query,
-		rt.ID, rt.Name, rt.Description, rt.Icon, rt.Capacity, rt.SortOrder, rt.IsActive,
+		rt.ID, rt.Name, rt.Description, rt.Icon, rt.Capacity, rt.VehicleCategories, rt.SortOrder, rt.IsActive,
 	).Scan(&rt.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update ride type: %w", err)
@@ -308,7 +309,7 @@ func (r *Repository) GetAvailableRideTypes(ctx context.Context, countryID, cityI
 	// Try city-level first
 	if cityID != nil {
 		query := `
-			SELECT rt.id, rt.name, rt.description, rt.icon, rt.capacity,
+			SELECT rt.id, rt.name, rt.description, rt.icon, rt.capacity, rt.vehicle_categories,
 			       COALESCE(crt.sort_order, rt.sort_order) AS sort_order,
 			       rt.is_active, rt.created_at, rt.updated_at
 			FROM ride_types rt
@@ -327,7 +328,7 @@ func (r *Repository) GetAvailableRideTypes(ctx context.Context, countryID, cityI
 	// Try country-level
 	if countryID != nil {
 		query := `
-			SELECT rt.id, rt.name, rt.description, rt.icon, rt.capacity,
+			SELECT rt.id, rt.name, rt.description, rt.icon, rt.capacity, rt.vehicle_categories,
 			       COALESCE(crt.sort_order, rt.sort_order) AS sort_order,
 			       rt.is_active, rt.created_at, rt.updated_at
 			FROM ride_types rt
@@ -345,7 +346,7 @@ func (r *Repository) GetAvailableRideTypes(ctx context.Context, countryID, cityI
 
 	// Global fallback: all active ride types
 	query := `
-		SELECT id, name, description, icon, capacity, sort_order,
+		SELECT id, name, description, icon, capacity, vehicle_categories, sort_order,
 		       is_active, created_at, updated_at
 		FROM ride_types
 		WHERE is_active = true
@@ -366,7 +367,7 @@ func (r *Repository) scanRideTypes(ctx context.Context, query string, args ...in
 	for rows.Next() {
 		rt := &RideType{}
 		err := rows.Scan(
-			&rt.ID, &rt.Name, &rt.Description, &rt.Icon, &rt.Capacity, &rt.SortOrder,
+			&rt.ID, &rt.Name, &rt.Description, &rt.Icon, &rt.Capacity, &rt.VehicleCategories, &rt.SortOrder,
 			&rt.IsActive, &rt.CreatedAt, &rt.UpdatedAt,
 		)
 		if err != nil {
@@ -0,0 +1,724 @@
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/internal/maps"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	tripStatePrefix = "safety:trip:" // Maps driver_id -> monitored trip
+	tripStateTTL    = 4 * time.Hour
+
+	// Attempts to save a lifecycle change before leaving it to redelivery
+	maxTripSaveAttempts = 3
+)
+
+// errTripConflict is returned when a trip was saved by another event since
+// it was loaded
+var errTripConflict = errors.New("monitored trip changed concurrently")
+
+// RoutePlanner plans the route a trip is expected to follow. *maps.Service
+// implements it.
+type RoutePlanner interface {
+	GetRoute(ctx context.Context, req *maps.RouteRequest) (*maps.RouteResponse, error)
+}
+
+// AnomalyConfig tunes the trip anomaly detector
+type AnomalyConfig struct {
+	// Consecutive off-route locations needed before a deviation is raised,
+	// so a single bad GPS fix doesn't alert the rider
+	DeviationConfirmations int
+
+	// Speed the driver must stay above for SpeedingMinDuration before a
+	// speed alert is raised
+	SpeedLimitKmh       int
+	SpeedingMinDuration time.Duration
+
+	// The driver counts as stopped while staying within this radius
+	StopRadiusMeters float64
+
+	// Minimum time between two alerts of the same kind on one trip
+	AlertCooldown time.Duration
+}
+
+// DefaultAnomalyConfig returns the default detector configuration
+func DefaultAnomalyConfig() AnomalyConfig {
+	return AnomalyConfig{
+		DeviationConfirmations: 2,
+		SpeedLimitKmh:          120,
+		SpeedingMinDuration:    30 * time.Second,
+		StopRadiusMeters:       50,
+		AlertCooldown:          10 * time.Minute,
+	}
+}
+
+// monitoredTrip is the detector's state for a driver's current ride
+type monitoredTrip struct {
+	RideID   uuid.UUID   `json:"ride_id"`
+	RiderID  uuid.UUID   `json:"rider_id"`
+	DriverID uuid.UUID   `json:"driver_id"`
+	Pickup   *Coordinate `json:"pickup,omitempty"`
+	Dropoff  *Coordinate `json:"dropoff,omitempty"`
+	Stops    []tripStop  `json:"stops,omitempty"` // In visiting order
+	Started  bool        `json:"started"`
+
+	// Ended marks a completed or cancelled ride, kept until the TTL so late
+	// events for it don't start monitoring it again
+	Ended bool `json:"ended"`
+
+	// Version of the stored trip this was loaded from; 0 when not stored
+	Version int64 `json:"-"`
+
+	// Planned route; empty when it couldn't be planned, which turns route
+	// deviation checks off for the trip
+	Route        []Coordinate `json:"route,omitempty"`
+	RoutePlanned bool         `json:"route_planned"`
+
+	// Rider's alert preferences when the trip started
+	DeviationAlerts bool `json:"deviation_alerts"`
+	SpeedAlerts     bool `json:"speed_alerts"`
+	LongStopMins    int  `json:"long_stop_mins"`
+
+	LastSeen      time.Time                     `json:"last_seen"`
+	OffRoute      int                           `json:"off_route"` // Consecutive off-route locations
+	SpeedingSince *time.Time                    `json:"speeding_since,omitempty"`
+	StopAt        *Coordinate                   `json:"stop_at,omitempty"`
+	StoppedSince  *time.Time                    `json:"stopped_since,omitempty"`
+	StopAlerted   bool                          `json:"stop_alerted"`
+	LastAlerts    map[SafetyCheckType]time.Time `json:"last_alerts,omitempty"`
+}
+
+// tripStop is an intermediate stop the route is planned through
+type tripStop struct {
+	ID       uuid.UUID  `json:"id"`
+	Order    int        `json:"order"`
+	Location Coordinate `json:"location"`
+}
+
+// setStop adds or moves a stop, keeping stops in visiting order. It reports
+// whether the stops changed.
+func (t *monitoredTrip) setStop(stop tripStop) bool {
+	for i, existing := range t.Stops {
+		if existing.ID == stop.ID {
+			if existing == stop {
+				return false
+			}
+			t.Stops = append(t.Stops[:i], t.Stops[i+1:]...)
+			break
+		}
+	}
+	i := sort.Search(len(t.Stops), func(i int) bool { return t.Stops[i].Order > stop.Order })
+	t.Stops = append(t.Stops, tripStop{})
+	copy(t.Stops[i+1:], t.Stops[i:])
+	t.Stops[i] = stop
+	return true
+}
+
+// removeStop drops a stop and reports whether the trip had it
+func (t *monitoredTrip) removeStop(id uuid.UUID) bool {
+	for i, existing := range t.Stops {
+		if existing.ID == id {
+			t.Stops = append(t.Stops[:i], t.Stops[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// AnomalyDetector watches driver locations during active rides and raises
+// route deviation alerts, speed alerts and long-stop safety checks according
+// to the rider's safety settings
+type AnomalyDetector struct {
+	service *Service
+	planner RoutePlanner
+	config  AnomalyConfig
+	trips   tripStore
+}
+
+// NewAnomalyDetector creates a detector that raises alerts through the safety
+// service. planner may be nil, in which case route deviation isn't checked.
+func NewAnomalyDetector(service *Service, planner RoutePlanner, config AnomalyConfig) *AnomalyDetector {
+	return &AnomalyDetector{
+		service: service,
+		planner: planner,
+		config:  config,
+		trips:   &memoryTripStore{trips: make(map[uuid.UUID]storedTrip)},
+	}
+}
+
+// SetRedis keeps trip state in Redis, so every instance consuming the shared
+// event subscriptions sees the same trips
+func (d *AnomalyDetector) SetRedis(client redis.Cmdable) {
+	d.trips = &redisTripStore{client: client, script: redis.NewScript(saveTripScript)}
+}
+
+// RegisterSubscriptions subscribes to ride lifecycle and driver location events on the bus.
+func (d *AnomalyDetector) RegisterSubscriptions(ctx context.Context, bus *eventbus.Bus) error {
+	subscriptions := []struct {
+		subject  string
+		consumer string
+		handler  eventbus.HandlerFunc
+	}{
+		{eventbus.SubjectRideAccepted, "safety-ride-accepted", d.handleRideAccepted},
+		{eventbus.SubjectRideStarted, "safety-ride-started", d.handleRideStarted},
+		{eventbus.SubjectRideStopUpdated, "safety-ride-stops", d.handleStopUpdated},
+		{eventbus.SubjectRideCompleted, "safety-ride-completed", d.handleRideCompleted},
+		{eventbus.SubjectRideCancelled, "safety-ride-cancelled", d.handleRideCancelled},
+		{eventbus.SubjectDriverLocationUpdated, "safety-driver-location", d.handleLocationUpdated},
+	}
+	for _, sub := range subscriptions {
+		if err := bus.Subscribe(ctx, sub.subject, sub.consumer, sub.handler); err != nil {
+			return fmt.Errorf("subscribe to %s: %w", sub.subject, err)
+		}
+	}
+	logger.Info("safety: subscribed to ride and driver location events")
+	return nil
+}
+
+func (d *AnomalyDetector) handleRideAccepted(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideAcceptedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride accepted: %w", err)
+	}
+
+	return d.updateTrip(ctx, data.DriverID, data.RideID, func(trip *monitoredTrip) bool {
+		trip.RiderID = data.RiderID
+		trip.Pickup = &Coordinate{Latitude: data.PickupLatitude, Longitude: data.PickupLongitude}
+		trip.Dropoff = &Coordinate{Latitude: data.DropoffLatitude, Longitude: data.DropoffLongitude}
+		for _, stop := range data.Stops {
+			trip.setStop(tripStop{
+				ID:       stop.StopID,
+				Order:    stop.StopOrder,
+				Location: Coordinate{Latitude: stop.Latitude, Longitude: stop.Longitude},
+			})
+		}
+
+		// The start may have been handled first
+		if trip.Started {
+			d.planRoute(ctx, trip)
+		}
+		return true
+	})
+}
+
+func (d *AnomalyDetector) handleRideStarted(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideStartedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride started: %w", err)
+	}
+
+	settings, err := d.service.GetSafetySettings(ctx, data.RiderID)
+	if err != nil {
+		return fmt.Errorf("get rider safety settings: %w", err)
+	}
+
+	return d.updateTrip(ctx, data.DriverID, data.RideID, func(trip *monitoredTrip) bool {
+		trip.RiderID = data.RiderID
+		trip.Started = true
+		trip.DeviationAlerts = settings.RouteDeviationAlert
+		trip.SpeedAlerts = settings.SpeedAlertEnabled
+		trip.LongStopMins = settings.LongStopAlertMins
+
+		d.planRoute(ctx, trip)
+		return true
+	})
+}
+
+// handleStopUpdated re-plans the route when a stop is added to or skipped on
+// an assigned ride, so the driver isn't flagged for heading to it or past it
+func (d *AnomalyDetector) handleStopUpdated(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideStopUpdatedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride stop updated: %w", err)
+	}
+	// Stops added before a driver is assigned come with the accepted event
+	if data.DriverID == uuid.Nil {
+		return nil
+	}
+
+	return d.updateTrip(ctx, data.DriverID, data.RideID, func(trip *monitoredTrip) bool {
+		var changed bool
+		switch data.Status {
+		case "pending":
+			changed = trip.setStop(tripStop{
+				ID:       data.StopID,
+				Order:    data.StopOrder,
+				Location: Coordinate{Latitude: data.Latitude, Longitude: data.Longitude},
+			})
+		case "skipped":
+			changed = trip.removeStop(data.StopID)
+		}
+		if !changed {
+			return false
+		}
+
+		if trip.Started {
+			trip.Route = nil
+			trip.RoutePlanned = false
+			trip.OffRoute = 0
+			d.planRoute(ctx, trip)
+		}
+		return true
+	})
+}
+
+func (d *AnomalyDetector) handleRideCompleted(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideCompletedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride completed: %w", err)
+	}
+	return d.stopMonitoring(ctx, data.DriverID, data.RideID)
+}
+
+func (d *AnomalyDetector) handleRideCancelled(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideCancelledData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride cancelled: %w", err)
+	}
+	if data.DriverID == uuid.Nil {
+		return nil
+	}
+	return d.stopMonitoring(ctx, data.DriverID, data.RideID)
+}
+
+// handleLocationUpdated checks a location against the driver's active trip.
+// Failures are logged rather than retried, since the next location
+// supersedes this one. The location is dropped if the trip changed while it
+// was being checked, rather than overwriting that change. Alerts it makes due
+// are raised only once their cooldown is saved with the trip, so a dropped
+// location raises nothing and a redelivered one doesn't raise twice.
+func (d *AnomalyDetector) handleLocationUpdated(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.DriverLocationUpdatedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal driver location: %w", err)
+	}
+
+	trip, err := d.trips.get(ctx, data.DriverID)
+	if err != nil {
+		logger.Warn("safety: failed to load monitored trip", zap.String("driver_id", data.DriverID.String()), zap.Error(err))
+		return nil
+	}
+	if trip == nil || !trip.Started || trip.Ended {
+		return nil
+	}
+
+	at := data.Timestamp
+	if at.IsZero() {
+		at = event.Timestamp
+	}
+	// Late deliveries would corrupt the stop and speeding timers
+	if !at.After(trip.LastSeen) {
+		return nil
+	}
+	trip.LastSeen = at
+
+	point := Coordinate{Latitude: data.Latitude, Longitude: data.Longitude}
+	var alerts []*tripAlert
+	for _, alert := range []*tripAlert{
+		d.checkRoute(trip, point, at),
+		d.checkSpeed(trip, data.Speed, point, at),
+		d.checkStop(trip, point, at),
+	} {
+		if alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	err = d.trips.save(ctx, trip)
+	if errors.Is(err, errTripConflict) {
+		logger.Debug("safety: monitored trip changed, dropping location", zap.String("ride_id", trip.RideID.String()))
+		return nil
+	}
+	if err != nil {
+		logger.Warn("safety: failed to save monitored trip", zap.String("ride_id", trip.RideID.String()), zap.Error(err))
+		return nil
+	}
+
+	for _, alert := range alerts {
+		raised, err := alert.raise(ctx)
+		if err != nil {
+			logger.Warn("safety: failed to raise alert", zap.String("ride_id", trip.RideID.String()),
+				zap.String("type", string(alert.kind)), zap.Error(err))
+		}
+		if err != nil || !raised {
+			d.releaseCooldown(ctx, trip, alert.kind, at)
+		}
+	}
+	return nil
+}
+
+// tripAlert is an alert a location made due. The check that returns it has
+// already started the alert's cooldown on the trip.
+type tripAlert struct {
+	kind SafetyCheckType
+	// raise creates the alert, reporting false if the service decided
+	// against it
+	raise func(ctx context.Context) (bool, error)
+}
+
+// releaseCooldown clears the cooldown started for an alert that was not
+// raised, so the next location can try again
+func (d *AnomalyDetector) releaseCooldown(ctx context.Context, trip *monitoredTrip, kind SafetyCheckType, at time.Time) {
+	err := d.updateTrip(ctx, trip.DriverID, trip.RideID, func(t *monitoredTrip) bool {
+		last, ok := t.LastAlerts[kind]
+		if !ok || !last.Equal(at) {
+			return false
+		}
+		delete(t.LastAlerts, kind)
+		if kind == SafetyCheckLongStop {
+			t.StopAlerted = false
+		}
+		return true
+	})
+	if err != nil {
+		logger.Warn("safety: failed to release alert cooldown", zap.String("ride_id", trip.RideID.String()),
+			zap.String("type", string(kind)), zap.Error(err))
+	}
+}
+
+// tripFor returns the driver's monitored trip for rideID, starting a new one
+// if the driver has none or is still recorded on an earlier ride
+func (d *AnomalyDetector) tripFor(ctx context.Context, driverID, rideID uuid.UUID) (*monitoredTrip, error) {
+	trip, err := d.trips.get(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if trip == nil {
+		return &monitoredTrip{RideID: rideID, DriverID: driverID}, nil
+	}
+	if trip.RideID != rideID {
+		// Replaces the earlier ride's trip, so it takes over its version
+		return &monitoredTrip{RideID: rideID, DriverID: driverID, Version: trip.Version}, nil
+	}
+	return trip, nil
+}
+
+// updateTrip applies update to the driver's trip for rideID and saves it if
+// update reports a change. When another event saved the trip in between, the
+// trip is reloaded and update applied again. Ended trips are left alone.
+func (d *AnomalyDetector) updateTrip(ctx context.Context, driverID, rideID uuid.UUID, update func(*monitoredTrip) bool) error {
+	return d.retryOnConflict(func() error {
+		trip, err := d.tripFor(ctx, driverID, rideID)
+		if err != nil {
+			return fmt.Errorf("load trip: %w", err)
+		}
+		if trip.Ended || !update(trip) {
+			return nil
+		}
+		return d.trips.save(ctx, trip)
+	})
+}
+
+// stopMonitoring marks the driver's trip for rideID ended. The trip is kept
+// until it expires so that late events for the ride are ignored.
+func (d *AnomalyDetector) stopMonitoring(ctx context.Context, driverID, rideID uuid.UUID) error {
+	return d.retryOnConflict(func() error {
+		trip, err := d.trips.get(ctx, driverID)
+		if err != nil {
+			return fmt.Errorf("load trip: %w", err)
+		}
+		ended := &monitoredTrip{RideID: rideID, DriverID: driverID, Ended: true}
+		if trip != nil {
+			// The driver may already be on their next ride
+			if trip.RideID != rideID || trip.Ended {
+				return nil
+			}
+			ended.Version = trip.Version
+		}
+		return d.trips.save(ctx, ended)
+	})
+}
+
+func (d *AnomalyDetector) retryOnConflict(fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if !errors.Is(err, errTripConflict) || attempt == maxTripSaveAttempts {
+			return err
+		}
+	}
+}
+
+// planRoute plans the route from pickup through the stops to dropoff, once
+// per trip unless the stops change
+func (d *AnomalyDetector) planRoute(ctx context.Context, trip *monitoredTrip) {
+	if d.planner == nil || trip.RoutePlanned || trip.Pickup == nil || trip.Dropoff == nil {
+		return
+	}
+	trip.RoutePlanned = true
+
+	req := &maps.RouteRequest{
+		Origin:      maps.Coordinate{Latitude: trip.Pickup.Latitude, Longitude: trip.Pickup.Longitude},
+		Destination: maps.Coordinate{Latitude: trip.Dropoff.Latitude, Longitude: trip.Dropoff.Longitude},
+	}
+	for _, stop := range trip.Stops {
+		req.Waypoints = append(req.Waypoints, maps.Coordinate{Latitude: stop.Location.Latitude, Longitude: stop.Location.Longitude})
+	}
+	resp, err := d.planner.GetRoute(ctx, req)
+	if err != nil {
+		logger.Warn("safety: failed to plan route, route deviation checks disabled for ride",
+			zap.String("ride_id", trip.RideID.String()), zap.Error(err))
+		return
+	}
+	if len(resp.Routes) == 0 || len(resp.Routes[0].Coordinates) < 2 {
+		logger.Warn("safety: planned route has no geometry, route deviation checks disabled for ride",
+			zap.String("ride_id", trip.RideID.String()))
+		return
+	}
+
+	trip.Route = make([]Coordinate, len(resp.Routes[0].Coordinates))
+	for i, c := range resp.Routes[0].Coordinates {
+		trip.Route[i] = Coordinate{Latitude: c.Latitude, Longitude: c.Longitude}
+	}
+}
+
+func (d *AnomalyDetector) checkRoute(trip *monitoredTrip, point Coordinate, at time.Time) *tripAlert {
+	if !trip.DeviationAlerts || len(trip.Route) < 2 {
+		return nil
+	}
+
+	expected, meters := closestOnRoute(trip.Route, point)
+	if meters < routeDeviationThresholdMeters {
+		trip.OffRoute = 0
+		return nil
+	}
+	trip.OffRoute++
+	if trip.OffRoute < d.config.DeviationConfirmations || !d.cooledDown(trip, SafetyCheckRouteDeviation, at) {
+		return nil
+	}
+
+	trip.LastAlerts[SafetyCheckRouteDeviation] = at
+	rideID, driverID := trip.RideID, trip.DriverID
+	return &tripAlert{kind: SafetyCheckRouteDeviation, raise: func(ctx context.Context) (bool, error) {
+		alert, err := d.service.CheckRouteDeviation(ctx, rideID, driverID,
+			point.Latitude, point.Longitude, expected.Latitude, expected.Longitude)
+		return alert != nil, err
+	}}
+}
+
+func (d *AnomalyDetector) checkSpeed(trip *monitoredTrip, speedKmh float64, point Coordinate, at time.Time) *tripAlert {
+	if !trip.SpeedAlerts {
+		return nil
+	}
+
+	if speedKmh <= float64(d.config.SpeedLimitKmh) {
+		trip.SpeedingSince = nil
+		return nil
+	}
+	if trip.SpeedingSince == nil {
+		trip.SpeedingSince = &at
+		return nil
+	}
+	overFor := at.Sub(*trip.SpeedingSince)
+	if overFor < d.config.SpeedingMinDuration || !d.cooledDown(trip, SafetyCheckSpeedAlert, at) {
+		return nil
+	}
+
+	trip.LastAlerts[SafetyCheckSpeedAlert] = at
+	rideID, riderID, driverID := trip.RideID, trip.RiderID, trip.DriverID
+	return &tripAlert{kind: SafetyCheckSpeedAlert, raise: func(ctx context.Context) (bool, error) {
+		_, err := d.service.RaiseSpeedAlert(ctx, rideID, riderID, driverID,
+			speedKmh, d.config.SpeedLimitKmh, point.Latitude, point.Longitude, overFor)
+		return err == nil, err
+	}}
+}
+
+func (d *AnomalyDetector) checkStop(trip *monitoredTrip, point Coordinate, at time.Time) *tripAlert {
+	if trip.LongStopMins <= 0 {
+		return nil
+	}
+
+	// Moving away starts a new stop
+	if trip.StopAt == nil || distanceMeters(*trip.StopAt, point) > d.config.StopRadiusMeters {
+		trip.StopAt = &point
+		trip.StoppedSince = &at
+		trip.StopAlerted = false
+		return nil
+	}
+
+	// One check per stop
+	if trip.StopAlerted || at.Sub(*trip.StoppedSince) < time.Duration(trip.LongStopMins)*time.Minute {
+		return nil
+	}
+	if !d.cooledDown(trip, SafetyCheckLongStop, at) {
+		return nil
+	}
+
+	trip.StopAlerted = true
+	trip.LastAlerts[SafetyCheckLongStop] = at
+	rideID, riderID := trip.RideID, trip.RiderID
+	reason := fmt.Sprintf("Vehicle stopped for over %d minutes", trip.LongStopMins)
+	return &tripAlert{kind: SafetyCheckLongStop, raise: func(ctx context.Context) (bool, error) {
+		_, err := d.service.SendSafetyCheck(ctx, rideID, riderID, SafetyCheckLongStop, reason)
+		return err == nil, err
+	}}
+}
+
+// cooledDown reports whether enough time has passed since the trip's last
+// alert of this kind
+func (d *AnomalyDetector) cooledDown(trip *monitoredTrip, kind SafetyCheckType, at time.Time) bool {
+	if trip.LastAlerts == nil {
+		trip.LastAlerts = make(map[SafetyCheckType]time.Time)
+	}
+	last, ok := trip.LastAlerts[kind]
+	return !ok || at.Sub(last) >= d.config.AlertCooldown
+}
+
+// closestOnRoute returns the point on the route nearest to p and its distance
+// in meters. Segments are projected onto a plane around p, which is accurate
+// at the distances involved.
+func closestOnRoute(route []Coordinate, p Coordinate) (Coordinate, float64) {
+	metersPerDegree := 6371000.0 * math.Pi / 180.0
+	cosLatitude := math.Cos(p.Latitude * math.Pi / 180.0)
+	project := func(c Coordinate) (float64, float64) {
+		return (c.Longitude - p.Longitude) * metersPerDegree * cosLatitude, (c.Latitude - p.Latitude) * metersPerDegree
+	}
+
+	best := route[0]
+	bestDistance := math.Inf(1)
+	for i := 0; i+1 < len(route); i++ {
+		ax, ay := project(route[i])
+		bx, by := project(route[i+1])
+		dx, dy := bx-ax, by-ay
+
+		t := 0.0
+		if length := dx*dx + dy*dy; length > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+		}
+		x, y := ax+t*dx, ay+t*dy
+		if distance := math.Hypot(x, y); distance < bestDistance {
+			bestDistance = distance
+			best = Coordinate{
+				Latitude:  route[i].Latitude + t*(route[i+1].Latitude-route[i].Latitude),
+				Longitude: route[i].Longitude + t*(route[i+1].Longitude-route[i].Longitude),
+			}
+		}
+	}
+	return best, bestDistance
+}
+
+func distanceMeters(a, b Coordinate) float64 {
+	return haversineDistance(a.Latitude, a.Longitude, b.Latitude, b.Longitude) * 1000
+}
+
+// ========================================
+// TRIP STATE
+// ========================================
+
+// tripStore keeps monitored trips by driver ID
+type tripStore interface {
+	get(ctx context.Context, driverID uuid.UUID) (*monitoredTrip, error) // nil when the driver has none
+	// save stores the trip if the stored version still matches trip.Version,
+	// then bumps it; otherwise it returns errTripConflict
+	save(ctx context.Context, trip *monitoredTrip) error
+}
+
+// memoryTripStore keeps trips in process memory, for single-instance setups
+type memoryTripStore struct {
+	mu    sync.Mutex
+	trips map[uuid.UUID]storedTrip
+}
+
+type storedTrip struct {
+	version int64
+	data    []byte
+}
+
+func (s *memoryTripStore) get(ctx context.Context, driverID uuid.UUID) (*monitoredTrip, error) {
+	s.mu.Lock()
+	stored, ok := s.trips[driverID]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	var trip monitoredTrip
+	if err := json.Unmarshal(stored.data, &trip); err != nil {
+		return nil, err
+	}
+	trip.Version = stored.version
+	return &trip, nil
+}
+
+func (s *memoryTripStore) save(ctx context.Context, trip *monitoredTrip) error {
+	data, err := json.Marshal(trip)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.trips[trip.DriverID].version != trip.Version {
+		return errTripConflict
+	}
+	trip.Version++
+	s.trips[trip.DriverID] = storedTrip{version: trip.Version, data: data}
+	return nil
+}
+
+// saveTripScript stores a trip hash if its version still matches ARGV[1].
+// ARGV[2] is the trip and ARGV[3] the TTL in milliseconds.
+const saveTripScript = `
+local version = tonumber(redis.call("HGET", KEYS[1], "version") or "0")
+if version ~= tonumber(ARGV[1]) then
+    return 0
+end
+redis.call("HSET", KEYS[1], "version", version + 1, "trip", ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return 1
+`
+
+// redisTripStore keeps trips in Redis with a TTL, so trips whose completion
+// was never seen expire on their own
+type redisTripStore struct {
+	client redis.Cmdable
+	script *redis.Script
+}
+
+func (s *redisTripStore) get(ctx context.Context, driverID uuid.UUID) (*monitoredTrip, error) {
+	values, err := s.client.HMGet(ctx, tripStatePrefix+driverID.String(), "version", "trip").Result()
+	if err != nil {
+		return nil, err
+	}
+	version, _ := values[0].(string)
+	data, _ := values[1].(string)
+	if data == "" {
+		return nil, nil
+	}
+	var trip monitoredTrip
+	if err := json.Unmarshal([]byte(data), &trip); err != nil {
+		return nil, err
+	}
+	if trip.Version, err = strconv.ParseInt(version, 10, 64); err != nil {
+		return nil, fmt.Errorf("parse trip version: %w", err)
+	}
+	return &trip, nil
+}
+
+func (s *redisTripStore) save(ctx context.Context, trip *monitoredTrip) error {
+	data, err := json.Marshal(trip)
+	if err != nil {
+		return err
+	}
+	key := tripStatePrefix + trip.DriverID.String()
+	saved, err := s.script.Run(ctx, s.client, []string{key}, trip.Version, data, tripStateTTL.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return errTripConflict
+	}
+	trip.Version++
+	return nil
+}
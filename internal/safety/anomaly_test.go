@@ -0,0 +1,458 @@
+package safety
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/internal/maps"
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePlanner returns a fixed route
+type fakePlanner struct {
+	route []maps.Coordinate
+	err   error
+	calls int
+	last  *maps.RouteRequest
+}
+
+func (p *fakePlanner) GetRoute(ctx context.Context, req *maps.RouteRequest) (*maps.RouteResponse, error) {
+	p.calls++
+	p.last = req
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &maps.RouteResponse{Routes: []maps.Route{{Coordinates: p.route}}}, nil
+}
+
+// northboundRoute runs straight north for about 5.5 km
+func northboundRoute() *fakePlanner {
+	return &fakePlanner{route: []maps.Coordinate{
+		{Latitude: 40.70, Longitude: -74.00},
+		{Latitude: 40.72, Longitude: -74.00},
+		{Latitude: 40.75, Longitude: -74.00},
+	}}
+}
+
+type testTrip struct {
+	rideID, riderID, driverID uuid.UUID
+	start                     time.Time
+}
+
+func deliver(t *testing.T, handler eventbus.HandlerFunc, data interface{}) {
+	t.Helper()
+	event, err := eventbus.NewEvent("test", "test", data)
+	require.NoError(t, err)
+	require.NoError(t, handler(context.Background(), event))
+}
+
+// startTrip accepts and starts a ride from the start of the route to its end
+func startTrip(t *testing.T, d *AnomalyDetector, repo *mockRepo, settings *SafetySettings) *testTrip {
+	t.Helper()
+	trip := &testTrip{rideID: uuid.New(), riderID: uuid.New(), driverID: uuid.New(), start: time.Now()}
+	settings.UserID = trip.riderID
+	repo.On("GetSafetySettings", mock.Anything, trip.riderID).Return(settings, nil)
+
+	deliver(t, d.handleRideAccepted, eventbus.RideAcceptedData{
+		RideID: trip.rideID, RiderID: trip.riderID, DriverID: trip.driverID,
+		PickupLatitude: 40.70, PickupLongitude: -74.00,
+		DropoffLatitude: 40.75, DropoffLongitude: -74.00,
+	})
+	deliver(t, d.handleRideStarted, eventbus.RideStartedData{
+		RideID: trip.rideID, RiderID: trip.riderID, DriverID: trip.driverID, StartedAt: trip.start,
+	})
+	return trip
+}
+
+// locate reports the driver's location the given time into the trip
+func locate(t *testing.T, d *AnomalyDetector, trip *testTrip, after time.Duration, latitude, longitude, speed float64) {
+	t.Helper()
+	deliver(t, d.handleLocationUpdated, eventbus.DriverLocationUpdatedData{
+		DriverID:  trip.driverID,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Speed:     speed,
+		Timestamp: trip.start.Add(after),
+	})
+}
+
+func TestAnomalyDetector_RouteDeviationDebounced(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), northboundRoute(), DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{RouteDeviationAlert: true})
+
+	repo.On("CreateRouteDeviationAlert", mock.Anything, mock.MatchedBy(func(a *RouteDeviationAlert) bool {
+		// Compared against the nearest point on the route, not the dropoff
+		return a.RideID == trip.rideID && a.ExpectedLongitude == -74.00 &&
+			a.ExpectedLatitude > 40.72 && a.ExpectedLatitude < 40.74
+	})).Return(nil)
+
+	locate(t, d, trip, 10*time.Second, 40.71, -74.0002, 40)
+
+	// About 1 km east of the route; one fix isn't enough
+	locate(t, d, trip, 20*time.Second, 40.73, -73.988, 40)
+	repo.AssertNotCalled(t, "CreateRouteDeviationAlert", mock.Anything, mock.Anything)
+
+	locate(t, d, trip, 30*time.Second, 40.73, -73.988, 40)
+	locate(t, d, trip, 40*time.Second, 40.73, -73.988, 40)
+	repo.AssertNumberOfCalls(t, "CreateRouteDeviationAlert", 1)
+
+	// Still off route once the cooldown has passed
+	locate(t, d, trip, 11*time.Minute, 40.73, -73.988, 40)
+	repo.AssertNumberOfCalls(t, "CreateRouteDeviationAlert", 2)
+}
+
+func TestAnomalyDetector_RouteDeviationRespectsSettings(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), northboundRoute(), DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{RouteDeviationAlert: false})
+
+	for i := 1; i <= 5; i++ {
+		locate(t, d, trip, time.Duration(i)*10*time.Second, 40.73, -73.988, 40)
+	}
+	repo.AssertNotCalled(t, "CreateRouteDeviationAlert", mock.Anything, mock.Anything)
+}
+
+func TestAnomalyDetector_RouteNotPlanned(t *testing.T) {
+	repo := new(mockRepo)
+	planner := &fakePlanner{err: errors.New("maps unavailable")}
+	d := NewAnomalyDetector(newTestService(repo), planner, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{RouteDeviationAlert: true})
+
+	for i := 1; i <= 5; i++ {
+		locate(t, d, trip, time.Duration(i)*10*time.Second, 40.73, -73.988, 40)
+	}
+	repo.AssertNotCalled(t, "CreateRouteDeviationAlert", mock.Anything, mock.Anything)
+
+	// Planning isn't retried on every location
+	assert.Equal(t, 1, planner.calls)
+}
+
+func TestAnomalyDetector_StartBeforeAccept(t *testing.T) {
+	repo := new(mockRepo)
+	planner := northboundRoute()
+	d := NewAnomalyDetector(newTestService(repo), planner, DefaultAnomalyConfig())
+	rideID, riderID, driverID := uuid.New(), uuid.New(), uuid.New()
+	repo.On("GetSafetySettings", mock.Anything, riderID).Return(&SafetySettings{RouteDeviationAlert: true}, nil)
+
+	deliver(t, d.handleRideStarted, eventbus.RideStartedData{RideID: rideID, RiderID: riderID, DriverID: driverID})
+	assert.Equal(t, 0, planner.calls)
+
+	deliver(t, d.handleRideAccepted, eventbus.RideAcceptedData{
+		RideID: rideID, RiderID: riderID, DriverID: driverID,
+		PickupLatitude: 40.70, PickupLongitude: -74.00,
+		DropoffLatitude: 40.75, DropoffLongitude: -74.00,
+	})
+	assert.Equal(t, 1, planner.calls)
+
+	trip, err := d.trips.get(context.Background(), driverID)
+	require.NoError(t, err)
+	assert.True(t, trip.Started)
+	assert.True(t, trip.DeviationAlerts)
+	assert.Len(t, trip.Route, 3)
+}
+
+func TestAnomalyDetector_PlansRouteThroughStops(t *testing.T) {
+	repo := new(mockRepo)
+	planner := northboundRoute()
+	d := NewAnomalyDetector(newTestService(repo), planner, DefaultAnomalyConfig())
+	rideID, riderID, driverID := uuid.New(), uuid.New(), uuid.New()
+	repo.On("GetSafetySettings", mock.Anything, riderID).Return(&SafetySettings{UserID: riderID, RouteDeviationAlert: true}, nil)
+
+	first, second := uuid.New(), uuid.New()
+	deliver(t, d.handleRideAccepted, eventbus.RideAcceptedData{
+		RideID: rideID, RiderID: riderID, DriverID: driverID,
+		PickupLatitude: 40.70, PickupLongitude: -74.00,
+		DropoffLatitude: 40.75, DropoffLongitude: -74.00,
+		Stops: []eventbus.RideStopPoint{
+			{StopID: second, StopOrder: 2, Latitude: 40.73, Longitude: -74.01},
+			{StopID: first, StopOrder: 1, Latitude: 40.71, Longitude: -74.01},
+		},
+	})
+	deliver(t, d.handleRideStarted, eventbus.RideStartedData{RideID: rideID, RiderID: riderID, DriverID: driverID})
+	require.Equal(t, 1, planner.calls)
+	assert.Equal(t, []maps.Coordinate{
+		{Latitude: 40.71, Longitude: -74.01},
+		{Latitude: 40.73, Longitude: -74.01},
+	}, planner.last.Waypoints)
+
+	// A stop added mid-trip re-plans the route
+	added := uuid.New()
+	deliver(t, d.handleStopUpdated, eventbus.RideStopUpdatedData{
+		RideID: rideID, DriverID: driverID, StopID: added, StopOrder: 3,
+		Latitude: 40.74, Longitude: -74.02, Status: "pending", AddedMidTrip: true,
+	})
+	require.Equal(t, 2, planner.calls)
+	assert.Len(t, planner.last.Waypoints, 3)
+
+	// Arriving at a stop doesn't change the route
+	deliver(t, d.handleStopUpdated, eventbus.RideStopUpdatedData{
+		RideID: rideID, DriverID: driverID, StopID: first, StopOrder: 1,
+		Latitude: 40.71, Longitude: -74.01, Status: "arrived",
+	})
+	assert.Equal(t, 2, planner.calls)
+
+	deliver(t, d.handleStopUpdated, eventbus.RideStopUpdatedData{
+		RideID: rideID, DriverID: driverID, StopID: second, StopOrder: 2,
+		Latitude: 40.73, Longitude: -74.01, Status: "skipped",
+	})
+	require.Equal(t, 3, planner.calls)
+	assert.Equal(t, []maps.Coordinate{
+		{Latitude: 40.71, Longitude: -74.01},
+		{Latitude: 40.74, Longitude: -74.02},
+	}, planner.last.Waypoints)
+}
+
+func TestAnomalyDetector_StopBeforeAssignment(t *testing.T) {
+	planner := northboundRoute()
+	d := NewAnomalyDetector(newTestService(new(mockRepo)), planner, DefaultAnomalyConfig())
+
+	deliver(t, d.handleStopUpdated, eventbus.RideStopUpdatedData{
+		RideID: uuid.New(), StopID: uuid.New(), StopOrder: 1, Status: "pending",
+	})
+	assert.Zero(t, planner.calls)
+}
+
+func TestAnomalyDetector_Speeding(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{SpeedAlertEnabled: true})
+
+	repo.On("CreateSpeedAlert", mock.Anything, mock.MatchedBy(func(a *SpeedAlert) bool {
+		return a.RideID == trip.rideID && a.DriverID == trip.driverID &&
+			a.SpeedKmh == 145 && a.SpeedLimit == 120 && a.Severity == "moderate" && a.Duration == 40
+	})).Return(nil).Once()
+	repo.On("CreateSafetyCheck", mock.Anything, mock.MatchedBy(func(c *SafetyCheck) bool {
+		return c.UserID == trip.riderID && c.Type == SafetyCheckSpeedAlert
+	})).Return(nil).Once()
+
+	// A short burst over the limit is ignored
+	locate(t, d, trip, 0, 40.70, -74.00, 140)
+	locate(t, d, trip, 10*time.Second, 40.70, -74.00, 100)
+	locate(t, d, trip, 20*time.Second, 40.70, -74.00, 130)
+	locate(t, d, trip, 40*time.Second, 40.70, -74.00, 130)
+	repo.AssertNotCalled(t, "CreateSpeedAlert", mock.Anything, mock.Anything)
+
+	locate(t, d, trip, 60*time.Second, 40.70, -74.00, 145)
+	locate(t, d, trip, 70*time.Second, 40.70, -74.00, 145)
+	repo.AssertExpectations(t)
+}
+
+func TestAnomalyDetector_SpeedAlertsDisabled(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{SpeedAlertEnabled: false})
+
+	for i := 0; i < 10; i++ {
+		locate(t, d, trip, time.Duration(i)*10*time.Second, 40.70, -74.00, 160)
+	}
+	repo.AssertNotCalled(t, "CreateSpeedAlert", mock.Anything, mock.Anything)
+}
+
+func TestAnomalyDetector_LongStop(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{LongStopAlertMins: 5})
+
+	repo.On("CreateSafetyCheck", mock.Anything, mock.MatchedBy(func(c *SafetyCheck) bool {
+		return c.RideID == trip.rideID && c.UserID == trip.riderID && c.Type == SafetyCheckLongStop
+	})).Return(nil)
+
+	// GPS drift within the stop radius still counts as stopped
+	locate(t, d, trip, 0, 40.7100, -74.0000, 0)
+	locate(t, d, trip, 3*time.Minute, 40.7101, -74.0001, 0)
+	repo.AssertNotCalled(t, "CreateSafetyCheck", mock.Anything, mock.Anything)
+
+	locate(t, d, trip, 6*time.Minute, 40.7100, -74.0000, 0)
+	locate(t, d, trip, 8*time.Minute, 40.7100, -74.0000, 0)
+	repo.AssertNumberOfCalls(t, "CreateSafetyCheck", 1)
+
+	// Moving on and stopping again, within the cooldown
+	locate(t, d, trip, 9*time.Minute, 40.7200, -74.0000, 30)
+	locate(t, d, trip, 15*time.Minute, 40.7200, -74.0000, 0)
+	repo.AssertNumberOfCalls(t, "CreateSafetyCheck", 1)
+
+	locate(t, d, trip, 19*time.Minute, 40.7200, -74.0000, 0)
+	repo.AssertNumberOfCalls(t, "CreateSafetyCheck", 2)
+}
+
+// conflictingTripStore fails the next save with errTripConflict, as if
+// another event saved the trip first
+type conflictingTripStore struct {
+	tripStore
+	conflict bool
+}
+
+func (s *conflictingTripStore) save(ctx context.Context, trip *monitoredTrip) error {
+	if s.conflict {
+		s.conflict = false
+		return errTripConflict
+	}
+	return s.tripStore.save(ctx, trip)
+}
+
+func TestAnomalyDetector_AlertWaitsForSavedCooldown(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+	store := &conflictingTripStore{tripStore: d.trips}
+	d.trips = store
+	trip := startTrip(t, d, repo, &SafetySettings{LongStopAlertMins: 5})
+
+	repo.On("CreateSafetyCheck", mock.Anything, mock.Anything).Return(errors.New("db down")).Once()
+	repo.On("CreateSafetyCheck", mock.Anything, mock.Anything).Return(nil)
+
+	locate(t, d, trip, 0, 40.7100, -74.0000, 0)
+
+	// The location is dropped on a conflict, so nothing is raised
+	store.conflict = true
+	locate(t, d, trip, 6*time.Minute, 40.7100, -74.0000, 0)
+	repo.AssertNotCalled(t, "CreateSafetyCheck", mock.Anything, mock.Anything)
+
+	// A failed alert releases its cooldown and is retried on the next location
+	locate(t, d, trip, 7*time.Minute, 40.7100, -74.0000, 0)
+	locate(t, d, trip, 8*time.Minute, 40.7100, -74.0000, 0)
+	repo.AssertNumberOfCalls(t, "CreateSafetyCheck", 2)
+
+	locate(t, d, trip, 9*time.Minute, 40.7100, -74.0000, 0)
+	repo.AssertNumberOfCalls(t, "CreateSafetyCheck", 2)
+}
+
+func TestAnomalyDetector_IgnoresLateLocations(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{LongStopAlertMins: 5})
+
+	locate(t, d, trip, 10*time.Minute, 40.7100, -74.0000, 0)
+	locate(t, d, trip, 0, 40.7100, -74.0000, 0)
+	locate(t, d, trip, 11*time.Minute, 40.7100, -74.0000, 0)
+	repo.AssertNotCalled(t, "CreateSafetyCheck", mock.Anything, mock.Anything)
+}
+
+func TestAnomalyDetector_StopsMonitoringWhenRideEnds(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), northboundRoute(), DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{RouteDeviationAlert: true, SpeedAlertEnabled: true})
+
+	// Completion of the driver's previous ride doesn't end this one
+	deliver(t, d.handleRideCompleted, eventbus.RideCompletedData{RideID: uuid.New(), DriverID: trip.driverID})
+	monitored, err := d.trips.get(context.Background(), trip.driverID)
+	require.NoError(t, err)
+	require.NotNil(t, monitored)
+
+	deliver(t, d.handleRideCompleted, eventbus.RideCompletedData{RideID: trip.rideID, RiderID: trip.riderID, DriverID: trip.driverID})
+	monitored, err = d.trips.get(context.Background(), trip.driverID)
+	require.NoError(t, err)
+	require.NotNil(t, monitored)
+	assert.True(t, monitored.Ended)
+
+	for i := 1; i <= 5; i++ {
+		locate(t, d, trip, time.Duration(i)*10*time.Second, 40.73, -73.988, 160)
+	}
+	repo.AssertNotCalled(t, "CreateRouteDeviationAlert", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "CreateSpeedAlert", mock.Anything, mock.Anything)
+}
+
+func TestAnomalyDetector_LateEventsDontRestartEndedRide(t *testing.T) {
+	repo := new(mockRepo)
+	planner := northboundRoute()
+	d := NewAnomalyDetector(newTestService(repo), planner, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{RouteDeviationAlert: true})
+	deliver(t, d.handleRideCompleted, eventbus.RideCompletedData{RideID: trip.rideID, DriverID: trip.driverID})
+
+	// A redelivered start for the finished ride is ignored
+	deliver(t, d.handleRideStarted, eventbus.RideStartedData{
+		RideID: trip.rideID, RiderID: trip.riderID, DriverID: trip.driverID, StartedAt: trip.start,
+	})
+	monitored, err := d.trips.get(context.Background(), trip.driverID)
+	require.NoError(t, err)
+	assert.True(t, monitored.Ended)
+	assert.False(t, monitored.Started)
+
+	// The driver's next ride replaces it
+	next := uuid.New()
+	deliver(t, d.handleRideAccepted, eventbus.RideAcceptedData{RideID: next, RiderID: trip.riderID, DriverID: trip.driverID})
+	monitored, err = d.trips.get(context.Background(), trip.driverID)
+	require.NoError(t, err)
+	assert.Equal(t, next, monitored.RideID)
+	assert.False(t, monitored.Ended)
+}
+
+func TestAnomalyDetector_LocationDoesNotOverwriteConcurrentEnd(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+	trip := startTrip(t, d, repo, &SafetySettings{})
+
+	// The ride ends between a location loading the trip and saving it
+	ctx := context.Background()
+	stale, err := d.trips.get(ctx, trip.driverID)
+	require.NoError(t, err)
+	deliver(t, d.handleRideCompleted, eventbus.RideCompletedData{RideID: trip.rideID, DriverID: trip.driverID})
+
+	stale.LastSeen = trip.start.Add(time.Minute)
+	assert.ErrorIs(t, d.trips.save(ctx, stale), errTripConflict)
+
+	monitored, err := d.trips.get(ctx, trip.driverID)
+	require.NoError(t, err)
+	assert.True(t, monitored.Ended)
+}
+
+func TestMemoryTripStore_SaveChecksVersion(t *testing.T) {
+	ctx := context.Background()
+	store := &memoryTripStore{trips: make(map[uuid.UUID]storedTrip)}
+	driverID := uuid.New()
+
+	first := &monitoredTrip{RideID: uuid.New(), DriverID: driverID}
+	require.NoError(t, store.save(ctx, first))
+	assert.Equal(t, int64(1), first.Version)
+
+	// A second trip created without seeing the first conflicts
+	assert.ErrorIs(t, store.save(ctx, &monitoredTrip{RideID: uuid.New(), DriverID: driverID}), errTripConflict)
+
+	a, err := store.get(ctx, driverID)
+	require.NoError(t, err)
+	b, err := store.get(ctx, driverID)
+	require.NoError(t, err)
+	a.Started = true
+	require.NoError(t, store.save(ctx, a))
+	assert.ErrorIs(t, store.save(ctx, b), errTripConflict)
+
+	saved, err := store.get(ctx, driverID)
+	require.NoError(t, err)
+	assert.True(t, saved.Started)
+	assert.Equal(t, int64(2), saved.Version)
+}
+
+func TestAnomalyDetector_CancelledBeforeAssignment(t *testing.T) {
+	repo := new(mockRepo)
+	d := NewAnomalyDetector(newTestService(repo), nil, DefaultAnomalyConfig())
+
+	deliver(t, d.handleRideCancelled, eventbus.RideCancelledData{RideID: uuid.New(), CancelledBy: "rider"})
+}
+
+func TestClosestOnRoute(t *testing.T) {
+	route := []Coordinate{
+		{Latitude: 40.70, Longitude: -74.00},
+		{Latitude: 40.72, Longitude: -74.00},
+		{Latitude: 40.72, Longitude: -73.98},
+	}
+
+	// About 1 km west of the first segment
+	closest, meters := closestOnRoute(route, Coordinate{Latitude: 40.71, Longitude: -74.0119})
+	assert.InDelta(t, 1000, meters, 20)
+	assert.InDelta(t, 40.71, closest.Latitude, 1e-6)
+	assert.InDelta(t, -74.00, closest.Longitude, 1e-6)
+
+	// Past the end of the route the end is nearest
+	closest, meters = closestOnRoute(route, Coordinate{Latitude: 40.72, Longitude: -73.97})
+	assert.InDelta(t, 843, meters, 20)
+	assert.Equal(t, route[2], closest)
+
+	_, meters = closestOnRoute(route, Coordinate{Latitude: 40.72, Longitude: -73.99})
+	assert.InDelta(t, 0, meters, 1)
+}
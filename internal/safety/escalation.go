@@ -0,0 +1,355 @@
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/richxcame/ride-hailing/pkg/websocket"
+	"go.uber.org/zap"
+)
+
+const (
+	// Default time an SOS alert may go unacknowledged before emergency
+	// services are dispatched
+	defaultEmergencyServiceAfter = 5 * time.Minute
+	// Delay before a failed page or dispatch is retried
+	escalationRetryInterval = 30 * time.Second
+	// How long a claimed escalation is hidden from other workers; longer
+	// than a webhook call can take
+	escalationLease = 2 * time.Minute
+	// Alerts escalated per worker poll
+	escalationBatchSize = 20
+)
+
+// RideLookup loads the ride an emergency was raised on, so dispatch carries
+// the ride details. *rides.Service implements it.
+type RideLookup interface {
+	GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ride, error)
+}
+
+// SetRideLookup sets where ride details for emergency dispatch come from
+func (s *Service) SetRideLookup(rides RideLookup) {
+	s.rides = rides
+}
+
+// escalates reports whether SOS alerts have any escalation step to run
+func (s *Service) escalates() bool {
+	return s.onCallClient != nil || s.emergencyClient != nil
+}
+
+// dispatchAt returns when emergency services are dispatched for an alert
+// nobody acknowledged, or nil when no emergency service is configured
+func (s *Service) dispatchAt(alert *EmergencyAlert) *time.Time {
+	if s.emergencyClient == nil {
+		return nil
+	}
+	at := alert.CreatedAt.Add(s.emergencyServiceAfter)
+	return &at
+}
+
+// ProcessEmergencyEscalations runs the escalation steps that are due
+// (called by worker)
+func (s *Service) ProcessEmergencyEscalations(ctx context.Context) (int, error) {
+	alerts, err := s.repo.ClaimDueEmergencyEscalations(ctx, escalationBatchSize, escalationLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, alert := range alerts {
+		if err := s.advanceEscalation(ctx, alert); err != nil {
+			logger.Error("Failed to escalate emergency alert", zap.String("alert_id", alert.ID.String()), zap.Error(err))
+		}
+	}
+	return len(alerts), nil
+}
+
+// advanceEscalation runs the alert's next escalation step and schedules the
+// one after it. The on-call safety queue is paged first; emergency services
+// are dispatched once the alert has gone unacknowledged for
+// emergencyServiceAfter, even if paging kept failing.
+func (s *Service) advanceEscalation(ctx context.Context, alert *EmergencyAlert) error {
+	now := time.Now()
+	dispatchAt := s.dispatchAt(alert)
+	dispatchDue := dispatchAt != nil && !now.Before(*dispatchAt)
+
+	if alert.EscalationLevel < EscalationOnCallPaged {
+		if err := s.pageOnCall(ctx, alert, dispatchAt); err != nil {
+			s.recordAlertEvent(ctx, alert.ID, AlertEventOnCallPageFailed, nil, map[string]interface{}{"error": err.Error()})
+			if !dispatchDue {
+				next := now.Add(escalationRetryInterval)
+				if dispatchAt != nil && dispatchAt.Before(next) {
+					next = *dispatchAt
+				}
+				return s.repo.ScheduleEmergencyEscalation(ctx, alert.ID, alert.EscalationLevel, &next)
+			}
+			// Nobody could be paged in time; emergency services are next
+		} else {
+			alert.EscalationLevel = EscalationOnCallPaged
+			if !dispatchDue {
+				return s.repo.ScheduleEmergencyEscalation(ctx, alert.ID, alert.EscalationLevel, dispatchAt)
+			}
+		}
+	}
+
+	if dispatchAt == nil {
+		return s.repo.ScheduleEmergencyEscalation(ctx, alert.ID, alert.EscalationLevel, nil)
+	}
+
+	reference, err := s.dispatchEmergencyServices(ctx, alert)
+	if err != nil {
+		s.recordAlertEvent(ctx, alert.ID, AlertEventDispatchFailed, nil, map[string]interface{}{"error": err.Error()})
+		next := now.Add(escalationRetryInterval)
+		return s.repo.ScheduleEmergencyEscalation(ctx, alert.ID, alert.EscalationLevel, &next)
+	}
+	s.recordAlertEvent(ctx, alert.ID, AlertEventDispatched, nil, map[string]interface{}{"reference": reference})
+	return s.repo.MarkEmergencyServicesDispatched(ctx, alert.ID, reference)
+}
+
+// pageOnCall pages the on-call safety queue. Without a pager webhook the
+// queue only sees the alert on the admin dashboard, so there is nothing to do.
+func (s *Service) pageOnCall(ctx context.Context, alert *EmergencyAlert, acknowledgeBy *time.Time) error {
+	if s.onCallClient == nil {
+		return nil
+	}
+
+	payload := map[string]interface{}{
+		"event":        "safety.sos.page",
+		"alert_id":     alert.ID.String(),
+		"user_id":      alert.UserID.String(),
+		"ride_id":      alert.RideID,
+		"type":         alert.Type,
+		"description":  alert.Description,
+		"latitude":     alert.Latitude,
+		"longitude":    alert.Longitude,
+		"share_url":    alert.ShareURL,
+		"triggered_at": alert.CreatedAt,
+	}
+	// When emergency services are dispatched unless someone responds
+	if acknowledgeBy != nil {
+		payload["acknowledge_by"] = *acknowledgeBy
+	}
+
+	if _, err := s.onCallClient.PostWithIdempotency(ctx, "", payload, nil, "sos-page-"+alert.ID.String()); err != nil {
+		return err
+	}
+	s.recordAlertEvent(ctx, alert.ID, AlertEventOnCallPaged, nil, map[string]interface{}{"acknowledge_by": acknowledgeBy})
+	return nil
+}
+
+// dispatchEmergencyServices hands the alert to the emergency service, with
+// the ride details and the live location link. It returns the service's
+// reference number, if it gave one.
+func (s *Service) dispatchEmergencyServices(ctx context.Context, alert *EmergencyAlert) (string, error) {
+	payload := map[string]interface{}{
+		"event":        "safety.sos.dispatch",
+		"alert_id":     alert.ID.String(),
+		"user_id":      alert.UserID.String(),
+		"type":         alert.Type,
+		"description":  alert.Description,
+		"triggered_at": alert.CreatedAt,
+		"location": map[string]interface{}{
+			"latitude":  alert.Latitude,
+			"longitude": alert.Longitude,
+			"address":   alert.Address,
+		},
+		"live_location_url": alert.ShareURL,
+		"emergency_number":  s.emergencyNumber,
+	}
+	if alert.LastLocationAt != nil {
+		payload["last_location"] = map[string]interface{}{
+			"latitude":  alert.LastLatitude,
+			"longitude": alert.LastLongitude,
+			"at":        alert.LastLocationAt,
+		}
+	}
+	if alert.RideID != nil {
+		payload["ride"] = s.rideDetails(ctx, *alert.RideID)
+	}
+
+	respBody, err := s.emergencyClient.PostWithIdempotency(ctx, "", payload, nil, "sos-dispatch-"+alert.ID.String())
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Reference string `json:"reference"`
+	}
+	if len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, &resp); err != nil {
+			logger.Warn("Unreadable emergency service response", zap.String("alert_id", alert.ID.String()), zap.Error(err))
+		}
+	}
+	return resp.Reference, nil
+}
+
+// rideDetails describes the alert's ride for dispatch. Only the ID is sent
+// when the ride can't be loaded.
+func (s *Service) rideDetails(ctx context.Context, rideID uuid.UUID) map[string]interface{} {
+	details := map[string]interface{}{"id": rideID.String()}
+	if s.rides == nil {
+		return details
+	}
+
+	ride, err := s.rides.GetRide(ctx, rideID)
+	if err != nil {
+		logger.Warn("Failed to load ride for emergency dispatch", zap.String("ride_id", rideID.String()), zap.Error(err))
+		return details
+	}
+	details["status"] = ride.Status
+	details["rider_id"] = ride.RiderID.String()
+	if ride.DriverID != nil {
+		details["driver_id"] = ride.DriverID.String()
+	}
+	details["pickup"] = map[string]interface{}{
+		"latitude":  ride.PickupLatitude,
+		"longitude": ride.PickupLongitude,
+		"address":   ride.PickupAddress,
+	}
+	details["dropoff"] = map[string]interface{}{
+		"latitude":  ride.DropoffLatitude,
+		"longitude": ride.DropoffLongitude,
+		"address":   ride.DropoffAddress,
+	}
+	details["started_at"] = ride.StartedAt
+	return details
+}
+
+// UpdateSOSLocation records the user's location while their alert is open.
+// Once emergency services were dispatched, each update is forwarded to them.
+func (s *Service) UpdateSOSLocation(ctx context.Context, userID, alertID uuid.UUID, req *UpdateSOSLocationRequest) error {
+	alert, err := s.repo.GetEmergencyAlert(ctx, alertID)
+	if err != nil {
+		return err
+	}
+	if alert == nil {
+		return fmt.Errorf("alert not found")
+	}
+	if alert.UserID != userID {
+		return fmt.Errorf("unauthorized")
+	}
+	if alert.Status != EmergencyStatusActive && alert.Status != EmergencyStatusResponded {
+		return fmt.Errorf("alert is not active")
+	}
+
+	now := time.Now()
+	if err := s.repo.UpdateEmergencyAlertLocation(ctx, alertID, req.Latitude, req.Longitude, now); err != nil {
+		return err
+	}
+
+	if s.wsHub != nil {
+		// Live SOS locations are for the safety dashboard only
+		s.wsHub.SendToRole(string(models.RoleAdmin), &websocket.Message{
+			Type: "emergency_location",
+			Data: map[string]interface{}{
+				"alert_id":  alertID.String(),
+				"latitude":  req.Latitude,
+				"longitude": req.Longitude,
+				"at":        now,
+			},
+		})
+	}
+
+	if alert.EscalatedAt != nil && s.emergencyClient != nil {
+		payload := map[string]interface{}{
+			"event":     "safety.sos.location",
+			"alert_id":  alertID.String(),
+			"latitude":  req.Latitude,
+			"longitude": req.Longitude,
+			"at":        now,
+		}
+		if _, err := s.emergencyClient.Post(ctx, "", payload, nil); err != nil {
+			logger.WithContext(ctx).Warn("failed to forward SOS location to emergency service",
+				zap.String("alert_id", alertID.String()),
+				zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// GetEmergencyAlertWithTimeline retrieves an emergency alert with its audit
+// timeline (admin)
+func (s *Service) GetEmergencyAlertWithTimeline(ctx context.Context, alertID uuid.UUID) (*EmergencyAlert, error) {
+	alert, err := s.repo.GetEmergencyAlert(ctx, alertID)
+	if err != nil || alert == nil {
+		return alert, err
+	}
+	alert.Timeline, err = s.repo.GetEmergencyAlertEvents(ctx, alertID)
+	if err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// recordAlertEvent appends to an alert's audit timeline. A failure is only
+// logged so it never holds up the emergency response.
+func (s *Service) recordAlertEvent(ctx context.Context, alertID uuid.UUID, eventType EmergencyAlertEventType, actorID *uuid.UUID, details map[string]interface{}) {
+	event := &EmergencyAlertEvent{
+		ID:        uuid.New(),
+		AlertID:   alertID,
+		Type:      eventType,
+		ActorID:   actorID,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if err := s.repo.CreateEmergencyAlertEvent(ctx, event); err != nil {
+		logger.Warn("Failed to record emergency alert event",
+			zap.String("alert_id", alertID.String()),
+			zap.String("event", string(eventType)),
+			zap.Error(err))
+	}
+}
+
+// ========================================
+// ESCALATION WORKER
+// ========================================
+
+// EscalationWorker periodically runs due SOS escalation steps. Several
+// workers may run at once: alerts are claimed with a lease.
+type EscalationWorker struct {
+	service  *Service
+	interval time.Duration
+	done     chan struct{}
+}
+
+// NewEscalationWorker creates a worker that polls for due escalations every
+// interval
+func NewEscalationWorker(service *Service, interval time.Duration) *EscalationWorker {
+	return &EscalationWorker{
+		service:  service,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the worker until ctx is cancelled or Stop is called
+func (w *EscalationWorker) Start(ctx context.Context) {
+	logger.Info("Starting SOS escalation worker", zap.Duration("interval", w.interval))
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := w.service.ProcessEmergencyEscalations(ctx); err != nil {
+				logger.Warn("SOS escalation run failed", zap.Error(err))
+			}
+		case <-ctx.Done():
+			logger.Info("SOS escalation worker stopped")
+			return
+		case <-w.done:
+			logger.Info("SOS escalation worker shutdown requested")
+			return
+		}
+	}
+}
+
+// Stop signals the worker to stop
+func (w *EscalationWorker) Stop() {
+	close(w.done)
+}
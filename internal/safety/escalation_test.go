@@ -0,0 +1,277 @@
+package safety
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// webhook records the requests a fake pager or emergency service receives
+type webhook struct {
+	mu       sync.Mutex
+	status   int
+	response string
+	requests []map[string]interface{}
+	keys     []string
+}
+
+func newWebhook(t *testing.T, status int, response string) (*webhook, string) {
+	hook := &webhook{status: status, response: response}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		hook.mu.Lock()
+		hook.requests = append(hook.requests, body)
+		hook.keys = append(hook.keys, r.Header.Get("Idempotency-Key"))
+		hook.mu.Unlock()
+		w.WriteHeader(hook.status)
+		_, _ = w.Write([]byte(hook.response))
+	}))
+	t.Cleanup(srv.Close)
+	return hook, srv.URL
+}
+
+func (h *webhook) received() []map[string]interface{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+type fakeRides struct {
+	ride *models.Ride
+}
+
+func (f *fakeRides) GetRide(ctx context.Context, rideID uuid.UUID) (*models.Ride, error) {
+	return f.ride, nil
+}
+
+func activeAlert(createdAgo time.Duration, level EscalationLevel) *EmergencyAlert {
+	return &EmergencyAlert{
+		ID:              uuid.New(),
+		UserID:          uuid.New(),
+		Type:            EmergencySOS,
+		Status:          EmergencyStatusActive,
+		Latitude:        40.7128,
+		Longitude:       -74.0060,
+		EscalationLevel: level,
+		CreatedAt:       time.Now().Add(-createdAgo),
+	}
+}
+
+func expectEvent(repo *mockRepo, eventType EmergencyAlertEventType) {
+	repo.On("CreateEmergencyAlertEvent", mock.Anything, mock.MatchedBy(func(e *EmergencyAlertEvent) bool {
+		return e.Type == eventType
+	})).Return(nil).Once()
+}
+
+func TestAdvanceEscalation_PagesOnCall(t *testing.T) {
+	pager, pagerURL := newWebhook(t, http.StatusOK, "")
+	_, emergencyURL := newWebhook(t, http.StatusOK, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{OnCallWebhookURL: pagerURL, EmergencyServiceWebhookURL: emergencyURL})
+
+	alert := activeAlert(time.Second, EscalationRaised)
+	dispatchAt := alert.CreatedAt.Add(defaultEmergencyServiceAfter)
+	expectEvent(repo, AlertEventOnCallPaged)
+	repo.On("ScheduleEmergencyEscalation", mock.Anything, alert.ID, EscalationOnCallPaged, &dispatchAt).Return(nil)
+
+	require.NoError(t, svc.advanceEscalation(context.Background(), alert))
+
+	pages := pager.received()
+	require.Len(t, pages, 1)
+	assert.Equal(t, alert.ID.String(), pages[0]["alert_id"])
+	assert.NotEmpty(t, pages[0]["acknowledge_by"])
+	assert.Equal(t, "sos-page-"+alert.ID.String(), pager.keys[0])
+	repo.AssertExpectations(t)
+}
+
+func TestAdvanceEscalation_PageFailureRetried(t *testing.T) {
+	_, pagerURL := newWebhook(t, http.StatusServiceUnavailable, "")
+	emergency, emergencyURL := newWebhook(t, http.StatusOK, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{OnCallWebhookURL: pagerURL, EmergencyServiceWebhookURL: emergencyURL})
+
+	alert := activeAlert(time.Second, EscalationRaised)
+	expectEvent(repo, AlertEventOnCallPageFailed)
+	repo.On("ScheduleEmergencyEscalation", mock.Anything, alert.ID, EscalationRaised, mock.MatchedBy(func(next *time.Time) bool {
+		return next != nil && time.Until(*next) > 20*time.Second && time.Until(*next) <= escalationRetryInterval
+	})).Return(nil)
+
+	require.NoError(t, svc.advanceEscalation(context.Background(), alert))
+	assert.Empty(t, emergency.received())
+	repo.AssertExpectations(t)
+}
+
+func TestAdvanceEscalation_DispatchesUnacknowledgedAlert(t *testing.T) {
+	emergency, emergencyURL := newWebhook(t, http.StatusOK, `{"reference":"CAD-1042"}`)
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{EmergencyServiceWebhookURL: emergencyURL, EmergencyServiceAfter: 3 * time.Minute})
+
+	rideID, driverID := uuid.New(), uuid.New()
+	svc.SetRideLookup(&fakeRides{ride: &models.Ride{
+		ID: rideID, DriverID: &driverID, Status: models.RideStatusInProgress,
+		PickupAddress: "1 Main St", DropoffAddress: "9 Elm St",
+	}})
+
+	alert := activeAlert(4*time.Minute, EscalationOnCallPaged)
+	alert.RideID = &rideID
+	alert.ShareURL = "https://ride.example.com/share/abc"
+	latitude, longitude, at := 40.72, -74.01, time.Now()
+	alert.LastLatitude, alert.LastLongitude, alert.LastLocationAt = &latitude, &longitude, &at
+
+	expectEvent(repo, AlertEventDispatched)
+	repo.On("MarkEmergencyServicesDispatched", mock.Anything, alert.ID, "CAD-1042").Return(nil)
+
+	require.NoError(t, svc.advanceEscalation(context.Background(), alert))
+
+	dispatches := emergency.received()
+	require.Len(t, dispatches, 1)
+	dispatch := dispatches[0]
+	assert.Equal(t, "https://ride.example.com/share/abc", dispatch["live_location_url"])
+	assert.Equal(t, 40.72, dispatch["last_location"].(map[string]interface{})["latitude"])
+	ride := dispatch["ride"].(map[string]interface{})
+	assert.Equal(t, driverID.String(), ride["driver_id"])
+	assert.Equal(t, "1 Main St", ride["pickup"].(map[string]interface{})["address"])
+	assert.Equal(t, "sos-dispatch-"+alert.ID.String(), emergency.keys[0])
+	repo.AssertExpectations(t)
+}
+
+func TestAdvanceEscalation_DispatchesWhenPagingKeepsFailing(t *testing.T) {
+	_, pagerURL := newWebhook(t, http.StatusInternalServerError, "")
+	emergency, emergencyURL := newWebhook(t, http.StatusAccepted, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{OnCallWebhookURL: pagerURL, EmergencyServiceWebhookURL: emergencyURL})
+
+	alert := activeAlert(6*time.Minute, EscalationRaised)
+	expectEvent(repo, AlertEventOnCallPageFailed)
+	expectEvent(repo, AlertEventDispatched)
+	repo.On("MarkEmergencyServicesDispatched", mock.Anything, alert.ID, "").Return(nil)
+
+	require.NoError(t, svc.advanceEscalation(context.Background(), alert))
+	assert.Len(t, emergency.received(), 1)
+	repo.AssertExpectations(t)
+}
+
+func TestAdvanceEscalation_DispatchFailureRetried(t *testing.T) {
+	_, emergencyURL := newWebhook(t, http.StatusBadGateway, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{EmergencyServiceWebhookURL: emergencyURL})
+
+	alert := activeAlert(6*time.Minute, EscalationOnCallPaged)
+	expectEvent(repo, AlertEventDispatchFailed)
+	repo.On("ScheduleEmergencyEscalation", mock.Anything, alert.ID, EscalationOnCallPaged, mock.AnythingOfType("*time.Time")).Return(nil)
+
+	require.NoError(t, svc.advanceEscalation(context.Background(), alert))
+	repo.AssertNotCalled(t, "MarkEmergencyServicesDispatched", mock.Anything, mock.Anything, mock.Anything)
+	repo.AssertExpectations(t)
+}
+
+func TestAdvanceEscalation_PagingOnly(t *testing.T) {
+	_, pagerURL := newWebhook(t, http.StatusOK, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{OnCallWebhookURL: pagerURL})
+
+	// Without an emergency service, paging ends the escalation
+	alert := activeAlert(time.Second, EscalationRaised)
+	expectEvent(repo, AlertEventOnCallPaged)
+	repo.On("ScheduleEmergencyEscalation", mock.Anything, alert.ID, EscalationOnCallPaged, (*time.Time)(nil)).Return(nil)
+
+	require.NoError(t, svc.advanceEscalation(context.Background(), alert))
+	repo.AssertExpectations(t)
+}
+
+func TestProcessEmergencyEscalations(t *testing.T) {
+	emergency, emergencyURL := newWebhook(t, http.StatusOK, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{EmergencyServiceWebhookURL: emergencyURL})
+
+	due := []*EmergencyAlert{
+		activeAlert(6*time.Minute, EscalationOnCallPaged),
+		activeAlert(7*time.Minute, EscalationOnCallPaged),
+	}
+	repo.On("ClaimDueEmergencyEscalations", mock.Anything, escalationBatchSize, escalationLease).Return(due, nil)
+	repo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
+	repo.On("MarkEmergencyServicesDispatched", mock.Anything, mock.Anything, "").Return(nil)
+
+	n, err := svc.ProcessEmergencyEscalations(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Len(t, emergency.received(), 2)
+	repo.AssertNumberOfCalls(t, "MarkEmergencyServicesDispatched", 2)
+}
+
+func TestTriggerSOS_WithoutEscalation(t *testing.T) {
+	repo := new(mockRepo)
+	svc := newTestService(repo)
+	userID := uuid.New()
+
+	repo.On("CreateEmergencyAlert", mock.Anything, mock.MatchedBy(func(a *EmergencyAlert) bool {
+		return a.NextEscalationAt == nil && a.EscalationLevel == EscalationRaised
+	})).Return(nil)
+	repo.On("GetSOSContacts", mock.Anything, userID).Return([]*EmergencyContact{}, nil)
+	expectEvent(repo, AlertEventTriggered)
+
+	_, err := svc.TriggerSOS(context.Background(), userID, &TriggerSOSRequest{Type: EmergencySOS, Latitude: 40.71, Longitude: -74.0})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestTriggerSOS_LeasesFirstEscalationStep(t *testing.T) {
+	_, pagerURL := newWebhook(t, http.StatusOK, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{OnCallWebhookURL: pagerURL})
+	userID := uuid.New()
+
+	scheduled := make(chan struct{})
+	repo.On("CreateEmergencyAlert", mock.Anything, mock.MatchedBy(func(a *EmergencyAlert) bool {
+		return a.NextEscalationAt != nil && a.NextEscalationAt.After(a.CreatedAt)
+	})).Return(nil)
+	repo.On("GetSOSContacts", mock.Anything, userID).Return([]*EmergencyContact{}, nil)
+	repo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
+	repo.On("ScheduleEmergencyEscalation", mock.Anything, mock.Anything, EscalationOnCallPaged, (*time.Time)(nil)).
+		Run(func(mock.Arguments) { close(scheduled) }).Return(nil)
+
+	_, err := svc.TriggerSOS(context.Background(), userID, &TriggerSOSRequest{Type: EmergencySOS, Latitude: 40.71, Longitude: -74.0})
+	require.NoError(t, err)
+
+	select {
+	case <-scheduled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("on-call queue was not paged")
+	}
+}
+
+func TestUpdateSOSLocation(t *testing.T) {
+	emergency, emergencyURL := newWebhook(t, http.StatusOK, "")
+	repo := new(mockRepo)
+	svc := NewService(repo, Config{EmergencyServiceWebhookURL: emergencyURL})
+
+	alert := activeAlert(time.Minute, EscalationOnCallPaged)
+	repo.On("GetEmergencyAlert", mock.Anything, alert.ID).Return(alert, nil)
+	repo.On("UpdateEmergencyAlertLocation", mock.Anything, alert.ID, 40.73, -74.02, mock.AnythingOfType("time.Time")).Return(nil)
+
+	req := &UpdateSOSLocationRequest{Latitude: 40.73, Longitude: -74.02}
+	require.NoError(t, svc.UpdateSOSLocation(context.Background(), alert.UserID, alert.ID, req))
+	assert.Empty(t, emergency.received())
+
+	// Once dispatched, updates are forwarded to the emergency service
+	escalatedAt := time.Now()
+	alert.EscalatedAt = &escalatedAt
+	require.NoError(t, svc.UpdateSOSLocation(context.Background(), alert.UserID, alert.ID, req))
+	forwarded := emergency.received()
+	require.Len(t, forwarded, 1)
+	assert.Equal(t, "safety.sos.location", forwarded[0]["event"])
+
+	err := svc.UpdateSOSLocation(context.Background(), uuid.New(), alert.ID, req)
+	assert.EqualError(t, err, "unauthorized")
+}
@@ -32,6 +32,7 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 		safety.DELETE("/sos/:id", h.CancelSOS)
 		safety.GET("/sos", h.GetUserEmergencies)
 		safety.GET("/sos/:id", h.GetEmergency)
+		safety.POST("/sos/:id/location", h.UpdateSOSLocation)
 
 		// Emergency Contacts
 		safety.POST("/contacts", h.CreateEmergencyContact)
@@ -141,6 +142,45 @@ func (h *Handler) CancelSOS(c *gin.Context) {
 	common.SuccessResponse(c, gin.H{"message": "emergency alert cancelled"})
 }
 
+// UpdateSOSLocation reports the user's location during an emergency
+// @Summary Update SOS location
+// @Description Records the user's latest location while their emergency alert is open
+// @Tags Safety
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert ID"
+// @Param request body UpdateSOSLocationRequest true "Location"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Security BearerAuth
+// @Router /api/v1/safety/sos/{id}/location [post]
+func (h *Handler) UpdateSOSLocation(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	alertID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid alert ID")
+		return
+	}
+
+	var req UpdateSOSLocationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+
+	if err := h.service.UpdateSOSLocation(c.Request.Context(), userID, alertID, &req); err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	common.SuccessResponse(c, gin.H{"message": "location updated"})
+}
+
 // GetEmergency retrieves a specific emergency alert
 func (h *Handler) GetEmergency(c *gin.Context) {
 	alertID, err := uuid.Parse(c.Param("id"))
@@ -772,6 +812,7 @@ func (h *Handler) RegisterAdminRoutes(rg *gin.RouterGroup, authMiddleware gin.Ha
 	{
 		// Emergency alerts
 		admin.GET("/emergencies", h.GetActiveEmergencies)
+		admin.GET("/emergencies/:id", h.AdminGetEmergency)
 		admin.POST("/emergencies/:id/respond", h.RespondToEmergency)
 		admin.POST("/emergencies/:id/resolve", h.ResolveEmergency)
 
@@ -795,6 +836,27 @@ func (h *Handler) GetActiveEmergencies(c *gin.Context) {
 	common.SuccessResponse(c, alerts)
 }
 
+// AdminGetEmergency retrieves an emergency alert with its audit timeline (admin)
+func (h *Handler) AdminGetEmergency(c *gin.Context) {
+	alertID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		common.ErrorResponse(c, http.StatusBadRequest, "invalid alert ID")
+		return
+	}
+
+	alert, err := h.service.GetEmergencyAlertWithTimeline(c.Request.Context(), alertID)
+	if err != nil {
+		common.ErrorResponse(c, http.StatusInternalServerError, "failed to get alert")
+		return
+	}
+	if alert == nil {
+		common.ErrorResponse(c, http.StatusNotFound, "alert not found")
+		return
+	}
+
+	common.SuccessResponse(c, alert)
+}
+
 // RespondToEmergency marks an emergency as responded (admin)
 func (h *Handler) RespondToEmergency(c *gin.Context) {
 	adminID, err := getUserID(c)
@@ -65,6 +65,42 @@ func (m *MockRepository) GetUserEmergencyAlerts(ctx context.Context, userID uuid
 	return args.Get(0).([]*EmergencyAlert), args.Error(1)
 }
 
+func (m *MockRepository) UpdateEmergencyAlertLocation(ctx context.Context, id uuid.UUID, latitude, longitude float64, at time.Time) error {
+	args := m.Called(ctx, id, latitude, longitude, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ClaimDueEmergencyEscalations(ctx context.Context, limit int, lease time.Duration) ([]*EmergencyAlert, error) {
+	args := m.Called(ctx, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*EmergencyAlert), args.Error(1)
+}
+
+func (m *MockRepository) ScheduleEmergencyEscalation(ctx context.Context, id uuid.UUID, level EscalationLevel, nextAt *time.Time) error {
+	args := m.Called(ctx, id, level, nextAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) MarkEmergencyServicesDispatched(ctx context.Context, id uuid.UUID, referenceNumber string) error {
+	args := m.Called(ctx, id, referenceNumber)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateEmergencyAlertEvent(ctx context.Context, event *EmergencyAlertEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetEmergencyAlertEvents(ctx context.Context, alertID uuid.UUID) ([]*EmergencyAlertEvent, error) {
+	args := m.Called(ctx, alertID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*EmergencyAlertEvent), args.Error(1)
+}
+
 func (m *MockRepository) CreateEmergencyContact(ctx context.Context, contact *EmergencyContact) error {
 	args := m.Called(ctx, contact)
 	return args.Error(0)
@@ -207,6 +243,11 @@ func (m *MockRepository) GetRecentRouteDeviations(ctx context.Context, rideID uu
 	return args.Get(0).([]*RouteDeviationAlert), args.Error(1)
 }
 
+func (m *MockRepository) CreateSpeedAlert(ctx context.Context, alert *SpeedAlert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
 func (m *MockRepository) CreateSafetyIncidentReport(ctx context.Context, report *SafetyIncidentReport) error {
 	args := m.Called(ctx, report)
 	return args.Error(0)
@@ -417,6 +458,7 @@ func TestHandler_TriggerSOS_Success(t *testing.T) {
 	}
 
 	mockRepo.On("CreateEmergencyAlert", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlert")).Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 	mockRepo.On("GetSOSContacts", mock.Anything, userID).Return([]*EmergencyContact{}, nil)
 
 	c, w := setupTestContext("POST", "/api/v1/safety/sos", reqBody)
@@ -523,6 +565,7 @@ func TestHandler_TriggerSOS_WithRideID(t *testing.T) {
 	}
 
 	mockRepo.On("CreateEmergencyAlert", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlert")).Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 	mockRepo.On("GetSOSContacts", mock.Anything, userID).Return([]*EmergencyContact{}, nil)
 	mockRepo.On("CreateRideShareLink", mock.Anything, mock.AnythingOfType("*safety.RideShareLink")).Return(nil)
 
@@ -583,6 +626,7 @@ func TestHandler_TriggerSOS_AllEmergencyTypes(t *testing.T) {
 			}
 
 			mockRepo.On("CreateEmergencyAlert", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlert")).Return(nil)
+			mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 			mockRepo.On("GetSOSContacts", mock.Anything, userID).Return([]*EmergencyContact{}, nil)
 
 			c, w := setupTestContext("POST", "/api/v1/safety/sos", reqBody)
@@ -613,6 +657,7 @@ func TestHandler_CancelSOS_Success(t *testing.T) {
 
 	mockRepo.On("GetEmergencyAlert", mock.Anything, alertID).Return(alert, nil)
 	mockRepo.On("UpdateEmergencyAlertStatus", mock.Anything, alertID, EmergencyStatusCancelled, (*uuid.UUID)(nil), "Cancelled by user").Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 
 	c, w := setupTestContext("DELETE", "/api/v1/safety/sos/"+alertID.String(), nil)
 	c.Params = gin.Params{{Key: "id", Value: alertID.String()}}
@@ -2946,6 +2991,7 @@ func TestHandler_RespondToEmergency_Success(t *testing.T) {
 	alertID := uuid.New()
 
 	mockRepo.On("UpdateEmergencyAlertStatus", mock.Anything, alertID, EmergencyStatusResponded, &adminID, "").Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 
 	c, w := setupTestContext("POST", "/api/v1/admin/safety/emergencies/"+alertID.String()+"/respond", nil)
 	c.Params = gin.Params{{Key: "id", Value: alertID.String()}}
@@ -3007,6 +3053,7 @@ func TestHandler_ResolveEmergency_Success(t *testing.T) {
 	}
 
 	mockRepo.On("UpdateEmergencyAlertStatus", mock.Anything, alertID, EmergencyStatusResolved, &adminID, "Police responded and situation resolved").Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 
 	c, w := setupTestContext("POST", "/api/v1/admin/safety/emergencies/"+alertID.String()+"/resolve", reqBody)
 	c.Params = gin.Params{{Key: "id", Value: alertID.String()}}
@@ -3034,6 +3081,7 @@ func TestHandler_ResolveEmergency_FalseAlarm(t *testing.T) {
 	}
 
 	mockRepo.On("UpdateEmergencyAlertStatus", mock.Anything, alertID, EmergencyStatusFalse, &adminID, "Accidental trigger confirmed by user").Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 
 	c, w := setupTestContext("POST", "/api/v1/admin/safety/emergencies/"+alertID.String()+"/resolve", reqBody)
 	c.Params = gin.Params{{Key: "id", Value: alertID.String()}}
@@ -3189,6 +3237,7 @@ func TestHandler_TriggerSOS_WithDescription(t *testing.T) {
 	}
 
 	mockRepo.On("CreateEmergencyAlert", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlert")).Return(nil)
+	mockRepo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 	mockRepo.On("GetSOSContacts", mock.Anything, userID).Return([]*EmergencyContact{}, nil)
 
 	c, w := setupTestContext("POST", "/api/v1/safety/sos", reqBody)
@@ -15,6 +15,14 @@ type RepositoryInterface interface {
 	UpdateEmergencyAlertStatus(ctx context.Context, id uuid.UUID, status EmergencyStatus, respondedBy *uuid.UUID, resolution string) error
 	GetActiveEmergencyAlerts(ctx context.Context) ([]*EmergencyAlert, error)
 	GetUserEmergencyAlerts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*EmergencyAlert, error)
+	UpdateEmergencyAlertLocation(ctx context.Context, id uuid.UUID, latitude, longitude float64, at time.Time) error
+
+	// Emergency Escalation
+	ClaimDueEmergencyEscalations(ctx context.Context, limit int, lease time.Duration) ([]*EmergencyAlert, error)
+	ScheduleEmergencyEscalation(ctx context.Context, id uuid.UUID, level EscalationLevel, nextAt *time.Time) error
+	MarkEmergencyServicesDispatched(ctx context.Context, id uuid.UUID, referenceNumber string) error
+	CreateEmergencyAlertEvent(ctx context.Context, event *EmergencyAlertEvent) error
+	GetEmergencyAlertEvents(ctx context.Context, alertID uuid.UUID) ([]*EmergencyAlertEvent, error)
 
 	// Emergency Contacts
 	CreateEmergencyContact(ctx context.Context, contact *EmergencyContact) error
@@ -49,6 +57,9 @@ type RepositoryInterface interface {
 	UpdateRouteDeviationAcknowledgement(ctx context.Context, id uuid.UUID, response string) error
 	GetRecentRouteDeviations(ctx context.Context, rideID uuid.UUID, since time.Duration) ([]*RouteDeviationAlert, error)
 
+	// Speed Alerts
+	CreateSpeedAlert(ctx context.Context, alert *SpeedAlert) error
+
 	// Safety Incident Reports
 	CreateSafetyIncidentReport(ctx context.Context, report *SafetyIncidentReport) error
 	GetSafetyIncidentReport(ctx context.Context, id uuid.UUID) (*SafetyIncidentReport, error)
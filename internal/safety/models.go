@@ -59,10 +59,60 @@ type EmergencyAlert struct {
 	// Contacts notified
 	ContactsNotified []uuid.UUID   `json:"contacts_notified,omitempty"`
 
+	// Escalation
+	EscalationLevel  EscalationLevel `json:"escalation_level"`
+	NextEscalationAt *time.Time      `json:"next_escalation_at,omitempty"` // When the next step is due
+	EscalatedAt      *time.Time      `json:"escalated_at,omitempty"`       // When emergency services were dispatched
+
+	// Live location
+	ShareURL       string     `json:"share_url,omitempty"` // Live ride tracking link
+	LastLatitude   *float64   `json:"last_latitude,omitempty"`
+	LastLongitude  *float64   `json:"last_longitude,omitempty"`
+	LastLocationAt *time.Time `json:"last_location_at,omitempty"`
+
+	// Audit timeline, oldest first (only on single alert views)
+	Timeline []*EmergencyAlertEvent `json:"timeline,omitempty"`
+
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
+// EscalationLevel is how far an emergency alert has been escalated
+type EscalationLevel int
+
+const (
+	EscalationRaised           EscalationLevel = 0 // Contacts notified
+	EscalationOnCallPaged      EscalationLevel = 1 // On-call safety queue paged
+	EscalationEmergencyService EscalationLevel = 2 // Emergency services dispatched
+)
+
+// EmergencyAlertEventType is the kind of an alert timeline entry
+type EmergencyAlertEventType string
+
+const (
+	AlertEventTriggered        EmergencyAlertEventType = "triggered"
+	AlertEventContactNotified  EmergencyAlertEventType = "contact_notified"
+	AlertEventContactFailed    EmergencyAlertEventType = "contact_notification_failed"
+	AlertEventShareLinkCreated EmergencyAlertEventType = "share_link_created"
+	AlertEventOnCallPaged      EmergencyAlertEventType = "on_call_paged"
+	AlertEventOnCallPageFailed EmergencyAlertEventType = "on_call_page_failed"
+	AlertEventAcknowledged     EmergencyAlertEventType = "acknowledged"
+	AlertEventDispatched       EmergencyAlertEventType = "emergency_services_dispatched"
+	AlertEventDispatchFailed   EmergencyAlertEventType = "emergency_services_dispatch_failed"
+	AlertEventCancelled        EmergencyAlertEventType = "cancelled"
+	AlertEventResolved         EmergencyAlertEventType = "resolved"
+)
+
+// EmergencyAlertEvent is an entry in an emergency alert's audit timeline
+type EmergencyAlertEvent struct {
+	ID        uuid.UUID               `json:"id"`
+	AlertID   uuid.UUID               `json:"alert_id"`
+	Type      EmergencyAlertEventType `json:"type"`
+	ActorID   *uuid.UUID              `json:"actor_id,omitempty"` // Nil for system actions
+	Details   map[string]interface{}  `json:"details,omitempty"`
+	CreatedAt time.Time               `json:"created_at"`
+}
+
 // EmergencyContact represents a user's emergency contact
 type EmergencyContact struct {
 	ID          uuid.UUID `json:"id"`
@@ -303,6 +353,12 @@ type TriggerSOSRequest struct {
 	Description string        `json:"description,omitempty"`
 }
 
+// UpdateSOSLocationRequest reports the user's location during an emergency
+type UpdateSOSLocationRequest struct {
+	Latitude  float64 `json:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" binding:"required"`
+}
+
 // TriggerSOSResponse represents the response to an SOS trigger
 type TriggerSOSResponse struct {
 	AlertID         uuid.UUID `json:"alert_id"`
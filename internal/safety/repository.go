@@ -2,6 +2,7 @@ package safety
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -24,51 +25,85 @@ func NewRepository(db *pgxpool.Pool) *Repository {
 // EMERGENCY ALERTS
 // ========================================
 
+// emergencyAlertColumns are the columns scanned by scanEmergencyAlert
+const emergencyAlertColumns = `
+	id, user_id, ride_id, type, status,
+	latitude, longitude, COALESCE(address, ''), COALESCE(description, ''),
+	audio_recording_url, video_recording_url,
+	responded_at, responded_by, resolved_at, COALESCE(resolution, ''),
+	COALESCE(police_notified, false), COALESCE(police_ref_number, ''),
+	escalation_level, next_escalation_at, escalated_at,
+	COALESCE(share_url, ''), last_latitude, last_longitude, last_location_at,
+	created_at, updated_at`
+
+func scanEmergencyAlert(row interface {
+	Scan(dest ...any) error
+}) (*EmergencyAlert, error) {
+	var alert EmergencyAlert
+	err := row.Scan(
+		&alert.ID, &alert.UserID, &alert.RideID, &alert.Type, &alert.Status,
+		&alert.Latitude, &alert.Longitude, &alert.Address, &alert.Description,
+		&alert.AudioRecordingURL, &alert.VideoRecordingURL,
+		&alert.RespondedAt, &alert.RespondedBy, &alert.ResolvedAt, &alert.Resolution,
+		&alert.PoliceNotified, &alert.PoliceRefNumber,
+		&alert.EscalationLevel, &alert.NextEscalationAt, &alert.EscalatedAt,
+		&alert.ShareURL, &alert.LastLatitude, &alert.LastLongitude, &alert.LastLocationAt,
+		&alert.CreatedAt, &alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func scanEmergencyAlerts(rows pgx.Rows) ([]*EmergencyAlert, error) {
+	defer rows.Close()
+
+	var alerts []*EmergencyAlert
+	for rows.Next() {
+		alert, err := scanEmergencyAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+	return alerts, rows.Err()
+}
+
 // CreateEmergencyAlert creates a new emergency alert
 func (r *Repository) CreateEmergencyAlert(ctx context.Context, alert *EmergencyAlert) error {
 	query := `
 		INSERT INTO emergency_alerts (
 			id, user_id, ride_id, type, status,
 			latitude, longitude, address, description,
-			police_notified, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			police_notified, escalation_level, next_escalation_at, share_url,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''), $14, $15)
 	`
 	_, err := r.db.Exec(ctx, query,
 		alert.ID, alert.UserID, alert.RideID, alert.Type, alert.Status,
 		alert.Latitude, alert.Longitude, alert.Address, alert.Description,
-		alert.PoliceNotified, alert.CreatedAt, alert.UpdatedAt,
+		alert.PoliceNotified, alert.EscalationLevel, alert.NextEscalationAt, alert.ShareURL,
+		alert.CreatedAt, alert.UpdatedAt,
 	)
 	return err
 }
 
 // GetEmergencyAlert retrieves an emergency alert by ID
 func (r *Repository) GetEmergencyAlert(ctx context.Context, id uuid.UUID) (*EmergencyAlert, error) {
-	query := `
-		SELECT id, user_id, ride_id, type, status,
-			latitude, longitude, address, description,
-			audio_recording_url, video_recording_url,
-			responded_at, responded_by, resolved_at, resolution,
-			police_notified, police_ref_number,
-			created_at, updated_at
+	query := `SELECT ` + emergencyAlertColumns + `
 		FROM emergency_alerts
 		WHERE id = $1
 	`
-	var alert EmergencyAlert
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&alert.ID, &alert.UserID, &alert.RideID, &alert.Type, &alert.Status,
-		&alert.Latitude, &alert.Longitude, &alert.Address, &alert.Description,
-		&alert.AudioRecordingURL, &alert.VideoRecordingURL,
-		&alert.RespondedAt, &alert.RespondedBy, &alert.ResolvedAt, &alert.Resolution,
-		&alert.PoliceNotified, &alert.PoliceRefNumber,
-		&alert.CreatedAt, &alert.UpdatedAt,
-	)
+	alert, err := scanEmergencyAlert(r.db.QueryRow(ctx, query, id))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
-	return &alert, err
+	return alert, err
 }
 
-// UpdateEmergencyAlertStatus updates the status of an emergency alert
+// UpdateEmergencyAlertStatus updates the status of an emergency alert. Any
+// pending escalation stops once the alert is no longer active.
 func (r *Repository) UpdateEmergencyAlertStatus(ctx context.Context, id uuid.UUID, status EmergencyStatus, respondedBy *uuid.UUID, resolution string) error {
 	query := `
 		UPDATE emergency_alerts
@@ -77,6 +112,7 @@ func (r *Repository) UpdateEmergencyAlertStatus(ctx context.Context, id uuid.UUI
 			responded_by = COALESCE($3, responded_by),
 			resolved_at = CASE WHEN $2 IN ('resolved', 'false_alarm', 'cancelled') THEN NOW() ELSE resolved_at END,
 			resolution = COALESCE(NULLIF($4, ''), resolution),
+			next_escalation_at = CASE WHEN $2 = 'active' THEN next_escalation_at ELSE NULL END,
 			updated_at = NOW()
 		WHERE id = $1
 	`
@@ -86,13 +122,7 @@ func (r *Repository) UpdateEmergencyAlertStatus(ctx context.Context, id uuid.UUI
 
 // GetActiveEmergencyAlerts retrieves all active emergency alerts
 func (r *Repository) GetActiveEmergencyAlerts(ctx context.Context) ([]*EmergencyAlert, error) {
-	query := `
-		SELECT id, user_id, ride_id, type, status,
-			latitude, longitude, address, description,
-			audio_recording_url, video_recording_url,
-			responded_at, responded_by, resolved_at, resolution,
-			police_notified, police_ref_number,
-			created_at, updated_at
+	query := `SELECT ` + emergencyAlertColumns + `
 		FROM emergency_alerts
 		WHERE status IN ('active', 'responded')
 		ORDER BY created_at DESC
@@ -101,35 +131,12 @@ func (r *Repository) GetActiveEmergencyAlerts(ctx context.Context) ([]*Emergency
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var alerts []*EmergencyAlert
-	for rows.Next() {
-		var alert EmergencyAlert
-		if err := rows.Scan(
-			&alert.ID, &alert.UserID, &alert.RideID, &alert.Type, &alert.Status,
-			&alert.Latitude, &alert.Longitude, &alert.Address, &alert.Description,
-			&alert.AudioRecordingURL, &alert.VideoRecordingURL,
-			&alert.RespondedAt, &alert.RespondedBy, &alert.ResolvedAt, &alert.Resolution,
-			&alert.PoliceNotified, &alert.PoliceRefNumber,
-			&alert.CreatedAt, &alert.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		alerts = append(alerts, &alert)
-	}
-	return alerts, nil
+	return scanEmergencyAlerts(rows)
 }
 
 // GetUserEmergencyAlerts retrieves emergency alerts for a user
 func (r *Repository) GetUserEmergencyAlerts(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*EmergencyAlert, error) {
-	query := `
-		SELECT id, user_id, ride_id, type, status,
-			latitude, longitude, address, description,
-			audio_recording_url, video_recording_url,
-			responded_at, responded_by, resolved_at, resolution,
-			police_notified, police_ref_number,
-			created_at, updated_at
+	query := `SELECT ` + emergencyAlertColumns + `
 		FROM emergency_alerts
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -139,24 +146,122 @@ func (r *Repository) GetUserEmergencyAlerts(ctx context.Context, userID uuid.UUI
 	if err != nil {
 		return nil, err
 	}
+	return scanEmergencyAlerts(rows)
+}
+
+// UpdateEmergencyAlertLocation records the user's latest location during an
+// emergency
+func (r *Repository) UpdateEmergencyAlertLocation(ctx context.Context, id uuid.UUID, latitude, longitude float64, at time.Time) error {
+	query := `
+		UPDATE emergency_alerts
+		SET last_latitude = $2, last_longitude = $3, last_location_at = $4, updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, latitude, longitude, at)
+	return err
+}
+
+// ========================================
+// EMERGENCY ESCALATION
+// ========================================
+
+// ClaimDueEmergencyEscalations returns active alerts whose next escalation
+// step is due. Claimed alerts are hidden from other workers for the lease.
+func (r *Repository) ClaimDueEmergencyEscalations(ctx context.Context, limit int, lease time.Duration) ([]*EmergencyAlert, error) {
+	query := `
+		UPDATE emergency_alerts
+		SET next_escalation_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM emergency_alerts
+			WHERE status = 'active'
+			  AND next_escalation_at <= NOW()
+			ORDER BY next_escalation_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + emergencyAlertColumns
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim emergency escalations: %w", err)
+	}
+	return scanEmergencyAlerts(rows)
+}
+
+// ScheduleEmergencyEscalation records the alert's escalation level and when
+// its next step is due (nil when there is none). Alerts that were
+// acknowledged or closed meanwhile are left alone.
+func (r *Repository) ScheduleEmergencyEscalation(ctx context.Context, id uuid.UUID, level EscalationLevel, nextAt *time.Time) error {
+	query := `
+		UPDATE emergency_alerts
+		SET escalation_level = $2, next_escalation_at = $3, updated_at = NOW()
+		WHERE id = $1 AND status = 'active'
+	`
+	_, err := r.db.Exec(ctx, query, id, level, nextAt)
+	return err
+}
+
+// MarkEmergencyServicesDispatched records that emergency services were
+// dispatched for the alert, ending its escalation
+func (r *Repository) MarkEmergencyServicesDispatched(ctx context.Context, id uuid.UUID, referenceNumber string) error {
+	query := `
+		UPDATE emergency_alerts
+		SET escalation_level = $2,
+			next_escalation_at = NULL,
+			escalated_at = NOW(),
+			police_notified = TRUE,
+			police_ref_number = COALESCE(NULLIF($3, ''), police_ref_number),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := r.db.Exec(ctx, query, id, EscalationEmergencyService, referenceNumber)
+	return err
+}
+
+// CreateEmergencyAlertEvent appends an entry to an alert's audit timeline
+func (r *Repository) CreateEmergencyAlertEvent(ctx context.Context, event *EmergencyAlertEvent) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert event details: %w", err)
+	}
+	if event.Details == nil {
+		details = []byte("{}")
+	}
+
+	query := `
+		INSERT INTO emergency_alert_events (id, alert_id, event_type, actor_id, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err = r.db.Exec(ctx, query, event.ID, event.AlertID, event.Type, event.ActorID, details, event.CreatedAt)
+	return err
+}
+
+// GetEmergencyAlertEvents returns an alert's audit timeline, oldest first
+func (r *Repository) GetEmergencyAlertEvents(ctx context.Context, alertID uuid.UUID) ([]*EmergencyAlertEvent, error) {
+	query := `
+		SELECT id, alert_id, event_type, actor_id, details, created_at
+		FROM emergency_alert_events
+		WHERE alert_id = $1
+		ORDER BY created_at, id
+	`
+	rows, err := r.db.Query(ctx, query, alertID)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
-	var alerts []*EmergencyAlert
+	events := make([]*EmergencyAlertEvent, 0)
 	for rows.Next() {
-		var alert EmergencyAlert
-		if err := rows.Scan(
-			&alert.ID, &alert.UserID, &alert.RideID, &alert.Type, &alert.Status,
-			&alert.Latitude, &alert.Longitude, &alert.Address, &alert.Description,
-			&alert.AudioRecordingURL, &alert.VideoRecordingURL,
-			&alert.RespondedAt, &alert.RespondedBy, &alert.ResolvedAt, &alert.Resolution,
-			&alert.PoliceNotified, &alert.PoliceRefNumber,
-			&alert.CreatedAt, &alert.UpdatedAt,
-		); err != nil {
+		var event EmergencyAlertEvent
+		var details []byte
+		if err := rows.Scan(&event.ID, &event.AlertID, &event.Type, &event.ActorID, &details, &event.CreatedAt); err != nil {
 			return nil, err
 		}
-		alerts = append(alerts, &alert)
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, fmt.Errorf("failed to decode alert event details: %w", err)
+		}
+		events = append(events, &event)
 	}
-	return alerts, nil
+	return events, rows.Err()
 }
 
 // ========================================
@@ -657,6 +762,26 @@ func (r *Repository) GetRecentRouteDeviations(ctx context.Context, rideID uuid.U
 	return alerts, nil
 }
 
+// ========================================
+// SPEED ALERTS
+// ========================================
+
+// CreateSpeedAlert creates a new speed alert
+func (r *Repository) CreateSpeedAlert(ctx context.Context, alert *SpeedAlert) error {
+	query := `
+		INSERT INTO speed_alerts (
+			id, ride_id, driver_id, speed_kmh, speed_limit, latitude, longitude,
+			road_name, severity, duration_seconds, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+	_, err := r.db.Exec(ctx, query,
+		alert.ID, alert.RideID, alert.DriverID, alert.SpeedKmh, alert.SpeedLimit,
+		alert.Location.Latitude, alert.Location.Longitude,
+		alert.RoadName, alert.Severity, alert.Duration, alert.CreatedAt,
+	)
+	return err
+}
+
 // ========================================
 // SAFETY INCIDENT REPORTS
 // ========================================
@@ -6,12 +6,14 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/pkg/eventbus"
 	"github.com/richxcame/ride-hailing/pkg/httpclient"
 	"github.com/richxcame/ride-hailing/pkg/logger"
+	"github.com/richxcame/ride-hailing/pkg/models"
 	redisclient "github.com/richxcame/ride-hailing/pkg/redis"
 	"github.com/richxcame/ride-hailing/pkg/websocket"
 	"go.uber.org/zap"
@@ -20,14 +22,21 @@ import (
 // Service handles safety business logic
 type Service struct {
 	repo               RepositoryInterface
-	eventBus           *eventbus.Bus
+	eventBus           EventPublisher
 	wsHub              *websocket.Hub
 	redis              redisclient.ClientInterface
 	notificationClient *httpclient.Client
 	mapsClient         *httpclient.Client
 	ridesClient        *httpclient.Client
+	onCallClient       *httpclient.Client // Pages the on-call safety queue
+	emergencyClient    *httpclient.Client // Dispatches emergency services
+	rides              RideLookup
 	baseShareURL       string
 	emergencyNumber    string // Local emergency number (e.g., "911", "112")
+
+	// How long an SOS alert may go unacknowledged before emergency services
+	// are dispatched
+	emergencyServiceAfter time.Duration
 }
 
 // Config holds service configuration
@@ -37,6 +46,14 @@ type Config struct {
 	NotificationURL string
 	MapsServiceURL  string
 	RidesServiceURL string
+
+	// SOS escalation: the on-call safety queue is paged through
+	// OnCallWebhookURL, and alerts still unacknowledged after
+	// EmergencyServiceAfter are sent to EmergencyServiceWebhookURL. Either
+	// step is skipped when its URL is empty.
+	OnCallWebhookURL           string
+	EmergencyServiceWebhookURL string
+	EmergencyServiceAfter      time.Duration
 }
 
 // NewService creates a new safety service
@@ -54,11 +71,28 @@ func NewService(repo RepositoryInterface, cfg Config) *Service {
 	if cfg.RidesServiceURL != "" {
 		s.ridesClient = httpclient.NewClient(cfg.RidesServiceURL)
 	}
+	if cfg.OnCallWebhookURL != "" {
+		s.onCallClient = httpclient.NewClient(cfg.OnCallWebhookURL)
+	}
+	if cfg.EmergencyServiceWebhookURL != "" {
+		s.emergencyClient = httpclient.NewClient(cfg.EmergencyServiceWebhookURL)
+	}
+	s.emergencyServiceAfter = cfg.EmergencyServiceAfter
+	if s.emergencyServiceAfter <= 0 {
+		s.emergencyServiceAfter = defaultEmergencyServiceAfter
+	}
 	return s
 }
 
+// EventPublisher sends safety events. Implemented by eventbus.Bus and
+// eventbus.Dialer.
+type EventPublisher interface {
+	Publish(ctx context.Context, subject string, event *eventbus.Event) error
+	Broadcast(subject string, event *eventbus.Event) error
+}
+
 // SetEventBus sets the NATS event bus
-func (s *Service) SetEventBus(bus *eventbus.Bus) {
+func (s *Service) SetEventBus(bus EventPublisher) {
 	s.eventBus = bus
 }
 
@@ -76,23 +110,48 @@ func (s *Service) SetRedis(redis redisclient.ClientInterface) {
 // EMERGENCY SOS
 // ========================================
 
-// TriggerSOS triggers an emergency SOS alert
+// TriggerSOS triggers an emergency SOS alert and starts its escalation
 func (s *Service) TriggerSOS(ctx context.Context, userID uuid.UUID, req *TriggerSOSRequest) (*TriggerSOSResponse, error) {
 	logger.Info("SOS triggered", zap.String("user_id", userID.String()), zap.String("type", string(req.Type)))
 
+	// Create share link for emergency contacts
+	var shareLink string
+	if req.RideID != nil {
+		link, err := s.CreateShareLink(ctx, userID, &CreateShareLinkRequest{
+			RideID:        *req.RideID,
+			ExpiryMinutes: 60, // 1 hour for emergency
+			ShareLocation: true,
+			ShareDriver:   true,
+			ShareETA:      true,
+			ShareRoute:    true,
+		})
+		if err == nil {
+			shareLink = link.ShareURL
+		}
+	}
+
 	// Create emergency alert
+	now := time.Now()
 	alert := &EmergencyAlert{
-		ID:             uuid.New(),
-		UserID:         userID,
-		RideID:         req.RideID,
-		Type:           req.Type,
-		Status:         EmergencyStatusActive,
-		Latitude:       req.Latitude,
-		Longitude:      req.Longitude,
-		Description:    req.Description,
-		PoliceNotified: false,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+		ID:              uuid.New(),
+		UserID:          userID,
+		RideID:          req.RideID,
+		Type:            req.Type,
+		Status:          EmergencyStatusActive,
+		Latitude:        req.Latitude,
+		Longitude:       req.Longitude,
+		Description:     req.Description,
+		PoliceNotified:  false,
+		EscalationLevel: EscalationRaised,
+		ShareURL:        shareLink,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	// This request runs the first escalation step; the lease keeps the
+	// escalation worker away unless the request dies before finishing it
+	if s.escalates() {
+		lease := now.Add(escalationLease)
+		alert.NextEscalationAt = &lease
 	}
 
 	// Get address from coordinates (async, don't block)
@@ -102,6 +161,15 @@ func (s *Service) TriggerSOS(ctx context.Context, userID uuid.UUID, req *Trigger
 		logger.Error("Failed to create emergency alert", zap.Error(err))
 		return nil, fmt.Errorf("failed to create emergency alert: %w", err)
 	}
+	s.recordAlertEvent(ctx, alert.ID, AlertEventTriggered, &userID, map[string]interface{}{
+		"type":      alert.Type,
+		"ride_id":   alert.RideID,
+		"latitude":  alert.Latitude,
+		"longitude": alert.Longitude,
+	})
+	if shareLink != "" {
+		s.recordAlertEvent(ctx, alert.ID, AlertEventShareLinkCreated, nil, map[string]interface{}{"share_url": shareLink})
+	}
 
 	// Get contacts to notify
 	contacts, err := s.repo.GetSOSContacts(ctx, userID)
@@ -114,8 +182,13 @@ func (s *Service) TriggerSOS(ctx context.Context, userID uuid.UUID, req *Trigger
 	for _, contact := range contacts {
 		if err := s.notifyEmergencyContact(ctx, contact, alert); err != nil {
 			logger.Warn("Failed to notify contact", zap.String("contact_id", contact.ID.String()), zap.Error(err))
+			s.recordAlertEvent(ctx, alert.ID, AlertEventContactFailed, nil, map[string]interface{}{
+				"contact_id": contact.ID.String(),
+				"error":      err.Error(),
+			})
 		} else {
 			contactsNotified++
+			s.recordAlertEvent(ctx, alert.ID, AlertEventContactNotified, nil, map[string]interface{}{"contact_id": contact.ID.String()})
 		}
 	}
 
@@ -127,25 +200,18 @@ func (s *Service) TriggerSOS(ctx context.Context, userID uuid.UUID, req *Trigger
 	// Publish event
 	s.publishSOSEvent(alert)
 
-	// Create share link for emergency contacts
-	var shareLink string
-	if req.RideID != nil {
-		link, err := s.CreateShareLink(ctx, userID, &CreateShareLinkRequest{
-			RideID:        *req.RideID,
-			ExpiryMinutes: 60, // 1 hour for emergency
-			ShareLocation: true,
-			ShareDriver:   true,
-			ShareETA:      true,
-			ShareRoute:    true,
-		})
-		if err == nil {
-			shareLink = link.ShareURL
-		}
-	}
-
 	// Notify admin dashboard via WebSocket
 	s.notifyAdminDashboard(alert)
 
+	// Page the on-call safety queue without holding up the response
+	if s.escalates() {
+		go func() {
+			if err := s.advanceEscalation(context.Background(), alert); err != nil {
+				logger.Error("Failed to escalate emergency alert", zap.String("alert_id", alert.ID.String()), zap.Error(err))
+			}
+		}()
+	}
+
 	return &TriggerSOSResponse{
 		AlertID:          alert.ID,
 		Status:           string(alert.Status),
@@ -172,7 +238,11 @@ func (s *Service) CancelSOS(ctx context.Context, userID uuid.UUID, alertID uuid.
 		return fmt.Errorf("alert is not active")
 	}
 
-	return s.repo.UpdateEmergencyAlertStatus(ctx, alertID, EmergencyStatusCancelled, nil, "Cancelled by user")
+	if err := s.repo.UpdateEmergencyAlertStatus(ctx, alertID, EmergencyStatusCancelled, nil, "Cancelled by user"); err != nil {
+		return err
+	}
+	s.recordAlertEvent(ctx, alertID, AlertEventCancelled, &userID, nil)
+	return nil
 }
 
 // ResolveEmergencyAlert resolves an emergency alert (admin action)
@@ -181,7 +251,14 @@ func (s *Service) ResolveEmergencyAlert(ctx context.Context, adminID, alertID uu
 	if isFalseAlarm {
 		status = EmergencyStatusFalse
 	}
-	return s.repo.UpdateEmergencyAlertStatus(ctx, alertID, status, &adminID, resolution)
+	if err := s.repo.UpdateEmergencyAlertStatus(ctx, alertID, status, &adminID, resolution); err != nil {
+		return err
+	}
+	s.recordAlertEvent(ctx, alertID, AlertEventResolved, &adminID, map[string]interface{}{
+		"resolution":     resolution,
+		"is_false_alarm": isFalseAlarm,
+	})
+	return nil
 }
 
 // GetActiveEmergencies retrieves all active emergencies (for admin)
@@ -635,24 +712,57 @@ func (s *Service) ProcessExpiredSafetyChecks(ctx context.Context) (int, error) {
 
 // AddTrustedDriver adds a driver to a rider's trusted list
 func (s *Service) AddTrustedDriver(ctx context.Context, riderID, driverID uuid.UUID, note string) error {
-	return s.repo.AddTrustedDriver(ctx, riderID, driverID, note)
+	if err := s.repo.AddTrustedDriver(ctx, riderID, driverID, note); err != nil {
+		return err
+	}
+	s.broadcastDriverListsChanged(riderID)
+	return nil
 }
 
 // RemoveTrustedDriver removes a driver from the trusted list
 func (s *Service) RemoveTrustedDriver(ctx context.Context, riderID, driverID uuid.UUID) error {
-	return s.repo.RemoveTrustedDriver(ctx, riderID, driverID)
+	if err := s.repo.RemoveTrustedDriver(ctx, riderID, driverID); err != nil {
+		return err
+	}
+	s.broadcastDriverListsChanged(riderID)
+	return nil
 }
 
 // BlockDriver blocks a driver
 func (s *Service) BlockDriver(ctx context.Context, riderID, driverID uuid.UUID, reason string) error {
 	// Also remove from trusted if exists
 	s.repo.RemoveTrustedDriver(ctx, riderID, driverID)
-	return s.repo.AddBlockedDriver(ctx, riderID, driverID, reason)
+	if err := s.repo.AddBlockedDriver(ctx, riderID, driverID, reason); err != nil {
+		return err
+	}
+	s.broadcastDriverListsChanged(riderID)
+	return nil
 }
 
 // UnblockDriver unblocks a driver
 func (s *Service) UnblockDriver(ctx context.Context, riderID, driverID uuid.UUID) error {
-	return s.repo.RemoveBlockedDriver(ctx, riderID, driverID)
+	if err := s.repo.RemoveBlockedDriver(ctx, riderID, driverID); err != nil {
+		return err
+	}
+	s.broadcastDriverListsChanged(riderID)
+	return nil
+}
+
+// broadcastDriverListsChanged tells matching to drop its cached copy of the
+// rider's blocked and trusted drivers, so a block applies to the next offer
+// rather than after the cache expires
+func (s *Service) broadcastDriverListsChanged(riderID uuid.UUID) {
+	if s.eventBus == nil {
+		return
+	}
+	event, err := eventbus.NewEvent(eventbus.SubjectRiderDriverListsChanged, "safety-service",
+		eventbus.RiderDriverListsChangedData{RiderID: riderID})
+	if err == nil {
+		err = s.eventBus.Broadcast(eventbus.SubjectRiderDriverListsChanged, event)
+	}
+	if err != nil {
+		logger.Warn("Failed to broadcast driver list change", zap.String("rider_id", riderID.String()), zap.Error(err))
+	}
 }
 
 // GetBlockedDrivers retrieves all blocked drivers for a rider
@@ -713,9 +823,14 @@ func (s *Service) GetPendingSafetyChecks(ctx context.Context, userID uuid.UUID)
 	return s.repo.GetPendingSafetyChecks(ctx, userID)
 }
 
-// MarkEmergencyResponded marks an emergency alert as responded (admin)
+// MarkEmergencyResponded marks an emergency alert as responded (admin).
+// Acknowledging the alert stops its escalation.
 func (s *Service) MarkEmergencyResponded(ctx context.Context, alertID, adminID uuid.UUID) error {
-	return s.repo.UpdateEmergencyAlertStatus(ctx, alertID, EmergencyStatusResponded, &adminID, "")
+	if err := s.repo.UpdateEmergencyAlertStatus(ctx, alertID, EmergencyStatusResponded, &adminID, ""); err != nil {
+		return err
+	}
+	s.recordAlertEvent(ctx, alertID, AlertEventAcknowledged, &adminID, nil)
+	return nil
 }
 
 // UpdateIncidentStatus updates a safety incident's status (admin)
@@ -727,14 +842,18 @@ func (s *Service) UpdateIncidentStatus(ctx context.Context, incidentID uuid.UUID
 // ROUTE DEVIATION
 // ========================================
 
+// routeDeviationThresholdMeters is how far off the expected route a driver
+// must be before a deviation alert is raised
+const routeDeviationThresholdMeters = 500
+
 // CheckRouteDeviation checks if driver deviated from route
 func (s *Service) CheckRouteDeviation(ctx context.Context, rideID, driverID uuid.UUID, actualLatitude, actualLongitude, expectedLatitude, expectedLongitude float64) (*RouteDeviationAlert, error) {
 	// Calculate deviation distance
 	deviation := haversineDistance(actualLatitude, actualLongitude, expectedLatitude, expectedLongitude)
 	deviationMeters := int(deviation * 1000)
 
-	// Only alert if deviation is significant (> 500 meters)
-	if deviationMeters < 500 {
+	// Only alert if deviation is significant
+	if deviationMeters < routeDeviationThresholdMeters {
 		return nil, nil
 	}
 
@@ -762,6 +881,48 @@ func (s *Service) CheckRouteDeviation(ctx context.Context, rideID, driverID uuid
 	return alert, nil
 }
 
+// ========================================
+// SPEED ALERTS
+// ========================================
+
+// RaiseSpeedAlert records that the driver has been over speedLimit for
+// overFor and asks the rider whether they are safe
+func (s *Service) RaiseSpeedAlert(ctx context.Context, rideID, riderID, driverID uuid.UUID, speedKmh float64, speedLimit int, latitude, longitude float64, overFor time.Duration) (*SpeedAlert, error) {
+	severity := "minor"
+	switch over := speedKmh - float64(speedLimit); {
+	case over >= 40:
+		severity = "severe"
+	case over >= 20:
+		severity = "moderate"
+	}
+
+	alert := &SpeedAlert{
+		ID:         uuid.New(),
+		RideID:     rideID,
+		DriverID:   driverID,
+		SpeedKmh:   speedKmh,
+		SpeedLimit: speedLimit,
+		Location:   Coordinate{Latitude: latitude, Longitude: longitude},
+		Severity:   severity,
+		Duration:   int(overFor.Seconds()),
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.repo.CreateSpeedAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+
+	reason := fmt.Sprintf("Driver travelling at %.0f km/h", speedKmh)
+	if _, err := s.SendSafetyCheck(ctx, rideID, riderID, SafetyCheckSpeedAlert, reason); err != nil {
+		logger.WithContext(ctx).Warn("failed to send speed alert safety check",
+			zap.String("alert_id", alert.ID.String()),
+			zap.String("ride_id", rideID.String()),
+			zap.Error(err))
+	}
+
+	return alert, nil
+}
+
 // ========================================
 // HELPER FUNCTIONS
 // ========================================
@@ -829,7 +990,7 @@ func (s *Service) notifyAdminDashboard(alert *EmergencyAlert) {
 	}
 
 	// Send to all admin clients
-	s.wsHub.SendToAll(&websocket.Message{
+	s.wsHub.SendToRole(string(models.RoleAdmin), &websocket.Message{
 		Type: "emergency_alert",
 		Data: map[string]interface{}{
 			"alert_id":  alert.ID.String(),
@@ -964,11 +1125,13 @@ func (s *Service) notifyRiderOfDeviation(ctx context.Context, alert *RouteDeviat
 func haversineDistance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
 	const earthRadius = 6371.0
 
-	deltaLatitude := (latitude2 - latitude1) * 3.141592653589793 / 180.0
-	deltaLongitude := (longitude2 - longitude1) * 3.141592653589793 / 180.0
+	deltaLatitude := (latitude2 - latitude1) * math.Pi / 180.0
+	deltaLongitude := (longitude2 - longitude1) * math.Pi / 180.0
 
-	a := (deltaLatitude/2)*(deltaLatitude/2) + (latitude1*3.141592653589793/180.0)*(latitude2*3.141592653589793/180.0)*(deltaLongitude/2)*(deltaLongitude/2)
-	c := 2 * (a)
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(latitude1*math.Pi/180.0)*math.Cos(latitude2*math.Pi/180.0)*
+			math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+	c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
 
 	return earthRadius * c
 }
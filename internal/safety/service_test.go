@@ -48,6 +48,36 @@ func (m *mockRepo) GetUserEmergencyAlerts(ctx context.Context, userID uuid.UUID,
 	}
 	return args.Get(0).([]*EmergencyAlert), args.Error(1)
 }
+func (m *mockRepo) UpdateEmergencyAlertLocation(ctx context.Context, id uuid.UUID, latitude, longitude float64, at time.Time) error {
+	args := m.Called(ctx, id, latitude, longitude, at)
+	return args.Error(0)
+}
+func (m *mockRepo) ClaimDueEmergencyEscalations(ctx context.Context, limit int, lease time.Duration) ([]*EmergencyAlert, error) {
+	args := m.Called(ctx, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*EmergencyAlert), args.Error(1)
+}
+func (m *mockRepo) ScheduleEmergencyEscalation(ctx context.Context, id uuid.UUID, level EscalationLevel, nextAt *time.Time) error {
+	args := m.Called(ctx, id, level, nextAt)
+	return args.Error(0)
+}
+func (m *mockRepo) MarkEmergencyServicesDispatched(ctx context.Context, id uuid.UUID, referenceNumber string) error {
+	args := m.Called(ctx, id, referenceNumber)
+	return args.Error(0)
+}
+func (m *mockRepo) CreateEmergencyAlertEvent(ctx context.Context, event *EmergencyAlertEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+func (m *mockRepo) GetEmergencyAlertEvents(ctx context.Context, alertID uuid.UUID) ([]*EmergencyAlertEvent, error) {
+	args := m.Called(ctx, alertID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*EmergencyAlertEvent), args.Error(1)
+}
 func (m *mockRepo) CreateEmergencyContact(ctx context.Context, contact *EmergencyContact) error {
 	args := m.Called(ctx, contact)
 	return args.Error(0)
@@ -167,6 +197,10 @@ func (m *mockRepo) GetRecentRouteDeviations(ctx context.Context, rideID uuid.UUI
 	}
 	return args.Get(0).([]*RouteDeviationAlert), args.Error(1)
 }
+func (m *mockRepo) CreateSpeedAlert(ctx context.Context, alert *SpeedAlert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
 func (m *mockRepo) CreateSafetyIncidentReport(ctx context.Context, report *SafetyIncidentReport) error {
 	args := m.Called(ctx, report)
 	return args.Error(0)
@@ -248,6 +282,7 @@ func TestTriggerSOS_Success(t *testing.T) {
 	userID := uuid.New()
 
 	repo.On("CreateEmergencyAlert", ctx, mock.AnythingOfType("*safety.EmergencyAlert")).Return(nil)
+	repo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 	repo.On("GetSOSContacts", ctx, userID).Return([]*EmergencyContact{}, nil)
 
 	req := &TriggerSOSRequest{
@@ -310,6 +345,7 @@ func TestCancelSOS_Success(t *testing.T) {
 
 	repo.On("GetEmergencyAlert", ctx, alertID).Return(alert, nil)
 	repo.On("UpdateEmergencyAlertStatus", ctx, alertID, EmergencyStatusCancelled, (*uuid.UUID)(nil), "Cancelled by user").Return(nil)
+	repo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 
 	err := svc.CancelSOS(ctx, userID, alertID)
 	require.NoError(t, err)
@@ -343,6 +379,7 @@ func TestResolveEmergencyAlert_Success(t *testing.T) {
 	alertID := uuid.New()
 
 	repo.On("UpdateEmergencyAlertStatus", ctx, alertID, EmergencyStatusResolved, &adminID, "Issue resolved by support").Return(nil)
+	repo.On("CreateEmergencyAlertEvent", mock.Anything, mock.AnythingOfType("*safety.EmergencyAlertEvent")).Return(nil)
 
 	err := svc.ResolveEmergencyAlert(ctx, adminID, alertID, "Issue resolved by support", false)
 	require.NoError(t, err)
@@ -547,10 +584,9 @@ func TestCheckRouteDeviation_DeviationDetected(t *testing.T) {
 
 	repo.On("CreateRouteDeviationAlert", ctx, mock.AnythingOfType("*safety.RouteDeviationAlert")).Return(nil)
 
-	// Large longitude difference to ensure > 500m with the simplified formula
 	alert, err := svc.CheckRouteDeviation(ctx, rideID, driverID,
 		40.7128, -74.0060, // actual
-		40.7128, -72.0060, // expected -- ~2 degrees longitude away
+		40.7218, -74.0060, // expected -- ~1 km north
 	)
 	require.NoError(t, err)
 	require.NotNil(t, alert, "alert should be created for large deviation")
@@ -682,13 +718,13 @@ func TestHaversineDistance(t *testing.T) {
 			name:       "short distance (latitude only, no longitude diff)",
 			latitude1:  40.7128, longitude1: -74.0060,
 			latitude2:  40.7218, longitude2: -74.0060,
-			minKm:      0.0, maxKm: 0.001, // buggy formula yields ~0.00008 km for small latitude-only diffs
+			minKm:      0.95, maxKm: 1.05,
 		},
 		{
 			name:       "moderate distance (New York to Philadelphia)",
 			latitude1:  40.7128, longitude1: -74.0060,
 			latitude2:  39.9526, longitude2: -75.1652,
-			minKm:      1.0, maxKm: 2.0, // buggy formula yields ~1.21 km instead of ~130 km
+			minKm:      125.0, maxKm: 135.0,
 		},
 	}
 
@@ -704,19 +740,16 @@ func TestHaversineDistance(t *testing.T) {
 }
 
 func TestHaversineDistance_NonNegative(t *testing.T) {
-	// The implementation uses a simplified formula that can return negative values
-	// when latitudes have opposite signs (the cos(latitude) terms are replaced with
-	// raw radian products which go negative across hemispheres).
-	// Test with same-hemisphere coordinates where the formula stays non-negative.
 	coords := []struct{ latitude, longitude float64 }{
 		{0, 0}, {0, 180}, {0, -180},
 		{45.5, 122.5}, {40.7, -74.0},
+		{-33.9, 151.2}, {-22.9, -43.2},
 	}
 
 	for i := range coords {
 		for j := range coords {
 			d := haversineDistance(coords[i].latitude, coords[i].longitude, coords[j].latitude, coords[j].longitude)
-			assert.GreaterOrEqual(t, d, 0.0, "distance should be non-negative for same-hemisphere coords")
+			assert.GreaterOrEqual(t, d, 0.0, "distance should be non-negative")
 		}
 	}
 }
@@ -792,7 +825,7 @@ func TestRouteDeviationThreshold(t *testing.T) {
 		{
 			name:               "large deviation - should alert",
 			actualLatitude:     40.7128, actualLongitude: -74.0060,
-			expectedLatitude:   40.7128, expectedLongitude: -72.0060, // large longitude diff triggers alert with simplified formula
+			expectedLatitude:   40.7218, expectedLongitude: -74.0060, // ~1 km north
 			shouldTriggerAlert: true,
 		},
 	}
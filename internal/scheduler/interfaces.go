@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -17,3 +18,17 @@ type Database interface {
 	// Exec executes a query that doesn't return rows, typically INSERT, UPDATE, DELETE.
 	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
 }
+
+// PricingVersionScheduler activates, expires and rolls back pricing versions.
+// It is optional; the worker skips the job when none is set.
+type PricingVersionScheduler interface {
+	Run(ctx context.Context) error
+}
+
+// PayoutBatcher pays drivers out on their payout schedule and reconciles the
+// payment provider's transfers with the payouts. It is optional; without one
+// the worker only logs drivers eligible for a payout.
+type PayoutBatcher interface {
+	RunPayoutBatches(ctx context.Context, now time.Time) error
+	RunPayoutReconciliation(ctx context.Context, now time.Time) error
+}
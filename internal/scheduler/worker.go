@@ -24,6 +24,10 @@ const (
 	payoutInterval = 24 * time.Hour
 	// Minimum wallet balance eligible for automatic daily payout
 	payoutMinimumBalance = 5.0
+	// Create and pay due payout batches, and retry interrupted ones, hourly
+	payoutBatchInterval = 1 * time.Hour
+	// Reconcile payouts with the payment provider once per day
+	payoutReconcileInterval = 24 * time.Hour
 )
 
 // Worker handles scheduled ride processing and maintenance tasks
@@ -36,6 +40,9 @@ type Worker struct {
 	lastDriverPerfRefresh  time.Time
 	lastRevenueRefresh     time.Time
 	lastPayoutRun          time.Time
+	lastPayoutReconcile    time.Time
+	pricingVersions        PricingVersionScheduler
+	payouts                PayoutBatcher
 }
 
 // NewWorker creates a new scheduler worker
@@ -53,6 +60,18 @@ func NewWorker(db Database, logger *zap.Logger, notificationsServiceURL string,
 	}
 }
 
+// SetPricingVersionScheduler enables scheduled activation, expiry and guarded
+// rollback of pricing versions.
+func (w *Worker) SetPricingVersionScheduler(s PricingVersionScheduler) {
+	w.pricingVersions = s
+}
+
+// SetPayoutBatcher enables scheduled payout batches and their daily
+// reconciliation with the payment provider
+func (w *Worker) SetPayoutBatcher(b PayoutBatcher) {
+	w.payouts = b
+}
+
 // Start begins the scheduled ride processing loop and maintenance tasks
 func (w *Worker) Start(ctx context.Context) {
 	w.logger.Info("Starting scheduler worker with maintenance tasks")
@@ -64,6 +83,7 @@ func (w *Worker) Start(ctx context.Context) {
 	w.processScheduledRides(ctx)
 	w.expireStaleRides(ctx)
 	w.refreshMaterializedViews(ctx)
+	w.processPricingVersions(ctx)
 
 	for {
 		select {
@@ -72,6 +92,8 @@ func (w *Worker) Start(ctx context.Context) {
 			w.expireStaleRides(ctx)
 			w.refreshMaterializedViews(ctx)
 			w.processPendingPayouts(ctx)
+			w.reconcilePayouts(ctx)
+			w.processPricingVersions(ctx)
 		case <-ctx.Done():
 			w.logger.Info("Scheduler worker stopped")
 			return
@@ -339,10 +361,22 @@ func (w *Worker) refreshMaterializedViews(ctx context.Context) {
 	}
 }
 
-// processPendingPayouts runs once per day and logs drivers whose wallet balance
-// exceeds the minimum payout threshold. Actual bank transfer (Stripe Connect /
-// local payment rails) is wired separately; this job creates the audit trail.
+// processPendingPayouts pays out drivers whose payout batch is due when a
+// PayoutBatcher is set. Without one it runs once per day and logs drivers
+// whose wallet balance exceeds the minimum payout threshold.
 func (w *Worker) processPendingPayouts(ctx context.Context) {
+	if w.payouts != nil {
+		if time.Since(w.lastPayoutRun) < payoutBatchInterval {
+			return
+		}
+		if err := w.payouts.RunPayoutBatches(ctx, time.Now()); err != nil {
+			w.logger.Error("Failed to run payout batches", zap.Error(err))
+			return
+		}
+		w.lastPayoutRun = time.Now()
+		return
+	}
+
 	if time.Since(w.lastPayoutRun) < payoutInterval {
 		return
 	}
@@ -381,13 +415,36 @@ func (w *Worker) processPendingPayouts(ctx context.Context) {
 			zap.Float64("balance", balance),
 			zap.String("currency", currency),
 		)
-		// TODO: call Stripe Connect / local payment rail here when integration is ready
 	}
 
 	w.logger.Info("Daily payout job completed", zap.Int("eligible_drivers", count))
 	w.lastPayoutRun = time.Now()
 }
 
+// reconcilePayouts matches the payment provider's transfers back to payouts
+// once per day and records mismatches
+func (w *Worker) reconcilePayouts(ctx context.Context) {
+	if w.payouts == nil || time.Since(w.lastPayoutReconcile) < payoutReconcileInterval {
+		return
+	}
+	if err := w.payouts.RunPayoutReconciliation(ctx, time.Now()); err != nil {
+		w.logger.Error("Failed to reconcile payouts", zap.Error(err))
+		return
+	}
+	w.lastPayoutReconcile = time.Now()
+}
+
+// processPricingVersions switches pricing versions whose effective time has
+// come and rolls back versions whose guard metric dropped
+func (w *Worker) processPricingVersions(ctx context.Context) {
+	if w.pricingVersions == nil {
+		return
+	}
+	if err := w.pricingVersions.Run(ctx); err != nil {
+		w.logger.Error("Failed to process pricing version schedule", zap.Error(err))
+	}
+}
+
 // refreshView refreshes a specific materialized view concurrently
 func (w *Worker) refreshView(ctx context.Context, viewName string) error {
 	query := "REFRESH MATERIALIZED VIEW CONCURRENTLY " + viewName
@@ -1880,3 +1880,109 @@ func TestWorker_UsesHTTPClientPackage(t *testing.T) {
 	assert.NotNil(t, worker.notificationsClient)
 	assert.IsType(t, (*httpclient.Client)(nil), worker.notificationsClient)
 }
+
+// ============================================================================
+// Pricing Version Schedule Tests
+// ============================================================================
+
+// fakePricingVersionScheduler counts runs and returns a fixed error
+type fakePricingVersionScheduler struct {
+	runs int
+	err  error
+}
+
+func (f *fakePricingVersionScheduler) Run(_ context.Context) error {
+	f.runs++
+	return f.err
+}
+
+func TestWorker_ProcessPricingVersions(t *testing.T) {
+	t.Run("skips when not configured", func(t *testing.T) {
+		worker := newTestWorker(new(MockDatabase))
+		assert.NotPanics(t, func() { worker.processPricingVersions(context.Background()) })
+	})
+
+	t.Run("runs the scheduler", func(t *testing.T) {
+		worker := newTestWorker(new(MockDatabase))
+		versions := &fakePricingVersionScheduler{}
+		worker.SetPricingVersionScheduler(versions)
+
+		worker.processPricingVersions(context.Background())
+		assert.Equal(t, 1, versions.runs)
+	})
+
+	t.Run("logs errors without stopping", func(t *testing.T) {
+		worker := newTestWorker(new(MockDatabase))
+		versions := &fakePricingVersionScheduler{err: errors.New("db down")}
+		worker.SetPricingVersionScheduler(versions)
+
+		assert.NotPanics(t, func() { worker.processPricingVersions(context.Background()) })
+		assert.Equal(t, 1, versions.runs)
+	})
+}
+
+// ============================================================================
+// Payout Batch Tests
+// ============================================================================
+
+// fakePayoutBatcher counts runs and returns a fixed error
+type fakePayoutBatcher struct {
+	batches    int
+	reconciles int
+	err        error
+}
+
+func (f *fakePayoutBatcher) RunPayoutBatches(_ context.Context, _ time.Time) error {
+	f.batches++
+	return f.err
+}
+
+func (f *fakePayoutBatcher) RunPayoutReconciliation(_ context.Context, _ time.Time) error {
+	f.reconciles++
+	return f.err
+}
+
+func TestWorker_ProcessPendingPayouts_WithBatcher(t *testing.T) {
+	t.Run("runs batches hourly instead of querying wallets", func(t *testing.T) {
+		mockDB := new(MockDatabase)
+		worker := newTestWorker(mockDB)
+		batcher := &fakePayoutBatcher{}
+		worker.SetPayoutBatcher(batcher)
+
+		worker.processPendingPayouts(context.Background())
+		worker.processPendingPayouts(context.Background())
+		assert.Equal(t, 1, batcher.batches)
+		mockDB.AssertNotCalled(t, "Query", mock.Anything, mock.Anything, mock.Anything)
+
+		worker.lastPayoutRun = time.Now().Add(-payoutBatchInterval)
+		worker.processPendingPayouts(context.Background())
+		assert.Equal(t, 2, batcher.batches)
+	})
+
+	t.Run("retries on the next tick after an error", func(t *testing.T) {
+		worker := newTestWorker(new(MockDatabase))
+		batcher := &fakePayoutBatcher{err: errors.New("db down")}
+		worker.SetPayoutBatcher(batcher)
+
+		worker.processPendingPayouts(context.Background())
+		worker.processPendingPayouts(context.Background())
+		assert.Equal(t, 2, batcher.batches)
+	})
+}
+
+func TestWorker_ReconcilePayouts(t *testing.T) {
+	t.Run("skips when not configured", func(t *testing.T) {
+		worker := newTestWorker(new(MockDatabase))
+		assert.NotPanics(t, func() { worker.reconcilePayouts(context.Background()) })
+	})
+
+	t.Run("runs once per day", func(t *testing.T) {
+		worker := newTestWorker(new(MockDatabase))
+		batcher := &fakePayoutBatcher{}
+		worker.SetPayoutBatcher(batcher)
+
+		worker.reconcilePayouts(context.Background())
+		worker.reconcilePayouts(context.Background())
+		assert.Equal(t, 1, batcher.reconciles)
+	})
+}
@@ -99,6 +99,11 @@ func (m *MockRepository) IncrementRideUsage(ctx context.Context, subID uuid.UUID
 	return args.Error(0)
 }
 
+func (m *MockRepository) ReleaseRideUsage(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockRepository) IncrementUpgradeUsage(ctx context.Context, subID uuid.UUID) error {
 	args := m.Called(ctx, subID)
 	return args.Error(0)
@@ -22,6 +22,7 @@ type RepositoryInterface interface {
 	GetSubscriptionByID(ctx context.Context, id uuid.UUID) (*Subscription, error)
 	UpdateSubscription(ctx context.Context, sub *Subscription) error
 	IncrementRideUsage(ctx context.Context, subID uuid.UUID, savings float64) error
+	ReleaseRideUsage(ctx context.Context, rideID uuid.UUID) (bool, error)
 	IncrementUpgradeUsage(ctx context.Context, subID uuid.UUID) error
 	IncrementCancellationUsage(ctx context.Context, subID uuid.UUID) error
 	GetExpiredSubscriptions(ctx context.Context) ([]*Subscription, error)
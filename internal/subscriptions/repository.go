@@ -294,6 +294,29 @@ func (r *Repository) IncrementRideUsage(ctx context.Context, subID uuid.UUID, sa
 	return err
 }
 
+// ReleaseRideUsage gives back the ride and savings a subscription recorded for
+// a ride, deleting its usage log. It returns false if the ride used none.
+func (r *Repository) ReleaseRideUsage(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	query := `
+		WITH released AS (
+			DELETE FROM subscription_usage_logs
+			WHERE ride_id = $1 AND usage_type = 'ride'
+			RETURNING subscription_id, savings_amount
+		)
+		UPDATE subscriptions s
+		SET rides_used = GREATEST(s.rides_used - 1, 0),
+			total_saved = GREATEST(s.total_saved - released.savings_amount, 0),
+			updated_at = NOW()
+		FROM released
+		WHERE s.id = released.subscription_id
+	`
+	tag, err := r.db.Exec(ctx, query, rideID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 // IncrementUpgradeUsage increments upgrade usage count
 func (r *Repository) IncrementUpgradeUsage(ctx context.Context, subID uuid.UUID) error {
 	query := `UPDATE subscriptions SET upgrades_used = upgrades_used + 1, updated_at = NOW() WHERE id = $1`
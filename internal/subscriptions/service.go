@@ -2,6 +2,7 @@ package subscriptions
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -261,6 +262,13 @@ func (s *Service) CancelSubscription(ctx context.Context, userID uuid.UUID, reas
 
 // ApplySubscriptionDiscount applies subscription benefits to a ride fare
 func (s *Service) ApplySubscriptionDiscount(ctx context.Context, userID, rideID uuid.UUID, originalFare float64, rideType string) (float64, error) {
+	return s.ApplySubscriptionDiscountUpTo(ctx, userID, rideID, originalFare, rideType, originalFare)
+}
+
+// ApplySubscriptionDiscountUpTo applies subscription benefits to a ride fare,
+// saving the rider no more than maxSavings. Usage is recorded with the capped
+// savings.
+func (s *Service) ApplySubscriptionDiscountUpTo(ctx context.Context, userID, rideID uuid.UUID, originalFare float64, rideType string, maxSavings float64) (float64, error) {
 	sub, err := s.repo.GetActiveSubscription(ctx, userID)
 	if err != nil || sub == nil {
 		return originalFare, nil // No subscription, return original fare
@@ -320,6 +328,9 @@ func (s *Service) ApplySubscriptionDiscount(ctx context.Context, userID, rideID
 	if discountedFare < 0 {
 		discountedFare = 0
 	}
+	if originalFare-discountedFare > maxSavings {
+		discountedFare = originalFare - maxSavings
+	}
 
 	savings := originalFare - discountedFare
 
@@ -340,6 +351,19 @@ func (s *Service) ApplySubscriptionDiscount(ctx context.Context, userID, rideID
 	return discountedFare, nil
 }
 
+// ReleaseRideDiscount gives back the subscription ride a ride used, for a
+// ride whose settlement was rolled back
+func (s *Service) ReleaseRideDiscount(ctx context.Context, rideID uuid.UUID) error {
+	released, err := s.repo.ReleaseRideUsage(ctx, rideID)
+	if err != nil {
+		return fmt.Errorf("release ride usage: %w", err)
+	}
+	if released {
+		logger.Info("Subscription ride usage released", zap.String("ride_id", rideID.String()))
+	}
+	return nil
+}
+
 // HasFreeCancellation checks if the user has free cancellations remaining
 func (s *Service) HasFreeCancellation(ctx context.Context, userID uuid.UUID) bool {
 	sub, err := s.repo.GetActiveSubscription(ctx, userID)
@@ -81,6 +81,11 @@ func (m *mockRepo) IncrementRideUsage(ctx context.Context, subID uuid.UUID, savi
 	return args.Error(0)
 }
 
+func (m *mockRepo) ReleaseRideUsage(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *mockRepo) IncrementUpgradeUsage(ctx context.Context, subID uuid.UUID) error {
 	args := m.Called(ctx, subID)
 	return args.Error(0)
@@ -602,6 +607,27 @@ func TestHasFreeCancellation(t *testing.T) {
 	}
 }
 
+func TestApplySubscriptionDiscountUpTo_CapsSavings(t *testing.T) {
+	repo := new(mockRepo)
+	service := NewService(repo, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+	plan := newDiscountPlan()
+	sub := newActiveSubscription(userID, plan.ID)
+
+	repo.On("GetActiveSubscription", mock.Anything, userID).Return(sub, nil).Once()
+	repo.On("GetPlanByID", mock.Anything, plan.ID).Return(plan, nil).Once()
+	// 15% of 100 = 15, capped at 6; usage records the capped savings
+	repo.On("CreateUsageLog", mock.Anything, mock.AnythingOfType("*subscriptions.SubscriptionUsageLog")).Return(nil).Once()
+	repo.On("IncrementRideUsage", mock.Anything, sub.ID, 6.0).Return(nil).Once()
+
+	fare, err := service.ApplySubscriptionDiscountUpTo(ctx, userID, uuid.New(), 100.0, "standard", 6.0)
+	require.NoError(t, err)
+	assert.InDelta(t, 94.0, fare, 0.01)
+	repo.AssertExpectations(t)
+}
+
 // ========================================
 // HAS SURGE PROTECTION TESTS
 // ========================================
@@ -0,0 +1,106 @@
+package vehicle
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IneligibleReason explains why a driver cannot take a ride
+type IneligibleReason string
+
+const (
+	IneligibleNoVehicle        IneligibleReason = "no_active_vehicle"
+	IneligibleCategory         IneligibleReason = "vehicle_category"
+	IneligibleCapacity         IneligibleReason = "capacity"
+	IneligibleWheelchairAccess IneligibleReason = "wheelchair_access"
+	IneligibleChildSeat        IneligibleReason = "child_seat"
+	IneligiblePetFriendly      IneligibleReason = "pet_friendly"
+)
+
+// ineligibleMessages are shown to riders when no nearby driver qualifies
+var ineligibleMessages = map[IneligibleReason]string{
+	IneligibleNoVehicle:        "No nearby drivers have an approved vehicle right now.",
+	IneligibleCategory:         "No nearby drivers have a vehicle for this ride type.",
+	IneligibleCapacity:         "No nearby drivers have a vehicle with enough seats.",
+	IneligibleWheelchairAccess: "No nearby drivers have a wheelchair accessible vehicle.",
+	IneligibleChildSeat:        "No nearby drivers have a child seat.",
+	IneligiblePetFriendly:      "No nearby drivers accept pets.",
+}
+
+// RideRequirements describes what a ride needs from the driver's vehicle.
+// Categories come from the ride type; the rest from the rider's preferences.
+type RideRequirements struct {
+	Categories       []VehicleCategory `json:"categories,omitempty"` // Empty allows any category
+	MinPassengers    int               `json:"min_passengers,omitempty"`
+	WheelchairAccess bool              `json:"wheelchair_access,omitempty"`
+	ChildSeat        bool              `json:"child_seat,omitempty"`
+	PetFriendly      bool              `json:"pet_friendly,omitempty"`
+}
+
+// DriverEquipment is what a driver can offer: their primary approved vehicle
+// combined with the capabilities they declared.
+type DriverEquipment struct {
+	HasVehicle       bool            `json:"has_vehicle"`
+	Category         VehicleCategory `json:"category,omitempty"`
+	MaxPassengers    int             `json:"max_passengers"`
+	WheelchairAccess bool            `json:"wheelchair_access"`
+	ChildSeat        bool            `json:"child_seat"`
+	PetFriendly      bool            `json:"pet_friendly"`
+}
+
+// Check returns why the driver cannot take the ride, or an empty reason if
+// they can. Wheelchair accessible vehicles always count as wheelchair access.
+func (r RideRequirements) Check(e DriverEquipment) IneligibleReason {
+	if !e.HasVehicle {
+		return IneligibleNoVehicle
+	}
+	if len(r.Categories) > 0 && !containsCategory(r.Categories, e.Category) {
+		return IneligibleCategory
+	}
+	if r.MinPassengers > 0 && e.MaxPassengers < r.MinPassengers {
+		return IneligibleCapacity
+	}
+	if r.WheelchairAccess && !e.WheelchairAccess && e.Category != VehicleCategoryWAV {
+		return IneligibleWheelchairAccess
+	}
+	if r.ChildSeat && !e.ChildSeat {
+		return IneligibleChildSeat
+	}
+	if r.PetFriendly && !e.PetFriendly {
+		return IneligiblePetFriendly
+	}
+	return ""
+}
+
+func containsCategory(categories []VehicleCategory, c VehicleCategory) bool {
+	for _, allowed := range categories {
+		if allowed == c {
+			return true
+		}
+	}
+	return false
+}
+
+// MostCommonReason returns the reason that excluded the most drivers. Ties
+// go to the requirement checked first, which is the more fundamental one.
+func MostCommonReason(counts map[IneligibleReason]int) IneligibleReason {
+	order := []IneligibleReason{
+		IneligibleNoVehicle, IneligibleCategory, IneligibleCapacity,
+		IneligibleWheelchairAccess, IneligibleChildSeat, IneligiblePetFriendly,
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	if counts[order[0]] == 0 {
+		return ""
+	}
+	return order[0]
+}
+
+// NoEligibleDriverMessage returns the rider-facing explanation for a reason
+func NoEligibleDriverMessage(reason IneligibleReason) string {
+	if msg, ok := ineligibleMessages[reason]; ok {
+		return msg
+	}
+	return fmt.Sprintf("No nearby drivers match this ride (%s).", reason)
+}
@@ -0,0 +1,105 @@
+package vehicle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRideRequirements_Check(t *testing.T) {
+	sedan := DriverEquipment{HasVehicle: true, Category: VehicleCategoryEconomy, MaxPassengers: 4}
+
+	tests := []struct {
+		name      string
+		req       RideRequirements
+		equipment DriverEquipment
+		want      IneligibleReason
+	}{
+		{
+			name:      "no requirements",
+			req:       RideRequirements{},
+			equipment: sedan,
+			want:      "",
+		},
+		{
+			name:      "no approved vehicle",
+			req:       RideRequirements{},
+			equipment: DriverEquipment{},
+			want:      IneligibleNoVehicle,
+		},
+		{
+			name:      "category allowed",
+			req:       RideRequirements{Categories: []VehicleCategory{VehicleCategoryEconomy, VehicleCategoryComfort}},
+			equipment: sedan,
+			want:      "",
+		},
+		{
+			name:      "category not allowed",
+			req:       RideRequirements{Categories: []VehicleCategory{VehicleCategoryPremium, VehicleCategoryLux}},
+			equipment: sedan,
+			want:      IneligibleCategory,
+		},
+		{
+			name:      "too few seats",
+			req:       RideRequirements{MinPassengers: 6},
+			equipment: sedan,
+			want:      IneligibleCapacity,
+		},
+		{
+			name:      "wheelchair access missing",
+			req:       RideRequirements{WheelchairAccess: true},
+			equipment: sedan,
+			want:      IneligibleWheelchairAccess,
+		},
+		{
+			name:      "wheelchair accessible vehicle",
+			req:       RideRequirements{WheelchairAccess: true},
+			equipment: DriverEquipment{HasVehicle: true, Category: VehicleCategoryWAV, MaxPassengers: 4},
+			want:      "",
+		},
+		{
+			name:      "child seat missing",
+			req:       RideRequirements{ChildSeat: true},
+			equipment: sedan,
+			want:      IneligibleChildSeat,
+		},
+		{
+			name:      "pets not accepted",
+			req:       RideRequirements{PetFriendly: true},
+			equipment: sedan,
+			want:      IneligiblePetFriendly,
+		},
+		{
+			name: "all needs met",
+			req:  RideRequirements{MinPassengers: 4, ChildSeat: true, PetFriendly: true},
+			equipment: DriverEquipment{
+				HasVehicle: true, Category: VehicleCategoryComfort, MaxPassengers: 4, ChildSeat: true, PetFriendly: true,
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.req.Check(tt.equipment))
+		})
+	}
+}
+
+func TestMostCommonReason(t *testing.T) {
+	assert.Equal(t, IneligibleReason(""), MostCommonReason(nil))
+	assert.Equal(t, IneligibleChildSeat, MostCommonReason(map[IneligibleReason]int{
+		IneligibleCategory:  1,
+		IneligibleChildSeat: 3,
+	}))
+	// Ties go to the requirement checked first
+	assert.Equal(t, IneligibleCategory, MostCommonReason(map[IneligibleReason]int{
+		IneligiblePetFriendly: 2,
+		IneligibleCategory:    2,
+	}))
+}
+
+func TestNoEligibleDriverMessage(t *testing.T) {
+	assert.Equal(t, "No nearby drivers accept pets.", NoEligibleDriverMessage(IneligiblePetFriendly))
+	assert.Contains(t, NoEligibleDriverMessage("unknown"), "unknown")
+}
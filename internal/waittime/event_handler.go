@@ -0,0 +1,66 @@
+package waittime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/richxcame/ride-hailing/pkg/eventbus"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// EventHandler drives pickup wait timers from ride lifecycle events.
+type EventHandler struct {
+	service *Service
+}
+
+// NewEventHandler creates an event handler backed by the wait time service.
+func NewEventHandler(service *Service) *EventHandler {
+	return &EventHandler{service: service}
+}
+
+// RegisterSubscriptions subscribes to driver arrival and ride start events on the bus.
+func (h *EventHandler) RegisterSubscriptions(ctx context.Context, bus *eventbus.Bus) error {
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideDriverArrived, "waittime-driver-arrived", h.handleDriverArrived); err != nil {
+		return fmt.Errorf("subscribe to %s: %w", eventbus.SubjectRideDriverArrived, err)
+	}
+	if err := bus.Subscribe(ctx, eventbus.SubjectRideStarted, "waittime-ride-started", h.handleRideStarted); err != nil {
+		return fmt.Errorf("subscribe to %s: %w", eventbus.SubjectRideStarted, err)
+	}
+	logger.Info("waittime: subscribed to ride arrival and start events")
+	return nil
+}
+
+func (h *EventHandler) handleDriverArrived(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideDriverArrivedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal driver arrived: %w", err)
+	}
+
+	if err := h.service.StartPickupWait(ctx, data.RideID, data.DriverID, data.ArrivedAt); err != nil {
+		logger.Error("waittime: failed to start pickup wait",
+			zap.String("ride_id", data.RideID.String()),
+			zap.String("driver_id", data.DriverID.String()),
+			zap.Error(err),
+		)
+		return fmt.Errorf("start pickup wait: %w", err)
+	}
+	return nil
+}
+
+func (h *EventHandler) handleRideStarted(ctx context.Context, event *eventbus.Event) error {
+	var data eventbus.RideStartedData
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		return fmt.Errorf("unmarshal ride started: %w", err)
+	}
+
+	if err := h.service.EndPickupWait(ctx, data.RideID, data.StartedAt); err != nil {
+		logger.Error("waittime: failed to end pickup wait",
+			zap.String("ride_id", data.RideID.String()),
+			zap.Error(err),
+		)
+		return fmt.Errorf("end pickup wait: %w", err)
+	}
+	return nil
+}
@@ -26,7 +26,8 @@ func NewHandler(service *Service) *Handler {
 // DRIVER ENDPOINTS
 // ========================================
 
-// StartWait starts the wait timer when driver arrives
+// StartWait starts a non-pickup wait timer, e.g. at an intermediate stop.
+// Pickup waits start from the ride's driver_arrived event.
 // POST /api/v1/driver/wait/start
 func (h *Handler) StartWait(c *gin.Context) {
 	driverID, err := middleware.GetUserID(c)
@@ -172,7 +172,7 @@ func TestHandler_StartWait_Success(t *testing.T) {
 
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	mockRepo.On("GetActiveWaitByRide", mock.Anything, rideID).Return(nil, nil)
@@ -199,7 +199,7 @@ func TestHandler_StartWait_Unauthorized(t *testing.T) {
 	rideID := uuid.New()
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	c, w := setupTestContext("POST", "/api/v1/driver/wait/start", reqBody)
@@ -240,7 +240,7 @@ func TestHandler_StartWait_AlreadyActive(t *testing.T) {
 
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	mockRepo.On("GetActiveWaitByRide", mock.Anything, rideID).Return(existingRecord, nil)
@@ -265,7 +265,7 @@ func TestHandler_StartWait_ServiceError(t *testing.T) {
 
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	mockRepo.On("GetActiveWaitByRide", mock.Anything, rideID).Return(nil, nil)
@@ -291,7 +291,7 @@ func TestHandler_StartWait_NoActiveConfig(t *testing.T) {
 
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	// No active config - should use defaults
@@ -834,7 +834,7 @@ func TestHandler_StartWait_ValidationCases(t *testing.T) {
 			name: "valid request",
 			reqBody: StartWaitRequest{
 				RideID:   uuid.New(),
-				WaitType: "pickup",
+				WaitType: "dropoff",
 			},
 			setUserContext: true,
 			expectedStatus: http.StatusCreated,
@@ -848,7 +848,7 @@ func TestHandler_StartWait_ValidationCases(t *testing.T) {
 		{
 			name: "missing ride_id",
 			reqBody: map[string]interface{}{
-				"wait_type": "pickup",
+				"wait_type": "dropoff",
 			},
 			setUserContext: true,
 			expectedStatus: http.StatusBadRequest,
@@ -857,7 +857,7 @@ func TestHandler_StartWait_ValidationCases(t *testing.T) {
 			name: "unauthorized",
 			reqBody: StartWaitRequest{
 				RideID:   uuid.New(),
-				WaitType: "pickup",
+				WaitType: "dropoff",
 			},
 			setUserContext: false,
 			expectedStatus: http.StatusUnauthorized,
@@ -969,7 +969,7 @@ func TestHandler_StartWait_ResponseFormat(t *testing.T) {
 
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	mockRepo.On("GetActiveWaitByRide", mock.Anything, rideID).Return(nil, nil)
@@ -1113,7 +1113,7 @@ func TestHandler_StartWait_RepoError(t *testing.T) {
 
 	reqBody := StartWaitRequest{
 		RideID:   rideID,
-		WaitType: "pickup",
+		WaitType: "dropoff",
 	}
 
 	mockRepo.On("GetActiveWaitByRide", mock.Anything, rideID).Return(nil, errors.New("db error"))
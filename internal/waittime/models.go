@@ -20,6 +20,10 @@ type WaitTimeConfig struct {
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// WaitTypePickup is the wait between the driver arriving at pickup and the
+// ride starting. It is started and stopped by ride lifecycle events.
+const WaitTypePickup = "pickup"
+
 // WaitTimeRecord tracks actual wait time for a ride
 type WaitTimeRecord struct {
 	ID              uuid.UUID  `json:"id" db:"id"`
@@ -27,8 +27,14 @@ func NewService(repo RepositoryInterface) *Service {
 	return &Service{repo: repo}
 }
 
-// StartWait records that the driver has arrived and the wait timer begins
+// StartWait starts a wait timer requested by the driver app. Pickup waits are
+// started from the ride's driver_arrived event instead, so the arrival time
+// cannot be set by the client.
 func (s *Service) StartWait(ctx context.Context, driverID uuid.UUID, req *StartWaitRequest) (*WaitTimeRecord, error) {
+	if req.WaitType == WaitTypePickup {
+		return nil, common.NewBadRequestError("pickup wait starts automatically when the driver arrives", nil)
+	}
+
 	// Check no active wait exists
 	existing, err := s.repo.GetActiveWaitByRide(ctx, req.RideID)
 	if err != nil {
@@ -38,6 +44,26 @@ func (s *Service) StartWait(ctx context.Context, driverID uuid.UUID, req *StartW
 		return nil, common.NewConflictError("wait timer already active for this ride")
 	}
 
+	return s.startWait(ctx, req.RideID, driverID, req.WaitType, time.Now())
+}
+
+// StartPickupWait starts the pickup wait timer at the driver's arrival time.
+// It is a no-op if a wait is already active, so redelivered events are safe.
+func (s *Service) StartPickupWait(ctx context.Context, rideID, driverID uuid.UUID, arrivedAt time.Time) error {
+	existing, err := s.repo.GetActiveWaitByRide(ctx, rideID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	_, err = s.startWait(ctx, rideID, driverID, WaitTypePickup, arrivedAt)
+	return err
+}
+
+// startWait creates an active wait record using the active config.
+func (s *Service) startWait(ctx context.Context, rideID, driverID uuid.UUID, waitType string, arrivedAt time.Time) (*WaitTimeRecord, error) {
 	// Get active config or use defaults
 	config, err := s.repo.GetActiveConfig(ctx)
 	if err != nil {
@@ -53,11 +79,11 @@ func (s *Service) StartWait(ctx context.Context, driverID uuid.UUID, req *StartW
 	now := time.Now()
 	record := &WaitTimeRecord{
 		ID:              uuid.New(),
-		RideID:          req.RideID,
+		RideID:          rideID,
 		DriverID:        driverID,
 		ConfigID:        config.ID,
-		WaitType:        req.WaitType,
-		ArrivedAt:       now,
+		WaitType:        waitType,
+		ArrivedAt:       arrivedAt,
 		FreeMinutes:     config.FreeWaitMinutes,
 		ChargePerMinute: config.ChargePerMinute,
 		Status:          "waiting",
@@ -86,9 +112,30 @@ func (s *Service) StopWait(ctx context.Context, driverID uuid.UUID, req *StopWai
 		return nil, common.NewForbiddenError("not your ride")
 	}
 
+	if err := s.completeWait(ctx, record, time.Now()); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// EndPickupWait stops the active pickup wait at the time the ride started.
+// It is a no-op if no pickup wait is active.
+func (s *Service) EndPickupWait(ctx context.Context, rideID uuid.UUID, startedAt time.Time) error {
+	record, err := s.repo.GetActiveWaitByRide(ctx, rideID)
+	if err != nil {
+		return err
+	}
+	if record == nil || record.WaitType != WaitTypePickup {
+		return nil
+	}
+	return s.completeWait(ctx, record, startedAt)
+}
+
+// completeWait calculates charges for a wait that ended at endedAt and marks
+// the record completed.
+func (s *Service) completeWait(ctx context.Context, record *WaitTimeRecord, endedAt time.Time) error {
 	// Calculate wait time and charges
-	now := time.Now()
-	totalWaitMin := now.Sub(record.ArrivedAt).Minutes()
+	totalWaitMin := math.Max(0, endedAt.Sub(record.ArrivedAt).Minutes())
 	chargeableMin := math.Max(0, totalWaitMin-float64(record.FreeMinutes))
 	totalCharge := chargeableMin * record.ChargePerMinute
 
@@ -111,7 +158,7 @@ func (s *Service) StopWait(ctx context.Context, driverID uuid.UUID, req *StopWai
 	totalWaitMin = math.Round(totalWaitMin*100) / 100
 
 	if err := s.repo.CompleteWait(ctx, record.ID, totalWaitMin, chargeableMin, totalCharge, wasCapped); err != nil {
-		return nil, err
+		return err
 	}
 
 	record.TotalWaitMinutes = totalWaitMin
@@ -119,9 +166,9 @@ func (s *Service) StopWait(ctx context.Context, driverID uuid.UUID, req *StopWai
 	record.TotalCharge = totalCharge
 	record.WasCapped = wasCapped
 	record.Status = "completed"
-	record.StartedAt = &now
+	record.StartedAt = &endedAt
 
-	return record, nil
+	return nil
 }
 
 // GetWaitTimeSummary retrieves all wait time charges for a ride
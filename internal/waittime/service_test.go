@@ -132,7 +132,7 @@ func TestStartWait(t *testing.T) {
 				assert.Equal(t, rideID, record.RideID)
 				assert.Equal(t, driverID, record.DriverID)
 				assert.Equal(t, configID, record.ConfigID)
-				assert.Equal(t, "pickup", record.WaitType)
+				assert.Equal(t, "dropoff", record.WaitType)
 				assert.Equal(t, 5, record.FreeMinutes)
 				assert.Equal(t, 0.50, record.ChargePerMinute)
 				assert.Equal(t, "waiting", record.Status)
@@ -192,7 +192,7 @@ func TestStartWait(t *testing.T) {
 
 			req := &StartWaitRequest{
 				RideID:   rideID,
-				WaitType: "pickup",
+				WaitType: "dropoff",
 			}
 
 			record, err := svc.StartWait(context.Background(), driverID, req)
@@ -215,6 +215,104 @@ func TestStartWait(t *testing.T) {
 	}
 }
 
+func TestStartWait_RejectsPickup(t *testing.T) {
+	m := new(mockRepo)
+	svc := newTestService(m)
+
+	record, err := svc.StartWait(context.Background(), uuid.New(), &StartWaitRequest{
+		RideID:   uuid.New(),
+		WaitType: WaitTypePickup,
+	})
+
+	require.Error(t, err)
+	assert.Nil(t, record)
+	var appErr *common.AppError
+	require.True(t, errors.As(err, &appErr))
+	assert.Equal(t, 400, appErr.Code)
+	m.AssertExpectations(t)
+}
+
+// ========================================
+// RIDE EVENT TESTS
+// ========================================
+
+func TestStartPickupWait(t *testing.T) {
+	rideID := uuid.New()
+	driverID := uuid.New()
+	arrivedAt := time.Now().Add(-2 * time.Minute)
+
+	t.Run("creates pickup wait at arrival time", func(t *testing.T) {
+		m := new(mockRepo)
+		m.On("GetActiveWaitByRide", mock.Anything, rideID).Return(nil, nil)
+		m.On("GetActiveConfig", mock.Anything).Return(nil, errors.New("no config"))
+		m.On("CreateRecord", mock.Anything, mock.MatchedBy(func(rec *WaitTimeRecord) bool {
+			return rec.RideID == rideID && rec.DriverID == driverID &&
+				rec.WaitType == WaitTypePickup && rec.ArrivedAt.Equal(arrivedAt)
+		})).Return(nil)
+
+		err := newTestService(m).StartPickupWait(context.Background(), rideID, driverID, arrivedAt)
+
+		require.NoError(t, err)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("redelivered event is a no-op", func(t *testing.T) {
+		m := new(mockRepo)
+		m.On("GetActiveWaitByRide", mock.Anything, rideID).Return(&WaitTimeRecord{ID: uuid.New(), Status: "waiting"}, nil)
+
+		err := newTestService(m).StartPickupWait(context.Background(), rideID, driverID, arrivedAt)
+
+		require.NoError(t, err)
+		m.AssertNotCalled(t, "CreateRecord", mock.Anything, mock.Anything)
+	})
+}
+
+func TestEndPickupWait(t *testing.T) {
+	rideID := uuid.New()
+	recordID := uuid.New()
+	arrivedAt := time.Now().Add(-20 * time.Minute)
+	startedAt := arrivedAt.Add(8 * time.Minute)
+
+	t.Run("charges up to the ride start time", func(t *testing.T) {
+		m := new(mockRepo)
+		m.On("GetActiveWaitByRide", mock.Anything, rideID).Return(&WaitTimeRecord{
+			ID:              recordID,
+			RideID:          rideID,
+			WaitType:        WaitTypePickup,
+			ArrivedAt:       arrivedAt,
+			FreeMinutes:     3,
+			ChargePerMinute: 0.5,
+			Status:          "waiting",
+		}, nil)
+		m.On("GetActiveConfig", mock.Anything).Return(nil, errors.New("no config"))
+		m.On("CompleteWait", mock.Anything, recordID, 8.0, 5.0, 2.5, false).Return(nil)
+
+		err := newTestService(m).EndPickupWait(context.Background(), rideID, startedAt)
+
+		require.NoError(t, err)
+		m.AssertExpectations(t)
+	})
+
+	t.Run("ignores non-pickup waits", func(t *testing.T) {
+		m := new(mockRepo)
+		m.On("GetActiveWaitByRide", mock.Anything, rideID).Return(&WaitTimeRecord{ID: recordID, WaitType: "dropoff"}, nil)
+
+		err := newTestService(m).EndPickupWait(context.Background(), rideID, startedAt)
+
+		require.NoError(t, err)
+		m.AssertNotCalled(t, "CompleteWait", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("no active wait", func(t *testing.T) {
+		m := new(mockRepo)
+		m.On("GetActiveWaitByRide", mock.Anything, rideID).Return(nil, nil)
+
+		err := newTestService(m).EndPickupWait(context.Background(), rideID, startedAt)
+
+		require.NoError(t, err)
+	})
+}
+
 // ========================================
 // STOP WAIT TESTS
 // ========================================
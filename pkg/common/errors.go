@@ -37,17 +37,23 @@ const (
 	ErrCodeConflict = "RESOURCE_CONFLICT"
 
 	// Ride errors
-	ErrCodeRideNotAvailable = "RIDE_NOT_AVAILABLE"
-	ErrCodeRideNotStarted   = "RIDE_NOT_STARTED"
-	ErrCodeRideAlreadyDone  = "RIDE_ALREADY_COMPLETED"
+	ErrCodeRideNotAvailable      = "RIDE_NOT_AVAILABLE"
+	ErrCodeRideNotStarted        = "RIDE_NOT_STARTED"
+	ErrCodeRideAlreadyDone       = "RIDE_ALREADY_COMPLETED"
+	ErrCodeRideInvalidTransition = "RIDE_INVALID_TRANSITION"
 
 	// Payment errors
 	ErrCodePaymentFailed    = "PAYMENT_FAILED"
 	ErrCodeInsufficientFunds = "PAYMENT_INSUFFICIENT_FUNDS"
 
+	// Pricing errors
+	ErrCodeQuoteExpired = "PRICING_QUOTE_EXPIRED"
+	ErrCodeQuoteInvalid = "PRICING_QUOTE_INVALID"
+
 	// Driver errors
 	ErrCodeDriverUnauthorized = "DRIVER_UNAUTHORIZED"
 	ErrCodeDriverUnavailable  = "DRIVER_UNAVAILABLE"
+	ErrCodeNoEligibleDriver   = "DRIVER_NONE_ELIGIBLE"
 
 	// System errors
 	ErrCodeInternal           = "INTERNAL_ERROR"
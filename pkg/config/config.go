@@ -33,6 +33,24 @@ type Config struct {
 	Maps          MapsConfig
 	Checkr        CheckrConfig
 	Onfido        OnfidoConfig
+	Matching      MatchingConfig
+}
+
+// MatchingConfig holds the realtime service's batch matching configuration
+type MatchingConfig struct {
+	BatchEnabled      bool
+	BatchWindowMS     int // How long an H3 cell collects ride requests before drivers are assigned
+	BatchETATimeoutMS int // Pickup ETAs not estimated in time use straight-line distance
+}
+
+// BatchWindow returns the batch window as a duration
+func (m MatchingConfig) BatchWindow() time.Duration {
+	return time.Duration(m.BatchWindowMS) * time.Millisecond
+}
+
+// BatchETATimeout returns the pickup ETA deadline as a duration
+func (m MatchingConfig) BatchETATimeout() time.Duration {
+	return time.Duration(m.BatchETATimeoutMS) * time.Millisecond
 }
 
 // CheckrConfig holds Checkr background check configuration
@@ -151,6 +169,7 @@ type NATSConfig struct {
 	URL        string
 	StreamName string
 	Enabled    bool
+	MaxDeliver int // deliveries before a failing event is dead-lettered
 }
 
 // FirebaseConfig holds Firebase configuration
@@ -164,12 +183,20 @@ type FirebaseConfig struct {
 // PaymentsConfig holds payment provider configuration.
 type PaymentsConfig struct {
 	StripeAPIKey string
+	// Webhook signing secrets for the platform endpoint and the Connect
+	// endpoint that receives events from drivers' connected accounts
+	StripeWebhookSecret        string
+	StripeConnectWebhookSecret string
+	// Accept unsigned webhooks when no secret is set; development only and
+	// ignored in production
+	StripeWebhookAllowUnsigned bool
 }
 
 // BusinessConfig holds configurable business logic parameters.
 type BusinessConfig struct {
 	CommissionRate      float64 // Platform commission rate (default: 0.20 = 20%)
 	CancellationFeeRate float64 // Cancellation fee rate (default: 0.10 = 10%)
+	AuthHoldBufferRate  float64 // Card hold above the estimated fare (default: 0.20 = 20%)
 }
 
 // NotificationsConfig stores third-party notification credentials.
@@ -387,6 +414,7 @@ func Load(serviceName string) (*Config, error) {
 			URL:        getEnv("NATS_URL", "nats://localhost:4222"),
 			StreamName: getEnv("NATS_STREAM_NAME", "RIDEHAILING"),
 			Enabled:    getEnvAsBool("NATS_ENABLED", false),
+			MaxDeliver: getEnvAsInt("NATS_MAX_DELIVER", 5),
 		},
 		Firebase: FirebaseConfig{
 			ProjectID:       getEnv("FIREBASE_PROJECT_ID", ""),
@@ -395,11 +423,15 @@ func Load(serviceName string) (*Config, error) {
 			Enabled:         getEnvAsBool("FIREBASE_ENABLED", false),
 		},
 		Payments: PaymentsConfig{
-			StripeAPIKey: getEnv("STRIPE_API_KEY", ""),
+			StripeAPIKey:               getEnv("STRIPE_API_KEY", ""),
+			StripeWebhookSecret:        getEnv("STRIPE_WEBHOOK_SECRET", ""),
+			StripeConnectWebhookSecret: getEnv("STRIPE_CONNECT_WEBHOOK_SECRET", ""),
+			StripeWebhookAllowUnsigned: getEnvAsBool("STRIPE_WEBHOOK_ALLOW_UNSIGNED", false),
 		},
 		Business: BusinessConfig{
 			CommissionRate:      getEnvAsFloat("COMMISSION_RATE", 0.20),
 			CancellationFeeRate: getEnvAsFloat("CANCELLATION_FEE_RATE", 0.10),
+			AuthHoldBufferRate:  getEnvAsFloat("AUTH_HOLD_BUFFER_RATE", 0.20),
 		},
 		Notifications: NotificationsConfig{
 			TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
@@ -460,6 +492,11 @@ func Load(serviceName string) (*Config, error) {
 			TrafficRefreshSecs:  getEnvAsInt("MAPS_TRAFFIC_REFRESH_SECONDS", 60),
 			FallbackToHaversine: getEnvAsBool("MAPS_FALLBACK_HAVERSINE", true),
 		},
+		Matching: MatchingConfig{
+			BatchEnabled:      getEnvAsBool("MATCHING_BATCH_ENABLED", false),
+			BatchWindowMS:     getEnvAsInt("MATCHING_BATCH_WINDOW_MS", 2000),
+			BatchETATimeoutMS: getEnvAsInt("MATCHING_BATCH_ETA_TIMEOUT_MS", 1000),
+		},
 		Checkr: CheckrConfig{
 			APIKey:     getEnv("CHECKR_API_KEY", ""),
 			WebhookKey: getEnv("CHECKR_WEBHOOK_KEY", ""),
@@ -766,6 +803,8 @@ func (c *Config) applySecretOverrides(ctx context.Context) error {
 		if apiKey := firstNonEmpty(secret.Data["api_key"], secret.Data["key"], secret.Data["stripe_api_key"]); apiKey != "" {
 			c.Payments.StripeAPIKey = apiKey
 		}
+		overrideString(&c.Payments.StripeWebhookSecret, secret.Data["webhook_secret"])
+		overrideString(&c.Payments.StripeConnectWebhookSecret, secret.Data["connect_webhook_secret"])
 	}
 
 	if ref := c.Secrets.References.Twilio; ref != nil {
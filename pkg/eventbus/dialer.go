@@ -0,0 +1,113 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// ErrNotConnected is returned by a Dialer that has not reached the bus yet.
+var ErrNotConnected = errors.New("event bus not connected")
+
+// Dialer connects to the bus in the background, retrying until it succeeds,
+// so a service can start and stage events while NATS is down. Until then
+// Publish and Broadcast fail with ErrNotConnected and Connected reports
+// false; once connected they go to the bus.
+type Dialer struct {
+	cfg      Config
+	interval time.Duration
+	dial     func(Config) (*Bus, error)
+
+	mu        sync.RWMutex
+	bus       *Bus
+	onConnect []func(*Bus)
+}
+
+// NewDialer creates a dialer that retries every interval (default 5s).
+func NewDialer(cfg Config, interval time.Duration) *Dialer {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &Dialer{cfg: cfg, interval: interval, dial: New}
+}
+
+// OnConnect registers fn to run once the bus is connected, for wiring that
+// needs the bus itself such as subscriptions. Register before calling Run.
+func (d *Dialer) OnConnect(fn func(*Bus)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onConnect = append(d.onConnect, fn)
+}
+
+// Run connects to the bus, retrying until it succeeds or ctx is cancelled,
+// and then runs the OnConnect callbacks.
+func (d *Dialer) Run(ctx context.Context) {
+	for attempt := 1; ; attempt++ {
+		bus, err := d.dial(d.cfg)
+		if err == nil {
+			d.mu.Lock()
+			d.bus = bus
+			callbacks := d.onConnect
+			d.mu.Unlock()
+
+			logger.Info("NATS event bus connected", zap.Int("attempt", attempt))
+			for _, fn := range callbacks {
+				fn(bus)
+			}
+			return
+		}
+		if attempt == 1 {
+			logger.Warn("Failed to connect to NATS, retrying in the background",
+				zap.Duration("interval", d.interval), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.interval):
+		}
+	}
+}
+
+// Bus returns the connected bus, or nil before Run has connected.
+func (d *Dialer) Bus() *Bus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.bus
+}
+
+// Connected returns true once the bus is connected and its NATS connection
+// is active.
+func (d *Dialer) Connected() bool {
+	bus := d.Bus()
+	return bus != nil && bus.Connected()
+}
+
+// Publish sends an event through the bus once it is connected.
+func (d *Dialer) Publish(ctx context.Context, subject string, event *Event) error {
+	bus := d.Bus()
+	if bus == nil {
+		return ErrNotConnected
+	}
+	return bus.Publish(ctx, subject, event)
+}
+
+// Broadcast sends an event over core NATS once the bus is connected.
+func (d *Dialer) Broadcast(subject string, event *Event) error {
+	bus := d.Bus()
+	if bus == nil {
+		return ErrNotConnected
+	}
+	return bus.Broadcast(subject, event)
+}
+
+// Close closes the bus if it was connected.
+func (d *Dialer) Close() {
+	if bus := d.Bus(); bus != nil {
+		bus.Close()
+	}
+}
@@ -0,0 +1,52 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialer_RetriesUntilConnected(t *testing.T) {
+	d := NewDialer(Config{}, time.Millisecond)
+	bus := &Bus{}
+	attempts := 0
+	d.dial = func(Config) (*Bus, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("connection refused")
+		}
+		return bus, nil
+	}
+
+	var connected *Bus
+	d.OnConnect(func(b *Bus) { connected = b })
+
+	evt, err := NewEvent("rides.requested", "test", nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, d.Publish(context.Background(), SubjectRideRequested, evt), ErrNotConnected)
+	assert.ErrorIs(t, d.Broadcast(SubjectRiderDriverListsChanged, evt), ErrNotConnected)
+	assert.False(t, d.Connected())
+
+	d.Run(context.Background())
+
+	assert.Equal(t, 3, attempts)
+	assert.Same(t, bus, connected)
+	assert.Same(t, bus, d.Bus())
+}
+
+func TestDialer_StopsWhenCancelled(t *testing.T) {
+	d := NewDialer(Config{}, time.Hour)
+	d.dial = func(Config) (*Bus, error) { return nil, errors.New("connection refused") }
+	d.OnConnect(func(*Bus) { t.Error("OnConnect must not run without a connection") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx)
+
+	assert.Nil(t, d.Bus())
+	d.Close()
+}
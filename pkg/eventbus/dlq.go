@@ -0,0 +1,280 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// Subject prefixes for dead-letter handling.
+const (
+	// SubjectDeadLetterPrefix prefixes per-consumer DLQ subjects (dlq.<consumer>).
+	SubjectDeadLetterPrefix = "dlq"
+	// SubjectReplayPrefix prefixes targeted redelivery subjects
+	// (replay.<consumer>.<original subject>).
+	SubjectReplayPrefix = "replay"
+)
+
+const (
+	defaultMaxDeliver = 5
+	defaultDLQMaxAge  = 14 * 24 * time.Hour
+	maxRetryDelay     = 30 * time.Second
+)
+
+// ErrDeadLetterNotFound is returned when a DLQ sequence does not exist.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrDeadLetterNotReplayable is returned when a dead letter has no decodable
+// event (e.g. the original message was malformed JSON).
+var ErrDeadLetterNotReplayable = errors.New("dead letter has no replayable event")
+
+// DeadLetter records an event that a consumer could not process.
+type DeadLetter struct {
+	Consumer       string    `json:"consumer"`
+	Subject        string    `json:"subject"`
+	Event          *Event    `json:"event,omitempty"`
+	RawData        []byte    `json:"raw_data,omitempty"` // set when the message could not be decoded
+	Error          string    `json:"error"`
+	Deliveries     uint64    `json:"deliveries"`
+	StreamSequence uint64    `json:"stream_sequence"` // sequence in the main stream
+	FailedAt       time.Time `json:"failed_at"`
+}
+
+// DeadLetterMessage is a DeadLetter together with its position in the DLQ stream.
+type DeadLetterMessage struct {
+	Sequence uint64 `json:"sequence"`
+	DeadLetter
+}
+
+// DeadLetterSubject returns the DLQ subject for a consumer.
+func DeadLetterSubject(consumerName string) string {
+	return SubjectDeadLetterPrefix + "." + consumerName
+}
+
+// ReplaySubject returns the subject prefix a consumer listens on for replays.
+func ReplaySubject(consumerName string) string {
+	return SubjectReplayPrefix + "." + consumerName
+}
+
+func dlqStreamName(streamName string) string {
+	return streamName + "_DLQ"
+}
+
+// originalSubject strips the replay prefix from a delivered subject.
+func originalSubject(consumerName, subject string) string {
+	return strings.TrimPrefix(subject, ReplaySubject(consumerName)+".")
+}
+
+// retryDelay returns the redelivery delay after n failed deliveries.
+func retryDelay(deliveries uint64) time.Duration {
+	if deliveries < 1 {
+		deliveries = 1
+	}
+	if deliveries > 6 {
+		return maxRetryDelay
+	}
+	delay := time.Second << (deliveries - 1)
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// deadLetter publishes msg to the consumer's DLQ and acks it. If the DLQ publish
+// fails the message is nacked so it is retried rather than lost.
+func (b *Bus) deadLetter(ctx context.Context, msg jetstream.Msg, consumerName, subject string, event *Event, cause error, deliveries uint64) {
+	dl := DeadLetter{
+		Consumer:   consumerName,
+		Subject:    subject,
+		Event:      event,
+		Error:      cause.Error(),
+		Deliveries: deliveries,
+		FailedAt:   time.Now().UTC(),
+	}
+	if event == nil {
+		dl.RawData = msg.Data()
+	}
+	if meta, err := msg.Metadata(); err == nil {
+		dl.StreamSequence = meta.Sequence.Stream
+	}
+
+	data, err := json.Marshal(dl)
+	if err != nil {
+		logger.Error("failed to marshal dead letter", zap.String("consumer", consumerName), zap.Error(err))
+		msg.NakWithDelay(retryDelay(deliveries))
+		return
+	}
+
+	pubCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Dedupe on the source position so a redelivered poison message is only recorded once
+	msgID := fmt.Sprintf("%s-%d", consumerName, dl.StreamSequence)
+	if _, err := b.js.Publish(pubCtx, DeadLetterSubject(consumerName), data, jetstream.WithMsgID(msgID)); err != nil {
+		logger.Error("failed to publish dead letter, will retry",
+			zap.String("consumer", consumerName),
+			zap.String("subject", subject),
+			zap.Error(err),
+		)
+		msg.NakWithDelay(retryDelay(deliveries))
+		return
+	}
+
+	recordEventProcessed(consumerName, subject, resultDeadLettered)
+	deadLettersTotal.WithLabelValues(consumerName, subject).Inc()
+	msg.Ack()
+}
+
+// DeadLetterFilter narrows a DLQ listing.
+type DeadLetterFilter struct {
+	Consumer string // empty for all consumers
+	AfterSeq uint64 // return messages with sequence > AfterSeq
+	Limit    int
+}
+
+func (b *Bus) dlqStream(ctx context.Context) (jetstream.Stream, error) {
+	streamName := b.cfg.StreamName
+	if streamName == "" {
+		streamName = "RIDEHAILING"
+	}
+	stream, err := b.js.Stream(ctx, dlqStreamName(streamName))
+	if err != nil {
+		return nil, fmt.Errorf("open dead-letter stream: %w", err)
+	}
+	return stream, nil
+}
+
+// ListDeadLetters returns dead-lettered events in DLQ order.
+func (b *Bus) ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*DeadLetterMessage, error) {
+	stream, err := b.dlqStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	subject := SubjectDeadLetterPrefix + ".>"
+	if filter.Consumer != "" {
+		subject = DeadLetterSubject(filter.Consumer)
+	}
+
+	results := make([]*DeadLetterMessage, 0, limit)
+	seq := filter.AfterSeq + 1
+	for len(results) < limit {
+		raw, err := stream.GetMsg(ctx, seq, jetstream.WithGetMsgSubject(subject))
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read dead letter: %w", err)
+		}
+		dlm, err := decodeDeadLetter(raw)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, dlm)
+		seq = raw.Sequence + 1
+	}
+	return results, nil
+}
+
+// GetDeadLetter returns a single dead-lettered event by DLQ sequence.
+func (b *Bus) GetDeadLetter(ctx context.Context, seq uint64) (*DeadLetterMessage, error) {
+	stream, err := b.dlqStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := stream.GetMsg(ctx, seq)
+	if errors.Is(err, jetstream.ErrMsgNotFound) {
+		return nil, ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read dead letter: %w", err)
+	}
+	return decodeDeadLetter(raw)
+}
+
+// ReplayDeadLetter redelivers a dead-lettered event to the consumer that failed
+// it (only that consumer receives it) and removes it from the DLQ.
+func (b *Bus) ReplayDeadLetter(ctx context.Context, seq uint64) (*DeadLetterMessage, error) {
+	dlm, err := b.GetDeadLetter(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	if dlm.Event == nil {
+		return nil, ErrDeadLetterNotReplayable
+	}
+
+	data, err := json.Marshal(dlm.Event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+
+	// A fresh message ID so JetStream duplicate detection does not drop the replay
+	subject := ReplaySubject(dlm.Consumer) + "." + dlm.Subject
+	msgID := fmt.Sprintf("%s-replay-%d", dlm.Event.ID, seq)
+	if _, err := b.js.Publish(ctx, subject, data, jetstream.WithMsgID(msgID)); err != nil {
+		return nil, fmt.Errorf("publish replay to %s: %w", subject, err)
+	}
+
+	if err := b.deleteDeadLetter(ctx, seq); err != nil {
+		return nil, err
+	}
+
+	deadLetterReplaysTotal.WithLabelValues(dlm.Consumer, dlm.Subject).Inc()
+	logger.Info("dead letter replayed",
+		zap.Uint64("sequence", seq),
+		zap.String("consumer", dlm.Consumer),
+		zap.String("event_id", dlm.Event.ID),
+	)
+	return dlm, nil
+}
+
+// DiscardDeadLetter permanently removes a dead-lettered event.
+func (b *Bus) DiscardDeadLetter(ctx context.Context, seq uint64) (*DeadLetterMessage, error) {
+	dlm, err := b.GetDeadLetter(ctx, seq)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.deleteDeadLetter(ctx, seq); err != nil {
+		return nil, err
+	}
+
+	deadLetterDiscardsTotal.WithLabelValues(dlm.Consumer, dlm.Subject).Inc()
+	logger.Info("dead letter discarded",
+		zap.Uint64("sequence", seq),
+		zap.String("consumer", dlm.Consumer),
+	)
+	return dlm, nil
+}
+
+func (b *Bus) deleteDeadLetter(ctx context.Context, seq uint64) error {
+	stream, err := b.dlqStream(ctx)
+	if err != nil {
+		return err
+	}
+	if err := stream.DeleteMsg(ctx, seq); err != nil {
+		if errors.Is(err, jetstream.ErrMsgNotFound) {
+			return ErrDeadLetterNotFound
+		}
+		return fmt.Errorf("delete dead letter: %w", err)
+	}
+	return nil
+}
+
+func decodeDeadLetter(raw *jetstream.RawStreamMsg) (*DeadLetterMessage, error) {
+	dlm := &DeadLetterMessage{Sequence: raw.Sequence}
+	if err := json.Unmarshal(raw.Data, &dlm.DeadLetter); err != nil {
+		return nil, fmt.Errorf("decode dead letter %d: %w", raw.Sequence, err)
+	}
+	return dlm, nil
+}
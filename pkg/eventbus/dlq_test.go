@@ -0,0 +1,87 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterSubjects(t *testing.T) {
+	assert.Equal(t, "dlq.notifications-rides", DeadLetterSubject("notifications-rides"))
+	assert.Equal(t, "replay.notifications-rides", ReplaySubject("notifications-rides"))
+	assert.Equal(t, "RIDEHAILING_DLQ", dlqStreamName("RIDEHAILING"))
+}
+
+func TestOriginalSubject(t *testing.T) {
+	tests := []struct {
+		name     string
+		consumer string
+		subject  string
+		want     string
+	}{
+		{"live delivery", "notifications-rides", "rides.requested", "rides.requested"},
+		{"replayed delivery", "notifications-rides", "replay.notifications-rides.rides.requested", "rides.requested"},
+		{"replay for another consumer is untouched", "notifications-rides", "replay.realtime-rides.rides.requested", "replay.realtime-rides.rides.requested"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, originalSubject(tt.consumer, tt.subject))
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, retryDelay(0))
+	assert.Equal(t, time.Second, retryDelay(1))
+	assert.Equal(t, 2*time.Second, retryDelay(2))
+	assert.Equal(t, 16*time.Second, retryDelay(5))
+	assert.Equal(t, maxRetryDelay, retryDelay(6))
+	assert.Equal(t, maxRetryDelay, retryDelay(100))
+}
+
+func TestWithMaxDeliver(t *testing.T) {
+	opts := subscribeOptions{maxDeliver: defaultMaxDeliver}
+	WithMaxDeliver(10)(&opts)
+	assert.Equal(t, 10, opts.maxDeliver)
+}
+
+func TestDeadLetter_JSONRoundTrip(t *testing.T) {
+	evt, err := NewEvent("ride.requested", "rides-service", map[string]string{"ride_id": "abc"})
+	require.NoError(t, err)
+
+	msg := DeadLetterMessage{
+		Sequence: 42,
+		DeadLetter: DeadLetter{
+			Consumer:       "notifications-rides",
+			Subject:        SubjectRideRequested,
+			Event:          evt,
+			Error:          "smtp timeout",
+			Deliveries:     5,
+			StreamSequence: 1001,
+			FailedAt:       time.Now().UTC().Truncate(time.Second),
+		},
+	}
+
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+
+	// The embedded DeadLetter is flattened alongside the DLQ sequence
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, float64(42), raw["sequence"])
+	assert.Equal(t, "notifications-rides", raw["consumer"])
+	assert.NotContains(t, raw, "raw_data")
+
+	var decoded DeadLetterMessage
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, msg.Sequence, decoded.Sequence)
+	assert.Equal(t, msg.Consumer, decoded.Consumer)
+	assert.Equal(t, msg.Error, decoded.Error)
+	assert.Equal(t, msg.Deliveries, decoded.Deliveries)
+	assert.Equal(t, evt.ID, decoded.Event.ID)
+	assert.True(t, msg.FailedAt.Equal(decoded.FailedAt))
+}
@@ -15,11 +15,13 @@ import (
 
 // Subjects for ride-hailing events.
 const (
-	SubjectRideRequested = "rides.requested"
-	SubjectRideAccepted  = "rides.accepted"
-	SubjectRideStarted   = "rides.started"
-	SubjectRideCompleted = "rides.completed"
-	SubjectRideCancelled = "rides.cancelled"
+	SubjectRideRequested     = "rides.requested"
+	SubjectRideAccepted      = "rides.accepted"
+	SubjectRideDriverArrived = "rides.driver_arrived"
+	SubjectRideStarted       = "rides.started"
+	SubjectRideCompleted     = "rides.completed"
+	SubjectRideCancelled     = "rides.cancelled"
+	SubjectRideStopUpdated   = "rides.stop_updated"
 
 	SubjectPaymentProcessed = "payments.processed"
 	SubjectPaymentFailed    = "payments.failed"
@@ -29,6 +31,10 @@ const (
 	SubjectDriverOffline         = "drivers.offline"
 
 	SubjectFraudDetected = "fraud.detected"
+
+	// Sent with Broadcast, so every process caching a rider's blocked and
+	// trusted drivers drops them
+	SubjectRiderDriverListsChanged = "riders.driver_lists_changed"
 )
 
 // Event is the envelope for all events published through the bus.
@@ -60,9 +66,11 @@ type HandlerFunc func(ctx context.Context, event *Event) error
 
 // Config holds NATS connection settings.
 type Config struct {
-	URL       string
-	Name      string // client connection name
-	StreamName string // JetStream stream name (default: "RIDEHAILING")
+	URL        string
+	Name       string        // client connection name
+	StreamName string        // JetStream stream name (default: "RIDEHAILING")
+	MaxDeliver int           // deliveries before an event is dead-lettered (default: 5)
+	DLQMaxAge  time.Duration // retention of dead-lettered events (default: 14 days)
 }
 
 // DefaultConfig returns sensible defaults for local development.
@@ -71,15 +79,17 @@ func DefaultConfig() Config {
 		URL:        nats.DefaultURL,
 		Name:       "ride-hailing",
 		StreamName: "RIDEHAILING",
+		MaxDeliver: defaultMaxDeliver,
+		DLQMaxAge:  defaultDLQMaxAge,
 	}
 }
 
 // Bus wraps a NATS JetStream connection for publishing and subscribing.
 type Bus struct {
-	conn   *nats.Conn
-	js     jetstream.JetStream
-	cfg    Config
-	subs   []jetstream.ConsumeContext
+	conn *nats.Conn
+	js   jetstream.JetStream
+	cfg  Config
+	subs []jetstream.ConsumeContext
 }
 
 // New connects to NATS and ensures the JetStream stream exists.
@@ -119,7 +129,7 @@ func New(cfg Config) (*Bus, error) {
 
 	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
 		Name:      streamName,
-		Subjects:  []string{"rides.>", "payments.>", "drivers.>", "fraud.>"},
+		Subjects:  []string{"rides.>", "payments.>", "drivers.>", "fraud.>", SubjectReplayPrefix + ".>"},
 		Storage:   jetstream.FileStorage,
 		Retention: jetstream.InterestPolicy,
 		MaxAge:    72 * time.Hour,
@@ -130,6 +140,25 @@ func New(cfg Config) (*Bus, error) {
 		return nil, fmt.Errorf("create stream: %w", err)
 	}
 
+	dlqMaxAge := cfg.DLQMaxAge
+	if dlqMaxAge <= 0 {
+		dlqMaxAge = defaultDLQMaxAge
+	}
+
+	// Dead-lettered events are kept until an operator replays or discards them
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      dlqStreamName(streamName),
+		Subjects:  []string{SubjectDeadLetterPrefix + ".>"},
+		Storage:   jetstream.FileStorage,
+		Retention: jetstream.LimitsPolicy,
+		MaxAge:    dlqMaxAge,
+		Replicas:  1,
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("create dead-letter stream: %w", err)
+	}
+
 	logger.Info("NATS event bus connected",
 		zap.String("url", cfg.URL),
 		zap.String("stream", streamName),
@@ -160,21 +189,65 @@ func (b *Bus) Publish(ctx context.Context, subject string, event *Event) error {
 	return nil
 }
 
+// Broadcast sends an event over core NATS to every current subscriber of
+// subject, without JetStream persistence. It suits signals that each process
+// must see and that cost nothing when missed, such as cache invalidation.
+func (b *Bus) Broadcast(subject string, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+	if err := b.conn.Publish(subject, data); err != nil {
+		return fmt.Errorf("broadcast to %s: %w", subject, err)
+	}
+	return nil
+}
+
+// SubscribeOption customises a single subscription.
+type SubscribeOption func(*subscribeOptions)
+
+type subscribeOptions struct {
+	maxDeliver int
+}
+
+// WithMaxDeliver overrides Config.MaxDeliver for one consumer.
+func WithMaxDeliver(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.maxDeliver = n
+	}
+}
+
 // Subscribe creates a durable consumer and processes messages with the handler.
 // The consumerName should be unique per subscribing service (e.g., "notifications-rides").
-func (b *Bus) Subscribe(ctx context.Context, subject, consumerName string, handler HandlerFunc) error {
+//
+// Failed events are redelivered with backoff. Once an event has been delivered
+// MaxDeliver times it is moved to the consumer's dead-letter subject
+// (dlq.<consumerName>) and acked, so a poison message cannot block the consumer.
+// Events replayed from the DLQ arrive on replay.<consumerName> and are handed
+// to the same handler.
+func (b *Bus) Subscribe(ctx context.Context, subject, consumerName string, handler HandlerFunc, opts ...SubscribeOption) error {
 	streamName := b.cfg.StreamName
 	if streamName == "" {
 		streamName = "RIDEHAILING"
 	}
 
+	options := subscribeOptions{maxDeliver: b.cfg.MaxDeliver}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.maxDeliver <= 0 {
+		options.maxDeliver = defaultMaxDeliver
+	}
+
 	consumer, err := b.js.CreateOrUpdateConsumer(ctx, streamName, jetstream.ConsumerConfig{
-		Name:          consumerName,
-		Durable:       consumerName,
-		FilterSubject: subject,
-		AckPolicy:     jetstream.AckExplicitPolicy,
-		AckWait:       30 * time.Second,
-		MaxDeliver:    5,
+		Name:           consumerName,
+		Durable:        consumerName,
+		FilterSubjects: []string{subject, ReplaySubject(consumerName) + ".>"},
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        30 * time.Second,
+		// Unlimited on the server: the client dead-letters after maxDeliver so
+		// nothing is dropped if the DLQ publish itself fails.
+		MaxDeliver:    -1,
 		DeliverPolicy: jetstream.DeliverNewPolicy,
 	})
 	if err != nil {
@@ -182,24 +255,7 @@ func (b *Bus) Subscribe(ctx context.Context, subject, consumerName string, handl
 	}
 
 	cc, err := consumer.Consume(func(msg jetstream.Msg) {
-		var event Event
-		if err := json.Unmarshal(msg.Data(), &event); err != nil {
-			logger.Warn("failed to unmarshal event", zap.Error(err))
-			msg.Term() // don't redeliver malformed messages
-			return
-		}
-
-		if err := handler(ctx, &event); err != nil {
-			logger.Warn("event handler error, will retry",
-				zap.String("event_id", event.ID),
-				zap.String("type", event.Type),
-				zap.Error(err),
-			)
-			msg.Nak() // redeliver
-			return
-		}
-
-		msg.Ack()
+		b.handleMsg(ctx, consumerName, options.maxDeliver, handler, msg)
 	})
 	if err != nil {
 		return fmt.Errorf("consume %s: %w", consumerName, err)
@@ -209,13 +265,62 @@ func (b *Bus) Subscribe(ctx context.Context, subject, consumerName string, handl
 	logger.Info("subscribed to events",
 		zap.String("subject", subject),
 		zap.String("consumer", consumerName),
+		zap.Int("max_deliver", options.maxDeliver),
 	)
 	return nil
 }
 
+// handleMsg runs the handler for one delivery and acks, retries or dead-letters it.
+func (b *Bus) handleMsg(ctx context.Context, consumerName string, maxDeliver int, handler HandlerFunc, msg jetstream.Msg) {
+	subject := originalSubject(consumerName, msg.Subject())
+
+	var deliveries uint64 = 1
+	if meta, err := msg.Metadata(); err == nil {
+		deliveries = meta.NumDelivered
+	}
+
+	var event Event
+	if err := json.Unmarshal(msg.Data(), &event); err != nil {
+		logger.Warn("failed to unmarshal event, dead-lettering",
+			zap.String("consumer", consumerName),
+			zap.Error(err),
+		)
+		// Malformed messages will never succeed; skip retries
+		b.deadLetter(ctx, msg, consumerName, subject, nil, err, deliveries)
+		return
+	}
+
+	if err := handler(ctx, &event); err != nil {
+		if deliveries >= uint64(maxDeliver) {
+			logger.Error("event handler failed permanently, dead-lettering",
+				zap.String("consumer", consumerName),
+				zap.String("event_id", event.ID),
+				zap.String("type", event.Type),
+				zap.Uint64("deliveries", deliveries),
+				zap.Error(err),
+			)
+			b.deadLetter(ctx, msg, consumerName, subject, &event, err, deliveries)
+			return
+		}
+
+		logger.Warn("event handler error, will retry",
+			zap.String("event_id", event.ID),
+			zap.String("type", event.Type),
+			zap.Uint64("deliveries", deliveries),
+			zap.Error(err),
+		)
+		recordEventProcessed(consumerName, subject, resultRetry)
+		msg.NakWithDelay(retryDelay(deliveries))
+		return
+	}
+
+	recordEventProcessed(consumerName, subject, resultAck)
+	msg.Ack()
+}
+
 // SubscribeAll subscribes to a wildcard subject (e.g., "rides.>").
-func (b *Bus) SubscribeAll(ctx context.Context, subjectPattern, consumerName string, handler HandlerFunc) error {
-	return b.Subscribe(ctx, subjectPattern, consumerName, handler)
+func (b *Bus) SubscribeAll(ctx context.Context, subjectPattern, consumerName string, handler HandlerFunc, opts ...SubscribeOption) error {
+	return b.Subscribe(ctx, subjectPattern, consumerName, handler, opts...)
 }
 
 // Close drains subscriptions and closes the NATS connection.
@@ -140,9 +140,11 @@ func TestSubjectConstants(t *testing.T) {
 	}{
 		{"RideRequested", SubjectRideRequested, "rides.requested"},
 		{"RideAccepted", SubjectRideAccepted, "rides.accepted"},
+		{"RideDriverArrived", SubjectRideDriverArrived, "rides.driver_arrived"},
 		{"RideStarted", SubjectRideStarted, "rides.started"},
 		{"RideCompleted", SubjectRideCompleted, "rides.completed"},
 		{"RideCancelled", SubjectRideCancelled, "rides.cancelled"},
+		{"RideStopUpdated", SubjectRideStopUpdated, "rides.stop_updated"},
 		{"PaymentProcessed", SubjectPaymentProcessed, "payments.processed"},
 		{"PaymentFailed", SubjectPaymentFailed, "payments.failed"},
 		{"DriverLocationUpdated", SubjectDriverLocationUpdated, "drivers.location.updated"},
@@ -168,6 +170,8 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, "nats://127.0.0.1:4222", cfg.URL)
 	assert.Equal(t, "ride-hailing", cfg.Name)
 	assert.Equal(t, "RIDEHAILING", cfg.StreamName)
+	assert.Equal(t, 5, cfg.MaxDeliver)
+	assert.Equal(t, 14*24*time.Hour, cfg.DLQMaxAge)
 }
 
 // ---------------------------------------------------------------------------
@@ -25,6 +25,7 @@ type RideRequestedData struct {
 	EstimatedDistance float64   `json:"estimated_distance_km"`
 	EstimatedDuration int       `json:"estimated_duration_minutes"`
 	Currency          string    `json:"currency"`
+	PaymentMethod     string    `json:"payment_method,omitempty"` // card rides get a payment hold
 	RequestedAt       time.Time `json:"requested_at"`
 }
 
@@ -38,6 +39,44 @@ type RideAcceptedData struct {
 	DropoffLatitude   float64   `json:"dropoff_latitude"`
 	DropoffLongitude  float64   `json:"dropoff_longitude"`
 	AcceptedAt        time.Time `json:"accepted_at"`
+	Stops             []RideStopPoint `json:"stops,omitempty"` // intermediate stops in visiting order
+}
+
+// RideStopPoint is an intermediate stop carried on ride events.
+type RideStopPoint struct {
+	StopID    uuid.UUID `json:"stop_id"`
+	StopOrder int       `json:"stop_order"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+}
+
+// RideDriverArrivedData is emitted when the driver reaches the pickup point.
+// ArrivedAt is the authoritative start of the pickup wait.
+type RideDriverArrivedData struct {
+	RideID    uuid.UUID `json:"ride_id"`
+	RiderID   uuid.UUID `json:"rider_id"`
+	DriverID  uuid.UUID `json:"driver_id"`
+	ArrivedAt time.Time `json:"arrived_at"`
+}
+
+// RideStopUpdatedData is emitted when a stop is added to a ride or the
+// driver arrives at, leaves or skips it. EstimatedFare is the ride's estimate
+// after the change.
+type RideStopUpdatedData struct {
+	RideID        uuid.UUID  `json:"ride_id"`
+	RiderID       uuid.UUID  `json:"rider_id"`
+	DriverID      uuid.UUID  `json:"driver_id"` // uuid.Nil until a driver is assigned
+	StopID        uuid.UUID  `json:"stop_id"`
+	StopOrder     int        `json:"stop_order"`
+	Latitude      float64    `json:"latitude"`
+	Longitude     float64    `json:"longitude"`
+	Address       string     `json:"address"`
+	Status        string     `json:"status"`
+	WaitMinutes   int        `json:"wait_minutes"`
+	AddedMidTrip  bool       `json:"added_mid_trip"`
+	ArrivedAt     *time.Time `json:"arrived_at,omitempty"`
+	DepartedAt    *time.Time `json:"departed_at,omitempty"`
+	EstimatedFare float64    `json:"estimated_fare"`
 }
 
 // RideStartedData is emitted when a ride begins.
@@ -59,15 +98,45 @@ type RideCompletedData struct {
 	DistanceKm     float64   `json:"distance_km"`
 	DurationMin    float64   `json:"duration_min"`
 	CompletedAt    time.Time `json:"completed_at"`
+
+	// Reductions settled against FareAmount before the ride completed.
+	// AmountDue is what is left to charge the rider's payment method; it is
+	// nil on events staged before settlement moved into completion.
+	Discount      float64          `json:"discount"`
+	Credit        float64          `json:"credit"`
+	AmountDue     *float64         `json:"amount_due,omitempty"`
+	DiscountLines []FareAdjustment `json:"discount_lines,omitempty"`
 }
 
+// ChargeAmount returns what to charge the rider for the ride: the amount due
+// after discounts, or the whole fare on events that predate it.
+func (d RideCompletedData) ChargeAmount() float64 {
+	if d.AmountDue != nil {
+		return *d.AmountDue
+	}
+	return d.FareAmount
+}
+
+// FareAdjustment is one discount or credit settled against a ride's fare.
+type FareAdjustment struct {
+	Source string  `json:"source"` // promo, subscription, loyalty or gift_card
+	Type   string  `json:"type"`   // discount or credit
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// CancelReasonPaymentDeclined is the reason given when the system cancels a
+// ride because the rider's card declined the fare hold.
+const CancelReasonPaymentDeclined = "payment_declined"
+
 // RideCancelledData is emitted when a ride is cancelled.
 type RideCancelledData struct {
 	RideID      uuid.UUID `json:"ride_id"`
 	RiderID     uuid.UUID `json:"rider_id"`
 	DriverID    uuid.UUID `json:"driver_id"` // zero if not yet assigned
-	CancelledBy string    `json:"cancelled_by"` // "rider" or "driver"
+	CancelledBy string    `json:"cancelled_by"` // "rider", "driver" or "system"
 	Reason      string    `json:"reason"`
+	FeeWaived   bool      `json:"fee_waived,omitempty"` // the cancellation policy waived the rider's fee
 	CancelledAt time.Time `json:"cancelled_at"`
 }
 
@@ -104,6 +173,12 @@ type DriverLocationUpdatedData struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// RiderDriverListsChangedData is broadcast when a rider blocks, unblocks,
+// trusts or stops trusting a driver.
+type RiderDriverListsChangedData struct {
+	RiderID uuid.UUID `json:"rider_id"`
+}
+
 // FraudDetectedData is emitted when suspicious activity is detected.
 type FraudDetectedData struct {
 	UserID     uuid.UUID `json:"user_id"`
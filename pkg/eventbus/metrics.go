@@ -0,0 +1,39 @@
+package eventbus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Consumer processing results.
+const (
+	resultAck          = "ack"
+	resultRetry        = "retry"
+	resultDeadLettered = "dead_lettered"
+)
+
+var (
+	eventsProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_events_processed_total",
+		Help: "Total number of event deliveries handled by consumers, by result",
+	}, []string{"consumer", "subject", "result"})
+
+	deadLettersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_dead_letters_total",
+		Help: "Total number of events moved to a dead-letter queue",
+	}, []string{"consumer", "subject"})
+
+	deadLetterReplaysTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_dead_letter_replays_total",
+		Help: "Total number of dead-lettered events replayed to their consumer",
+	}, []string{"consumer", "subject"})
+
+	deadLetterDiscardsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "eventbus_dead_letter_discards_total",
+		Help: "Total number of dead-lettered events discarded by an operator",
+	}, []string{"consumer", "subject"})
+)
+
+func recordEventProcessed(consumer, subject, result string) {
+	eventsProcessedTotal.WithLabelValues(consumer, subject, result).Inc()
+}
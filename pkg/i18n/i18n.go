@@ -36,3 +36,10 @@ func Translate(key, lang string, args ...interface{}) string {
 	}
 	return fmt.Sprintf(tmpl, args...)
 }
+
+// HasTranslation reports whether key is translated into lang itself, i.e.
+// Translate would not fall back to English.
+func HasTranslation(key, lang string) bool {
+	_, ok := translations[key][lang]
+	return ok
+}
@@ -70,3 +70,9 @@ func TestFormatAmount_AED(t *testing.T) {
 func TestFormatAmount_UnknownCurrency(t *testing.T) {
 	assert.Equal(t, "10.00 XYZ", FormatAmount(10.0, "XYZ"))
 }
+
+func TestHasTranslation(t *testing.T) {
+	assert.True(t, HasTranslation("notification.ride.accepted.title", "ru"))
+	assert.False(t, HasTranslation("notification.ride.accepted.title", "zh"))
+	assert.False(t, HasTranslation("does.not.exist", "en"))
+}
@@ -86,6 +86,14 @@ var translations = map[string]map[string]string{
 		"tk": "Ýol tamamlandy. Siz %s gazandyňyz",
 	},
 
+	// ─── Ride Receipt (email subject) ────────────────────────────────────────
+	"notification.ride.receipt.subject": {
+		"en": "Your Ride Receipt",
+		"ru": "Чек за поездку",
+		"tr": "Yolculuk Makbuzunuz",
+		"tk": "Ýoluňyzyň Çeki",
+	},
+
 	// ─── Ride Cancelled ──────────────────────────────────────────────────────
 	"notification.ride.cancelled.title": {
 		"en": "Ride Cancelled",
@@ -112,6 +120,24 @@ var translations = map[string]map[string]string{
 		"tr": "sürücü",
 		"tk": "sürüji",
 	},
+	"notification.ride.cancelled.by.system": {
+		"en": "the system",
+		"ru": "системой",
+		"tr": "sistem",
+		"tk": "ulgam",
+	},
+	"notification.ride.payment_declined.title": {
+		"en": "Payment Declined",
+		"ru": "Платёж отклонён",
+		"tr": "Ödeme Reddedildi",
+		"tk": "Töleg Ret Edildi",
+	},
+	"notification.ride.payment_declined.body": {
+		"en": "Your card was declined, so your ride was cancelled. Add a new payment method and request again.",
+		"ru": "Ваша карта отклонена, поэтому поездка отменена. Добавьте другой способ оплаты и закажите снова.",
+		"tr": "Kartınız reddedildiği için yolculuğunuz iptal edildi. Yeni bir ödeme yöntemi ekleyip tekrar talep edin.",
+		"tk": "Kartyňyz ret edildi, şonuň üçin ýoluňyz ýatyryldy. Täze töleg usulyny goşup, täzeden sargyt ediň.",
+	},
 
 	// ─── Payment Received ────────────────────────────────────────────────────
 	"notification.payment.received.title": {
@@ -206,6 +206,7 @@ func TestRideStatus_Constants(t *testing.T) {
 	}{
 		{"requested", RideStatusRequested, "requested"},
 		{"accepted", RideStatusAccepted, "accepted"},
+		{"driver_arrived", RideStatusDriverArrived, "driver_arrived"},
 		{"in_progress", RideStatusInProgress, "in_progress"},
 		{"completed", RideStatusCompleted, "completed"},
 		{"cancelled", RideStatusCancelled, "cancelled"},
@@ -835,6 +836,36 @@ func TestPayment_ZeroValues(t *testing.T) {
 	}
 }
 
+// TestDriverOfferStats_Rates tests offer response rates and cool-down
+func TestDriverOfferStats_Rates(t *testing.T) {
+	stats := DriverOfferStats{OffersSent: 12, OffersAccepted: 6, OffersDeclined: 2, OffersExpired: 2}
+
+	if stats.Resolved() != 10 {
+		t.Errorf("Expected 10 resolved offers, got %d", stats.Resolved())
+	}
+	if stats.AcceptanceRate() != 0.6 {
+		t.Errorf("Expected acceptance rate 0.6, got %f", stats.AcceptanceRate())
+	}
+	if stats.IgnoredRate() != 0.2 {
+		t.Errorf("Expected ignored rate 0.2, got %f", stats.IgnoredRate())
+	}
+
+	empty := DriverOfferStats{OffersSent: 3}
+	if empty.AcceptanceRate() != 0 || empty.IgnoredRate() != 0 {
+		t.Error("Rates should be zero before any offer is resolved")
+	}
+
+	now := time.Now()
+	until := now.Add(5 * time.Minute)
+	stats.CooldownUntil = &until
+	if !stats.InCooldown(now) {
+		t.Error("Driver should be in cool-down before cooldown_until")
+	}
+	if stats.InCooldown(until.Add(time.Second)) {
+		t.Error("Driver should not be in cool-down after cooldown_until")
+	}
+}
+
 // ==================== Benchmark Tests ====================
 
 func BenchmarkUser_JSON_Marshal(b *testing.B) {
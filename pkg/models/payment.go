@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/richxcame/ride-hailing/pkg/money"
 )
 
 // PaymentStatus represents payment status
@@ -48,6 +49,72 @@ type Payment struct {
 	UpdatedAt       time.Time              `json:"updated_at" db:"updated_at"`
 }
 
+// AmountMoney returns the payment amount in minor units of its currency.
+func (p *Payment) AmountMoney() money.Money {
+	return money.FromMajor(p.Amount, p.Currency)
+}
+
+// PaymentHoldStatus represents the state of a card authorization hold
+type PaymentHoldStatus string
+
+const (
+	PaymentHoldAuthorized PaymentHoldStatus = "authorized"
+	PaymentHoldProcessing PaymentHoldStatus = "processing" // being captured or released
+	PaymentHoldCaptured   PaymentHoldStatus = "captured"
+	PaymentHoldReleased   PaymentHoldStatus = "released"
+	PaymentHoldFailed     PaymentHoldStatus = "failed" // the card declined the hold
+)
+
+// PaymentHold is an authorization placed on the rider's card when a ride is
+// requested and settled when the ride completes or is cancelled
+type PaymentHold struct {
+	ID                    uuid.UUID         `json:"id" db:"id"`
+	RideID                uuid.UUID         `json:"ride_id" db:"ride_id"`
+	RiderID               uuid.UUID         `json:"rider_id" db:"rider_id"`
+	StripePaymentIntentID *string           `json:"stripe_payment_intent_id,omitempty" db:"stripe_payment_intent_id"`
+	Currency              string            `json:"currency" db:"currency"`
+	EstimatedAmount       float64           `json:"estimated_amount" db:"estimated_amount"`
+	AuthorizedAmount      float64           `json:"authorized_amount" db:"authorized_amount"`
+	CapturedAmount        float64           `json:"captured_amount" db:"captured_amount"`
+	Status                PaymentHoldStatus `json:"status" db:"status"`
+	PaymentID             *uuid.UUID        `json:"payment_id,omitempty" db:"payment_id"`
+	FailureReason         *string           `json:"failure_reason,omitempty" db:"failure_reason"`
+	CapturedAt            *time.Time        `json:"captured_at,omitempty" db:"captured_at"`
+	ReleasedAt            *time.Time        `json:"released_at,omitempty" db:"released_at"`
+	CreatedAt             time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt             time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// AuthorizedMoney returns the held amount in minor units of its currency.
+func (h *PaymentHold) AuthorizedMoney() money.Money {
+	return money.FromMajor(h.AuthorizedAmount, h.Currency)
+}
+
+// WebhookEventStatus represents how far a received webhook event got
+type WebhookEventStatus string
+
+const (
+	WebhookEventProcessing WebhookEventStatus = "processing"
+	WebhookEventProcessed  WebhookEventStatus = "processed"
+	WebhookEventFailed     WebhookEventStatus = "failed" // retried when redelivered
+)
+
+// StripeWebhookEvent is a verified Stripe webhook event, stored raw under its
+// event ID so each event is applied once
+type StripeWebhookEvent struct {
+	ID          uuid.UUID          `json:"id" db:"id"`
+	EventID     string             `json:"event_id" db:"event_id"`
+	Type        string             `json:"event_type" db:"event_type"`
+	AccountID   *string            `json:"account_id,omitempty" db:"account_id"` // connected account, if any
+	Livemode    bool               `json:"livemode" db:"livemode"`
+	Payload     []byte             `json:"-" db:"payload"`
+	Status      WebhookEventStatus `json:"status" db:"status"`
+	Attempts    int                `json:"attempts" db:"attempts"`
+	LastError   *string            `json:"last_error,omitempty" db:"last_error"`
+	ReceivedAt  time.Time          `json:"received_at" db:"received_at"`
+	ProcessedAt *time.Time         `json:"processed_at,omitempty" db:"processed_at"`
+}
+
 // Wallet represents a user's wallet
 type Wallet struct {
 	ID        uuid.UUID `json:"id" db:"id"`
@@ -59,6 +126,11 @@ type Wallet struct {
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// BalanceMoney returns the wallet balance in minor units of its currency.
+func (w *Wallet) BalanceMoney() money.Money {
+	return money.FromMajor(w.Balance, w.Currency)
+}
+
 // WalletTransaction represents a wallet transaction
 type WalletTransaction struct {
 	ID            uuid.UUID  `json:"id" db:"id"`
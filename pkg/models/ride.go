@@ -10,11 +10,26 @@ import (
 type RideStatus string
 
 const (
-	RideStatusRequested  RideStatus = "requested"
-	RideStatusAccepted   RideStatus = "accepted"
-	RideStatusInProgress RideStatus = "in_progress"
-	RideStatusCompleted  RideStatus = "completed"
-	RideStatusCancelled  RideStatus = "cancelled"
+	RideStatusRequested     RideStatus = "requested"
+	RideStatusAccepted      RideStatus = "accepted"
+	RideStatusDriverArrived RideStatus = "driver_arrived"
+	RideStatusInProgress    RideStatus = "in_progress"
+	RideStatusCompleted     RideStatus = "completed"
+	RideStatusCancelled     RideStatus = "cancelled"
+)
+
+// MaxRideStops is the number of intermediate stops a ride may have between
+// pickup and dropoff.
+const MaxRideStops = 3
+
+// RideStopStatus represents the progress of an intermediate stop
+type RideStopStatus string
+
+const (
+	RideStopStatusPending   RideStopStatus = "pending"
+	RideStopStatusArrived   RideStopStatus = "arrived"
+	RideStopStatusCompleted RideStopStatus = "completed"
+	RideStopStatusSkipped   RideStopStatus = "skipped"
 )
 
 // Ride represents a ride in the system
@@ -38,6 +53,7 @@ type Ride struct {
 	SurgeMultiplier           float64    `json:"surge_multiplier" db:"surge_multiplier"`
 	RequestedAt               time.Time  `json:"requested_at" db:"requested_at"`
 	AcceptedAt                *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	ArrivedAt                 *time.Time `json:"arrived_at,omitempty" db:"arrived_at"`
 	StartedAt                 *time.Time `json:"started_at,omitempty" db:"started_at"`
 	CompletedAt               *time.Time `json:"completed_at,omitempty" db:"completed_at"`
 	CancelledAt               *time.Time `json:"cancelled_at,omitempty" db:"cancelled_at"`
@@ -59,8 +75,82 @@ type Ride struct {
 	PricingVersionID          *uuid.UUID `json:"pricing_version_id,omitempty" db:"pricing_version_id"`
 	WasNegotiated             bool       `json:"was_negotiated" db:"was_negotiated"`
 	NegotiationSessionID      *uuid.UUID `json:"negotiation_session_id,omitempty" db:"negotiation_session_id"`
+	PaymentMethod             *string    `json:"payment_method,omitempty" db:"payment_method"`
 	CreatedAt                 time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt                 time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Intermediate stops, loaded from ride_stops in visiting order
+	Stops []*RideStop `json:"stops,omitempty" db:"-"`
+
+	// Upfront quote the ride was requested with, loaded from ride_fare_quotes
+	FareQuote *RideFareQuote `json:"fare_quote,omitempty" db:"-"`
+
+	// Discounts and credits settled against the final fare, loaded from
+	// ride_discount_lines in the order they applied
+	DiscountLines []*RideDiscountLine `json:"discount_lines,omitempty" db:"-"`
+}
+
+// RideDiscountLine is one promo, subscription, loyalty or gift card reduction
+// on a completed ride's fare. Gift card balance is a credit (LineType
+// "credit"); the rest are discounts.
+type RideDiscountLine struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	RideID      uuid.UUID  `json:"ride_id" db:"ride_id"`
+	Position    int        `json:"position" db:"position"`
+	Source      string     `json:"source" db:"source"`
+	LineType    string     `json:"line_type" db:"line_type"`
+	Label       string     `json:"label" db:"label"`
+	Amount      float64    `json:"amount" db:"amount"`
+	ReferenceID *uuid.UUID `json:"reference_id,omitempty" db:"reference_id"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RideFareQuote is the fare quote a ride was requested with. While Locked the
+// rider pays QuotedFare; the quote is released, and the ride re-priced, when
+// the route deviates too far from the quote or stops are added.
+type RideFareQuote struct {
+	RideID           uuid.UUID  `json:"ride_id" db:"ride_id"`
+	QuoteID          uuid.UUID  `json:"quote_id" db:"quote_id"`
+	QuotedFare       float64    `json:"quoted_fare" db:"quoted_fare"`
+	CurrencyCode     string     `json:"currency_code" db:"currency_code"`
+	SurgeMultiplier  float64    `json:"surge_multiplier" db:"surge_multiplier"`
+	DistanceKm       float64    `json:"distance_km" db:"distance_km"`
+	DurationMinutes  int        `json:"duration_minutes" db:"duration_minutes"`
+	PricingVersionID *uuid.UUID `json:"pricing_version_id,omitempty" db:"pricing_version_id"`
+	IssuedAt         time.Time  `json:"issued_at" db:"issued_at"`
+	ExpiresAt        time.Time  `json:"expires_at" db:"expires_at"`
+	Locked           bool       `json:"locked" db:"locked"`
+	ReleaseReason    *string    `json:"release_reason,omitempty" db:"release_reason"`
+	ReleasedAt       *time.Time `json:"released_at,omitempty" db:"released_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// RideStop represents an intermediate stop between pickup and dropoff
+type RideStop struct {
+	ID           uuid.UUID      `json:"id" db:"id"`
+	RideID       uuid.UUID      `json:"ride_id" db:"ride_id"`
+	StopOrder    int            `json:"stop_order" db:"stop_order"`
+	Latitude     float64        `json:"latitude" db:"latitude"`
+	Longitude    float64        `json:"longitude" db:"longitude"`
+	Address      string         `json:"address" db:"address"`
+	Status       RideStopStatus `json:"status" db:"status"`
+	WaitMinutes  int            `json:"wait_minutes" db:"wait_minutes"` // billed wait at the stop
+	AddedMidTrip bool           `json:"added_mid_trip" db:"added_mid_trip"`
+	ArrivedAt    *time.Time     `json:"arrived_at,omitempty" db:"arrived_at"`
+	DepartedAt   *time.Time     `json:"departed_at,omitempty" db:"departed_at"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
+
+// RideStopInput defines an intermediate stop in a ride request
+type RideStopInput struct {
+	Latitude  float64 `json:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" binding:"required"`
+	Address   string  `json:"address" binding:"required"`
+}
+
+// RideStopStatusRequest represents a driver's update to a stop
+type RideStopStatusRequest struct {
+	Status RideStopStatus `json:"status" binding:"required,oneof=arrived completed skipped"`
 }
 
 // RideRequest represents a ride request from a rider
@@ -71,10 +161,18 @@ type RideRequest struct {
 	DropoffLatitude  float64    `json:"dropoff_latitude" binding:"required"`
 	DropoffLongitude float64    `json:"dropoff_longitude" binding:"required"`
 	DropoffAddress   string     `json:"dropoff_address" binding:"required"`
-	RideTypeID       *uuid.UUID `json:"ride_type_id,omitempty"` // Optional: defaults to Economy
-	PromoCode        string     `json:"promo_code,omitempty"`   // Optional promo code
-	ScheduledAt      *time.Time `json:"scheduled_at,omitempty"` // Optional: for scheduled rides
-	IsScheduled      bool       `json:"is_scheduled,omitempty"` // Is this a scheduled ride?
+	RideTypeID       *uuid.UUID `json:"ride_type_id,omitempty"`   // Optional: defaults to Economy
+	PromoCode        string     `json:"promo_code,omitempty"`     // Optional promo code
+	PaymentMethod    string     `json:"payment_method,omitempty"` // Optional: checked against promo code payment rules
+	ScheduledAt      *time.Time `json:"scheduled_at,omitempty"`   // Optional: for scheduled rides
+	IsScheduled      bool       `json:"is_scheduled,omitempty"`   // Is this a scheduled ride?
+
+	// Multi-stop
+	Stops         []RideStopInput `json:"stops,omitempty" binding:"omitempty,max=3,dive"` // Optional: intermediate stops in visiting order
+	OptimizeStops bool            `json:"optimize_stops,omitempty"`                       // Reorder stops for the shortest route
+
+	// Upfront pricing
+	QuoteToken string `json:"quote_token,omitempty"` // Optional: quote from an estimate, locks the fare
 }
 
 // RideResponse represents a ride response with additional details
@@ -86,7 +184,7 @@ type RideResponse struct {
 
 // RideUpdateRequest represents a request to update ride status
 type RideUpdateRequest struct {
-	Status             RideStatus `json:"status" binding:"required,oneof=accepted in_progress completed cancelled"`
+	Status             RideStatus `json:"status" binding:"required,oneof=accepted driver_arrived in_progress completed cancelled"`
 	CancellationReason *string    `json:"cancellation_reason,omitempty"`
 }
 
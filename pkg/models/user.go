@@ -57,6 +57,56 @@ type Driver struct {
 	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// OfferOutcome is how a driver responded to a ride offer
+type OfferOutcome string
+
+const (
+	OfferOutcomeAccepted OfferOutcome = "accepted"
+	OfferOutcomeDeclined OfferOutcome = "declined"
+	OfferOutcomeExpired  OfferOutcome = "expired"
+)
+
+// DriverOfferStats counts how a driver has responded to ride offers. Offers
+// withdrawn because another driver took the ride or the rider cancelled are
+// sent but never resolved.
+type DriverOfferStats struct {
+	DriverID           uuid.UUID  `json:"driver_id" db:"driver_id"`
+	OffersSent         int        `json:"offers_sent" db:"offers_sent"`
+	OffersAccepted     int        `json:"offers_accepted" db:"offers_accepted"`
+	OffersDeclined     int        `json:"offers_declined" db:"offers_declined"`
+	OffersExpired      int        `json:"offers_expired" db:"offers_expired"`
+	ConsecutiveIgnored int        `json:"consecutive_ignored" db:"consecutive_ignored"` // Expired offers since the last response
+	CooldownUntil      *time.Time `json:"cooldown_until,omitempty" db:"cooldown_until"`
+	LastOfferAt        *time.Time `json:"last_offer_at,omitempty" db:"last_offer_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Resolved returns the number of offers the driver accepted, declined or let expire.
+func (s *DriverOfferStats) Resolved() int {
+	return s.OffersAccepted + s.OffersDeclined + s.OffersExpired
+}
+
+// AcceptanceRate returns the share of resolved offers the driver accepted.
+func (s *DriverOfferStats) AcceptanceRate() float64 {
+	if s.Resolved() == 0 {
+		return 0
+	}
+	return float64(s.OffersAccepted) / float64(s.Resolved())
+}
+
+// IgnoredRate returns the share of resolved offers that expired without a response.
+func (s *DriverOfferStats) IgnoredRate() float64 {
+	if s.Resolved() == 0 {
+		return 0
+	}
+	return float64(s.OffersExpired) / float64(s.Resolved())
+}
+
+// InCooldown reports whether the driver is paused from receiving offers at t.
+func (s *DriverOfferStats) InCooldown(t time.Time) bool {
+	return s.CooldownUntil != nil && s.CooldownUntil.After(t)
+}
+
 // RegisterRequest represents registration request
 type RegisterRequest struct {
 	Email       string   `json:"email" binding:"required,email"`
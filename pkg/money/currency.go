@@ -0,0 +1,57 @@
+package money
+
+import (
+	"strings"
+	"sync"
+)
+
+// DefaultExponent is the number of minor-unit digits assumed for currencies
+// that are neither in the ISO 4217 table below nor registered at runtime.
+const DefaultExponent = 2
+
+var (
+	exponentsMu sync.RWMutex
+
+	// exponents lists ISO 4217 currencies whose minor unit is not 1/100.
+	// Everything else uses DefaultExponent. internal/currency registers the
+	// decimal_places from the currencies table on top of this.
+	exponents = map[string]int{
+		// Zero-decimal currencies
+		"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0,
+		"KMF": 0, "KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0,
+		"VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+		// Three-decimal currencies
+		"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	}
+)
+
+// Exponent returns the number of minor-unit digits for a currency code.
+func Exponent(currency string) int {
+	exponentsMu.RLock()
+	defer exponentsMu.RUnlock()
+	if exp, ok := exponents[normalizeCode(currency)]; ok {
+		return exp
+	}
+	return DefaultExponent
+}
+
+// RegisterCurrency sets the minor-unit exponent for a currency, overriding the
+// built-in table. Exponents outside 0..4 are ignored.
+func RegisterCurrency(code string, exponent int) {
+	if exponent < 0 || exponent > 4 {
+		return
+	}
+	exponentsMu.Lock()
+	defer exponentsMu.Unlock()
+	exponents[normalizeCode(code)] = exponent
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+var pow10 = [...]int64{1, 10, 100, 1000, 10000}
+
+func scale(currency string) int64 {
+	return pow10[Exponent(currency)]
+}
@@ -0,0 +1,274 @@
+// Package money represents monetary amounts as integer minor units (cents for
+// USD, yen for JPY, fils for KWD) tagged with an ISO 4217 currency code.
+//
+// Rounding rules:
+//   - Decimal and float inputs (API requests, legacy DECIMAL columns) are
+//     converted with RoundHalfUp, which matches Postgres ROUND(numeric).
+//   - Proportional amounts such as commission or fees use MulRate with
+//     RoundHalfEven; the counterpart is derived by subtraction, never rounded
+//     independently, so the parts always sum to the original amount.
+//   - Splitting an amount between several parties uses Allocate, which hands
+//     leftover minor units to the earliest shares.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+var ErrCurrencyMismatch = errors.New("money: currency mismatch")
+
+// ErrInvalidAllocation is returned when Allocate or Split get unusable ratios.
+var ErrInvalidAllocation = errors.New("money: invalid allocation")
+
+// RoundingMode selects how fractional minor units are resolved.
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // half away from zero
+	RoundHalfEven                     // half to even (banker's rounding)
+	RoundDown                         // toward zero
+	RoundUp                           // away from zero
+)
+
+// Money is an amount in the minor unit of its currency.
+type Money struct {
+	Amount   int64  `json:"amount"`   // minor units
+	Currency string `json:"currency"` // ISO 4217 code
+}
+
+// New creates a Money from minor units.
+func New(minor int64, currency string) Money {
+	return Money{Amount: minor, Currency: normalizeCode(currency)}
+}
+
+// Zero returns a zero amount in the given currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// FromMajor converts a major-unit amount (e.g. 12.34 dollars) using RoundHalfUp.
+func FromMajor(major float64, currency string) Money {
+	return FromMajorRounded(major, currency, RoundHalfUp)
+}
+
+// FromMajorRounded converts a major-unit amount with an explicit rounding mode.
+// The float is read as its shortest decimal representation, so 19.99 becomes
+// 1999 cents rather than 1998 as a naive int64(19.99*100) would give.
+func FromMajorRounded(major float64, currency string, mode RoundingMode) Money {
+	if math.IsNaN(major) || math.IsInf(major, 0) {
+		return Zero(currency)
+	}
+	r := decimalRat(major)
+	r.Mul(r, new(big.Rat).SetInt64(scale(currency)))
+	return New(roundRat(r, mode), currency)
+}
+
+// Major returns the amount in major units as a float64, for legacy fields and
+// display. Do not do arithmetic on the result.
+func (m Money) Major() float64 {
+	f, _ := strconv.ParseFloat(m.Decimal(), 64)
+	return f
+}
+
+// Decimal formats the amount in major units with the currency's exponent,
+// e.g. "12.34" for USD or "1200" for JPY.
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	abs := m.Amount
+	sign := ""
+	if abs < 0 {
+		sign = "-"
+		abs = -abs
+	}
+	if exp == 0 {
+		return sign + strconv.FormatInt(abs, 10)
+	}
+	s := pow10[exp]
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/s, exp, abs%s)
+}
+
+// String returns the amount with its currency code, e.g. "12.34 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool { return m.Amount == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// IsPositive reports whether the amount is above zero.
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+
+// SameCurrency reports whether both amounts use the same currency.
+func (m Money) SameCurrency(o Money) bool {
+	return normalizeCode(m.Currency) == normalizeCode(o.Currency)
+}
+
+// Add returns m + o.
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return New(m.Amount+o.Amount, m.Currency), nil
+}
+
+// Sub returns m - o.
+func (m Money) Sub(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return New(m.Amount-o.Amount, m.Currency), nil
+}
+
+// Neg returns -m.
+func (m Money) Neg() Money {
+	return New(-m.Amount, m.Currency)
+}
+
+// Cmp compares m and o, returning -1, 0 or +1.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.SameCurrency(o) {
+		return 0, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// MulRate multiplies the amount by a rate (e.g. a 0.20 commission rate) and
+// rounds to a whole minor unit. The rate is taken as its shortest decimal
+// representation so 0.1 is exactly one tenth.
+func (m Money) MulRate(rate float64, mode RoundingMode) Money {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return Zero(m.Currency)
+	}
+	r := decimalRat(rate)
+	r.Mul(r, new(big.Rat).SetInt64(m.Amount))
+	return New(roundRat(r, mode), m.Currency)
+}
+
+// SplitRate splits the amount into a rate-based share and the remainder, e.g.
+// platform commission and driver earnings. The share is rounded half-even and
+// the remainder takes whatever is left, so share + rest == m.
+func (m Money) SplitRate(rate float64) (share, rest Money) {
+	share = m.MulRate(rate, RoundHalfEven)
+	return share, New(m.Amount-share.Amount, m.Currency)
+}
+
+// Allocate splits the amount in proportion to the given weights. Shares are
+// rounded down and the leftover minor units go one each to the earliest
+// shares, so the result always sums to m.
+func (m Money) Allocate(weights ...int64) ([]Money, error) {
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("%w: no weights", ErrInvalidAllocation)
+	}
+	var total int64
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("%w: negative weight", ErrInvalidAllocation)
+		}
+		total += w
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%w: weights sum to zero", ErrInvalidAllocation)
+	}
+
+	sign := int64(1)
+	amount := m.Amount
+	if amount < 0 {
+		sign, amount = -1, -amount
+	}
+
+	shares := make([]Money, len(weights))
+	var allocated int64
+	for i, w := range weights {
+		r := new(big.Rat).SetFrac(big.NewInt(amount), big.NewInt(total))
+		r.Mul(r, new(big.Rat).SetInt64(w))
+		part := roundRat(r, RoundDown)
+		shares[i] = New(sign*part, m.Currency)
+		allocated += part
+	}
+	for i := 0; allocated < amount; i = (i + 1) % len(shares) {
+		if weights[i] == 0 {
+			continue
+		}
+		shares[i].Amount += sign
+		allocated++
+	}
+	return shares, nil
+}
+
+// Split divides the amount into n near-equal shares.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: split into %d", ErrInvalidAllocation, n)
+	}
+	weights := make([]int64, n)
+	for i := range weights {
+		weights[i] = 1
+	}
+	return m.Allocate(weights...)
+}
+
+// Convert converts the amount into another currency at the given rate (units
+// of the target currency per unit of m's currency), taking both currencies'
+// exponents into account.
+func (m Money) Convert(to string, rate float64, mode RoundingMode) Money {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return Zero(to)
+	}
+	r := new(big.Rat).SetFrac(big.NewInt(m.Amount), big.NewInt(scale(m.Currency)))
+	r.Mul(r, decimalRat(rate))
+	r.Mul(r, new(big.Rat).SetInt64(scale(to)))
+	return New(roundRat(r, mode), to)
+}
+
+// decimalRat returns the exact value of f's shortest decimal representation.
+func decimalRat(f float64) *big.Rat {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'f', -1, 64))
+	if !ok {
+		return new(big.Rat).SetFloat64(f)
+	}
+	return r
+}
+
+// roundRat rounds r to an integer using mode.
+func roundRat(r *big.Rat, mode RoundingMode) int64 {
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return q.Int64()
+	}
+
+	step := big.NewInt(int64(r.Sign()))
+	half := new(big.Int).Abs(rem)
+	half.Lsh(half, 1)
+	cmp := half.Cmp(den)
+
+	roundAway := false
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		roundAway = true
+	case RoundHalfEven:
+		roundAway = cmp > 0 || (cmp == 0 && q.Bit(0) == 1)
+	default: // RoundHalfUp
+		roundAway = cmp >= 0
+	}
+	if roundAway {
+		q.Add(q, step)
+	}
+	return q.Int64()
+}
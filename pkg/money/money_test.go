@@ -0,0 +1,181 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExponent(t *testing.T) {
+	assert.Equal(t, 2, Exponent("USD"))
+	assert.Equal(t, 2, Exponent("usd"))
+	assert.Equal(t, 0, Exponent("JPY"))
+	assert.Equal(t, 3, Exponent("KWD"))
+	assert.Equal(t, DefaultExponent, Exponent("XYZ"))
+}
+
+func TestRegisterCurrency(t *testing.T) {
+	RegisterCurrency("zzt", 0)
+	t.Cleanup(func() {
+		exponentsMu.Lock()
+		delete(exponents, "ZZT")
+		exponentsMu.Unlock()
+	})
+
+	assert.Equal(t, 0, Exponent("ZZT"))
+	assert.Equal(t, int64(12), FromMajor(12.4, "ZZT").Amount)
+
+	// Out-of-range exponents are ignored
+	RegisterCurrency("ZZT", 9)
+	assert.Equal(t, 0, Exponent("ZZT"))
+}
+
+func TestFromMajor(t *testing.T) {
+	tests := []struct {
+		name     string
+		major    float64
+		currency string
+		want     int64
+	}{
+		{"whole dollars", 12, "USD", 1200},
+		{"cents survive float representation", 19.99, "USD", 1999},
+		{"classic 0.1 + 0.2", 0.1 + 0.2, "USD", 30},
+		{"half rounds away from zero", 1.005, "USD", 101},
+		{"negative half rounds away from zero", -1.005, "USD", -101},
+		{"zero-decimal currency", 1500.5, "JPY", 1501},
+		{"three-decimal currency", 1.2345, "KWD", 1235},
+		{"lower-case code", 2.5, "eur", 250},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := FromMajor(tt.major, tt.currency)
+			assert.Equal(t, tt.want, m.Amount)
+		})
+	}
+}
+
+func TestFromMajorRounded(t *testing.T) {
+	assert.Equal(t, int64(1002), FromMajorRounded(10.025, "USD", RoundHalfEven).Amount)
+	assert.Equal(t, int64(1004), FromMajorRounded(10.035, "USD", RoundHalfEven).Amount)
+	assert.Equal(t, int64(1002), FromMajorRounded(10.029, "USD", RoundDown).Amount)
+	assert.Equal(t, int64(1003), FromMajorRounded(10.021, "USD", RoundUp).Amount)
+	assert.Equal(t, int64(-1002), FromMajorRounded(-10.029, "USD", RoundDown).Amount)
+	assert.Equal(t, int64(-1003), FromMajorRounded(-10.021, "USD", RoundUp).Amount)
+}
+
+func TestDecimalAndMajor(t *testing.T) {
+	assert.Equal(t, "12.34", New(1234, "USD").Decimal())
+	assert.Equal(t, "-0.05", New(-5, "USD").Decimal())
+	assert.Equal(t, "1200", New(1200, "JPY").Decimal())
+	assert.Equal(t, "1.005", New(1005, "KWD").Decimal())
+	assert.Equal(t, "12.34 USD", New(1234, "usd").String())
+	assert.Equal(t, 19.99, New(1999, "USD").Major())
+}
+
+func TestArithmetic(t *testing.T) {
+	a := New(1050, "USD")
+	b := New(275, "USD")
+
+	sum, err := a.Add(b)
+	require.NoError(t, err)
+	assert.Equal(t, New(1325, "USD"), sum)
+
+	diff, err := b.Sub(a)
+	require.NoError(t, err)
+	assert.Equal(t, int64(-775), diff.Amount)
+	assert.True(t, diff.IsNegative())
+	assert.Equal(t, int64(775), diff.Neg().Amount)
+
+	cmp, err := a.Cmp(b)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cmp)
+
+	_, err = a.Add(New(100, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = a.Cmp(New(100, "EUR"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMulRate(t *testing.T) {
+	// 0.1 is treated as exactly one tenth, so 5 * 0.1 is a true half
+	assert.Equal(t, int64(0), New(5, "USD").MulRate(0.1, RoundHalfEven).Amount)
+	assert.Equal(t, int64(1), New(5, "USD").MulRate(0.1, RoundHalfUp).Amount)
+	assert.Equal(t, int64(400), New(1999, "USD").MulRate(0.2, RoundHalfEven).Amount)
+}
+
+func TestSplitRate(t *testing.T) {
+	fares := []int64{1999, 1, 333, 1005, 123457}
+	for _, fare := range fares {
+		share, rest := New(fare, "USD").SplitRate(0.2)
+		assert.Equal(t, fare, share.Amount+rest.Amount, "fare %d", fare)
+	}
+
+	commission, net := New(1999, "USD").SplitRate(0.2)
+	assert.Equal(t, int64(400), commission.Amount)
+	assert.Equal(t, int64(1599), net.Amount)
+}
+
+func TestAllocate(t *testing.T) {
+	shares, err := New(100, "USD").Allocate(1, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{34, 33, 33}, amounts(shares))
+
+	shares, err = New(-100, "USD").Allocate(1, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-34, -33, -33}, amounts(shares))
+
+	shares, err = New(1000, "USD").Allocate(70, 30, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{700, 300, 0}, amounts(shares))
+
+	shares, err = New(1, "USD").Allocate(0, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{0, 1, 0}, amounts(shares))
+
+	_, err = New(100, "USD").Allocate()
+	assert.ErrorIs(t, err, ErrInvalidAllocation)
+	_, err = New(100, "USD").Allocate(0, 0)
+	assert.ErrorIs(t, err, ErrInvalidAllocation)
+	_, err = New(100, "USD").Allocate(1, -1)
+	assert.ErrorIs(t, err, ErrInvalidAllocation)
+}
+
+func TestSplit(t *testing.T) {
+	shares, err := New(1000, "USD").Split(3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{334, 333, 333}, amounts(shares))
+
+	_, err = New(1000, "USD").Split(0)
+	assert.ErrorIs(t, err, ErrInvalidAllocation)
+}
+
+func TestConvert(t *testing.T) {
+	// 10.00 USD at 150.25 JPY/USD = 1502.5 JPY
+	assert.Equal(t, New(1503, "JPY"), New(1000, "USD").Convert("JPY", 150.25, RoundHalfUp))
+	assert.Equal(t, New(1502, "JPY"), New(1000, "USD").Convert("JPY", 150.25, RoundHalfEven))
+	// 1500 JPY at 0.0067 USD/JPY = 10.05 USD
+	assert.Equal(t, New(1005, "USD"), New(1500, "JPY").Convert("USD", 0.0067, RoundHalfUp))
+	// 10.00 USD at 0.307 KWD/USD = 3.070 KWD
+	assert.Equal(t, New(3070, "KWD"), New(1000, "USD").Convert("KWD", 0.307, RoundHalfUp))
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(New(1999, "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1999,"currency":"USD"}`, string(data))
+
+	var m Money
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, New(1999, "USD"), m)
+}
+
+func amounts(ms []Money) []int64 {
+	out := make([]int64, len(ms))
+	for i, m := range ms {
+		out[i] = m.Amount
+	}
+	return out
+}
@@ -79,7 +79,7 @@ func validatePastTime(fl validator.FieldLevel) bool {
 // validateRideStatus checks if ride status is valid
 func validateRideStatus(fl validator.FieldLevel) bool {
 	status := fl.Field().String()
-	validStatuses := []string{"requested", "accepted", "in_progress", "completed", "cancelled"}
+	validStatuses := []string{"requested", "accepted", "driver_arrived", "in_progress", "completed", "cancelled"}
 	return contains(validStatuses, status)
 }
 
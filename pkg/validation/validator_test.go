@@ -531,6 +531,7 @@ func TestValidateStruct_UpdateRideStatusRequest_Valid(t *testing.T) {
 	}{
 		{"requested", "requested"},
 		{"accepted", "accepted"},
+		{"driver_arrived", "driver_arrived"},
 		{"in_progress", "in_progress"},
 		{"completed", "completed"},
 		{"cancelled", "cancelled"},
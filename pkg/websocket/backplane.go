@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// Time allowed to publish a broadcast or update presence
+	backplaneTimeout = 2 * time.Second
+
+	// Presence entries are refreshed this often and expire after presenceTTL,
+	// so users held by a node that died drop out on their own
+	presenceRefreshInterval = 30 * time.Second
+	presenceTTL             = 3 * presenceRefreshInterval
+)
+
+// Envelope is a broadcast passed between hubs on different nodes
+type Envelope struct {
+	Origin string `json:"origin"`         // Node that sent it
+	Node   string `json:"node,omitempty"` // Only this node delivers it, when set
+	BroadcastMessage
+}
+
+// Backplane carries broadcasts between the hubs of every realtime node, so a
+// message sent on one node reaches sockets held by the others
+type Backplane interface {
+	// Publish sends an envelope to every subscribed node, or only to
+	// env.Node when it is set
+	Publish(ctx context.Context, env *Envelope) error
+
+	// Subscribe delivers envelopes meant for nodeID to handler until ctx is
+	// cancelled
+	Subscribe(ctx context.Context, nodeID string, handler func(*Envelope)) error
+}
+
+// Presence records which node holds each connected user
+type Presence interface {
+	// SetOnline records that nodeID holds the user's connection
+	SetOnline(ctx context.Context, userID, nodeID string) error
+
+	// SetOffline forgets the user, unless another node has taken the
+	// connection over since
+	SetOffline(ctx context.Context, userID, nodeID string) error
+
+	// Refresh keeps the users held by nodeID from expiring
+	Refresh(ctx context.Context, nodeID string, userIDs []string) error
+
+	// Locate returns the node holding the user, or "" when the user is not
+	// connected anywhere
+	Locate(ctx context.Context, userID string) (string, error)
+}
+
+// ========================================
+// IN-MEMORY IMPLEMENTATIONS
+// ========================================
+
+// MemoryBackplane connects hubs in the same process, standing in for Redis
+// in tests and single-node setups
+type MemoryBackplane struct {
+	mu   sync.RWMutex
+	seq  int
+	subs map[int]memorySubscription
+}
+
+type memorySubscription struct {
+	nodeID  string
+	handler func(*Envelope)
+}
+
+// NewMemoryBackplane creates an in-process backplane
+func NewMemoryBackplane() *MemoryBackplane {
+	return &MemoryBackplane{subs: make(map[int]memorySubscription)}
+}
+
+// Publish delivers the envelope to subscribers before returning. It goes
+// through JSON like it would over Redis, so messages that can't cross nodes
+// fail here too.
+func (b *MemoryBackplane) Publish(ctx context.Context, env *Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	b.mu.RLock()
+	subs := make([]memorySubscription, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if env.Node == "" || env.Node == sub.nodeID {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range subs {
+		var received Envelope
+		if err := json.Unmarshal(data, &received); err != nil {
+			return err
+		}
+		sub.handler(&received)
+	}
+	return nil
+}
+
+// Subscribe registers handler until ctx is cancelled
+func (b *MemoryBackplane) Subscribe(ctx context.Context, nodeID string, handler func(*Envelope)) error {
+	b.mu.Lock()
+	b.seq++
+	id := b.seq
+	b.subs[id] = memorySubscription{nodeID: nodeID, handler: handler}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs, id)
+		b.mu.Unlock()
+	}()
+	return nil
+}
+
+// MemoryPresence tracks presence in process memory. Entries don't expire.
+type MemoryPresence struct {
+	mu    sync.RWMutex
+	nodes map[string]string // user ID -> node ID
+}
+
+// NewMemoryPresence creates an empty in-memory presence tracker
+func NewMemoryPresence() *MemoryPresence {
+	return &MemoryPresence{nodes: make(map[string]string)}
+}
+
+func (p *MemoryPresence) SetOnline(ctx context.Context, userID, nodeID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nodes[userID] = nodeID
+	return nil
+}
+
+func (p *MemoryPresence) SetOffline(ctx context.Context, userID, nodeID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.nodes[userID] == nodeID {
+		delete(p.nodes, userID)
+	}
+	return nil
+}
+
+func (p *MemoryPresence) Refresh(ctx context.Context, nodeID string, userIDs []string) error {
+	return nil
+}
+
+func (p *MemoryPresence) Locate(ctx context.Context, userID string) (string, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.nodes[userID], nil
+}
@@ -0,0 +1,221 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newNode starts a hub connected to the shared backplane and presence
+func newNode(t *testing.T, backplane Backplane, presence Presence) *Hub {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	hub := NewHub()
+	require.NoError(t, hub.SetBackplane(ctx, backplane))
+	if presence != nil {
+		hub.SetPresence(presence)
+	}
+	go hub.Run()
+	return hub
+}
+
+// connect registers a client with the hub
+func connect(t *testing.T, hub *Hub, id, role string) *Client {
+	t.Helper()
+	client := NewClient(id, createTestWebSocketConn(t), hub, role, zap.NewNop())
+	hub.Register <- client
+	time.Sleep(10 * time.Millisecond)
+	return client
+}
+
+// receive waits for the next message sent to the client
+func receive(t *testing.T, client *Client) *Message {
+	t.Helper()
+	select {
+	case msg, ok := <-client.Send:
+		require.True(t, ok, "client channel closed")
+		return msg
+	case <-time.After(time.Second):
+		t.Fatalf("no message for %s", client.ID)
+		return nil
+	}
+}
+
+// assertNoMessage checks the client got nothing
+func assertNoMessage(t *testing.T, client *Client) {
+	t.Helper()
+	select {
+	case msg := <-client.Send:
+		t.Fatalf("unexpected message for %s: %s", client.ID, msg.Type)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestBackplane_SendToUserOnOtherNode(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	presence := NewMemoryPresence()
+	nodeA := newNode(t, backplane, presence)
+	nodeB := newNode(t, backplane, presence)
+	nodeC := newNode(t, backplane, presence)
+
+	rider := connect(t, nodeB, "rider-1", "rider")
+	bystander := connect(t, nodeC, "rider-2", "rider")
+
+	nodeA.SendToUser("rider-1", &Message{Type: "ride.accepted", Data: map[string]interface{}{"eta": 4.0}})
+
+	msg := receive(t, rider)
+	assert.Equal(t, "ride.accepted", msg.Type)
+	assert.Equal(t, 4.0, msg.Data["eta"])
+	assertNoMessage(t, bystander)
+}
+
+func TestBackplane_SendToUserWithoutPresence(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	nodeA := newNode(t, backplane, nil)
+	nodeB := newNode(t, backplane, nil)
+	newNode(t, backplane, nil)
+
+	driver := connect(t, nodeB, "driver-1", "driver")
+
+	// Every node is asked, only the one holding the user delivers
+	nodeA.SendToUser("driver-1", &Message{Type: "ride.offer"})
+
+	assert.Equal(t, "ride.offer", receive(t, driver).Type)
+	assertNoMessage(t, driver)
+}
+
+func TestBackplane_SendToUserOffline(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	presence := NewMemoryPresence()
+	nodeA := newNode(t, backplane, presence)
+	nodeB := newNode(t, backplane, presence)
+
+	other := connect(t, nodeB, "rider-2", "rider")
+
+	nodeA.SendToUser("rider-1", &Message{Type: "ride.accepted"})
+
+	assertNoMessage(t, other)
+}
+
+func TestBackplane_SendToRideAcrossNodes(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	nodeA := newNode(t, backplane, NewMemoryPresence())
+	nodeB := newNode(t, backplane, NewMemoryPresence())
+
+	driver := connect(t, nodeA, "driver-1", "driver")
+	rider := connect(t, nodeB, "rider-1", "rider")
+	nodeA.AddClientToRide(driver.ID, "ride-1")
+	nodeB.AddClientToRide(rider.ID, "ride-1")
+
+	nodeA.SendToRide("ride-1", &Message{Type: "ride_update"})
+	assert.Equal(t, "ride_update", receive(t, driver).Type)
+	assert.Equal(t, "ride_update", receive(t, rider).Type)
+
+	// Chat from the driver reaches the rider but not the driver
+	nodeA.SendToRideExcept("ride-1", driver.ID, &Message{Type: "chat_message"})
+	assert.Equal(t, "chat_message", receive(t, rider).Type)
+	assertNoMessage(t, driver)
+}
+
+func TestBackplane_SendToMultipleUsers(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	nodeA := newNode(t, backplane, nil)
+	nodeB := newNode(t, backplane, nil)
+
+	local := connect(t, nodeA, "driver-1", "driver")
+	remote := connect(t, nodeB, "driver-2", "driver")
+	unlisted := connect(t, nodeB, "driver-3", "driver")
+
+	nodeA.SendToMultipleUsers([]string{"driver-1", "driver-2", "driver-9"}, &Message{Type: "negotiation.new"})
+
+	assert.Equal(t, "negotiation.new", receive(t, local).Type)
+	assert.Equal(t, "negotiation.new", receive(t, remote).Type)
+	assertNoMessage(t, unlisted)
+}
+
+func TestBackplane_CloseNegotiationAcrossNodes(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	nodeA := newNode(t, backplane, nil)
+	nodeB := newNode(t, backplane, nil)
+
+	rider := connect(t, nodeA, "rider-1", "rider")
+	driver := connect(t, nodeB, "driver-1", "driver")
+	nodeA.AddClientToNegotiation(rider.ID, "session-1")
+	nodeB.AddClientToNegotiation(driver.ID, "session-1")
+
+	nodeA.SendToNegotiation("session-1", &Message{Type: "negotiation.offer"})
+	assert.Equal(t, "negotiation.offer", receive(t, rider).Type)
+	assert.Equal(t, "negotiation.offer", receive(t, driver).Type)
+
+	nodeA.CloseNegotiation("session-1", "session_expired")
+
+	msg := receive(t, driver)
+	assert.Equal(t, "negotiation.closed", msg.Type)
+	assert.Equal(t, "session_expired", msg.Data["reason"])
+	assert.Equal(t, "negotiation.closed", receive(t, rider).Type)
+	assert.Equal(t, 0, nodeA.GetNegotiationCount())
+	assert.Equal(t, 0, nodeB.GetNegotiationCount())
+}
+
+func TestBackplane_Presence(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	presence := NewMemoryPresence()
+	nodeA := newNode(t, backplane, presence)
+	nodeB := newNode(t, backplane, presence)
+	ctx := context.Background()
+
+	client := connect(t, nodeA, "rider-1", "rider")
+	node, err := nodeB.LocateUser(ctx, "rider-1")
+	require.NoError(t, err)
+	assert.Equal(t, nodeA.NodeID(), node)
+
+	nodeA.Unregister <- client
+	time.Sleep(10 * time.Millisecond)
+	node, err = nodeB.LocateUser(ctx, "rider-1")
+	require.NoError(t, err)
+	assert.Empty(t, node)
+}
+
+func TestBackplane_ReconnectToOtherNode(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	presence := NewMemoryPresence()
+	nodeA := newNode(t, backplane, presence)
+	nodeB := newNode(t, backplane, presence)
+
+	stale := connect(t, nodeA, "rider-1", "rider")
+	current := connect(t, nodeB, "rider-1", "rider")
+	time.Sleep(10 * time.Millisecond)
+
+	// The old node drops its connection, and going offline there leaves the
+	// new node's presence alone
+	_, ok := nodeA.GetClient("rider-1")
+	assert.False(t, ok)
+	_, open := <-stale.Send
+	assert.False(t, open)
+
+	node, err := nodeA.LocateUser(context.Background(), "rider-1")
+	require.NoError(t, err)
+	assert.Equal(t, nodeB.NodeID(), node)
+
+	nodeA.SendToUser("rider-1", &Message{Type: "ride.accepted"})
+	assert.Equal(t, "ride.accepted", receive(t, current).Type)
+}
+
+func TestMemoryPresence_SetOfflineKeepsNewerNode(t *testing.T) {
+	presence := NewMemoryPresence()
+	ctx := context.Background()
+
+	require.NoError(t, presence.SetOnline(ctx, "rider-1", "node-a"))
+	require.NoError(t, presence.SetOnline(ctx, "rider-1", "node-b"))
+	require.NoError(t, presence.SetOffline(ctx, "rider-1", "node-a"))
+
+	node, err := presence.Locate(ctx, "rider-1")
+	require.NoError(t, err)
+	assert.Equal(t, "node-b", node)
+}
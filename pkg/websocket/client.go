@@ -30,13 +30,15 @@ type Message struct {
 	UserID    string                 `json:"user_id,omitempty"`
 	Timestamp time.Time              `json:"timestamp"`
 	Data      map[string]interface{} `json:"data"`
+	Seq       int64                  `json:"seq,omitempty"`    // Position in Stream, for messages kept for replay
+	Stream    string                 `json:"stream,omitempty"` // "user:<id>" or "ride:<id>"
 }
 
 // Client represents a WebSocket client connection
 type Client struct {
 	ID        string          // Unique client identifier (user ID)
 	RideID    string          // Current ride ID (if in a ride)
-	Role      string          // "rider" or "driver"
+	Role      string          // "rider", "driver" or "admin"
 	Conn      *websocket.Conn // WebSocket connection
 	Send      chan *Message   // Buffered channel of outbound messages
 	Hub       *Hub            // Reference to hub
@@ -44,6 +46,11 @@ type Client struct {
 	mu        sync.RWMutex    // Protects concurrent access
 	closeOnce sync.Once       // Ensures channel is closed only once
 	closed    bool            // Tracks if channel is closed
+
+	resume    *int64           // Last sequence number the client had, when resuming
+	replaying int              // Replays in progress
+	held      []*Message       // Sequenced messages held back until replays finish
+	replayed  map[string]int64 // Last sequence number replayed per stream
 }
 
 // NewClient creates a new WebSocket client
@@ -122,8 +129,27 @@ func (c *Client) WritePump() {
 	}
 }
 
-// SendMessage sends a message to the client
+// SendMessage sends a message to the client. Sequenced messages are held
+// back while missed ones are being replayed, so the client gets its streams
+// in order.
 func (c *Client) SendMessage(msg *Message) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	if c.replaying > 0 && msg.Seq > 0 {
+		c.held = append(c.held, msg)
+		c.mu.Unlock()
+		return
+	}
+	c.mu.Unlock()
+
+	c.deliver(msg)
+}
+
+// deliver queues a message for the write pump
+func (c *Client) deliver(msg *Message) {
 	c.mu.Lock()
 	if c.closed {
 		c.mu.Unlock()
@@ -145,6 +171,85 @@ func (c *Client) SendMessage(msg *Message) {
 	}
 }
 
+// ResumeFrom marks the client as resuming a dropped connection, having
+// received its user stream up to lastSeq. Call it before registering the
+// client; messages sent meanwhile are held until the replay is done.
+func (c *Client) ResumeFrom(lastSeq int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resume = &lastSeq
+	c.replaying++
+}
+
+// resumeSeq returns the sequence number the client is resuming from, if it
+// is resuming
+func (c *Client) resumeSeq() (int64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.resume == nil {
+		return 0, false
+	}
+	return *c.resume, true
+}
+
+// beginReplay holds back sequenced messages until finishReplay
+func (c *Client) beginReplay() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.replaying++
+}
+
+// finishReplay sends the messages missed in stream, then the messages held
+// back during the replay that the replay didn't already cover. When the
+// stream can't be replayed in full the client is told to resync instead.
+func (c *Client) finishReplay(stream string, msgs []*Message, complete bool) {
+	if !complete {
+		c.deliver(&Message{
+			Type:      "resync_required",
+			Timestamp: time.Now(),
+			Data:      map[string]interface{}{"stream": stream},
+		})
+	}
+	for _, msg := range msgs {
+		c.deliver(msg)
+	}
+
+	c.mu.Lock()
+	if len(msgs) > 0 {
+		if c.replayed == nil {
+			c.replayed = make(map[string]int64)
+		}
+		c.replayed[stream] = msgs[len(msgs)-1].Seq
+	}
+	c.mu.Unlock()
+
+	// Messages keep being held while earlier ones are flushed
+	for {
+		c.mu.Lock()
+		if c.replaying > 1 || len(c.held) == 0 {
+			c.replaying--
+			if c.replaying == 0 {
+				c.replayed = nil
+			}
+			c.mu.Unlock()
+			return
+		}
+		held := c.held
+		c.held = nil
+		replayed := make(map[string]int64, len(c.replayed))
+		for s, seq := range c.replayed {
+			replayed[s] = seq
+		}
+		c.mu.Unlock()
+
+		for _, msg := range held {
+			if msg.Seq > replayed[msg.Stream] {
+				c.deliver(msg)
+			}
+		}
+	}
+}
+
 // SetRide associates the client with a ride
 func (c *Client) SetRide(rideID string) {
 	c.mu.Lock()
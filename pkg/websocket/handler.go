@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -30,7 +31,11 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// HandleWebSocket handles WebSocket upgrade and authentication
+// HandleWebSocket handles WebSocket upgrade and authentication. A client
+// reconnecting after a dropped connection passes resume=true, and optionally
+// last_seq, the last sequence number it received in its user stream; it is
+// then sent the messages it missed since that or its last ack, whichever is
+// later.
 func HandleWebSocket(c *gin.Context, hub *Hub, jwtProvider jwtkeys.KeyProvider) {
 	// Get token from query parameter or header
 	tokenString := c.Query("token")
@@ -65,6 +70,16 @@ func HandleWebSocket(c *gin.Context, hub *Hub, jwtProvider jwtkeys.KeyProvider)
 		return
 	}
 
+	resume := c.Query("resume") == "true"
+	var lastSeq int64
+	if raw := c.Query("last_seq"); resume && raw != "" {
+		lastSeq, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || lastSeq < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid last_seq"})
+			return
+		}
+	}
+
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -74,12 +89,18 @@ func HandleWebSocket(c *gin.Context, hub *Hub, jwtProvider jwtkeys.KeyProvider)
 
 	// Determine role
 	role := "rider"
-	if claims.Role == models.RoleDriver {
+	switch claims.Role {
+	case models.RoleDriver:
 		role = "driver"
+	case models.RoleAdmin:
+		role = "admin"
 	}
 
 	// Create client
 	client := NewClient(claims.UserID.String(), conn, hub, role, zap.L())
+	if resume {
+		client.ResumeFrom(lastSeq)
+	}
 
 	// Register client with hub
 	hub.Register <- client
@@ -1,8 +1,11 @@
 package websocket
 
 import (
+	"context"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/richxcame/ride-hailing/pkg/logger"
 	"go.uber.org/zap"
 )
@@ -33,20 +36,35 @@ type Hub struct {
 	// Message handlers by message type
 	handlers map[string]MessageHandler
 
+	// Identifies this hub among the nodes sharing a backplane
+	nodeID string
+
+	// Carries broadcasts to hubs on other nodes (optional)
+	backplane Backplane
+
+	// Tracks which node holds each user (optional)
+	presence Presence
+
+	// Sequences user and ride messages and keeps them for replay
+	replay ReplayStore
+
 	// Mutex for thread-safe operations
 	mu sync.RWMutex
 }
 
 // BroadcastMessage represents a message to be broadcast
 type BroadcastMessage struct {
-	Target   string   // "user", "ride", "all"
-	TargetID string   // User ID or Ride ID
-	Message  *Message // Message to send
+	Target    string   `json:"target"`               // "user", "users", "ride", "negotiation", "all"
+	TargetID  string   `json:"target_id,omitempty"`  // User ID, ride ID or negotiation session ID
+	TargetIDs []string `json:"target_ids,omitempty"` // User IDs for "users"
+	ExcludeID string   `json:"exclude_id,omitempty"` // Client left out of a ride broadcast
+	Role      string   `json:"role,omitempty"`       // Only clients with this role get a ride broadcast
+	Message   *Message `json:"message,omitempty"`    // Message to send
 }
 
 // NewHub creates a new Hub instance
 func NewHub() *Hub {
-	return &Hub{
+	h := &Hub{
 		clients:      make(map[string]*Client),
 		rides:        make(map[string]map[string]*Client),
 		negotiations: make(map[string]map[string]*Client),
@@ -54,22 +72,67 @@ func NewHub() *Hub {
 		Unregister:   make(chan *Client),
 		Broadcast:    make(chan *BroadcastMessage, 256),
 		handlers:     make(map[string]MessageHandler),
+		nodeID:       uuid.NewString(),
+		replay:       NewMemoryReplayStore(),
 	}
+	h.handlers["ack"] = h.handleAck
+	return h
+}
+
+// SetBackplane connects the hub to the hubs on other nodes. Broadcasts are
+// then published to every node, and envelopes from other nodes are
+// delivered to this hub's clients until ctx is cancelled. Call it before
+// Run.
+func (h *Hub) SetBackplane(ctx context.Context, backplane Backplane) error {
+	if err := backplane.Subscribe(ctx, h.nodeID, h.receive); err != nil {
+		return err
+	}
+	h.backplane = backplane
+	return nil
+}
+
+// SetPresence sets where the hub records which node holds each user, so
+// messages for a user are sent to that node alone. Call it before Run.
+func (h *Hub) SetPresence(presence Presence) {
+	h.presence = presence
+}
+
+// SetReplayStore replaces the in-memory replay store, which hubs sharing a
+// backplane need so they number streams alike. Call it before Run.
+func (h *Hub) SetReplayStore(store ReplayStore) {
+	h.replay = store
+}
+
+// NodeID returns the ID this hub is known by on the backplane
+func (h *Hub) NodeID() string {
+	return h.nodeID
 }
 
 // Run starts the hub's main loop
 func (h *Hub) Run() {
-	logger.Info("WebSocket Hub started")
+	logger.Info("WebSocket Hub started", zap.String("node_id", h.nodeID))
+	refresh := time.NewTicker(presenceRefreshInterval)
+	defer refresh.Stop()
+
 	for {
 		select {
 		case client := <-h.Register:
 			h.registerClient(client)
+			go h.announce(client.ID)
+			if lastSeq, ok := client.resumeSeq(); ok {
+				go h.replayUser(client, lastSeq)
+			}
 
 		case client := <-h.Unregister:
 			h.unregisterClient(client)
 
 		case broadcast := <-h.Broadcast:
 			h.broadcastMessage(broadcast)
+
+		case <-refresh.C:
+			if h.presence != nil {
+				go h.refreshPresence(h.clientIDs())
+			}
 		}
 	}
 }
@@ -125,6 +188,10 @@ func (h *Hub) unregisterClient(client *Client) {
 			close(client.Send)
 		})
 		logger.Info("Client unregistered", zap.String("client_id", client.ID))
+
+		if h.presence != nil {
+			go h.setOffline(client.ID)
+		}
 	} else if ok && existingClient != client {
 		// Old client trying to unregister after being replaced by a new connection
 		logger.Info("Ignoring unregister for replaced client", zap.String("client_id", client.ID))
@@ -150,6 +217,9 @@ func (h *Hub) broadcastMessage(broadcast *BroadcastMessage) {
 		// Send to all clients in a ride
 		if ride, ok := h.rides[broadcast.TargetID]; ok {
 			for _, client := range ride {
+				if client.ID == broadcast.ExcludeID || (broadcast.Role != "" && client.Role != broadcast.Role) {
+					continue
+				}
 				client.SendMessage(broadcast.Message)
 			}
 		}
@@ -162,6 +232,14 @@ func (h *Hub) broadcastMessage(broadcast *BroadcastMessage) {
 			}
 		}
 
+	case "role":
+		// Send to every client with the role
+		for _, client := range h.clients {
+			if client.Role == broadcast.TargetID {
+				client.SendMessage(broadcast.Message)
+			}
+		}
+
 	case "all":
 		// Send to all connected clients
 		for _, client := range h.clients {
@@ -210,6 +288,10 @@ func (h *Hub) AddClientToRide(clientID, rideID string) {
 	h.rides[rideID][clientID] = client
 	client.SetRide(rideID)
 
+	// A participant rejoining the ride gets what they missed
+	client.beginReplay()
+	go h.replayRide(client, rideID)
+
 	logger.Info("Client joined ride", zap.String("client_id", clientID), zap.String("ride_id", rideID))
 }
 
@@ -232,30 +314,76 @@ func (h *Hub) RemoveClientFromRide(clientID, rideID string) {
 	logger.Info("Client left ride", zap.String("client_id", clientID), zap.String("ride_id", rideID))
 }
 
-// SendToUser sends a message to a specific user
+// SendToUser sends a message to a specific user, on whichever node holds
+// their connection. It is sequenced in the user's stream and kept for
+// replay, so a user who is reconnecting gets it too.
 func (h *Hub) SendToUser(userID string, msg *Message) {
-	h.Broadcast <- &BroadcastMessage{
+	h.sequence(userStream(userID), msg)
+	broadcast := &BroadcastMessage{
 		Target:   "user",
 		TargetID: userID,
 		Message:  msg,
 	}
+	if _, local := h.GetClient(userID); local || h.backplane == nil {
+		h.Broadcast <- broadcast
+		return
+	}
+	h.sendToRemoteUser(broadcast)
 }
 
-// SendToRide sends a message to all clients in a ride
+// SendToRide sends a message to all clients in a ride. It is sequenced in
+// the ride's stream and kept for replay.
 func (h *Hub) SendToRide(rideID string, msg *Message) {
-	h.Broadcast <- &BroadcastMessage{
+	h.SendToRideExcept(rideID, "", msg)
+}
+
+// SendToRideExcept sends a message to all clients in a ride but one, usually
+// the client it came from
+func (h *Hub) SendToRideExcept(rideID, excludeClientID string, msg *Message) {
+	h.sequence(rideStream(rideID), msg)
+	broadcast := &BroadcastMessage{
+		Target:    "ride",
+		TargetID:  rideID,
+		ExcludeID: excludeClientID,
+		Message:   msg,
+	}
+	h.Broadcast <- broadcast
+	h.publish(&Envelope{BroadcastMessage: *broadcast})
+}
+
+// SendToRideRole sends a message to the clients in a ride with the given
+// role. The message is not sequenced, as the ride stream is replayed to
+// everyone in the ride.
+func (h *Hub) SendToRideRole(rideID, role string, msg *Message) {
+	broadcast := &BroadcastMessage{
 		Target:   "ride",
 		TargetID: rideID,
+		Role:     role,
+		Message:  msg,
+	}
+	h.Broadcast <- broadcast
+	h.publish(&Envelope{BroadcastMessage: *broadcast})
+}
+
+// SendToRole sends a message to every connected client with the given role
+func (h *Hub) SendToRole(role string, msg *Message) {
+	broadcast := &BroadcastMessage{
+		Target:   "role",
+		TargetID: role,
 		Message:  msg,
 	}
+	h.Broadcast <- broadcast
+	h.publish(&Envelope{BroadcastMessage: *broadcast})
 }
 
 // SendToAll broadcasts a message to all connected clients
 func (h *Hub) SendToAll(msg *Message) {
-	h.Broadcast <- &BroadcastMessage{
+	broadcast := &BroadcastMessage{
 		Target:  "all",
 		Message: msg,
 	}
+	h.Broadcast <- broadcast
+	h.publish(&Envelope{BroadcastMessage: *broadcast})
 }
 
 // GetClient returns a client by ID
@@ -336,11 +464,13 @@ func (h *Hub) RemoveClientFromNegotiation(clientID, sessionID string) {
 
 // SendToNegotiation sends a message to all clients in a negotiation session
 func (h *Hub) SendToNegotiation(sessionID string, msg *Message) {
-	h.Broadcast <- &BroadcastMessage{
+	broadcast := &BroadcastMessage{
 		Target:   "negotiation",
 		TargetID: sessionID,
 		Message:  msg,
 	}
+	h.Broadcast <- broadcast
+	h.publish(&Envelope{BroadcastMessage: *broadcast})
 }
 
 // GetClientsInNegotiation returns all clients in a negotiation session
@@ -364,8 +494,19 @@ func (h *Hub) GetNegotiationCount() int {
 	return len(h.negotiations)
 }
 
-// CloseNegotiation closes a negotiation room and notifies all participants
+// CloseNegotiation closes a negotiation room on every node and notifies all
+// participants
 func (h *Hub) CloseNegotiation(sessionID string, reason string) {
+	h.closeNegotiation(sessionID, reason)
+	h.publish(&Envelope{BroadcastMessage: BroadcastMessage{
+		Target:   "negotiation_closed",
+		TargetID: sessionID,
+		Message:  &Message{Data: map[string]interface{}{"reason": reason}},
+	}})
+}
+
+// closeNegotiation closes this node's part of a negotiation room
+func (h *Hub) closeNegotiation(sessionID string, reason string) {
 	// Collect clients and remove room under lock
 	h.mu.Lock()
 	negotiation, ok := h.negotiations[sessionID]
@@ -395,14 +536,31 @@ func (h *Hub) CloseNegotiation(sessionID string, reason string) {
 	}
 }
 
-// SendToMultipleUsers sends a message to multiple users
+// SendToMultipleUsers sends a message to multiple users. Users not
+// connected to this node are handed to the others in a single broadcast.
 func (h *Hub) SendToMultipleUsers(userIDs []string, msg *Message) {
+	remote := h.sendToLocalUsers(userIDs, msg)
+	if len(remote) > 0 {
+		h.publish(&Envelope{BroadcastMessage: BroadcastMessage{
+			Target:    "users",
+			TargetIDs: remote,
+			Message:   msg,
+		}})
+	}
+}
+
+// sendToLocalUsers sends a message to the users connected to this node and
+// returns the IDs of the rest
+func (h *Hub) sendToLocalUsers(userIDs []string, msg *Message) []string {
 	// Collect clients under lock
 	h.mu.RLock()
 	clients := make([]*Client, 0, len(userIDs))
+	var missing []string
 	for _, userID := range userIDs {
 		if client, ok := h.clients[userID]; ok {
 			clients = append(clients, client)
+		} else {
+			missing = append(missing, userID)
 		}
 	}
 	h.mu.RUnlock()
@@ -411,4 +569,231 @@ func (h *Hub) SendToMultipleUsers(userIDs []string, msg *Message) {
 	for _, client := range clients {
 		client.SendMessage(msg)
 	}
+	return missing
+}
+
+// ========================================
+// CROSS-NODE DELIVERY
+// ========================================
+
+// publish hands a broadcast to the hubs on other nodes
+func (h *Hub) publish(env *Envelope) {
+	if h.backplane == nil {
+		return
+	}
+	env.Origin = h.nodeID
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	if err := h.backplane.Publish(ctx, env); err != nil {
+		logger.Error("Failed to publish to backplane",
+			zap.String("target", env.Target),
+			zap.String("target_id", env.TargetID),
+			zap.Error(err))
+	}
+}
+
+// sendToRemoteUser sends a message for a user this node doesn't hold to the
+// node that does. Without presence, or when it can't be read, every node is
+// asked to deliver it.
+func (h *Hub) sendToRemoteUser(broadcast *BroadcastMessage) {
+	env := &Envelope{BroadcastMessage: *broadcast}
+	if h.presence != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+		nodeID, err := h.presence.Locate(ctx, broadcast.TargetID)
+		cancel()
+
+		switch {
+		case err != nil:
+			logger.Warn("Failed to locate user, broadcasting to all nodes", zap.String("user_id", broadcast.TargetID), zap.Error(err))
+		case nodeID == "" || nodeID == h.nodeID:
+			logger.Warn("User not connected to any node for message delivery",
+				zap.String("user_id", broadcast.TargetID), zap.String("type", broadcast.Message.Type))
+			return
+		default:
+			env.Node = nodeID
+		}
+	}
+	h.publish(env)
+}
+
+// receive delivers an envelope from another node to this node's clients
+func (h *Hub) receive(env *Envelope) {
+	if env.Origin == h.nodeID {
+		return
+	}
+
+	switch env.Target {
+	case "user":
+		// Every node gets user messages when presence is unknown, so only
+		// the node holding the user delivers it
+		if client, ok := h.GetClient(env.TargetID); ok {
+			client.SendMessage(env.Message)
+		}
+
+	case "users":
+		h.sendToLocalUsers(env.TargetIDs, env.Message)
+
+	case "negotiation_closed":
+		reason, _ := env.Message.Data["reason"].(string)
+		h.closeNegotiation(env.TargetID, reason)
+
+	case "evict":
+		// The user connected to another node, so this node's connection is
+		// stale
+		if client, ok := h.GetClient(env.TargetID); ok {
+			h.Unregister <- client
+			logger.Info("Replaced client connected to another node",
+				zap.String("client_id", env.TargetID), zap.String("node_id", env.Origin))
+		}
+
+	default:
+		h.broadcastMessage(&env.BroadcastMessage)
+	}
+}
+
+// announce records a newly registered user as held by this node and drops
+// any older connection they left on another node
+func (h *Hub) announce(userID string) {
+	if h.presence != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+		if err := h.presence.SetOnline(ctx, userID, h.nodeID); err != nil {
+			logger.Warn("Failed to record presence", zap.String("user_id", userID), zap.Error(err))
+		}
+		cancel()
+	}
+	h.publish(&Envelope{BroadcastMessage: BroadcastMessage{Target: "evict", TargetID: userID}})
+}
+
+// setOffline removes a user who disconnected from this node from presence
+func (h *Hub) setOffline(userID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	if err := h.presence.SetOffline(ctx, userID, h.nodeID); err != nil {
+		logger.Warn("Failed to clear presence", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// refreshPresence keeps the users connected to this node from expiring
+func (h *Hub) refreshPresence(userIDs []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	if err := h.presence.Refresh(ctx, h.nodeID, userIDs); err != nil {
+		logger.Warn("Failed to refresh presence", zap.Int("clients", len(userIDs)), zap.Error(err))
+	}
+}
+
+// clientIDs returns the IDs of the clients connected to this node
+func (h *Hub) clientIDs() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]string, 0, len(h.clients))
+	for id := range h.clients {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// LocateUser returns the node holding a user's connection, or "" when they
+// are not connected anywhere that presence knows of
+func (h *Hub) LocateUser(ctx context.Context, userID string) (string, error) {
+	if _, ok := h.GetClient(userID); ok {
+		return h.nodeID, nil
+	}
+	if h.presence == nil {
+		return "", nil
+	}
+	return h.presence.Locate(ctx, userID)
+}
+
+// ========================================
+// SEQUENCING AND REPLAY
+// ========================================
+
+// sequence numbers a message in its stream. A message the store can't take
+// is still sent, without a sequence number.
+func (h *Hub) sequence(stream string, msg *Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	if err := h.replay.Append(ctx, stream, msg); err != nil {
+		logger.Error("Failed to sequence message", zap.String("stream", stream), zap.String("type", msg.Type), zap.Error(err))
+	}
+}
+
+// handleAck records how far a client has received one of its streams, which
+// is where a resume starts from
+func (h *Hub) handleAck(client *Client, msg *Message) {
+	stream, _ := msg.Data["stream"].(string)
+	seq, _ := msg.Data["seq"].(float64)
+
+	rideID := client.GetRide()
+	if stream != userStream(client.ID) && (rideID == "" || stream != rideStream(rideID)) {
+		logger.Warn("Ack for a stream the client doesn't receive", zap.String("client_id", client.ID), zap.String("stream", stream))
+		return
+	}
+	if seq < 1 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+	if err := h.replay.Ack(ctx, client.ID, stream, int64(seq)); err != nil {
+		logger.Warn("Failed to record ack", zap.String("client_id", client.ID), zap.String("stream", stream), zap.Error(err))
+	}
+}
+
+// replayUser sends a resuming client the messages in their stream after the
+// later of lastSeq and their last ack
+func (h *Hub) replayUser(client *Client, lastSeq int64) {
+	stream := userStream(client.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	acked, err := h.replay.Acked(ctx, client.ID, stream)
+	if err != nil {
+		logger.Warn("Failed to read ack, resuming from the client's sequence", zap.String("client_id", client.ID), zap.Error(err))
+	}
+	if acked > lastSeq {
+		lastSeq = acked
+	}
+
+	msgs, complete, err := h.replay.Since(ctx, stream, lastSeq)
+	if err != nil {
+		logger.Error("Failed to read missed messages", zap.String("client_id", client.ID), zap.Error(err))
+		msgs, complete = nil, false
+	}
+	client.finishReplay(stream, msgs, complete)
+	logger.Info("Client resumed",
+		zap.String("client_id", client.ID),
+		zap.Int64("from_seq", lastSeq),
+		zap.Int("replayed", len(msgs)),
+		zap.Bool("complete", complete))
+}
+
+// replayRide sends a client rejoining a ride the ride messages after their
+// last ack, leaving out what they sent themselves. Clients that never acked
+// the ride are joining it for the first time and get nothing.
+func (h *Hub) replayRide(client *Client, rideID string) {
+	stream := rideStream(rideID)
+	ctx, cancel := context.WithTimeout(context.Background(), backplaneTimeout)
+	defer cancel()
+
+	acked, err := h.replay.Acked(ctx, client.ID, stream)
+	if err != nil || acked == 0 {
+		client.finishReplay(stream, nil, true)
+		return
+	}
+
+	msgs, complete, err := h.replay.Since(ctx, stream, acked)
+	if err != nil {
+		logger.Error("Failed to read missed ride messages", zap.String("client_id", client.ID), zap.String("ride_id", rideID), zap.Error(err))
+		msgs, complete = nil, false
+	}
+	missed := make([]*Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if msg.UserID != client.ID {
+			missed = append(missed, msg)
+		}
+	}
+	client.finishReplay(stream, missed, complete)
 }
@@ -336,6 +336,38 @@ func TestSendToRide(t *testing.T) {
 	}
 }
 
+// TestSendToRideRole tests sending to one role in a ride
+func TestSendToRideRole(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	rider := NewClient("rider-123", createTestWebSocketConn(t), hub, "rider", zap.NewNop())
+	driver := NewClient("driver-456", createTestWebSocketConn(t), hub, "driver", zap.NewNop())
+	hub.Register <- rider
+	hub.Register <- driver
+	time.Sleep(10 * time.Millisecond)
+
+	rideID := "ride-789"
+	hub.AddClientToRide(rider.ID, rideID)
+	hub.AddClientToRide(driver.ID, rideID)
+	time.Sleep(10 * time.Millisecond)
+
+	hub.SendToRideRole(rideID, "rider", &Message{Type: "driver_location", RideID: rideID})
+
+	select {
+	case msg := <-rider.Send:
+		assert.Equal(t, "driver_location", msg.Type)
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Rider did not receive message")
+	}
+
+	select {
+	case <-driver.Send:
+		t.Fatal("Driver should not receive rider-only message")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 // TestSendToAll tests broadcasting to all clients
 func TestSendToAll(t *testing.T) {
 	hub := NewHub()
@@ -374,6 +406,42 @@ func TestSendToAll(t *testing.T) {
 	}
 }
 
+// TestSendToRole tests sending only to clients with a role
+func TestSendToRole(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	admin := NewClient("admin-1", createTestWebSocketConn(t), hub, "admin", zap.NewNop())
+	rider := NewClient("rider-1", createTestWebSocketConn(t), hub, "rider", zap.NewNop())
+	driver := NewClient("driver-1", createTestWebSocketConn(t), hub, "driver", zap.NewNop())
+	hub.Register <- admin
+	hub.Register <- rider
+	hub.Register <- driver
+
+	time.Sleep(10 * time.Millisecond)
+
+	hub.SendToRole("admin", &Message{
+		Type: "emergency_location",
+		Data: map[string]interface{}{"alert_id": "alert-1"},
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	select {
+	case <-admin.Send:
+		// Message received
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Admin did not receive message")
+	}
+
+	for _, client := range []*Client{rider, driver} {
+		select {
+		case <-client.Send:
+			t.Fatalf("%s should not receive admin messages", client.Role)
+		default:
+		}
+	}
+}
+
 // TestRegisterHandler tests handler registration
 func TestRegisterHandler(t *testing.T) {
 	hub := NewHub()
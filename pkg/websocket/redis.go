@@ -0,0 +1,214 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/richxcame/ride-hailing/pkg/logger"
+	"go.uber.org/zap"
+)
+
+const (
+	backplaneBroadcastChannel = "ws:broadcast"
+	backplaneNodeChannel      = "ws:node:"
+	presenceKeyPrefix         = "ws:presence:"
+	replaySeqKeyPrefix        = "ws:seq:"
+	replayBufferKeyPrefix     = "ws:buffer:"
+	replayAckKeyPrefix        = "ws:ack:"
+)
+
+// Deletes a presence entry only if it still names the node going offline
+var presenceReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Records an ack only if it is later than the one already recorded
+var replayAckScript = redis.NewScript(`
+local current = tonumber(redis.call("HGET", KEYS[1], ARGV[1]) or "0")
+if tonumber(ARGV[2]) > current then
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+end
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+return 0
+`)
+
+// RedisBackplane carries broadcasts between nodes over Redis pub/sub.
+// Broadcasts go to a channel every node subscribes to; messages for a user
+// whose node is known go to that node's own channel.
+type RedisBackplane struct {
+	client redis.UniversalClient
+}
+
+// NewRedisBackplane creates a backplane on the given Redis client
+func NewRedisBackplane(client redis.UniversalClient) *RedisBackplane {
+	return &RedisBackplane{client: client}
+}
+
+// Publish sends the envelope to the broadcast channel, or to env.Node's
+// channel when it is set
+func (b *RedisBackplane) Publish(ctx context.Context, env *Envelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	channel := backplaneBroadcastChannel
+	if env.Node != "" {
+		channel = backplaneNodeChannel + env.Node
+	}
+	return b.client.Publish(ctx, channel, data).Err()
+}
+
+// Subscribe listens on the broadcast channel and nodeID's channel. It returns
+// once Redis has confirmed the subscription; the connection is re-established
+// by the client if it drops.
+func (b *RedisBackplane) Subscribe(ctx context.Context, nodeID string, handler func(*Envelope)) error {
+	sub := b.client.Subscribe(ctx, backplaneBroadcastChannel, backplaneNodeChannel+nodeID)
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return err
+	}
+
+	go func() {
+		defer sub.Close()
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var env Envelope
+				if err := json.Unmarshal([]byte(msg.Payload), &env); err != nil {
+					logger.Warn("Dropping malformed backplane message", zap.String("channel", msg.Channel), zap.Error(err))
+					continue
+				}
+				handler(&env)
+			}
+		}
+	}()
+	return nil
+}
+
+// RedisPresence keeps the node holding each user in Redis with a TTL, so a
+// node that dies without cleaning up stops being reported within presenceTTL
+type RedisPresence struct {
+	client redis.UniversalClient
+}
+
+// NewRedisPresence creates a presence tracker on the given Redis client
+func NewRedisPresence(client redis.UniversalClient) *RedisPresence {
+	return &RedisPresence{client: client}
+}
+
+func (p *RedisPresence) SetOnline(ctx context.Context, userID, nodeID string) error {
+	return p.client.Set(ctx, presenceKeyPrefix+userID, nodeID, presenceTTL).Err()
+}
+
+func (p *RedisPresence) SetOffline(ctx context.Context, userID, nodeID string) error {
+	return presenceReleaseScript.Run(ctx, p.client, []string{presenceKeyPrefix + userID}, nodeID).Err()
+}
+
+func (p *RedisPresence) Refresh(ctx context.Context, nodeID string, userIDs []string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	pipe := p.client.Pipeline()
+	for _, userID := range userIDs {
+		pipe.Set(ctx, presenceKeyPrefix+userID, nodeID, presenceTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (p *RedisPresence) Locate(ctx context.Context, userID string) (string, error) {
+	nodeID, err := p.client.Get(ctx, presenceKeyPrefix+userID).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return nodeID, err
+}
+
+// RedisReplayStore sequences streams with a Redis counter and keeps their
+// latest messages in a sorted set scored by sequence number, so every node
+// numbers a stream the same way and a client can resume on any node
+type RedisReplayStore struct {
+	client redis.UniversalClient
+}
+
+// NewRedisReplayStore creates a replay store on the given Redis client
+func NewRedisReplayStore(client redis.UniversalClient) *RedisReplayStore {
+	return &RedisReplayStore{client: client}
+}
+
+func (s *RedisReplayStore) Append(ctx context.Context, stream string, msg *Message) error {
+	seqKey := replaySeqKeyPrefix + stream
+	seq, err := s.client.Incr(ctx, seqKey).Result()
+	if err != nil {
+		return err
+	}
+	msg.Seq = seq
+	msg.Stream = stream
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	bufKey := replayBufferKeyPrefix + stream
+	pipe := s.client.TxPipeline()
+	pipe.Expire(ctx, seqKey, replayStreamTTL)
+	pipe.ZAdd(ctx, bufKey, redis.Z{Score: float64(seq), Member: data})
+	pipe.ZRemRangeByRank(ctx, bufKey, 0, -replayBufferSize-1)
+	pipe.Expire(ctx, bufKey, replayStreamTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func (s *RedisReplayStore) Since(ctx context.Context, stream string, seq int64) ([]*Message, bool, error) {
+	pipe := s.client.Pipeline()
+	lastCmd := pipe.Get(ctx, replaySeqKeyPrefix+stream)
+	bufCmd := pipe.ZRangeByScore(ctx, replayBufferKeyPrefix+stream, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(seq, 10),
+		Max: "+inf",
+	})
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, false, err
+	}
+
+	last, err := lastCmd.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, false, err
+	}
+	buf := make([]*Message, 0, len(bufCmd.Val()))
+	for _, data := range bufCmd.Val() {
+		var msg Message
+		if err := json.Unmarshal([]byte(data), &msg); err != nil {
+			return nil, false, err
+		}
+		buf = append(buf, &msg)
+	}
+
+	msgs, complete := sinceBuffer(buf, last, seq)
+	return msgs, complete, nil
+}
+
+func (s *RedisReplayStore) Ack(ctx context.Context, userID, stream string, seq int64) error {
+	return replayAckScript.Run(ctx, s.client, []string{replayAckKeyPrefix + userID},
+		stream, seq, int64(replayStreamTTL.Seconds())).Err()
+}
+
+func (s *RedisReplayStore) Acked(ctx context.Context, userID, stream string) (int64, error) {
+	seq, err := s.client.HGet(ctx, replayAckKeyPrefix+userID, stream).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return seq, err
+}
@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// Messages kept per stream for clients resuming after a dropped
+	// connection
+	replayBufferSize = 100
+
+	// Streams and acks left idle this long are dropped. A stream that comes
+	// back starts again at sequence 1, which resuming clients are told to
+	// resync from.
+	replayStreamTTL = 6 * time.Hour
+)
+
+// Stream names. Messages to a user are sequenced in the user's stream and
+// messages to a ride in the ride's, so each ride participant sees the same
+// numbers.
+func userStream(userID string) string { return "user:" + userID }
+func rideStream(rideID string) string { return "ride:" + rideID }
+
+// ReplayStore sequences messages per stream and keeps the latest ones, so
+// clients that lost their connection can be sent what they missed
+type ReplayStore interface {
+	// Append gives msg the next sequence number in stream and keeps it for
+	// replay
+	Append(ctx context.Context, stream string, msg *Message) error
+
+	// Since returns the kept messages in stream after seq, oldest first.
+	// complete is false when some of them are no longer kept, or the stream
+	// restarted since seq was sent.
+	Since(ctx context.Context, stream string, seq int64) (msgs []*Message, complete bool, err error)
+
+	// Ack records the last sequence number in stream the user received
+	Ack(ctx context.Context, userID, stream string, seq int64) error
+
+	// Acked returns the last sequence number in stream the user
+	// acknowledged, or 0
+	Acked(ctx context.Context, userID, stream string) (int64, error)
+}
+
+// sinceBuffer returns the messages after seq from a stream whose last
+// number is last and whose kept messages are buf, oldest first
+func sinceBuffer(buf []*Message, last, seq int64) ([]*Message, bool) {
+	if seq > last {
+		// The stream restarted after the client's last message
+		return nil, false
+	}
+	if seq == last {
+		return nil, true
+	}
+
+	var msgs []*Message
+	for _, msg := range buf {
+		if msg.Seq > seq {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, len(msgs) > 0 && msgs[0].Seq == seq+1
+}
+
+// MemoryReplayStore keeps streams in process memory. It is the hub's default
+// and only sequences correctly for a single node.
+type MemoryReplayStore struct {
+	mu        sync.Mutex
+	streams   map[string]*memoryStream
+	acks      map[string]memoryAck // user ID + stream -> ack
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryStream struct {
+	seq     int64
+	buf     []*Message
+	touched time.Time
+}
+
+type memoryAck struct {
+	seq     int64
+	touched time.Time
+}
+
+// NewMemoryReplayStore creates an empty in-memory replay store
+func NewMemoryReplayStore() *MemoryReplayStore {
+	return &MemoryReplayStore{
+		streams: make(map[string]*memoryStream),
+		acks:    make(map[string]memoryAck),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryReplayStore) Append(ctx context.Context, stream string, msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep()
+
+	st, ok := s.streams[stream]
+	if !ok {
+		st = &memoryStream{}
+		s.streams[stream] = st
+	}
+	st.seq++
+	st.touched = s.now()
+	msg.Seq = st.seq
+	msg.Stream = stream
+
+	st.buf = append(st.buf, msg)
+	if len(st.buf) > replayBufferSize {
+		st.buf = st.buf[len(st.buf)-replayBufferSize:]
+	}
+	return nil
+}
+
+func (s *MemoryReplayStore) Since(ctx context.Context, stream string, seq int64) ([]*Message, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.streams[stream]
+	if !ok {
+		st = &memoryStream{}
+	}
+	msgs, complete := sinceBuffer(st.buf, st.seq, seq)
+	return msgs, complete, nil
+}
+
+func (s *MemoryReplayStore) Ack(ctx context.Context, userID, stream string, seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := userID + "|" + stream
+	if ack := s.acks[key]; seq > ack.seq {
+		s.acks[key] = memoryAck{seq: seq, touched: s.now()}
+	}
+	return nil
+}
+
+func (s *MemoryReplayStore) Acked(ctx context.Context, userID, stream string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.acks[userID+"|"+stream].seq, nil
+}
+
+// sweep drops idle streams and acks, at most once per TTL
+func (s *MemoryReplayStore) sweep() {
+	now := s.now()
+	if now.Sub(s.lastSweep) < replayStreamTTL {
+		return
+	}
+	s.lastSweep = now
+
+	for stream, st := range s.streams {
+		if now.Sub(st.touched) >= replayStreamTTL {
+			delete(s.streams, stream)
+		}
+	}
+	for key, ack := range s.acks {
+		if now.Sub(ack.touched) >= replayStreamTTL {
+			delete(s.acks, key)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// resume connects a client that is resuming from lastSeq
+func resume(t *testing.T, hub *Hub, id string, lastSeq int64) *Client {
+	t.Helper()
+	client := NewClient(id, createTestWebSocketConn(t), hub, "rider", zap.NewNop())
+	client.ResumeFrom(lastSeq)
+	hub.Register <- client
+	return client
+}
+
+func ack(hub *Hub, client *Client, stream string, seq int64) {
+	hub.HandleMessage(client, &Message{Type: "ack", Data: map[string]interface{}{"stream": stream, "seq": float64(seq)}})
+}
+
+func TestMemoryReplayStore_Sequences(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Append(ctx, "ride:1", &Message{Type: "driver_location"}))
+	}
+	other := &Message{Type: "ride.accepted"}
+	require.NoError(t, store.Append(ctx, "user:1", other))
+	assert.Equal(t, int64(1), other.Seq)
+	assert.Equal(t, "user:1", other.Stream)
+
+	msgs, complete, err := store.Since(ctx, "ride:1", 1)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	require.Len(t, msgs, 2)
+	assert.Equal(t, int64(2), msgs[0].Seq)
+	assert.Equal(t, int64(3), msgs[1].Seq)
+
+	msgs, complete, err = store.Since(ctx, "ride:1", 3)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Empty(t, msgs)
+}
+
+func TestMemoryReplayStore_Gaps(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	for i := 0; i < replayBufferSize+5; i++ {
+		require.NoError(t, store.Append(ctx, "user:1", &Message{Type: "notification"}))
+	}
+
+	// The first five are no longer kept
+	msgs, complete, err := store.Since(ctx, "user:1", 2)
+	require.NoError(t, err)
+	assert.False(t, complete)
+	assert.Len(t, msgs, replayBufferSize)
+
+	msgs, complete, err = store.Since(ctx, "user:1", 5)
+	require.NoError(t, err)
+	assert.True(t, complete)
+	assert.Len(t, msgs, replayBufferSize)
+
+	// A stream that expired starts over below the client's last number
+	_, complete, err = store.Since(ctx, "user:2", 40)
+	require.NoError(t, err)
+	assert.False(t, complete)
+}
+
+func TestMemoryReplayStore_Expiry(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Append(ctx, "ride:1", &Message{}))
+	require.NoError(t, store.Ack(ctx, "rider-1", "ride:1", 1))
+
+	now = now.Add(replayStreamTTL + time.Minute)
+	msg := &Message{}
+	require.NoError(t, store.Append(ctx, "ride:2", msg))
+
+	_, complete, err := store.Since(ctx, "ride:1", 1)
+	require.NoError(t, err)
+	assert.False(t, complete)
+	acked, err := store.Acked(ctx, "rider-1", "ride:1")
+	require.NoError(t, err)
+	assert.Zero(t, acked)
+}
+
+func TestMemoryReplayStore_AckOnlyMovesForward(t *testing.T) {
+	store := NewMemoryReplayStore()
+	ctx := context.Background()
+
+	require.NoError(t, store.Ack(ctx, "rider-1", "user:rider-1", 7))
+	require.NoError(t, store.Ack(ctx, "rider-1", "user:rider-1", 4))
+
+	acked, err := store.Acked(ctx, "rider-1", "user:rider-1")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), acked)
+}
+
+func TestClient_HoldsMessagesDuringReplay(t *testing.T) {
+	hub := NewHub()
+	client := NewClient("rider-1", nil, hub, "rider", zap.NewNop())
+
+	client.beginReplay()
+	client.SendMessage(&Message{Type: "driver_location", Stream: "ride:1", Seq: 3})
+	client.SendMessage(&Message{Type: "driver_location", Stream: "ride:1", Seq: 4})
+	client.SendMessage(&Message{Type: "pong"})
+
+	// Unsequenced messages aren't held
+	assert.Equal(t, "pong", receive(t, client).Type)
+
+	client.finishReplay("ride:1", []*Message{
+		{Type: "chat_message", Stream: "ride:1", Seq: 2},
+		{Type: "driver_location", Stream: "ride:1", Seq: 3},
+	}, true)
+
+	for _, seq := range []int64{2, 3, 4} {
+		assert.Equal(t, seq, receive(t, client).Seq)
+	}
+	assertNoMessage(t, client)
+
+	// Once replayed, messages go straight out again
+	client.SendMessage(&Message{Type: "driver_location", Stream: "ride:1", Seq: 5})
+	assert.Equal(t, int64(5), receive(t, client).Seq)
+}
+
+func TestHub_ResumeReplaysMissedUserMessages(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	first := connect(t, hub, "rider-1", "rider")
+	hub.SendToUser("rider-1", &Message{Type: "ride.accepted"})
+	msg := receive(t, first)
+	assert.Equal(t, int64(1), msg.Seq)
+	assert.Equal(t, "user:rider-1", msg.Stream)
+	ack(hub, first, msg.Stream, msg.Seq)
+
+	// The connection drops and messages are sent meanwhile
+	hub.Unregister <- first
+	time.Sleep(10 * time.Millisecond)
+	hub.SendToUser("rider-1", &Message{Type: "driver.arrived"})
+	hub.SendToUser("rider-1", &Message{Type: "ride.started"})
+	time.Sleep(10 * time.Millisecond)
+
+	resumed := resume(t, hub, "rider-1", 0)
+	assert.Equal(t, "driver.arrived", receive(t, resumed).Type)
+	assert.Equal(t, "ride.started", receive(t, resumed).Type)
+	assertNoMessage(t, resumed)
+
+	hub.SendToUser("rider-1", &Message{Type: "ride.completed"})
+	msg = receive(t, resumed)
+	assert.Equal(t, "ride.completed", msg.Type)
+	assert.Equal(t, int64(4), msg.Seq)
+}
+
+func TestHub_ResumeFromClientSequence(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	for _, msgType := range []string{"ride.accepted", "driver.arrived", "ride.started"} {
+		hub.SendToUser("rider-1", &Message{Type: msgType})
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// The client received two messages but never acked them
+	resumed := resume(t, hub, "rider-1", 2)
+	msg := receive(t, resumed)
+	assert.Equal(t, "ride.started", msg.Type)
+	assertNoMessage(t, resumed)
+}
+
+func TestHub_ResumeAfterBufferOverflow(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	for i := 0; i < replayBufferSize+1; i++ {
+		hub.SendToUser("rider-1", &Message{Type: "notification"})
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	resumed := resume(t, hub, "rider-1", 0)
+	msg := receive(t, resumed)
+	assert.Equal(t, "resync_required", msg.Type)
+	assert.Equal(t, "user:rider-1", msg.Data["stream"])
+	assert.Equal(t, int64(2), receive(t, resumed).Seq)
+}
+
+func TestHub_RejoinRideReplaysMissedMessages(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	rider := connect(t, hub, "rider-1", "rider")
+	driver := connect(t, hub, "driver-1", "driver")
+	hub.AddClientToRide(rider.ID, "ride-1")
+	hub.AddClientToRide(driver.ID, "ride-1")
+	time.Sleep(10 * time.Millisecond)
+
+	hub.SendToRide("ride-1", &Message{Type: "ride_update"})
+	msg := receive(t, rider)
+	receive(t, driver)
+	ack(hub, rider, msg.Stream, msg.Seq)
+
+	// The rider drops off while the ride goes on
+	hub.Unregister <- rider
+	time.Sleep(10 * time.Millisecond)
+	hub.SendToRideExcept("ride-1", driver.ID, &Message{Type: "driver_location", UserID: driver.ID})
+	hub.SendToRideExcept("ride-1", rider.ID, &Message{Type: "chat_message", UserID: rider.ID})
+
+	rider = connect(t, hub, "rider-1", "rider")
+	hub.AddClientToRide(rider.ID, "ride-1")
+
+	// The rider's own chat message isn't sent back
+	msg = receive(t, rider)
+	assert.Equal(t, "driver_location", msg.Type)
+	assert.Equal(t, int64(2), msg.Seq)
+	assertNoMessage(t, rider)
+}
+
+func TestHub_AckForOtherStreamIgnored(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	client := connect(t, hub, "rider-1", "rider")
+
+	ack(hub, client, "user:rider-2", 9)
+	ack(hub, client, "ride:ride-1", 9)
+
+	acked, err := hub.replay.Acked(context.Background(), "rider-1", "user:rider-2")
+	require.NoError(t, err)
+	assert.Zero(t, acked)
+	acked, err = hub.replay.Acked(context.Background(), "rider-1", "ride:ride-1")
+	require.NoError(t, err)
+	assert.Zero(t, acked)
+}
+
+func TestHub_ResumeOnOtherNode(t *testing.T) {
+	backplane := NewMemoryBackplane()
+	presence := NewMemoryPresence()
+	store := NewMemoryReplayStore()
+	nodes := make([]*Hub, 2)
+	for i := range nodes {
+		nodes[i] = NewHub()
+		require.NoError(t, nodes[i].SetBackplane(context.Background(), backplane))
+		nodes[i].SetPresence(presence)
+		nodes[i].SetReplayStore(store)
+		go nodes[i].Run()
+	}
+	nodeA, nodeB := nodes[0], nodes[1]
+
+	// Sent while the rider is between connections
+	nodeA.SendToUser("rider-1", &Message{Type: "driver.arrived"})
+
+	resumed := resume(t, nodeB, "rider-1", 0)
+	msg := receive(t, resumed)
+	assert.Equal(t, "driver.arrived", msg.Type)
+	assert.Equal(t, int64(1), msg.Seq)
+}
@@ -18,7 +18,6 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
-	"github.com/stripe/stripe-go/v83"
 
 	"github.com/richxcame/ride-hailing/internal/auth"
 	"github.com/richxcame/ride-hailing/internal/payments"
@@ -30,6 +29,7 @@ import (
 	"github.com/richxcame/ride-hailing/pkg/middleware"
 	"github.com/richxcame/ride-hailing/pkg/models"
 	"github.com/richxcame/ride-hailing/test/helpers"
+	"github.com/richxcame/ride-hailing/test/mocks"
 )
 
 const (
@@ -61,8 +61,6 @@ type authSession struct {
 	Token string
 }
 
-type fakeStripeClient struct{}
-
 var (
 	services   map[string]*serviceInstance
 	dbPool     *pgxpool.Pool
@@ -153,7 +151,7 @@ func startRidesService(cfg *config.Config) *serviceInstance {
 
 func startPaymentsService(cfg *config.Config) *serviceInstance {
 	repo := payments.NewRepository(dbPool)
-	service := payments.NewService(repo, &fakeStripeClient{}, &cfg.Business)
+	service := payments.NewService(repo, mocks.NewFakeStripeClient(), &cfg.Business)
 	handler := payments.NewHandler(service)
 
 	router := newRouter(cfg.Server.ServiceName)
@@ -174,38 +172,6 @@ func newRouter(serviceName string) *gin.Engine {
 	return router
 }
 
-func (f *fakeStripeClient) CreateCustomer(email, name string, metadata map[string]string) (*stripe.Customer, error) {
-	return &stripe.Customer{ID: "cus_test"}, nil
-}
-
-func (f *fakeStripeClient) CreatePaymentIntent(amount int64, currency, customerID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
-	return &stripe.PaymentIntent{ID: "pi_test", Status: stripe.PaymentIntentStatusSucceeded}, nil
-}
-
-func (f *fakeStripeClient) ConfirmPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return &stripe.PaymentIntent{ID: paymentIntentID, Status: stripe.PaymentIntentStatusSucceeded}, nil
-}
-
-func (f *fakeStripeClient) CapturePaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return &stripe.PaymentIntent{ID: paymentIntentID, Status: stripe.PaymentIntentStatusSucceeded}, nil
-}
-
-func (f *fakeStripeClient) CreateRefund(chargeID string, amount *int64, reason string) (*stripe.Refund, error) {
-	return &stripe.Refund{ID: "re_test"}, nil
-}
-
-func (f *fakeStripeClient) CreateTransfer(amount int64, currency, destination, description string, metadata map[string]string) (*stripe.Transfer, error) {
-	return &stripe.Transfer{ID: "tr_test"}, nil
-}
-
-func (f *fakeStripeClient) GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return &stripe.PaymentIntent{ID: paymentIntentID, Status: stripe.PaymentIntentStatusSucceeded}, nil
-}
-
-func (f *fakeStripeClient) CancelPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
-	return &stripe.PaymentIntent{ID: paymentIntentID, Status: stripe.PaymentIntentStatusCanceled}, nil
-}
-
 func TestAuthIntegration_RegisterLoginAndProfile(t *testing.T) {
 	truncateTables(t)
 
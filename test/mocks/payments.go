@@ -85,6 +85,16 @@ func (m *MockPaymentsRepository) GetRideDriverID(ctx context.Context, rideID uui
 	return id, args.Error(1)
 }
 
+func (m *MockPaymentsRepository) GetRideStatus(ctx context.Context, rideID uuid.UUID) (models.RideStatus, error) {
+	args := m.Called(ctx, rideID)
+	return args.Get(0).(models.RideStatus), args.Error(1)
+}
+
+func (m *MockPaymentsRepository) GetRiderCard(ctx context.Context, riderID uuid.UUID) (string, string, error) {
+	args := m.Called(ctx, riderID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
 func (m *MockPaymentsRepository) GetPaymentsByRideID(ctx context.Context, rideID uuid.UUID) ([]*models.Payment, error) {
 	args := m.Called(ctx, rideID)
 	if args.Get(0) == nil {
@@ -103,6 +113,55 @@ func (m *MockPaymentsRepository) GetDriverEarningsSummary(ctx context.Context, d
 	return args.Get(0).(float64), args.Get(1).(float64), args.Get(2).(float64), args.Error(3)
 }
 
+func (m *MockPaymentsRepository) CreatePaymentHold(ctx context.Context, hold *models.PaymentHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockPaymentsRepository) GetPaymentHoldByRideID(ctx context.Context, rideID uuid.UUID) (*models.PaymentHold, error) {
+	args := m.Called(ctx, rideID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentHold), args.Error(1)
+}
+
+func (m *MockPaymentsRepository) ClaimPaymentHold(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentsRepository) UpdatePaymentHold(ctx context.Context, hold *models.PaymentHold) error {
+	args := m.Called(ctx, hold)
+	return args.Error(0)
+}
+
+func (m *MockPaymentsRepository) GetPaymentHoldByPaymentIntent(ctx context.Context, paymentIntentID string) (*models.PaymentHold, error) {
+	args := m.Called(ctx, paymentIntentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PaymentHold), args.Error(1)
+}
+
+func (m *MockPaymentsRepository) GetPaymentByStripePaymentID(ctx context.Context, paymentIntentID string) (*models.Payment, error) {
+	args := m.Called(ctx, paymentIntentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Payment), args.Error(1)
+}
+
+func (m *MockPaymentsRepository) ClaimWebhookEvent(ctx context.Context, event *models.StripeWebhookEvent) (bool, error) {
+	args := m.Called(ctx, event)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPaymentsRepository) FinishWebhookEvent(ctx context.Context, eventID string, handleErr error) error {
+	args := m.Called(ctx, eventID, handleErr)
+	return args.Error(0)
+}
+
 // MockStripeClient is a mock implementation of the Stripe client
 type MockStripeClient struct {
 	mock.Mock
@@ -140,6 +199,30 @@ func (m *MockStripeClient) CapturePaymentIntent(paymentIntentID string) (*stripe
 	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
 }
 
+func (m *MockStripeClient) CreateAuthorizationHold(amount int64, currency, customerID, paymentMethodID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	args := m.Called(amount, currency, customerID, paymentMethodID, description, metadata)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
+
+func (m *MockStripeClient) CapturePaymentIntentAmount(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	args := m.Called(paymentIntentID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
+
+func (m *MockStripeClient) IncrementAuthorization(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	args := m.Called(paymentIntentID, amount)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*stripe.PaymentIntent), args.Error(1)
+}
+
 func (m *MockStripeClient) CreateRefund(chargeID string, amount *int64, reason string) (*stripe.Refund, error) {
 	args := m.Called(chargeID, amount, reason)
 	if args.Get(0) == nil {
@@ -156,6 +239,14 @@ func (m *MockStripeClient) CreateTransfer(amount int64, currency, destination, d
 	return args.Get(0).(*stripe.Transfer), args.Error(1)
 }
 
+func (m *MockStripeClient) ListTransfers(createdFrom, createdTo int64) ([]*stripe.Transfer, error) {
+	args := m.Called(createdFrom, createdTo)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*stripe.Transfer), args.Error(1)
+}
+
 func (m *MockStripeClient) GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
 	args := m.Called(paymentIntentID)
 	if args.Get(0) == nil {
@@ -50,6 +50,44 @@ func (m *MockSafetyRepository) GetUserEmergencyAlerts(ctx context.Context, userI
 	return args.Get(0).([]*safety.EmergencyAlert), args.Error(1)
 }
 
+func (m *MockSafetyRepository) UpdateEmergencyAlertLocation(ctx context.Context, id uuid.UUID, latitude, longitude float64, at time.Time) error {
+	args := m.Called(ctx, id, latitude, longitude, at)
+	return args.Error(0)
+}
+
+// Emergency Escalation
+
+func (m *MockSafetyRepository) ClaimDueEmergencyEscalations(ctx context.Context, limit int, lease time.Duration) ([]*safety.EmergencyAlert, error) {
+	args := m.Called(ctx, limit, lease)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*safety.EmergencyAlert), args.Error(1)
+}
+
+func (m *MockSafetyRepository) ScheduleEmergencyEscalation(ctx context.Context, id uuid.UUID, level safety.EscalationLevel, nextAt *time.Time) error {
+	args := m.Called(ctx, id, level, nextAt)
+	return args.Error(0)
+}
+
+func (m *MockSafetyRepository) MarkEmergencyServicesDispatched(ctx context.Context, id uuid.UUID, referenceNumber string) error {
+	args := m.Called(ctx, id, referenceNumber)
+	return args.Error(0)
+}
+
+func (m *MockSafetyRepository) CreateEmergencyAlertEvent(ctx context.Context, event *safety.EmergencyAlertEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockSafetyRepository) GetEmergencyAlertEvents(ctx context.Context, alertID uuid.UUID) ([]*safety.EmergencyAlertEvent, error) {
+	args := m.Called(ctx, alertID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*safety.EmergencyAlertEvent), args.Error(1)
+}
+
 // Emergency Contacts
 
 func (m *MockSafetyRepository) CreateEmergencyContact(ctx context.Context, contact *safety.EmergencyContact) error {
@@ -202,6 +240,13 @@ func (m *MockSafetyRepository) GetRecentRouteDeviations(ctx context.Context, rid
 	return args.Get(0).([]*safety.RouteDeviationAlert), args.Error(1)
 }
 
+// Speed Alerts
+
+func (m *MockSafetyRepository) CreateSpeedAlert(ctx context.Context, alert *safety.SpeedAlert) error {
+	args := m.Called(ctx, alert)
+	return args.Error(0)
+}
+
 // Safety Incident Reports
 
 func (m *MockSafetyRepository) CreateSafetyIncidentReport(ctx context.Context, report *safety.SafetyIncidentReport) error {
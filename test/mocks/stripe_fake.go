@@ -0,0 +1,296 @@
+package mocks
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v83"
+)
+
+// FakeStripeClient is an in-memory stand-in for the Stripe API. Unlike
+// MockStripeClient it keeps state: payment intents move through the same
+// statuses as on Stripe, holds can be incremented, captured in part or
+// cancelled, and idempotency keys return the first result. Tests drive card
+// declines with DeclineHolds and DeclineIncrements.
+type FakeStripeClient struct {
+	// DeclineHolds makes new authorization holds fail with a card decline
+	DeclineHolds bool
+	// DeclineIncrements makes incremental authorizations fail, as for cards
+	// that don't support them
+	DeclineIncrements bool
+
+	mu          sync.Mutex
+	seq         int
+	intents     map[string]*stripe.PaymentIntent
+	transfers   []*stripe.Transfer
+	refunds     []*stripe.Refund
+	idempotency map[string]string // idempotency key -> object ID
+}
+
+// NewFakeStripeClient creates an empty fake Stripe account
+func NewFakeStripeClient() *FakeStripeClient {
+	return &FakeStripeClient{
+		intents:     make(map[string]*stripe.PaymentIntent),
+		idempotency: make(map[string]string),
+	}
+}
+
+func (f *FakeStripeClient) nextID(prefix string) string {
+	f.seq++
+	return fmt.Sprintf("%s_fake_%d", prefix, f.seq)
+}
+
+func (f *FakeStripeClient) intent(id string) (*stripe.PaymentIntent, error) {
+	pi, ok := f.intents[id]
+	if !ok {
+		return nil, invalidRequest(http.StatusNotFound, "resource_missing", fmt.Sprintf("No such payment_intent: '%s'", id))
+	}
+	return pi, nil
+}
+
+func (f *FakeStripeClient) newIntent(amount int64, currency, description string, metadata map[string]string) *stripe.PaymentIntent {
+	pi := &stripe.PaymentIntent{
+		ID:          f.nextID("pi"),
+		Amount:      amount,
+		Currency:    stripe.Currency(currency),
+		Description: description,
+		Metadata:    metadata,
+		Created:     time.Now().Unix(),
+	}
+	f.intents[pi.ID] = pi
+	return pi
+}
+
+func (f *FakeStripeClient) succeed(pi *stripe.PaymentIntent, amount int64) {
+	pi.Status = stripe.PaymentIntentStatusSucceeded
+	pi.AmountReceived = amount
+	pi.AmountCapturable = 0
+	pi.LatestCharge = &stripe.Charge{ID: f.nextID("ch"), Amount: amount, Captured: true}
+}
+
+func invalidRequest(status int, code, msg string) *stripe.Error {
+	return &stripe.Error{Type: stripe.ErrorTypeInvalidRequest, HTTPStatusCode: status, Code: stripe.ErrorCode(code), Msg: msg}
+}
+
+func cardDeclined() *stripe.Error {
+	return &stripe.Error{Type: stripe.ErrorTypeCard, HTTPStatusCode: http.StatusPaymentRequired, Code: stripe.ErrorCodeCardDeclined, Msg: "Your card was declined."}
+}
+
+// PaymentIntent returns a copy of a payment intent's current state
+func (f *FakeStripeClient) PaymentIntent(id string) *stripe.PaymentIntent {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, ok := f.intents[id]
+	if !ok {
+		return nil
+	}
+	cp := *pi
+	return &cp
+}
+
+// PaymentIntents returns how many payment intents have been created
+func (f *FakeStripeClient) PaymentIntents() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.intents)
+}
+
+func (f *FakeStripeClient) CreateCustomer(email, name string, metadata map[string]string) (*stripe.Customer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return &stripe.Customer{ID: f.nextID("cus"), Email: email, Name: name, Metadata: metadata}, nil
+}
+
+// CreatePaymentIntent charges immediately, as for a saved card
+func (f *FakeStripeClient) CreatePaymentIntent(amount int64, currency, customerID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi := f.newIntent(amount, currency, description, metadata)
+	f.succeed(pi, amount)
+	cp := *pi
+	return &cp, nil
+}
+
+func (f *FakeStripeClient) ConfirmPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, err := f.intent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status == "" || pi.Status == stripe.PaymentIntentStatusRequiresConfirmation {
+		f.succeed(pi, pi.Amount)
+	}
+	cp := *pi
+	return &cp, nil
+}
+
+func (f *FakeStripeClient) CapturePaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, err := f.intent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	return f.capture(pi, pi.AmountCapturable)
+}
+
+// CreateAuthorizationHold authorizes amount without capturing it, or fails
+// with a card decline when DeclineHolds is set
+func (f *FakeStripeClient) CreateAuthorizationHold(amount int64, currency, customerID, paymentMethodID, description string, metadata map[string]string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := metadata["idempotency_key"]
+	if id, ok := f.idempotency[key]; ok && key != "" {
+		cp := *f.intents[id]
+		return &cp, nil
+	}
+	if f.DeclineHolds {
+		return nil, cardDeclined()
+	}
+
+	pi := f.newIntent(amount, currency, description, metadata)
+	pi.Customer = &stripe.Customer{ID: customerID}
+	pi.PaymentMethod = &stripe.PaymentMethod{ID: paymentMethodID}
+	pi.CaptureMethod = stripe.PaymentIntentCaptureMethodManual
+	pi.Status = stripe.PaymentIntentStatusRequiresCapture
+	pi.AmountCapturable = amount
+	if key != "" {
+		f.idempotency[key] = pi.ID
+	}
+	cp := *pi
+	return &cp, nil
+}
+
+// CapturePaymentIntentAmount captures part of a hold and releases the rest
+func (f *FakeStripeClient) CapturePaymentIntentAmount(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, err := f.intent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	return f.capture(pi, amount)
+}
+
+func (f *FakeStripeClient) capture(pi *stripe.PaymentIntent, amount int64) (*stripe.PaymentIntent, error) {
+	if pi.Status != stripe.PaymentIntentStatusRequiresCapture {
+		return nil, invalidRequest(http.StatusBadRequest, "payment_intent_unexpected_state",
+			fmt.Sprintf("This PaymentIntent could not be captured because it has a status of %s.", pi.Status))
+	}
+	if amount <= 0 || amount > pi.AmountCapturable {
+		return nil, invalidRequest(http.StatusBadRequest, "amount_too_large",
+			fmt.Sprintf("The amount to capture must be at most the capturable amount (%d).", pi.AmountCapturable))
+	}
+	f.succeed(pi, amount)
+	cp := *pi
+	return &cp, nil
+}
+
+// IncrementAuthorization raises a hold to amount, or fails with a card
+// decline when DeclineIncrements is set
+func (f *FakeStripeClient) IncrementAuthorization(paymentIntentID string, amount int64) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, err := f.intent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status != stripe.PaymentIntentStatusRequiresCapture {
+		return nil, invalidRequest(http.StatusBadRequest, "payment_intent_unexpected_state",
+			fmt.Sprintf("This PaymentIntent's authorization could not be incremented because it has a status of %s.", pi.Status))
+	}
+	if amount <= pi.Amount {
+		return nil, invalidRequest(http.StatusBadRequest, "parameter_invalid_integer",
+			"The new amount must be greater than the currently authorized amount.")
+	}
+	if f.DeclineIncrements {
+		return nil, cardDeclined()
+	}
+	pi.Amount = amount
+	pi.AmountCapturable = amount
+	cp := *pi
+	return &cp, nil
+}
+
+func (f *FakeStripeClient) CreateRefund(chargeID string, amount *int64, reason string) (*stripe.Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := &stripe.Refund{ID: f.nextID("re"), Charge: &stripe.Charge{ID: chargeID}, Reason: stripe.RefundReason(reason), Status: stripe.RefundStatusSucceeded}
+	if amount != nil {
+		r.Amount = *amount
+	}
+	f.refunds = append(f.refunds, r)
+	return r, nil
+}
+
+func (f *FakeStripeClient) CreateTransfer(amount int64, currency, destination, description string, metadata map[string]string) (*stripe.Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := metadata["idempotency_key"]
+	if id, ok := f.idempotency[key]; ok && key != "" {
+		for _, t := range f.transfers {
+			if t.ID == id {
+				return t, nil
+			}
+		}
+	}
+
+	t := &stripe.Transfer{
+		ID:          f.nextID("tr"),
+		Amount:      amount,
+		Currency:    stripe.Currency(currency),
+		Destination: &stripe.Account{ID: destination},
+		Description: description,
+		Metadata:    metadata,
+		Created:     time.Now().Unix(),
+	}
+	f.transfers = append(f.transfers, t)
+	if key != "" {
+		f.idempotency[key] = t.ID
+	}
+	return t, nil
+}
+
+func (f *FakeStripeClient) ListTransfers(createdFrom, createdTo int64) ([]*stripe.Transfer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var transfers []*stripe.Transfer
+	for _, t := range f.transfers {
+		if t.Created >= createdFrom && t.Created < createdTo {
+			transfers = append(transfers, t)
+		}
+	}
+	return transfers, nil
+}
+
+func (f *FakeStripeClient) GetPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, err := f.intent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	cp := *pi
+	return &cp, nil
+}
+
+// CancelPaymentIntent cancels an uncaptured intent, releasing any hold
+func (f *FakeStripeClient) CancelPaymentIntent(paymentIntentID string) (*stripe.PaymentIntent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pi, err := f.intent(paymentIntentID)
+	if err != nil {
+		return nil, err
+	}
+	if pi.Status == stripe.PaymentIntentStatusSucceeded || pi.Status == stripe.PaymentIntentStatusCanceled {
+		return nil, invalidRequest(http.StatusBadRequest, "payment_intent_unexpected_state",
+			fmt.Sprintf("You cannot cancel this PaymentIntent because it has a status of %s.", pi.Status))
+	}
+	pi.Status = stripe.PaymentIntentStatusCanceled
+	pi.AmountCapturable = 0
+	cp := *pi
+	return &cp, nil
+}
@@ -90,6 +90,11 @@ func (m *MockSubscriptionsRepository) IncrementRideUsage(ctx context.Context, su
 	return args.Error(0)
 }
 
+func (m *MockSubscriptionsRepository) ReleaseRideUsage(ctx context.Context, rideID uuid.UUID) (bool, error) {
+	args := m.Called(ctx, rideID)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockSubscriptionsRepository) IncrementUpgradeUsage(ctx context.Context, subID uuid.UUID) error {
 	args := m.Called(ctx, subID)
 	return args.Error(0)